@@ -9,11 +9,17 @@ import (
 	"syscall"
 	"time"
 
+	"giddyup/api/internal/cache"
 	"giddyup/api/internal/config"
 	"giddyup/api/internal/database"
+	"giddyup/api/internal/export"
 	"giddyup/api/internal/logger"
+	"giddyup/api/internal/matching"
+	"giddyup/api/internal/ratelimit"
+	"giddyup/api/internal/repository"
 	"giddyup/api/internal/router"
 	"giddyup/api/internal/services"
+	"giddyup/api/internal/usage"
 )
 
 func main() {
@@ -55,6 +61,25 @@ func main() {
 	logger.Info("✅ Database connection established")
 	logger.Info("✅ Search path set to: racing, public")
 
+	// Matchers honour racing.match_overrides and write racing.match_audit
+	matching.SetStore(matching.NewDBStore(db.DB.DB))
+
+	// Course names from every source resolve through racing.course_alias
+	matching.UseCourseRegistry(db.DB.DB)
+
+	// Response cache; loaders and live prices in this process invalidate it
+	responses, err := cache.Open(cfg.Cache)
+	if err != nil {
+		logger.Error("Failed to open response cache: %v", err)
+		os.Exit(1)
+	}
+	cache.SetDefault(responses)
+	if responses.Enabled() {
+		logger.Info("🗄️  Response cache: %s store (live %v, settled %v, stats %v)", cfg.Cache.Store, cfg.Cache.LiveTTL, cfg.Cache.SettledTTL, cfg.Cache.StatsTTL)
+	} else {
+		logger.Info("Response cache disabled (CACHE_ENABLED=false): ETags only")
+	}
+
 	// Initialize auto-update service
 	autoUpdateEnabled := os.Getenv("AUTO_UPDATE_ON_STARTUP") == "true"
 	dataDir := os.Getenv("DATA_DIR")
@@ -74,9 +99,45 @@ func main() {
 		logger.Info("Auto-update service disabled (set AUTO_UPDATE_ON_STARTUP=true to enable)")
 	}
 
+	// Intraday racecard/result refresher (keeps today's cards and results current)
+	if os.Getenv("ENABLE_RACECARD_SCHEDULER") == "true" {
+		logger.Info("🕐 Racecard scheduler enabled")
+		services.NewRacecardSchedulerFromEnv(db.DB, dataDir).RunInBackground()
+	} else {
+		logger.Info("Racecard scheduler disabled (set ENABLE_RACECARD_SCHEDULER=true to enable)")
+	}
+
 	// Setup router
 	logger.Info("Initializing router and handlers...")
-	r := router.Setup(db, cfg.CORS.Origins)
+	if cfg.Auth.Required {
+		logger.Info("🔒 Authentication required (API key or JWT)")
+	} else {
+		logger.Warn("Authentication not required: anonymous requests get the %s role (set AUTH_REQUIRED=true before exposing the API)", cfg.Auth.AnonymousRole)
+	}
+	if cfg.RateLimit.Enabled {
+		logger.Info("🚦 Rate limits (%s store): %s", cfg.RateLimit.Store, ratelimit.Describe(cfg.RateLimit.Quotas))
+	} else {
+		logger.Warn("Rate limiting disabled (RATE_LIMIT_ENABLED=false)")
+	}
+
+	// Per-client usage, flushed to racing.api_usage
+	meter := usage.NewMeter(db.DB.DB, 0)
+	meter.Start()
+
+	// Export jobs run in the background, writing files to EXPORT_DIR
+	var exports *export.Runner
+	if cfg.Export.Workers > 0 {
+		exports = export.NewRunner(repository.NewExportRepository(db), cfg.Export.Dir, cfg.Export.Workers, cfg.Export.TTL)
+		if err := exports.Start(); err != nil {
+			logger.Error("Failed to start export workers: %v", err)
+			os.Exit(1)
+		}
+		logger.Info("📦 Export workers: %d (files in %s, kept %v)", cfg.Export.Workers, cfg.Export.Dir, cfg.Export.TTL)
+	} else {
+		logger.Info("Export workers disabled on this instance (EXPORT_WORKERS=0)")
+	}
+
+	r := router.Setup(db, cfg, meter, exports, responses)
 
 	// Create HTTP server
 	srv := &http.Server{
@@ -115,6 +176,12 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		logger.Error("Server forced to shutdown: %v", err)
 	}
+	if exports != nil {
+		exports.Close()
+	}
+	if err := meter.Close(); err != nil {
+		logger.Error("Failed to flush usage: %v", err)
+	}
 
 	logger.Info("✅ Server exited cleanly")
 }
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"giddyup/api/internal/auth"
+
+	"github.com/lib/pq"
+)
+
+// create_api_key creates an API key directly in racing.api_keys - use it for
+// the first admin key, after which keys can be managed at /api/v1/admin/keys
+func main() {
+	name := flag.String("name", "", "Key name (who or what uses it)")
+	role := flag.String("role", auth.RoleAdmin, "Role: reader, analyst, trader or admin")
+	scopes := flag.String("scopes", "", "Comma-separated scopes (default: all of the role's)")
+	expiresDays := flag.Int("expires-days", 0, "Expire the key after N days (0 = never)")
+	flag.Parse()
+
+	if *name == "" {
+		log.Fatal("Usage: create_api_key -name <name> [-role admin] [-scopes read,analysis] [-expires-days 90]")
+	}
+
+	var requested []string
+	if *scopes != "" {
+		requested = strings.Split(*scopes, ",")
+	}
+	granted, err := auth.ScopesFor(*role, requested)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	connStr := fmt.Sprintf("host=%s port=%s dbname=%s user=%s password=%s sslmode=disable",
+		getEnv("DB_HOST", "localhost"),
+		getEnv("DB_PORT", "5432"),
+		getEnv("DB_NAME", "horse_db"),
+		getEnv("DB_USER", "postgres"),
+		getEnv("DB_PASSWORD", "password"))
+
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		log.Fatalf("❌ Failed to connect: %v", err)
+	}
+	defer db.Close()
+
+	key, err := auth.GenerateKey()
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	var expires interface{}
+	if *expiresDays > 0 {
+		expires = *expiresDays
+	}
+
+	var keyID int64
+	err = db.QueryRow(`
+		INSERT INTO racing.api_keys (name, key_prefix, key_hash, role, scopes, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, 'create_api_key', now() + make_interval(days => $6::int))
+		RETURNING key_id
+	`, *name, auth.DisplayPrefix(key), auth.HashKey(key), *role, pq.Array(granted), expires).Scan(&keyID)
+	if err != nil {
+		log.Fatalf("❌ Failed to create key: %v", err)
+	}
+
+	log.Printf("✅ Created key %d (%s, role %s, scopes %s)", keyID, *name, *role, strings.Join(granted, ","))
+	log.Printf("   Store it now - it is not shown again")
+	fmt.Println(key)
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"giddyup/api/internal/database"
+	"giddyup/api/internal/export"
+	"giddyup/api/internal/models"
+	"giddyup/api/internal/repository"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+)
+
+var (
+	dbConn   = flag.String("db", "host=localhost port=5432 dbname=horse_db user=postgres password=password sslmode=disable", "Database connection string")
+	dataset  = flag.String("dataset", "runners", "Dataset: races or runners")
+	format   = flag.String("format", "csv", "Format: csv, ndjson or parquet")
+	columns  = flag.String("columns", "", "Comma-separated columns (default: the dataset's default columns; -list shows them)")
+	outPath  = flag.String("out", "", "Output file (default: stdout)")
+	dateFrom = flag.String("date-from", "", "First race date YYYY-MM-DD")
+	dateTo   = flag.String("date-to", "", "Last race date YYYY-MM-DD")
+	region   = flag.String("region", "", "Region, e.g. GB or IRE")
+	courseID = flag.Int64("course-id", 0, "Course ID")
+	raceType = flag.String("type", "", "Race type, e.g. Flat or Hurdle")
+	filters  = flag.String("filters", "", `Any other race search filters as JSON, e.g. '{"class":"1","field_min":8}'`)
+	limit    = flag.Int("limit", 0, "Stop after N rows (default: 0 = all)")
+	list     = flag.Bool("list", false, "List each dataset's columns and exit")
+)
+
+func main() {
+	flag.Parse()
+
+	if *list {
+		for _, ds := range repository.ExportDatasets() {
+			log.Printf("%s:", ds.Dataset)
+			for _, col := range ds.Columns {
+				mark := ""
+				if col.Default {
+					mark = " (default)"
+				}
+				log.Printf("  %-14s %s%s", col.Name, col.Type, mark)
+			}
+		}
+		return
+	}
+
+	var f models.RaceFilterSet
+	if *filters != "" {
+		if err := json.Unmarshal([]byte(*filters), &f); err != nil {
+			log.Fatalf("Invalid -filters: %v", err)
+		}
+	}
+	for _, d := range []*string{dateFrom, dateTo} {
+		if *d != "" {
+			if _, err := time.Parse("2006-01-02", *d); err != nil {
+				log.Fatalf("Invalid date %q: %v", *d, err)
+			}
+		}
+	}
+	setString(&f.DateFrom, *dateFrom)
+	setString(&f.DateTo, *dateTo)
+	setString(&f.Region, *region)
+	setString(&f.Type, *raceType)
+	if *courseID != 0 {
+		f.CourseID = courseID
+	}
+
+	var names []string
+	if *columns != "" {
+		names = strings.Split(*columns, ",")
+	}
+	cols, err := repository.ExportColumns(*dataset, names)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	conn, err := sqlx.Connect("postgres", *dbConn+" search_path=racing,public")
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer conn.Close()
+	repo := repository.NewExportRepository(&database.DB{DB: conn})
+
+	var out io.Writer = os.Stdout
+	var file *os.File
+	if *outPath != "" {
+		file, err = os.Create(*outPath)
+		if err != nil {
+			log.Fatalf("Failed to create %s: %v", *outPath, err)
+		}
+		out = file
+	}
+	buf := bufio.NewWriterSize(out, 1<<20)
+
+	w, err := export.NewWriter(*format, buf, cols)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	start := time.Now()
+	rows, err := repo.Stream(ctx, *dataset, cols, f, *limit, w.Write)
+	if err == nil {
+		err = w.Close()
+	}
+	if err == nil {
+		err = buf.Flush()
+	}
+	if file != nil {
+		if cerr := file.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			// Don't leave a truncated file behind
+			os.Remove(*outPath)
+		}
+	}
+	if err != nil {
+		log.Fatalf("Export failed after %d rows: %v", rows, err)
+	}
+	log.Printf("✅ Exported %d %s as %s in %v", rows, *dataset, *format, time.Since(start).Round(time.Millisecond))
+}
+
+func setString(p **string, v string) {
+	if v != "" {
+		*p = &v
+	}
+}
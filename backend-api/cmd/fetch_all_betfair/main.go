@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"giddyup/api/internal/betfair"
+	"giddyup/api/internal/matching"
 	"giddyup/api/internal/scraper"
 
 	_ "github.com/lib/pq"
@@ -72,6 +73,12 @@ func main() {
 	log.Println("✅ Database connected")
 	log.Println("")
 
+	// Matchers honour racing.match_overrides and write racing.match_audit
+	matching.SetStore(matching.NewDBStore(db))
+
+	// Course names from every source resolve through racing.course_alias
+	matching.UseCourseRegistry(db)
+
 	// Get Betfair credentials
 	appKey := os.Getenv("BETFAIR_APP_KEY")
 	sessionToken := os.Getenv("BETFAIR_SESSION_TOKEN")
@@ -250,7 +257,7 @@ func loadRacesFromDB(db *sql.DB, dateStr string) ([]scraper.Race, map[string]int
 				Ran:      ran,
 			}
 			racesMap[raceID] = race
-			raceIDMap[generateRaceKey(*race)] = raceID
+			raceIDMap[race.Key()] = raceID
 		}
 
 		// Add runner if present
@@ -483,18 +490,6 @@ func mirrorLatestPrices(db *sql.DB, ts time.Time) error {
 	return err
 }
 
-// Helper functions
-// Must match the format used in internal/betfair/matcher.go generateRaceKeyHelper()
-func generateRaceKey(race scraper.Race) string {
-	normCourse := strings.ToLower(strings.TrimSpace(race.Course))
-	normTime := race.OffTime
-	normName := strings.ToLower(strings.TrimSpace(race.RaceName))
-	normType := strings.ToLower(strings.TrimSpace(race.Type))
-	normRegion := strings.ToUpper(strings.TrimSpace(race.Region))
-
-	return fmt.Sprintf("%s|%s|%s|%s|%s|%s", race.Date, normRegion, normCourse, normTime, normName, normType)
-}
-
 func nullFloat(f float64) interface{} {
 	if f == 0 {
 		return nil
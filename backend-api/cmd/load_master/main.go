@@ -15,6 +15,11 @@ import (
 	"strings"
 	"time"
 
+	"giddyup/api/internal/cache"
+	"giddyup/api/internal/loader"
+	"giddyup/api/internal/matching"
+	"giddyup/api/internal/pipeline"
+
 	"github.com/lib/pq"
 )
 
@@ -28,9 +33,11 @@ Assumptions:
   jockeys(jockey_norm)        via `jockeys_uniq`
   owners(owner_norm)          via `owners_uniq`
   bloodlines(sire_norm,dam_norm,damsire_norm) via `bloodlines_uniq`
+- Course registry functions racing.resolve_course / racing.queue_course_review (migration 019).
 - races unique on (race_key, race_date)
 - runners unique on (runner_key, race_date)
 - Optional DB function: create_partitions_for_year(int)
+- racing.etl_runs / racing.ingested_days (migration 001).
 */
 
 var (
@@ -42,6 +49,7 @@ var (
 	limitN     = flag.Int("limit", 0, "Limit months processed")
 	makeParts  = flag.Bool("create-partitions", true, "Call create_partitions_for_year")
 	fixRan     = flag.Bool("fix-ran", false, "Update races.ran to computed starters when mismatched")
+	newCourses = flag.Bool("create-courses", false, "Create courses for unresolved names instead of queueing them for review (bootstrap an empty registry)")
 	verbose    = flag.Bool("v", true, "Verbose logging")
 	timeoutMin = flag.Int("timeout", 60, "Overall timeout minutes")
 )
@@ -86,6 +94,27 @@ func main() {
 		log.Fatalf("set search_path: %v", err)
 	}
 
+	// Loads invalidate the API's response cache when it's shared
+	if err := cache.UseSharedStore(); err != nil {
+		log.Printf("warn: response cache not reachable, API entries will age out: %v", err)
+	}
+
+	// One master load at a time; each month also takes its dates' locks (see loadMonth)
+	lock, err := pipeline.TryLock(ctx, db, "ingest:master")
+	if errors.Is(err, pipeline.ErrLocked) {
+		log.Println("Another load_master run is in progress - exiting.")
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer lock.Release()
+
+	run, err := pipeline.StartETLRun(ctx, db, masterScope(pairs))
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	if *makeParts && len(years) > 0 {
 		log.Printf("Creating partitions for %d year(s): %v", len(years), years)
 		for _, y := range years {
@@ -97,13 +126,17 @@ func main() {
 	}
 
 	totalRaces, totalRunners := 0, 0
+	failed := 0
+	var lastErr error
 	startAll := time.Now()
 
 	for i, p := range pairs {
 		log.Printf("[%d/%d] Loading %s/%s %s", i+1, len(pairs), strings.ToUpper(p.Region), p.RaceType, p.YearMonth)
-		rUp, ruUp, err := loadMonth(ctx, db, p)
+		rUp, ruUp, err := loadMonth(ctx, db, p, run.ID)
 		if err != nil {
 			log.Printf("  ❌ ERROR loading %s/%s %s: %v", p.Region, p.RaceType, p.YearMonth, err)
+			failed++
+			lastErr = err
 			continue
 		}
 		log.Printf("  ✓ Upserted: %d races, %d runners", rUp, ruUp)
@@ -111,9 +144,44 @@ func main() {
 		totalRunners += ruUp
 	}
 
+	// The run fails only if nothing loaded; per-month errors are in the stats
+	var runErr error
+	if failed == len(pairs) {
+		runErr = lastErr
+	}
+	run.Finish(map[string]int{
+		"months":           len(pairs),
+		"months_failed":    failed,
+		"races_inserted":   totalRaces,
+		"runners_inserted": totalRunners,
+		"errors":           failed,
+	}, runErr)
+
 	log.Printf("=== DONE in %s | races=%d runners=%d ===", time.Since(startAll).Round(time.Second), totalRaces, totalRunners)
 }
 
+// masterScope describes a master load for racing.etl_runs (months, not days)
+func masterScope(pairs []pair) pipeline.Scope {
+	scope := pipeline.Scope{Kind: "master", Source: "master"}
+	seen := make(map[string]bool)
+	for _, p := range pairs {
+		region, code := "r:"+strings.ToLower(p.Region), "c:"+pipeline.RaceCode(p.RaceType)
+		if !seen[region] {
+			seen[region] = true
+			scope.Regions = append(scope.Regions, strings.ToLower(p.Region))
+		}
+		if !seen[code] {
+			seen[code] = true
+			scope.Codes = append(scope.Codes, pipeline.RaceCode(p.RaceType))
+		}
+		if !seen[p.YearMonth] {
+			seen[p.YearMonth] = true
+			scope.Dates = append(scope.Dates, p.YearMonth)
+		}
+	}
+	return scope
+}
+
 /* -------------------- discovery -------------------- */
 
 func discoverPairs(root, region, rtype, month string) ([]pair, error) {
@@ -193,7 +261,7 @@ func strconvAtoiSafe(s string) (int, error) {
 
 /* -------------------- month loader -------------------- */
 
-func loadMonth(ctx context.Context, db *sql.DB, p pair) (racesUp int, runnersUp int, err error) {
+func loadMonth(ctx context.Context, db *sql.DB, p pair, runID int64) (racesUp int, runnersUp int, err error) {
 	tx, err := db.BeginTx(ctx, &sql.TxOptions{})
 	if err != nil {
 		return 0, 0, err
@@ -224,53 +292,45 @@ func loadMonth(ctx context.Context, db *sql.DB, p pair) (racesUp int, runnersUp
 		"race_key", "num", "pos", "horse", "jockey", "trainer", "runner_key",
 	)
 
-	// 3) Upsert dimensions (courses, horses, trainers, jockeys)
-	if *verbose {
-		log.Printf("  → upserting dimensions")
-	}
-	if _, err := tx.ExecContext(ctx, fmt.Sprintf(sqlUpsertCourses, rTemp)); err != nil {
-		return 0, 0, fmt.Errorf("upsert courses: %w", err)
-	}
-	if _, err := tx.ExecContext(ctx, fmt.Sprintf(sqlUpsertHorses, ruTemp)); err != nil {
-		return 0, 0, fmt.Errorf("upsert horses: %w", err)
-	}
-	if _, err := tx.ExecContext(ctx, fmt.Sprintf(sqlUpsertTrainers, ruTemp)); err != nil {
-		return 0, 0, fmt.Errorf("upsert trainers: %w", err)
-	}
-	if _, err := tx.ExecContext(ctx, fmt.Sprintf(sqlUpsertJockeys, ruTemp)); err != nil {
-		return 0, 0, fmt.Errorf("upsert jockeys: %w", err)
-	}
-
-	// 4) Upsert races
+	// 3) Merge: dimensions, races, runners, ingested days (waits for other
+	//    loaders writing these dates; their locks are held until commit)
 	if *verbose {
-		log.Printf("  → upserting races")
+		log.Printf("  → merging")
 	}
-	if err := tx.QueryRowContext(ctx, fmt.Sprintf(sqlUpsertRaces, rTemp)).Scan(&racesUp); err != nil {
-		return 0, 0, fmt.Errorf("upsert races: %w", err)
+	stats, err := loader.Merge(ctx, tx, rTemp, ruTemp, loader.MergeOptions{
+		Source:        matching.CourseSourceMaster,
+		CreateCourses: *newCourses,
+		RunID:         runID,
+		Loader:        "master",
+	})
+	if err != nil {
+		return 0, 0, err
 	}
-
-	// 5) Upsert runners (join to races via race_key + date from races temp)
-	if *verbose {
-		log.Printf("  → upserting runners")
+	if stats.CoursesQueued > 0 {
+		log.Printf("  ⚠️  %d unresolved course name(s) queued in racing.course_review_queue", stats.CoursesQueued)
 	}
-	if err := tx.QueryRowContext(ctx, fmt.Sprintf(sqlUpsertRunners, ruTemp, rTemp, ruTemp)).Scan(&runnersUp); err != nil {
-		return 0, 0, fmt.Errorf("upsert runners: %w", err)
+	if stats.RacesHeld+stats.RunnersHeld+stats.Warnings > 0 {
+		log.Printf("  ⚠️  %d race(s) and %d runner(s) quarantined, %d data-quality warning(s) - see racing.quarantine",
+			stats.RacesHeld, stats.RunnersHeld, stats.Warnings)
 	}
+	racesUp, runnersUp = stats.Races, stats.Runners
 
-	// 6) Validation
-	if *verbose {
-		log.Printf("  → validation")
-	}
-	if err := runValidation(ctx, tx, *fixRan); err != nil {
-		log.Printf("  warn: validation: %v", err)
+	// 4) Data-quality rules ran in the merge; optionally correct ran
+	if *fixRan {
+		if err := fixRanValues(ctx, tx, rTemp); err != nil {
+			log.Printf("  warn: fix ran: %v", err)
+		}
 	}
 
-	// 7) Analyze hot tables (cheap)
+	// 5) Analyze hot tables (cheap)
 	_, _ = tx.ExecContext(ctx, `ANALYZE races; ANALYZE runners;`)
 
 	if err := tx.Commit(); err != nil {
 		return 0, 0, err
 	}
+
+	// 6) Drop cached responses built from these races and dates
+	cache.InvalidateRaces(ctx, stats.RaceIDs, stats.Dates, true)
 	return racesUp, runnersUp, nil
 }
 
@@ -372,38 +432,6 @@ func requireCols(hdr []string, needed ...string) {
 
 /* -------------------- SQL templates (fmt.Sprintf with temp names) -------------------- */
 
-const sqlUpsertCourses = `
-INSERT INTO racing.courses (course_name, region)
-SELECT DISTINCT t.course, t.region
-FROM %s t
-WHERE t.course IS NOT NULL AND t.course <> '' AND t.region IS NOT NULL
-ON CONFLICT ON CONSTRAINT courses_uniq DO NOTHING;
-`
-
-const sqlUpsertHorses = `
-INSERT INTO racing.horses (horse_name)
-SELECT DISTINCT t.horse
-FROM %s t
-WHERE t.horse IS NOT NULL AND t.horse <> ''
-ON CONFLICT ON CONSTRAINT horses_uniq DO NOTHING;
-`
-
-const sqlUpsertTrainers = `
-INSERT INTO racing.trainers (trainer_name)
-SELECT DISTINCT t.trainer
-FROM %s t
-WHERE t.trainer IS NOT NULL AND t.trainer <> ''
-ON CONFLICT ON CONSTRAINT trainers_uniq DO NOTHING;
-`
-
-const sqlUpsertJockeys = `
-INSERT INTO racing.jockeys (jockey_name)
-SELECT DISTINCT t.jockey
-FROM %s t
-WHERE t.jockey IS NOT NULL AND t.jockey <> ''
-ON CONFLICT ON CONSTRAINT jockeys_uniq DO NOTHING;
-`
-
 const sqlUpsertOwners = `
 INSERT INTO racing.owners (owner_name)
 SELECT DISTINCT t.owner
@@ -422,253 +450,42 @@ WHERE (t.sire IS NOT NULL AND t.sire <> '')
 ON CONFLICT ON CONSTRAINT bloodlines_uniq DO NOTHING;
 `
 
-const sqlUpsertRaces = `
-WITH c AS (
-  SELECT course_id, course_name, region FROM racing.courses
-),
-ins AS (
-  INSERT INTO racing.races (
-    race_key, race_date, region, course_id, off_time, race_name, race_type,
-    class, pattern, rating_band, age_band, sex_rest,
-    dist_raw, dist_f, dist_m, going, surface, ran
-  )
-  SELECT DISTINCT
-    t.race_key,
-    t.date::date,
-    t.region,
-    c.course_id,
-    CASE WHEN t.off ~ '^[0-9]{1,2}:[0-9]{2}$' THEN t.off::time ELSE NULL END,
-    t.race_name,
-    t.type,
-    NULLIF(t.class,''),
-    NULLIF(t.pattern,''),
-    NULLIF(t.rating_band,''),
-    NULLIF(t.age_band,''),
-    NULLIF(t.sex_rest,''),
-    NULLIF(t.dist,''),
-    NULLIF(REPLACE(t.dist_f,'f',''),'')::double precision,
-    NULLIF(t.dist_m,'')::int,
-    NULLIF(t.going,''),
-    NULLIF(t.surface,''),
-    NULLIF(t.ran,'')::int
-  FROM %s t
-  JOIN c ON racing.norm_text(c.course_name) = racing.norm_text(t.course)
-       AND c.region = t.region
-  ON CONFLICT (race_key, race_date) DO UPDATE SET
-    region    = EXCLUDED.region,
-    course_id = EXCLUDED.course_id,
-    off_time  = EXCLUDED.off_time,
-    race_name = EXCLUDED.race_name,
-    race_type = EXCLUDED.race_type,
-    class     = EXCLUDED.class,
-    pattern   = EXCLUDED.pattern,
-    rating_band = EXCLUDED.rating_band,
-    age_band  = EXCLUDED.age_band,
-    sex_rest  = EXCLUDED.sex_rest,
-    dist_raw  = EXCLUDED.dist_raw,
-    dist_f    = COALESCE(EXCLUDED.dist_f, races.dist_f),
-    dist_m    = COALESCE(EXCLUDED.dist_m, races.dist_m),
-    going     = COALESCE(EXCLUDED.going, races.going),
-    surface   = EXCLUDED.surface,
-    ran       = COALESCE(EXCLUDED.ran, races.ran)
-  RETURNING 1
-)
-SELECT count(*) FROM ins;
-`
+/* -------------------- ran fix -------------------- */
 
-const sqlUpsertRunners = `
-WITH rmap AS (
-  -- Map race_key to race_id + race_date using THIS month's races CSV
-  SELECT DISTINCT rc.race_key, ra.race_id, ra.race_date
-  FROM %s rr
-  JOIN %s rc USING (race_key)
-  JOIN racing.races ra
-    ON ra.race_key = rc.race_key
-   AND ra.race_date = rc.date::date
+// fixRanValues sets races.ran to the computed starters for the month's races
+// where they differ (the ran_matches_runners rule records the mismatches)
+func fixRanValues(ctx context.Context, tx *sql.Tx, racesTable string) error {
+	const upd = `
+WITH staged AS (
+  SELECT DISTINCT ra.race_id
+  FROM %s t
+  JOIN racing.races ra ON ra.race_key = t.race_key AND ra.race_date = t.date::date
 ),
-h AS (SELECT horse_id, horse_name, horse_norm FROM racing.horses),
-j AS (SELECT jockey_id, jockey_name, jockey_norm FROM racing.jockeys),
-t AS (SELECT trainer_id, trainer_name, trainer_norm FROM racing.trainers),
-ins AS (
-  INSERT INTO racing.runners (
-    runner_key, race_id, race_date,
-    num, pos_raw, draw,
-    horse_id, age, jockey_id, trainer_id, lbs,
-    "or", rpr, comment,
-    win_bsp, win_ppwap, place_bsp, place_ppwap
-  )
-  SELECT DISTINCT
-    t0.runner_key,
-    rm.race_id,
-    rm.race_date,
-    NULLIF(regexp_replace(t0.num, '[^0-9]', '', 'g'),'')::int,
-    NULLIF(t0.pos,''),
-    NULLIF(regexp_replace(t0.draw, '[^0-9]', '', 'g'),'')::int,
-    h.horse_id,
-    NULLIF(regexp_replace(t0.age, '[^0-9]', '', 'g'),'')::int,
-    j.jockey_id,
-    t.trainer_id,
-    NULLIF(regexp_replace(t0.lbs, '[^0-9]', '', 'g'),'')::int,
-    NULLIF(regexp_replace(t0."or", '[^0-9]', '', 'g'),'')::int,
-    NULLIF(regexp_replace(t0.rpr, '[^0-9]', '', 'g'),'')::int,
-    NULLIF(t0.comment,''),
-    CASE WHEN t0.win_bsp ~ '^[0-9]+\.?[0-9]*$' AND t0.win_bsp::double precision > 1.0 THEN t0.win_bsp::double precision ELSE NULL END,
-    CASE WHEN t0.win_ppwap ~ '^[0-9]+\.?[0-9]*$' AND t0.win_ppwap::double precision > 1.0 THEN t0.win_ppwap::double precision ELSE NULL END,
-    CASE WHEN t0.place_bsp ~ '^[0-9]+\.?[0-9]*$' AND t0.place_bsp::double precision > 1.0 THEN t0.place_bsp::double precision ELSE NULL END,
-    CASE WHEN t0.place_ppwap ~ '^[0-9]+\.?[0-9]*$' AND t0.place_ppwap::double precision > 1.0 THEN t0.place_ppwap::double precision ELSE NULL END
-  FROM %s t0
-  JOIN rmap rm ON rm.race_key = t0.race_key
-  LEFT JOIN h ON racing.norm_text(h.horse_name) = racing.norm_text(t0.horse)
-  LEFT JOIN j ON racing.norm_text(j.jockey_name) = racing.norm_text(t0.jockey)
-  LEFT JOIN t ON racing.norm_text(t.trainer_name) = racing.norm_text(t0.trainer)
-  ON CONFLICT (runner_key, race_date) DO UPDATE SET
-    num         = EXCLUDED.num,
-    pos_raw     = EXCLUDED.pos_raw,
-    draw        = EXCLUDED.draw,
-    horse_id    = COALESCE(EXCLUDED.horse_id, runners.horse_id),
-    age         = EXCLUDED.age,
-    jockey_id   = COALESCE(EXCLUDED.jockey_id, runners.jockey_id),
-    trainer_id  = COALESCE(EXCLUDED.trainer_id, runners.trainer_id),
-    lbs         = EXCLUDED.lbs,
-    "or"        = COALESCE(EXCLUDED."or", runners."or"),
-    rpr         = COALESCE(EXCLUDED.rpr, runners.rpr),
-    comment     = COALESCE(EXCLUDED.comment, runners.comment),
-    win_bsp     = COALESCE(EXCLUDED.win_bsp, runners.win_bsp),
-    win_ppwap   = COALESCE(EXCLUDED.win_ppwap, runners.win_ppwap),
-    place_bsp   = COALESCE(EXCLUDED.place_bsp, runners.place_bsp),
-    place_ppwap = COALESCE(EXCLUDED.place_ppwap, runners.place_ppwap)
-  RETURNING 1
-)
-SELECT count(*) FROM ins;
-`
-
-/* -------------------- validation -------------------- */
-
-func runValidation(ctx context.Context, tx *sql.Tx, fixRan bool) error {
-	// 1) Race count sanity
-	var rc, rru int
-	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM racing.races`).Scan(&rc); err != nil {
-		return err
-	}
-	if err := tx.QueryRowContext(ctx, `SELECT COUNT(DISTINCT race_id) FROM racing.runners`).Scan(&rru); err != nil {
-		return err
-	}
-	if rc != rru {
-		log.Printf("  warn: race count mismatch: races=%d distinct(race_id in runners)=%d", rc, rru)
-	}
-
-	// 2) Compute starters per race_id (exclude obvious non-runners)
-	//    NOTE: keep the list tight to avoid false positives; adjust as needed.
-	const startersCTE = `
-WITH starters AS (
+starters AS (
   SELECT
-    race_id,
+    ru.race_id,
     COUNT(*) FILTER (
       WHERE pos_raw IS DISTINCT FROM '' AND pos_raw IS NOT NULL
         AND NOT (pos_raw ILIKE 'NR' OR pos_raw ILIKE 'N/R' OR pos_raw ILIKE 'WD' OR pos_raw ILIKE 'W/D'
                  OR pos_raw ILIKE 'RES' OR pos_raw ILIKE 'RESERVE')
     ) AS n_starters
-  FROM racing.runners
-  GROUP BY race_id
-)
-SELECT ra.race_id, ra.race_key, ra.race_date, ra.ran, s.n_starters
-FROM racing.races ra
-LEFT JOIN starters s USING (race_id)
-WHERE COALESCE(ra.ran, -1) <> COALESCE(s.n_starters, -1);
-`
-
-	rows, err := tx.QueryContext(ctx, startersCTE)
-	if err != nil {
-		return fmt.Errorf("compute starters: %w", err)
-	}
-	defer rows.Close()
-
-	type mis struct {
-		raceID    int64
-		raceKey   string
-		raceDate  time.Time
-		ran       sql.NullInt64
-		nStarters sql.NullInt64
-	}
-	var mismatches []mis
-	for rows.Next() {
-		var m mis
-		if err := rows.Scan(&m.raceID, &m.raceKey, &m.raceDate, &m.ran, &m.nStarters); err != nil {
-			return err
-		}
-		mismatches = append(mismatches, m)
-	}
-	if err := rows.Err(); err != nil {
-		return err
-	}
-
-	if len(mismatches) > 0 {
-		log.Printf("  warn: %d races have RAN != starters", len(mismatches))
-		for i := 0; i < len(mismatches) && i < 5; i++ {
-			m := mismatches[i]
-			log.Printf("    sample race_id=%d date=%s ran=%v starters=%v",
-				m.raceID, m.raceDate.Format("2006-01-02"),
-				nullI64(m.ran), nullI64(m.nStarters))
-		}
-
-		if fixRan {
-			// Update ran to computed starters (only where > 0)
-			const upd = `
-WITH starters AS (
-  SELECT
-    race_id,
-    COUNT(*) FILTER (
-      WHERE pos_raw IS DISTINCT FROM '' AND pos_raw IS NOT NULL
-        AND NOT (pos_raw ILIKE 'NR' OR pos_raw ILIKE 'N/R' OR pos_raw ILIKE 'WD' OR pos_raw ILIKE 'W/D'
-                 OR pos_raw ILIKE 'RES' OR pos_raw ILIKE 'RESERVE')
-    ) AS n_starters
-  FROM racing.runners
-  GROUP BY race_id
+  FROM racing.runners ru
+  JOIN staged USING (race_id)
+  GROUP BY ru.race_id
 )
 UPDATE racing.races ra
 SET ran = s.n_starters
 FROM starters s
 WHERE ra.race_id = s.race_id
-  AND s.n_starters IS NOT NULL
   AND s.n_starters > 0
   AND ra.ran IS DISTINCT FROM s.n_starters;
 `
-			res, err := tx.ExecContext(ctx, upd)
-			if err != nil {
-				log.Printf("  warn: failed to fix ran values: %v", err)
-			} else {
-				if n, _ := res.RowsAffected(); n > 0 {
-					log.Printf("  ✓ fixed %d races.ran to computed starters", n)
-				}
-			}
-		}
-	}
-
-	// 3) Sentinel prices still present?
-	var sent int
-	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM racing.runners WHERE win_bsp = 1`).Scan(&sent); err != nil {
+	res, err := tx.ExecContext(ctx, fmt.Sprintf(upd, racesTable))
+	if err != nil {
 		return err
 	}
-	if sent > 0 {
-		log.Printf("  warn: %d rows still have sentinel price 1.0 (should be NULL)", sent)
-	}
-
-	// 4) Duplicate keys (should be 0)
-	var dupR, dupRu int
-	_ = tx.QueryRowContext(ctx, `SELECT COUNT(*) - COUNT(DISTINCT race_key) FROM racing.races`).Scan(&dupR)
-	_ = tx.QueryRowContext(ctx, `SELECT COUNT(*) - COUNT(DISTINCT runner_key) FROM racing.runners`).Scan(&dupRu)
-	if dupR > 0 {
-		log.Printf("  error: %d duplicate race_key in races", dupR)
-	}
-	if dupRu > 0 {
-		log.Printf("  error: %d duplicate runner_key in runners", dupRu)
-	}
-	return nil
-}
-
-func nullI64(v sql.NullInt64) any {
-	if v.Valid {
-		return v.Int64
+	if n, _ := res.RowsAffected(); n > 0 {
+		log.Printf("  ✓ fixed %d races.ran to computed starters", n)
 	}
 	return nil
 }
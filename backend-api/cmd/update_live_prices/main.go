@@ -16,6 +16,8 @@ import (
 	"syscall"
 	"time"
 
+	"giddyup/api/internal/scraper"
+
 	_ "github.com/lib/pq"
 )
 
@@ -320,7 +322,7 @@ func fetchHorseRacingMarkets(sessionToken, targetDate string) ([]MarketCatalogue
 	params := map[string]interface{}{
 		"filter": map[string]interface{}{
 			"eventTypeIds":    []string{"7"}, // 7 = Horse Racing (tennis was "2")
-			"marketCountries": []string{"GB", "IE"},
+			"marketCountries": scraper.BetfairCountries(),
 			"marketTypeCodes": []string{"WIN"},
 			"marketStartTime": map[string]string{
 				"from": startDate.UTC().Format(time.RFC3339),
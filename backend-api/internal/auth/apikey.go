@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// keyPrefix marks GiddyUp API keys ("gu_" + 32 random URL-safe characters)
+const keyPrefix = "gu_"
+
+// GenerateKey returns a new random API key
+func GenerateKey() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	return keyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashKey returns the stored form of a key (hex SHA-256)
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// DisplayPrefix returns the part of a key kept for listings ("gu_AbCd1234")
+func DisplayPrefix(key string) string {
+	if n := len(keyPrefix) + 8; len(key) > n {
+		return key[:n]
+	}
+	return key
+}
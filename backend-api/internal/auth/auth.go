@@ -0,0 +1,249 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Scopes gate groups of routes
+const (
+	ScopeRead     = "read"     // Races, horses, people, entries, courses, search
+	ScopeAnalysis = "analysis" // Market, bias, analysis and angle endpoints
+	ScopeTrade    = "trade"    // Trading endpoints (none yet)
+	ScopeAdmin    = "admin"    // /admin: scraping, reconciliation, registries, keys
+)
+
+// Roles and the scopes each one may hold
+const (
+	RoleReader  = "reader"
+	RoleAnalyst = "analyst"
+	RoleTrader  = "trader"
+	RoleAdmin   = "admin"
+)
+
+var roleScopes = map[string][]string{
+	RoleReader:  {ScopeRead},
+	RoleAnalyst: {ScopeRead, ScopeAnalysis},
+	RoleTrader:  {ScopeRead, ScopeAnalysis, ScopeTrade},
+	RoleAdmin:   {ScopeRead, ScopeAnalysis, ScopeTrade, ScopeAdmin},
+}
+
+// Authentication methods (Principal.Method)
+const (
+	MethodAPIKey    = "api_key"
+	MethodJWT       = "jwt"
+	MethodAnonymous = "anonymous"
+)
+
+var (
+	// ErrNoCredentials means the request carried no API key or token
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials means the key or token is unknown, revoked, expired or badly signed
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Principal is an authenticated caller
+type Principal struct {
+	Method  string   `json:"method"` // api_key, jwt or anonymous
+	Subject string   `json:"subject"`
+	KeyID   int64    `json:"key_id,omitempty"` // racing.api_keys (API keys only)
+	Role    string   `json:"role"`
+	Scopes  []string `json:"scopes"`
+}
+
+// HasScope reports whether the principal holds a scope
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidRole reports whether a role exists
+func ValidRole(role string) bool {
+	_, ok := roleScopes[role]
+	return ok
+}
+
+// RoleScopes returns the scopes a role may hold
+func RoleScopes(role string) []string {
+	return append([]string(nil), roleScopes[role]...)
+}
+
+// Roles lists the roles with their scopes
+func Roles() map[string][]string {
+	roles := make(map[string][]string, len(roleScopes))
+	for role := range roleScopes {
+		roles[role] = RoleScopes(role)
+	}
+	return roles
+}
+
+// ScopesFor returns the scopes a key or token of this role gets when it asks
+// for requested: all of the role's scopes if none are requested, otherwise
+// the requested ones, which must all belong to the role
+func ScopesFor(role string, requested []string) ([]string, error) {
+	allowed, ok := roleScopes[role]
+	if !ok {
+		return nil, fmt.Errorf("unknown role %q", role)
+	}
+	if len(requested) == 0 {
+		return RoleScopes(role), nil
+	}
+
+	seen := make(map[string]bool)
+	scopes := make([]string, 0, len(requested))
+	for _, scope := range requested {
+		scope = strings.TrimSpace(scope)
+		if scope == "" || seen[scope] {
+			continue
+		}
+		if !contains(allowed, scope) {
+			return nil, fmt.Errorf("scope %q is not allowed for role %s", scope, role)
+		}
+		seen[scope] = true
+		scopes = append(scopes, scope)
+	}
+	sort.Strings(scopes)
+	return scopes, nil
+}
+
+// Config configures an Authenticator
+type Config struct {
+	Required      bool   // Reject requests without credentials (otherwise they get AnonymousRole)
+	AnonymousRole string // Role for requests without credentials when not Required
+	JWTSecret     string // HS256 secret; empty disables JWTs
+	JWTIssuer     string // Required iss claim (empty = any)
+}
+
+// keyCacheTTL is how long a looked-up key is trusted before it is re-read
+// (so a revoked key stops working within this window)
+const keyCacheTTL = time.Minute
+
+type cachedKey struct {
+	principal *Principal
+	expires   time.Time
+}
+
+// Authenticator resolves API keys (racing.api_keys) and JWTs to principals
+type Authenticator struct {
+	db  *sql.DB
+	cfg Config
+
+	mu   sync.Mutex
+	keys map[string]cachedKey // By key hash
+}
+
+// NewAuthenticator creates an authenticator
+func NewAuthenticator(db *sql.DB, cfg Config) *Authenticator {
+	if cfg.AnonymousRole == "" {
+		cfg.AnonymousRole = RoleReader
+	}
+	return &Authenticator{db: db, cfg: cfg, keys: make(map[string]cachedKey)}
+}
+
+// Required reports whether requests must carry credentials
+func (a *Authenticator) Required() bool {
+	return a.cfg.Required
+}
+
+// Anonymous returns the principal for requests without credentials
+// (ErrNoCredentials if credentials are required)
+func (a *Authenticator) Anonymous() (*Principal, error) {
+	if a.cfg.Required {
+		return nil, ErrNoCredentials
+	}
+	return &Principal{
+		Method:  MethodAnonymous,
+		Subject: "anonymous",
+		Role:    a.cfg.AnonymousRole,
+		Scopes:  RoleScopes(a.cfg.AnonymousRole),
+	}, nil
+}
+
+// Authenticate resolves a bearer credential: a JWT if it has three
+// dot-separated parts, otherwise an API key
+func (a *Authenticator) Authenticate(ctx context.Context, credential string) (*Principal, error) {
+	if credential == "" {
+		return a.Anonymous()
+	}
+	if strings.Count(credential, ".") == 2 {
+		if a.cfg.JWTSecret == "" {
+			return nil, fmt.Errorf("%w: JWTs are not enabled", ErrInvalidCredentials)
+		}
+		return VerifyJWT(credential, a.cfg.JWTSecret, a.cfg.JWTIssuer, time.Now())
+	}
+	return a.lookupKey(ctx, credential)
+}
+
+// Forget drops a key from the cache so a revocation takes effect at once
+func (a *Authenticator) Forget(keyID int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for hash, cached := range a.keys {
+		if cached.principal.KeyID == keyID {
+			delete(a.keys, hash)
+		}
+	}
+}
+
+// lookupKey resolves an API key, from the cache if it was read recently.
+// Reading it marks it used.
+func (a *Authenticator) lookupKey(ctx context.Context, key string) (*Principal, error) {
+	hash := HashKey(key)
+	now := time.Now()
+
+	a.mu.Lock()
+	cached, ok := a.keys[hash]
+	a.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.principal, nil
+	}
+
+	p := &Principal{Method: MethodAPIKey}
+	var scopes []string
+	err := a.db.QueryRowContext(ctx, `
+		UPDATE racing.api_keys SET last_used_at = now()
+		WHERE key_hash = $1
+		  AND revoked_at IS NULL
+		  AND (expires_at IS NULL OR expires_at > now())
+		RETURNING key_id, name, role, scopes
+	`, hash).Scan(&p.KeyID, &p.Subject, &p.Role, pq.Array(&scopes))
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up API key: %w", err)
+	}
+
+	// Never trust stored scopes beyond the role's
+	for _, scope := range scopes {
+		if contains(roleScopes[p.Role], scope) {
+			p.Scopes = append(p.Scopes, scope)
+		}
+	}
+
+	a.mu.Lock()
+	a.keys[hash] = cachedKey{principal: p, expires: now.Add(keyCacheTTL)}
+	a.mu.Unlock()
+	return p, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
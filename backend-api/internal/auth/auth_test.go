@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScopesFor(t *testing.T) {
+	cases := []struct {
+		name      string
+		role      string
+		requested []string
+		want      []string
+		wantErr   bool
+	}{
+		{name: "reader gets its role's scopes", role: RoleReader, want: []string{ScopeRead}},
+		{name: "analyst gets its role's scopes", role: RoleAnalyst, want: []string{ScopeRead, ScopeAnalysis}},
+		{name: "admin gets every scope", role: RoleAdmin, want: []string{ScopeRead, ScopeAnalysis, ScopeTrade, ScopeAdmin}},
+		{name: "narrowed, sorted and deduplicated", role: RoleAdmin, requested: []string{"read", " analysis", "read", ""},
+			want: []string{ScopeAnalysis, ScopeRead}},
+		{name: "scope beyond the role", role: RoleAnalyst, requested: []string{ScopeRead, ScopeAdmin}, wantErr: true},
+		{name: "reader asking for analysis", role: RoleReader, requested: []string{ScopeAnalysis}, wantErr: true},
+		{name: "unknown scope", role: RoleAdmin, requested: []string{"write"}, wantErr: true},
+		{name: "unknown role", role: "owner", wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ScopesFor(tc.role, tc.requested)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tc.wantErr)
+			}
+			if !tc.wantErr && !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Claims are the JWT claims GiddyUp reads. Scopes come from "scope"
+// (space-separated, RFC 8693) or "scopes" (array); without either the token
+// gets all of its role's scopes.
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss,omitempty"`
+	Role      string   `json:"role"`
+	Scope     string   `json:"scope,omitempty"`
+	Scopes    []string `json:"scopes,omitempty"`
+	ExpiresAt int64    `json:"exp"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+}
+
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// SignJWT signs claims with HS256
+func SignJWT(claims Claims, secret string) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal claims: %w", err)
+	}
+	signed := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signed + "." + sign(signed, secret), nil
+}
+
+// VerifyJWT checks an HS256 token's signature, expiry, issuer and role and
+// returns its principal. Tokens must expire; other algorithms are rejected.
+func VerifyJWT(token, secret, issuer string, now time.Time) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidCredentials)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return nil, fmt.Errorf("%w: unsupported token algorithm", ErrInvalidCredentials)
+	}
+
+	expected := sign(parts[0]+"."+parts[1], secret)
+	if !hmac.Equal([]byte(parts[2]), []byte(expected)) {
+		return nil, fmt.Errorf("%w: bad token signature", ErrInvalidCredentials)
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed token claims", ErrInvalidCredentials)
+	}
+	if claims.ExpiresAt == 0 || now.Unix() >= claims.ExpiresAt {
+		return nil, fmt.Errorf("%w: token expired", ErrInvalidCredentials)
+	}
+	if claims.NotBefore != 0 && now.Unix() < claims.NotBefore {
+		return nil, fmt.Errorf("%w: token not valid yet", ErrInvalidCredentials)
+	}
+	if issuer != "" && claims.Issuer != issuer {
+		return nil, fmt.Errorf("%w: unexpected token issuer", ErrInvalidCredentials)
+	}
+
+	requested := claims.Scopes
+	if claims.Scope != "" {
+		requested = strings.Fields(claims.Scope)
+	}
+	scopes, err := ScopesFor(claims.Role, requested)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	return &Principal{
+		Method:  MethodJWT,
+		Subject: claims.Subject,
+		Role:    claims.Role,
+		Scopes:  scopes,
+	}, nil
+}
+
+func sign(signed, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
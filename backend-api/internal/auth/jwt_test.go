@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "test-secret"
+
+func TestVerifyJWT(t *testing.T) {
+	now := time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC)
+	valid := Claims{Subject: "svc", Issuer: "giddyup", Role: RoleAnalyst, ExpiresAt: now.Add(time.Hour).Unix()}
+
+	token := func(claims Claims, secret string) string {
+		t.Helper()
+		tok, err := SignJWT(claims, secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tok
+	}
+	with := func(change func(*Claims)) Claims {
+		c := valid
+		change(&c)
+		return c
+	}
+
+	cases := []struct {
+		name   string
+		token  string
+		issuer string
+		scopes []string // nil when the token must be rejected
+	}{
+		{name: "valid", token: token(valid, testSecret), issuer: "giddyup", scopes: []string{ScopeRead, ScopeAnalysis}},
+		{name: "any issuer accepted when none configured", token: token(with(func(c *Claims) { c.Issuer = "other" }), testSecret),
+			scopes: []string{ScopeRead, ScopeAnalysis}},
+		{name: "scope claim narrows", token: token(with(func(c *Claims) { c.Scope = "read" }), testSecret), issuer: "giddyup",
+			scopes: []string{ScopeRead}},
+		{name: "expired", token: token(with(func(c *Claims) { c.ExpiresAt = now.Unix() }), testSecret), issuer: "giddyup"},
+		{name: "no expiry", token: token(with(func(c *Claims) { c.ExpiresAt = 0 }), testSecret), issuer: "giddyup"},
+		{name: "not valid yet", token: token(with(func(c *Claims) { c.NotBefore = now.Add(time.Minute).Unix() }), testSecret),
+			issuer: "giddyup"},
+		{name: "wrong issuer", token: token(with(func(c *Claims) { c.Issuer = "other" }), testSecret), issuer: "giddyup"},
+		{name: "signed with another secret", token: token(valid, "other-secret"), issuer: "giddyup"},
+		{name: "claims tampered after signing", token: tamper(t, token(valid, testSecret), `"role":"analyst"`, `"role":"admin"`),
+			issuer: "giddyup"},
+		{name: "alg none", token: strings.Join([]string{
+			base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`)),
+			strings.Split(token(valid, testSecret), ".")[1], "",
+		}, "."), issuer: "giddyup"},
+		{name: "scope beyond the role", token: token(with(func(c *Claims) { c.Scopes = []string{ScopeAdmin} }), testSecret),
+			issuer: "giddyup"},
+		{name: "unknown role", token: token(with(func(c *Claims) { c.Role = "owner" }), testSecret), issuer: "giddyup"},
+		{name: "malformed", token: "not-a-token", issuer: "giddyup"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := VerifyJWT(tc.token, testSecret, tc.issuer, now)
+			if tc.scopes == nil {
+				if !errors.Is(err, ErrInvalidCredentials) {
+					t.Fatalf("err = %v, want ErrInvalidCredentials", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if p.Method != MethodJWT || p.Subject != valid.Subject || !reflect.DeepEqual(p.Scopes, tc.scopes) {
+				t.Fatalf("got %+v, want subject %s with scopes %v", p, valid.Subject, tc.scopes)
+			}
+		})
+	}
+}
+
+// tamper rewrites part of a signed token's claims, keeping its signature
+func tamper(t *testing.T, token, old, repl string) string {
+	t.Helper()
+	parts := strings.Split(token, ".")
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(payload), old) {
+		t.Fatalf("claims %s have no %s", payload, old)
+	}
+	parts[1] = base64.RawURLEncoding.EncodeToString([]byte(strings.Replace(string(payload), old, repl, 1)))
+	return strings.Join(parts, ".")
+}
@@ -2,12 +2,12 @@ package betfair
 
 import (
 	"context"
-	"crypto/md5"
 	"fmt"
 	"log"
 	"strings"
 	"time"
 
+	"giddyup/api/internal/matching"
 	"giddyup/api/internal/scraper"
 )
 
@@ -19,6 +19,8 @@ type RaceMapping struct {
 	OffTime     string
 	Runners     map[int64]int64 // selectionID → runner_id
 	RunnerNames map[int64]string // selectionID → normalized horse name (for debugging)
+	Score       matching.Score          // How confidently the market was matched to the race
+	Coverage    matching.RunnerCoverage // How many of the race's runners were paired with selections
 }
 
 // Matcher handles Racing Post ↔ Betfair matching
@@ -31,18 +33,22 @@ func NewMatcher(client *Client) *Matcher {
 	return &Matcher{client: client}
 }
 
-// FindTodaysMarkets discovers today's UK/IRE horse racing markets
+// FindTodaysMarkets discovers today's horse racing markets in the enabled jurisdictions
 func (m *Matcher) FindTodaysMarkets(ctx context.Context, date string) ([]MarketCatalogue, error) {
 	// Parse date to get time range
 	startDate, err := time.Parse("2006-01-02", date)
 	if err != nil {
 		return nil, err
 	}
-	endDate := startDate.AddDate(0, 0, 1) // Next day
+	// Races are dated by the course's calendar, so a day's US evening and
+	// Australian morning markets start either side of the UTC day - widen the
+	// window and let matching drop markets dated otherwise
+	endDate := startDate.AddDate(0, 0, 1).Add(12 * time.Hour)
+	startDate = startDate.Add(-12 * time.Hour)
 
 	filter := MarketFilter{
 		EventTypeIds:    []string{"7"}, // 7 = Horse Racing
-		MarketCountries: []string{"GB", "IE"}, // UK and Ireland
+		MarketCountries: scraper.BetfairCountries(), // Enabled jurisdictions (default GB/IE)
 		MarketTypeCodes: []string{"WIN"}, // WIN markets only
 		MarketStartTime: &TimeRange{
 			From: &startDate,
@@ -53,6 +59,7 @@ func (m *Matcher) FindTodaysMarkets(ctx context.Context, date string) ([]MarketC
 	projection := []MarketProjection{
 		ProjectionEvent,
 		ProjectionRunnerDescription,
+		ProjectionRunnerMetadata, // Saddle-cloth numbers for runner matching
 		ProjectionMarketStartTime,
 	}
 
@@ -61,78 +68,91 @@ func (m *Matcher) FindTodaysMarkets(ctx context.Context, date string) ([]MarketC
 		return nil, fmt.Errorf("list markets: %w", err)
 	}
 
-	log.Printf("[Betfair] Found %d markets for %s (GB/IE WIN markets)", len(markets), date)
+	log.Printf("[Betfair] Found %d markets for %s (%s WIN markets)", len(markets), date,
+		strings.Join(filter.MarketCountries, "/"))
 	return markets, nil
 }
 
-// MatchRacesToMarkets maps Racing Post races to Betfair markets
+// MatchRacesToMarkets maps Racing Post races to Betfair markets using the shared
+// matching engine. Races are resolved to race_id via their RaceID (when loaded from
+// the database) or raceIDMap keyed by race_key. Manual overrides are honoured and
+// every attempt is audited under the "live" pipeline.
 func (m *Matcher) MatchRacesToMarkets(rpRaces []scraper.Race, bfMarkets []MarketCatalogue, raceIDMap map[string]int64) map[string]*RaceMapping {
 	mappings := make(map[string]*RaceMapping)
 
-	// Build Betfair market lookup by (normalized venue, off time)
-	bfMap := make(map[string]MarketCatalogue)
+	// Describe markets for the engine (skip markets without venue/start time)
+	var markets []MarketCatalogue
+	var candidates []matching.Race
 	for _, market := range bfMarkets {
 		if market.Event == nil || market.MarketStartTime == nil {
 			continue
 		}
+		markets = append(markets, market)
+		candidates = append(candidates, marketToMatchingRace(market))
+	}
 
-		venue := scraper.NormalizeName(market.Event.Venue)
-		offTime := market.MarketStartTime.Format("15:04")
-		key := fmt.Sprintf("%s|%s", venue, offTime)
-		bfMap[key] = market
+	targets := make([]matching.Race, len(rpRaces))
+	for i, race := range rpRaces {
+		targets[i] = race.MatchingRace()
 	}
 
+	engine := matching.NewForPipeline(matching.PipelineLive)
+	defer engine.Flush()
+	results := engine.MatchAll(targets, candidates)
+
 	// Match Racing Post races
 	matched := 0
-	for _, race := range rpRaces {
-		normCourse := scraper.NormalizeName(race.Course)
-		
-		// Convert off_time to HH:MM format (strip seconds for Betfair matching)
-		offTimeHHMM := race.OffTime
-		if len(strings.Split(offTimeHHMM, ":")) == 3 {
-			// "12:35:00" → "12:35"
-			parts := strings.Split(offTimeHHMM, ":")
-			offTimeHHMM = parts[0] + ":" + parts[1]
+	var totalCoverage matching.RunnerCoverage
+	for _, result := range results {
+		race := rpRaces[result.Target]
+		if !result.Matched() {
+			log.Printf("[Matcher] No Betfair market for: %s @ %s (%s, best %.2f)",
+				race.Course, race.OffTime, result.Outcome, result.Score.Total)
+			continue
 		}
-		
-		// Try exact time match first
-		key := fmt.Sprintf("%s|%s", normCourse, offTimeHHMM)
-		bfMarket, found := bfMap[key]
-		
-		// Try ±1 minute tolerance
-		if !found {
-			bfMarket, found = m.findMarketWithTimeTolerance(bfMap, normCourse, offTimeHHMM)
+		bfMarket := markets[result.Candidate]
+
+		// Get race_id
+		raceID := int64(race.RaceID)
+		if raceID == 0 {
+			var ok bool
+			raceID, ok = raceIDMap[race.Key()]
+			if !ok {
+				log.Printf("[Matcher] No race_id for race_key: %s", race.Key())
+				continue
+			}
 		}
 
-		if !found {
-			log.Printf("[Matcher] No Betfair market for: %s @ %s", race.Course, offTimeHHMM)
-			continue
+		// Match runners (selection IDs are the override refs)
+		horses := make([]matching.RunnerTarget, len(race.Runners))
+		for j, rpRunner := range race.Runners {
+			horses[j] = matching.RunnerTarget{Name: rpRunner.Horse, Num: rpRunner.Num}
 		}
-
-		// Get race_id from our map
-		raceKey := generateRaceKeyHelper(race)
-		raceID, ok := raceIDMap[raceKey]
-		if !ok {
-			log.Printf("[Matcher] No race_id for race_key: %s", raceKey)
-			continue
+		selections := make([]matching.RunnerCandidate, len(bfMarket.Runners))
+		for j, bfRunner := range bfMarket.Runners {
+			selections[j] = matching.RunnerCandidate{
+				Ref:          matching.RunnerRef(bfRunner.SelectionID, bfRunner.RunnerName),
+				Name:         bfRunner.RunnerName,
+				Cloth:        bfRunner.ClothNumber(),
+				SortPriority: bfRunner.SortPriority,
+			}
 		}
 
-		// Match runners by normalized horse name
 		runnerMap := make(map[int64]int64)    // selectionID → runner_id
 		runnerNames := make(map[int64]string) // for debugging
-		
-		for _, bfRunner := range bfMarket.Runners {
-			normBFHorse := scraper.NormalizeName(bfRunner.RunnerName)
-			
-			// Find matching RP runner
-			for _, rpRunner := range race.Runners {
-				normRPHorse := scraper.NormalizeName(rpRunner.Horse)
-				if normBFHorse == normRPHorse && rpRunner.RunnerID > 0 {
-					runnerMap[bfRunner.SelectionID] = int64(rpRunner.RunnerID)
-					runnerNames[bfRunner.SelectionID] = normBFHorse
-					break
-				}
+
+		runnerResults := engine.MatchRunners(targets[result.Target].Key, race.Date, horses, selections)
+		coverage := matching.Coverage(runnerResults, len(selections))
+		totalCoverage.Add(coverage)
+
+		for _, rr := range runnerResults {
+			rpRunner := race.Runners[rr.Horse]
+			if !rr.Matched() || rpRunner.RunnerID <= 0 {
+				continue
 			}
+			bfRunner := bfMarket.Runners[rr.Candidate]
+			runnerMap[bfRunner.SelectionID] = int64(rpRunner.RunnerID)
+			runnerNames[bfRunner.SelectionID] = scraper.NormalizeName(bfRunner.RunnerName)
 		}
 
 		if len(runnerMap) > 0 {
@@ -143,58 +163,37 @@ func (m *Matcher) MatchRacesToMarkets(rpRaces []scraper.Race, bfMarkets []Market
 				OffTime:     race.OffTime,
 				Runners:     runnerMap,
 				RunnerNames: runnerNames,
+				Score:       result.Score,
+				Coverage:    coverage,
 			}
 			matched++
-			log.Printf("[Matcher] ✓ Matched: %s @ %s → market %s (%d/%d runners)",
-				race.Course, race.OffTime, bfMarket.MarketID, len(runnerMap), len(race.Runners))
+			log.Printf("[Matcher] ✓ Matched: %s @ %s → market %s (score %.2f, %s)",
+				race.Course, race.OffTime, bfMarket.MarketID, result.Score.Total, coverage)
 		}
 	}
 
-	log.Printf("[Matcher] Matched %d/%d races with Betfair markets", matched, len(rpRaces))
+	log.Printf("[Matcher] Matched %d/%d races with Betfair markets %v, %s", matched, len(rpRaces), matching.Summary(results), totalCoverage)
 	return mappings
 }
 
-// findMarketWithTimeTolerance tries to find a market within ±1 minute
-func (m *Matcher) findMarketWithTimeTolerance(bfMap map[string]MarketCatalogue, venue string, offTime string) (MarketCatalogue, bool) {
-	// Parse HH:MM (or HH:MM:SS)
-	var t time.Time
-	var err error
-	if len(strings.Split(offTime, ":")) == 3 {
-		t, err = time.Parse("15:04:05", offTime)
-	} else {
-		t, err = time.Parse("15:04", offTime)
+// marketToMatchingRace describes a market for the matching engine: start time in UK
+// local, dated by the course's own calendar as the Sporting Life races are
+func marketToMatchingRace(market MarketCatalogue) matching.Race {
+	start := market.MarketStartTime.In(scraper.UK())
+	horses := make([]string, 0, len(market.Runners))
+	for _, runner := range market.Runners {
+		horses = append(horses, runner.RunnerName)
 	}
-	if err != nil {
-		return MarketCatalogue{}, false
+	region := ""
+	if j, ok := scraper.LookupJurisdiction(market.Event.CountryCode); ok {
+		region = j.Code
 	}
-
-	// Try ±1 minute
-	for delta := -1; delta <= 1; delta++ {
-		adjusted := t.Add(time.Duration(delta) * time.Minute)
-		key := fmt.Sprintf("%s|%s", venue, adjusted.Format("15:04"))
-		if market, found := bfMap[key]; found {
-			return market, true
-		}
+	return matching.Race{
+		Date:    scraper.LocalRaceDate(start, region, market.Event.Venue),
+		Venue:   market.Event.Venue,
+		OffTime: start.Format("15:04"),
+		Name:    market.MarketName,
+		Horses:  horses,
+		Ref:     market.MarketID,
 	}
-
-	return MarketCatalogue{}, false
 }
-
-// Helper to generate race key (MUST match generateRaceKey in autoupdate.go)
-func generateRaceKeyHelper(race scraper.Race) string {
-	normCourse := strings.ToLower(strings.TrimSpace(race.Course))
-	normTime := race.OffTime
-	if len(normTime) >= 5 {
-		normTime = normTime[:5] // Strip seconds: "12:35:00" → "12:35"
-	}
-	normName := strings.ToLower(strings.TrimSpace(race.RaceName))
-	normType := strings.ToLower(strings.TrimSpace(race.Type))
-	normRegion := strings.ToUpper(strings.TrimSpace(race.Region))
-
-	// Generate MD5 hash (MUST match autoupdate.go format!)
-	data := fmt.Sprintf("%s|%s|%s|%s|%s|%s", race.Date, normRegion, normCourse, normTime, normName, normType)
-	hash := md5.Sum([]byte(data))
-	return fmt.Sprintf("%x", hash)
-}
-
-
@@ -0,0 +1,44 @@
+package betfair
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMarketToMatchingRace(t *testing.T) {
+	at := func(s string) *time.Time {
+		ts, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &ts
+	}
+	cases := []struct {
+		name    string
+		market  MarketCatalogue
+		date    string
+		offTime string
+	}{
+		{
+			name:    "GB afternoon",
+			market:  MarketCatalogue{MarketStartTime: at("2025-10-18T13:05:00Z"), Event: &Event{CountryCode: "GB", Venue: "Ascot"}},
+			date:    "2025-10-18",
+			offTime: "14:05",
+		},
+		{
+			// 01:30 UK on the 19th is 20:30 in New York on the 18th, the date the card carries
+			name:    "US race after UK midnight",
+			market:  MarketCatalogue{MarketStartTime: at("2025-10-19T00:30:00Z"), Event: &Event{CountryCode: "US", Venue: "Belmont Park"}},
+			date:    "2025-10-18",
+			offTime: "01:30",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := marketToMatchingRace(tc.market)
+			if got.Date != tc.date || got.OffTime != tc.offTime {
+				t.Fatalf("got %s %s, want %s %s", got.Date, got.OffTime, tc.date, tc.offTime)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package betfair
 
-import "time"
+import (
+	"strconv"
+	"time"
+)
 
 // Core API types - minimal subset needed for live prices
 
@@ -21,6 +24,7 @@ type MarketProjection string
 const (
 	ProjectionEvent             MarketProjection = "EVENT"
 	ProjectionRunnerDescription MarketProjection = "RUNNER_DESCRIPTION"
+	ProjectionRunnerMetadata    MarketProjection = "RUNNER_METADATA"
 	ProjectionMarketStartTime   MarketProjection = "MARKET_START_TIME"
 )
 
@@ -48,9 +52,16 @@ type Event struct {
 }
 
 type RunnerCatalog struct {
-	SelectionID int64  `json:"selectionId"`
-	RunnerName  string `json:"runnerName"`
-	SortPriority int   `json:"sortPriority"`
+	SelectionID  int64             `json:"selectionId"`
+	RunnerName   string            `json:"runnerName"`
+	SortPriority int               `json:"sortPriority"`
+	Metadata     map[string]string `json:"metadata,omitempty"` // RUNNER_METADATA (CLOTH_NUMBER, STALL_DRAW, ...)
+}
+
+// ClothNumber returns the saddle-cloth number from the runner metadata (0 if unknown)
+func (r RunnerCatalog) ClothNumber() int {
+	n, _ := strconv.Atoi(r.Metadata["CLOTH_NUMBER"])
+	return n
 }
 
 // MarketBook - live prices and volumes
@@ -0,0 +1,263 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"giddyup/api/internal/config"
+)
+
+// Freshness classes set how long a response is cached (by the server and by
+// clients): settled data changes only when results are amended, live data
+// with every price tick or racecard refresh
+type Freshness int
+
+const (
+	Live    Freshness = iota // Today's races and unsettled (prelim) cards
+	Settled                  // Races with results before today
+	Stats                    // Aggregates over settled results (profiles, calibration)
+)
+
+// TTLs are the lifetimes of each freshness class
+type TTLs struct {
+	Live    time.Duration
+	Settled time.Duration
+	Stats   time.Duration
+}
+
+// Of returns the lifetime of a freshness class
+func (t TTLs) Of(f Freshness) time.Duration {
+	switch f {
+	case Settled:
+		return t.Settled
+	case Stats:
+		return t.Stats
+	default:
+		return t.Live
+	}
+}
+
+// RaceFreshness classes a race: settled once its results are loaded
+// (prelim=false) and its day is over, live until then
+func RaceFreshness(date string, prelim bool) Freshness {
+	if len(date) > 10 {
+		date = date[:10]
+	}
+	if !prelim && date < time.Now().Format("2006-01-02") {
+		return Settled
+	}
+	return Live
+}
+
+// Tags name what a cached response was built from; writers invalidate them
+const TagResults = "results" // Any settled result (profiles, calibration)
+
+// RaceTag is the tag of responses built from a race
+func RaceTag(raceID int64) string {
+	return "race:" + strconv.FormatInt(raceID, 10)
+}
+
+// DateTag is the tag of responses built from a day's races (date as
+// YYYY-MM-DD, or a timestamp on that day)
+func DateTag(date string) string {
+	if len(date) > 10 {
+		date = date[:10]
+	}
+	return "date:" + date
+}
+
+// Entry is a cached response with its validators
+type Entry struct {
+	Body         []byte    `json:"body"`
+	ContentType  string    `json:"content_type"`
+	ETag         string    `json:"etag"`
+	LastModified time.Time `json:"last_modified"`
+	Expires      time.Time `json:"expires"`
+}
+
+// Store keeps encoded entries by key and indexes them by tag. Entries expire
+// after their TTL; Invalidate drops every entry carrying any of the tags.
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags []string) error
+	Invalidate(ctx context.Context, tags ...string) error
+	Purge(ctx context.Context) error
+}
+
+// Cache is the response cache. Without a store it only carries the TTLs:
+// responses still get validators and Cache-Control but nothing is kept.
+// Store failures are logged and treated as misses so a cache outage never
+// fails a request.
+type Cache struct {
+	store Store
+	ttls  TTLs
+}
+
+// New creates a cache over store (nil for validators only)
+func New(store Store, ttls TTLs) *Cache {
+	return &Cache{store: store, ttls: ttls}
+}
+
+// Open creates the cache cfg describes, connecting to Redis when it's the
+// store. A disabled cache still carries the TTLs.
+func Open(cfg config.CacheConfig) (*Cache, error) {
+	ttls := TTLs{Live: cfg.LiveTTL, Settled: cfg.SettledTTL, Stats: cfg.StatsTTL}
+	if !cfg.Enabled {
+		return New(nil, ttls), nil
+	}
+	if cfg.Store != "redis" {
+		return New(NewMemoryStore(cfg.MaxBytes), ttls), nil
+	}
+	store, err := NewRedisStore(cfg.RedisURL, "giddyup:cache:")
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := store.Ping(ctx); err != nil {
+		store.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	return New(store, ttls), nil
+}
+
+// Enabled reports whether responses are kept
+func (c *Cache) Enabled() bool {
+	return c != nil && c.store != nil
+}
+
+// TTL returns the lifetime of a freshness class
+func (c *Cache) TTL(f Freshness) time.Duration {
+	return c.ttls.Of(f)
+}
+
+// Get returns the entry cached under key
+func (c *Cache) Get(ctx context.Context, key string) (*Entry, bool) {
+	if !c.Enabled() {
+		return nil, false
+	}
+	data, ok, err := c.store.Get(ctx, key)
+	if err != nil {
+		log.Printf("[Cache] ⚠️  get %s: %v", key, err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+	var e Entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		log.Printf("[Cache] ⚠️  corrupt entry %s: %v", key, err)
+		return nil, false
+	}
+	return &e, true
+}
+
+// Set caches an entry under key until it expires
+func (c *Cache) Set(ctx context.Context, key string, e *Entry, tags []string) {
+	if !c.Enabled() {
+		return
+	}
+	ttl := time.Until(e.Expires)
+	if ttl <= 0 {
+		return
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		log.Printf("[Cache] ⚠️  encode %s: %v", key, err)
+		return
+	}
+	if err := c.store.Set(ctx, key, data, ttl, tags); err != nil {
+		log.Printf("[Cache] ⚠️  set %s: %v", key, err)
+	}
+}
+
+// Invalidate drops every entry carrying any of the tags
+func (c *Cache) Invalidate(ctx context.Context, tags ...string) {
+	if !c.Enabled() || len(tags) == 0 {
+		return
+	}
+	if err := c.store.Invalidate(ctx, tags...); err != nil {
+		log.Printf("[Cache] ⚠️  invalidate %d tag(s): %v", len(tags), err)
+	}
+}
+
+// Purge drops every entry
+func (c *Cache) Purge(ctx context.Context) error {
+	if !c.Enabled() {
+		return nil
+	}
+	if err := c.store.Purge(ctx); err != nil {
+		return fmt.Errorf("failed to purge cache: %w", err)
+	}
+	return nil
+}
+
+var (
+	defaultMu    sync.RWMutex
+	defaultCache *Cache
+)
+
+// SetDefault sets the cache writers invalidate (see Invalidate); until it is
+// called, invalidation is a no-op
+func SetDefault(c *Cache) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultCache = c
+}
+
+// UseSharedStore points the default cache at the API's store when it is
+// shared (CACHE_STORE=redis), so loaders running outside the API invalidate
+// what they write. A memory store can't be reached from another process;
+// its entries age out by TTL instead.
+func UseSharedStore() error {
+	if os.Getenv("CACHE_STORE") != "redis" {
+		return nil
+	}
+	url := os.Getenv("CACHE_REDIS_URL")
+	if url == "" {
+		url = "redis://localhost:6379/0"
+	}
+	c, err := Open(config.CacheConfig{Enabled: true, Store: "redis", RedisURL: url})
+	if err != nil {
+		return err
+	}
+	SetDefault(c)
+	return nil
+}
+
+// Invalidate drops entries carrying any of the tags from the default cache
+func Invalidate(ctx context.Context, tags ...string) {
+	defaultMu.RLock()
+	c := defaultCache
+	defaultMu.RUnlock()
+	c.Invalidate(ctx, tags...)
+}
+
+// InvalidateRaces drops responses built from the races and their dates, and
+// (results=true) everything aggregated over settled results
+func InvalidateRaces(ctx context.Context, raceIDs []int64, dates []string, results bool) {
+	tags := make([]string, 0, len(raceIDs)+len(dates)+1)
+	seen := make(map[string]bool, cap(tags))
+	add := func(tag string) {
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	for _, id := range raceIDs {
+		add(RaceTag(id))
+	}
+	for _, date := range dates {
+		add(DateTag(date))
+	}
+	if results {
+		add(TagResults)
+	}
+	Invalidate(ctx, tags...)
+}
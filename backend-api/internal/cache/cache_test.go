@@ -0,0 +1,150 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// stores returns each store kind, Redis backed by an in-process stub
+func stores(t *testing.T) map[string]Store {
+	mr := miniredis.RunT(t)
+	redisStore, err := NewRedisStore("redis://"+mr.Addr()+"/0", "test:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { redisStore.Close() })
+	return map[string]Store{
+		"memory": NewMemoryStore(1 << 20),
+		"redis":  redisStore,
+	}
+}
+
+func TestStoreInvalidateByTag(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			set := func(key string, tags ...string) {
+				if err := store.Set(ctx, key, []byte(key), time.Hour, tags); err != nil {
+					t.Fatalf("set %s: %v", key, err)
+				}
+			}
+			has := func(key string) bool {
+				_, ok, err := store.Get(ctx, key)
+				if err != nil {
+					t.Fatalf("get %s: %v", key, err)
+				}
+				return ok
+			}
+
+			set("/races/1", RaceTag(1), DateTag("2024-01-01"))
+			set("/races/2", RaceTag(2), DateTag("2024-01-01"))
+			set("/races/3", RaceTag(3), DateTag("2024-01-02"))
+			set("/horses/9/profile", TagResults)
+
+			if err := store.Invalidate(ctx, RaceTag(1)); err != nil {
+				t.Fatal(err)
+			}
+			if has("/races/1") || !has("/races/2") || !has("/races/3") {
+				t.Fatal("race tag should drop only its race")
+			}
+
+			if err := store.Invalidate(ctx, DateTag("2024-01-01"), TagResults); err != nil {
+				t.Fatal(err)
+			}
+			if has("/races/2") || has("/horses/9/profile") || !has("/races/3") {
+				t.Fatal("date and results tags should drop their entries only")
+			}
+
+			if err := store.Purge(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if has("/races/3") {
+				t.Fatal("purge should drop everything")
+			}
+		})
+	}
+}
+
+func TestMemoryStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(30)
+	for i := 1; i <= 3; i++ {
+		store.Set(ctx, fmt.Sprint(i), make([]byte, 10), time.Hour, []string{"t"})
+	}
+	store.Get(ctx, "1") // 2 is now the oldest
+	store.Set(ctx, "4", make([]byte, 10), time.Hour, []string{"t"})
+
+	if _, ok, _ := store.Get(ctx, "2"); ok {
+		t.Fatal("least recently used entry should be evicted")
+	}
+	for _, key := range []string{"1", "3", "4"} {
+		if _, ok, _ := store.Get(ctx, key); !ok {
+			t.Fatalf("entry %s should be kept", key)
+		}
+	}
+
+	// Evicted entries leave their tags
+	store.Invalidate(ctx, "t")
+	if store.Len() != 0 || len(store.tags) != 0 {
+		t.Fatalf("invalidate left %d entries, %d tags", store.Len(), len(store.tags))
+	}
+}
+
+func TestMemoryStoreExpires(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(1 << 10)
+	store.Set(ctx, "k", []byte("v"), -time.Second, nil)
+	if _, ok, _ := store.Get(ctx, "k"); ok {
+		t.Fatal("expired entry returned")
+	}
+}
+
+func TestCacheRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			c := New(store, TTLs{Live: time.Minute})
+			SetDefault(c)
+			defer SetDefault(nil)
+
+			e := &Entry{Body: []byte(`{"race_id":1}`), ContentType: "application/json", ETag: `"abc"`,
+				LastModified: time.Now().UTC().Truncate(time.Second), Expires: time.Now().Add(c.TTL(Live))}
+			c.Set(ctx, "/races/1", e, []string{RaceTag(1), DateTag("2024-01-01T00:00:00Z")})
+
+			got, ok := c.Get(ctx, "/races/1")
+			if !ok || string(got.Body) != string(e.Body) || got.ETag != e.ETag || !got.LastModified.Equal(e.LastModified) {
+				t.Fatalf("got %+v, %v", got, ok)
+			}
+
+			InvalidateRaces(ctx, nil, []string{"2024-01-01"}, false)
+			if _, ok := c.Get(ctx, "/races/1"); ok {
+				t.Fatal("InvalidateRaces should drop the date's entries")
+			}
+		})
+	}
+}
+
+func TestRaceFreshness(t *testing.T) {
+	today := time.Now().Format("2006-01-02")
+	past := time.Now().AddDate(0, 0, -3).Format("2006-01-02")
+	cases := []struct {
+		date   string
+		prelim bool
+		want   Freshness
+	}{
+		{past, false, Settled},
+		{past + "T00:00:00Z", false, Settled},
+		{past, true, Live},
+		{today, false, Live},
+		{today + "T00:00:00Z", false, Live},
+	}
+	for _, tc := range cases {
+		if got := RaceFreshness(tc.date, tc.prelim); got != tc.want {
+			t.Errorf("RaceFreshness(%s, %v) = %v, want %v", tc.date, tc.prelim, got, tc.want)
+		}
+	}
+}
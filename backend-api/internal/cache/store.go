@@ -0,0 +1,231 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// MemoryStore keeps entries in process memory (per API instance), evicting
+// the least recently used once they pass maxBytes
+type MemoryStore struct {
+	mu       sync.Mutex
+	maxBytes int
+	bytes    int
+	lru      *list.List // Front is most recently used
+	items    map[string]*list.Element
+	tags     map[string]map[string]bool // Tag -> keys
+}
+
+type memoryItem struct {
+	key     string
+	value   []byte
+	expires time.Time
+	tags    []string
+}
+
+// NewMemoryStore creates an in-memory store holding up to maxBytes of entries
+func NewMemoryStore(maxBytes int) *MemoryStore {
+	return &MemoryStore{
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		items:    make(map[string]*list.Element),
+		tags:     make(map[string]map[string]bool),
+	}
+}
+
+// Get returns the entry under key unless it has expired
+func (m *MemoryStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	el, ok := m.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+	item := el.Value.(*memoryItem)
+	if !time.Now().Before(item.expires) {
+		m.remove(el)
+		return nil, false, nil
+	}
+	m.lru.MoveToFront(el)
+	return item.value, true, nil
+}
+
+// Set stores an entry, evicting old ones to make room. Entries bigger than
+// the whole store aren't kept.
+func (m *MemoryStore) Set(_ context.Context, key string, value []byte, ttl time.Duration, tags []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if el, ok := m.items[key]; ok {
+		m.remove(el)
+	}
+	if len(value) > m.maxBytes {
+		return nil
+	}
+	for m.bytes+len(value) > m.maxBytes {
+		m.remove(m.lru.Back())
+	}
+
+	m.items[key] = m.lru.PushFront(&memoryItem{key: key, value: value, expires: time.Now().Add(ttl), tags: tags})
+	m.bytes += len(value)
+	for _, tag := range tags {
+		if m.tags[tag] == nil {
+			m.tags[tag] = make(map[string]bool)
+		}
+		m.tags[tag][key] = true
+	}
+	return nil
+}
+
+// Invalidate drops every entry carrying any of the tags
+func (m *MemoryStore) Invalidate(_ context.Context, tags ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range m.tags[tag] {
+			if el, ok := m.items[key]; ok {
+				m.remove(el)
+			}
+		}
+		delete(m.tags, tag)
+	}
+	return nil
+}
+
+// Purge drops every entry
+func (m *MemoryStore) Purge(_ context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lru.Init()
+	m.items = make(map[string]*list.Element)
+	m.tags = make(map[string]map[string]bool)
+	m.bytes = 0
+	return nil
+}
+
+// Len returns the number of entries held (expired ones included until
+// they're next touched)
+func (m *MemoryStore) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.items)
+}
+
+func (m *MemoryStore) remove(el *list.Element) {
+	item := m.lru.Remove(el).(*memoryItem)
+	delete(m.items, item.key)
+	m.bytes -= len(item.value)
+	for _, tag := range item.tags {
+		if keys := m.tags[tag]; keys != nil {
+			delete(keys, item.key)
+			if len(keys) == 0 {
+				delete(m.tags, tag)
+			}
+		}
+	}
+}
+
+// RedisStore keeps entries in Redis (or anything speaking its protocol) so
+// every API instance and loader shares one cache. Each tag is a set of the
+// keys carrying it; sets live as long as their longest-lived entry.
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStore connects to url (redis://[:password@]host:port/db) and keys
+// everything under prefix
+func NewRedisStore(url, prefix string) (*RedisStore, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+	return &RedisStore{client: redis.NewClient(opts), prefix: prefix}, nil
+}
+
+// Ping checks the connection
+func (r *RedisStore) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+// Close closes the connection pool
+func (r *RedisStore) Close() error {
+	return r.client.Close()
+}
+
+func (r *RedisStore) entryKey(key string) string { return r.prefix + "entry:" + key }
+func (r *RedisStore) tagKey(tag string) string   { return r.prefix + "tag:" + tag }
+
+// Get returns the entry under key
+func (r *RedisStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := r.client.Get(ctx, r.entryKey(key)).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set stores an entry and adds it to its tags' sets
+func (r *RedisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags []string) error {
+	pipe := r.client.TxPipeline()
+	pipe.Set(ctx, r.entryKey(key), value, ttl)
+	for _, tag := range tags {
+		pipe.SAdd(ctx, r.tagKey(tag), key)
+		pipe.ExpireNX(ctx, r.tagKey(tag), ttl)
+		pipe.ExpireGT(ctx, r.tagKey(tag), ttl)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Invalidate drops every entry carrying any of the tags
+func (r *RedisStore) Invalidate(ctx context.Context, tags ...string) error {
+	for _, tag := range tags {
+		keys, err := r.client.SMembers(ctx, r.tagKey(tag)).Result()
+		if err != nil {
+			return err
+		}
+		del := make([]string, 0, len(keys)+1)
+		for _, key := range keys {
+			del = append(del, r.entryKey(key))
+		}
+		del = append(del, r.tagKey(tag))
+		if err := r.client.Del(ctx, del...).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Purge drops every entry and tag under the prefix
+func (r *RedisStore) Purge(ctx context.Context) error {
+	iter := r.client.Scan(ctx, 0, r.prefix+"*", 500).Iterator()
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) == 500 {
+			if err := r.client.Del(ctx, keys...).Err(); err != nil {
+				return err
+			}
+			keys = keys[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if len(keys) > 0 {
+		return r.client.Del(ctx, keys...).Err()
+	}
+	return nil
+}
@@ -2,15 +2,24 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"strconv"
 	"strings"
+	"time"
+
+	"giddyup/api/internal/ratelimit"
 )
 
 type Config struct {
-	Database DatabaseConfig
-	Server   ServerConfig
-	CORS     CORSConfig
+	Database  DatabaseConfig
+	Server    ServerConfig
+	CORS      CORSConfig
+	Auth      AuthConfig
+	RateLimit RateLimitConfig
+	Export    ExportConfig
+	GraphQL   GraphQLConfig
+	Cache     CacheConfig
 }
 
 type DatabaseConfig struct {
@@ -22,14 +31,50 @@ type DatabaseConfig struct {
 }
 
 type ServerConfig struct {
-	Port int
-	Env  string
+	Port           int
+	Env            string
+	TrustedProxies []string // IPs or CIDRs whose X-Forwarded-For gives the client IP (none = the peer's address)
 }
 
 type CORSConfig struct {
 	Origins []string
 }
 
+type AuthConfig struct {
+	Required      bool   // Requests without an API key or JWT are rejected
+	AnonymousRole string // Role for requests without credentials when not required
+	JWTSecret     string // HS256 secret for JWTs (empty = API keys only)
+	JWTIssuer     string // Required JWT iss claim (empty = any)
+}
+
+type RateLimitConfig struct {
+	Enabled bool
+	Store   string                     // memory or postgres (shared by every API instance)
+	Quotas  map[string]ratelimit.Quota // Per route group
+}
+
+type ExportConfig struct {
+	Dir         string        // Where export jobs write their files
+	Workers     int           // Export jobs run at once by this instance
+	TTL         time.Duration // How long a finished export can be downloaded
+	SyncMaxDays int           // Longest date range streamed directly; longer ones need a job
+}
+
+type GraphQLConfig struct {
+	MaxCost  int // Highest estimated cost of a query (see gql.Cost)
+	MaxDepth int // Deepest field nesting of a query
+}
+
+type CacheConfig struct {
+	Enabled    bool
+	Store      string        // memory (per instance) or redis (shared)
+	RedisURL   string        // redis://[:password@]host:port/db when Store is redis
+	MaxBytes   int           // Memory store size
+	LiveTTL    time.Duration // Today's and unsettled races
+	SettledTTL time.Duration // Races with results before today
+	StatsTTL   time.Duration // Profiles and calibration
+}
+
 func Load() (*Config, error) {
 	// Database config
 	dbPort, err := strconv.Atoi(getEnv("DB_PORT", "5432"))
@@ -43,6 +88,100 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("invalid SERVER_PORT: %w", err)
 	}
 
+	// Client IPs (quotas, usage, logs) only come from X-Forwarded-For behind these proxies
+	trustedProxies := parseList(os.Getenv("TRUSTED_PROXIES"))
+	for _, p := range trustedProxies {
+		if _, _, err := net.ParseCIDR(p); err != nil && net.ParseIP(p) == nil {
+			return nil, fmt.Errorf("invalid TRUSTED_PROXIES entry %q (IP or CIDR)", p)
+		}
+	}
+
+	// Auth config: required unless AUTH_REQUIRED=false turns it off (whatever ENV says,
+	// so a deploy that forgets ENV isn't open)
+	env := getEnv("ENV", "development")
+	authRequired := true
+	if v := os.Getenv("AUTH_REQUIRED"); v != "" {
+		authRequired, err = strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid AUTH_REQUIRED: %w", err)
+		}
+	}
+	anonymousRole := getEnv("AUTH_ANONYMOUS_ROLE", "reader")
+	if anonymousRole != "reader" && anonymousRole != "analyst" {
+		return nil, fmt.Errorf("invalid AUTH_ANONYMOUS_ROLE %q (reader or analyst)", anonymousRole)
+	}
+
+	// Rate limits: per client and route group, RATE_LIMITS overrides the defaults
+	rateLimited := true
+	if v := os.Getenv("RATE_LIMIT_ENABLED"); v != "" {
+		rateLimited, err = strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RATE_LIMIT_ENABLED: %w", err)
+		}
+	}
+	rateStore := getEnv("RATE_LIMIT_STORE", "memory")
+	if rateStore != "memory" && rateStore != "postgres" {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_STORE %q (memory or postgres)", rateStore)
+	}
+	quotas, err := ratelimit.ParseQuotas(os.Getenv("RATE_LIMITS"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RATE_LIMITS: %w", err)
+	}
+
+	// Exports: short ranges stream, the rest run as jobs writing to EXPORT_DIR
+	exportWorkers, err := strconv.Atoi(getEnv("EXPORT_WORKERS", "2"))
+	if err != nil || exportWorkers < 0 {
+		return nil, fmt.Errorf("invalid EXPORT_WORKERS %q", os.Getenv("EXPORT_WORKERS"))
+	}
+	exportTTL, err := time.ParseDuration(getEnv("EXPORT_TTL", "168h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid EXPORT_TTL: %w", err)
+	}
+	exportSyncDays, err := strconv.Atoi(getEnv("EXPORT_SYNC_MAX_DAYS", "31"))
+	if err != nil || exportSyncDays < 1 {
+		return nil, fmt.Errorf("invalid EXPORT_SYNC_MAX_DAYS %q", os.Getenv("EXPORT_SYNC_MAX_DAYS"))
+	}
+
+	// GraphQL: queries over the cost or depth limit are rejected before running
+	graphqlMaxCost, err := strconv.Atoi(getEnv("GRAPHQL_MAX_COST", "5000"))
+	if err != nil || graphqlMaxCost < 1 {
+		return nil, fmt.Errorf("invalid GRAPHQL_MAX_COST %q", os.Getenv("GRAPHQL_MAX_COST"))
+	}
+	graphqlMaxDepth, err := strconv.Atoi(getEnv("GRAPHQL_MAX_DEPTH", "8"))
+	if err != nil || graphqlMaxDepth < 1 {
+		return nil, fmt.Errorf("invalid GRAPHQL_MAX_DEPTH %q", os.Getenv("GRAPHQL_MAX_DEPTH"))
+	}
+
+	// Response cache: validators always, stored responses unless CACHE_ENABLED=false
+	cacheEnabled := true
+	if v := os.Getenv("CACHE_ENABLED"); v != "" {
+		cacheEnabled, err = strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CACHE_ENABLED: %w", err)
+		}
+	}
+	cacheStore := getEnv("CACHE_STORE", "memory")
+	if cacheStore != "memory" && cacheStore != "redis" {
+		return nil, fmt.Errorf("invalid CACHE_STORE %q (memory or redis)", cacheStore)
+	}
+	cacheRedisURL := getEnv("CACHE_REDIS_URL", "redis://localhost:6379/0")
+	cacheMaxMB, err := strconv.Atoi(getEnv("CACHE_MAX_MB", "128"))
+	if err != nil || cacheMaxMB < 1 {
+		return nil, fmt.Errorf("invalid CACHE_MAX_MB %q", os.Getenv("CACHE_MAX_MB"))
+	}
+	cacheLiveTTL, err := time.ParseDuration(getEnv("CACHE_LIVE_TTL", "30s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE_LIVE_TTL: %w", err)
+	}
+	cacheSettledTTL, err := time.ParseDuration(getEnv("CACHE_SETTLED_TTL", "24h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE_SETTLED_TTL: %w", err)
+	}
+	cacheStatsTTL, err := time.ParseDuration(getEnv("CACHE_STATS_TTL", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE_STATS_TTL: %w", err)
+	}
+
 	cfg := &Config{
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -52,11 +191,42 @@ func Load() (*Config, error) {
 			Password: getEnv("DB_PASSWORD", "password"),
 		},
 		Server: ServerConfig{
-			Port: serverPort,
-			Env:  getEnv("ENV", "development"),
+			Port:           serverPort,
+			Env:            env,
+			TrustedProxies: trustedProxies,
 		},
 		CORS: CORSConfig{
-			Origins: parseCORSOrigins(getEnv("CORS_ORIGINS", "http://localhost:3000,http://localhost:3001,http://localhost:5173")),
+			Origins: parseList(getEnv("CORS_ORIGINS", "http://localhost:3000,http://localhost:3001,http://localhost:5173")),
+		},
+		Auth: AuthConfig{
+			Required:      authRequired,
+			AnonymousRole: anonymousRole,
+			JWTSecret:     os.Getenv("JWT_SECRET"),
+			JWTIssuer:     os.Getenv("JWT_ISSUER"),
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: rateLimited,
+			Store:   rateStore,
+			Quotas:  quotas,
+		},
+		Export: ExportConfig{
+			Dir:         getEnv("EXPORT_DIR", "exports"),
+			Workers:     exportWorkers,
+			TTL:         exportTTL,
+			SyncMaxDays: exportSyncDays,
+		},
+		GraphQL: GraphQLConfig{
+			MaxCost:  graphqlMaxCost,
+			MaxDepth: graphqlMaxDepth,
+		},
+		Cache: CacheConfig{
+			Enabled:    cacheEnabled,
+			Store:      cacheStore,
+			RedisURL:   cacheRedisURL,
+			MaxBytes:   cacheMaxMB << 20,
+			LiveTTL:    cacheLiveTTL,
+			SettledTTL: cacheSettledTTL,
+			StatsTTL:   cacheStatsTTL,
 		},
 	}
 
@@ -81,12 +251,12 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
-// parseCORSOrigins splits comma-separated origins into a slice
-func parseCORSOrigins(originsStr string) []string {
-	origins := strings.Split(originsStr, ",")
-	result := make([]string, 0, len(origins))
-	for _, origin := range origins {
-		trimmed := strings.TrimSpace(origin)
+// parseList splits a comma-separated list (origins, proxies) into a slice
+func parseList(s string) []string {
+	items := strings.Split(s, ",")
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		trimmed := strings.TrimSpace(item)
 		if trimmed != "" {
 			result = append(result, trimmed)
 		}
@@ -0,0 +1,223 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"giddyup/api/internal/logger"
+	"giddyup/api/internal/models"
+	"giddyup/api/internal/repository"
+)
+
+const (
+	pollInterval = 10 * time.Second // Queue check when no job was submitted here
+	sweepEvery   = time.Minute      // Expiry of old files
+	staleAfter   = 6 * time.Hour    // A job running this long lost its worker
+)
+
+// Runner runs queued export jobs (racing.export_jobs) on a few workers,
+// writing each file to dir, and deletes files once they expire. Several API
+// instances can share the queue.
+type Runner struct {
+	repo    *repository.ExportRepository
+	dir     string
+	workers int
+	ttl     time.Duration
+
+	wake   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRunner creates a runner; nothing runs until Start
+func NewRunner(repo *repository.ExportRepository, dir string, workers int, ttl time.Duration) *Runner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Runner{
+		repo:    repo,
+		dir:     dir,
+		workers: workers,
+		ttl:     ttl,
+		wake:    make(chan struct{}, 1),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Start creates the export directory and starts the workers (none when
+// workers is 0: another instance runs the jobs)
+func (r *Runner) Start() error {
+	if r.workers == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create export directory: %w", err)
+	}
+	for i := 0; i < r.workers; i++ {
+		r.wg.Add(1)
+		go r.work()
+	}
+	r.wg.Add(1)
+	go r.sweep()
+	return nil
+}
+
+// Close stops the workers; jobs they were running go back in the queue
+func (r *Runner) Close() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+// Wake tells an idle worker a job was queued
+func (r *Runner) Wake() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (r *Runner) work() {
+	defer r.wg.Done()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain the queue, then wait for a submission or the next poll
+		for r.ctx.Err() == nil {
+			job, err := r.repo.ClaimJob(r.ctx)
+			if err != nil {
+				if r.ctx.Err() == nil {
+					logger.Error("Export: %v", err)
+				}
+				break
+			}
+			if job == nil {
+				break
+			}
+			r.run(job)
+		}
+
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-r.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Runner) sweep() {
+	defer r.wg.Done()
+	ticker := time.NewTicker(sweepEvery)
+	defer ticker.Stop()
+
+	for {
+		now := time.Now()
+		if n, err := r.repo.RequeueStale(now.Add(-staleAfter)); err != nil {
+			logger.Error("Export: %v", err)
+		} else if n > 0 {
+			logger.Warn("Export: requeued %d stale jobs", n)
+		}
+		paths, err := r.repo.ExpireJobs(now)
+		if err != nil {
+			logger.Error("Export: %v", err)
+		}
+		for _, path := range paths {
+			if path == "" {
+				continue
+			}
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				logger.Warn("Export: failed to delete %s: %v", path, err)
+			}
+		}
+
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// run writes a job's file, to a .part file renamed once it is complete
+func (r *Runner) run(job *models.ExportJob) {
+	start := time.Now()
+	path := filepath.Join(r.dir, fmt.Sprintf("export-%d.%s", job.JobID, Extension(job.Format)))
+	logger.Info("Export: job %d started (%s %s)", job.JobID, job.Dataset, job.Format)
+
+	rows, size, err := r.write(job, path+".part")
+	if err != nil {
+		os.Remove(path + ".part")
+		if r.ctx.Err() != nil {
+			// Shutting down: another worker picks it up from the start
+			if err := r.repo.RequeueJob(job.JobID); err != nil {
+				logger.Error("Export: %v", err)
+			}
+			return
+		}
+		logger.Error("Export: job %d failed after %d rows: %v", job.JobID, rows, err)
+		if err := r.repo.FailJob(job.JobID, rows, err.Error()); err != nil {
+			logger.Error("Export: %v", err)
+		}
+		return
+	}
+
+	if err := os.Rename(path+".part", path); err != nil {
+		if err := r.repo.FailJob(job.JobID, rows, err.Error()); err != nil {
+			logger.Error("Export: %v", err)
+		}
+		return
+	}
+	if err := r.repo.CompleteJob(job.JobID, rows, size, path, time.Now().Add(r.ttl)); err != nil {
+		logger.Error("Export: %v", err)
+		return
+	}
+	logger.Info("Export: job %d completed: %d rows, %d bytes in %v", job.JobID, rows, size, time.Since(start))
+}
+
+func (r *Runner) write(job *models.ExportJob, path string) (int64, int64, error) {
+	cols, err := repository.ExportColumns(job.Dataset, job.Columns)
+	if err != nil {
+		return 0, 0, err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer f.Close()
+
+	cw := &countingWriter{w: f}
+	w, err := NewWriter(job.Format, cw, cols)
+	if err != nil {
+		return 0, 0, err
+	}
+	rows, err := r.repo.Stream(r.ctx, job.Dataset, cols, job.Filters, 0, w.Write)
+	if err != nil {
+		return rows, 0, err
+	}
+	if err := w.Close(); err != nil {
+		return rows, 0, fmt.Errorf("failed to finish export file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return rows, 0, fmt.Errorf("failed to write export file: %w", err)
+	}
+	return rows, cw.n, nil
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,247 @@
+// Package export writes races and runners as CSV, NDJSON or Parquet and runs
+// background export jobs (racing.export_jobs).
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"giddyup/api/internal/models"
+	"giddyup/api/internal/repository"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Writer encodes export rows (values in column order, as passed by
+// repository.ExportRepository.Stream)
+type Writer interface {
+	Write(row []interface{}) error
+	// Close flushes what is buffered and ends the file; it doesn't close the
+	// underlying writer
+	Close() error
+}
+
+// NewWriter returns a writer for the format (CSV when empty)
+func NewWriter(format string, w io.Writer, cols []repository.ExportColumn) (Writer, error) {
+	switch format {
+	case models.ExportCSV, "":
+		return newCSVWriter(w, cols)
+	case models.ExportNDJSON:
+		return newNDJSONWriter(w, cols), nil
+	case models.ExportParquet:
+		return newParquetWriter(w, cols), nil
+	}
+	return nil, fmt.Errorf("unknown export format %q", format)
+}
+
+// ContentType returns the media type of a format
+func ContentType(format string) string {
+	switch format {
+	case models.ExportNDJSON:
+		return "application/x-ndjson"
+	case models.ExportParquet:
+		return "application/vnd.apache.parquet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Extension returns the file extension of a format
+func Extension(format string) string {
+	if format == "" {
+		return models.ExportCSV
+	}
+	return format
+}
+
+// csvWriter writes a header row then one record per row; nulls are empty
+type csvWriter struct {
+	w   *csv.Writer
+	rec []string
+}
+
+func newCSVWriter(w io.Writer, cols []repository.ExportColumn) (*csvWriter, error) {
+	cw := &csvWriter{w: csv.NewWriter(w), rec: make([]string, len(cols))}
+	for i, col := range cols {
+		cw.rec[i] = col.Name
+	}
+	if err := cw.w.Write(cw.rec); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+func (cw *csvWriter) Write(row []interface{}) error {
+	for i, v := range row {
+		cw.rec[i] = formatValue(v)
+	}
+	return cw.w.Write(cw.rec)
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+func formatValue(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(x)
+	case time.Time:
+		return x.Format("2006-01-02")
+	}
+	return fmt.Sprint(v)
+}
+
+// ndjsonWriter writes one JSON object per line with keys in column order
+type ndjsonWriter struct {
+	w    *bufio.Writer
+	keys [][]byte // `"name":` per column
+	buf  []byte
+}
+
+func newNDJSONWriter(w io.Writer, cols []repository.ExportColumn) *ndjsonWriter {
+	nw := &ndjsonWriter{w: bufio.NewWriter(w), keys: make([][]byte, len(cols))}
+	for i, col := range cols {
+		k, _ := json.Marshal(col.Name)
+		nw.keys[i] = append(k, ':')
+	}
+	return nw
+}
+
+func (nw *ndjsonWriter) Write(row []interface{}) error {
+	b := append(nw.buf[:0], '{')
+	for i, v := range row {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = append(b, nw.keys[i]...)
+		switch x := v.(type) {
+		case nil:
+			b = append(b, "null"...)
+		case int64:
+			b = strconv.AppendInt(b, x, 10)
+		case float64:
+			b = strconv.AppendFloat(b, x, 'f', -1, 64)
+		case bool:
+			b = strconv.AppendBool(b, x)
+		case time.Time:
+			b = append(b, '"')
+			b = x.AppendFormat(b, "2006-01-02")
+			b = append(b, '"')
+		default:
+			s, err := json.Marshal(formatValue(v))
+			if err != nil {
+				return err
+			}
+			b = append(b, s...)
+		}
+	}
+	b = append(b, '}', '\n')
+	nw.buf = b
+	_, err := nw.w.Write(b)
+	return err
+}
+
+func (nw *ndjsonWriter) Close() error {
+	return nw.w.Flush()
+}
+
+// parquetFlushRows is how many rows go in a row group, bounding the memory
+// a Parquet export holds
+const parquetFlushRows = 50000
+
+// parquetWriter writes a flat schema of optional columns typed from the
+// export columns; dates are DATE (days since the epoch)
+type parquetWriter struct {
+	w       *parquet.Writer
+	cols    []repository.ExportColumn
+	index   []int // Column i's position in the schema (ordered by name)
+	row     parquet.Row
+	pending int
+}
+
+func newParquetWriter(w io.Writer, cols []repository.ExportColumn) *parquetWriter {
+	group := make(parquet.Group, len(cols))
+	for _, col := range cols {
+		group[col.Name] = parquet.Optional(parquetNode(col.Type))
+	}
+	schema := parquet.NewSchema("export", group)
+
+	position := make(map[string]int, len(cols))
+	for i, path := range schema.Columns() {
+		position[path[0]] = i
+	}
+	pw := &parquetWriter{
+		w:     parquet.NewWriter(w, schema),
+		cols:  cols,
+		index: make([]int, len(cols)),
+		row:   make(parquet.Row, len(cols)),
+	}
+	for i, col := range cols {
+		pw.index[i] = position[col.Name]
+	}
+	return pw
+}
+
+func parquetNode(typ string) parquet.Node {
+	switch typ {
+	case repository.ExportInt:
+		return parquet.Int(64)
+	case repository.ExportFloat:
+		return parquet.Leaf(parquet.DoubleType)
+	case repository.ExportBool:
+		return parquet.Leaf(parquet.BooleanType)
+	case repository.ExportDate:
+		return parquet.Date()
+	}
+	return parquet.String()
+}
+
+func (pw *parquetWriter) Write(row []interface{}) error {
+	for i, v := range row {
+		idx := pw.index[i]
+		var value parquet.Value
+		switch x := v.(type) {
+		case nil:
+			pw.row[idx] = parquet.Value{}.Level(0, 0, idx)
+			continue
+		case int64:
+			value = parquet.Int64Value(x)
+		case float64:
+			value = parquet.DoubleValue(x)
+		case bool:
+			value = parquet.BooleanValue(x)
+		case time.Time:
+			value = parquet.Int32Value(int32(x.Unix() / 86400))
+		default:
+			value = parquet.ByteArrayValue([]byte(formatValue(v)))
+		}
+		pw.row[idx] = value.Level(0, 1, idx)
+	}
+	if _, err := pw.w.WriteRows([]parquet.Row{pw.row}); err != nil {
+		return err
+	}
+	pw.pending++
+	if pw.pending >= parquetFlushRows {
+		pw.pending = 0
+		return pw.w.Flush()
+	}
+	return nil
+}
+
+func (pw *parquetWriter) Close() error {
+	return pw.w.Close()
+}
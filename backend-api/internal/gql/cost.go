@@ -0,0 +1,176 @@
+package gql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Field weights by Type.field (default 1). Profiles are a dozen aggregate
+// queries each and runs a query per parent; the rest load in batches.
+var fieldWeights = map[string]int{
+	"Horse.profile":   50,
+	"Trainer.profile": 50,
+	"Jockey.profile":  50,
+	"Horse.runs":      5,
+	"Trainer.runs":    5,
+	"Jockey.runs":     5,
+}
+
+// Typical sizes of lists without a first argument (default 10)
+var listSizes = map[string]int{
+	"Query.meetings":    15,
+	"Query.courses":     100,
+	"Meeting.races":     8,
+	"Race.runners":      12,
+	"Runner.form":       6,
+	"Runner.sectionals": 8,
+	// Connections are counted by their first argument
+	"RaceConnection.nodes": 1,
+	"RunConnection.nodes":  1,
+}
+
+// Cost estimates what an operation will fetch before it runs: each object
+// field costs its weight once per parent object, and a list (or a field
+// with a first argument) multiplies everything under it by its size.
+// Scalars are free. It also returns the deepest object nesting. The
+// document must have been validated.
+func Cost(schema *graphql.Schema, doc *ast.Document, operationName string, vars map[string]interface{}) (cost, depth int, err error) {
+	w := costWalker{schema: schema, vars: vars, fragments: map[string]*ast.FragmentDefinition{}}
+	var op *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.FragmentDefinition:
+			w.fragments[def.Name.Value] = def
+		case *ast.OperationDefinition:
+			if operationName == "" || (def.Name != nil && def.Name.Value == operationName) {
+				if op != nil && operationName == "" {
+					return 0, 0, fmt.Errorf("operationName is required with more than one operation")
+				}
+				op = def
+			}
+		}
+	}
+	if op == nil {
+		return 0, 0, fmt.Errorf("unknown operation %q", operationName)
+	}
+	if op.Operation != ast.OperationTypeQuery {
+		return 0, 0, fmt.Errorf("only queries are supported")
+	}
+
+	w.vars = withDefaults(op, vars)
+	w.walk(op.SelectionSet, schema.QueryType(), 1, 0)
+	return w.cost, w.depth, nil
+}
+
+// withDefaults adds the operation's variable defaults for variables the
+// request left out, as execution will
+func withDefaults(op *ast.OperationDefinition, vars map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(vars)+len(op.VariableDefinitions))
+	for _, def := range op.VariableDefinitions {
+		if v, ok := def.DefaultValue.(*ast.IntValue); ok && def.Variable != nil {
+			if n, err := strconv.Atoi(v.Value); err == nil {
+				out[def.Variable.Name.Value] = n
+			}
+		}
+	}
+	for name, v := range vars {
+		out[name] = v
+	}
+	return out
+}
+
+type costWalker struct {
+	schema    *graphql.Schema
+	vars      map[string]interface{}
+	fragments map[string]*ast.FragmentDefinition
+	cost      int
+	depth     int
+}
+
+// maxCount keeps multiplied counts from overflowing; anything near it is far
+// over any sane limit anyway
+const maxCount = 1 << 30
+
+func (w *costWalker) walk(set *ast.SelectionSet, parent graphql.Type, count, depth int) {
+	if set == nil {
+		return
+	}
+	for _, sel := range set.Selections {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			obj, ok := parent.(*graphql.Object)
+			if !ok || sel.SelectionSet == nil || strings.HasPrefix(sel.Name.Value, "__") {
+				continue
+			}
+			def, ok := obj.Fields()[sel.Name.Value]
+			if !ok {
+				continue
+			}
+			key := obj.Name() + "." + def.Name
+			weight, ok := fieldWeights[key]
+			if !ok {
+				weight = 1
+			}
+			w.cost = min(w.cost+count*weight, maxCount)
+			w.depth = max(w.depth, depth+1)
+			w.walk(sel.SelectionSet, graphql.GetNamed(def.Type).(graphql.Type), min(count*w.size(key, def, sel), maxCount), depth+1)
+
+		case *ast.InlineFragment:
+			t := parent
+			if sel.TypeCondition != nil {
+				t = w.schema.Type(sel.TypeCondition.Name.Value)
+			}
+			w.walk(sel.SelectionSet, t, count, depth)
+
+		case *ast.FragmentSpread:
+			if frag, ok := w.fragments[sel.Name.Value]; ok {
+				w.walk(frag.SelectionSet, w.schema.Type(frag.TypeCondition.Name.Value), count, depth)
+			}
+		}
+	}
+}
+
+// size is how many of a field's type one parent gets
+func (w *costWalker) size(key string, def *graphql.FieldDefinition, field *ast.Field) int {
+	for _, arg := range def.Args {
+		if arg.Name() == "first" {
+			return w.intArg(field, arg)
+		}
+	}
+	if _, ok := graphql.GetNullable(def.Type).(*graphql.List); !ok {
+		return 1
+	}
+	if n, ok := listSizes[key]; ok {
+		return n
+	}
+	return 10
+}
+
+// intArg is an Int argument's value: literal, variable or default
+func (w *costWalker) intArg(field *ast.Field, def *graphql.Argument) int {
+	n := 0
+	if v, ok := def.DefaultValue.(int); ok {
+		n = v
+	}
+	for _, arg := range field.Arguments {
+		if arg.Name.Value != def.Name() {
+			continue
+		}
+		switch v := arg.Value.(type) {
+		case *ast.IntValue:
+			n, _ = strconv.Atoi(v.Value)
+		case *ast.Variable:
+			switch x := w.vars[v.Name.Value].(type) {
+			case float64:
+				n = int(x)
+			case int:
+				n = x
+			}
+		}
+	}
+	return min(max(n, 1), maxFirst)
+}
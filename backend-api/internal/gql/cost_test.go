@@ -0,0 +1,47 @@
+package gql
+
+import (
+	"testing"
+
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+func TestCostFirst(t *testing.T) {
+	schema, err := NewSchema()
+	if err != nil {
+		t.Fatalf("NewSchema: %v", err)
+	}
+
+	// races costs 1 and each of its first nodes another 1
+	tests := []struct {
+		name  string
+		query string
+		vars  map[string]interface{}
+		want  int
+	}{
+		{"argument default", `{ races { nodes { race_id } } }`, nil, 1 + 50},
+		{"literal", `{ races(first: 5) { nodes { race_id } } }`, nil, 1 + 5},
+		{"variable", `query($n: Int) { races(first: $n) { nodes { race_id } } }`, map[string]interface{}{"n": float64(200)}, 1 + 200},
+		{"variable default", `query($n: Int = 300) { races(first: $n) { nodes { race_id } } }`, nil, 1 + 300},
+		{"variable over its default", `query($n: Int = 300) { races(first: $n) { nodes { race_id } } }`, map[string]interface{}{"n": float64(7)}, 1 + 7},
+		{"capped at maxFirst", `{ races(first: 100000) { nodes { race_id } } }`, nil, 1 + maxFirst},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parser.Parse(parser.ParseParams{Source: tt.query})
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			cost, depth, err := Cost(&schema, doc, "", tt.vars)
+			if err != nil {
+				t.Fatalf("Cost: %v", err)
+			}
+			if cost != tt.want {
+				t.Errorf("cost = %d, want %d", cost, tt.want)
+			}
+			if depth != 2 {
+				t.Errorf("depth = %d, want 2", depth)
+			}
+		})
+	}
+}
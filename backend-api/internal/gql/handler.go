@@ -0,0 +1,127 @@
+package gql
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"giddyup/api/internal/config"
+	"giddyup/api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// Request is a GraphQL request body
+type Request struct {
+	Query         string                 `json:"query" binding:"required"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Response is a GraphQL response body; extensions.cost is the query's
+// estimated cost
+type Response = graphql.Result
+
+// Handler serves the schema over HTTP
+type Handler struct {
+	schema   graphql.Schema
+	raceRepo *repository.RaceRepository
+
+	profileRepo *repository.ProfileRepository
+	cfg         config.GraphQLConfig
+}
+
+// NewHandler builds the schema; it panics if the schema is invalid, which is
+// a bug caught at startup
+func NewHandler(raceRepo *repository.RaceRepository, profileRepo *repository.ProfileRepository, cfg config.GraphQLConfig) *Handler {
+	schema, err := NewSchema()
+	if err != nil {
+		panic(fmt.Sprintf("GraphQL schema: %v", err))
+	}
+	return &Handler{schema: schema, raceRepo: raceRepo, profileRepo: profileRepo, cfg: cfg}
+}
+
+// Serve runs a query, from a JSON body or (GET) the query, operationName and
+// variables parameters. Requests that don't parse, validate or fit the cost
+// and depth limits are a 400 and run nothing.
+// POST /api/v1/graphql
+// GET /api/v1/graphql?query=...
+func (h *Handler) Serve(c *gin.Context) {
+	var req Request
+	if c.Request.Method == http.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		if vars := c.Query("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+				reject(c, fmt.Errorf("invalid variables: %w", err))
+				return
+			}
+		}
+		if req.Query == "" {
+			reject(c, fmt.Errorf("query is required"))
+			return
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		reject(c, err)
+		return
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{Errors: gqlerrors.FormatErrors(err)})
+		return
+	}
+	if result := graphql.ValidateDocument(&h.schema, doc, nil); !result.IsValid {
+		c.JSON(http.StatusBadRequest, Response{Errors: result.Errors})
+		return
+	}
+
+	cost, depth, err := Cost(&h.schema, doc, req.OperationName, req.Variables)
+	if err != nil {
+		reject(c, err)
+		return
+	}
+	limits := map[string]interface{}{
+		"cost":      cost,
+		"max_cost":  h.cfg.MaxCost,
+		"depth":     depth,
+		"max_depth": h.cfg.MaxDepth,
+	}
+	if depth > h.cfg.MaxDepth {
+		c.JSON(http.StatusBadRequest, Response{
+			Errors:     gqlerrors.FormatErrors(fmt.Errorf("query depth %d is over the limit of %d", depth, h.cfg.MaxDepth)),
+			Extensions: limits,
+		})
+		return
+	}
+	if cost > h.cfg.MaxCost {
+		c.JSON(http.StatusBadRequest, Response{
+			Errors:     gqlerrors.FormatErrors(fmt.Errorf("query cost %d is over the limit of %d; ask for fewer items or nested lists", cost, h.cfg.MaxCost)),
+			Extensions: limits,
+		})
+		return
+	}
+
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       withLoaders(c.Request.Context(), newLoaders(h.raceRepo, h.profileRepo)),
+	})
+	if result.Extensions == nil {
+		result.Extensions = map[string]interface{}{}
+	}
+	result.Extensions["cost"] = cost
+	c.JSON(http.StatusOK, result)
+}
+
+func reject(c *gin.Context, err error) {
+	c.JSON(http.StatusBadRequest, Response{Errors: gqlerrors.FormatErrors(err)})
+}
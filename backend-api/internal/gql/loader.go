@@ -0,0 +1,78 @@
+package gql
+
+// Loader batches lookups by key, dataloader style. Load queues a key and
+// returns a thunk; the executor runs thunks breadth first, so by the time the
+// first one runs every sibling has queued its key and one fetch serves them
+// all. Results are cached for the request. A request's resolvers run on one
+// goroutine, so loaders aren't locked.
+type Loader[K comparable, V any] struct {
+	fetch  func(keys []K) (map[K]V, error)
+	queue  []K
+	queued map[K]bool
+	cache  map[K]V
+	errs   map[K]error
+}
+
+// NewLoader creates a loader fetching queued keys with fetch. Keys fetch
+// leaves out load as V's zero value; extra keys it returns are cached.
+func NewLoader[K comparable, V any](fetch func(keys []K) (map[K]V, error)) *Loader[K, V] {
+	return &Loader[K, V]{
+		fetch:  fetch,
+		queued: make(map[K]bool),
+		cache:  make(map[K]V),
+		errs:   make(map[K]error),
+	}
+}
+
+// Prime caches a value already at hand (e.g. races listed by a parent field)
+func (l *Loader[K, V]) Prime(key K, value V) {
+	if _, ok := l.cache[key]; !ok {
+		l.cache[key] = value
+	}
+}
+
+// Load queues key and returns a thunk yielding its value
+func (l *Loader[K, V]) Load(key K) func() (V, error) {
+	if _, ok := l.cache[key]; !ok && !l.queued[key] && l.errs[key] == nil {
+		l.queued[key] = true
+		l.queue = append(l.queue, key)
+	}
+	return func() (V, error) {
+		l.flush()
+		if err := l.errs[key]; err != nil {
+			var zero V
+			return zero, err
+		}
+		return l.cache[key], nil
+	}
+}
+
+// Thunk is Load as a resolver result
+func (l *Loader[K, V]) Thunk(key K) func() (interface{}, error) {
+	load := l.Load(key)
+	return func() (interface{}, error) {
+		return load()
+	}
+}
+
+// flush fetches every queued key in one batch
+func (l *Loader[K, V]) flush() {
+	if len(l.queue) == 0 {
+		return
+	}
+	keys := l.queue
+	l.queue = nil
+	l.queued = make(map[K]bool)
+
+	values, err := l.fetch(keys)
+	for _, key := range keys {
+		if err != nil {
+			l.errs[key] = err
+			continue
+		}
+		l.cache[key] = values[key]
+	}
+	for key, value := range values {
+		l.cache[key] = value
+	}
+}
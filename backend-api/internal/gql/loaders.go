@@ -0,0 +1,171 @@
+package gql
+
+import (
+	"context"
+	"errors"
+
+	"giddyup/api/internal/logger"
+	"giddyup/api/internal/models"
+	"giddyup/api/internal/repository"
+
+	"github.com/graphql-go/graphql"
+)
+
+// loaders are one request's repositories and batch loaders
+type loaders struct {
+	raceRepo    *repository.RaceRepository
+	profileRepo *repository.ProfileRepository
+
+	races      *Loader[int64, *models.Race]
+	runners    *Loader[int64, []models.Runner] // By race
+	sectionals *Loader[int64, []models.Sectional]
+	livePrices *Loader[int64, *models.LivePrice]
+	form       *Loader[models.FormKey, []models.FormRun]
+	courses    *Loader[int64, *models.Course]
+
+	horses   *Loader[int64, *models.Horse]
+	trainers *Loader[int64, *models.Trainer]
+	jockeys  *Loader[int64, *models.Jockey]
+
+	horseProfiles   *Loader[int64, *models.HorseProfile]
+	trainerProfiles *Loader[int64, *models.TrainerProfile]
+	jockeyProfiles  *Loader[int64, *models.JockeyProfile]
+}
+
+func newLoaders(raceRepo *repository.RaceRepository, profileRepo *repository.ProfileRepository) *loaders {
+	return &loaders{
+		raceRepo:    raceRepo,
+		profileRepo: profileRepo,
+
+		races: NewLoader(func(ids []int64) (map[int64]*models.Race, error) {
+			races, err := raceRepo.GetRacesByIDs(ids)
+			if err != nil {
+				return nil, internalError("races", err)
+			}
+			byID := make(map[int64]*models.Race, len(races))
+			for i := range races {
+				byID[races[i].RaceID] = &races[i]
+			}
+			return byID, nil
+		}),
+		runners: NewLoader(func(raceIDs []int64) (map[int64][]models.Runner, error) {
+			runners, err := raceRepo.GetRunnersForRaces(raceIDs, models.RaceView{Runners: true})
+			if err != nil {
+				return nil, internalError("runners", err)
+			}
+			byRace := make(map[int64][]models.Runner, len(raceIDs))
+			for _, runner := range runners {
+				byRace[runner.RaceID] = append(byRace[runner.RaceID], runner)
+			}
+			return byRace, nil
+		}),
+		sectionals: NewLoader(func(runnerIDs []int64) (map[int64][]models.Sectional, error) {
+			byRunner, err := raceRepo.GetRunnerSectionals(runnerIDs)
+			if err != nil {
+				return nil, internalError("sectionals", err)
+			}
+			return byRunner, nil
+		}),
+		livePrices: NewLoader(func(runnerIDs []int64) (map[int64]*models.LivePrice, error) {
+			byRunner, err := raceRepo.GetLivePrices(runnerIDs)
+			if err != nil {
+				return nil, internalError("live prices", err)
+			}
+			return byRunner, nil
+		}),
+		form: NewLoader(func(keys []models.FormKey) (map[models.FormKey][]models.FormRun, error) {
+			byKey, err := raceRepo.GetForm(keys)
+			if err != nil {
+				return nil, internalError("form", err)
+			}
+			return byKey, nil
+		}),
+		// Courses are few: the first load caches them all
+		courses: NewLoader(func([]int64) (map[int64]*models.Course, error) {
+			courses, err := raceRepo.GetCourses()
+			if err != nil {
+				return nil, internalError("courses", err)
+			}
+			byID := make(map[int64]*models.Course, len(courses))
+			for i := range courses {
+				byID[courses[i].CourseID] = &courses[i]
+			}
+			return byID, nil
+		}),
+
+		horses: NewLoader(func(ids []int64) (map[int64]*models.Horse, error) {
+			horses, err := profileRepo.GetHorses(ids)
+			if err != nil {
+				return nil, internalError("horses", err)
+			}
+			byID := make(map[int64]*models.Horse, len(horses))
+			for i := range horses {
+				byID[horses[i].HorseID] = &horses[i]
+			}
+			return byID, nil
+		}),
+		trainers: NewLoader(func(ids []int64) (map[int64]*models.Trainer, error) {
+			trainers, err := profileRepo.GetTrainers(ids)
+			if err != nil {
+				return nil, internalError("trainers", err)
+			}
+			byID := make(map[int64]*models.Trainer, len(trainers))
+			for i := range trainers {
+				byID[trainers[i].TrainerID] = &trainers[i]
+			}
+			return byID, nil
+		}),
+		jockeys: NewLoader(func(ids []int64) (map[int64]*models.Jockey, error) {
+			jockeys, err := profileRepo.GetJockeys(ids)
+			if err != nil {
+				return nil, internalError("jockeys", err)
+			}
+			byID := make(map[int64]*models.Jockey, len(jockeys))
+			for i := range jockeys {
+				byID[jockeys[i].JockeyID] = &jockeys[i]
+			}
+			return byID, nil
+		}),
+
+		// Profiles are a dozen aggregate queries each and don't batch; the
+		// loaders only dedupe them (and Cost weighs them heavily)
+		horseProfiles:   NewLoader(eachKey("horse profile", profileRepo.GetHorseProfile)),
+		trainerProfiles: NewLoader(eachKey("trainer profile", profileRepo.GetTrainerProfile)),
+		jockeyProfiles:  NewLoader(eachKey("jockey profile", profileRepo.GetJockeyProfile)),
+	}
+}
+
+// eachKey fetches keys one at a time
+func eachKey[V any](what string, get func(int64) (V, error)) func([]int64) (map[int64]V, error) {
+	return func(ids []int64) (map[int64]V, error) {
+		byID := make(map[int64]V, len(ids))
+		for _, id := range ids {
+			v, err := get(id)
+			if err != nil {
+				return nil, internalError(what, err)
+			}
+			byID[id] = v
+		}
+		return byID, nil
+	}
+}
+
+// internalError logs a repository failure and returns what clients see.
+// Cursor errors are the client's and pass through.
+func internalError(what string, err error) error {
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		return repository.ErrInvalidCursor
+	}
+	logger.Error("GraphQL: failed to load %s: %v", what, err)
+	return errors.New("failed to load " + what)
+}
+
+type loadersKey struct{}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(p graphql.ResolveParams) *loaders {
+	return p.Context.Value(loadersKey{}).(*loaders)
+}
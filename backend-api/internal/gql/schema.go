@@ -0,0 +1,487 @@
+package gql
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"giddyup/api/internal/models"
+
+	"github.com/graphql-go/graphql"
+)
+
+// maxFirst is the largest page a connection returns, as for REST lists
+const maxFirst = 1000
+
+// NewSchema builds the schema. Scalar fields come from the models' json tags,
+// so names and values match the REST responses; the default resolver reads
+// them off the structs by the same tags. Relations resolve through the
+// request's loaders (see withLoaders).
+func NewSchema() (graphql.Schema, error) {
+	course := object("Course", "A racecourse", models.Course{})
+	livePrice := object("LivePrice", "A runner's latest intraday exchange price", models.LivePrice{})
+	sectional := object("Sectional", "One timed section of a runner's race", models.Sectional{})
+	formRun := object("FormRun", "One of a runner's runs before the race", models.FormRun{})
+	runner := object("Runner", "A horse's run in a race", models.Runner{})
+	race := object("Race", "A race", models.Race{})
+	meeting := object("Meeting", "A day's racing at a course", models.MeetingWithRaces{})
+	horse := object("Horse", "A horse", models.Horse{})
+	trainer := object("Trainer", "A trainer", models.Trainer{})
+	jockey := object("Jockey", "A jockey", models.Jockey{})
+	run := object("Run", "A run in a horse's, trainer's or jockey's record", models.FormEntry{})
+	career := object("CareerSummary", "Career record", models.CareerSummary{})
+	split := object("StatsSplit", "Record split by going, distance, course or race type", models.StatsSplit{})
+	trend := object("TrendPoint", "One run's ratings", models.TrendPoint{})
+	period := object("FormPeriod", "Record over a recent period", models.FormPeriod{})
+	combo := object("TrainerCombo", "A jockey's record for a trainer", models.TrainerCombo{})
+
+	horseProfile := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "HorseProfile",
+		Description: "A horse's career summary, form and splits, as /horses/{id}/profile",
+		Fields: graphql.Fields{
+			"career_summary":  {Type: graphql.NewNonNull(career)},
+			"recent_form":     {Type: list(run)},
+			"going_splits":    {Type: list(split)},
+			"distance_splits": {Type: list(split)},
+			"course_splits":   {Type: list(split)},
+			"rpr_trend":       {Type: list(trend)},
+		},
+	})
+	trainerProfile := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "TrainerProfile",
+		Description: "A trainer's rolling form and splits, as /trainers/{id}/profile",
+		Fields: graphql.Fields{
+			"rolling_form":  {Type: list(period)},
+			"course_splits": {Type: list(split)},
+			"type_splits":   {Type: list(split)},
+			"dist_splits":   {Type: list(split)},
+		},
+	})
+	jockeyProfile := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "JockeyProfile",
+		Description: "A jockey's record, rolling form and splits, as /jockeys/{id}/profile",
+		Fields: graphql.Fields{
+			"career_stats":   {Type: graphql.NewNonNull(career)},
+			"rolling_form":   {Type: list(period)},
+			"trainer_combos": {Type: list(combo)},
+			"course_splits":  {Type: list(split)},
+		},
+	})
+	raceConnection := connectionType("RaceConnection", race)
+	runConnection := connectionType("RunConnection", run)
+
+	// Race and meeting relations
+	race.AddFieldConfig("course", &graphql.Field{
+		Type: course,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return loadCourse(p, sourceOf[models.Race](p).CourseID), nil
+		},
+	})
+	race.AddFieldConfig("runners", &graphql.Field{
+		Type:        list(runner),
+		Description: "Runners by race card number",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return loadersFrom(p).runners.Thunk(sourceOf[models.Race](p).RaceID), nil
+		},
+	})
+	meeting.AddFieldConfig("course", &graphql.Field{
+		Type: course,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return loadCourse(p, sourceOf[models.MeetingWithRaces](p).CourseID), nil
+		},
+	})
+	meeting.AddFieldConfig("races", &graphql.Field{Type: list(race), Description: "Races by off time"})
+
+	// Runner relations
+	runner.AddFieldConfig("race", &graphql.Field{
+		Type: graphql.NewNonNull(race),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return loadersFrom(p).races.Thunk(sourceOf[models.Runner](p).RaceID), nil
+		},
+	})
+	runner.AddFieldConfig("horse", &graphql.Field{
+		Type: horse,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			ru := sourceOf[models.Runner](p)
+			if ru.HorseID == nil {
+				return nil, nil
+			}
+			if ru.HorseName != nil {
+				return &models.Horse{HorseID: *ru.HorseID, HorseName: *ru.HorseName}, nil
+			}
+			return loadersFrom(p).horses.Thunk(*ru.HorseID), nil
+		},
+	})
+	runner.AddFieldConfig("trainer", &graphql.Field{
+		Type: trainer,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			ru := sourceOf[models.Runner](p)
+			if ru.TrainerID == nil {
+				return nil, nil
+			}
+			if ru.TrainerName != nil {
+				return &models.Trainer{TrainerID: *ru.TrainerID, TrainerName: *ru.TrainerName}, nil
+			}
+			return loadersFrom(p).trainers.Thunk(*ru.TrainerID), nil
+		},
+	})
+	runner.AddFieldConfig("jockey", &graphql.Field{
+		Type: jockey,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			ru := sourceOf[models.Runner](p)
+			if ru.JockeyID == nil {
+				return nil, nil
+			}
+			if ru.JockeyName != nil {
+				return &models.Jockey{JockeyID: *ru.JockeyID, JockeyName: *ru.JockeyName}, nil
+			}
+			return loadersFrom(p).jockeys.Thunk(*ru.JockeyID), nil
+		},
+	})
+	runner.AddFieldConfig("sectionals", &graphql.Field{
+		Type:        list(sectional),
+		Description: "Sectional splits, where published",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return loadersFrom(p).sectionals.Thunk(sourceOf[models.Runner](p).RunnerID), nil
+		},
+	})
+	runner.AddFieldConfig("form", &graphql.Field{
+		Type:        list(formRun),
+		Description: "The horse's last 6 runs before this race, latest first",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			ru := sourceOf[models.Runner](p)
+			if ru.HorseID == nil {
+				return []models.FormRun{}, nil
+			}
+			return loadersFrom(p).form.Thunk(models.FormKey{HorseID: *ru.HorseID, Before: day(ru.RaceDate)}), nil
+		},
+	})
+	runner.AddFieldConfig("live_price", &graphql.Field{
+		Type: livePrice,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return loadersFrom(p).livePrices.Thunk(sourceOf[models.Runner](p).RunnerID), nil
+		},
+	})
+	formRun.AddFieldConfig("race", &graphql.Field{
+		Type: race,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return loadersFrom(p).races.Thunk(sourceOf[models.FormRun](p).RaceID), nil
+		},
+	})
+	run.AddFieldConfig("race", &graphql.Field{
+		Type: race,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return loadersFrom(p).races.Thunk(sourceOf[models.FormEntry](p).RaceID), nil
+		},
+	})
+
+	// Horse, trainer and jockey profiles and runs
+	horse.AddFieldConfig("profile", &graphql.Field{
+		Type: horseProfile,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return loadersFrom(p).horseProfiles.Thunk(sourceOf[models.Horse](p).HorseID), nil
+		},
+	})
+	horse.AddFieldConfig("runs", runsField(runConnection, "horse", func(p graphql.ResolveParams) int64 {
+		return sourceOf[models.Horse](p).HorseID
+	}))
+	trainer.AddFieldConfig("profile", &graphql.Field{
+		Type: trainerProfile,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return loadersFrom(p).trainerProfiles.Thunk(sourceOf[models.Trainer](p).TrainerID), nil
+		},
+	})
+	trainer.AddFieldConfig("runs", runsField(runConnection, "trainer", func(p graphql.ResolveParams) int64 {
+		return sourceOf[models.Trainer](p).TrainerID
+	}))
+	jockey.AddFieldConfig("profile", &graphql.Field{
+		Type: jockeyProfile,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return loadersFrom(p).jockeyProfiles.Thunk(sourceOf[models.Jockey](p).JockeyID), nil
+		},
+	})
+	jockey.AddFieldConfig("runs", runsField(runConnection, "jockey", func(p graphql.ResolveParams) int64 {
+		return sourceOf[models.Jockey](p).JockeyID
+	}))
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"race": {
+				Type: race,
+				Args: graphql.FieldConfigArgument{"race_id": {Type: graphql.NewNonNull(graphql.Int)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadersFrom(p).races.Thunk(int64(p.Args["race_id"].(int))), nil
+				},
+			},
+			"races": {
+				Type:        graphql.NewNonNull(raceConnection),
+				Description: "Races matching the filter, newest first (as /races/search)",
+				Args: graphql.FieldConfigArgument{
+					"filter": {Type: inputObject("RaceFilter", models.RaceFilterSet{})},
+					"first":  {Type: graphql.Int, DefaultValue: 50},
+					"after":  {Type: graphql.String, Description: "next_cursor of the previous page"},
+				},
+				Resolve: resolveRaces,
+			},
+			"meetings": {
+				Type:        list(meeting),
+				Description: "A day's meetings and their races (as /meetings)",
+				Args:        graphql.FieldConfigArgument{"date": {Type: graphql.NewNonNull(graphql.String)}},
+				Resolve:     resolveMeetings,
+			},
+			"horse": {
+				Type: horse,
+				Args: graphql.FieldConfigArgument{"horse_id": {Type: graphql.NewNonNull(graphql.Int)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadersFrom(p).horses.Thunk(int64(p.Args["horse_id"].(int))), nil
+				},
+			},
+			"trainer": {
+				Type: trainer,
+				Args: graphql.FieldConfigArgument{"trainer_id": {Type: graphql.NewNonNull(graphql.Int)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadersFrom(p).trainers.Thunk(int64(p.Args["trainer_id"].(int))), nil
+				},
+			},
+			"jockey": {
+				Type: jockey,
+				Args: graphql.FieldConfigArgument{"jockey_id": {Type: graphql.NewNonNull(graphql.Int)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadersFrom(p).jockeys.Thunk(int64(p.Args["jockey_id"].(int))), nil
+				},
+			},
+			"course": {
+				Type: course,
+				Args: graphql.FieldConfigArgument{"course_id": {Type: graphql.NewNonNull(graphql.Int)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id := int64(p.Args["course_id"].(int))
+					return loadCourse(p, &id), nil
+				},
+			},
+			"courses": {
+				Type: list(course),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					courses, err := loadersFrom(p).raceRepo.GetCourses()
+					if err != nil {
+						return nil, internalError("courses", err)
+					}
+					return courses, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
+
+// resolveRaces pages through race search
+func resolveRaces(p graphql.ResolveParams) (interface{}, error) {
+	l := loadersFrom(p)
+
+	var filters models.RaceFilters
+	if filter, ok := p.Args["filter"]; ok {
+		b, err := json.Marshal(filter)
+		if err == nil {
+			err = json.Unmarshal(b, &filters.RaceFilterSet)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter: %w", err)
+		}
+	}
+	for _, d := range []*string{filters.DateFrom, filters.DateTo} {
+		if d != nil {
+			if err := checkDate(*d); err != nil {
+				return nil, err
+			}
+		}
+	}
+	first, err := firstArg(p)
+	if err != nil {
+		return nil, err
+	}
+	filters.Limit = first
+	filters.Cursor, _ = p.Args["after"].(string)
+
+	races, next, err := l.raceRepo.SearchRaces(filters, models.RaceView{})
+	if err != nil {
+		return nil, internalError("races", err)
+	}
+	for i := range races {
+		l.races.Prime(races[i].RaceID, &races[i])
+	}
+	return newConnection(races, next), nil
+}
+
+// resolveMeetings lists a day's meetings
+func resolveMeetings(p graphql.ResolveParams) (interface{}, error) {
+	l := loadersFrom(p)
+
+	date := p.Args["date"].(string)
+	if err := checkDate(date); err != nil {
+		return nil, err
+	}
+	meetings, err := l.raceRepo.GetRacesByMeetings(date, models.RaceView{})
+	if err != nil {
+		return nil, internalError("meetings", err)
+	}
+	for _, meeting := range meetings {
+		for i := range meeting.Races {
+			l.races.Prime(meeting.Races[i].RaceID, &meeting.Races[i])
+		}
+	}
+	return meetings, nil
+}
+
+// runsField pages through a horse's, trainer's or jockey's runs, latest
+// first (as /{entity}s/{id}/runs). Runs don't batch: each parent is a query.
+func runsField(conn *graphql.Object, entity string, id func(graphql.ResolveParams) int64) *graphql.Field {
+	return &graphql.Field{
+		Type:        graphql.NewNonNull(conn),
+		Description: "Runs, latest first",
+		Args: graphql.FieldConfigArgument{
+			"first": {Type: graphql.Int, DefaultValue: 20},
+			"after": {Type: graphql.String, Description: "next_cursor of the previous page"},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			first, err := firstArg(p)
+			if err != nil {
+				return nil, err
+			}
+			after, _ := p.Args["after"].(string)
+			runs, next, err := loadersFrom(p).profileRepo.GetRuns(entity, id(p), first, after)
+			if err != nil {
+				return nil, internalError(entity+" runs", err)
+			}
+			return newConnection(runs, next), nil
+		},
+	}
+}
+
+func loadCourse(p graphql.ResolveParams, courseID *int64) interface{} {
+	if courseID == nil {
+		return nil
+	}
+	return loadersFrom(p).courses.Thunk(*courseID)
+}
+
+// connection is a page of a list
+type connection struct {
+	Nodes      interface{} `json:"nodes"`
+	NextCursor *string     `json:"next_cursor"`
+}
+
+func newConnection(nodes interface{}, next string) connection {
+	conn := connection{Nodes: nodes}
+	if next != "" {
+		conn.NextCursor = &next
+	}
+	return conn
+}
+
+func connectionType(name string, node *graphql.Object) *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name:        name,
+		Description: "A page of " + node.Name() + "s",
+		Fields: graphql.Fields{
+			"nodes":       {Type: list(node)},
+			"next_cursor": {Type: graphql.String, Description: "Pass as after for the next page (null on the last)"},
+		},
+	})
+}
+
+func firstArg(p graphql.ResolveParams) (int, error) {
+	first := p.Args["first"].(int)
+	if first < 1 || first > maxFirst {
+		return 0, fmt.Errorf("first must be between 1 and %d", maxFirst)
+	}
+	return first, nil
+}
+
+func checkDate(d string) error {
+	if _, err := time.Parse("2006-01-02", d); err != nil {
+		return fmt.Errorf("invalid date %q, use YYYY-MM-DD", d)
+	}
+	return nil
+}
+
+// day trims a date column's value to YYYY-MM-DD
+func day(d string) string {
+	if len(d) > 10 {
+		return d[:10]
+	}
+	return d
+}
+
+// sourceOf is the model a field resolves on: loaders yield pointers, lists
+// values
+func sourceOf[T any](p graphql.ResolveParams) T {
+	if v, ok := p.Source.(*T); ok {
+		return *v
+	}
+	return p.Source.(T)
+}
+
+// list is a non-null list of non-null t
+func list(t graphql.Type) graphql.Output {
+	return graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(t)))
+}
+
+// object is a type with model's scalar fields. Pointer fields are nullable.
+func object(name, description string, model interface{}) *graphql.Object {
+	fields := graphql.Fields{}
+	eachScalar(model, func(name string, t graphql.Type, nullable bool) {
+		if !nullable {
+			t = graphql.NewNonNull(t)
+		}
+		fields[name] = &graphql.Field{Type: t}
+	})
+	return graphql.NewObject(graphql.ObjectConfig{Name: name, Description: description, Fields: fields})
+}
+
+// inputObject is an input with model's scalar fields, all optional
+func inputObject(name string, model interface{}) *graphql.InputObject {
+	fields := graphql.InputObjectConfigFieldMap{}
+	eachScalar(model, func(name string, t graphql.Type, _ bool) {
+		fields[name] = &graphql.InputObjectFieldConfig{Type: t}
+	})
+	return graphql.NewInputObject(graphql.InputObjectConfig{Name: name, Fields: fields})
+}
+
+// eachScalar calls fn with the json name and GraphQL type of each of a
+// model's scalar fields. Nested models are left to NewSchema.
+func eachScalar(model interface{}, fn func(name string, t graphql.Type, nullable bool)) {
+	rt := reflect.TypeOf(model)
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		ft, nullable := f.Type, f.Type.Kind() == reflect.Ptr
+		if nullable {
+			ft = ft.Elem()
+		}
+		var t graphql.Type
+		switch ft.Kind() {
+		case reflect.Int, reflect.Int64:
+			t = graphql.Int
+		case reflect.Float64:
+			t = graphql.Float
+		case reflect.String:
+			t = graphql.String
+		case reflect.Bool:
+			t = graphql.Boolean
+		case reflect.Slice:
+			if ft.Elem().Kind() != reflect.String {
+				continue
+			}
+			t = graphql.NewList(graphql.NewNonNull(graphql.String))
+		default:
+			continue
+		}
+		fn(name, t, nullable)
+	}
+}
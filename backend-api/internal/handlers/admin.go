@@ -2,13 +2,14 @@ package handlers
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
-	"strconv"
+	"os"
 	"time"
 
-	"giddyup/api/internal/loader"
-	"giddyup/api/internal/scraper"
-	"giddyup/api/internal/stitcher"
+	"giddyup/api/internal/logger"
+	"giddyup/api/internal/pipeline"
+	"giddyup/api/internal/services"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
@@ -16,228 +17,238 @@ import (
 
 // AdminHandler handles administrative endpoints
 type AdminHandler struct {
-	db *sqlx.DB
+	db      *sqlx.DB
+	dataDir string
+	ingest  *pipeline.Pipeline
 }
 
 // NewAdminHandler creates a new admin handler
 func NewAdminHandler(db *sqlx.DB) *AdminHandler {
+	dataDir := os.Getenv("DATA_DIR")
+	if dataDir == "" {
+		dataDir = "/home/smonaghan/GiddyUp/data" // Default data directory
+	}
+
 	return &AdminHandler{
-		db: db,
+		db:      db,
+		dataDir: dataDir,
+		ingest:  pipeline.New(db.DB, dataDir),
 	}
 }
 
-// ScrapeYesterday scrapes yesterday's results, stitches, and loads
+// ScrapeYesterday runs the ingestion pipeline for yesterday's results
 // POST /api/v1/admin/scrape/yesterday
 func (h *AdminHandler) ScrapeYesterday(c *gin.Context) {
 	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	h.runPipeline(c, pipeline.Options{Date: yesterday})
+}
 
-	// Scrape Sporting Life results
-	slScraper := scraper.NewSportingLifeAPIV2()
-	rpRaces, err := slScraper.GetRacesForDate(yesterday)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to scrape Sporting Life",
+// ScrapeDate runs the ingestion pipeline for a specific date, resuming from the
+// failed stage if the date's last run was interrupted (unless fresh is set)
+// POST /api/v1/admin/scrape/date
+// Body: {"date": "2025-10-13", "fresh": false}
+func (h *AdminHandler) ScrapeDate(c *gin.Context) {
+	var req struct {
+		Date  string `json:"date" binding:"required"`
+		Fresh bool   `json:"fresh"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
 			"details": err.Error(),
 		})
 		return
 	}
 
-	// Download and stitch Betfair data
-	bfStitcher := scraper.NewBetfairStitcher("/home/smonaghan/GiddyUp/data")
-	bfStitcher.StitchBetfairForDate(yesterday, "uk")
-	bfStitcher.StitchBetfairForDate(yesterday, "ire")
-
-	// Load stitched Betfair races
-	bfRacesUK, _ := bfStitcher.LoadStitchedRacesForDate(yesterday, "uk")
-	bfRacesIRE, _ := bfStitcher.LoadStitchedRacesForDate(yesterday, "ire")
-	bfRaces := append(bfRacesUK, bfRacesIRE...)
-
-	// Convert to BetfairPrice format for stitcher
-	bfPrices := h.convertStitchedToPrices(bfRaces)
-
-	// Stitch data
-	stitcherInst := stitcher.New(rpRaces, bfPrices)
-	masterRaces, masterRunners, err := stitcherInst.StitchData()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to stitch data",
-			"details": err.Error(),
+	if _, err := time.Parse("2006-01-02", req.Date); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid date format, expected YYYY-MM-DD",
 		})
 		return
 	}
 
-	// Load to database
-	bulkLoader := loader.NewBulkLoader(h.db.DB)
-	stats, err := bulkLoader.LoadRaces(masterRaces, masterRunners)
+	h.runPipeline(c, pipeline.Options{Date: req.Date, Fresh: req.Fresh})
+}
+
+// ResumeIngestion re-runs every interrupted pipeline run from the stage it failed at
+// POST /api/v1/admin/scrape/resume
+func (h *AdminHandler) ResumeIngestion(c *gin.Context) {
+	results, err := h.ingest.Resume(c.Request.Context())
 	if err != nil {
+		logger.HandlerError("AdminHandler", "ResumeIngestion", err, 500)
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to load data",
+			"error":   "Failed to resume ingestion",
 			"details": err.Error(),
 		})
 		return
 	}
+	if len(results) > 0 {
+		if err := h.ingest.RefreshViews(c.Request.Context()); err != nil {
+			logger.HandlerError("AdminHandler", "ResumeIngestion", err, 500)
+		}
+	}
 
+	if results == nil {
+		results = []*pipeline.Result{}
+	}
 	c.JSON(http.StatusOK, gin.H{
-		"date":             yesterday,
-		"races_scraped":    len(rpRaces),
-		"betfair_stitched": len(bfRaces),
-		"races_loaded":     stats.RacesLoaded,
-		"runners_loaded":   stats.RunnersLoaded,
+		"resumed": results,
+		"count":   len(results),
 	})
 }
 
-// convertStitchedToPrices converts stitched races to BetfairPrice format
-func (h *AdminHandler) convertStitchedToPrices(stitchedRaces []scraper.StitchedRace) []scraper.BetfairPrice {
-	prices := []scraper.BetfairPrice{}
+// ReconcileResults re-fetches settled results and applies amendments
+// (disqualifications, amended placings, SP/BSP corrections) with history
+// POST /api/v1/admin/scrape/reconcile
+// Body: {"date": "2025-10-13"} for one date, or {"days": 3} for the last N days
+func (h *AdminHandler) ReconcileResults(c *gin.Context) {
+	var req struct {
+		Date string `json:"date"`
+		Days int    `json:"days"`
+	}
 
-	for _, race := range stitchedRaces {
-		for _, runner := range race.Runners {
-			price := scraper.BetfairPrice{
-				Date:    race.Date,
-				OffTime: race.OffTime,
-				Horse:   runner.Horse,
-			}
+	if err := c.ShouldBindJSON(&req); err != nil && err.Error() != "EOF" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"details": err.Error(),
+		})
+		return
+	}
 
-			// Parse WIN prices
-			if val, err := strconv.ParseFloat(runner.WinBSP, 64); err == nil {
-				price.WinBSP = val
-			}
-			if val, err := strconv.ParseFloat(runner.WinPPWAP, 64); err == nil {
-				price.WinPPWAP = val
-			}
-			if val, err := strconv.ParseFloat(runner.WinMorningWAP, 64); err == nil {
-				price.WinMorningWAP = val
-			}
-			if val, err := strconv.ParseFloat(runner.WinPPMax, 64); err == nil {
-				price.WinPPMax = val
-			}
-			if val, err := strconv.ParseFloat(runner.WinPPMin, 64); err == nil {
-				price.WinPPMin = val
-			}
-			if val, err := strconv.ParseFloat(runner.WinIPMax, 64); err == nil {
-				price.WinIPMax = val
-			}
-			if val, err := strconv.ParseFloat(runner.WinIPMin, 64); err == nil {
-				price.WinIPMin = val
-			}
-			if val, err := strconv.ParseFloat(runner.WinMorningVol, 64); err == nil {
-				price.WinMorningVol = val
-			}
-			if val, err := strconv.ParseFloat(runner.WinPreVol, 64); err == nil {
-				price.WinPreVol = val
-			}
-			if val, err := strconv.ParseFloat(runner.WinIPVol, 64); err == nil {
-				price.WinIPVol = val
-			}
+	ctx := c.Request.Context()
+	if req.Date != "" {
+		if _, err := time.Parse("2006-01-02", req.Date); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid date format, expected YYYY-MM-DD",
+			})
+			return
+		}
 
-			// Parse PLACE prices
-			if val, err := strconv.ParseFloat(runner.PlaceBSP, 64); err == nil {
-				price.PlaceBSP = val
-			}
-			if val, err := strconv.ParseFloat(runner.PlacePPWAP, 64); err == nil {
-				price.PlacePPWAP = val
-			}
-			if val, err := strconv.ParseFloat(runner.PlaceMorningWAP, 64); err == nil {
-				price.PlaceMorningWAP = val
-			}
-			if val, err := strconv.ParseFloat(runner.PlacePPMax, 64); err == nil {
-				price.PlacePPMax = val
-			}
-			if val, err := strconv.ParseFloat(runner.PlacePPMin, 64); err == nil {
-				price.PlacePPMin = val
-			}
-			if val, err := strconv.ParseFloat(runner.PlaceIPMax, 64); err == nil {
-				price.PlaceIPMax = val
-			}
-			if val, err := strconv.ParseFloat(runner.PlaceIPMin, 64); err == nil {
-				price.PlaceIPMin = val
-			}
-			if val, err := strconv.ParseFloat(runner.PlaceMorningVol, 64); err == nil {
-				price.PlaceMorningVol = val
-			}
-			if val, err := strconv.ParseFloat(runner.PlacePreVol, 64); err == nil {
-				price.PlacePreVol = val
-			}
-			if val, err := strconv.ParseFloat(runner.PlaceIPVol, 64); err == nil {
-				price.PlaceIPVol = val
+		result, err := h.ingest.Reconcile(ctx, req.Date)
+		if errors.Is(err, pipeline.ErrLocked) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Date is already being ingested",
+				"details": err.Error(),
+			})
+			return
+		}
+		if err != nil {
+			logger.HandlerError("AdminHandler", "ReconcileResults", err, 500)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Reconciliation failed",
+				"details": err.Error(),
+				"result":  result,
+			})
+			return
+		}
+		if result.RacesAmended > 0 {
+			if err := h.ingest.RefreshViews(ctx); err != nil {
+				logger.HandlerError("AdminHandler", "ReconcileResults", err, 500)
 			}
-
-			prices = append(prices, price)
 		}
+		c.JSON(http.StatusOK, result)
+		return
 	}
 
-	return prices
-}
+	results, err := h.ingest.ReconcileRecent(ctx, req.Days)
+	if err != nil {
+		logger.HandlerError("AdminHandler", "ReconcileResults", err, 500)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Reconciliation failed",
+			"details": err.Error(),
+		})
+		return
+	}
 
-// ScrapeDate scrapes a specific date
-// POST /api/v1/admin/scrape/date
-// Body: {"date": "2025-10-13"}
-func (h *AdminHandler) ScrapeDate(c *gin.Context) {
-	var req struct {
-		Date string `json:"date" binding:"required"`
+	if results == nil {
+		results = []*pipeline.ReconcileResult{}
 	}
+	amendments := 0
+	for _, res := range results {
+		amendments += len(res.Amendments)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"reconciled": results,
+		"count":      len(results),
+		"amendments": amendments,
+	})
+}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request",
+// runPipeline runs one date through the pipeline and reports the result
+func (h *AdminHandler) runPipeline(c *gin.Context, opts pipeline.Options) {
+	result, err := h.ingest.Run(c.Request.Context(), opts)
+	if errors.Is(err, pipeline.ErrLocked) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Date is already being ingested",
 			"details": err.Error(),
 		})
 		return
 	}
-
-	// Scrape Sporting Life results
-	slScraper := scraper.NewSportingLifeAPIV2()
-	rpRaces, err := slScraper.GetRacesForDate(req.Date)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to scrape Sporting Life",
+			"error":   "Ingestion failed",
 			"details": err.Error(),
+			"result":  result, // update_id/start_stage let the run be resumed
 		})
 		return
 	}
 
-	// Download and stitch Betfair data
-	bfStitcher := scraper.NewBetfairStitcher("/home/smonaghan/GiddyUp/data")
-	bfStitcher.StitchBetfairForDate(req.Date, "uk")
-	bfStitcher.StitchBetfairForDate(req.Date, "ire")
-
-	// Load stitched Betfair races
-	bfRacesUK, _ := bfStitcher.LoadStitchedRacesForDate(req.Date, "uk")
-	bfRacesIRE, _ := bfStitcher.LoadStitchedRacesForDate(req.Date, "ire")
-	bfRaces := append(bfRacesUK, bfRacesIRE...)
+	c.JSON(http.StatusOK, result)
+}
 
-	// Convert to BetfairPrice format for stitcher
-	bfPrices := h.convertStitchedToPrices(bfRaces)
+// IngestEntries fetches forward entries/declarations
+// POST /api/v1/admin/entries
+// Body: {"date": "2025-11-29"} for a single (e.g. long-range big-race) date,
+// or {"days": 5} for today plus the next N days
+func (h *AdminHandler) IngestEntries(c *gin.Context) {
+	var req struct {
+		Date string `json:"date"`
+		Days int    `json:"days"`
+	}
 
-	// Stitch data (match RP + BF)
-	stitcherInst := stitcher.New(rpRaces, bfPrices)
-	masterRaces, masterRunners, err := stitcherInst.StitchData()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to stitch data",
+	if err := c.ShouldBindJSON(&req); err != nil && err.Error() != "EOF" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
 			"details": err.Error(),
 		})
 		return
 	}
 
-	// Save master CSVs (skip database for now)
-	masterWriter := loader.NewMasterWriter("/home/smonaghan/GiddyUp/data")
-	err = masterWriter.SaveMasterData(req.Date, masterRaces, masterRunners)
+	entriesService := services.NewEntriesService(h.db, h.dataDir)
+
+	var races, entries int
+	var err error
+	if req.Date != "" {
+		if _, perr := time.Parse("2006-01-02", req.Date); perr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid date format, expected YYYY-MM-DD",
+			})
+			return
+		}
+		races, entries, err = entriesService.IngestDate(req.Date)
+	} else {
+		if req.Days <= 0 {
+			req.Days = 5
+		}
+		races, entries, err = entriesService.IngestAhead(req.Days)
+	}
+
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to save master CSVs",
+			"error":   "Failed to ingest entries",
 			"details": err.Error(),
+			"races":   races,
+			"entries": entries,
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"date":             req.Date,
-		"races_scraped":    len(rpRaces),
-		"betfair_stitched": len(bfRaces),
-		"races_matched":    len(masterRaces),
-		"runners_created":  len(masterRunners),
-		"master_csv_saved": true,
+		"date":    req.Date,
+		"days":    req.Days,
+		"races":   races,
+		"entries": entries,
 	})
 }
 
@@ -247,13 +258,16 @@ func (h *AdminHandler) GetUpdateStatus(c *gin.Context) {
 	query := `
 		SELECT 
 			update_date::text,
+			update_type,
 			status,
+			COALESCE(failed_stage, stage),
 			races_loaded,
 			runners_loaded,
-			completed_at::text
+			completed_at::text,
+			error_message
 		FROM racing.data_updates
-		WHERE update_type = 'daily'
-		ORDER BY update_date DESC
+		WHERE update_type IN ('daily', 'backfill')
+		ORDER BY update_date DESC, update_id DESC
 		LIMIT 10
 	`
 
@@ -268,18 +282,23 @@ func (h *AdminHandler) GetUpdateStatus(c *gin.Context) {
 
 	updates := []map[string]interface{}{}
 	for rows.Next() {
-		var date, status, completedAt sql.NullString
+		var date, kind, status, stage, completedAt, errMsg sql.NullString
 		var racesLoaded, runnersLoaded sql.NullInt64
 
-		rows.Scan(&date, &status, &racesLoaded, &runnersLoaded, &completedAt)
+		rows.Scan(&date, &kind, &status, &stage, &racesLoaded, &runnersLoaded, &completedAt, &errMsg)
 
 		update := map[string]interface{}{
 			"date":           date.String,
+			"kind":           kind.String,
 			"status":         status.String,
+			"stage":          stage.String, // Failed stage, or the one in progress
 			"races_loaded":   racesLoaded.Int64,
 			"runners_loaded": runnersLoaded.Int64,
 			"completed_at":   completedAt.String,
 		}
+		if errMsg.Valid {
+			update["error"] = errMsg.String
+		}
 		updates = append(updates, update)
 	}
 
@@ -295,7 +314,7 @@ func (h *AdminHandler) DetectGaps(c *gin.Context) {
 	query := `
 		SELECT DISTINCT update_date::text
 		FROM racing.data_updates
-		WHERE update_type = 'daily'
+		WHERE update_type IN ('daily', 'backfill')
 		  AND status = 'completed'
 		  AND update_date >= CURRENT_DATE - INTERVAL '30 days'
 		ORDER BY update_date
@@ -69,17 +69,17 @@ func (h *AngleHandler) GetNearMissPastCases(c *gin.Context) {
 	if c.Query("summary") == "" {
 		params.Summary = true
 	}
+	params.Limit = pageLimit(c, 200)
 
 	logger.Debug("NearMissPast: params=%+v", params)
 
 	result, err := h.repo.GetNearMissPastCases(params)
 	if err != nil {
 		logger.HandlerError("AngleHandler", "GetNearMissPastCases", err, 500)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "failed to get past cases",
-		})
+		pageError(c, err, "failed to get past cases")
 		return
 	}
+	result.Meta.Offset = params.Offset
 
 	logger.Debug("NearMissPast: found %d cases", len(result.Cases))
 	if result.Summary != nil {
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"giddyup/api/internal/auth"
+	"giddyup/api/internal/logger"
+	"giddyup/api/internal/middleware"
+	"giddyup/api/internal/models"
+	"giddyup/api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyHandler struct {
+	repo  *repository.APIKeyRepository
+	authn *auth.Authenticator
+}
+
+func NewAPIKeyHandler(repo *repository.APIKeyRepository, authn *auth.Authenticator) *APIKeyHandler {
+	return &APIKeyHandler{repo: repo, authn: authn}
+}
+
+// GetMe returns the authenticated caller with its role and scopes
+// GET /api/v1/auth/me
+func (h *APIKeyHandler) GetMe(c *gin.Context) {
+	c.JSON(http.StatusOK, middleware.CurrentPrincipal(c))
+}
+
+// GetRoles lists the roles and the scopes each may hold
+// GET /api/v1/admin/keys/roles
+func (h *APIKeyHandler) GetRoles(c *gin.Context) {
+	c.JSON(http.StatusOK, auth.Roles())
+}
+
+// GetKeys lists API keys (?revoked=true to include revoked keys)
+// GET /api/v1/admin/keys
+func (h *APIKeyHandler) GetKeys(c *gin.Context) {
+	var filters models.APIKeyFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	keys, err := h.repo.GetKeys(filters)
+	if err != nil {
+		logger.HandlerError("APIKeyHandler", "GetKeys", err, 500)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to get API keys",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// CreateKey creates an API key and returns it. The key is only shown here;
+// only its hash is stored.
+// POST /api/v1/admin/keys
+// Body: {"name": "backtester", "role": "analyst", "scopes": ["read"], "expires_in_days": 90}
+func (h *APIKeyHandler) CreateKey(c *gin.Context) {
+	var req models.APIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	createdBy := ""
+	if p := middleware.CurrentPrincipal(c); p != nil {
+		createdBy = p.Subject
+	}
+
+	key, err := h.repo.CreateKey(req, createdBy)
+	if errors.Is(err, repository.ErrInvalidScopes) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		logger.HandlerError("APIKeyHandler", "CreateKey", err, 500)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to create API key",
+		})
+		return
+	}
+
+	logger.Info("APIKeyHandler: key %d (%s, %s) created by %s", key.KeyID, key.Name, key.Role, createdBy)
+	c.JSON(http.StatusCreated, key)
+}
+
+// RevokeKey revokes an API key; it stops working immediately
+// DELETE /api/v1/admin/keys/:id
+func (h *APIKeyHandler) RevokeKey(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid key ID",
+		})
+		return
+	}
+
+	revoked, err := h.repo.RevokeKey(id)
+	if err != nil {
+		logger.HandlerError("APIKeyHandler", "RevokeKey", err, 500)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to revoke API key",
+		})
+		return
+	}
+	if !revoked {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "API key not found",
+		})
+		return
+	}
+	h.authn.Forget(id)
+
+	c.JSON(http.StatusOK, gin.H{"revoked": id})
+}
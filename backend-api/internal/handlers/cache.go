@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"giddyup/api/internal/cache"
+	"giddyup/api/internal/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CacheHandler struct {
+	cache *cache.Cache
+}
+
+func NewCacheHandler(c *cache.Cache) *CacheHandler {
+	return &CacheHandler{cache: c}
+}
+
+// Purge drops cached responses: those built from a race or date when
+// race_id or date is given, otherwise all of them
+// DELETE /api/v1/admin/cache?race_id=123&date=2024-01-01
+func (h *CacheHandler) Purge(c *gin.Context) {
+	var tags []string
+	if v := c.Query("race_id"); v != "" {
+		raceID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid race_id",
+			})
+			return
+		}
+		tags = append(tags, cache.RaceTag(raceID))
+	}
+	if v := c.Query("date"); v != "" {
+		if _, err := time.Parse("2006-01-02", v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid date format, use YYYY-MM-DD",
+			})
+			return
+		}
+		tags = append(tags, cache.DateTag(v))
+	}
+
+	if len(tags) > 0 {
+		h.cache.Invalidate(c.Request.Context(), tags...)
+		c.JSON(http.StatusOK, gin.H{"invalidated": tags})
+		return
+	}
+
+	if err := h.cache.Purge(c.Request.Context()); err != nil {
+		logger.HandlerError("CacheHandler", "Purge", err, 500)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to purge cache",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"invalidated": []string{"*"}})
+}
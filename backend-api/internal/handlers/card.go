@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"giddyup/api/internal/cache"
+	"giddyup/api/internal/logger"
+	"giddyup/api/internal/middleware"
+	"giddyup/api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetRaceCard returns a race's full racecard: each runner with its last 6
+// runs, days since it ran, course/distance/C&D wins, record on the going and
+// trip, trainer's 14-day form, jockey/trainer record and OR change
+// GET /api/v1/races/:id/card
+func (h *RaceHandler) GetRaceCard(c *gin.Context) {
+	raceID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid race ID",
+		})
+		return
+	}
+
+	card, err := h.repo.GetRaceCard(raceID)
+	if errors.Is(err, repository.ErrRaceNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "race not found",
+		})
+		return
+	}
+	if err != nil {
+		logger.HandlerError("RaceHandler", "GetRaceCard", err, 500)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to get racecard",
+		})
+		return
+	}
+
+	// The field and declarations change with the race; form with results
+	middleware.Cacheable(c, cache.RaceFreshness(card.Race.RaceDate, card.Race.Prelim),
+		cache.RaceTag(raceID), cache.DateTag(card.Race.RaceDate), cache.TagResults)
+	c.JSON(http.StatusOK, card)
+}
@@ -0,0 +1,336 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"giddyup/api/internal/logger"
+	"giddyup/api/internal/matching"
+	"giddyup/api/internal/models"
+	"giddyup/api/internal/repository"
+	"giddyup/api/internal/scraper"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CourseRegistryHandler struct {
+	repo *repository.CourseRegistryRepository
+}
+
+func NewCourseRegistryHandler(repo *repository.CourseRegistryRepository) *CourseRegistryHandler {
+	return &CourseRegistryHandler{repo: repo}
+}
+
+// GetCourse returns a registry course with its per-source aliases
+// GET /api/v1/admin/courses/:id
+func (h *CourseRegistryHandler) GetCourse(c *gin.Context) {
+	courseID, ok := parseCourseID(c, "id")
+	if !ok {
+		return
+	}
+
+	course, err := h.repo.GetCourse(courseID)
+	if errors.Is(err, repository.ErrCourseNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "course not found",
+		})
+		return
+	}
+	if err != nil {
+		logger.HandlerError("CourseRegistryHandler", "GetCourse", err, 500)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to get course",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, course)
+}
+
+// UpdateCourse changes a course's name, timezone or track characteristics
+// PATCH /api/v1/admin/courses/:id
+func (h *CourseRegistryHandler) UpdateCourse(c *gin.Context) {
+	courseID, ok := parseCourseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.CourseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if !validDirection(c, req.Direction) {
+		return
+	}
+
+	course, err := h.repo.UpdateCourse(courseID, req)
+	if errors.Is(err, repository.ErrCourseNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "course not found",
+		})
+		return
+	}
+	if err != nil {
+		logger.HandlerError("CourseRegistryHandler", "UpdateCourse", err, 500)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to update course",
+		})
+		return
+	}
+
+	registerCourse(course)
+	c.JSON(http.StatusOK, course)
+}
+
+// AddAlias records a source's name for a course
+// POST /api/v1/admin/courses/:id/aliases
+func (h *CourseRegistryHandler) AddAlias(c *gin.Context) {
+	courseID, ok := parseCourseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.CourseAliasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if !validCourseSource(req.Source) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "source must be one of sportinglife, betfair, master, manual",
+		})
+		return
+	}
+
+	result, err := h.repo.AddAlias(courseID, req)
+	if errors.Is(err, repository.ErrCourseNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "course not found",
+		})
+		return
+	}
+	if err != nil {
+		logger.HandlerError("CourseRegistryHandler", "AddAlias", err, 500)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to add course alias",
+		})
+		return
+	}
+
+	registerCourse(&result.Course)
+	c.JSON(http.StatusCreated, result)
+}
+
+// DeleteAlias removes a course alias
+// DELETE /api/v1/admin/courses/aliases/:alias_id
+func (h *CourseRegistryHandler) DeleteAlias(c *gin.Context) {
+	aliasID, ok := parseCourseID(c, "alias_id")
+	if !ok {
+		return
+	}
+
+	deleted, err := h.repo.DeleteAlias(aliasID)
+	if err != nil {
+		logger.HandlerError("CourseRegistryHandler", "DeleteAlias", err, 500)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to delete course alias",
+		})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "course alias not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deleted": aliasID})
+}
+
+// GetReviewQueue lists course names loaders couldn't resolve
+// GET /api/v1/admin/courses/review
+func (h *CourseRegistryHandler) GetReviewQueue(c *gin.Context) {
+	var filters models.CourseReviewFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	reviews, err := h.repo.GetReviewQueue(filters)
+	if err != nil {
+		logger.HandlerError("CourseRegistryHandler", "GetReviewQueue", err, 500)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to get course review queue",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, reviews)
+}
+
+// ResolveReview maps a queued course name to an existing or new course
+// POST /api/v1/admin/courses/review/:id/resolve
+func (h *CourseRegistryHandler) ResolveReview(c *gin.Context) {
+	reviewID, ok := parseCourseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.CourseResolveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if (req.CourseID == nil) == (req.Course == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "exactly one of course_id or course is required",
+		})
+		return
+	}
+	if req.Course != nil {
+		if !validDirection(c, req.Course.Direction) {
+			return
+		}
+	}
+
+	result, err := h.repo.ResolveReview(reviewID, withDefaultTimezone(req))
+	switch {
+	case errors.Is(err, repository.ErrReviewNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "course review not found",
+		})
+		return
+	case errors.Is(err, repository.ErrCourseNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "course not found",
+		})
+		return
+	case errors.Is(err, repository.ErrReviewClosed):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "course review already resolved",
+		})
+		return
+	case err != nil:
+		logger.HandlerError("CourseRegistryHandler", "ResolveReview", err, 500)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to resolve course review",
+		})
+		return
+	}
+
+	registerCourse(&result.Course)
+	c.JSON(http.StatusOK, result)
+}
+
+// IgnoreReview marks a queued course name as not worth registering
+// POST /api/v1/admin/courses/review/:id/ignore
+func (h *CourseRegistryHandler) IgnoreReview(c *gin.Context) {
+	reviewID, ok := parseCourseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Note string `json:"note"`
+	}
+	_ = c.ShouldBindJSON(&req)
+
+	review, err := h.repo.IgnoreReview(reviewID, req.Note)
+	switch {
+	case errors.Is(err, repository.ErrReviewNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "course review not found",
+		})
+		return
+	case errors.Is(err, repository.ErrReviewClosed):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "course review already resolved",
+		})
+		return
+	case err != nil:
+		logger.HandlerError("CourseRegistryHandler", "IgnoreReview", err, 500)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to ignore course review",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+// GetOrphans lists course_ids referenced by races but missing from the registry
+// GET /api/v1/admin/courses/orphans
+func (h *CourseRegistryHandler) GetOrphans(c *gin.Context) {
+	orphans, err := h.repo.GetOrphans()
+	if err != nil {
+		logger.HandlerError("CourseRegistryHandler", "GetOrphans", err, 500)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to get orphaned courses",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, orphans)
+}
+
+// registerCourse makes a course's aliases and timezone effective in this process
+// straight away (other processes pick them up when they next load the registry)
+func registerCourse(course *models.CourseDetail) {
+	for _, alias := range course.Aliases {
+		matching.Courses().AddAlias(course.CourseName, alias.Alias)
+	}
+	if course.Timezone != nil {
+		matching.Courses().SetTimezone(course.Region, course.CourseName, *course.Timezone)
+	}
+}
+
+// withDefaultTimezone fills a new course's timezone from its jurisdiction
+func withDefaultTimezone(req models.CourseResolveRequest) models.CourseResolveRequest {
+	if req.Course != nil && req.Course.Timezone == "" && req.Course.Region != "" {
+		course := *req.Course
+		course.Timezone = scraper.CourseTimezone(course.Region, course.CourseName)
+		req.Course = &course
+	}
+	return req
+}
+
+func validCourseSource(source string) bool {
+	for _, s := range matching.CourseSources {
+		if source == s {
+			return true
+		}
+	}
+	return false
+}
+
+func validDirection(c *gin.Context, direction string) bool {
+	switch direction {
+	case "", "left", "right", "straight", "both":
+		return true
+	}
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error": "direction must be one of left, right, straight, both",
+	})
+	return false
+}
+
+func parseCourseID(c *gin.Context, param string) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param(param), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid " + param,
+		})
+		return 0, false
+	}
+	return id, true
+}
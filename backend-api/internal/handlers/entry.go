@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"giddyup/api/internal/logger"
+	"giddyup/api/internal/models"
+	"giddyup/api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EntryHandler struct {
+	repo *repository.EntryRepository
+}
+
+func NewEntryHandler(repo *repository.EntryRepository) *EntryHandler {
+	return &EntryHandler{repo: repo}
+}
+
+// GetEntries returns forward entries filtered by horse, trainer, race, status or date
+// GET /api/v1/entries
+func (h *EntryHandler) GetEntries(c *gin.Context) {
+	var filters models.EntryFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		logger.Warn("GetEntries: invalid filters: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	h.respond(c, "GetEntries", filters)
+}
+
+// GetHorseEntries returns upcoming entries for a horse
+// GET /api/v1/horses/:id/entries
+func (h *EntryHandler) GetHorseEntries(c *gin.Context) {
+	h.byID(c, "GetHorseEntries", "horse", func(f *models.EntryFilters, id int64) { f.HorseID = &id })
+}
+
+// GetTrainerEntries returns upcoming entries for a trainer
+// GET /api/v1/trainers/:id/entries
+func (h *EntryHandler) GetTrainerEntries(c *gin.Context) {
+	h.byID(c, "GetTrainerEntries", "trainer", func(f *models.EntryFilters, id int64) { f.TrainerID = &id })
+}
+
+// GetRaceEntries returns all entries for an entry race (Sporting Life race ID)
+// GET /api/v1/entries/races/:id
+func (h *EntryHandler) GetRaceEntries(c *gin.Context) {
+	h.byID(c, "GetRaceEntries", "race", func(f *models.EntryFilters, id int64) { f.EntryRaceID = &id })
+}
+
+// byID binds the common filters, applies the path ID and responds
+func (h *EntryHandler) byID(c *gin.Context, endpoint, entity string, apply func(*models.EntryFilters, int64)) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		logger.Warn("%s: invalid %s ID: %v", endpoint, entity, err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid " + entity + " ID",
+		})
+		return
+	}
+
+	var filters models.EntryFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		logger.Warn("%s: invalid filters: %v", endpoint, err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	apply(&filters, id)
+
+	h.respond(c, endpoint, filters)
+}
+
+func (h *EntryHandler) respond(c *gin.Context, endpoint string, filters models.EntryFilters) {
+	filters.Limit = pageLimit(c, 200)
+	logger.Debug("%s: filters=%+v", endpoint, filters)
+
+	entries, err := h.repo.GetEntries(filters)
+	if err != nil {
+		logger.HandlerError("EntryHandler", endpoint, err, 500)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to get entries",
+		})
+		return
+	}
+
+	logger.Debug("%s: found %d entries", endpoint, len(entries))
+	c.JSON(http.StatusOK, entries)
+}
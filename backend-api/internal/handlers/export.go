@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"giddyup/api/internal/auth"
+	"giddyup/api/internal/config"
+	"giddyup/api/internal/export"
+	"giddyup/api/internal/logger"
+	"giddyup/api/internal/middleware"
+	"giddyup/api/internal/models"
+	"giddyup/api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExportHandler struct {
+	repo   *repository.ExportRepository
+	runner *export.Runner // nil when another instance runs the jobs
+	cfg    config.ExportConfig
+}
+
+func NewExportHandler(repo *repository.ExportRepository, runner *export.Runner, cfg config.ExportConfig) *ExportHandler {
+	return &ExportHandler{repo: repo, runner: runner, cfg: cfg}
+}
+
+// GetColumns lists the columns of each dataset and which are exported by default
+// GET /api/v1/export/columns
+func (h *ExportHandler) GetColumns(c *gin.Context) {
+	c.JSON(http.StatusOK, repository.ExportDatasets())
+}
+
+// ExportRaces streams races matching the race search filters
+// GET /api/v1/export/races?date_from=2024-05-01&date_to=2024-05-31&format=csv
+func (h *ExportHandler) ExportRaces(c *gin.Context) {
+	h.stream(c, "races", "ExportRaces")
+}
+
+// ExportRunners streams the runners of races matching the race search filters
+// GET /api/v1/export/runners?date_from=2024-05-01&date_to=2024-05-31&format=parquet&columns=race_id,horse_name,pos_num,win_bsp
+func (h *ExportHandler) ExportRunners(c *gin.Context) {
+	h.stream(c, "runners", "ExportRunners")
+}
+
+// stream writes an export as it is read. The status and headers go out with
+// the first row, so a failure part way through is reported in the
+// X-Export-Error trailer (the row count is in X-Export-Rows).
+func (h *ExportHandler) stream(c *gin.Context, dataset, endpoint string) {
+	var params models.ExportParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	from, to, ok := h.syncRange(c, params.RaceFilterSet)
+	if !ok {
+		return
+	}
+	cols, err := repository.ExportColumns(dataset, splitColumns(params.Columns))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	format := export.Extension(params.Format)
+	filename := fmt.Sprintf("%s-%s-%s.%s", dataset, from, to, format)
+	c.Header("Content-Type", export.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("Trailer", "X-Export-Rows, X-Export-Error")
+	// The stream can outlast the server's write timeout
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		logger.Warn("ExportHandler: %s: write deadline not lifted: %v", endpoint, err)
+	}
+	c.Status(http.StatusOK)
+
+	w, err := export.NewWriter(format, c.Writer, cols)
+	if err == nil {
+		var rows int64
+		rows, err = h.repo.Stream(c.Request.Context(), dataset, cols, params.RaceFilterSet, 0, w.Write)
+		if err == nil {
+			err = w.Close()
+		}
+		c.Writer.Header().Set("X-Export-Rows", strconv.FormatInt(rows, 10))
+		c.Set(middleware.RowsKey, int(rows))
+	}
+	if err != nil {
+		logger.HandlerError("ExportHandler", endpoint, err, 500)
+		c.Writer.Header().Set("X-Export-Error", "export failed, the file is incomplete")
+	}
+}
+
+// syncRange requires date_from and date_to no more than SyncMaxDays apart
+// for a streamed export, writing a 400 pointing at jobs otherwise
+func (h *ExportHandler) syncRange(c *gin.Context, f models.RaceFilterSet) (string, string, bool) {
+	if f.DateFrom == nil || f.DateTo == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "date_from and date_to are required, use POST /api/v1/export/jobs for larger exports",
+		})
+		return "", "", false
+	}
+	from, _ := time.Parse("2006-01-02", *f.DateFrom)
+	to, _ := time.Parse("2006-01-02", *f.DateTo)
+	days := int(to.Sub(from).Hours()/24) + 1
+	if days < 1 || days > h.cfg.SyncMaxDays {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":    fmt.Sprintf("date_from must be on or before date_to, at most %d days apart; use POST /api/v1/export/jobs for larger exports", h.cfg.SyncMaxDays),
+			"max_days": h.cfg.SyncMaxDays,
+		})
+		return "", "", false
+	}
+	return *f.DateFrom, *f.DateTo, true
+}
+
+func splitColumns(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+// CreateJob queues an export of any size; poll the job until it is completed,
+// then download it
+// POST /api/v1/export/jobs
+func (h *ExportHandler) CreateJob(c *gin.Context) {
+	var req models.ExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	cols, err := repository.ExportColumns(req.Dataset, req.Columns)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	job := &models.ExportJob{
+		Client:  middleware.ClientID(c),
+		Dataset: req.Dataset,
+		Format:  export.Extension(req.Format),
+		Filters: req.Filters,
+	}
+	for _, col := range cols {
+		job.Columns = append(job.Columns, col.Name)
+	}
+	if p := middleware.CurrentPrincipal(c); p != nil && p.KeyID != 0 {
+		job.KeyID = &p.KeyID
+	}
+
+	if err := h.repo.CreateJob(job); err != nil {
+		logger.HandlerError("ExportHandler", "CreateJob", err, 500)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to queue export",
+		})
+		return
+	}
+	if h.runner != nil {
+		h.runner.Wake()
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/v1/export/jobs/%d", job.JobID))
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetJobs returns the caller's latest export jobs
+// GET /api/v1/export/jobs
+func (h *ExportHandler) GetJobs(c *gin.Context) {
+	limit := pageLimit(c, 50)
+	jobs, err := h.repo.ListJobs(middleware.ClientID(c), limit)
+	if err != nil {
+		logger.HandlerError("ExportHandler", "GetJobs", err, 500)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to list export jobs",
+		})
+		return
+	}
+	for i := range jobs {
+		setDownloadURL(&jobs[i])
+	}
+
+	c.JSON(http.StatusOK, models.NewPage(jobs, limit, ""))
+}
+
+// GetJob returns an export job's status (download_url once it is completed)
+// GET /api/v1/export/jobs/:id
+func (h *ExportHandler) GetJob(c *gin.Context) {
+	job, ok := h.findJob(c, "GetJob")
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// DownloadJob returns a completed export's file
+// GET /api/v1/export/jobs/:id/download
+func (h *ExportHandler) DownloadJob(c *gin.Context) {
+	job, ok := h.findJob(c, "DownloadJob")
+	if !ok {
+		return
+	}
+
+	switch job.Status {
+	case models.ExportCompleted:
+	case models.ExportExpired:
+		c.JSON(http.StatusGone, gin.H{
+			"error": "export has expired, queue it again",
+		})
+		return
+	default:
+		c.JSON(http.StatusConflict, gin.H{
+			"error":  "export is not completed",
+			"status": job.Status,
+		})
+		return
+	}
+	if job.FilePath == nil {
+		c.JSON(http.StatusGone, gin.H{
+			"error": "export file is no longer available",
+		})
+		return
+	}
+	if _, err := os.Stat(*job.FilePath); err != nil {
+		logger.HandlerError("ExportHandler", "DownloadJob", err, 410)
+		c.JSON(http.StatusGone, gin.H{
+			"error": "export file is no longer available",
+		})
+		return
+	}
+
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		logger.Warn("ExportHandler: DownloadJob: write deadline not lifted: %v", err)
+	}
+	c.Header("Content-Type", export.ContentType(job.Format))
+	c.Set(middleware.RowsKey, int(job.Rows))
+	c.FileAttachment(*job.FilePath, fmt.Sprintf("%s-export-%d.%s", job.Dataset, job.JobID, job.Format))
+}
+
+// findJob loads the :id job, answering 404 unless it belongs to the caller
+// (admins see every job)
+func (h *ExportHandler) findJob(c *gin.Context, endpoint string) (*models.ExportJob, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid job ID",
+		})
+		return nil, false
+	}
+
+	job, err := h.repo.GetJob(id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		logger.HandlerError("ExportHandler", endpoint, err, 500)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to get export job",
+		})
+		return nil, false
+	}
+	p := middleware.CurrentPrincipal(c)
+	if job == nil || (job.Client != middleware.ClientID(c) && (p == nil || !p.HasScope(auth.ScopeAdmin))) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "export job not found",
+		})
+		return nil, false
+	}
+
+	setDownloadURL(job)
+	return job, true
+}
+
+func setDownloadURL(job *models.ExportJob) {
+	if job.Status == models.ExportCompleted {
+		job.DownloadURL = fmt.Sprintf("/api/v1/export/jobs/%d/download", job.JobID)
+	}
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"giddyup/api/internal/cache"
+	"giddyup/api/internal/logger"
+	"giddyup/api/internal/middleware"
+	"giddyup/api/internal/models"
+	"giddyup/api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxCompareHorses caps /horses/compare (pairs grow with the square)
+const maxCompareHorses = 10
+
+type H2HHandler struct {
+	repo *repository.H2HRepository
+}
+
+func NewH2HHandler(repo *repository.H2HRepository) *H2HHandler {
+	return &H2HHandler{repo: repo}
+}
+
+// CompareHorses compares horses pair by pair: their direct meetings and
+// collateral form through common opponents, with weight-adjusted margins
+// GET /api/v1/horses/compare?ids=101,202,303&date_from=2023-01-01&opponents=10
+func (h *H2HHandler) CompareHorses(c *gin.Context) {
+	var params models.HorseCompareParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	ids, ok := horseIDs(c, params.IDs)
+	if !ok {
+		return
+	}
+	if !h2hParams(c, &params.H2HParams) {
+		return
+	}
+
+	comparison, err := h.repo.CompareHorses(ids, params.H2HParams)
+	if errors.Is(err, repository.ErrHorseNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		logger.HandlerError("H2HHandler", "CompareHorses", err, 500)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to compare horses",
+		})
+		return
+	}
+
+	middleware.Cacheable(c, cache.Stats, cache.TagResults)
+	c.JSON(http.StatusOK, comparison)
+}
+
+// GetRaceH2H compares every pair in a race's field on their form before it
+// GET /api/v1/races/:id/h2h?date_from=2023-01-01&opponents=5
+func (h *H2HHandler) GetRaceH2H(c *gin.Context) {
+	raceID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid race ID",
+		})
+		return
+	}
+	var params models.H2HParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if !h2hParams(c, &params) {
+		return
+	}
+
+	comparison, err := h.repo.GetRaceH2H(raceID, params)
+	if errors.Is(err, repository.ErrRaceNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "race not found",
+		})
+		return
+	}
+	if err != nil {
+		logger.HandlerError("H2HHandler", "GetRaceH2H", err, 500)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to compare race field",
+		})
+		return
+	}
+
+	// The field changes with non-runners; form with results
+	middleware.Cacheable(c, cache.Stats, cache.RaceTag(raceID), cache.TagResults)
+	c.JSON(http.StatusOK, comparison)
+}
+
+// horseIDs parses 2 to maxCompareHorses distinct comma-separated IDs,
+// answering 400 otherwise
+func horseIDs(c *gin.Context, s string) ([]int64, bool) {
+	var ids []int64
+	seen := make(map[int64]bool)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil || id <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid horse ID " + strconv.Quote(part),
+				"field": "ids",
+			})
+			return nil, false
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) < 2 || len(ids) > maxCompareHorses {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ids must name 2 to " + strconv.Itoa(maxCompareHorses) + " different horses",
+			"field": "ids",
+		})
+		return nil, false
+	}
+	return ids, true
+}
+
+// h2hParams defaults and caps the opponents limit
+func h2hParams(c *gin.Context, params *models.H2HParams) bool {
+	switch {
+	case params.Opponents == 0:
+		params.Opponents = 10
+	case params.Opponents < 0 || params.Opponents > 50:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "opponents must be between 1 and 50",
+			"field": "opponents",
+		})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"giddyup/api/internal/logger"
+	"giddyup/api/internal/models"
+	"giddyup/api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HorseIdentityHandler struct {
+	repo *repository.HorseIdentityRepository
+}
+
+func NewHorseIdentityHandler(repo *repository.HorseIdentityRepository) *HorseIdentityHandler {
+	return &HorseIdentityHandler{repo: repo}
+}
+
+// GetIdentity returns a horse's identity attributes, aliases, runs per foaling year and log
+// GET /api/v1/admin/horses/:id/identity
+func (h *HorseIdentityHandler) GetIdentity(c *gin.Context) {
+	horseID, ok := parseHorseID(c)
+	if !ok {
+		return
+	}
+
+	identity, err := h.repo.GetIdentity(horseID)
+	if errors.Is(err, repository.ErrHorseNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "horse not found",
+		})
+		return
+	}
+	if err != nil {
+		logger.HandlerError("HorseIdentityHandler", "GetIdentity", err, 500)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to get horse identity",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, identity)
+}
+
+// GetConflicts lists horses whose runs imply more than one foaling year
+// GET /api/v1/admin/horses/conflicts
+func (h *HorseIdentityHandler) GetConflicts(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	conflicts, err := h.repo.GetConflicts(limit, offset)
+	if err != nil {
+		logger.HandlerError("HorseIdentityHandler", "GetConflicts", err, 500)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to get horse identity conflicts",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, conflicts)
+}
+
+// GetLog returns the horse identity audit log
+// GET /api/v1/admin/horses/identity-log
+func (h *HorseIdentityHandler) GetLog(c *gin.Context) {
+	var filters models.HorseIdentityLogFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	entries, err := h.repo.GetLog(filters)
+	if err != nil {
+		logger.HandlerError("HorseIdentityHandler", "GetLog", err, 500)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to get horse identity log",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// Merge merges two records of the same horse
+// POST /api/v1/admin/horses/merge
+func (h *HorseIdentityHandler) Merge(c *gin.Context) {
+	var req models.HorseMergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if req.SourceID == req.TargetID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "source_id and target_id must differ",
+		})
+		return
+	}
+
+	identity, err := h.repo.Merge(req)
+	if errors.Is(err, repository.ErrHorseNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "horse not found",
+		})
+		return
+	}
+	if err != nil {
+		logger.HandlerError("HorseIdentityHandler", "Merge", err, 500)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to merge horses",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, identity)
+}
+
+// Split moves runs of a conflated horse to a new horse
+// POST /api/v1/admin/horses/:id/split
+func (h *HorseIdentityHandler) Split(c *gin.Context) {
+	horseID, ok := parseHorseID(c)
+	if !ok {
+		return
+	}
+
+	var req models.HorseSplitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if len(req.RunnerIDs) == 0 && req.FoaledYear == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "runner_ids or foaled_year is required",
+		})
+		return
+	}
+
+	identity, err := h.repo.Split(horseID, req)
+	switch {
+	case errors.Is(err, repository.ErrHorseNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "horse not found",
+		})
+		return
+	case errors.Is(err, repository.ErrNothingToSplit):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "no runs of this horse match the selection",
+		})
+		return
+	case err != nil:
+		logger.HandlerError("HorseIdentityHandler", "Split", err, 500)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to split horse",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, identity)
+}
+
+func parseHorseID(c *gin.Context) (int64, bool) {
+	horseID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid horse ID",
+		})
+		return 0, false
+	}
+	return horseID, true
+}
@@ -5,7 +5,10 @@ import (
 	"strconv"
 	"time"
 
+	"giddyup/api/internal/cache"
 	"giddyup/api/internal/logger"
+	"giddyup/api/internal/middleware"
+	"giddyup/api/internal/models"
 	"giddyup/api/internal/repository"
 
 	"github.com/gin-gonic/gin"
@@ -19,8 +22,8 @@ func NewMarketHandler(repo *repository.MarketRepository) *MarketHandler {
 	return &MarketHandler{repo: repo}
 }
 
-// GetMarketMovers returns steamers and drifters
-// GET /api/v1/market/movers?date=2024-01-13&min_move=20&type=steamer
+// GetMarketMovers returns steamers and drifters, biggest move first, one page at a time
+// GET /api/v1/market/movers?date=2024-01-13&min_move=20&type=steamer&limit=100&cursor=<meta.next_cursor>
 func (h *MarketHandler) GetMarketMovers(c *gin.Context) {
 	date := c.DefaultQuery("date", time.Now().Format("2006-01-02"))
 	minMove := 20.0
@@ -30,20 +33,19 @@ func (h *MarketHandler) GetMarketMovers(c *gin.Context) {
 		}
 	}
 	moveType := c.DefaultQuery("type", "all")
+	limit := pageLimit(c, 100)
 
-	logger.Debug("GetMarketMovers: date=%s, minMove=%.1f, type=%s", date, minMove, moveType)
+	logger.Debug("GetMarketMovers: date=%s, minMove=%.1f, type=%s, limit=%d", date, minMove, moveType, limit)
 
-	movers, err := h.repo.GetMarketMovers(date, minMove, moveType)
+	movers, next, err := h.repo.GetMarketMovers(date, minMove, moveType, limit, c.Query("cursor"))
 	if err != nil {
 		logger.HandlerError("MarketHandler", "GetMarketMovers", err, 500)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "failed to get market movers",
-		})
+		pageError(c, err, "failed to get market movers")
 		return
 	}
 
 	logger.Debug("GetMarketMovers: found %d movers", len(movers))
-	c.JSON(http.StatusOK, movers)
+	c.JSON(http.StatusOK, models.NewPage(movers, limit, next))
 }
 
 // GetWinCalibration returns win market calibration
@@ -60,6 +62,7 @@ func (h *MarketHandler) GetWinCalibration(c *gin.Context) {
 		return
 	}
 
+	middleware.Cacheable(c, cache.Stats, cache.TagResults)
 	c.JSON(http.StatusOK, calibration)
 }
 
@@ -77,6 +80,7 @@ func (h *MarketHandler) GetPlaceCalibration(c *gin.Context) {
 		return
 	}
 
+	middleware.Cacheable(c, cache.Stats, cache.TagResults)
 	c.JSON(http.StatusOK, calibration)
 }
 
@@ -103,12 +107,33 @@ func (h *MarketHandler) GetInPlayMoves(c *gin.Context) {
 	c.JSON(http.StatusOK, moves)
 }
 
-// GetBookVsExchange compares SP vs BSP
+// GetBookVsExchange compares SP vs BSP, or each bookmaker vs the exchange with by=bookmaker
 // GET /api/v1/market/book-vs-exchange?date_from=2024-01-01&date_to=2024-12-31
+// GET /api/v1/market/book-vs-exchange?by=bookmaker&bookmaker=Bet365&max_lag_secs=300
 func (h *MarketHandler) GetBookVsExchange(c *gin.Context) {
 	dateFrom := c.DefaultQuery("date_from", time.Now().AddDate(0, -1, 0).Format("2006-01-02"))
 	dateTo := c.DefaultQuery("date_to", time.Now().Format("2006-01-02"))
 
+	if c.Query("by") == "bookmaker" {
+		var bookmaker *string
+		if b := c.Query("bookmaker"); b != "" {
+			bookmaker = &b
+		}
+		maxLag, _ := strconv.Atoi(c.DefaultQuery("max_lag_secs", "300"))
+
+		comparison, err := h.repo.GetBookmakerVsExchange(dateFrom, dateTo, bookmaker, maxLag)
+		if err != nil {
+			logger.HandlerError("MarketHandler", "GetBookVsExchange", err, 500)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "failed to get bookmaker vs exchange comparison",
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, comparison)
+		return
+	}
+
 	comparison, err := h.repo.GetBookVsExchange(dateFrom, dateTo)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"giddyup/api/internal/logger"
+	"giddyup/api/internal/matching"
+	"giddyup/api/internal/models"
+	"giddyup/api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MatchingHandler struct {
+	repo *repository.MatchingRepository
+}
+
+func NewMatchingHandler(repo *repository.MatchingRepository) *MatchingHandler {
+	return &MatchingHandler{repo: repo}
+}
+
+// GetOverrides lists manual match overrides (optionally ?date=YYYY-MM-DD)
+// GET /api/v1/admin/matching/overrides
+func (h *MatchingHandler) GetOverrides(c *gin.Context) {
+	var date *string
+	if d := c.Query("date"); d != "" {
+		date = &d
+	}
+
+	overrides, err := h.repo.GetOverrides(date)
+	if err != nil {
+		logger.HandlerError("MatchingHandler", "GetOverrides", err, 500)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to get match overrides",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, overrides)
+}
+
+// CreateOverride pins or forbids a race ↔ market or horse ↔ selection pairing
+// POST /api/v1/admin/matching/overrides
+func (h *MatchingHandler) CreateOverride(c *gin.Context) {
+	var req models.MatchOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if req.RaceID == nil && req.RaceKey == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "race_id or race_key is required",
+		})
+		return
+	}
+	if req.Level == matching.LevelRunner && matching.NormalizeName(req.Horse) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "horse is required for runner overrides",
+		})
+		return
+	}
+
+	override, err := h.repo.CreateOverride(req)
+	if errors.Is(err, repository.ErrRaceNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "race not found",
+		})
+		return
+	}
+	if err != nil {
+		logger.HandlerError("MatchingHandler", "CreateOverride", err, 500)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to create match override",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, override)
+}
+
+// DeleteOverride removes a manual match override
+// DELETE /api/v1/admin/matching/overrides/:id
+func (h *MatchingHandler) DeleteOverride(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid override ID",
+		})
+		return
+	}
+
+	deleted, err := h.repo.DeleteOverride(id)
+	if err != nil {
+		logger.HandlerError("MatchingHandler", "DeleteOverride", err, 500)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to delete match override",
+		})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "override not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deleted": id})
+}
+
+// GetAudit returns the match audit trail filtered by date, race_key, outcome, level or pipeline
+// GET /api/v1/admin/matching/audit
+func (h *MatchingHandler) GetAudit(c *gin.Context) {
+	var filters models.MatchAuditFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	audit, err := h.repo.GetAudit(filters)
+	if err != nil {
+		logger.HandlerError("MatchingHandler", "GetAudit", err, 500)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to get match audit",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, audit)
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"giddyup/api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pageLimit returns the page size ValidatePagination settled on (1-1000), or
+// def when the request has no limit
+func pageLimit(c *gin.Context, def int) int {
+	if limit := c.GetInt("validated_limit"); limit > 0 {
+		return limit
+	}
+	return def
+}
+
+// pageError answers a failed page fetch: 400 for a bad cursor, otherwise 500
+// with message
+func pageError(c *gin.Context, err error, message string) {
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
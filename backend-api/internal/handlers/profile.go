@@ -4,7 +4,10 @@ import (
 	"net/http"
 	"strconv"
 
+	"giddyup/api/internal/cache"
 	"giddyup/api/internal/logger"
+	"giddyup/api/internal/middleware"
+	"giddyup/api/internal/models"
 	"giddyup/api/internal/repository"
 
 	"github.com/gin-gonic/gin"
@@ -42,6 +45,7 @@ func (h *ProfileHandler) GetHorseProfile(c *gin.Context) {
 	}
 
 	logger.Debug("GetHorseProfile: loaded profile for %s (%d runs)", profile.Horse.HorseName, profile.CareerSummary.Runs)
+	middleware.Cacheable(c, cache.Stats, cache.TagResults)
 	c.JSON(http.StatusOK, profile)
 }
 
@@ -69,6 +73,7 @@ func (h *ProfileHandler) GetTrainerProfile(c *gin.Context) {
 	}
 
 	logger.Debug("GetTrainerProfile: loaded profile for %s", profile.Trainer.TrainerName)
+	middleware.Cacheable(c, cache.Stats, cache.TagResults)
 	c.JSON(http.StatusOK, profile)
 }
 
@@ -96,5 +101,48 @@ func (h *ProfileHandler) GetJockeyProfile(c *gin.Context) {
 	}
 
 	logger.Debug("GetJockeyProfile: loaded profile for %s", profile.Jockey.JockeyName)
+	middleware.Cacheable(c, cache.Stats, cache.TagResults)
 	c.JSON(http.StatusOK, profile)
 }
+
+// GetHorseRuns returns a horse's runs, latest first, one page at a time
+// GET /api/v1/horses/:id/runs?limit=50&cursor=<meta.next_cursor>
+func (h *ProfileHandler) GetHorseRuns(c *gin.Context) {
+	h.getRuns(c, "horse", "GetHorseRuns")
+}
+
+// GetTrainerRuns returns a trainer's runners, latest first, one page at a time
+// GET /api/v1/trainers/:id/runs?limit=50&cursor=<meta.next_cursor>
+func (h *ProfileHandler) GetTrainerRuns(c *gin.Context) {
+	h.getRuns(c, "trainer", "GetTrainerRuns")
+}
+
+// GetJockeyRuns returns a jockey's rides, latest first, one page at a time
+// GET /api/v1/jockeys/:id/runs?limit=50&cursor=<meta.next_cursor>
+func (h *ProfileHandler) GetJockeyRuns(c *gin.Context) {
+	h.getRuns(c, "jockey", "GetJockeyRuns")
+}
+
+func (h *ProfileHandler) getRuns(c *gin.Context, entity, method string) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		logger.Warn("%s: invalid %s ID: %v", method, entity, err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid " + entity + " ID",
+		})
+		return
+	}
+	limit := pageLimit(c, 50)
+
+	logger.Debug("%s: %s_id=%d, limit=%d", method, entity, id, limit)
+
+	runs, next, err := h.repo.GetRuns(entity, id, limit, c.Query("cursor"))
+	if err != nil {
+		logger.HandlerError("ProfileHandler", method, err, 500)
+		pageError(c, err, "failed to get "+entity+" runs")
+		return
+	}
+
+	logger.Debug("%s: found %d runs", method, len(runs))
+	c.JSON(http.StatusOK, models.NewPage(runs, limit, next))
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"giddyup/api/internal/logger"
+	"giddyup/api/internal/models"
+	"giddyup/api/internal/quality"
+	"giddyup/api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// qualityMaxDays caps the date range of the quality summary
+const qualityMaxDays = 366
+
+type QualityHandler struct {
+	repo *repository.QualityRepository
+}
+
+func NewQualityHandler(repo *repository.QualityRepository) *QualityHandler {
+	return &QualityHandler{repo: repo}
+}
+
+// GetSummary returns per-date load counts and open data-quality issues
+// GET /api/v1/admin/quality
+func (h *QualityHandler) GetSummary(c *gin.Context) {
+	var filters models.QualityFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	to := time.Now()
+	if filters.DateTo != nil {
+		to, _ = time.Parse("2006-01-02", *filters.DateTo)
+	}
+	from := to.AddDate(0, 0, -13)
+	if filters.DateFrom != nil {
+		from, _ = time.Parse("2006-01-02", *filters.DateFrom)
+	}
+	if from.After(to) || to.Sub(from) > qualityMaxDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "date_from must be on or before date_to, at most 366 days apart",
+		})
+		return
+	}
+
+	days, err := h.repo.GetDays(from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		logger.HandlerError("QualityHandler", "GetSummary", err, 500)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to get quality summary",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, days)
+}
+
+// GetDay returns a date's quality summary with its issues by rule and the issues
+// GET /api/v1/admin/quality/:date
+func (h *QualityHandler) GetDay(c *gin.Context) {
+	date := c.Param("date")
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid date format, expected YYYY-MM-DD",
+		})
+		return
+	}
+
+	var filters models.QualityIssueFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	detail, err := h.repo.GetDay(date, filters)
+	if err != nil {
+		logger.HandlerError("QualityHandler", "GetDay", err, 500)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to get quality issues",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, detail)
+}
+
+// GetRules lists the data-quality rules loaders check
+// GET /api/v1/admin/quality/rules
+func (h *QualityHandler) GetRules(c *gin.Context) {
+	c.JSON(http.StatusOK, quality.Rules)
+}
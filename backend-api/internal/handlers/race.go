@@ -5,7 +5,9 @@ import (
 	"strconv"
 	"time"
 
+	"giddyup/api/internal/cache"
 	"giddyup/api/internal/logger"
+	"giddyup/api/internal/middleware"
 	"giddyup/api/internal/models"
 	"giddyup/api/internal/repository"
 
@@ -20,8 +22,9 @@ func NewRaceHandler(repo *repository.RaceRepository) *RaceHandler {
 	return &RaceHandler{repo: repo}
 }
 
-// SearchRaces handles race search with filters
-// GET /api/v1/races/search
+// SearchRaces handles race search with filters, one page at a time (fields=
+// and include= as for GetRace, no runners by default)
+// GET /api/v1/races/search?date_from=...&limit=100&cursor=<meta.next_cursor>
 func (h *RaceHandler) SearchRaces(c *gin.Context) {
 	start := time.Now()
 	logger.Info("→ SearchRaces request from %s | Query: %s", c.ClientIP(), c.Request.URL.RawQuery)
@@ -45,32 +48,41 @@ func (h *RaceHandler) SearchRaces(c *gin.Context) {
 		}
 	}
 
-	if filters.Limit <= 0 {
-		filters.Limit = 100
-	}
-	// Cap limit to reasonable maximum to prevent performance issues
-	if filters.Limit > 1000 {
-		filters.Limit = 1000
+	filters.Limit = pageLimit(c, 100)
+
+	view, ok := raceView(c, "", false)
+	if !ok {
+		return
 	}
 
 	logger.Debug("SearchRaces: filters=%+v", filters)
 
-	races, err := h.repo.SearchRaces(filters)
+	races, next, err := h.repo.SearchRaces(filters, view)
 	if err != nil {
 		logger.Error("SearchRaces: repository error: %v | Filters: %+v", err, filters)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "failed to search races",
-		})
+		pageError(c, err, "failed to search races")
 		return
 	}
 
 	duration := time.Since(start)
 	logger.Info("← SearchRaces: %d races | %v", len(races), duration)
-	c.JSON(http.StatusOK, races)
+	if view.Sparse() {
+		page := models.NewPage(sparseRaces(races, view), filters.Limit, next)
+		page.Meta.Offset = filters.Offset
+		c.JSON(http.StatusOK, page)
+		return
+	}
+	page := models.NewPage(races, filters.Limit, next)
+	page.Meta.Offset = filters.Offset
+	c.JSON(http.StatusOK, page)
 }
 
-// GetRace returns a single race with runners
-// GET /api/v1/races/:id
+// GetRace returns a single race with runners. Results are as settled (with any
+// amendments applied) unless ?as=first_published asks for them as first loaded.
+// fields= picks race fields (runners.<field> for runner fields); include=
+// embeds runners, runners.sectionals (the default), runners.form and
+// runners.live_price.
+// GET /api/v1/races/:id?as=settled|first_published&fields=race_name,runners.horse_name&include=runners.form
 func (h *RaceHandler) GetRace(c *gin.Context) {
 	start := time.Now()
 	raceID, err := strconv.ParseInt(c.Param("id"), 10, 64)
@@ -84,7 +96,16 @@ func (h *RaceHandler) GetRace(c *gin.Context) {
 
 	logger.Info("→ GetRace: race_id=%d | IP: %s", raceID, c.ClientIP())
 
-	race, err := h.repo.GetRaceByID(raceID)
+	firstPublished, ok := resultsAsFirstPublished(c)
+	if !ok {
+		return
+	}
+	view, ok := raceView(c, models.IncludeRunners+","+models.IncludeSectionals, false)
+	if !ok {
+		return
+	}
+
+	race, err := h.repo.GetRaceByID(raceID, view)
 	if err != nil {
 		logger.Error("GetRace: repository error for race_id=%d: %v", raceID, err)
 		c.JSON(http.StatusNotFound, gin.H{
@@ -93,13 +114,36 @@ func (h *RaceHandler) GetRace(c *gin.Context) {
 		return
 	}
 
+	if firstPublished && race.Race.AmendedAt != nil {
+		race.Runners, err = h.repo.AsFirstPublished(raceID, race.Runners)
+		if err != nil {
+			logger.Error("GetRace: amendments error for race_id=%d: %v", raceID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "failed to get amendments",
+			})
+			return
+		}
+	}
+
 	duration := time.Since(start)
 	logger.Info("← GetRace: race_id=%d, %d runners | %v", raceID, len(race.Runners), duration)
+	middleware.Cacheable(c, cache.RaceFreshness(race.Race.RaceDate, race.Race.Prelim),
+		cache.RaceTag(raceID), cache.DateTag(race.Race.RaceDate))
+	if view.Sparse() {
+		out := gin.H{"race": sparse(race.Race, view.RaceFields, "race_id")}
+		if view.Runners {
+			out["runners"] = sparseRunners(race.Runners, view)
+		}
+		c.JSON(http.StatusOK, out)
+		return
+	}
 	c.JSON(http.StatusOK, race)
 }
 
-// GetRaceRunners returns runners for a race
-// GET /api/v1/races/:id/runners
+// GetRaceRunners returns runners for a race (?as= as for GetRace; fields=
+// names runner fields, include= adds runners.sectionals, runners.form and
+// runners.live_price)
+// GET /api/v1/races/:id/runners?as=settled|first_published&fields=horse_name,win_bsp
 func (h *RaceHandler) GetRaceRunners(c *gin.Context) {
 	start := time.Now()
 	raceID, err := strconv.ParseInt(c.Param("id"), 10, 64)
@@ -113,7 +157,17 @@ func (h *RaceHandler) GetRaceRunners(c *gin.Context) {
 
 	logger.Info("→ GetRaceRunners: race_id=%d | IP: %s", raceID, c.ClientIP())
 
-	runners, err := h.repo.GetRaceRunners(raceID)
+	firstPublished, ok := resultsAsFirstPublished(c)
+	if !ok {
+		return
+	}
+
+	view, ok := raceView(c, "", true)
+	if !ok {
+		return
+	}
+
+	runners, err := h.repo.GetRaceRunners(raceID, view)
 	if err != nil {
 		logger.Error("GetRaceRunners: repository error for race_id=%d: %v", raceID, err)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -122,26 +176,116 @@ func (h *RaceHandler) GetRaceRunners(c *gin.Context) {
 		return
 	}
 
+	if firstPublished {
+		runners, err = h.repo.AsFirstPublished(raceID, runners)
+		if err != nil {
+			logger.Error("GetRaceRunners: amendments error for race_id=%d: %v", raceID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "failed to get amendments",
+			})
+			return
+		}
+	}
+
 	duration := time.Since(start)
 	logger.Info("← GetRaceRunners: race_id=%d, %d runners | %v", raceID, len(runners), duration)
+	if view.Sparse() {
+		c.JSON(http.StatusOK, sparseRunners(runners, view))
+		return
+	}
 	c.JSON(http.StatusOK, runners)
 }
 
-// GetRecentRaces returns races for a specific date
+// GetAmendedRaces returns races whose results were amended after settling
+// GET /api/v1/races/amended?date_from=2025-10-01&kind=disqualification
+func (h *RaceHandler) GetAmendedRaces(c *gin.Context) {
+	start := time.Now()
+	var filters models.AmendedRaceFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		logger.Warn("GetAmendedRaces: invalid parameters: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid query parameters",
+		})
+		return
+	}
+
+	logger.Info("→ GetAmendedRaces | IP: %s", c.ClientIP())
+
+	races, err := h.repo.GetAmendedRaces(filters)
+	if err != nil {
+		logger.Error("GetAmendedRaces: repository error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to get amended races",
+		})
+		return
+	}
+
+	duration := time.Since(start)
+	logger.Info("← GetAmendedRaces: %d races | %v", len(races), duration)
+	c.JSON(http.StatusOK, races)
+}
+
+// GetRaceAmendments returns a race's amendment history (old value, new value,
+// source and time per changed field), oldest first
+// GET /api/v1/races/:id/amendments
+func (h *RaceHandler) GetRaceAmendments(c *gin.Context) {
+	start := time.Now()
+	raceID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		logger.Warn("GetRaceAmendments: invalid race ID: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid race ID",
+		})
+		return
+	}
+
+	logger.Info("→ GetRaceAmendments: race_id=%d | IP: %s", raceID, c.ClientIP())
+
+	amendments, err := h.repo.GetRaceAmendments(raceID)
+	if err != nil {
+		logger.Error("GetRaceAmendments: repository error for race_id=%d: %v", raceID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to get amendments",
+		})
+		return
+	}
+
+	duration := time.Since(start)
+	logger.Info("← GetRaceAmendments: race_id=%d, %d amendments | %v", raceID, len(amendments), duration)
+	c.JSON(http.StatusOK, models.RaceAmendments{RaceID: raceID, Amendments: amendments})
+}
+
+// resultsAsFirstPublished reads ?as= (settled, the default, or
+// first_published) and writes a 400 for anything else
+func resultsAsFirstPublished(c *gin.Context) (bool, bool) {
+	switch c.DefaultQuery("as", "settled") {
+	case "settled":
+		return false, true
+	case "first_published":
+		return true, true
+	}
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error": "invalid as parameter, expected settled or first_published",
+	})
+	return false, false
+}
+
+// GetRecentRaces returns races for a specific date (fields= and include= as
+// for GetRace, no runners by default)
 // GET /api/v1/races?date=2024-01-13&limit=50
 func (h *RaceHandler) GetRecentRaces(c *gin.Context) {
 	start := time.Now()
 	date := c.DefaultQuery("date", time.Now().Format("2006-01-02"))
-	limit := 50
-	if l := c.Query("limit"); l != "" {
-		if parsedLimit, err := strconv.Atoi(l); err == nil {
-			limit = parsedLimit
-		}
-	}
+	limit := pageLimit(c, 50)
 
 	logger.Info("→ GetRecentRaces: date=%s, limit=%d | IP: %s", date, limit, c.ClientIP())
 
-	races, err := h.repo.GetRecentRaces(date, limit)
+	view, ok := raceView(c, "", false)
+	if !ok {
+		return
+	}
+
+	races, err := h.repo.GetRecentRaces(date, limit, view)
 	if err != nil {
 		logger.Error("GetRecentRaces: repository error for date=%s: %v", date, err)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -152,6 +296,10 @@ func (h *RaceHandler) GetRecentRaces(c *gin.Context) {
 
 	duration := time.Since(start)
 	logger.Info("← GetRecentRaces: %d races on %s | %v", len(races), date, duration)
+	if view.Sparse() {
+		c.JSON(http.StatusOK, sparseRaces(races, view))
+		return
+	}
 	c.JSON(http.StatusOK, races)
 }
 
@@ -187,15 +335,21 @@ func (h *RaceHandler) GetCourseMeetings(c *gin.Context) {
 	c.JSON(http.StatusOK, meetings)
 }
 
-// GetMeetings returns races grouped by meetings (course + date)
-// GET /api/v1/meetings
+// GetMeetings returns races grouped by meetings (course + date), with their
+// runners unless include= says otherwise (fields= as for GetRace)
+// GET /api/v1/meetings?date=2025-10-14&include=&fields=race_name,off_time
 func (h *RaceHandler) GetMeetings(c *gin.Context) {
 	start := time.Now()
 	date := c.DefaultQuery("date", time.Now().Format("2006-01-02"))
 
 	logger.Info("→ GetMeetings: date=%s | IP: %s", date, c.ClientIP())
 
-	meetings, err := h.repo.GetRacesByMeetings(date)
+	view, ok := raceView(c, models.IncludeRunners, false)
+	if !ok {
+		return
+	}
+
+	meetings, err := h.repo.GetRacesByMeetings(date, view)
 	if err != nil {
 		logger.Error("GetMeetings: repository error: %v | Date: %s", err, date)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -216,7 +370,7 @@ func (h *RaceHandler) GetMeetings(c *gin.Context) {
 		}(),
 		duration)
 
-	c.JSON(http.StatusOK, meetings)
+	renderMeetings(c, meetings, view)
 }
 
 // GetCourses returns all courses
@@ -248,7 +402,12 @@ func (h *RaceHandler) GetTodayMeetings(c *gin.Context) {
 	
 	logger.Info("→ GetTodayMeetings (calculated: %s) | IP: %s", today, c.ClientIP())
 	
-	meetings, err := h.repo.GetRacesByMeetings(today)
+	view, ok := raceView(c, models.IncludeRunners, false)
+	if !ok {
+		return
+	}
+
+	meetings, err := h.repo.GetRacesByMeetings(today, view)
 	if err != nil {
 		logger.Error("GetTodayMeetings: repository error: %v | Date: %s", err, today)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -260,7 +419,7 @@ func (h *RaceHandler) GetTodayMeetings(c *gin.Context) {
 	duration := time.Since(start)
 	logger.Info("← GetTodayMeetings: %d meetings for %s | %v", len(meetings), today, duration)
 	
-	c.JSON(http.StatusOK, meetings)
+	renderMeetings(c, meetings, view)
 }
 
 // GetTomorrowMeetings returns tomorrow's meetings (convenience endpoint)
@@ -270,7 +429,12 @@ func (h *RaceHandler) GetTomorrowMeetings(c *gin.Context) {
 	
 	logger.Info("→ GetTomorrowMeetings (calculated: %s) | IP: %s", tomorrow, c.ClientIP())
 	
-	meetings, err := h.repo.GetRacesByMeetings(tomorrow)
+	view, ok := raceView(c, models.IncludeRunners, false)
+	if !ok {
+		return
+	}
+
+	meetings, err := h.repo.GetRacesByMeetings(tomorrow, view)
 	if err != nil {
 		logger.Error("GetTomorrowMeetings: repository error: %v | Date: %s", err, tomorrow)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -282,5 +446,5 @@ func (h *RaceHandler) GetTomorrowMeetings(c *gin.Context) {
 	duration := time.Since(start)
 	logger.Info("← GetTomorrowMeetings: %d meetings for %s | %v", len(meetings), tomorrow, duration)
 	
-	c.JSON(http.StatusOK, meetings)
+	renderMeetings(c, meetings, view)
 }
@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"fmt"
 	"net/http"
 
 	"giddyup/api/internal/logger"
@@ -31,12 +30,7 @@ func (h *SearchHandler) GlobalSearch(c *gin.Context) {
 		return
 	}
 
-	limit := 10
-	if l := c.Query("limit"); l != "" {
-		if _, err := fmt.Sscanf(l, "%d", &limit); err != nil {
-			limit = 10
-		}
-	}
+	limit := pageLimit(c, 10)
 
 	logger.Debug("GlobalSearch: query='%s', limit=%d", query, limit)
 
@@ -53,8 +47,8 @@ func (h *SearchHandler) GlobalSearch(c *gin.Context) {
 	c.JSON(http.StatusOK, results)
 }
 
-// SearchComments handles full-text search in runner comments
-// GET /api/v1/search/comments?q=<query>&date_from=<date>&date_to=<date>&region=<region>
+// SearchComments handles full-text search in runner comments, one page at a time
+// GET /api/v1/search/comments?q=<query>&date_from=<date>&date_to=<date>&region=<region>&cursor=<meta.next_cursor>
 func (h *SearchHandler) SearchComments(c *gin.Context) {
 	var params models.CommentSearchParams
 	if err := c.ShouldBindQuery(&params); err != nil {
@@ -65,21 +59,17 @@ func (h *SearchHandler) SearchComments(c *gin.Context) {
 		return
 	}
 
-	if params.Limit <= 0 {
-		params.Limit = 100
-	}
+	params.Limit = pageLimit(c, 100)
 
 	logger.Debug("SearchComments: query='%s', limit=%d", params.Query, params.Limit)
 
-	results, err := h.repo.SearchComments(params)
+	results, next, err := h.repo.SearchComments(params)
 	if err != nil {
 		logger.HandlerError("SearchHandler", "SearchComments", err, 500)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "failed to search comments",
-		})
+		pageError(c, err, "failed to search comments")
 		return
 	}
 
 	logger.Debug("SearchComments: found %d results", len(results))
-	c.JSON(http.StatusOK, results)
+	c.JSON(http.StatusOK, models.NewPage(results, params.Limit, next))
 }
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"giddyup/api/internal/logger"
+	"giddyup/api/internal/middleware"
+	"giddyup/api/internal/models"
+	"giddyup/api/internal/ratelimit"
+	"giddyup/api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// usageMaxDays caps the date range of usage queries
+const usageMaxDays = 92
+
+type UsageHandler struct {
+	repo    *repository.UsageRepository
+	limiter *ratelimit.Limiter // nil when rate limiting is off
+}
+
+func NewUsageHandler(repo *repository.UsageRepository, limiter *ratelimit.Limiter) *UsageHandler {
+	return &UsageHandler{repo: repo, limiter: limiter}
+}
+
+// GetMyUsage returns the caller's own usage per day and route, with its quotas
+// GET /api/v1/usage?date_from=2025-10-01&date_to=2025-10-07
+func (h *UsageHandler) GetMyUsage(c *gin.Context) {
+	var filters models.UsageFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	from, to, ok := usageRange(c, filters)
+	if !ok {
+		return
+	}
+
+	client := middleware.ClientID(c)
+	filters.Client, filters.KeyID = &client, nil
+
+	rows, err := h.repo.GetUsage(from, to, filters)
+	if err != nil {
+		logger.HandlerError("UsageHandler", "GetMyUsage", err, 500)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to get usage",
+		})
+		return
+	}
+
+	quotas := map[string]string{}
+	if h.limiter != nil {
+		for group, q := range h.limiter.Quotas() {
+			quotas[group] = q.String()
+		}
+	}
+
+	c.JSON(http.StatusOK, models.UsageReport{Client: client, Quotas: quotas, Usage: rows})
+}
+
+// GetUsage returns every client's usage per day and route (filter by client or key_id)
+// GET /api/v1/admin/usage
+func (h *UsageHandler) GetUsage(c *gin.Context) {
+	var filters models.UsageFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	from, to, ok := usageRange(c, filters)
+	if !ok {
+		return
+	}
+
+	rows, err := h.repo.GetUsage(from, to, filters)
+	if err != nil {
+		logger.HandlerError("UsageHandler", "GetUsage", err, 500)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to get usage",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, rows)
+}
+
+// usageRange resolves date_from/date_to (default the last 7 days, UTC) and
+// writes a 400 if the range is backwards or too long
+func usageRange(c *gin.Context, filters models.UsageFilters) (string, string, bool) {
+	to := time.Now().UTC()
+	if filters.DateTo != nil {
+		to, _ = time.Parse("2006-01-02", *filters.DateTo)
+	}
+	from := to.AddDate(0, 0, -6)
+	if filters.DateFrom != nil {
+		from, _ = time.Parse("2006-01-02", *filters.DateFrom)
+	}
+	if from.After(to) || to.Sub(from) > usageMaxDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "date_from must be on or before date_to, at most 92 days apart",
+		})
+		return "", "", false
+	}
+	return from.Format("2006-01-02"), to.Format("2006-01-02"), true
+}
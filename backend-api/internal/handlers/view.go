@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"giddyup/api/internal/models"
+	"giddyup/api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// raceView reads ?fields= and ?include= (def when include is absent) and
+// writes a 400 for unknown names. On runner lists (runnerList) the runners
+// are the resource: bare field names are runner fields and runners are
+// always included. Runner fields or includes imply include=runners.
+func raceView(c *gin.Context, def string, runnerList bool) (models.RaceView, bool) {
+	var params models.RaceViewParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return models.RaceView{}, false
+	}
+	if _, ok := c.GetQuery("include"); !ok {
+		params.Include = def
+	}
+
+	view := models.RaceView{Runners: runnerList}
+	for _, name := range splitList(params.Include) {
+		switch name {
+		case models.IncludeRunners:
+			view.Runners = true
+		case models.IncludeSectionals:
+			view.Runners, view.Sectionals = true, true
+		case models.IncludeForm:
+			view.Runners, view.Form = true, true
+		case models.IncludeLivePrice:
+			view.Runners, view.LivePrice = true, true
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "unknown include " + name,
+				"allowed": []string{models.IncludeRunners, models.IncludeSectionals, models.IncludeForm, models.IncludeLivePrice},
+			})
+			return models.RaceView{}, false
+		}
+	}
+
+	for _, name := range splitList(params.Fields) {
+		if field, ok := strings.CutPrefix(name, "runners."); ok {
+			view.RunnerFields = append(view.RunnerFields, field)
+			view.Runners = true
+		} else if runnerList {
+			view.RunnerFields = append(view.RunnerFields, name)
+		} else {
+			view.RaceFields = append(view.RaceFields, name)
+		}
+	}
+
+	if err := repository.CheckRaceView(view); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return models.RaceView{}, false
+	}
+	return view, true
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// sparse renders v's JSON with only the named fields (all when none are
+// named) and the keep fields
+func sparse(v interface{}, fields []string, keep ...string) map[string]json.RawMessage {
+	b, _ := json.Marshal(v)
+	var out map[string]json.RawMessage
+	json.Unmarshal(b, &out)
+	if len(fields) == 0 {
+		return out
+	}
+
+	want := make(map[string]bool, len(fields)+len(keep))
+	for _, name := range fields {
+		want[name] = true
+	}
+	for _, name := range keep {
+		want[name] = true
+	}
+	for name := range out {
+		if !want[name] {
+			delete(out, name)
+		}
+	}
+	return out
+}
+
+// sparseRaces renders races with the view's fields and embedded runners
+func sparseRaces(races []models.Race, view models.RaceView) []map[string]json.RawMessage {
+	out := make([]map[string]json.RawMessage, len(races))
+	for i, race := range races {
+		runners := race.Runners
+		race.Runners = nil
+		out[i] = sparse(race, view.RaceFields, "race_id")
+		if view.Runners {
+			out[i]["runners"], _ = json.Marshal(sparseRunners(runners, view))
+		}
+	}
+	return out
+}
+
+// sparseRunners renders runners with the view's fields and includes
+func sparseRunners(runners []models.Runner, view models.RaceView) []map[string]json.RawMessage {
+	keep := []string{"runner_id", "sectionals", "form", "live_price"}
+	out := make([]map[string]json.RawMessage, len(runners))
+	for i, runner := range runners {
+		out[i] = sparse(runner, view.RunnerFields, keep...)
+	}
+	return out
+}
+
+// renderMeetings answers with meetings, their races trimmed to the view when
+// it is sparse
+func renderMeetings(c *gin.Context, meetings []models.MeetingWithRaces, view models.RaceView) {
+	if !view.Sparse() {
+		c.JSON(http.StatusOK, meetings)
+		return
+	}
+	out := make([]map[string]json.RawMessage, len(meetings))
+	for i, meeting := range meetings {
+		races := meeting.Races
+		meeting.Races = nil
+		out[i] = sparse(meeting, nil)
+		out[i]["races"], _ = json.Marshal(sparseRaces(races, view))
+	}
+	c.JSON(http.StatusOK, out)
+}
@@ -0,0 +1,293 @@
+package loader
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"giddyup/api/internal/cache"
+	"giddyup/api/internal/matching"
+	"giddyup/api/internal/stitcher"
+
+	"github.com/lib/pq"
+)
+
+// DefaultBatchRunners is roughly how many runners go into one batch (~2 weeks of GB/IRE racing)
+const DefaultBatchRunners = 20000
+
+// stageRaceCols and stageRunnerCols are the racing.stage_races /
+// racing.stage_runners columns the loader fills
+var stageRaceCols = []string{
+	"date", "region", "course", "off", "race_name", "type", "class", "pattern",
+	"rating_band", "age_band", "sex_rest", "dist", "dist_f", "going", "surface", "ran", "race_key",
+}
+
+var stageRunnerCols = []string{
+	"race_key", "runner_key", "num", "pos", "draw", "horse", "age", "lbs",
+	"jockey", "trainer", "or", "rpr", "comment",
+	"win_bsp", "win_ppwap", "win_morningwap", "win_ppmax", "win_ppmin", "place_bsp", "place_ppwap",
+	"match_jaccard", "match_time_diff_min", "match_reason",
+}
+
+// CopyLoader loads master races and runners in bulk: each batch of whole dates
+// is streamed through COPY into temp copies of racing.stage_races and
+// racing.stage_runners, then merged set-based (see Merge) and committed.
+type CopyLoader struct {
+	db *sql.DB
+
+	BatchRunners  int    // Runners per batch (whole dates are never split)
+	Source        string // Course registry source for the race course names
+	CreateCourses bool   // Create unresolved courses instead of queueing them for review
+	RunID         int64  // racing.etl_runs row to record loaded days under (0 = don't)
+}
+
+// LoadStats contains statistics about a load operation
+type LoadStats struct {
+	Batches       int
+	RacesLoaded   int
+	RunnersLoaded int
+	CoursesQueued int
+	RacesHeld     int // Quarantined by the data-quality rules
+	RunnersHeld   int
+	Warnings      int
+	Duration      float64 // Seconds
+}
+
+// NewCopyLoader creates a COPY loader for Sporting Life races
+func NewCopyLoader(db *sql.DB) *CopyLoader {
+	return &CopyLoader{
+		db:           db,
+		BatchRunners: DefaultBatchRunners,
+		Source:       matching.CourseSourceSportingLife,
+	}
+}
+
+// batch is a run of whole dates loaded in one transaction
+type batch struct {
+	dates   []string
+	races   []stitcher.MasterRace
+	runners []stitcher.MasterRunner
+}
+
+// Load loads races and their runners in date batches, logging progress after
+// each batch. Batches are committed as they go, so on error the returned
+// stats cover the batches already loaded.
+func (l *CopyLoader) Load(ctx context.Context, races []stitcher.MasterRace, runners []stitcher.MasterRunner) (*LoadStats, error) {
+	start := time.Now()
+	batches := l.batches(races, runners)
+	stats := &LoadStats{}
+
+	log.Printf("[Loader] Loading %d races and %d runners in %d batch(es)...", len(races), len(runners), len(batches))
+
+	for i, b := range batches {
+		batchStart := time.Now()
+		merged, err := l.loadBatch(ctx, b)
+		if err != nil {
+			stats.Duration = time.Since(start).Seconds()
+			return stats, fmt.Errorf("batch %d/%d (%s to %s): %w", i+1, len(batches), b.dates[0], b.dates[len(b.dates)-1], err)
+		}
+
+		stats.Batches++
+		stats.RacesLoaded += merged.Races
+		stats.RunnersLoaded += merged.Runners
+		stats.CoursesQueued += merged.CoursesQueued
+		stats.RacesHeld += merged.RacesHeld
+		stats.RunnersHeld += merged.RunnersHeld
+		stats.Warnings += merged.Warnings
+
+		elapsed := time.Since(batchStart)
+		log.Printf("[Loader] Batch %d/%d (%s to %s): %d races, %d runners in %v (%.0f runners/s) - %d/%d runners loaded",
+			i+1, len(batches), b.dates[0], b.dates[len(b.dates)-1], merged.Races, merged.Runners,
+			elapsed.Round(time.Millisecond), float64(merged.Runners)/elapsed.Seconds(),
+			stats.RunnersLoaded, len(runners))
+	}
+
+	if stats.CoursesQueued > 0 {
+		log.Printf("[Loader] ⚠️  %d unresolved course name(s) queued in racing.course_review_queue", stats.CoursesQueued)
+	}
+	if stats.RacesHeld+stats.RunnersHeld+stats.Warnings > 0 {
+		log.Printf("[Loader] ⚠️  %d race(s) and %d runner(s) quarantined, %d data-quality warning(s) - see racing.quarantine",
+			stats.RacesHeld, stats.RunnersHeld, stats.Warnings)
+	}
+
+	// Planner statistics are stale after a bulk load
+	if _, err := l.db.ExecContext(ctx, `ANALYZE racing.races; ANALYZE racing.runners;`); err != nil {
+		log.Printf("[Loader] Warning: analyze failed: %v", err)
+	}
+
+	stats.Duration = time.Since(start).Seconds()
+	log.Printf("[Loader] Loaded %d races and %d runners in %.1fs", stats.RacesLoaded, stats.RunnersLoaded, stats.Duration)
+	return stats, nil
+}
+
+// batches groups races by date and packs whole dates into batches of about
+// BatchRunners runners, in date order
+func (l *CopyLoader) batches(races []stitcher.MasterRace, runners []stitcher.MasterRunner) []batch {
+	racesByDate := make(map[string][]stitcher.MasterRace)
+	for _, race := range races {
+		racesByDate[race.Date] = append(racesByDate[race.Date], race)
+	}
+
+	// Runners follow their race's date (race keys repeat across dates)
+	raceDate := make(map[string]string, len(races))
+	for _, race := range races {
+		raceDate[race.RaceKey] = race.Date
+	}
+	runnersByDate := make(map[string][]stitcher.MasterRunner)
+	for _, runner := range runners {
+		date := runner.RaceDate
+		if date == "" {
+			date = raceDate[runner.RaceKey]
+		}
+		runnersByDate[date] = append(runnersByDate[date], runner)
+	}
+
+	dates := make([]string, 0, len(racesByDate))
+	for date := range racesByDate {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+
+	size := l.BatchRunners
+	if size <= 0 {
+		size = DefaultBatchRunners
+	}
+
+	var out []batch
+	var cur batch
+	for _, date := range dates {
+		cur.dates = append(cur.dates, date)
+		cur.races = append(cur.races, racesByDate[date]...)
+		cur.runners = append(cur.runners, runnersByDate[date]...)
+		if len(cur.runners) >= size {
+			out = append(out, cur)
+			cur = batch{}
+		}
+	}
+	if len(cur.dates) > 0 {
+		out = append(out, cur)
+	}
+	return out
+}
+
+// loadBatch stages and merges one batch in its own transaction, invalidating
+// cached responses built from its races once it commits
+func (l *CopyLoader) loadBatch(ctx context.Context, b batch) (*MergeStats, error) {
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Safe for batch ETL: a lost batch is reloaded by rerunning
+	if _, err := tx.ExecContext(ctx, `SET LOCAL synchronous_commit = off`); err != nil {
+		return nil, err
+	}
+
+	suffix := strconv.FormatInt(time.Now().UnixNano()%1e9, 10)
+	racesTable, runnersTable := "stage_races_"+suffix, "stage_runners_"+suffix
+	for table, like := range map[string]string{racesTable: "racing.stage_races", runnersTable: "racing.stage_runners"} {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`CREATE TEMP TABLE %s (LIKE %s) ON COMMIT DROP`, table, like)); err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", table, err)
+		}
+	}
+
+	if err := copyRows(ctx, tx, racesTable, stageRaceCols, len(b.races), func(i int) []interface{} {
+		return raceRow(b.races[i])
+	}); err != nil {
+		return nil, fmt.Errorf("failed to copy races: %w", err)
+	}
+	if err := copyRows(ctx, tx, runnersTable, stageRunnerCols, len(b.runners), func(i int) []interface{} {
+		return runnerRow(b.runners[i])
+	}); err != nil {
+		return nil, fmt.Errorf("failed to copy runners: %w", err)
+	}
+
+	merged, err := Merge(ctx, tx, racesTable, runnersTable, MergeOptions{
+		Source:        l.Source,
+		CreateCourses: l.CreateCourses,
+		RunID:         l.RunID,
+		Loader:        "copy",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit batch: %w", err)
+	}
+
+	// Results also change profiles and calibration
+	cache.InvalidateRaces(ctx, merged.RaceIDs, merged.Dates, true)
+	return merged, nil
+}
+
+// copyRows streams n rows into table through COPY
+func copyRows(ctx context.Context, tx *sql.Tx, table string, cols []string, n int, row func(i int) []interface{}) error {
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(table, cols...))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for i := 0; i < n; i++ {
+		if _, err := stmt.ExecContext(ctx, row(i)...); err != nil {
+			return err
+		}
+	}
+	_, err = stmt.ExecContext(ctx) // Flush
+	return err
+}
+
+func raceRow(r stitcher.MasterRace) []interface{} {
+	return []interface{}{
+		text(r.Date), text(r.Region), text(r.Course), text(r.OffTime), text(r.RaceName), text(r.Type),
+		text(r.Class), text(r.Pattern), text(r.RatingBand), text(r.AgeBand), text(r.SexRest),
+		text(r.Distance), decimal(r.DistanceF, 1), text(r.Going), text(r.Surface), integer(r.Ran), text(r.RaceKey),
+	}
+}
+
+func runnerRow(r stitcher.MasterRunner) []interface{} {
+	return []interface{}{
+		text(r.RaceKey), text(r.RunnerKey), integer(r.Num), text(r.Pos), integer(r.Draw), text(r.Horse),
+		integer(r.Age), integer(r.Lbs), text(r.Jockey), text(r.Trainer), integer(r.OR), integer(r.RPR), text(r.Comment),
+		decimal(r.WinBSP, 8), decimal(r.WinPPWAP, 4), decimal(r.WinMorningWAP, 4), decimal(r.WinPPMax, 4),
+		decimal(r.WinPPMin, 4), decimal(r.PlaceBSP, 8), decimal(r.PlacePPWAP, 4),
+		decimal(r.MatchJaccard, 4), matchTimeDiff(r), text(r.MatchReason),
+	}
+}
+
+// matchTimeDiff keeps a 0-minute difference for matched runners
+func matchTimeDiff(r stitcher.MasterRunner) interface{} {
+	if r.MatchReason == "" {
+		return nil
+	}
+	return strconv.Itoa(r.MatchTimeDiff)
+}
+
+// Staging columns are text; empty and zero values are staged as NULL
+
+func text(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func integer(i int) interface{} {
+	if i == 0 {
+		return nil
+	}
+	return strconv.Itoa(i)
+}
+
+func decimal(f float64, prec int) interface{} {
+	if f == 0 {
+		return nil
+	}
+	return strconv.FormatFloat(f, 'f', prec, 64)
+}
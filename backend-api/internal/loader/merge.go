@@ -0,0 +1,366 @@
+package loader
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"giddyup/api/internal/pipeline"
+	"giddyup/api/internal/quality"
+
+	"github.com/lib/pq"
+)
+
+// MergeOptions controls how staged races and runners are merged
+type MergeOptions struct {
+	Source        string // Course registry source the staged course names come from (matching.CourseSource*)
+	CreateCourses bool   // Create courses the registry can't resolve instead of queueing them for review
+	RunID         int64  // racing.etl_runs row to record the staged days under in racing.ingested_days (0 = don't)
+	Loader        string // Loader named in racing.quarantine (master or copy)
+}
+
+// MergeStats counts what a merge wrote
+type MergeStats struct {
+	Races         int
+	Runners       int
+	CoursesQueued int
+	RacesHeld     int // Quarantined by the data-quality rules
+	RunnersHeld   int
+	Warnings      int
+	RaceIDs       []int64  // Races written, for cache invalidation once the merge commits
+	Dates         []string // Staged dates
+}
+
+// Merge upserts staged races and runners (tables shaped like racing.stage_races
+// and racing.stage_runners, all text) into racing.races and racing.runners with
+// set-based statements: dimensions first, then races, then runners joined to
+// their races. It first takes the staged dates' locks, waiting for any other
+// loader writing them (the locks are held until tx ends), then screens the
+// staged rows against the data-quality rules: rows breaking an error rule are
+// deleted from the staged tables and quarantined.
+func Merge(ctx context.Context, tx *sql.Tx, racesTable, runnersTable string, opts MergeOptions) (*MergeStats, error) {
+	dates, err := StagedDates(ctx, tx, racesTable)
+	if err != nil {
+		return nil, err
+	}
+	if err := pipeline.LockDatesTx(ctx, tx, dates); err != nil {
+		return nil, err
+	}
+
+	stats := &MergeStats{Dates: dates}
+
+	if opts.Loader == "" {
+		opts.Loader = "master"
+	}
+	report, err := screen(ctx, tx, racesTable, runnersTable, quality.Source{Loader: opts.Loader, RunID: opts.RunID})
+	if err != nil {
+		return nil, err
+	}
+	stats.RacesHeld, stats.RunnersHeld = report.HeldRaces(), report.HeldRunners()
+	stats.Warnings = report.Count(quality.SeverityWarning)
+
+	// Dimensions (courses, horses, trainers, jockeys)
+	if opts.CreateCourses {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(sqlCreateCourses, racesTable), opts.Source); err != nil {
+			return nil, fmt.Errorf("failed to create courses: %w", err)
+		}
+	}
+	if err := tx.QueryRowContext(ctx, fmt.Sprintf(sqlQueueCourses, racesTable), opts.Source).Scan(&stats.CoursesQueued); err != nil {
+		return nil, fmt.Errorf("failed to queue courses: %w", err)
+	}
+	if err := resolveHorses(ctx, tx, racesTable, runnersTable); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(sqlUpsertTrainers, runnersTable)); err != nil {
+		return nil, fmt.Errorf("failed to upsert trainers: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(sqlUpsertJockeys, runnersTable)); err != nil {
+		return nil, fmt.Errorf("failed to upsert jockeys: %w", err)
+	}
+
+	if err := tx.QueryRowContext(ctx, fmt.Sprintf(sqlUpsertRaces, racesTable), opts.Source).Scan(&stats.Races, pq.Array(&stats.RaceIDs)); err != nil {
+		return nil, fmt.Errorf("failed to upsert races: %w", err)
+	}
+
+	// Runners join to races via race_key + the staged race's date
+	if err := tx.QueryRowContext(ctx, fmt.Sprintf(sqlUpsertRunners, runnersTable, racesTable, runnersTable)).Scan(&stats.Runners); err != nil {
+		return nil, fmt.Errorf("failed to upsert runners: %w", err)
+	}
+
+	if opts.RunID > 0 {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(sqlMarkIngested, racesTable), opts.RunID); err != nil {
+			return nil, fmt.Errorf("failed to mark ingested days: %w", err)
+		}
+	}
+
+	return stats, nil
+}
+
+// StagedDates lists the distinct race dates in a staged races table
+func StagedDates(ctx context.Context, tx *sql.Tx, racesTable string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`SELECT DISTINCT date::date::text FROM %s WHERE NULLIF(date, '') IS NOT NULL`, racesTable))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read staged dates: %w", err)
+	}
+	defer rows.Close()
+
+	var dates []string
+	for rows.Next() {
+		var d string
+		if err := rows.Scan(&d); err != nil {
+			return nil, err
+		}
+		dates = append(dates, d)
+	}
+	return dates, rows.Err()
+}
+
+// resolveHorses maps the staged runners' horses to horse_ids through
+// pipeline.ResolveHorseIDs (aliases, suffix and foaling-year checks, creating
+// horses as needed) and leaves the mapping in the stage_horse_ids temp table
+// for sqlUpsertRunners
+func resolveHorses(ctx context.Context, tx *sql.Tx, racesTable, runnersTable string) error {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(sqlStagedHorses, runnersTable, racesTable))
+	if err != nil {
+		return fmt.Errorf("failed to read staged horses: %w", err)
+	}
+	defer rows.Close()
+
+	var refs []pipeline.HorseRef
+	for rows.Next() {
+		var ref pipeline.HorseRef
+		if err := rows.Scan(&ref.Name, &ref.FoaledYear); err != nil {
+			return err
+		}
+		ref.Suffix = pipeline.HorseSuffix(ref.Name)
+		refs = append(refs, ref)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	ids, err := pipeline.ResolveHorseIDs(tx, refs)
+	if err != nil {
+		return fmt.Errorf("failed to resolve horses: %w", err)
+	}
+
+	names := make([]string, 0, len(refs))
+	years := make([]int64, 0, len(refs))
+	horseIDs := make([]int64, 0, len(refs))
+	for _, ref := range refs {
+		if id, ok := ids[ref.Key()]; ok {
+			names = append(names, ref.Name)
+			years = append(years, int64(ref.FoaledYear))
+			horseIDs = append(horseIDs, id)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, sqlCreateHorseIDs); err != nil {
+		return fmt.Errorf("failed to create horse map: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO stage_horse_ids (horse, foaled_year, horse_id)
+		SELECT * FROM unnest($1::text[], $2::int[], $3::bigint[])
+	`, pq.Array(names), pq.Array(years), pq.Array(horseIDs)); err != nil {
+		return fmt.Errorf("failed to fill horse map: %w", err)
+	}
+	return nil
+}
+
+/* -------------------- SQL templates (fmt.Sprintf with staged table names) -------------------- */
+
+// Courses resolve through the registry (racing.resolve_course) under the
+// source in $1; only CreateCourses adds new canonical rows, otherwise unknown
+// names are queued for review
+const sqlCreateCourses = `
+INSERT INTO racing.courses (course_name, region)
+SELECT DISTINCT t.course, t.region
+FROM %s t
+WHERE t.course IS NOT NULL AND t.course <> '' AND t.region IS NOT NULL
+  AND racing.resolve_course($1, t.course, t.region) IS NULL
+ON CONFLICT ON CONSTRAINT courses_uniq DO NOTHING;
+`
+
+const sqlQueueCourses = `
+SELECT count(*) FROM (
+  SELECT racing.queue_course_review($1, t.course, t.region, array_agg(DISTINCT t.race_key))
+  FROM %s t
+  WHERE t.course IS NOT NULL AND t.course <> '' AND t.region IS NOT NULL
+    AND racing.resolve_course($1, t.course, t.region) IS NULL
+  GROUP BY t.course, t.region
+) q;
+`
+
+// sqlStagedFoaledYear is a staged runner's race year less its age (0 unknown),
+// as pipeline.HorseRefFor derives it; t0 is the runner and rm its race
+const sqlStagedFoaledYear = `COALESCE(extract(year FROM rm.race_date)::int
+    - NULLIF(NULLIF(regexp_replace(t0.age, '[^0-9]', '', 'g'), '')::int, 0), 0)`
+
+// The distinct horses staged, with their foaling years
+const sqlStagedHorses = `
+SELECT DISTINCT trim(t0.horse), ` + sqlStagedFoaledYear + `
+FROM %s t0
+JOIN (SELECT DISTINCT race_key, date::date AS race_date FROM %s) rm USING (race_key)
+WHERE trim(t0.horse) <> '';
+`
+
+// stage_horse_ids holds resolveHorses' mapping for the rest of the transaction
+const sqlCreateHorseIDs = `
+CREATE TEMP TABLE IF NOT EXISTS stage_horse_ids (
+  horse text NOT NULL,
+  foaled_year int NOT NULL,
+  horse_id bigint NOT NULL,
+  PRIMARY KEY (horse, foaled_year)
+) ON COMMIT DROP;
+TRUNCATE stage_horse_ids;
+`
+
+const sqlUpsertTrainers = `
+INSERT INTO racing.trainers (trainer_name)
+SELECT DISTINCT t.trainer
+FROM %s t
+WHERE t.trainer IS NOT NULL AND t.trainer <> ''
+ON CONFLICT ON CONSTRAINT trainers_uniq DO NOTHING;
+`
+
+const sqlUpsertJockeys = `
+INSERT INTO racing.jockeys (jockey_name)
+SELECT DISTINCT t.jockey
+FROM %s t
+WHERE t.jockey IS NOT NULL AND t.jockey <> ''
+ON CONFLICT ON CONSTRAINT jockeys_uniq DO NOTHING;
+`
+
+const sqlUpsertRaces = `
+WITH ins AS (
+  INSERT INTO racing.races (
+    race_key, race_date, region, course_id, off_time, race_name, race_type,
+    class, pattern, rating_band, age_band, sex_rest,
+    dist_raw, dist_f, dist_m, going, surface, ran
+  )
+  SELECT DISTINCT
+    t.race_key,
+    t.date::date,
+    t.region,
+    racing.resolve_course($1, t.course, t.region),
+    CASE WHEN t.off ~ '^[0-9]{1,2}:[0-9]{2}$' THEN t.off::time ELSE NULL END,
+    t.race_name,
+    t.type,
+    NULLIF(t.class,''),
+    NULLIF(t.pattern,''),
+    NULLIF(t.rating_band,''),
+    NULLIF(t.age_band,''),
+    NULLIF(t.sex_rest,''),
+    NULLIF(t.dist,''),
+    NULLIF(REPLACE(t.dist_f,'f',''),'')::double precision,
+    NULLIF(t.dist_m,'')::int,
+    NULLIF(t.going,''),
+    NULLIF(t.surface,''),
+    NULLIF(t.ran,'')::int
+  FROM %s t
+  ON CONFLICT (race_key, race_date) DO UPDATE SET
+    region    = EXCLUDED.region,
+    course_id = EXCLUDED.course_id,
+    off_time  = EXCLUDED.off_time,
+    race_name = EXCLUDED.race_name,
+    race_type = EXCLUDED.race_type,
+    class     = EXCLUDED.class,
+    pattern   = EXCLUDED.pattern,
+    rating_band = EXCLUDED.rating_band,
+    age_band  = EXCLUDED.age_band,
+    sex_rest  = EXCLUDED.sex_rest,
+    dist_raw  = EXCLUDED.dist_raw,
+    dist_f    = COALESCE(EXCLUDED.dist_f, races.dist_f),
+    dist_m    = COALESCE(EXCLUDED.dist_m, races.dist_m),
+    going     = COALESCE(EXCLUDED.going, races.going),
+    surface   = EXCLUDED.surface,
+    ran       = COALESCE(EXCLUDED.ran, races.ran)
+  RETURNING race_id
+)
+SELECT count(*), COALESCE(array_agg(race_id), '{}') FROM ins;
+`
+
+// Horses come from stage_horse_ids (see resolveHorses); a runner already
+// loaded keeps its horse_id, so admin splits and merges survive a reload
+const sqlUpsertRunners = `
+WITH rmap AS (
+  -- Map race_key to race_id + race_date using the staged races
+  SELECT DISTINCT rc.race_key, ra.race_id, ra.race_date
+  FROM %s rr
+  JOIN %s rc USING (race_key)
+  JOIN racing.races ra
+    ON ra.race_key = rc.race_key
+   AND ra.race_date = rc.date::date
+),
+j AS (SELECT jockey_id, jockey_norm FROM racing.jockeys),
+t AS (SELECT trainer_id, trainer_norm FROM racing.trainers),
+ins AS (
+  INSERT INTO racing.runners (
+    runner_key, race_id, race_date,
+    num, pos_raw, draw,
+    horse_id, age, jockey_id, trainer_id, lbs,
+    "or", rpr, comment,
+    win_bsp, win_ppwap, place_bsp, place_ppwap
+  )
+  SELECT DISTINCT
+    t0.runner_key,
+    rm.race_id,
+    rm.race_date,
+    NULLIF(regexp_replace(t0.num, '[^0-9]', '', 'g'),'')::int,
+    NULLIF(t0.pos,''),
+    NULLIF(regexp_replace(t0.draw, '[^0-9]', '', 'g'),'')::int,
+    h.horse_id,
+    NULLIF(regexp_replace(t0.age, '[^0-9]', '', 'g'),'')::int,
+    j.jockey_id,
+    t.trainer_id,
+    NULLIF(regexp_replace(t0.lbs, '[^0-9]', '', 'g'),'')::int,
+    NULLIF(regexp_replace(t0."or", '[^0-9]', '', 'g'),'')::int,
+    NULLIF(regexp_replace(t0.rpr, '[^0-9]', '', 'g'),'')::int,
+    NULLIF(t0.comment,''),
+    CASE WHEN t0.win_bsp ~ '^[0-9]+\.?[0-9]*$' AND t0.win_bsp::double precision >= 1.01 THEN t0.win_bsp::double precision ELSE NULL END,
+    CASE WHEN t0.win_ppwap ~ '^[0-9]+\.?[0-9]*$' AND t0.win_ppwap::double precision >= 1.01 THEN t0.win_ppwap::double precision ELSE NULL END,
+    CASE WHEN t0.place_bsp ~ '^[0-9]+\.?[0-9]*$' AND t0.place_bsp::double precision >= 1.01 THEN t0.place_bsp::double precision ELSE NULL END,
+    CASE WHEN t0.place_ppwap ~ '^[0-9]+\.?[0-9]*$' AND t0.place_ppwap::double precision >= 1.01 THEN t0.place_ppwap::double precision ELSE NULL END
+  FROM %s t0
+  JOIN rmap rm ON rm.race_key = t0.race_key
+  LEFT JOIN stage_horse_ids h
+    ON h.horse = trim(t0.horse)
+   AND h.foaled_year = ` + sqlStagedFoaledYear + `
+  LEFT JOIN j ON j.jockey_norm = racing.norm_text(t0.jockey)
+  LEFT JOIN t ON t.trainer_norm = racing.norm_text(t0.trainer)
+  ON CONFLICT (runner_key, race_date) DO UPDATE SET
+    num         = EXCLUDED.num,
+    pos_raw     = EXCLUDED.pos_raw,
+    draw        = EXCLUDED.draw,
+    horse_id    = COALESCE(runners.horse_id, EXCLUDED.horse_id),
+    age         = EXCLUDED.age,
+    jockey_id   = COALESCE(EXCLUDED.jockey_id, runners.jockey_id),
+    trainer_id  = COALESCE(EXCLUDED.trainer_id, runners.trainer_id),
+    lbs         = EXCLUDED.lbs,
+    "or"        = COALESCE(EXCLUDED."or", runners."or"),
+    rpr         = COALESCE(EXCLUDED.rpr, runners.rpr),
+    comment     = COALESCE(EXCLUDED.comment, runners.comment),
+    win_bsp     = COALESCE(EXCLUDED.win_bsp, runners.win_bsp),
+    win_ppwap   = COALESCE(EXCLUDED.win_ppwap, runners.win_ppwap),
+    place_bsp   = COALESCE(EXCLUDED.place_bsp, runners.place_bsp),
+    place_ppwap = COALESCE(EXCLUDED.place_ppwap, runners.place_ppwap)
+  RETURNING 1
+)
+SELECT count(*) FROM ins;
+`
+
+// Days are recorded under the ingested_days code (pipeline.RaceCode); $1 = etl run
+const sqlMarkIngested = `
+INSERT INTO racing.ingested_days (region, code, d, run_id)
+SELECT DISTINCT
+  lower(t.region),
+  CASE WHEN lower(trim(t.type)) = 'flat' THEN 'flat' ELSE 'jumps' END,
+  t.date::date,
+  $1
+FROM %s t
+WHERE NULLIF(t.region, '') IS NOT NULL AND NULLIF(t.date, '') IS NOT NULL
+ON CONFLICT (region, code, d) DO UPDATE SET
+  run_id = EXCLUDED.run_id,
+  inserted_at = now();
+`
@@ -0,0 +1,201 @@
+package loader
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"giddyup/api/internal/quality"
+
+	"github.com/lib/pq"
+)
+
+// screen checks staged races and runners against the data-quality rules,
+// deletes the rows that break an error rule from the staged tables (so the
+// merge never sees them) and records every issue in racing.quarantine. Staged
+// rows are addressed by ctid: the staging tables have no keys of their own.
+func screen(ctx context.Context, tx *sql.Tx, racesTable, runnersTable string, src quality.Source) (*quality.Report, error) {
+	races, err := stagedRaces(ctx, tx, racesTable, runnersTable)
+	if err != nil {
+		return nil, err
+	}
+
+	report := quality.Check(races)
+	keepRace, keepRunner := report.Keep(races)
+
+	// Held races take their runners with them, unless the key survives in
+	// another staged row (the kept copy of a duplicate)
+	keptKeys := make(map[string]bool)
+	for i, race := range races {
+		if keepRace[i] {
+			keptKeys[race.Key] = true
+		}
+	}
+	var raceRefs, runnerRefs, heldKeys []string
+	for i, race := range races {
+		if !keepRace[i] {
+			raceRefs = append(raceRefs, race.Ref)
+			if !keptKeys[race.Key] {
+				heldKeys = append(heldKeys, race.Key)
+			}
+			continue
+		}
+		for j, runner := range race.Runners {
+			if !keepRunner[i][j] {
+				runnerRefs = append(runnerRefs, runner.Ref)
+			}
+		}
+	}
+
+	raceRows, err := deleteStaged(ctx, tx, racesTable, `ctid = ANY($1::tid[])`, raceRefs)
+	if err != nil {
+		return nil, err
+	}
+	runnerRows, err := deleteStaged(ctx, tx, runnersTable, `ctid = ANY($1::tid[])`, runnerRefs)
+	if err != nil {
+		return nil, err
+	}
+	heldRunners, err := deleteStaged(ctx, tx, runnersTable, `race_key = ANY($1::text[])`, heldKeys)
+	if err != nil {
+		return nil, err
+	}
+	fieldByKey := make(map[string][]json.RawMessage)
+	for _, row := range heldRunners {
+		fieldByKey[row.raceKey] = append(fieldByKey[row.raceKey], row.data)
+	}
+
+	// Quarantine payloads are the deleted staged rows
+	for i := range report.Issues {
+		issue := &report.Issues[i]
+		if !issue.Held() {
+			continue
+		}
+		race := races[issue.RaceIndex]
+		if issue.RunnerIndex >= 0 {
+			issue.Payload = runnerRows[race.Runners[issue.RunnerIndex].Ref].data
+			continue
+		}
+		payload := map[string]interface{}{"race": raceRows[race.Ref].data}
+		if !keptKeys[race.Key] {
+			payload["runners"] = fieldByKey[race.Key]
+		}
+		issue.Payload = payload
+	}
+
+	if err := quality.RecordTx(ctx, tx, src, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// stagedRaces reads the staged races with their runners in staging order.
+// Staged runners carry only the race key, so a key staged on two dates gives
+// both races the same runners.
+func stagedRaces(ctx context.Context, tx *sql.Tx, racesTable, runnersTable string) ([]quality.Race, error) {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`
+		SELECT ctid::text, COALESCE(race_key, ''), COALESCE(date, ''), COALESCE(region, ''),
+		       COALESCE(course, ''), COALESCE(off, ''), COALESCE(ran, '')
+		FROM %s ORDER BY ctid
+	`, racesTable))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read staged races: %w", err)
+	}
+	var races []quality.Race
+	byKey := make(map[string][]int)
+	for rows.Next() {
+		var r quality.Race
+		var ran string
+		if err := rows.Scan(&r.Ref, &r.Key, &r.Date, &r.Region, &r.Course, &r.OffTime, &ran); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		r.Ran = digits(ran)
+		byKey[r.Key] = append(byKey[r.Key], len(races))
+		races = append(races, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.QueryContext(ctx, fmt.Sprintf(`
+		SELECT ctid::text, COALESCE(race_key, ''), COALESCE(runner_key, ''), COALESCE(num, ''),
+		       COALESCE(pos, ''), COALESCE(draw, ''), COALESCE(horse, ''),
+		       COALESCE(win_bsp, ''), COALESCE(place_bsp, ''), COALESCE(dec, '')
+		FROM %s ORDER BY ctid
+	`, runnersTable))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read staged runners: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var r quality.Runner
+		var raceKey, num, draw, winBSP, placeBSP, dec string
+		if err := rows.Scan(&r.Ref, &raceKey, &r.Key, &num, &r.Pos, &draw, &r.Horse, &winBSP, &placeBSP, &dec); err != nil {
+			return nil, err
+		}
+		r.Num, r.Draw = digits(num), digits(draw)
+		r.WinBSP, r.PlaceBSP, r.Dec = price(winBSP), price(placeBSP), price(dec)
+		for _, i := range byKey[raceKey] {
+			races[i].Runners = append(races[i].Runners, r)
+		}
+	}
+	return races, rows.Err()
+}
+
+// stagedRow is a deleted staged row
+type stagedRow struct {
+	raceKey string
+	data    json.RawMessage
+}
+
+// deleteStaged deletes staged rows matching where ($1 = args) and returns
+// them by ctid
+func deleteStaged(ctx context.Context, tx *sql.Tx, table, where string, args []string) (map[string]stagedRow, error) {
+	deleted := make(map[string]stagedRow)
+	if len(args) == 0 {
+		return deleted, nil
+	}
+
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`
+		DELETE FROM %s t WHERE %s
+		RETURNING t.ctid::text, COALESCE(t.race_key, ''), to_jsonb(t)::text
+	`, table, where), pq.Array(args))
+	if err != nil {
+		return nil, fmt.Errorf("failed to quarantine staged rows from %s: %w", table, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ctid, data string
+		var row stagedRow
+		if err := rows.Scan(&ctid, &row.raceKey, &data); err != nil {
+			return nil, err
+		}
+		row.data = json.RawMessage(data)
+		deleted[ctid] = row
+	}
+	return deleted, rows.Err()
+}
+
+// digits parses the digits of a staged integer ("3", "(3)", "12a"), 0 if none
+func digits(s string) int {
+	n, _ := strconv.Atoi(strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s))
+	return n
+}
+
+// price parses a staged decimal, 0 if it isn't one
+func price(s string) float64 {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0
+	}
+	return f
+}
@@ -0,0 +1,230 @@
+package matching
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+)
+
+// Course name sources recorded in racing.course_alias
+const (
+	CourseSourceSportingLife = "sportinglife"
+	CourseSourceBetfair      = "betfair"
+	CourseSourceMaster       = "master"
+	CourseSourceManual       = "manual"
+)
+
+// CourseSources lists the valid racing.course_alias sources
+var CourseSources = []string{CourseSourceSportingLife, CourseSourceBetfair, CourseSourceMaster, CourseSourceManual}
+
+// builtinCourseAliases maps alternative course spellings (after courseKey) to one
+// canonical key. Sporting Life uses full course names, Betfair uses short venue
+// names. Used until (and alongside) the racing.course_alias registry is loaded.
+var builtinCourseAliases = map[string]string{
+	"kemptonpark":     "kempton",
+	"sandownpark":     "sandown",
+	"haydockpark":     "haydock",
+	"lingfieldpark":   "lingfield",
+	"epsomdowns":      "epsom",
+	"catterickbridge": "catterick",
+	"greatyarmouth":   "yarmouth",
+	"newmarketjuly":   "newmarket",
+	"newmarketrowley": "newmarket",
+	"cartmelpark":     "cartmel",
+	"bangorondee":     "bangor",
+	"bangorondeeside": "bangor",
+	"stratfordonavon": "stratford",
+	"perthhunt":       "perth",
+	"gowranpark":      "gowran",
+	"chelmsfordcity":  "chelmsford",
+	"parislongchamp":  "longchamp",
+	"saintcloud":      "stcloud",
+	"santaanitapark":  "santaanita",
+}
+
+// CourseRegistry maps every known spelling of a course to its canonical key,
+// and canonical courses to their timezones
+type CourseRegistry struct {
+	mu        sync.RWMutex
+	aliases   map[string]string // courseKey(alias) → courseKey(canonical name)
+	timezones map[string]string // "REGION|canonical key" → IANA timezone (racing.courses.timezone)
+}
+
+// NewCourseRegistry returns a registry holding the built-in aliases
+func NewCourseRegistry() *CourseRegistry {
+	r := &CourseRegistry{
+		aliases:   make(map[string]string, len(builtinCourseAliases)),
+		timezones: make(map[string]string),
+	}
+	for alias, canonical := range builtinCourseAliases {
+		r.aliases[alias] = canonical
+	}
+	return r
+}
+
+// AddAlias records that alias is another name for the course called canonical
+func (r *CourseRegistry) AddAlias(canonical, alias string) {
+	c, a := courseKey(canonical), courseKey(alias)
+	if c == "" || a == "" || c == a {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Follow an existing alias so chains collapse to one canonical key
+	if target, ok := r.aliases[c]; ok {
+		c = target
+	}
+	if a != c {
+		r.aliases[a] = c
+	}
+}
+
+// Canonical returns the canonical key for a normalized course name
+func (r *CourseRegistry) Canonical(key string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.aliases[key]
+	return c, ok
+}
+
+// SetTimezone records the timezone of a course in a region
+func (r *CourseRegistry) SetTimezone(region, course, timezone string) {
+	key := courseKey(course)
+	if key == "" || timezone == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.aliases[key]; ok {
+		key = c
+	}
+	r.timezones[timezoneKey(region, key)] = timezone
+}
+
+// Timezone returns the timezone recorded for a course in a region, found by
+// any of its names
+func (r *CourseRegistry) Timezone(region, course string) (string, bool) {
+	key := courseKey(course)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if c, ok := r.aliases[key]; ok {
+		key = c
+	}
+	tz, ok := r.timezones[timezoneKey(region, key)]
+	return tz, ok
+}
+
+func timezoneKey(region, key string) string {
+	return strings.ToUpper(strings.TrimSpace(region)) + "|" + key
+}
+
+// Len returns the number of aliases known
+func (r *CourseRegistry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.aliases)
+}
+
+// LoadCourseRegistry builds a registry from the built-in aliases plus every
+// racing.course_alias row, and the racing.courses timezones
+func LoadCourseRegistry(db *sql.DB) (*CourseRegistry, error) {
+	rows, err := db.Query(`
+		SELECT c.course_name, a.alias
+		FROM racing.course_alias a
+		JOIN racing.courses c ON c.course_id = a.course_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load course aliases: %w", err)
+	}
+	defer rows.Close()
+
+	r := NewCourseRegistry()
+	for rows.Next() {
+		var canonical, alias string
+		if err := rows.Scan(&canonical, &alias); err != nil {
+			return nil, fmt.Errorf("failed to scan course alias: %w", err)
+		}
+		r.AddAlias(canonical, alias)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// After the aliases, so each timezone lands on its course's canonical key
+	tzRows, err := db.Query(`
+		SELECT region, course_name, timezone
+		FROM racing.courses
+		WHERE timezone IS NOT NULL
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load course timezones: %w", err)
+	}
+	defer tzRows.Close()
+
+	for tzRows.Next() {
+		var region, course, timezone string
+		if err := tzRows.Scan(&region, &course, &timezone); err != nil {
+			return nil, fmt.Errorf("failed to scan course timezone: %w", err)
+		}
+		r.SetTimezone(region, course, timezone)
+	}
+	return r, tzRows.Err()
+}
+
+var (
+	coursesMu sync.RWMutex
+	courses   = NewCourseRegistry()
+)
+
+// SetCourseRegistry installs the registry NormalizeCourseName resolves through
+// (call at startup; nil restores the built-in aliases)
+func SetCourseRegistry(r *CourseRegistry) {
+	if r == nil {
+		r = NewCourseRegistry()
+	}
+	coursesMu.Lock()
+	defer coursesMu.Unlock()
+	courses = r
+}
+
+// UseCourseRegistry loads the registry from racing.course_alias and
+// racing.courses and installs it, keeping the built-in aliases (and
+// jurisdiction timezones) if the load fails
+func UseCourseRegistry(db *sql.DB) {
+	r, err := LoadCourseRegistry(db)
+	if err != nil {
+		log.Printf("[CourseRegistry] ⚠️  %v - using built-in aliases", err)
+		return
+	}
+	SetCourseRegistry(r)
+	log.Printf("[CourseRegistry] Loaded %d course aliases", r.Len())
+}
+
+// Courses returns the active course registry
+func Courses() *CourseRegistry {
+	coursesMu.RLock()
+	defer coursesMu.RUnlock()
+	return courses
+}
+
+// courseKey is the spelling-insensitive form of a course name
+// ("Kempton (AW)" → "kempton", "Bangor-on-Dee" → "bangorondee")
+func courseKey(course string) string {
+	course = strings.ToLower(course)
+	course = strings.TrimSpace(course)
+
+	// Remove "AW" suffix
+	course = strings.TrimSuffix(course, " (aw)")
+	course = strings.TrimSuffix(course, " aw")
+
+	// Remove common variations
+	course = strings.ReplaceAll(course, " ", "")
+	course = strings.ReplaceAll(course, "-", "")
+	course = strings.ReplaceAll(course, "'", "")
+	course = strings.ReplaceAll(course, "(", "")
+	course = strings.ReplaceAll(course, ")", "")
+
+	return course
+}
@@ -0,0 +1,458 @@
+// Package matching is the single Sporting Life ↔ Betfair race matching engine.
+//
+// Every pipeline (live market discovery, historical CSV merge, master stitcher)
+// describes both sides as Race values and asks the Engine for the best candidate.
+// Candidates are scored on time distance, venue, field overlap, race type and
+// handicap flag; a match needs a minimum total score and a clear margin over the
+// runner-up, and each candidate can only be used once.
+//
+// Manual pins and forbids (racing.match_overrides) are honoured by every engine
+// created with NewForPipeline, and each attempt is written to racing.match_audit
+// once a Store is installed with SetStore.
+package matching
+
+import (
+	"os"
+	"sort"
+	"strconv"
+)
+
+// Race is one side of a potential match (a Sporting Life race, a Betfair market,
+// or a group of Betfair CSV prices). Only Date and OffTime are required.
+type Race struct {
+	Date     string   // YYYY-MM-DD
+	Venue    string   // Course / venue name as the source spells it
+	OffTime  string   // UK local, "HH:MM" or "HH:MM:SS"
+	Name     string   // Race or market name
+	RaceType string   // Flat, Hurdle, Chase, NH Flat ("" = infer from name)
+	Horses   []string // Runner names (normalized internally)
+	Key      string   // race_key (targets; used for overrides and audit)
+	Ref      string   // Betfair-side ref (candidates; market ID or MarketRef)
+}
+
+// Weights controls how much each component contributes to the total score (sum to 1)
+type Weights struct {
+	Time     float64
+	Venue    float64
+	Field    float64
+	Type     float64
+	Handicap float64
+}
+
+// Config holds the matching thresholds
+type Config struct {
+	MaxTimeDiffMins int     // Candidates further apart than this are never considered
+	MinScore        float64 // Minimum total score to accept a match
+	MinMargin       float64 // Best must beat the runner-up by at least this much
+	RunnerMinScore  float64 // Minimum name similarity to pair two runners
+	Weights         Weights
+}
+
+// DefaultConfig returns the standard thresholds used by all pipelines
+func DefaultConfig() Config {
+	return Config{
+		MaxTimeDiffMins: 10,
+		MinScore:        0.6,
+		MinMargin:       0.05,
+		RunnerMinScore:  0.85,
+		Weights: Weights{
+			Time:     0.30,
+			Venue:    0.30,
+			Field:    0.25,
+			Type:     0.10,
+			Handicap: 0.05,
+		},
+	}
+}
+
+// ConfigFromEnv returns DefaultConfig overridden by MATCH_MAX_TIME_DIFF_MINS,
+// MATCH_MIN_SCORE, MATCH_MIN_MARGIN and MATCH_RUNNER_MIN_SCORE
+func ConfigFromEnv() Config {
+	cfg := DefaultConfig()
+	if v := os.Getenv("MATCH_MAX_TIME_DIFF_MINS"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
+			cfg.MaxTimeDiffMins = parsed
+		}
+	}
+	if v := os.Getenv("MATCH_MIN_SCORE"); v != "" {
+		if parsed, err := strconv.ParseFloat(v, 64); err == nil && parsed > 0 && parsed <= 1 {
+			cfg.MinScore = parsed
+		}
+	}
+	if v := os.Getenv("MATCH_MIN_MARGIN"); v != "" {
+		if parsed, err := strconv.ParseFloat(v, 64); err == nil && parsed >= 0 {
+			cfg.MinMargin = parsed
+		}
+	}
+	if v := os.Getenv("MATCH_RUNNER_MIN_SCORE"); v != "" {
+		if parsed, err := strconv.ParseFloat(v, 64); err == nil && parsed > 0 && parsed <= 1 {
+			cfg.RunnerMinScore = parsed
+		}
+	}
+	return cfg
+}
+
+// Score is the breakdown of one target/candidate comparison. Components are 0..1,
+// 0.5 meaning "unknown" (e.g. no runners listed on one side).
+type Score struct {
+	Total        float64 `json:"total"`
+	Time         float64 `json:"time"`
+	Venue        float64 `json:"venue"`
+	Field        float64 `json:"field"`
+	Type         float64 `json:"type"`
+	Handicap     float64 `json:"handicap"`
+	TimeDiffMins int     `json:"time_diff_mins"`
+	Jaccard      float64 `json:"jaccard"`
+}
+
+// Match outcomes
+const (
+	OutcomeMatched        = "matched"
+	OutcomeNoCandidates   = "no_candidates"   // Nothing on the same date within the time window
+	OutcomeBelowThreshold = "below_threshold" // Best candidate scored under MinScore
+	OutcomeAmbiguous      = "ambiguous"       // Runner-up too close to the best
+	OutcomeTaken          = "taken"           // Best candidate was assigned to a better-scoring target
+)
+
+// Result is the common match result for one target
+type Result struct {
+	Target    int     `json:"target"`    // Index into targets
+	Candidate int     `json:"candidate"` // Index into candidates (-1 when unmatched)
+	Outcome   string  `json:"outcome"`
+	Score     Score   `json:"score"`              // Score of the chosen (or best rejected) candidate
+	RunnerUp  float64 `json:"runner_up"`          // Total score of the second-best candidate
+	Override  string  `json:"override,omitempty"` // ActionPin when the pairing came from a manual pin
+}
+
+// Matched reports whether the target was matched to a candidate
+func (r Result) Matched() bool {
+	return r.Outcome == OutcomeMatched
+}
+
+// Engine scores and assigns candidates
+type Engine struct {
+	cfg       Config
+	pipeline  string     // Audit label ("" = no overrides/audit)
+	overrides *Overrides // Explicit overrides (nil = load from the store per run)
+	loaded    *Overrides // Overrides loaded by the last MatchAll (reused by MatchRunners)
+	pending   []AuditEntry
+}
+
+// New creates an engine with the given config
+func New(cfg Config) *Engine {
+	return &Engine{cfg: cfg}
+}
+
+// NewDefault creates an engine from ConfigFromEnv
+func NewDefault() *Engine {
+	return New(ConfigFromEnv())
+}
+
+// NewForPipeline creates an engine from ConfigFromEnv that honours overrides and
+// audits every attempt under the given pipeline label
+func NewForPipeline(pipeline string) *Engine {
+	e := NewDefault()
+	e.pipeline = pipeline
+	return e
+}
+
+// WithOverrides uses the given overrides instead of loading them from the store
+func (e *Engine) WithOverrides(o *Overrides) *Engine {
+	e.overrides = o
+	return e
+}
+
+// Config returns the engine's thresholds
+func (e *Engine) Config() Config {
+	return e.cfg
+}
+
+// Compare scores a candidate against a target. ok is false when the pair is
+// outside the hard filters (different date, or too far apart in time).
+func (e *Engine) Compare(target, candidate Race) (Score, bool) {
+	var s Score
+
+	if target.Date != "" && candidate.Date != "" && target.Date != candidate.Date {
+		return s, false
+	}
+
+	s.TimeDiffMins = TimeDiffMinutes(target.OffTime, candidate.OffTime)
+	if s.TimeDiffMins < 0 || s.TimeDiffMins > e.cfg.MaxTimeDiffMins {
+		return s, false
+	}
+	s.Time = 1 - float64(s.TimeDiffMins)/float64(e.cfg.MaxTimeDiffMins+1)
+
+	// Venue
+	tv, cv := CanonicalVenue(target.Venue), CanonicalVenue(candidate.Venue)
+	switch {
+	case tv == "" || cv == "":
+		s.Venue = 0.5
+	case tv == cv:
+		s.Venue = 1
+	}
+
+	// Field overlap
+	th, ch := horseSet(target.Horses), horseSet(candidate.Horses)
+	if len(th) == 0 || len(ch) == 0 {
+		s.Field = 0.5
+	} else {
+		s.Jaccard = jaccard(th, ch)
+		s.Field = s.Jaccard
+	}
+
+	// Race type
+	tt, ct := raceType(target), raceType(candidate)
+	switch {
+	case tt == "" || ct == "":
+		s.Type = 0.5
+	case tt == ct:
+		s.Type = 1
+	}
+
+	// Handicap flag (only meaningful if at least one name is present on each side)
+	if target.Name == "" || candidate.Name == "" {
+		s.Handicap = 0.5
+	} else if IsHandicap(target.Name) == IsHandicap(candidate.Name) {
+		s.Handicap = 1
+	}
+
+	w := e.cfg.Weights
+	s.Total = w.Time*s.Time + w.Venue*s.Venue + w.Field*s.Field + w.Type*s.Type + w.Handicap*s.Handicap
+	return s, true
+}
+
+// Best finds the best candidate for a single target
+func (e *Engine) Best(target Race, candidates []Race) Result {
+	return e.MatchAll([]Race{target}, candidates)[0]
+}
+
+// MatchAll matches every target to at most one candidate and every candidate to at
+// most one target. Pairs are assigned greedily from the highest score down, so a
+// weaker target can't steal a candidate that fits another target better.
+//
+// Pinned pairs are assigned before scoring (a target pinned to an absent market
+// stays unmatched), forbidden pairs are never considered, and a candidate pinned
+// to one target is withheld from all others.
+func (e *Engine) MatchAll(targets, candidates []Race) []Result {
+	type pair struct {
+		t, c  int
+		score Score
+	}
+
+	ov := e.overrides
+	if ov == nil && e.pipeline != "" {
+		ov = loadOverrides(targets)
+		e.loaded = ov
+	}
+
+	// Candidates claimed by a pin, and the candidate each pinned target gets
+	candidateByRef := make(map[string]int)
+	for ci, candidate := range candidates {
+		if candidate.Ref != "" {
+			candidateByRef[candidate.Ref] = ci
+		}
+	}
+	pinnedTo := make(map[int]int) // candidate → target
+	pins := make(map[int]int)     // target → candidate (-1 = pinned market absent)
+	for ti, target := range targets {
+		ref := ov.RacePin(target.Key)
+		if ref == "" {
+			continue
+		}
+		ci, ok := candidateByRef[ref]
+		if !ok {
+			pins[ti] = -1
+			continue
+		}
+		if _, taken := pinnedTo[ci]; !taken {
+			pinnedTo[ci] = ti
+			pins[ti] = ci
+		}
+	}
+
+	results := make([]Result, len(targets))
+	audits := make([][]AuditCandidate, len(targets))
+	var pairs []pair
+
+	for ti, target := range targets {
+		results[ti] = Result{Target: ti, Candidate: -1, Outcome: OutcomeNoCandidates}
+
+		best, second := -1.0, 0.0
+		for ci, candidate := range candidates {
+			s, ok := e.Compare(target, candidate)
+			if !ok {
+				continue
+			}
+			forbidden := ov.RaceAction(target.Key, candidate.Ref) == ActionForbid
+			if e.pipeline != "" {
+				audits[ti] = append(audits[ti], AuditCandidate{
+					Ref: candidate.Ref, Label: candidateLabel(candidate),
+					Score: &s, Total: s.Total, Forbidden: forbidden,
+				})
+			}
+			if forbidden {
+				continue
+			}
+			if owner, claimed := pinnedTo[ci]; claimed && owner != ti {
+				continue
+			}
+			if s.Total > best {
+				second = best
+				best = s.Total
+				results[ti].Score = s
+			} else if s.Total > second {
+				second = s.Total
+			}
+			if s.Total >= e.cfg.MinScore {
+				pairs = append(pairs, pair{t: ti, c: ci, score: s})
+			}
+		}
+		if second < 0 {
+			second = 0
+		}
+
+		switch {
+		case best < 0:
+			// No candidates - keep OutcomeNoCandidates
+		case best < e.cfg.MinScore:
+			results[ti].Outcome = OutcomeBelowThreshold
+			results[ti].RunnerUp = second
+		case best-second < e.cfg.MinMargin:
+			results[ti].Outcome = OutcomeAmbiguous
+			results[ti].RunnerUp = second
+		default:
+			results[ti].Outcome = OutcomeTaken // Until assigned below
+			results[ti].RunnerUp = second
+		}
+	}
+
+	// Pins win regardless of score
+	usedCandidates := make(map[int]bool)
+	for ti, ci := range pins {
+		r := &results[ti]
+		r.Override = ActionPin
+		if ci < 0 {
+			r.Candidate = -1
+			r.Outcome = OutcomeNoCandidates
+			continue
+		}
+		r.Score, _ = e.Compare(targets[ti], candidates[ci])
+		r.Candidate = ci
+		r.Outcome = OutcomeMatched
+		usedCandidates[ci] = true
+	}
+
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return pairs[i].score.Total > pairs[j].score.Total
+	})
+
+	for _, p := range pairs {
+		r := &results[p.t]
+		if r.Outcome != OutcomeTaken || usedCandidates[p.c] {
+			continue
+		}
+		r.Candidate = p.c
+		r.Score = p.score
+		r.Outcome = OutcomeMatched
+		usedCandidates[p.c] = true
+	}
+
+	if e.pipeline != "" {
+		e.auditRaces(targets, candidates, results, audits)
+	}
+
+	return results
+}
+
+// auditRaces writes one racing.match_audit row per target
+func (e *Engine) auditRaces(targets, candidates []Race, results []Result, audits [][]AuditCandidate) {
+	entries := make([]AuditEntry, 0, len(results))
+	for _, r := range results {
+		target := targets[r.Target]
+		entry := AuditEntry{
+			Pipeline:   e.pipeline,
+			Level:      LevelRace,
+			RaceDate:   target.Date,
+			RaceKey:    target.Key,
+			Subject:    candidateLabel(target),
+			Outcome:    r.Outcome,
+			Score:      r.Score.Total,
+			RunnerUp:   r.RunnerUp,
+			Override:   r.Override,
+			Candidates: topCandidates(audits[r.Target]),
+		}
+		if r.Candidate >= 0 {
+			entry.ChosenRef = candidates[r.Candidate].Ref
+		}
+		entries = append(entries, entry)
+	}
+	e.pending = append(e.pending, entries...)
+	e.Flush()
+}
+
+// Flush writes any buffered audit entries (runner attempts are buffered until the
+// next MatchAll or an explicit Flush)
+func (e *Engine) Flush() {
+	saveAudit(e.pending)
+	e.pending = nil
+}
+
+// activeOverrides returns the explicit overrides, else those loaded by MatchAll
+func (e *Engine) activeOverrides() *Overrides {
+	if e.overrides != nil {
+		return e.overrides
+	}
+	return e.loaded
+}
+
+func candidateLabel(r Race) string {
+	return r.Venue + " " + NormalizeTime(r.OffTime)
+}
+
+// Summary counts results by outcome (for logging)
+func Summary(results []Result) map[string]int {
+	counts := make(map[string]int)
+	for _, r := range results {
+		counts[r.Outcome]++
+	}
+	return counts
+}
+
+func raceType(r Race) string {
+	if t := NormalizeRaceType(r.RaceType); t != "" {
+		return t
+	}
+	return RaceTypeFromName(r.Name)
+}
+
+func horseSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, n := range names {
+		if norm := NormalizeName(n); norm != "" {
+			set[norm] = true
+		}
+	}
+	return set
+}
+
+// Jaccard returns the Jaccard similarity of two name lists (after NormalizeName)
+func Jaccard(a, b []string) float64 {
+	return jaccard(horseSet(a), horseSet(b))
+}
+
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	intersection := 0
+	for n := range a {
+		if b[n] {
+			intersection++
+		}
+	}
+	union := len(a) + len(b) - intersection
+	if union == 0 {
+		return 0
+	}
+	return float64(intersection) / float64(union)
+}
@@ -0,0 +1,245 @@
+package matching
+
+import (
+	"math"
+	"testing"
+)
+
+// testEngine is an engine with the default thresholds and no store
+func testEngine() *Engine {
+	return New(DefaultConfig())
+}
+
+func race(venue, off, name string, horses ...string) Race {
+	return Race{Date: "2025-10-18", Venue: venue, OffTime: off, Name: name, Horses: horses}
+}
+
+func market(ref, venue, off, name string, horses ...string) Race {
+	r := race(venue, off, name, horses...)
+	r.Ref = ref
+	return r
+}
+
+func TestCompareWeightedScore(t *testing.T) {
+	w := DefaultConfig().Weights
+	field := []string{"Alpha", "Bravo", "Charlie", "Delta"}
+	cases := []struct {
+		name      string
+		target    Race
+		candidate Race
+		ok        bool
+		want      Score
+	}{
+		{
+			name:      "identical",
+			target:    race("Kempton Park", "14:05", "Handicap Hurdle", field...),
+			candidate: race("Kempton", "14:05:00", "2m Hcap Hrd", field...),
+			ok:        true,
+			want:      Score{Time: 1, Venue: 1, Field: 1, Type: 1, Handicap: 1, Jaccard: 1},
+		},
+		{
+			name:      "five minutes out, no runners on one side",
+			target:    race("Kempton", "14:05", "Novices Chase", field...),
+			candidate: race("Kempton", "14:10", "Novices Chase"),
+			ok:        true,
+			want:      Score{Time: 1 - 5.0/11, Venue: 1, Field: 0.5, Type: 1, Handicap: 1, TimeDiffMins: 5},
+		},
+		{
+			name:      "other venue, half the field, type unknown, one handicap",
+			target:    race("Ascot", "15:00", "Maiden Stakes", "Alpha", "Bravo"),
+			candidate: race("Newbury", "15:00", "Handicap", "Alpha", "Bravo", "Charlie", "Delta"),
+			ok:        true,
+			want:      Score{Time: 1, Venue: 0, Field: 0.5, Type: 0.5, Handicap: 0, Jaccard: 0.5},
+		},
+		{
+			name:      "different date",
+			target:    race("Ascot", "15:00", ""),
+			candidate: Race{Date: "2025-10-19", Venue: "Ascot", OffTime: "15:00"},
+		},
+		{
+			name:      "outside the time window",
+			target:    race("Ascot", "15:00", ""),
+			candidate: race("Ascot", "15:11", ""),
+		},
+		{
+			name:      "unparseable off time",
+			target:    race("Ascot", "15:00", ""),
+			candidate: race("Ascot", "", ""),
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := testEngine().Compare(tc.target, tc.candidate)
+			if ok != tc.ok {
+				t.Fatalf("ok = %v, want %v", ok, tc.ok)
+			}
+			if !ok {
+				return
+			}
+			tc.want.Total = w.Time*tc.want.Time + w.Venue*tc.want.Venue + w.Field*tc.want.Field +
+				w.Type*tc.want.Type + w.Handicap*tc.want.Handicap
+			if !closeScores(got, tc.want) {
+				t.Fatalf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMatchAllOutcomes(t *testing.T) {
+	field := []string{"Alpha", "Bravo", "Charlie", "Delta"}
+	target := race("Ascot", "15:00", "Handicap Chase", field...)
+	cases := []struct {
+		name       string
+		candidates []Race
+		outcome    string
+		candidate  int
+	}{
+		{
+			name:       "no candidates",
+			candidates: []Race{market("1.1", "Ascot", "16:00", "Handicap Chase", field...)},
+			outcome:    OutcomeNoCandidates,
+			candidate:  -1,
+		},
+		{
+			name:       "below MinScore",
+			candidates: []Race{market("1.1", "Newbury", "15:09", "Maiden Hurdle", "Echo", "Foxtrot")},
+			outcome:    OutcomeBelowThreshold,
+			candidate:  -1,
+		},
+		{
+			name: "runner-up within MinMargin",
+			candidates: []Race{
+				market("1.1", "Ascot", "15:00", "Handicap Chase", field...),
+				market("1.2", "Ascot", "15:01", "Handicap Chase", field...),
+			},
+			outcome:   OutcomeAmbiguous,
+			candidate: -1,
+		},
+		{
+			name: "clear best",
+			candidates: []Race{
+				market("1.1", "Ascot", "15:05", "Maiden Hurdle", "Echo", "Foxtrot"),
+				market("1.2", "Ascot", "15:00", "Handicap Chase", field...),
+			},
+			outcome:   OutcomeMatched,
+			candidate: 1,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := testEngine().Best(target, tc.candidates)
+			if got.Outcome != tc.outcome || got.Candidate != tc.candidate {
+				t.Fatalf("got %s/%d (score %.3f, runner-up %.3f), want %s/%d",
+					got.Outcome, got.Candidate, got.Score.Total, got.RunnerUp, tc.outcome, tc.candidate)
+			}
+		})
+	}
+}
+
+func TestMatchAllOneToOne(t *testing.T) {
+	// Both targets want the only candidate; the closer one gets it whatever
+	// the order, and the other is left unmatched rather than sharing it
+	field := []string{"Alpha", "Bravo", "Charlie", "Delta"}
+	strong := race("Ascot", "15:00", "Handicap Chase", field...)
+	weak := race("Ascot", "15:04", "Handicap Chase", field...)
+	candidates := []Race{market("1.1", "Ascot", "15:00", "Handicap Chase", field...)}
+
+	for _, order := range [][]Race{{strong, weak}, {weak, strong}} {
+		results := testEngine().MatchAll(order, candidates)
+		for i, r := range results {
+			want := OutcomeTaken
+			if order[i].OffTime == strong.OffTime {
+				want = OutcomeMatched
+			}
+			if r.Outcome != want {
+				t.Fatalf("target %s: got %s, want %s", order[i].OffTime, r.Outcome, want)
+			}
+		}
+	}
+}
+
+func TestMatchAllOverrides(t *testing.T) {
+	field := []string{"Alpha", "Bravo", "Charlie", "Delta"}
+	a := race("Ascot", "15:00", "Handicap Chase", field...)
+	a.Key = "race-a"
+	b := race("Ascot", "15:30", "Maiden Hurdle", "Echo", "Foxtrot")
+	b.Key = "race-b"
+	good := market("1.1", "Ascot", "15:00", "Handicap Chase", field...)
+	poor := market("1.2", "Newbury", "15:08", "Novices Hurdle", "Golf")
+	late := market("1.3", "Ascot", "15:30", "Maiden Hurdle", "Echo", "Foxtrot")
+
+	cases := []struct {
+		name       string
+		overrides  []Override
+		targets    []Race
+		candidates []Race
+		want       []string // Outcome per target
+		chosen     []int    // Candidate per target
+		pinned     []bool
+	}{
+		{
+			name:       "pin beats a better score",
+			overrides:  []Override{{Level: LevelRace, RaceKey: "race-a", BFRef: "1.2", Action: ActionPin}},
+			targets:    []Race{a},
+			candidates: []Race{good, poor},
+			want:       []string{OutcomeMatched},
+			chosen:     []int{1},
+			pinned:     []bool{true},
+		},
+		{
+			name:       "pinned market absent",
+			overrides:  []Override{{Level: LevelRace, RaceKey: "race-a", BFRef: "1.9", Action: ActionPin}},
+			targets:    []Race{a},
+			candidates: []Race{good},
+			want:       []string{OutcomeNoCandidates},
+			chosen:     []int{-1},
+			pinned:     []bool{true},
+		},
+		{
+			name:       "forbidden pair is skipped",
+			overrides:  []Override{{Level: LevelRace, RaceKey: "race-a", BFRef: "1.1", Action: ActionForbid}},
+			targets:    []Race{a},
+			candidates: []Race{good},
+			want:       []string{OutcomeNoCandidates},
+			chosen:     []int{-1},
+			pinned:     []bool{false},
+		},
+		{
+			name:       "candidate pinned elsewhere is withheld",
+			overrides:  []Override{{Level: LevelRace, RaceKey: "race-b", BFRef: "1.1", Action: ActionPin}},
+			targets:    []Race{a, b},
+			candidates: []Race{good, late},
+			want:       []string{OutcomeNoCandidates, OutcomeMatched},
+			chosen:     []int{-1, 0},
+			pinned:     []bool{false, true},
+		},
+		{
+			name:       "pin by venue and off time",
+			overrides:  []Override{{Level: LevelRace, RaceKey: "race-a", BFRef: "Kempton Park|15:08", Action: ActionPin}},
+			targets:    []Race{a},
+			candidates: []Race{good, market(MarketRef("Kempton", "15:08"), "Kempton", "15:08", "")},
+			want:       []string{OutcomeMatched},
+			chosen:     []int{1},
+			pinned:     []bool{true},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := testEngine().WithOverrides(NewOverrides(tc.overrides))
+			results := e.MatchAll(tc.targets, tc.candidates)
+			for i, r := range results {
+				if r.Outcome != tc.want[i] || r.Candidate != tc.chosen[i] || (r.Override == ActionPin) != tc.pinned[i] {
+					t.Fatalf("target %d: got %s/%d override=%q, want %s/%d pinned=%v",
+						i, r.Outcome, r.Candidate, r.Override, tc.want[i], tc.chosen[i], tc.pinned[i])
+				}
+			}
+		})
+	}
+}
+
+func closeScores(a, b Score) bool {
+	near := func(x, y float64) bool { return math.Abs(x-y) < 1e-9 }
+	return near(a.Total, b.Total) && near(a.Time, b.Time) && near(a.Venue, b.Venue) &&
+		near(a.Field, b.Field) && near(a.Type, b.Type) && near(a.Handicap, b.Handicap) &&
+		near(a.Jaccard, b.Jaccard) && a.TimeDiffMins == b.TimeDiffMins
+}
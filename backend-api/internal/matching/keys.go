@@ -0,0 +1,34 @@
+package matching
+
+import (
+	"crypto/md5"
+	"fmt"
+	"strings"
+)
+
+// RaceKey is the idempotency key for racing.races.race_key:
+// MD5(date|REGION|course|HH:MM|race name|type), with course, name and type lowercased.
+// Every pipeline must use this so live, backfill and master loads land on the same row.
+func RaceKey(date, region, course, offTime, raceName, raceType string) string {
+	normCourse := strings.ToLower(strings.TrimSpace(course))
+	normTime := strings.TrimSpace(offTime)
+	if len(normTime) >= 5 {
+		normTime = normTime[:5] // Strip seconds: "12:35:00" → "12:35"
+	}
+	normName := strings.ToLower(strings.TrimSpace(raceName))
+	normType := strings.ToLower(strings.TrimSpace(raceType))
+	normRegion := strings.ToUpper(strings.TrimSpace(region))
+
+	data := fmt.Sprintf("%s|%s|%s|%s|%s|%s", date, normRegion, normCourse, normTime, normName, normType)
+	hash := md5.Sum([]byte(data))
+	return fmt.Sprintf("%x", hash)
+}
+
+// RunnerKey is the idempotency key for racing.runners.runner_key:
+// MD5(race_key|horse|num|draw), with the horse name lowercased
+func RunnerKey(raceKey, horse string, num, draw int) string {
+	normHorse := strings.ToLower(strings.TrimSpace(horse))
+	data := fmt.Sprintf("%s|%s|%d|%d", raceKey, normHorse, num, draw)
+	hash := md5.Sum([]byte(data))
+	return fmt.Sprintf("%x", hash)
+}
@@ -0,0 +1,217 @@
+package matching
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	romanSuffixRe = regexp.MustCompile(`\s+(i|ii|iii|iv|v|vi)$`)
+	whitespaceRe  = regexp.MustCompile(`\s+`)
+)
+
+// NormalizeName removes accents, punctuation, country codes
+// This matches the Python normalize_name function
+func NormalizeName(name string) string {
+	if name == "" {
+		return ""
+	}
+
+	// Remove country codes: (IRE), (GB), (FR), etc.
+	if idx := strings.Index(name, "("); idx != -1 {
+		name = name[:idx]
+	}
+
+	// Convert to lowercase first
+	name = strings.ToLower(name)
+
+	// Remove Roman numerals at end: "Name II" -> "Name"
+	name = romanSuffixRe.ReplaceAllString(name, "")
+
+	// Remove accents using unicode normalization
+	// NFD = Canonical Decomposition (separates accents from base characters)
+	// Remove Mark Nonspacing (removes the accent marks)
+	// NFC = Canonical Composition (recomposes characters)
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	name, _, _ = transform.String(t, name)
+
+	// Remove punctuation
+	name = strings.ReplaceAll(name, ".", " ")
+	name = strings.ReplaceAll(name, "'", "")
+	name = strings.ReplaceAll(name, "-", " ")
+	name = strings.ReplaceAll(name, ",", " ")
+
+	// Collapse whitespace
+	name = whitespaceRe.ReplaceAllString(name, " ")
+
+	return strings.TrimSpace(name)
+}
+
+// NormalizeCourseName normalizes course name for matching and resolves it through
+// the course registry, so every source's spelling of a course gives the same key
+// ("Kempton Park" and "Kempton" both become "kempton")
+func NormalizeCourseName(course string) string {
+	key := courseKey(course)
+	if canonical, ok := Courses().Canonical(key); ok {
+		return canonical
+	}
+	return key
+}
+
+// CanonicalVenue normalizes a venue through the course registry. Parenthesised
+// suffixes such as "(July)" or "(AW)" are dropped.
+func CanonicalVenue(venue string) string {
+	if idx := strings.Index(venue, "("); idx != -1 {
+		venue = venue[:idx]
+	}
+	return NormalizeCourseName(venue)
+}
+
+// NormalizeTime handles all time formats and returns canonical "HH:MM"
+func NormalizeTime(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return ""
+	}
+
+	// Fast-path: exact "HH:MM"
+	if len(s) == 5 && s[2] == ':' && s[0] >= '0' && s[0] <= '2' {
+		return s
+	}
+
+	// Accept "HH:MM:SS"
+	if len(s) >= 7 && strings.Count(s, ":") >= 2 {
+		parts := strings.SplitN(s, ":", 3)
+		if len(parts) >= 2 {
+			h, err1 := strconv.Atoi(parts[0])
+			m, err2 := strconv.Atoi(parts[1])
+			if err1 == nil && err2 == nil && h >= 0 && h < 24 && m >= 0 && m < 60 {
+				return fmt.Sprintf("%02d:%02d", h, m)
+			}
+		}
+	}
+
+	// Accept "H:MM" and "HH:MM" (non-zero-padded hour)
+	if strings.Count(s, ":") == 1 {
+		parts := strings.SplitN(s, ":", 2)
+		if len(parts) == 2 {
+			h, err1 := strconv.Atoi(parts[0])
+			m, err2 := strconv.Atoi(parts[1])
+			if err1 == nil && err2 == nil && h >= 0 && h < 24 && m >= 0 && m < 60 {
+				return fmt.Sprintf("%02d:%02d", h, m)
+			}
+		}
+	}
+
+	// Accept "HHMM" and "HMM"
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+
+	if len(digits) == 4 {
+		h, err1 := strconv.Atoi(digits[:2])
+		m, err2 := strconv.Atoi(digits[2:])
+		if err1 == nil && err2 == nil && h >= 0 && h < 24 && m >= 0 && m < 60 {
+			return fmt.Sprintf("%02d:%02d", h, m)
+		}
+	}
+
+	if len(digits) == 3 { // e.g. "935" => "09:35"
+		h, err1 := strconv.Atoi(digits[:1])
+		m, err2 := strconv.Atoi(digits[1:])
+		if err1 == nil && err2 == nil && h >= 0 && h < 24 && m >= 0 && m < 60 {
+			return fmt.Sprintf("%02d:%02d", h, m)
+		}
+	}
+
+	// Last resort: return as-is (better to see it in debug)
+	return s
+}
+
+// minutesOfDay converts a time string to minutes since midnight (-1 if unparseable)
+func minutesOfDay(s string) int {
+	hhmm := NormalizeTime(s)
+	if len(hhmm) != 5 || hhmm[2] != ':' {
+		return -1
+	}
+	h, err1 := strconv.Atoi(hhmm[:2])
+	m, err2 := strconv.Atoi(hhmm[3:])
+	if err1 != nil || err2 != nil {
+		return -1
+	}
+	return h*60 + m
+}
+
+// TimeDiffMinutes returns the absolute difference between two off times in minutes
+// (wrapping at midnight), or -1 if either can't be parsed
+func TimeDiffMinutes(a, b string) int {
+	ma, mb := minutesOfDay(a), minutesOfDay(b)
+	if ma < 0 || mb < 0 {
+		return -1
+	}
+	diff := ma - mb
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff > 12*60 {
+		diff = 24*60 - diff
+	}
+	return diff
+}
+
+// Canonical race types used for comparison
+const (
+	TypeFlat   = "flat"
+	TypeHurdle = "hurdle"
+	TypeChase  = "chase"
+	TypeNHFlat = "nhflat"
+)
+
+// NormalizeRaceType maps a race type ("NH Flat", "Hurdle", ...) to a canonical type ("" if unknown)
+func NormalizeRaceType(raceType string) string {
+	t := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(raceType), " ", ""))
+	switch t {
+	case "flat":
+		return TypeFlat
+	case "hurdle":
+		return TypeHurdle
+	case "chase":
+		return TypeChase
+	case "nhflat", "bumper":
+		return TypeNHFlat
+	}
+	return ""
+}
+
+// RaceTypeFromName infers the race type from a race/market name
+// ("2m4f Hcap Chs" → chase). Returns "" when the name carries no jumps marker,
+// since flat race names rarely say so.
+func RaceTypeFromName(name string) string {
+	n := " " + strings.ToLower(name) + " "
+	switch {
+	case strings.Contains(n, "nh flat") || strings.Contains(n, " nhf ") || strings.Contains(n, "bumper") ||
+		strings.Contains(n, "national hunt flat"):
+		return TypeNHFlat
+	case strings.Contains(n, "chase") || strings.Contains(n, " chs "):
+		return TypeChase
+	case strings.Contains(n, "hurdle") || strings.Contains(n, " hrd "):
+		return TypeHurdle
+	}
+	return ""
+}
+
+// IsHandicap reports whether a race/market name describes a handicap
+func IsHandicap(name string) bool {
+	n := strings.ToLower(name)
+	return strings.Contains(n, "handicap") || strings.Contains(n, "hcap")
+}
@@ -0,0 +1,163 @@
+package matching
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Override actions
+const (
+	ActionPin    = "pin"    // Always pair these two (skips scoring)
+	ActionForbid = "forbid" // Never pair these two
+)
+
+// Override levels
+const (
+	LevelRace   = "race"   // race_key ↔ Betfair market (market ID or "venue|HH:MM")
+	LevelRunner = "runner" // horse ↔ Betfair selection (selection ID or runner name)
+)
+
+// Override is one manual pin/forbid (see racing.match_overrides)
+type Override struct {
+	Level   string
+	RaceKey string
+	Horse   string // Runner level only (matched via NormalizeName)
+	BFRef   string
+	Action  string
+}
+
+// Overrides indexes manual pins/forbids for fast lookup during matching
+type Overrides struct {
+	races   map[string]map[string]string // race_key → bf_ref → action
+	runners map[string]map[string]string // race_key|horse → bf_ref → action
+}
+
+// NewOverrides indexes a list of overrides
+func NewOverrides(list []Override) *Overrides {
+	o := &Overrides{
+		races:   make(map[string]map[string]string),
+		runners: make(map[string]map[string]string),
+	}
+	for _, ov := range list {
+		o.Add(ov)
+	}
+	return o
+}
+
+// Add indexes one override
+func (o *Overrides) Add(ov Override) {
+	ref := NormalizeRef(ov.Level, ov.BFRef)
+	switch ov.Level {
+	case LevelRace:
+		if o.races[ov.RaceKey] == nil {
+			o.races[ov.RaceKey] = make(map[string]string)
+		}
+		o.races[ov.RaceKey][ref] = ov.Action
+	case LevelRunner:
+		key := runnerOverrideKey(ov.RaceKey, ov.Horse)
+		if o.runners[key] == nil {
+			o.runners[key] = make(map[string]string)
+		}
+		o.runners[key][ref] = ov.Action
+	}
+}
+
+// Len returns the number of indexed overrides
+func (o *Overrides) Len() int {
+	if o == nil {
+		return 0
+	}
+	n := 0
+	for _, refs := range o.races {
+		n += len(refs)
+	}
+	for _, refs := range o.runners {
+		n += len(refs)
+	}
+	return n
+}
+
+// RaceAction returns the override action for a race ↔ market pair ("" if none)
+func (o *Overrides) RaceAction(raceKey, ref string) string {
+	if o == nil || raceKey == "" {
+		return ""
+	}
+	return o.races[raceKey][ref]
+}
+
+// RacePin returns the market ref a race is pinned to ("" if none)
+func (o *Overrides) RacePin(raceKey string) string {
+	if o == nil || raceKey == "" {
+		return ""
+	}
+	return pinned(o.races[raceKey])
+}
+
+// RunnerAction returns the override action for a horse ↔ selection pair ("" if none)
+func (o *Overrides) RunnerAction(raceKey, horse, ref string) string {
+	if o == nil || raceKey == "" {
+		return ""
+	}
+	return o.runners[runnerOverrideKey(raceKey, horse)][ref]
+}
+
+// RunnerPin returns the selection ref a horse is pinned to ("" if none)
+func (o *Overrides) RunnerPin(raceKey, horse string) string {
+	if o == nil || raceKey == "" {
+		return ""
+	}
+	return pinned(o.runners[runnerOverrideKey(raceKey, horse)])
+}
+
+func pinned(refs map[string]string) string {
+	for ref, action := range refs {
+		if action == ActionPin {
+			return ref
+		}
+	}
+	return ""
+}
+
+func runnerOverrideKey(raceKey, horse string) string {
+	return raceKey + "|" + NormalizeName(horse)
+}
+
+// NormalizeRef canonicalises a Betfair-side reference so overrides entered by hand
+// compare equal to the refs the pipelines generate
+func NormalizeRef(level, ref string) string {
+	ref = strings.TrimSpace(ref)
+	switch {
+	case ref == "" || isNumericRef(ref):
+		return ref // Market ID ("1.2345") or selection ID
+	case level == LevelRace && strings.Contains(ref, "|"):
+		parts := strings.SplitN(ref, "|", 2)
+		return MarketRef(parts[0], parts[1])
+	case level == LevelRunner:
+		return NormalizeName(ref)
+	}
+	return ref
+}
+
+// RunnerRef is the Betfair-side reference for a runner: the selection ID when known,
+// otherwise the normalized runner name (historical CSVs)
+func RunnerRef(selectionID int64, name string) string {
+	if selectionID > 0 {
+		return strconv.FormatInt(selectionID, 10)
+	}
+	return NormalizeName(name)
+}
+
+func isNumericRef(ref string) bool {
+	for _, r := range ref {
+		if (r < '0' || r > '9') && r != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+// MarketRef is the Betfair-side reference for a race when no market ID is known
+// (historical CSVs): canonical venue and UK off time, e.g. "kempton|14:30"
+func MarketRef(venue, offTime string) string {
+	return CanonicalVenue(venue) + "|" + NormalizeTime(offTime)
+}
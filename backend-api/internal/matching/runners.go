@@ -0,0 +1,275 @@
+package matching
+
+import (
+	"fmt"
+	"sort"
+)
+
+// RunnerTarget is a horse on the Sporting Life side of a matched race
+type RunnerTarget struct {
+	Name string
+	Num  int // Saddle-cloth number (0 = unknown)
+}
+
+// RunnerCandidate is a runner on the Betfair side of a matched race
+type RunnerCandidate struct {
+	Ref          string // RunnerRef: selection ID, or normalized name for CSV data
+	Name         string
+	Cloth        int // Saddle-cloth number from RUNNER_METADATA (0 = unknown)
+	SortPriority int // Betfair sortPriority (0 = unknown)
+}
+
+// RunnerScore is the breakdown of one horse/runner comparison
+type RunnerScore struct {
+	Total float64 `json:"total"` // Name plus tie-breaker nudges (orders the assignment)
+	Name  float64 `json:"name"`  // NameSimilarity, compared with RunnerMinScore
+	Cloth int     `json:"cloth"` // 1 same saddle cloth, -1 different (rejects fuzzy names), 0 unknown
+	Sort  int     `json:"sort"`  // 1 when sortPriority equals the saddle cloth
+}
+
+// Tie-breaker nudges - small enough never to outweigh a real name difference
+const (
+	clothNudge = 0.02
+	sortNudge  = 0.01
+)
+
+// sameNameScore is the lowest NameSimilarity that is still the same name
+// (spacing aside); anything lower is fuzzy and needs the saddle cloths not to
+// disagree
+const sameNameScore = 0.98
+
+// RunnerResult is the match result for one horse
+type RunnerResult struct {
+	Horse     int         // Index into horses
+	Candidate int         // Index into candidates (-1 when unmatched)
+	Outcome   string      // OutcomeMatched, OutcomeNoCandidates, OutcomeBelowThreshold or OutcomeTaken
+	Override  string      // ActionPin / ActionForbid when an override decided the outcome
+	Score     RunnerScore // Score of the chosen (or best rejected) runner
+	RunnerUp  float64     // Name score of the second-best runner
+}
+
+// Matched reports whether the horse was matched to a runner
+func (r RunnerResult) Matched() bool {
+	return r.Outcome == OutcomeMatched
+}
+
+// Fuzzy reports whether the horse was matched on anything but an identical name
+func (r RunnerResult) Fuzzy() bool {
+	return r.Matched() && r.Override != ActionPin && r.Score.Name < 1
+}
+
+// CompareRunners scores a Betfair runner against a horse
+func (e *Engine) CompareRunners(horse RunnerTarget, candidate RunnerCandidate) RunnerScore {
+	s := RunnerScore{Name: NameSimilarity(horse.Name, candidate.Name)}
+	if horse.Num > 0 && candidate.Cloth > 0 {
+		if horse.Num == candidate.Cloth {
+			s.Cloth = 1
+		} else {
+			s.Cloth = -1
+		}
+	}
+	if horse.Num > 0 && candidate.SortPriority == horse.Num {
+		s.Sort = 1
+	}
+	s.Total = s.Name + clothNudge*float64(s.Cloth) + sortNudge*float64(s.Sort)
+	return s
+}
+
+// pairable reports whether a score is good enough to pair on: a name similar
+// enough, and - unless the names are the same - no known saddle-cloth clash,
+// since sound-alike names of different horses clear RunnerMinScore on their own
+func (e *Engine) pairable(s RunnerScore) bool {
+	if s.Name < e.cfg.RunnerMinScore {
+		return false
+	}
+	return s.Cloth >= 0 || s.Name >= sameNameScore
+}
+
+// MatchRunners pairs a race's horses with the runners of its matched market, one to
+// one. Names are compared fuzzily (country suffixes, apostrophes, typos, sound-alikes)
+// and pairs are assigned greedily from the best score down, saddle cloth and
+// sortPriority breaking ties. A fuzzy name never pairs with a runner whose
+// saddle cloth is known to differ. Runner pins and forbids for raceKey are honoured and
+// every attempt is buffered for the audit (see Flush).
+func (e *Engine) MatchRunners(raceKey, date string, horses []RunnerTarget, candidates []RunnerCandidate) []RunnerResult {
+	type pair struct {
+		h, c  int
+		score RunnerScore
+	}
+
+	ov := e.activeOverrides()
+
+	byRef := make(map[string]int, len(candidates))
+	for ci, c := range candidates {
+		if c.Ref != "" {
+			byRef[c.Ref] = ci
+		}
+	}
+
+	results := make([]RunnerResult, len(horses))
+	used := make(map[int]bool)
+	pinnedTo := make(map[int]int) // candidate → horse
+
+	// Pins first, so a pinned selection can't be claimed by name elsewhere
+	for hi, horse := range horses {
+		results[hi] = RunnerResult{Horse: hi, Candidate: -1, Outcome: OutcomeNoCandidates}
+		ref := ov.RunnerPin(raceKey, horse.Name)
+		if ref == "" {
+			continue
+		}
+		results[hi].Override = ActionPin
+		if ci, ok := byRef[ref]; ok && !used[ci] {
+			results[hi].Candidate = ci
+			results[hi].Outcome = OutcomeMatched
+			results[hi].Score = e.CompareRunners(horse, candidates[ci])
+			used[ci] = true
+			pinnedTo[ci] = hi
+		}
+	}
+
+	audits := make([][]AuditCandidate, len(horses))
+	var pairs []pair
+
+	for hi, horse := range horses {
+		r := &results[hi]
+		if r.Override == ActionPin || len(candidates) == 0 {
+			continue
+		}
+
+		best, second, forbiddenBest := -1.0, 0.0, -1.0
+		pairable := false
+		for ci, candidate := range candidates {
+			if _, claimed := pinnedTo[ci]; claimed {
+				continue
+			}
+			s := e.CompareRunners(horse, candidate)
+			forbidden := ov.RunnerAction(raceKey, horse.Name, candidate.Ref) == ActionForbid
+			if e.pipeline != "" && s.Name > 0 {
+				audits[hi] = append(audits[hi], AuditCandidate{
+					Ref: candidate.Ref, Label: candidate.Name,
+					Runner: &s, Total: s.Total, Forbidden: forbidden,
+				})
+			}
+			if forbidden {
+				if s.Total > forbiddenBest {
+					forbiddenBest = s.Total
+				}
+				continue
+			}
+			if s.Total > best {
+				if best >= 0 {
+					second = results[hi].Score.Name
+				}
+				best = s.Total
+				r.Score = s
+			} else if s.Name > second {
+				second = s.Name
+			}
+			if e.pairable(s) {
+				pairs = append(pairs, pair{h: hi, c: ci, score: s})
+				pairable = true
+			}
+		}
+
+		r.RunnerUp = second
+		if !pairable {
+			r.Outcome = OutcomeBelowThreshold
+		} else {
+			r.Outcome = OutcomeTaken // Until assigned below
+		}
+		if forbiddenBest > best && r.Outcome == OutcomeBelowThreshold {
+			r.Override = ActionForbid
+		}
+	}
+
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return pairs[i].score.Total > pairs[j].score.Total
+	})
+
+	for _, p := range pairs {
+		r := &results[p.h]
+		if r.Outcome != OutcomeTaken || used[p.c] {
+			continue
+		}
+		r.Candidate = p.c
+		r.Score = p.score
+		r.Outcome = OutcomeMatched
+		used[p.c] = true
+	}
+
+	if e.pipeline != "" {
+		e.auditRunners(raceKey, date, horses, candidates, results, audits)
+	}
+	return results
+}
+
+// auditRunners buffers one racing.match_audit row per horse
+func (e *Engine) auditRunners(raceKey, date string, horses []RunnerTarget, candidates []RunnerCandidate, results []RunnerResult, audits [][]AuditCandidate) {
+	for _, r := range results {
+		entry := AuditEntry{
+			Pipeline:   e.pipeline,
+			Level:      LevelRunner,
+			RaceDate:   date,
+			RaceKey:    raceKey,
+			Subject:    horses[r.Horse].Name,
+			Outcome:    r.Outcome,
+			Score:      r.Score.Name,
+			RunnerUp:   r.RunnerUp,
+			Override:   r.Override,
+			Candidates: topCandidates(audits[r.Horse]),
+		}
+		if r.Candidate >= 0 {
+			entry.ChosenRef = candidates[r.Candidate].Ref
+		}
+		e.pending = append(e.pending, entry)
+	}
+}
+
+// RunnerCoverage summarises how many of a race's runners were paired
+type RunnerCoverage struct {
+	Runners    int `json:"runners"`    // Horses on the Sporting Life side
+	Selections int `json:"selections"` // Runners on the Betfair side
+	Matched    int `json:"matched"`
+	Fuzzy      int `json:"fuzzy"`  // Matched on a non-identical name
+	Pinned     int `json:"pinned"` // Matched by a manual pin
+}
+
+// Coverage summarises a MatchRunners result
+func Coverage(results []RunnerResult, selections int) RunnerCoverage {
+	c := RunnerCoverage{Runners: len(results), Selections: selections}
+	for _, r := range results {
+		if !r.Matched() {
+			continue
+		}
+		c.Matched++
+		if r.Fuzzy() {
+			c.Fuzzy++
+		}
+		if r.Override == ActionPin {
+			c.Pinned++
+		}
+	}
+	return c
+}
+
+// Pct returns matched runners as a percentage of the Sporting Life field
+func (c RunnerCoverage) Pct() float64 {
+	if c.Runners == 0 {
+		return 0
+	}
+	return float64(c.Matched) / float64(c.Runners) * 100
+}
+
+// Add accumulates another race's coverage (for run totals)
+func (c *RunnerCoverage) Add(other RunnerCoverage) {
+	c.Runners += other.Runners
+	c.Selections += other.Selections
+	c.Matched += other.Matched
+	c.Fuzzy += other.Fuzzy
+	c.Pinned += other.Pinned
+}
+
+func (c RunnerCoverage) String() string {
+	return fmt.Sprintf("%d/%d runners (%.0f%%, %d fuzzy, %d pinned)",
+		c.Matched, c.Runners, c.Pct(), c.Fuzzy, c.Pinned)
+}
@@ -0,0 +1,87 @@
+package matching
+
+import "testing"
+
+func TestMatchRunners(t *testing.T) {
+	cases := []struct {
+		name       string
+		horses     []RunnerTarget
+		candidates []RunnerCandidate
+		overrides  []Override
+		want       []string // Outcome per horse
+		chosen     []int    // Candidate per horse
+	}{
+		{
+			name:       "exact and fuzzy names",
+			horses:     []RunnerTarget{{Name: "Sea The Stars (IRE)", Num: 1}, {Name: "Stradivarius", Num: 2}},
+			candidates: []RunnerCandidate{{Ref: "20", Name: "Stradivarious", Cloth: 2}, {Ref: "10", Name: "Sea The Stars", Cloth: 1}},
+			want:       []string{OutcomeMatched, OutcomeMatched},
+			chosen:     []int{1, 0},
+		},
+		{
+			name:       "sound-alike with clashing cloth is rejected",
+			horses:     []RunnerTarget{{Name: "Kathleen", Num: 3}},
+			candidates: []RunnerCandidate{{Ref: "30", Name: "Cathleen", Cloth: 7}},
+			want:       []string{OutcomeBelowThreshold},
+			chosen:     []int{-1},
+		},
+		{
+			name:       "sound-alike with matching or unknown cloth pairs",
+			horses:     []RunnerTarget{{Name: "Kathleen", Num: 3}, {Name: "Frankel"}},
+			candidates: []RunnerCandidate{{Ref: "30", Name: "Cathleen", Cloth: 3}, {Ref: "40", Name: "Frankle", Cloth: 5}},
+			want:       []string{OutcomeMatched, OutcomeMatched},
+			chosen:     []int{0, 1},
+		},
+		{
+			name:       "same name survives a clashing cloth",
+			horses:     []RunnerTarget{{Name: "Mc Gregor", Num: 4}},
+			candidates: []RunnerCandidate{{Ref: "50", Name: "McGregor", Cloth: 9}},
+			want:       []string{OutcomeMatched},
+			chosen:     []int{0},
+		},
+		{
+			name:       "cloth breaks a name tie",
+			horses:     []RunnerTarget{{Name: "Sea Bird", Num: 2}},
+			candidates: []RunnerCandidate{{Ref: "60", Name: "See Bird"}, {Ref: "61", Name: "Sae Bird", Cloth: 2}},
+			want:       []string{OutcomeMatched},
+			chosen:     []int{1},
+		},
+		{
+			name:       "one runner, two claimants",
+			horses:     []RunnerTarget{{Name: "Frankle"}, {Name: "Frankel"}},
+			candidates: []RunnerCandidate{{Ref: "70", Name: "Frankel"}},
+			want:       []string{OutcomeTaken, OutcomeMatched},
+			chosen:     []int{-1, 0},
+		},
+		{
+			name:       "pin and forbid",
+			horses:     []RunnerTarget{{Name: "Enable"}, {Name: "Kingman"}},
+			candidates: []RunnerCandidate{{Ref: "80", Name: "Enable"}, {Ref: "81", Name: "Kingmann"}},
+			overrides: []Override{
+				{Level: LevelRunner, RaceKey: "rk", Horse: "Enable", BFRef: "81", Action: ActionPin},
+				{Level: LevelRunner, RaceKey: "rk", Horse: "Kingman", BFRef: "80", Action: ActionForbid},
+			},
+			want:   []string{OutcomeMatched, OutcomeBelowThreshold},
+			chosen: []int{1, -1},
+		},
+		{
+			name:       "no runners",
+			horses:     []RunnerTarget{{Name: "Enable"}},
+			candidates: nil,
+			want:       []string{OutcomeNoCandidates},
+			chosen:     []int{-1},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := New(DefaultConfig()).WithOverrides(NewOverrides(tc.overrides))
+			results := e.MatchRunners("rk", "2025-10-18", tc.horses, tc.candidates)
+			for i, r := range results {
+				if r.Outcome != tc.want[i] || r.Candidate != tc.chosen[i] {
+					t.Errorf("%s: got %s/%d (name %.3f, cloth %d), want %s/%d", tc.horses[i].Name,
+						r.Outcome, r.Candidate, r.Score.Name, r.Score.Cloth, tc.want[i], tc.chosen[i])
+				}
+			}
+		})
+	}
+}
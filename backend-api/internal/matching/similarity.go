@@ -0,0 +1,120 @@
+package matching
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	clothPrefixRe = regexp.MustCompile(`^\d+[.)]?\s+`) // "3. Galileo Gold" (some Betfair markets)
+	apostropheRe  = regexp.MustCompile("[’‘`´]")
+)
+
+// RunnerName normalizes a runner name for fuzzy matching: NormalizeName plus
+// saddle-cloth prefixes and typographic apostrophes removed
+func RunnerName(name string) string {
+	name = strings.TrimSpace(name)
+	name = clothPrefixRe.ReplaceAllString(name, "")
+	name = apostropheRe.ReplaceAllString(name, "'")
+	return NormalizeName(name)
+}
+
+// NameSimilarity scores two horse names 0..1: 1 for the same normalized name,
+// 0.98 when only spacing differs ("Mc Gregor" / "McGregor"), otherwise the
+// Levenshtein ratio, raised to 0.9 when both names sound the same (phonetic keys
+// of 3+ letters)
+func NameSimilarity(a, b string) float64 {
+	na, nb := RunnerName(a), RunnerName(b)
+	if na == "" || nb == "" {
+		return 0
+	}
+	if na == nb {
+		return 1
+	}
+
+	ca, cb := strings.ReplaceAll(na, " ", ""), strings.ReplaceAll(nb, " ", "")
+	if ca == cb {
+		return 0.98
+	}
+
+	ratio := levenshteinRatio(ca, cb)
+	if ratio < 0.9 {
+		if ka := PhoneticKey(ca); len(ka) >= 3 && ka == PhoneticKey(cb) {
+			ratio = 0.9
+		}
+	}
+	return ratio
+}
+
+func levenshteinRatio(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// levenshtein is the edit distance between two rune slices (two-row DP)
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// phoneticRules rewrite letter groups that sound alike (applied in order)
+var phoneticRules = strings.NewReplacer(
+	"ph", "f", "gh", "g", "ck", "k", "qu", "kw", "q", "k",
+	"x", "ks", "z", "s", "c", "k", "w", "v", "y", "i",
+)
+
+// PhoneticKey is a simple sound-alike key for a compacted lowercase name: similar
+// consonant groups are unified, vowels after the first letter dropped and
+// repeated letters collapsed ("Kathleen" / "Cathleen" → "ktln")
+func PhoneticKey(name string) string {
+	name = phoneticRules.Replace(strings.ToLower(name))
+
+	var b strings.Builder
+	var last rune
+	for i, r := range name {
+		if r < 'a' || r > 'z' {
+			continue
+		}
+		if i > 0 && strings.ContainsRune("aeiouh", r) {
+			continue
+		}
+		if r == last {
+			continue
+		}
+		b.WriteRune(r)
+		last = r
+	}
+	return b.String()
+}
@@ -0,0 +1,54 @@
+package matching
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNameSimilarity(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want float64
+	}{
+		{"Sea The Stars (IRE)", "Sea The Stars", 1},
+		{"3. Galileo Gold", "Galileo Gold", 1},
+		{"O’Reilly", "O'Reilly", 1},
+		{"Mc Gregor", "McGregor", 0.98},
+		{"Stradivarius", "Stradivarious", 1 - 1.0/13},
+		{"Kathleen", "Cathleen", 0.9}, // Sound-alike lifts the 0.875 edit ratio
+		{"Frankel", "Frankle", 0.9},
+		{"Ab", "Ob", 0.5}, // Phonetic keys under 3 letters don't count
+		{"Enable", "Kingman", 1 - 6.0/7},
+		{"", "Enable", 0},
+	}
+	for _, tc := range cases {
+		got := NameSimilarity(tc.a, tc.b)
+		if math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("NameSimilarity(%q, %q) = %.4f, want %.4f", tc.a, tc.b, got, tc.want)
+		}
+		if rev := NameSimilarity(tc.b, tc.a); math.Abs(rev-got) > 1e-9 {
+			t.Errorf("NameSimilarity(%q, %q) = %.4f, not symmetric (%.4f)", tc.b, tc.a, rev, got)
+		}
+	}
+}
+
+func TestPhoneticKey(t *testing.T) {
+	cases := []struct {
+		name, want string
+	}{
+		{"kathleen", "ktln"},
+		{"cathleen", "ktln"},
+		{"philippa", "flp"},
+		{"quixote", "kwkst"},
+		{"mccoy", "mk"},
+		{"wizard", "vsrd"},
+		{"knight", "kngt"},
+		{"Hello", "hl"},
+		{"o'reilly", "orl"},
+	}
+	for _, tc := range cases {
+		if got := PhoneticKey(tc.name); got != tc.want {
+			t.Errorf("PhoneticKey(%q) = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
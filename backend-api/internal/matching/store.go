@@ -0,0 +1,232 @@
+package matching
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/lib/pq"
+)
+
+// Pipelines recorded in racing.match_audit
+const (
+	PipelineLive       = "live"       // Betfair API markets (betfair.Matcher)
+	PipelineHistorical = "historical" // Betfair SP CSVs (scraper.MatchAndMerge)
+	PipelineStitcher   = "stitcher"   // Master CSV stitcher (stitcher.Stitcher)
+)
+
+// AuditCandidate is one scored candidate recorded with an attempt
+type AuditCandidate struct {
+	Ref       string       `json:"ref"`
+	Label     string       `json:"label,omitempty"`
+	Total     float64      `json:"total"`
+	Score     *Score       `json:"score,omitempty"`  // Race level breakdown
+	Runner    *RunnerScore `json:"runner,omitempty"` // Runner level breakdown
+	Forbidden bool         `json:"forbidden,omitempty"`
+}
+
+// AuditEntry is one match attempt for a race or runner (see racing.match_audit)
+type AuditEntry struct {
+	Pipeline   string
+	Level      string
+	RaceDate   string
+	RaceKey    string
+	Subject    string // "Kempton Park 14:30" or the horse name
+	Outcome    string
+	ChosenRef  string
+	Score      float64
+	RunnerUp   float64
+	Override   string
+	Candidates []AuditCandidate
+}
+
+// Store loads overrides and persists audit entries
+type Store interface {
+	LoadOverrides(dates []string) (*Overrides, error)
+	SaveAudit(entries []AuditEntry) error
+}
+
+var (
+	storeMu sync.RWMutex
+	store   Store
+)
+
+// SetStore installs the store every engine uses for overrides and audit
+// (call once at startup; nil disables both)
+func SetStore(s Store) {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	store = s
+}
+
+func currentStore() Store {
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+	return store
+}
+
+// maxAuditCandidates caps how many candidates are kept per attempt (best first)
+const maxAuditCandidates = 5
+
+func topCandidates(c []AuditCandidate) []AuditCandidate {
+	sort.SliceStable(c, func(i, j int) bool { return c[i].Total > c[j].Total })
+	if len(c) > maxAuditCandidates {
+		c = c[:maxAuditCandidates]
+	}
+	return c
+}
+
+// DBStore is the Postgres-backed store (racing.match_overrides / racing.match_audit)
+type DBStore struct {
+	db *sql.DB
+}
+
+// NewDBStore creates a Postgres store
+func NewDBStore(db *sql.DB) *DBStore {
+	return &DBStore{db: db}
+}
+
+// LoadOverrides loads every override for the given race dates (plus undated ones)
+func (s *DBStore) LoadOverrides(dates []string) (*Overrides, error) {
+	rows, err := s.db.Query(`
+		SELECT level, race_key, horse, bf_ref, action
+		FROM racing.match_overrides
+		WHERE race_date IS NULL OR race_date::text = ANY($1)
+	`, pq.Array(dates))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load match overrides: %w", err)
+	}
+	defer rows.Close()
+
+	var list []Override
+	for rows.Next() {
+		var ov Override
+		if err := rows.Scan(&ov.Level, &ov.RaceKey, &ov.Horse, &ov.BFRef, &ov.Action); err != nil {
+			return nil, fmt.Errorf("failed to scan match override: %w", err)
+		}
+		list = append(list, ov)
+	}
+	return NewOverrides(list), rows.Err()
+}
+
+// SaveAudit writes audit entries in one transaction
+func (s *DBStore) SaveAudit(entries []AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(pq.CopyInSchema("racing", "match_audit",
+		"pipeline", "level", "race_date", "race_key", "subject", "outcome",
+		"chosen_ref", "score", "runner_up", "override", "candidates"))
+	if err != nil {
+		return fmt.Errorf("failed to prepare match audit copy: %w", err)
+	}
+
+	for _, e := range entries {
+		candidates, err := json.Marshal(e.Candidates)
+		if err != nil {
+			stmt.Close()
+			return err
+		}
+		var raceDate, chosen, override interface{}
+		if e.RaceDate != "" {
+			raceDate = e.RaceDate
+		}
+		if e.ChosenRef != "" {
+			chosen = e.ChosenRef
+		}
+		if e.Override != "" {
+			override = e.Override
+		}
+		if _, err := stmt.Exec(e.Pipeline, e.Level, raceDate, e.RaceKey, e.Subject, e.Outcome,
+			chosen, e.Score, e.RunnerUp, override, string(candidates)); err != nil {
+			stmt.Close()
+			return fmt.Errorf("failed to copy match audit row: %w", err)
+		}
+	}
+
+	if _, err := stmt.Exec(); err != nil {
+		stmt.Close()
+		return fmt.Errorf("failed to flush match audit: %w", err)
+	}
+	if err := stmt.Close(); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DefaultAuditRetentionDays is how long match audit rows are kept
+const DefaultAuditRetentionDays = 90
+
+// AuditRetentionDaysFromEnv returns MATCH_AUDIT_RETENTION_DAYS (default
+// DefaultAuditRetentionDays; 0 keeps every row)
+func AuditRetentionDaysFromEnv() int {
+	if v := os.Getenv("MATCH_AUDIT_RETENTION_DAYS"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
+			return parsed
+		}
+	}
+	return DefaultAuditRetentionDays
+}
+
+// PurgeAudit deletes audit rows written more than keepDays ago and returns
+// how many went (keepDays 0 keeps everything). The live matcher audits every
+// refresh, so without it racing.match_audit grows without bound.
+func (s *DBStore) PurgeAudit(keepDays int) (int64, error) {
+	if keepDays <= 0 {
+		return 0, nil
+	}
+	res, err := s.db.Exec(`
+		DELETE FROM racing.match_audit WHERE run_at < now() - make_interval(days => $1)
+	`, keepDays)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge match audit: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// loadOverrides fetches overrides for the target dates from the installed store
+func loadOverrides(targets []Race) *Overrides {
+	s := currentStore()
+	if s == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var dates []string
+	for _, t := range targets {
+		if t.Date != "" && !seen[t.Date] {
+			seen[t.Date] = true
+			dates = append(dates, t.Date)
+		}
+	}
+
+	ov, err := s.LoadOverrides(dates)
+	if err != nil {
+		log.Printf("[Matching] ⚠️  %v - matching without overrides", err)
+		return nil
+	}
+	return ov
+}
+
+// saveAudit persists entries through the installed store (errors are logged, not fatal)
+func saveAudit(entries []AuditEntry) {
+	s := currentStore()
+	if s == nil || len(entries) == 0 {
+		return
+	}
+	if err := s.SaveAudit(entries); err != nil {
+		log.Printf("[Matching] ⚠️  Failed to write %d audit entries: %v", len(entries), err)
+	}
+}
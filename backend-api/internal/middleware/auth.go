@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"giddyup/api/internal/auth"
+	"giddyup/api/internal/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// principalKey is the gin context key holding the request's *auth.Principal
+const principalKey = "principal"
+
+// Authenticate resolves the caller from "Authorization: Bearer <key or JWT>"
+// or "X-API-Key: <key>". Bad credentials get a 401; requests without any get
+// the anonymous principal, or a 401 when credentials are required.
+func Authenticate(a *auth.Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		credential := c.GetHeader("X-API-Key")
+		if header := c.GetHeader("Authorization"); header != "" {
+			scheme, token, _ := strings.Cut(header, " ")
+			if !strings.EqualFold(scheme, "Bearer") {
+				unauthorized(c, "unsupported authorization scheme, use Bearer")
+				return
+			}
+			credential = strings.TrimSpace(token)
+		}
+
+		principal, err := a.Authenticate(c.Request.Context(), credential)
+		switch {
+		case errors.Is(err, auth.ErrNoCredentials):
+			unauthorized(c, "authentication required")
+			return
+		case errors.Is(err, auth.ErrInvalidCredentials):
+			logger.Warn("Authenticate: rejected credentials from %s: %v", c.ClientIP(), err)
+			unauthorized(c, "invalid or expired credentials")
+			return
+		case err != nil:
+			logger.Error("Authenticate: %v", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error": "failed to authenticate",
+			})
+			return
+		}
+
+		c.Set(principalKey, principal)
+		c.Next()
+	}
+}
+
+// RequireScope rejects callers without the scope (403), or without any
+// principal (401)
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := CurrentPrincipal(c)
+		if principal == nil {
+			unauthorized(c, "authentication required")
+			return
+		}
+		if !principal.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":          "insufficient scope",
+				"required_scope": scope,
+				"role":           principal.Role,
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// CurrentPrincipal returns the request's authenticated caller (nil before Authenticate)
+func CurrentPrincipal(c *gin.Context) *auth.Principal {
+	if v, ok := c.Get(principalKey); ok {
+		if p, ok := v.(*auth.Principal); ok {
+			return p
+		}
+	}
+	return nil
+}
+
+func unauthorized(c *gin.Context, message string) {
+	c.Header("WWW-Authenticate", `Bearer realm="giddyup"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		"error": message,
+	})
+}
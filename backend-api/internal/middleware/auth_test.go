@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"giddyup/api/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testSecret = "test-secret"
+
+// adminRouter mounts /admin behind the admin scope the way router.Setup does;
+// JWTs need no database, so the authenticator has none
+func adminRouter(cfg auth.Config) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	v1 := r.Group("/api/v1", Authenticate(auth.NewAuthenticator(nil, cfg)))
+	v1.GET("/admin/status", RequireScope(auth.ScopeAdmin), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"subject": CurrentPrincipal(c).Subject})
+	})
+	return r
+}
+
+func TestAdminScope(t *testing.T) {
+	jwt := func(role, scope string) string {
+		tok, err := auth.SignJWT(auth.Claims{Subject: role, Role: role, Scope: scope,
+			ExpiresAt: time.Now().Add(time.Hour).Unix()}, testSecret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return "Bearer " + tok
+	}
+
+	cases := []struct {
+		name          string
+		required      bool
+		authorization string
+		want          int
+	}{
+		{name: "admin token", authorization: jwt(auth.RoleAdmin, ""), want: http.StatusOK},
+		{name: "admin role narrowed to read", authorization: jwt(auth.RoleAdmin, auth.ScopeRead), want: http.StatusForbidden},
+		{name: "trader token", authorization: jwt(auth.RoleTrader, ""), want: http.StatusForbidden},
+		{name: "reader token", authorization: jwt(auth.RoleReader, ""), want: http.StatusForbidden},
+		{name: "anonymous", want: http.StatusForbidden},
+		{name: "anonymous when credentials are required", required: true, want: http.StatusUnauthorized},
+		{name: "badly signed token", authorization: jwt(auth.RoleAdmin, "") + "x", want: http.StatusUnauthorized},
+		{name: "basic auth", authorization: "Basic YWRtaW46YWRtaW4=", want: http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := adminRouter(auth.Config{Required: tc.required, JWTSecret: testSecret})
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/status", nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tc.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tc.want, w.Body.String())
+			}
+		})
+	}
+}
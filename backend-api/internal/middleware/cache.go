@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"giddyup/api/internal/cache"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cachePolicyKey is the context key Cacheable sets
+const cachePolicyKey = "cache_policy"
+
+type cachePolicy struct {
+	freshness cache.Freshness
+	tags      []string
+}
+
+// Cacheable marks a handler's response as cacheable for its freshness class,
+// tagged with what it was built from so writers can invalidate it. Responses
+// of routes without the ResponseCache middleware are unaffected.
+func Cacheable(c *gin.Context, freshness cache.Freshness, tags ...string) {
+	c.Set(cachePolicyKey, cachePolicy{freshness: freshness, tags: tags})
+}
+
+// ResponseCache serves GETs from rc and stores the 200 responses handlers
+// mark Cacheable. Every marked response gets an ETag, Last-Modified and
+// Cache-Control: private, max-age by freshness, and a request whose
+// If-None-Match (or If-Modified-Since) still matches gets a 304 - with or
+// without a store behind rc. X-Cache says whether the store answered. A nil
+// rc turns it off.
+func ResponseCache(rc *cache.Cache) gin.HandlerFunc {
+	if rc == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		key := cacheKey(c.Request.URL)
+		if e, ok := rc.Get(c.Request.Context(), key); ok {
+			c.Header("X-Cache", "HIT")
+			writeCached(c, e)
+			c.Abort()
+			return
+		}
+
+		w := &bufferingWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		v, marked := c.Get(cachePolicyKey)
+		if !marked || w.status != http.StatusOK {
+			c.Writer.WriteHeader(w.status)
+			c.Writer.Write(w.body.Bytes())
+			return
+		}
+
+		policy := v.(cachePolicy)
+		now := time.Now().UTC().Truncate(time.Second)
+		sum := sha256.Sum256(w.body.Bytes())
+		e := &cache.Entry{
+			Body:         w.body.Bytes(),
+			ContentType:  c.Writer.Header().Get("Content-Type"),
+			ETag:         `"` + hex.EncodeToString(sum[:16]) + `"`,
+			LastModified: now,
+			Expires:      now.Add(rc.TTL(policy.freshness)),
+		}
+		rc.Set(c.Request.Context(), key, e, policy.tags)
+		if rc.Enabled() {
+			c.Header("X-Cache", "MISS")
+		}
+		writeCached(c, e)
+	}
+}
+
+// writeCached writes an entry, or a 304 if the client's copy is current
+func writeCached(c *gin.Context, e *cache.Entry) {
+	maxAge := int(time.Until(e.Expires).Seconds())
+	h := c.Writer.Header()
+	h.Set("ETag", e.ETag)
+	h.Set("Last-Modified", e.LastModified.Format(http.TimeFormat))
+	h.Set("Cache-Control", "private, max-age="+strconv.Itoa(max(maxAge, 0)))
+
+	if notModified(c.Request, e) {
+		c.Writer.WriteHeader(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
+		return
+	}
+	if e.ContentType != "" {
+		h.Set("Content-Type", e.ContentType)
+	}
+	c.Writer.WriteHeader(http.StatusOK)
+	c.Writer.Write(e.Body)
+}
+
+// notModified reports whether the request's validators match the entry;
+// If-None-Match wins over If-Modified-Since
+func notModified(r *http.Request, e *cache.Entry) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == e.ETag {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+		if t, err := http.ParseTime(ims); err == nil {
+			return !e.LastModified.After(t)
+		}
+	}
+	return false
+}
+
+// cacheKey is the path and query, parameters sorted so their order doesn't
+// split entries
+func cacheKey(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.Path
+	}
+	return u.Path + "?" + u.Query().Encode()
+}
+
+// bufferingWriter holds a response until the cache has seen it
+type bufferingWriter struct {
+	gin.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferingWriter) WriteHeader(code int) { w.status = code }
+func (w *bufferingWriter) WriteHeaderNow()      {}
+func (w *bufferingWriter) Status() int          { return w.status }
+func (w *bufferingWriter) Size() int            { return w.body.Len() }
+func (w *bufferingWriter) Written() bool        { return w.body.Len() > 0 }
+func (w *bufferingWriter) Flush()               {}
+
+func (w *bufferingWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *bufferingWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
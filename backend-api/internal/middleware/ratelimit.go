@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"giddyup/api/internal/auth"
+	"giddyup/api/internal/ratelimit"
+	"giddyup/api/internal/usage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientID identifies the caller for quotas and usage: its API key, its JWT
+// subject, or (anonymous) its IP
+func ClientID(c *gin.Context) string {
+	p := CurrentPrincipal(c)
+	switch {
+	case p != nil && p.Method == auth.MethodAPIKey:
+		return "key:" + strconv.FormatInt(p.KeyID, 10)
+	case p != nil && p.Method == auth.MethodJWT:
+		return "jwt:" + p.Subject
+	default:
+		return "ip:" + c.ClientIP()
+	}
+}
+
+// RateLimit applies the limiter's quota for the route's group (see
+// ratelimit.Routes) per client, sets X-RateLimit-* headers and answers 429
+// with Retry-After once the quota is used up
+func RateLimit(l *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		group := ratelimit.GroupFor(c.FullPath())
+		now := time.Now()
+		d := l.Allow(c.Request.Context(), ClientID(c), group, now)
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(d.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(d.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(d.Reset.Unix(), 10))
+
+		if !d.Allowed {
+			retry := int(math.Ceil(d.Reset.Sub(now).Seconds()))
+			c.Header("Retry-After", strconv.Itoa(retry))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":       "rate limit exceeded",
+				"group":       group,
+				"limit":       d.Limit,
+				"retry_after": retry,
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RowsKey is the context key a handler that doesn't answer with JSON (e.g.
+// an export stream) sets to the number of rows it returned, for usage
+const RowsKey = "usage_rows"
+
+// rowCountingWriter counts the rows of the JSON it writes
+type rowCountingWriter struct {
+	gin.ResponseWriter
+	rows usage.RowCounter
+}
+
+func (w *rowCountingWriter) Write(b []byte) (int, error) {
+	w.rows.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *rowCountingWriter) WriteString(s string) (int, error) {
+	w.rows.Write([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Unwrap lets http.ResponseController reach the connection (e.g. to lift
+// the write deadline for a long stream)
+func (w *rowCountingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Meter records each request's client, route, status, rows returned and
+// time taken (see usage.Meter)
+func Meter(m *usage.Meter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		w := &rowCountingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+
+		c.Next()
+
+		hit := usage.Hit{
+			Client:   ClientID(c),
+			Route:    c.FullPath(),
+			Method:   c.Request.Method,
+			Status:   c.Writer.Status(),
+			Duration: time.Since(start),
+			At:       start,
+		}
+		if hit.Status < 300 {
+			hit.Rows = w.rows.Rows()
+			if n, ok := c.Get(RowsKey); ok {
+				hit.Rows = n.(int)
+			}
+		}
+		if p := CurrentPrincipal(c); p != nil {
+			hit.KeyID = p.KeyID
+		}
+		if hit.Route == "" {
+			hit.Route = "(unmatched)"
+		}
+		m.Record(hit)
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestClientIDBehindProxies(t *testing.T) {
+	cases := []struct {
+		name    string
+		trusted []string
+		want    string
+	}{
+		{name: "no trusted proxies ignores X-Forwarded-For", want: "ip:10.0.0.5"},
+		{name: "trusted proxy", trusted: []string{"10.0.0.0/8"}, want: "ip:203.0.113.7"},
+		{name: "untrusted proxy", trusted: []string{"192.168.0.1"}, want: "ip:10.0.0.5"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			if err := r.SetTrustedProxies(tc.trusted); err != nil {
+				t.Fatal(err)
+			}
+			r.GET("/", func(c *gin.Context) {
+				c.String(http.StatusOK, ClientID(c))
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = "10.0.0.5:4242"
+			req.Header.Set("X-Forwarded-For", "203.0.113.7")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if got := w.Body.String(); got != tc.want {
+				t.Errorf("ClientID = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
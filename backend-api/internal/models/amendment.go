@@ -0,0 +1,50 @@
+package models
+
+import "github.com/lib/pq"
+
+// RunnerAmendment is a racing.runner_amendments row: one field of a settled
+// runner changed after the result was first loaded
+type RunnerAmendment struct {
+	AmendmentID int64   `json:"amendment_id" db:"amendment_id"`
+	RunnerID    int64   `json:"runner_id" db:"runner_id"`
+	RaceID      int64   `json:"race_id" db:"race_id"`
+	RaceDate    string  `json:"race_date" db:"race_date"`
+	RunnerKey   string  `json:"runner_key" db:"runner_key"`
+	HorseName   *string `json:"horse_name,omitempty" db:"horse_name"`
+	Field       string  `json:"field" db:"field"` // pos_raw, dec, win_bsp, place_bsp
+	OldValue    *string `json:"old_value" db:"old_value"`
+	NewValue    string  `json:"new_value" db:"new_value"`
+	Kind        string  `json:"kind" db:"kind"`     // disqualification, placing, sp, bsp
+	Source      string  `json:"source" db:"source"` // sportinglife, betfair
+	RunID       *int64  `json:"run_id,omitempty" db:"run_id"`
+	AmendedAt   string  `json:"amended_at" db:"amended_at"`
+}
+
+// AmendedRace is a race with amended results
+type AmendedRace struct {
+	RaceID        int64          `json:"race_id" db:"race_id"`
+	RaceKey       string         `json:"race_key" db:"race_key"`
+	RaceDate      string         `json:"race_date" db:"race_date"`
+	Region        string         `json:"region" db:"region"`
+	CourseName    *string        `json:"course_name,omitempty" db:"course_name"`
+	OffTime       *string        `json:"off_time,omitempty" db:"off_time"`
+	RaceName      string         `json:"race_name" db:"race_name"`
+	Kinds         pq.StringArray `json:"kinds" db:"kinds"`
+	Amendments    int            `json:"amendments" db:"amendments"`
+	LastAmendedAt string         `json:"last_amended_at" db:"last_amended_at"`
+}
+
+// RaceAmendments is a race's amendment history, oldest first
+type RaceAmendments struct {
+	RaceID     int64             `json:"race_id"`
+	Amendments []RunnerAmendment `json:"amendments"`
+}
+
+// AmendedRaceFilters represents query parameters for amended races
+type AmendedRaceFilters struct {
+	DateFrom *string `form:"date_from" binding:"omitempty,datetime=2006-01-02"`
+	DateTo   *string `form:"date_to" binding:"omitempty,datetime=2006-01-02"`
+	Kind     *string `form:"kind" binding:"omitempty,oneof=disqualification placing sp bsp"`
+	Limit    int     `form:"limit" binding:"omitempty,min=1,max=1000"`
+	Offset   int     `form:"offset" binding:"omitempty,min=0"`
+}
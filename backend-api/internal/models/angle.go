@@ -14,7 +14,8 @@ type NearMissQualifier struct {
 
 // EntryDetails represents the upcoming race entry
 type EntryDetails struct {
-	RaceID   int64    `json:"race_id" db:"next_race_id"`
+	RaceID   *int64   `json:"race_id,omitempty" db:"next_race_id"`
+	SLRaceID *int64   `json:"sl_race_id,omitempty" db:"next_sl_race_id"` // Entry race when source=entries
 	Date     string   `json:"date" db:"next_date"`
 	RaceType string   `json:"race_type" db:"next_race_type"`
 	CourseID int64    `json:"course_id" db:"next_course_id"`
@@ -22,6 +23,7 @@ type EntryDetails struct {
 	Surface  *string  `json:"surface,omitempty" db:"next_surface"`
 	Going    *string  `json:"going,omitempty" db:"next_going"`
 	OR       *int     `json:"or,omitempty" db:"next_or"`
+	Status   *string  `json:"status,omitempty" db:"next_status"` // Entry status when source=entries
 }
 
 // LastRunDetails represents the last completed run
@@ -40,6 +42,8 @@ type LastRunDetails struct {
 // NearMissTodayParams represents query parameters for today's qualifiers
 type NearMissTodayParams struct {
 	On             *string `form:"on"`
+	Source         string  `form:"source"` // "card" (default) or "entries"
+	Days           int     `form:"days"`   // Entries window from On (source=entries)
 	RaceType       *string `form:"race_type"`
 	LastPos        int     `form:"last_pos"`
 	BTNMax         float64 `form:"btn_max"`
@@ -94,13 +98,16 @@ type NearMissPastParams struct {
 	PriceSource    string  `form:"price_source"`
 	Summary        bool    `form:"summary"`
 	Limit          int     `form:"limit"`
-	Offset         int     `form:"offset"`
+	Offset         int     `form:"offset"` // Deprecated: use cursor
+	Cursor         string  `form:"cursor"` // meta.next_cursor of the previous page
 }
 
-// NearMissPastResponse represents the backtest response with optional summary
+// NearMissPastResponse is one page of backtest cases in the standard
+// envelope; the optional summary covers the cases on the page
 type NearMissPastResponse struct {
+	Cases   []NearMissPastCase `json:"data"`
 	Summary *AngleSummary      `json:"summary,omitempty"`
-	Cases   []NearMissPastCase `json:"cases"`
+	Meta    ResponseMeta       `json:"meta"`
 }
 
 // AngleSummary represents aggregate performance metrics
@@ -0,0 +1,38 @@
+package models
+
+import "github.com/lib/pq"
+
+// APIKey is a racing.api_keys row (the key itself is never stored)
+type APIKey struct {
+	KeyID      int64          `json:"key_id" db:"key_id"`
+	Name       string         `json:"name" db:"name"`
+	KeyPrefix  string         `json:"key_prefix" db:"key_prefix"` // First characters, to recognise the key
+	Role       string         `json:"role" db:"role"`             // reader, analyst, trader, admin
+	Scopes     pq.StringArray `json:"scopes" db:"scopes"`
+	CreatedBy  *string        `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt  string         `json:"created_at" db:"created_at"`
+	ExpiresAt  *string        `json:"expires_at,omitempty" db:"expires_at"`
+	LastUsedAt *string        `json:"last_used_at,omitempty" db:"last_used_at"`
+	RevokedAt  *string        `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// APIKeyCreated is a new key with its plaintext, which is only ever returned once
+type APIKeyCreated struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// APIKeyRequest is the body for creating an API key
+type APIKeyRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	Role          string   `json:"role" binding:"required,oneof=reader analyst trader admin"`
+	Scopes        []string `json:"scopes"`                                    // Default: all of the role's scopes
+	ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1"` // Default: never expires
+}
+
+// APIKeyFilters represents query parameters for listing API keys
+type APIKeyFilters struct {
+	Revoked bool `form:"revoked"` // Include revoked keys
+	Limit   int  `form:"limit"`
+	Offset  int  `form:"offset"`
+}
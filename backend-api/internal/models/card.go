@@ -0,0 +1,37 @@
+package models
+
+// RaceCard is a race with every runner's form in context
+type RaceCard struct {
+	Race    Race         `json:"race"`
+	Runners []CardRunner `json:"runners"`
+}
+
+// CardRunner is a runner with what a racecard shows alongside it, all from
+// runs before the race. Form holds the last 6 runs, latest first.
+type CardRunner struct {
+	Runner
+	DaysSinceRun   *int `json:"days_since_run,omitempty"`
+	LastOR         *int `json:"last_or,omitempty"`   // OR on its last run
+	ORChange       *int `json:"or_change,omitempty"` // OR today minus last_or
+	CourseWinner   bool `json:"course_winner"`
+	DistanceWinner bool `json:"distance_winner"` // Won within half a furlong of today's trip
+	CDWinner       bool `json:"cd_winner"`       // Both in the same race
+
+	// Record on today's going and distance band, and its best of each (by
+	// strike rate, 2+ runs) as in the horse profile splits
+	Going        *StatsSplit `json:"going,omitempty"`
+	BestGoing    *StatsSplit `json:"best_going,omitempty"`
+	Distance     *StatsSplit `json:"distance,omitempty"`
+	BestDistance *StatsSplit `json:"best_distance,omitempty"`
+
+	TrainerForm   *FormPeriod `json:"trainer_form,omitempty"`   // Trainer's 14 days before the race
+	JockeyTrainer *ComboStats `json:"jockey_trainer,omitempty"` // The pair's runs together before the race
+}
+
+// ComboStats is a jockey and trainer's record together
+type ComboStats struct {
+	Runs int      `json:"runs" db:"runs"`
+	Wins int      `json:"wins" db:"wins"`
+	SR   *float64 `json:"sr,omitempty" db:"sr"`
+	PL   *float64 `json:"pl,omitempty" db:"pl"` // Level stakes at BSP
+}
@@ -1,10 +1,16 @@
 package models
 
+import "github.com/lib/pq"
+
 // Course represents a racing course/venue
 type Course struct {
-	CourseID   int64  `json:"course_id" db:"course_id"`
-	CourseName string `json:"course_name" db:"course_name"`
-	Region     string `json:"region" db:"region"`
+	CourseID   int64          `json:"course_id" db:"course_id"`
+	CourseName string         `json:"course_name" db:"course_name"`
+	Region     string         `json:"region" db:"region"`
+	Timezone   *string        `json:"timezone,omitempty" db:"timezone"`
+	Surfaces   pq.StringArray `json:"surfaces" db:"surfaces"`
+	Direction  *string        `json:"direction,omitempty" db:"direction"` // left, right, straight, both
+	Profile    *string        `json:"profile,omitempty" db:"profile"`     // Track characteristics
 }
 
 // Meeting represents a race meeting at a course
@@ -0,0 +1,95 @@
+package models
+
+import "github.com/lib/pq"
+
+// CourseDetail is a registry course with every source's name for it
+type CourseDetail struct {
+	Course
+	UpdatedAt *string       `json:"updated_at,omitempty" db:"updated_at"`
+	Aliases   []CourseAlias `json:"aliases" db:"-"`
+}
+
+// CourseAlias is one source's name for a course
+type CourseAlias struct {
+	AliasID   int64  `json:"alias_id" db:"alias_id"`
+	CourseID  int64  `json:"course_id" db:"course_id"`
+	Source    string `json:"source" db:"source"` // sportinglife, betfair, master, manual
+	Region    string `json:"region" db:"region"`
+	Alias     string `json:"alias" db:"alias"`
+	CreatedAt string `json:"created_at" db:"created_at"`
+}
+
+// CourseAliasRequest adds a name for a course (region defaults to the course's)
+type CourseAliasRequest struct {
+	Source string `json:"source" binding:"required"`
+	Alias  string `json:"alias" binding:"required"`
+	Region string `json:"region"`
+}
+
+// CourseRequest creates a course or updates its attributes (nil/empty = unchanged)
+type CourseRequest struct {
+	CourseName string   `json:"course_name"`
+	Region     string   `json:"region"`
+	Timezone   string   `json:"timezone"`
+	Surfaces   []string `json:"surfaces"`
+	Direction  string   `json:"direction"`
+	Profile    string   `json:"profile"`
+}
+
+// CourseReview is a course name no loader could resolve
+type CourseReview struct {
+	ReviewID    int64              `json:"review_id" db:"review_id"`
+	Source      string             `json:"source" db:"source"`
+	Region      string             `json:"region" db:"region"`
+	CourseName  string             `json:"course_name" db:"course_name"`
+	RaceCount   int                `json:"race_count" db:"race_count"` // Races waiting on this name
+	SeenCount   int                `json:"seen_count" db:"seen_count"`
+	FirstSeen   string             `json:"first_seen" db:"first_seen"`
+	LastSeen    string             `json:"last_seen" db:"last_seen"`
+	Status      string             `json:"status" db:"status"` // pending, resolved, ignored
+	CourseID    *int64             `json:"course_id,omitempty" db:"course_id"`
+	ResolvedAt  *string            `json:"resolved_at,omitempty" db:"resolved_at"`
+	Note        *string            `json:"note,omitempty" db:"note"`
+	Suggestions []CourseSuggestion `json:"suggestions,omitempty" db:"-"`
+}
+
+// CourseSuggestion is a registry course whose name resembles a queued name
+type CourseSuggestion struct {
+	CourseID   int64   `json:"course_id" db:"course_id"`
+	CourseName string  `json:"course_name" db:"course_name"`
+	Similarity float64 `json:"similarity" db:"similarity"`
+}
+
+// CourseReviewFilters represents query parameters for the review queue
+type CourseReviewFilters struct {
+	Status *string `form:"status"` // Default pending
+	Source *string `form:"source"`
+	Region *string `form:"region"`
+	Limit  int     `form:"limit"`
+	Offset int     `form:"offset"`
+}
+
+// CourseResolveRequest resolves a queued name to an existing course (course_id)
+// or to a new one (course)
+type CourseResolveRequest struct {
+	CourseID *int64         `json:"course_id"`
+	Course   *CourseRequest `json:"course"`
+	Note     string         `json:"note"`
+}
+
+// CourseResolveResult reports what resolving a queued name changed
+type CourseResolveResult struct {
+	Course       CourseDetail `json:"course"`
+	Resolved     int          `json:"resolved"`      // Queue entries closed (same name from any source)
+	RacesUpdated int          `json:"races_updated"` // Races (and entry races) given the course_id
+}
+
+// CourseOrphan is a course_id referenced by races but missing from the registry
+type CourseOrphan struct {
+	CourseID    int64          `json:"course_id" db:"course_id"`
+	Region      string         `json:"region" db:"region"`
+	RaceCount   int            `json:"race_count" db:"race_count"`
+	FirstRace   string         `json:"first_race" db:"first_race"`
+	LastRace    string         `json:"last_race" db:"last_race"`
+	SampleRaces pq.StringArray `json:"sample_races" db:"sample_races"`
+}
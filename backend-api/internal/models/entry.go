@@ -0,0 +1,43 @@
+package models
+
+// Entry represents a horse entered or declared for an upcoming race
+type Entry struct {
+	EntryID     int64    `json:"entry_id" db:"entry_id"`
+	EntryRaceID int64    `json:"entry_race_id" db:"sl_race_id"`
+	RaceDate    string   `json:"race_date" db:"race_date"`
+	Region      string   `json:"region" db:"region"`
+	CourseID    *int64   `json:"course_id,omitempty" db:"course_id"`
+	CourseName  *string  `json:"course_name,omitempty" db:"course_name"`
+	OffTime     *string  `json:"off_time,omitempty" db:"off_time"`
+	RaceName    string   `json:"race_name" db:"race_name"`
+	RaceType    *string  `json:"race_type,omitempty" db:"race_type"`
+	Class       *string  `json:"class,omitempty" db:"class"`
+	DistF       *float64 `json:"dist_f,omitempty" db:"dist_f"`
+	Going       *string  `json:"going,omitempty" db:"going"`
+	HorseID     int64    `json:"horse_id" db:"horse_id"`
+	HorseName   string   `json:"horse_name" db:"horse_name"`
+	TrainerID   *int64   `json:"trainer_id,omitempty" db:"trainer_id"`
+	TrainerName *string  `json:"trainer_name,omitempty" db:"trainer_name"`
+	JockeyID    *int64   `json:"jockey_id,omitempty" db:"jockey_id"`
+	JockeyName  *string  `json:"jockey_name,omitempty" db:"jockey_name"`
+	Num         *int     `json:"num,omitempty" db:"num"`
+	Draw        *int     `json:"draw,omitempty" db:"draw"`
+	Lbs         *int     `json:"lbs,omitempty" db:"lbs"`
+	OR          *int     `json:"or,omitempty" db:"or"`
+	Status      string   `json:"status" db:"status"`
+	EnteredAt   string   `json:"entered_at" db:"entered_at"`
+	DeclaredAt  *string  `json:"declared_at,omitempty" db:"declared_at"`
+	SettledAt   *string  `json:"settled_at,omitempty" db:"settled_at"`
+}
+
+// EntryFilters represents query parameters for entry lookups
+type EntryFilters struct {
+	HorseID     *int64  `form:"horse_id"`
+	TrainerID   *int64  `form:"trainer_id"`
+	EntryRaceID *int64  `form:"race_id"`
+	Status      *string `form:"status"`
+	DateFrom    *string `form:"date_from"`
+	DateTo      *string `form:"date_to"`
+	Limit       int     `form:"limit"`
+	Offset      int     `form:"offset"`
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Export formats
+const (
+	ExportCSV     = "csv"
+	ExportNDJSON  = "ndjson"
+	ExportParquet = "parquet"
+)
+
+// Export job statuses
+const (
+	ExportQueued    = "queued"
+	ExportRunning   = "running"
+	ExportCompleted = "completed"
+	ExportFailed    = "failed"
+	ExportExpired   = "expired"
+)
+
+// ExportParams are the query parameters of a streamed export: the race
+// search filters plus format and columns
+type ExportParams struct {
+	RaceFilterSet
+	Format  string `form:"format" binding:"omitempty,oneof=csv ndjson parquet"`
+	Columns string `form:"columns"` // Comma-separated (default: the dataset's default columns)
+}
+
+// ExportRequest queues a background export
+type ExportRequest struct {
+	Dataset string        `json:"dataset" binding:"required,oneof=races runners"`
+	Format  string        `json:"format" binding:"omitempty,oneof=csv ndjson parquet"`
+	Columns []string      `json:"columns,omitempty"` // Default: the dataset's default columns
+	Filters RaceFilterSet `json:"filters"`
+}
+
+// ExportJob is a background export (racing.export_jobs)
+type ExportJob struct {
+	JobID       int64          `json:"job_id" db:"job_id"`
+	Client      string         `json:"client" db:"client"`
+	KeyID       *int64         `json:"-" db:"key_id"`
+	Dataset     string         `json:"dataset" db:"dataset"`
+	Format      string         `json:"format" db:"format"`
+	Columns     pq.StringArray `json:"columns" db:"columns"`
+	Filters     RaceFilterSet  `json:"filters" db:"-"`
+	Status      string         `json:"status" db:"status"`
+	Rows        int64          `json:"rows" db:"rows"`
+	Bytes       int64          `json:"bytes" db:"bytes"`
+	FilePath    *string        `json:"-" db:"file_path"`
+	Error       *string        `json:"error,omitempty" db:"error"`
+	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
+	StartedAt   *time.Time     `json:"started_at,omitempty" db:"started_at"`
+	CompletedAt *time.Time     `json:"completed_at,omitempty" db:"completed_at"`
+	ExpiresAt   *time.Time     `json:"expires_at,omitempty" db:"expires_at"`
+	DownloadURL string         `json:"download_url,omitempty" db:"-"`
+}
+
+// ExportColumnInfo describes a column that can be exported
+type ExportColumnInfo struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"` // integer, number, string, boolean or date
+	Default bool   `json:"default"`
+}
+
+// ExportDataset lists a dataset's columns
+type ExportDataset struct {
+	Dataset string             `json:"dataset"`
+	Columns []ExportColumnInfo `json:"columns"`
+}
@@ -0,0 +1,96 @@
+package models
+
+// H2HParams are the options of head-to-head comparisons
+type H2HParams struct {
+	DateFrom  *string `form:"date_from"` // Only races on or after this date (default: whole careers; 3 years before a race's h2h)
+	Opponents int     `form:"opponents"` // Common opponents listed per pair (default 10, max 50)
+}
+
+// HorseCompareParams select the horses /horses/compare compares
+type HorseCompareParams struct {
+	IDs string `form:"ids" binding:"required"` // 2-10 comma-separated horse IDs
+	H2HParams
+}
+
+// H2HRun is a horse's part in a race it shared with another: where it
+// finished, how far behind the winner and what it carried
+type H2HRun struct {
+	RunnerID int64    `json:"runner_id" db:"runner_id"`
+	HorseID  int64    `json:"horse_id" db:"horse_id"`
+	PosNum   *int     `json:"pos_num,omitempty" db:"pos_num"`
+	PosRaw   *string  `json:"pos_raw,omitempty" db:"pos_raw"`
+	OvrBTN   *float64 `json:"ovr_btn,omitempty" db:"ovr_btn"` // Lengths behind the winner
+	Lbs      *int     `json:"lbs,omitempty" db:"lbs"`
+	OR       *int     `json:"or,omitempty" db:"or"`
+	RPR      *int     `json:"rpr,omitempty" db:"rpr"`
+}
+
+// H2HRace is a settled race two horses (or a horse and a common opponent)
+// ran in
+type H2HRace struct {
+	RaceID     int64    `json:"race_id" db:"race_id"`
+	RaceDate   string   `json:"race_date" db:"race_date"`
+	CourseName *string  `json:"course_name,omitempty" db:"course_name"`
+	RaceName   string   `json:"race_name" db:"race_name"`
+	RaceType   string   `json:"race_type" db:"race_type"`
+	Going      *string  `json:"going,omitempty" db:"going"`
+	DistF      *float64 `json:"dist_f,omitempty" db:"dist_f"`
+}
+
+// H2HMeeting is a race two horses met in. Margins are lengths A finished
+// ahead of B (negative: behind); the adjusted margin is at level weights.
+// Both are missing unless both horses finished with a beaten distance.
+type H2HMeeting struct {
+	H2HRace
+	A              H2HRun   `json:"a"`
+	B              H2HRun   `json:"b"`
+	Margin         *float64 `json:"margin,omitempty"`
+	AdjustedMargin *float64 `json:"adjusted_margin,omitempty"`
+}
+
+// H2HOpponent is collateral form through one common opponent: how A and B
+// each fared against it (mean adjusted margins over their meetings) and what
+// that implies for A against B
+type H2HOpponent struct {
+	HorseID       int64   `json:"horse_id"`
+	HorseName     string  `json:"horse_name"`
+	AMeetings     int     `json:"a_meetings"` // A against the opponent
+	BMeetings     int     `json:"b_meetings"` // B against the opponent
+	AMargin       float64 `json:"a_margin"`
+	BMargin       float64 `json:"b_margin"`
+	ImpliedMargin float64 `json:"implied_margin"` // a_margin - b_margin
+	LastMetOn     string  `json:"last_met_on"`    // Latest meeting of either
+}
+
+// H2HSummary totals a pair's direct meetings
+type H2HSummary struct {
+	Meetings          int      `json:"meetings"`
+	AAhead            int      `json:"a_ahead"`
+	BAhead            int      `json:"b_ahead"`
+	AvgMargin         *float64 `json:"avg_margin,omitempty"`
+	AvgAdjustedMargin *float64 `json:"avg_adjusted_margin,omitempty"`
+}
+
+// H2HCollateral totals a pair's collateral form
+type H2HCollateral struct {
+	Opponents           int           `json:"opponents"` // Common opponents with margins against both
+	AvgImpliedMargin    *float64      `json:"avg_implied_margin,omitempty"`
+	MedianImpliedMargin *float64      `json:"median_implied_margin,omitempty"`
+	CommonOpponents     []H2HOpponent `json:"common_opponents"` // Most recently met first, up to the opponents limit
+}
+
+// H2HPair compares two horses directly and through common opponents
+type H2HPair struct {
+	A          Horse         `json:"a"`
+	B          Horse         `json:"b"`
+	Summary    H2HSummary    `json:"summary"`
+	Meetings   []H2HMeeting  `json:"meetings"` // Latest first
+	Collateral H2HCollateral `json:"collateral"`
+}
+
+// H2HComparison compares every pair of a set of horses
+type H2HComparison struct {
+	RaceID *int64    `json:"race_id,omitempty"` // /races/:id/h2h
+	Horses []Horse   `json:"horses"`
+	Pairs  []H2HPair `json:"pairs"`
+}
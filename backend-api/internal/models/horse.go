@@ -31,7 +31,10 @@ type CareerSummary struct {
 
 // FormEntry represents a single race result in form history
 type FormEntry struct {
+	RunnerID    int64    `json:"runner_id" db:"runner_id"`
+	RaceID      int64    `json:"race_id" db:"race_id"`
 	RaceDate    string   `json:"race_date" db:"race_date"`
+	HorseName   *string  `json:"horse_name,omitempty" db:"horse_name"`
 	CourseName  *string  `json:"course_name" db:"course_name"`
 	RaceName    string   `json:"race_name" db:"race_name"`
 	RaceType    string   `json:"race_type" db:"race_type"`
@@ -0,0 +1,77 @@
+package models
+
+import "encoding/json"
+
+// HorseIdentity is a horse with its identity attributes, aliases and decision log
+type HorseIdentity struct {
+	HorseID       int64              `json:"horse_id" db:"horse_id"`
+	HorseName     string             `json:"horse_name" db:"horse_name"`
+	Disambig      string             `json:"disambig,omitempty" db:"disambig"`
+	SLHorseID     *int64             `json:"sl_horse_id,omitempty" db:"sl_horse_id"`
+	BFSelectionID *int64             `json:"bf_selection_id,omitempty" db:"bf_selection_id"`
+	FoaledYear    *int               `json:"foaled_year,omitempty" db:"foaled_year"`
+	Suffix        *string            `json:"suffix,omitempty" db:"suffix"`
+	Sire          *string            `json:"sire,omitempty" db:"sire"`
+	Dam           *string            `json:"dam,omitempty" db:"dam"`
+	UpdatedAt     *string            `json:"updated_at,omitempty" db:"updated_at"`
+	Aliases       []string           `json:"aliases" db:"-"`
+	FoaledYears   []FoaledYearRuns   `json:"foaled_years" db:"-"` // Runs grouped by implied foaling year
+	Log           []HorseIdentityLog `json:"log" db:"-"`
+}
+
+// FoaledYearRuns counts a horse's runs per implied foaling year (race year - age)
+type FoaledYearRuns struct {
+	FoaledYear int    `json:"foaled_year" db:"foaled_year"`
+	Runs       int    `json:"runs" db:"runs"`
+	FirstRun   string `json:"first_run" db:"first_run"`
+	LastRun    string `json:"last_run" db:"last_run"`
+}
+
+// HorseIdentityLog is one resolver/admin identity decision
+type HorseIdentityLog struct {
+	LogID        int64           `json:"log_id" db:"log_id"`
+	HorseID      int64           `json:"horse_id" db:"horse_id"`
+	OtherHorseID *int64          `json:"other_horse_id,omitempty" db:"other_horse_id"`
+	Action       string          `json:"action" db:"action"`
+	Source       string          `json:"source" db:"source"`
+	Detail       json.RawMessage `json:"detail" db:"detail"`
+	Note         *string         `json:"note,omitempty" db:"note"`
+	CreatedAt    string          `json:"created_at" db:"created_at"`
+}
+
+// HorseIdentityConflict is a horse whose form implies more than one foaling year
+type HorseIdentityConflict struct {
+	HorseID       int64  `json:"horse_id" db:"horse_id"`
+	HorseName     string `json:"horse_name" db:"horse_name"`
+	MinFoaledYear int    `json:"min_foaled_year" db:"min_foaled_year"`
+	MaxFoaledYear int    `json:"max_foaled_year" db:"max_foaled_year"`
+	Runs          int    `json:"runs" db:"runs"`
+}
+
+// HorseMergeRequest merges source into target (source is deleted)
+type HorseMergeRequest struct {
+	SourceID int64  `json:"source_id" binding:"required"`
+	TargetID int64  `json:"target_id" binding:"required"`
+	Note     string `json:"note"`
+}
+
+// HorseSplitRequest moves runs to a new horse: the listed runner_ids, or every
+// run whose implied foaling year equals foaled_year
+type HorseSplitRequest struct {
+	RunnerIDs  []int64 `json:"runner_ids"`
+	FoaledYear *int    `json:"foaled_year"`
+	Name       string  `json:"name"`     // Defaults to the original name
+	Suffix     string  `json:"suffix"`   // e.g. IRE
+	Disambig   string  `json:"disambig"` // Defaults to suffix-year / year
+	SLHorseID  *int64  `json:"sl_horse_id"`
+	Note       string  `json:"note"`
+}
+
+// HorseIdentityLogFilters represents query parameters for the identity log
+type HorseIdentityLogFilters struct {
+	HorseID *int64  `form:"horse_id"`
+	Action  *string `form:"action"`
+	Source  *string `form:"source"`
+	Limit   int     `form:"limit"`
+	Offset  int     `form:"offset"`
+}
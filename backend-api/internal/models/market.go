@@ -3,6 +3,7 @@ package models
 // MarketMover represents a market mover (steamer/drifter)
 type MarketMover struct {
 	RaceID       int64    `json:"race_id" db:"race_id"`
+	RunnerID     int64    `json:"runner_id" db:"runner_id"`
 	RaceDate     string   `json:"race_date" db:"race_date"`
 	OffTime      *string  `json:"off_time,omitempty" db:"off_time"`
 	CourseName   string   `json:"course_name" db:"course_name"`
@@ -50,3 +51,16 @@ type BookVsExchange struct {
 	SPPL         *float64 `json:"sp_pl,omitempty" db:"sp_pl"`
 	BSPPL        *float64 `json:"bsp_pl,omitempty" db:"bsp_pl"`
 }
+
+// BookmakerVsExchange compares one bookmaker's shows with the Betfair exchange
+// price at the same moment (shows vs live back price, SP vs BSP)
+type BookmakerVsExchange struct {
+	Bookmaker        string   `json:"bookmaker" db:"bookmaker"`
+	ShowType         string   `json:"show_type" db:"show_type"`
+	Samples          int      `json:"samples" db:"samples"`
+	AvgBookPrice     *float64 `json:"avg_book_price,omitempty" db:"avg_book_price"`
+	AvgExchangePrice *float64 `json:"avg_exchange_price,omitempty" db:"avg_exchange_price"`
+	AvgRatio         *float64 `json:"avg_ratio,omitempty" db:"avg_ratio"`             // book / exchange
+	PctBookBetter    *float64 `json:"pct_book_better,omitempty" db:"pct_book_better"` // % of samples where book > exchange
+	AvgLagSecs       *float64 `json:"avg_lag_secs,omitempty" db:"avg_lag_secs"`       // book ts - exchange ts
+}
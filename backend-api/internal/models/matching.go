@@ -0,0 +1,57 @@
+package models
+
+import "encoding/json"
+
+// MatchOverride is a manual pin/forbid honoured by every matcher
+type MatchOverride struct {
+	OverrideID int64   `json:"override_id" db:"override_id"`
+	Level      string  `json:"level" db:"level"`
+	RaceKey    string  `json:"race_key" db:"race_key"`
+	RaceDate   *string `json:"race_date,omitempty" db:"race_date"`
+	Horse      string  `json:"horse,omitempty" db:"horse"`
+	BFRef      string  `json:"bf_ref" db:"bf_ref"`
+	Action     string  `json:"action" db:"action"`
+	Note       *string `json:"note,omitempty" db:"note"`
+	CreatedAt  string  `json:"created_at" db:"created_at"`
+}
+
+// MatchOverrideRequest is the body for creating an override. The race is given
+// either by race_id or by race_key (+ race_date).
+type MatchOverrideRequest struct {
+	Level    string `json:"level" binding:"required,oneof=race runner"`
+	RaceID   *int64 `json:"race_id"`
+	RaceKey  string `json:"race_key"`
+	RaceDate string `json:"race_date" binding:"omitempty,datetime=2006-01-02"`
+	Horse    string `json:"horse"`
+	BFRef    string `json:"bf_ref" binding:"required"`
+	Action   string `json:"action" binding:"required,oneof=pin forbid"`
+	Note     string `json:"note"`
+}
+
+// MatchAudit is one recorded match attempt
+type MatchAudit struct {
+	AuditID    int64           `json:"audit_id" db:"audit_id"`
+	RunAt      string          `json:"run_at" db:"run_at"`
+	Pipeline   string          `json:"pipeline" db:"pipeline"`
+	Level      string          `json:"level" db:"level"`
+	RaceDate   *string         `json:"race_date,omitempty" db:"race_date"`
+	RaceKey    string          `json:"race_key" db:"race_key"`
+	Subject    string          `json:"subject" db:"subject"`
+	Outcome    string          `json:"outcome" db:"outcome"`
+	ChosenRef  *string         `json:"chosen_ref,omitempty" db:"chosen_ref"`
+	Score      float64         `json:"score" db:"score"`
+	RunnerUp   float64         `json:"runner_up" db:"runner_up"`
+	Override   *string         `json:"override,omitempty" db:"override"`
+	Candidates json.RawMessage `json:"candidates" db:"candidates"`
+}
+
+// MatchAuditFilters represents query parameters for the audit trail
+type MatchAuditFilters struct {
+	Date     *string `form:"date" binding:"omitempty,datetime=2006-01-02"`
+	RaceKey  *string `form:"race_key"`
+	Outcome  *string `form:"outcome"`
+	Level    *string `form:"level" binding:"omitempty,oneof=race runner"`
+	Pipeline *string `form:"pipeline"`
+	Limit    int     `form:"limit"`
+	Offset   int     `form:"offset"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import "encoding/json"
+
+// QualityDay summarises a race date's load and its open data-quality issues
+type QualityDay struct {
+	Date          string `json:"date" db:"date"`
+	RacesLoaded   int    `json:"races_loaded" db:"races_loaded"`
+	RunnersLoaded int    `json:"runners_loaded" db:"runners_loaded"`
+	Errors        int    `json:"errors" db:"errors"`
+	Warnings      int    `json:"warnings" db:"warnings"`
+	Info          int    `json:"info" db:"info"`
+	RacesHeld     int    `json:"races_held" db:"races_held"`     // Quarantined, not loaded
+	RunnersHeld   int    `json:"runners_held" db:"runners_held"` // Quarantined, not loaded
+}
+
+// QualityDayDetail is a date's summary with its issues
+type QualityDayDetail struct {
+	QualityDay
+	ByRule []QualityRuleCount `json:"by_rule"`
+	Issues []QualityIssue     `json:"issues"`
+}
+
+// QualityRuleCount counts a date's open issues for one rule
+type QualityRuleCount struct {
+	Rule     string `json:"rule" db:"rule"`
+	Severity string `json:"severity" db:"severity"`
+	Count    int    `json:"count" db:"count"`
+}
+
+// QualityIssue is a racing.quarantine row
+type QualityIssue struct {
+	ID          int64           `json:"id" db:"id"`
+	Rule        string          `json:"rule" db:"rule"`
+	Severity    string          `json:"severity" db:"severity"` // info, warning, error
+	Scope       string          `json:"scope" db:"scope"`       // race, runner
+	RaceKey     string          `json:"race_key" db:"race_key"`
+	RaceDate    string          `json:"race_date" db:"race_date"`
+	Course      *string         `json:"course,omitempty" db:"course"`
+	OffTime     *string         `json:"off_time,omitempty" db:"off_time"`
+	RunnerKey   string          `json:"runner_key,omitempty" db:"runner_key"`
+	Horse       *string         `json:"horse,omitempty" db:"horse"`
+	Reason      string          `json:"reason" db:"reason"`
+	Held        bool            `json:"held" db:"held"`
+	Payload     json.RawMessage `json:"payload,omitempty" db:"payload"` // The held row (held issues only)
+	Source      string          `json:"source" db:"source"`
+	RunID       *int64          `json:"run_id,omitempty" db:"run_id"`
+	FirstSeenAt string          `json:"first_seen_at" db:"first_seen_at"`
+	LastSeenAt  string          `json:"last_seen_at" db:"last_seen_at"`
+	TimesSeen   int             `json:"times_seen" db:"times_seen"`
+	ResolvedAt  *string         `json:"resolved_at,omitempty" db:"resolved_at"`
+}
+
+// QualityFilters represents query parameters for the quality summary
+type QualityFilters struct {
+	DateFrom *string `form:"date_from" binding:"omitempty,datetime=2006-01-02"` // Default 14 days ago
+	DateTo   *string `form:"date_to" binding:"omitempty,datetime=2006-01-02"`   // Default today
+}
+
+// QualityIssueFilters represents query parameters for a date's issues
+type QualityIssueFilters struct {
+	Severity *string `form:"severity"`
+	Rule     *string `form:"rule"`
+	Held     *bool   `form:"held"`
+	Resolved bool    `form:"resolved"` // Include resolved issues
+	Limit    int     `form:"limit"`
+	Offset   int     `form:"offset"`
+}
@@ -4,21 +4,50 @@ import "time"
 
 // StandardResponse is the unified API envelope for all endpoints
 type StandardResponse struct {
-	Data    interface{}      `json:"data"`              // List, object, or null
-	Summary interface{}      `json:"summary,omitempty"` // Optional aggregates
-	Meta    ResponseMeta     `json:"meta"`              // Pagination, timing
-	Error   *ResponseError   `json:"error,omitempty"`   // Error details (null on success)
+	Data    interface{}    `json:"data"`              // List, object, or null
+	Summary interface{}    `json:"summary,omitempty"` // Optional aggregates
+	Meta    ResponseMeta   `json:"meta"`              // Pagination, timing
+	Error   *ResponseError `json:"error,omitempty"`   // Error details (null on success)
 }
 
 // ResponseMeta contains pagination and request metadata
 type ResponseMeta struct {
-	Limit       int       `json:"limit,omitempty"`        // Requested limit
-	Offset      int       `json:"offset,omitempty"`       // Requested offset
-	Returned    int       `json:"returned"`               // Actual count returned
-	Total       *int      `json:"total,omitempty"`        // Total available (if known)
-	RequestID   string    `json:"request_id,omitempty"`   // For tracing
-	GeneratedAt time.Time `json:"generated_at"`           // Response timestamp
-	LatencyMS   int64     `json:"latency_ms,omitempty"`   // Server-side latency
+	Limit       int       `json:"limit,omitempty"`      // Requested limit
+	Offset      int       `json:"offset,omitempty"`     // Requested offset
+	Returned    int       `json:"returned"`             // Actual count returned
+	Total       *int      `json:"total,omitempty"`      // Total available (if known)
+	RequestID   string    `json:"request_id,omitempty"` // For tracing
+	GeneratedAt time.Time `json:"generated_at"`         // Response timestamp
+	LatencyMS   int64     `json:"latency_ms,omitempty"` // Server-side latency
+	NextCursor  *string   `json:"next_cursor"`          // Cursor of the next page (null on the last)
+}
+
+// Page is one page of a list in the standard envelope. Pass Meta.NextCursor
+// back as ?cursor= for the next page.
+type Page[T any] struct {
+	Data []T          `json:"data"`
+	Meta ResponseMeta `json:"meta"`
+}
+
+// NewPage wraps one page of a list; next is "" on the last page
+func NewPage[T any](data []T, limit int, next string) Page[T] {
+	if data == nil {
+		data = []T{}
+	}
+	return Page[T]{Data: data, Meta: NewPageMeta(len(data), limit, next)}
+}
+
+// NewPageMeta returns the meta of a page of returned rows
+func NewPageMeta(returned, limit int, next string) ResponseMeta {
+	meta := ResponseMeta{
+		Limit:       limit,
+		Returned:    returned,
+		GeneratedAt: time.Now().UTC(),
+	}
+	if next != "" {
+		meta.NextCursor = &next
+	}
+	return meta
 }
 
 // ResponseError contains error details
@@ -92,9 +121,15 @@ const (
 
 // PaginationParams holds common pagination parameters
 type PaginationParams struct {
-	Limit  int `form:"limit" binding:"omitempty,min=1,max=1000"`
-	Offset int `form:"offset" binding:"omitempty,min=0"`
-	Sort   string `form:"sort"`  // Comma-separated list, use - prefix for DESC
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=1000"`
+	Offset int    `form:"offset" binding:"omitempty,min=0"`
+	Sort   string `form:"sort"` // Comma-separated list, use - prefix for DESC
+}
+
+// CursorParams pages through a list with opaque cursors
+type CursorParams struct {
+	Limit  int    `form:"limit"`
+	Cursor string `form:"cursor"` // meta.next_cursor of the previous page
 }
 
 // GetLimitOrDefault returns limit or default value
@@ -118,4 +153,3 @@ type DateRangeParams struct {
 	DateFrom string `form:"date_from" binding:"omitempty,datetime=2006-01-02"`
 	DateTo   string `form:"date_to" binding:"omitempty,datetime=2006-01-02"`
 }
-
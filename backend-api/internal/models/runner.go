@@ -72,4 +72,23 @@ type Runner struct {
 	
 	// Price metadata
 	PriceUpdatedAt *string `json:"price_updated_at,omitempty" db:"price_updated_at"`
+
+	// Sectional splits (include=runners.sectionals, where published)
+	Sectionals []Sectional `json:"sectionals,omitempty" db:"-"`
+
+	// Previous runs, latest first (include=runners.form)
+	Form []FormRun `json:"form,omitempty" db:"-"`
+
+	// Latest intraday exchange price (include=runners.live_price)
+	LivePrice *LivePrice `json:"live_price,omitempty" db:"-"`
+}
+
+// Sectional represents one timed section of a runner's race
+type Sectional struct {
+	RunnerID int64   `json:"-" db:"runner_id"`
+	Seq      int     `json:"seq" db:"section_seq"`
+	Label    *string `json:"label,omitempty" db:"section_label"`
+	DistY    *int    `json:"dist_y,omitempty" db:"dist_y"`
+	Secs     float64 `json:"secs" db:"secs"`
+	CumSecs  float64 `json:"cum_secs" db:"cum_secs"`
 }
@@ -38,4 +38,5 @@ type CommentSearchParams struct {
 	Region   *string `form:"region"`
 	CourseID *int64  `form:"course_id"`
 	Limit    int     `form:"limit"`
+	Cursor   string  `form:"cursor"` // meta.next_cursor of the previous page
 }
@@ -0,0 +1,34 @@
+package models
+
+// UsageRow totals a client's requests to one route on one day (UTC)
+type UsageRow struct {
+	Day       string  `json:"day" db:"day"`
+	Client    string  `json:"client" db:"client"` // key:<id>, jwt:<subject> or ip:<address>
+	KeyID     *int64  `json:"key_id,omitempty" db:"key_id"`
+	KeyName   *string `json:"key_name,omitempty" db:"key_name"`
+	Route     string  `json:"route" db:"route"`
+	Method    string  `json:"method" db:"method"`
+	Requests  int64   `json:"requests" db:"requests"`
+	Errors    int64   `json:"errors" db:"errors"`       // 5xx
+	Throttled int64   `json:"throttled" db:"throttled"` // 429
+	Rows      int64   `json:"rows" db:"rows"`
+	AvgMS     float64 `json:"avg_ms" db:"avg_ms"`
+	MaxMS     int64   `json:"max_ms" db:"max_ms"`
+}
+
+// UsageReport is a client's usage with its rate limits
+type UsageReport struct {
+	Client string            `json:"client"`
+	Quotas map[string]string `json:"quotas"` // Per route group, e.g. "heavy": "10/1m"
+	Usage  []UsageRow        `json:"usage"`
+}
+
+// UsageFilters represents query parameters for usage
+type UsageFilters struct {
+	DateFrom *string `form:"date_from" binding:"omitempty,datetime=2006-01-02"` // Default 6 days before date_to
+	DateTo   *string `form:"date_to" binding:"omitempty,datetime=2006-01-02"`   // Default today (UTC)
+	Client   *string `form:"client"`                                            // Admin only
+	KeyID    *int64  `form:"key_id"`                                            // Admin only
+	Limit    int     `form:"limit"`
+	Offset   int     `form:"offset"`
+}
@@ -0,0 +1,65 @@
+package models
+
+// Embedded resources (?include=)
+const (
+	IncludeRunners    = "runners"
+	IncludeSectionals = "runners.sectionals"
+	IncludeForm       = "runners.form"
+	IncludeLivePrice  = "runners.live_price"
+)
+
+// RaceView is what a race response carries: the race and runner fields
+// (?fields=, empty = every field) and the resources embedded (?include=).
+// The repository only selects and joins what the view needs.
+type RaceView struct {
+	RaceFields   []string
+	RunnerFields []string
+	Runners      bool
+	Sectionals   bool
+	Form         bool
+	LivePrice    bool
+}
+
+// Sparse reports whether fields were picked, so responses must be trimmed
+func (v RaceView) Sparse() bool {
+	return len(v.RaceFields) > 0 || len(v.RunnerFields) > 0
+}
+
+// RaceViewParams are the query parameters selecting a RaceView
+type RaceViewParams struct {
+	Fields  string `form:"fields"`  // Comma-separated race fields; runners.<field> for runner fields
+	Include string `form:"include"` // runners, runners.sectionals, runners.form, runners.live_price
+}
+
+// FormKey is a horse's form before a race date (YYYY-MM-DD)
+type FormKey struct {
+	HorseID int64
+	Before  string
+}
+
+// FormRun is one of a runner's previous runs (include=runners.form)
+type FormRun struct {
+	RaceID     int64    `json:"race_id" db:"race_id"`
+	RaceDate   string   `json:"race_date" db:"race_date"`
+	CourseName *string  `json:"course_name,omitempty" db:"course_name"`
+	RaceType   string   `json:"race_type" db:"race_type"`
+	Class      *string  `json:"class,omitempty" db:"class"`
+	DistF      *float64 `json:"dist_f,omitempty" db:"dist_f"`
+	Going      *string  `json:"going,omitempty" db:"going"`
+	Ran        int      `json:"ran" db:"ran"`
+	PosRaw     *string  `json:"pos_raw,omitempty" db:"pos_raw"`
+	PosNum     *int     `json:"pos_num,omitempty" db:"pos_num"`
+	BTN        *float64 `json:"btn,omitempty" db:"btn"`
+	OR         *int     `json:"or,omitempty" db:"or"`
+	RPR        *int     `json:"rpr,omitempty" db:"rpr"`
+	WinBSP     *float64 `json:"win_bsp,omitempty" db:"win_bsp"`
+}
+
+// LivePrice is a runner's latest intraday exchange price (include=runners.live_price)
+type LivePrice struct {
+	BackPrice *float64 `json:"back_price,omitempty" db:"back_price"`
+	LayPrice  *float64 `json:"lay_price,omitempty" db:"lay_price"`
+	VWAP      *float64 `json:"vwap,omitempty" db:"vwap"`
+	TradedVol *float64 `json:"traded_vol,omitempty" db:"traded_vol"`
+	At        string   `json:"at" db:"ts"`
+}
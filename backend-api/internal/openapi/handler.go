@@ -0,0 +1,82 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Spec serves the document once the router is complete. Its own routes are
+// registered before Build, so they are documented like the rest.
+type Spec struct {
+	info Info
+	ops  []Operation
+
+	mu   sync.RWMutex
+	body []byte
+}
+
+// NewSpec creates a spec for the operations
+func NewSpec(info Info, ops []Operation) *Spec {
+	return &Spec{info: info, ops: ops}
+}
+
+// Build generates the document from the engine's routes
+func (s *Spec) Build(routes gin.RoutesInfo) error {
+	body, err := json.Marshal(Build(s.info, routes, s.ops))
+	if err != nil {
+		return fmt.Errorf("failed to encode OpenAPI document: %w", err)
+	}
+	s.mu.Lock()
+	s.body = body
+	s.mu.Unlock()
+	return nil
+}
+
+// ServeJSON serves the document
+// GET /openapi.json
+func (s *Spec) ServeJSON(c *gin.Context) {
+	s.mu.RLock()
+	body := s.body
+	s.mu.RUnlock()
+	if body == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "OpenAPI document not built"})
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// docsPage loads Swagger UI from a CDN and points it at the document
+var docsPage = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>{{.Title}}</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({
+      url: {{.URL}},
+      dom_id: "#swagger-ui",
+      deepLinking: true,
+      persistAuthorization: true
+    });
+  </script>
+</body>
+</html>
+`))
+
+// ServeDocs serves the interactive docs UI
+// GET /docs
+func (s *Spec) ServeDocs(c *gin.Context) {
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	docsPage.Execute(c.Writer, struct{ Title, URL string }{s.info.Title, "/openapi.json"})
+}
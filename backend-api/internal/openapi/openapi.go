@@ -0,0 +1,375 @@
+// Package openapi generates the API's OpenAPI 3 document from the routes
+// registered on the gin engine and the model structs their handlers bind and
+// return. Every route needs an Operation (see Check); query parameters come
+// from the form and binding tags of the Query struct, bodies and responses
+// from json tags.
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"giddyup/api/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Operation documents one route
+type Operation struct {
+	Method      string
+	Path        string // As registered, e.g. /api/v1/races/:id
+	Summary     string
+	Description string
+	Scope       string      // Scope the route requires ("" = any caller)
+	Query       interface{} // Struct whose form tags are the query parameters
+	Params      []Param     // Query parameters the handler reads itself
+	Body        interface{} // JSON request body
+	Response    interface{} // Success body (a zero value of its type)
+	Status      int         // Success status (default 200)
+	ContentType string      // Success media type (default application/json)
+}
+
+// Param is a query parameter read with c.Query
+type Param struct {
+	Name        string
+	Type        string // string, integer, number, boolean or date
+	Description string
+	Required    bool
+	Enum        []string
+}
+
+// Info is the document's info object
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Document is an OpenAPI 3.0 document
+type Document struct {
+	OpenAPI    string                                 `json:"openapi"`
+	Info       Info                                   `json:"info"`
+	Tags       []Tag                                  `json:"tags,omitempty"`
+	Paths      map[string]map[string]*OperationObject `json:"paths"`
+	Components Components                             `json:"components"`
+}
+
+// Tag groups operations (one per first path segment)
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// Components holds shared schemas and the security schemes
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes"`
+}
+
+// SecurityScheme is an API key header or bearer token
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme,omitempty"`
+	In          string `json:"in,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// OperationObject is an operation in the document
+type OperationObject struct {
+	OperationID    string                `json:"operationId"`
+	Summary        string                `json:"summary,omitempty"`
+	Description    string                `json:"description,omitempty"`
+	Tags           []string              `json:"tags,omitempty"`
+	Parameters     []*Parameter          `json:"parameters,omitempty"`
+	RequestBody    *RequestBody          `json:"requestBody,omitempty"`
+	Responses      map[string]*Response  `json:"responses"`
+	Security       []map[string][]string `json:"security,omitempty"`
+	Scope          string                `json:"x-scope,omitempty"`
+	RateLimitGroup string                `json:"x-rate-limit-group,omitempty"`
+}
+
+// Parameter is a path or query parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody is a JSON request body
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response is one response of an operation
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds a body's schema
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// apiError is the body of every error response
+type apiError struct {
+	Error   string `json:"error"`
+	Details string `json:"details,omitempty"`
+}
+
+// Build generates the document for the routes that have an operation
+func Build(info Info, routes gin.RoutesInfo, ops []Operation) *Document {
+	s := newSchemas()
+	s.components["Error"] = s.object(reflect.TypeOf(apiError{}))
+	errorSchema := &Schema{Ref: "#/components/schemas/Error"}
+	byRoute := index(ops)
+
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info:    info,
+		Paths:   make(map[string]map[string]*OperationObject),
+		Components: Components{
+			Schemas: s.components,
+			SecuritySchemes: map[string]*SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", Description: "API key or JWT"},
+				"apiKey":     {Type: "apiKey", In: "header", Name: "X-API-Key"},
+			},
+		},
+	}
+
+	ids := make(map[string]int)
+	tags := make(map[string]bool)
+	for _, route := range routes {
+		op, ok := byRoute[route.Method+" "+route.Path]
+		if !ok {
+			continue
+		}
+
+		obj := &OperationObject{
+			OperationID: operationID(route, ids),
+			Summary:     op.Summary,
+			Description: op.Description,
+			Responses:   make(map[string]*Response),
+		}
+		if tag := tagFor(route.Path); tag != "" {
+			obj.Tags = []string{tag}
+			tags[tag] = true
+		}
+
+		for _, name := range pathParams(route.Path) {
+			schema := &Schema{Type: "integer", Format: "int64"}
+			if name == "date" {
+				schema = &Schema{Type: "string", Format: "date"}
+			}
+			obj.Parameters = append(obj.Parameters, &Parameter{Name: name, In: "path", Required: true, Schema: schema})
+		}
+		if op.Query != nil {
+			obj.Parameters = append(obj.Parameters, queryParams(s, reflect.TypeOf(op.Query))...)
+		}
+		for _, p := range op.Params {
+			obj.Parameters = append(obj.Parameters, p.parameter())
+		}
+		if op.Body != nil {
+			obj.RequestBody = &RequestBody{
+				Required: hasRequired(reflect.TypeOf(op.Body)),
+				Content:  map[string]*MediaType{"application/json": {Schema: s.of(op.Body)}},
+			}
+		}
+
+		status, contentType := op.Status, op.ContentType
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		obj.Responses[fmt.Sprint(status)] = &Response{
+			Description: http.StatusText(status),
+			Content:     map[string]*MediaType{contentType: {Schema: s.of(op.Response)}},
+		}
+
+		errors := []int{http.StatusInternalServerError}
+		if len(obj.Parameters) > 0 || op.Body != nil {
+			errors = append(errors, http.StatusBadRequest)
+		}
+		if strings.HasPrefix(route.Path, "/api/v1/") {
+			obj.RateLimitGroup = ratelimit.GroupFor(route.Path)
+			obj.Security = []map[string][]string{{"bearerAuth": {}}, {"apiKey": {}}}
+			errors = append(errors, http.StatusUnauthorized, http.StatusTooManyRequests)
+		}
+		if op.Scope != "" {
+			obj.Scope = op.Scope
+			errors = append(errors, http.StatusForbidden)
+		}
+		for _, code := range errors {
+			obj.Responses[fmt.Sprint(code)] = &Response{
+				Description: http.StatusText(code),
+				Content:     map[string]*MediaType{"application/json": {Schema: errorSchema}},
+			}
+		}
+
+		path := openAPIPath(route.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*OperationObject)
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = obj
+	}
+
+	for tag := range tags {
+		doc.Tags = append(doc.Tags, Tag{Name: tag})
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool { return doc.Tags[i].Name < doc.Tags[j].Name })
+	return doc
+}
+
+// Check lists routes without an operation, operations without a route and
+// operations without a response schema
+func Check(routes gin.RoutesInfo, ops []Operation) []string {
+	var problems []string
+	byRoute := index(ops)
+	routed := make(map[string]bool)
+	for _, route := range routes {
+		key := route.Method + " " + route.Path
+		routed[key] = true
+		if _, ok := byRoute[key]; !ok {
+			problems = append(problems, fmt.Sprintf("%s has no OpenAPI operation", key))
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, op := range ops {
+		key := op.Method + " " + op.Path
+		switch {
+		case seen[key]:
+			problems = append(problems, fmt.Sprintf("%s is documented twice", key))
+		case !routed[key]:
+			problems = append(problems, fmt.Sprintf("%s is documented but not routed", key))
+		case op.Response == nil:
+			problems = append(problems, fmt.Sprintf("%s has no response schema", key))
+		case op.Query != nil && reflect.TypeOf(op.Query).Kind() != reflect.Struct:
+			problems = append(problems, fmt.Sprintf("%s query parameters must be a struct", key))
+		}
+		seen[key] = true
+	}
+	return problems
+}
+
+func index(ops []Operation) map[string]Operation {
+	byRoute := make(map[string]Operation, len(ops))
+	for _, op := range ops {
+		byRoute[op.Method+" "+op.Path] = op
+	}
+	return byRoute
+}
+
+// queryParams lists a struct's form-tagged fields (embedded structs included)
+func queryParams(s *schemas, t reflect.Type) []*Parameter {
+	var params []*Parameter
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			params = append(params, queryParams(s, f.Type)...)
+			continue
+		}
+		name := tagName(f.Tag.Get("form"))
+		if name == "" || name == "-" {
+			continue
+		}
+		schema := s.schema(f.Type)
+		schema.Nullable = false
+		binding := f.Tag.Get("binding")
+		applyBinding(schema, binding)
+		params = append(params, &Parameter{
+			Name:     name,
+			In:       "query",
+			Required: strings.HasPrefix(binding, "required"),
+			Schema:   schema,
+		})
+	}
+	return params
+}
+
+// hasRequired reports whether a body struct has a required field (a body
+// without one may be left out)
+func hasRequired(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return true
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if strings.HasPrefix(t.Field(i).Tag.Get("binding"), "required") {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Param) parameter() *Parameter {
+	schema := &Schema{Type: p.Type, Enum: p.Enum}
+	switch p.Type {
+	case "date":
+		schema = &Schema{Type: "string", Format: "date"}
+	case "":
+		schema.Type = "string"
+	}
+	return &Parameter{Name: p.Name, In: "query", Description: p.Description, Required: p.Required, Schema: schema}
+}
+
+// operationID names an operation after its handler method (GetRaceRunners
+// becomes getRaceRunners), falling back to the method and path
+func operationID(route gin.RouteInfo, ids map[string]int) string {
+	name := route.Handler[strings.LastIndex(route.Handler, ".")+1:]
+	name = strings.TrimSuffix(name, "-fm")
+	if name == "" || strings.HasPrefix(name, "func") {
+		name = strings.ToLower(route.Method)
+		for _, part := range strings.FieldsFunc(route.Path, func(r rune) bool { return r == '/' || r == '-' || r == ':' || r == '.' }) {
+			name += strings.ToUpper(part[:1]) + part[1:]
+		}
+	}
+	name = strings.ToLower(name[:1]) + name[1:]
+
+	ids[name]++
+	if n := ids[name]; n > 1 {
+		name = fmt.Sprintf("%s%d", name, n)
+	}
+	return name
+}
+
+// tagFor groups /api/v1/races/... under "races" and /api/v1/admin/... under "admin"
+func tagFor(path string) string {
+	rest, ok := strings.CutPrefix(path, "/api/v1/")
+	if !ok {
+		return "meta"
+	}
+	tag, _, _ := strings.Cut(rest, "/")
+	return tag
+}
+
+func pathParams(path string) []string {
+	var names []string
+	for _, part := range strings.Split(path, "/") {
+		if strings.HasPrefix(part, ":") || strings.HasPrefix(part, "*") {
+			names = append(names, part[1:])
+		}
+	}
+	return names
+}
+
+// openAPIPath turns /races/:id into /races/{id}
+func openAPIPath(path string) string {
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, ":") || strings.HasPrefix(part, "*") {
+			parts[i] = "{" + part[1:] + "}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
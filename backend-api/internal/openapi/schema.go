@@ -0,0 +1,203 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is an OpenAPI 3.0 schema object (the subset the API needs)
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	rawType       = reflect.TypeOf(json.RawMessage{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// schemas builds component schemas from Go types: named structs become
+// #/components/schemas refs, everything else is inlined
+type schemas struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+}
+
+func newSchemas() *schemas {
+	return &schemas{components: make(map[string]*Schema), names: make(map[reflect.Type]string)}
+}
+
+// of returns the schema of a value's type
+func (s *schemas) of(v interface{}) *Schema {
+	return s.schema(reflect.TypeOf(v))
+}
+
+func (s *schemas) schema(t reflect.Type) *Schema {
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		t, nullable = t.Elem(), true
+	}
+
+	var out *Schema
+	switch {
+	case t == timeType:
+		out = &Schema{Type: "string", Format: "date-time"}
+	case t == rawType || t.Kind() == reflect.Interface:
+		out = &Schema{Description: "Any JSON value"}
+	case t.Kind() == reflect.Struct && t.Implements(marshalerType):
+		out = &Schema{Description: "Any JSON value"}
+	case t.Kind() == reflect.Struct:
+		if t.Name() == "" {
+			out = s.object(t)
+		} else {
+			out = &Schema{Ref: "#/components/schemas/" + s.component(t)}
+		}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			out = &Schema{Type: "string", Format: "byte"}
+		} else {
+			out = &Schema{Type: "array", Items: s.schema(t.Elem())}
+		}
+	case t.Kind() == reflect.Map:
+		out = &Schema{Type: "object", AdditionalProperties: s.schema(t.Elem())}
+	default:
+		out = primitive(t)
+	}
+
+	if nullable && out.Ref == "" {
+		out.Nullable = true
+	}
+	return out
+}
+
+// component registers a named struct once and returns its component name.
+// Types sharing a name across packages get the package as a prefix.
+func (s *schemas) component(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+	name := typeName(t)
+	if _, taken := s.components[name]; taken {
+		pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	s.names[t] = name
+	s.components[name] = &Schema{} // Placeholder so recursive types terminate
+	*s.components[name] = *s.object(t)
+	return name
+}
+
+// typeName capitalizes a type's name; an instantiated generic is named after
+// its type arguments (Page[...models.Race] becomes PageRace)
+func typeName(t reflect.Type) string {
+	name, args, generic := strings.Cut(t.Name(), "[")
+	if generic {
+		for _, arg := range strings.Split(strings.TrimSuffix(args, "]"), ",") {
+			arg = arg[strings.LastIndex(arg, ".")+1:]
+			name += strings.ToUpper(arg[:1]) + arg[1:]
+		}
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// object describes a struct's JSON fields; embedded structs are flattened
+func (s *schemas) object(t reflect.Type) *Schema {
+	out := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := tagName(f.Tag.Get("json"))
+		if name == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded := s.object(ft)
+				for k, v := range embedded.Properties {
+					out.Properties[k] = v
+				}
+				out.Required = append(out.Required, embedded.Required...)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop := s.schema(f.Type)
+		applyBinding(prop, f.Tag.Get("binding"))
+		out.Properties[name] = prop
+		if strings.HasPrefix(f.Tag.Get("binding"), "required") {
+			out.Required = append(out.Required, name)
+		}
+	}
+	return out
+}
+
+func primitive(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	default:
+		return &Schema{Type: "string"}
+	}
+}
+
+// applyBinding carries gin's validation tags (oneof, min, max, datetime)
+// into the schema
+func applyBinding(s *Schema, binding string) {
+	for _, rule := range strings.Split(binding, ",") {
+		key, value, _ := strings.Cut(rule, "=")
+		switch key {
+		case "oneof":
+			s.Enum = strings.Fields(value)
+		case "min", "max":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			if key == "min" {
+				s.Minimum = &n
+			} else {
+				s.Maximum = &n
+			}
+		case "datetime":
+			if value == "2006-01-02" {
+				s.Format = "date"
+			}
+		}
+	}
+}
+
+func tagName(tag string) string {
+	name, _, _ := strings.Cut(tag, ",")
+	return name
+}
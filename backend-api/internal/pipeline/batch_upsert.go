@@ -1,9 +1,8 @@
-package services
+package pipeline
 
 import (
 	"database/sql"
 	"fmt"
-	"log"
 	"strings"
 
 	"github.com/lib/pq"
@@ -92,88 +91,3 @@ func UpsertNamesAndFetchIDs(tx *sql.Tx, table, idCol, nameCol, uniqConstraint st
 
 	return out, rows.Err()
 }
-
-// upsertCoursesAndFetchIDs performs batch upsert for courses (two columns: name + region)
-func UpsertCoursesAndFetchIDs(tx *sql.Tx, courses map[string]string) (map[string]int64, error) {
-	if len(courses) == 0 {
-		return map[string]int64{}, nil
-	}
-
-	tmp := "tmp_courses"
-	if _, err := tx.Exec(`CREATE TEMP TABLE ` + tmp + ` (course_name text, region text) ON COMMIT DROP`); err != nil {
-		return nil, fmt.Errorf("create temp courses: %w", err)
-	}
-
-	stmt, err := tx.Prepare(pq.CopyIn(tmp, "course_name", "region"))
-	if err != nil {
-		return nil, fmt.Errorf("copyin prep courses: %w", err)
-	}
-
-	for name, region := range courses {
-		name = strings.TrimSpace(name)
-		region = strings.TrimSpace(region)
-		if name == "" {
-			continue
-		}
-		if _, err := stmt.Exec(name, region); err != nil {
-			stmt.Close()
-			return nil, err
-		}
-	}
-
-	if _, err := stmt.Exec(); err != nil {
-		stmt.Close()
-		return nil, err
-	}
-	if err := stmt.Close(); err != nil {
-		return nil, err
-	}
-
-	// Insert with region update on conflict
-	ins := `
-		WITH src AS (
-			SELECT DISTINCT course_name, region FROM tmp_courses
-			WHERE course_name IS NOT NULL AND course_name <> ''
-		)
-		INSERT INTO racing.courses (course_name, region)
-		SELECT course_name, region FROM src
-		ON CONFLICT ON CONSTRAINT courses_uniq DO UPDATE SET region = EXCLUDED.region
-	`
-	if _, err := tx.Exec(ins); err != nil {
-		return nil, fmt.Errorf("insert courses: %w", err)
-	}
-
-	// Fetch IDs
-	sel := `
-		SELECT c.course_id, c.course_name
-		FROM racing.courses c
-		JOIN tmp_courses t ON racing.norm_text(c.course_name) = racing.norm_text(t.course_name)
-	`
-	rows, err := tx.Query(sel)
-	if err != nil {
-		return nil, fmt.Errorf("select course ids: %w", err)
-	}
-	defer rows.Close()
-
-	out := map[string]int64{}
-	for rows.Next() {
-		var id int64
-		var nm string
-		if err := rows.Scan(&id, &nm); err != nil {
-			return nil, err
-		}
-		out[strings.TrimSpace(nm)] = id
-	}
-
-	// DEBUG: Log any courses that failed to match
-	if len(out) < len(courses) {
-		for courseName := range courses {
-			if _, found := out[strings.TrimSpace(courseName)]; !found {
-				log.Printf("⚠️  [CourseMatch] FAILED to find course_id for: '%s' (region: %s)",
-					courseName, courses[courseName])
-			}
-		}
-	}
-
-	return out, rows.Err()
-}
@@ -0,0 +1,79 @@
+package pipeline
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"giddyup/api/internal/scraper"
+)
+
+// insertBookmakerPrices appends a runner's bookmaker shows to racing.bookmaker_prices.
+// A show is only written when it differs from that bookmaker's last stored price, so
+// repeated racecard refreshes build a history (opening show → later shows) without
+// duplicating unchanged prices. The industry SP is one show_type 'sp' row (see upsertSP).
+func insertBookmakerPrices(ctx context.Context, tx *sql.Tx, runnerID, raceID int64, raceDate string, runner scraper.Runner) error {
+	for _, odds := range runner.BookmakerOdds {
+		if odds.Decimal < 1.01 || odds.Bookmaker == "" {
+			continue
+		}
+
+		ts := odds.CapturedAt
+		if ts.IsZero() {
+			ts = time.Now().UTC()
+		}
+
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO racing.bookmaker_prices (
+				runner_id, race_id, race_date, bookmaker, bookmaker_id,
+				ts, dec, frac, show_type, ew_places
+			)
+			SELECT $1, $2, $3, $4, $5, $6, $7, $8,
+				CASE WHEN last.dec IS NULL THEN 'opening' ELSE 'show' END,
+				$9
+			FROM (SELECT 1) one
+			LEFT JOIN LATERAL (
+				SELECT bp.dec
+				FROM racing.bookmaker_prices bp
+				WHERE bp.runner_id = $1 AND bp.race_date = $3
+					AND bp.bookmaker = $4 AND bp.show_type <> 'sp'
+				ORDER BY bp.ts DESC
+				LIMIT 1
+			) last ON true
+			WHERE last.dec IS DISTINCT FROM $7
+			ON CONFLICT DO NOTHING
+		`, runnerID, raceID, raceDate, odds.Bookmaker, nullInt(odds.BookmakerID),
+			ts, odds.Decimal, nullString(odds.Fractional), nullInt(odds.EachWayPlaces))
+		if err != nil {
+			return fmt.Errorf("failed to insert %s price: %w", odds.Bookmaker, err)
+		}
+	}
+
+	if runner.Dec >= 1.01 {
+		if err := upsertSP(ctx, tx, runnerID, raceID, raceDate, runner.Dec, runner.SP); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// upsertSP writes a runner's industry SP as its show_type 'sp' row, replacing
+// a different SP stored before (reloads and amendments)
+func upsertSP(ctx context.Context, tx *sql.Tx, runnerID, raceID int64, raceDate string, dec float64, frac string) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO racing.bookmaker_prices (
+			runner_id, race_id, race_date, bookmaker, ts, dec, frac, show_type
+		) VALUES ($1, $2, $3, 'SP', now(), $4, $5, 'sp')
+		ON CONFLICT (runner_id, race_date, bookmaker) WHERE show_type = 'sp' DO UPDATE SET
+			dec = EXCLUDED.dec,
+			frac = EXCLUDED.frac,
+			ts = EXCLUDED.ts
+		WHERE (racing.bookmaker_prices.dec, racing.bookmaker_prices.frac) IS DISTINCT FROM (EXCLUDED.dec, EXCLUDED.frac)
+	`, runnerID, raceID, raceDate, dec, nullString(frac))
+	if err != nil {
+		return fmt.Errorf("failed to upsert SP: %w", err)
+	}
+	return nil
+}
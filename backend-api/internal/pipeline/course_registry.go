@@ -0,0 +1,146 @@
+package pipeline
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"giddyup/api/internal/scraper"
+
+	"github.com/lib/pq"
+)
+
+// CourseRef identifies a course within a jurisdiction (the same name can exist in several,
+// e.g. Ascot GB and Ascot AUS)
+type CourseRef struct {
+	Name     string
+	Region   string
+	RaceKeys []string // Races waiting on this course (recorded if it goes to review)
+}
+
+// CourseKey is the region-qualified key used in course ID maps ("AUS|Ascot")
+func CourseKey(region, name string) string {
+	return strings.ToUpper(strings.TrimSpace(region)) + "|" + strings.TrimSpace(name)
+}
+
+// CourseRefsFor collects one CourseRef per course in races, with their race keys
+func CourseRefsFor(races []scraper.Race) []CourseRef {
+	byKey := make(map[string]int)
+	var refs []CourseRef
+	for _, race := range races {
+		name := strings.TrimSpace(race.Course)
+		if name == "" {
+			continue
+		}
+		key := CourseKey(race.Region, name)
+		i, ok := byKey[key]
+		if !ok {
+			i = len(refs)
+			byKey[key] = i
+			refs = append(refs, CourseRef{Name: name, Region: race.Region})
+		}
+		refs[i].RaceKeys = append(refs[i].RaceKeys, race.Key())
+	}
+	return refs
+}
+
+// ResolveCourseIDs resolves course names from a source (matching.CourseSource*) through
+// the course registry (racing.resolve_course) and returns IDs keyed both by
+// CourseKey(region, name) and by plain name. Courses are never created here: names
+// that don't resolve are queued in racing.course_review_queue with their race keys,
+// and those races load with a NULL course_id until an admin resolves the name.
+func ResolveCourseIDs(tx *sql.Tx, source string, courses []CourseRef) (map[string]int64, error) {
+	out := map[string]int64{}
+	if len(courses) == 0 {
+		return out, nil
+	}
+
+	tmp := "tmp_course_refs"
+	if _, err := tx.Exec(`CREATE TEMP TABLE IF NOT EXISTS ` + tmp + ` (course_name text, region text, race_key text) ON COMMIT DROP`); err != nil {
+		return nil, fmt.Errorf("create temp course refs: %w", err)
+	}
+	if _, err := tx.Exec(`TRUNCATE ` + tmp); err != nil {
+		return nil, fmt.Errorf("truncate temp course refs: %w", err)
+	}
+
+	stmt, err := tx.Prepare(pq.CopyIn(tmp, "course_name", "region", "race_key"))
+	if err != nil {
+		return nil, fmt.Errorf("copyin prep course refs: %w", err)
+	}
+
+	for _, ref := range courses {
+		name := strings.TrimSpace(ref.Name)
+		region := strings.ToUpper(strings.TrimSpace(ref.Region))
+		if name == "" {
+			continue
+		}
+		keys := ref.RaceKeys
+		if len(keys) == 0 {
+			keys = []string{""}
+		}
+		for _, key := range keys {
+			if _, err := stmt.Exec(name, region, key); err != nil {
+				stmt.Close()
+				return nil, err
+			}
+		}
+	}
+
+	if _, err := stmt.Exec(); err != nil {
+		stmt.Close()
+		return nil, err
+	}
+	if err := stmt.Close(); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(`
+		SELECT t.course_name, t.region, racing.resolve_course($1, t.course_name, t.region)
+		FROM (SELECT DISTINCT course_name, region FROM `+tmp+`) t
+	`, source)
+	if err != nil {
+		return nil, fmt.Errorf("resolve courses: %w", err)
+	}
+	defer rows.Close()
+
+	var unresolved []string
+	for rows.Next() {
+		var name, region string
+		var id sql.NullInt64
+		if err := rows.Scan(&name, &region, &id); err != nil {
+			return nil, err
+		}
+		if !id.Valid {
+			unresolved = append(unresolved, CourseKey(region, name))
+			continue
+		}
+		out[CourseKey(region, name)] = id.Int64
+		if _, taken := out[name]; !taken {
+			out[name] = id.Int64
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if len(unresolved) == 0 {
+		return out, nil
+	}
+
+	if _, err := tx.Exec(`
+		SELECT racing.queue_course_review($1, course_name, region,
+			array_agg(DISTINCT race_key) FILTER (WHERE race_key <> ''))
+		FROM `+tmp+`
+		WHERE racing.resolve_course($1, course_name, region) IS NULL
+		GROUP BY course_name, region
+	`, source); err != nil {
+		return nil, fmt.Errorf("queue course review: %w", err)
+	}
+	for _, key := range unresolved {
+		log.Printf("⚠️  [CourseRegistry] Unresolved %s course '%s' - queued for review", source, key)
+	}
+
+	return out, nil
+}
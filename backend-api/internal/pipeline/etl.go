@@ -0,0 +1,271 @@
+package pipeline
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"giddyup/api/internal/scraper"
+)
+
+// ErrLocked is returned when another loader holds the lock for a date
+var ErrLocked = errors.New("another loader is ingesting this date")
+
+// lockNamespace is the first key of every ingestion advisory lock
+// (pg_try_advisory_lock(int, int)), keeping them apart from other lock users
+const lockNamespace = 7421
+
+// lockPoll is how often a waiting loader retries a held lock
+const lockPoll = 5 * time.Second
+
+// DateLockKey is the advisory lock every loader writing a date's races takes
+// (results, racecards and intraday refreshes alike)
+func DateLockKey(date string) string {
+	return "ingest:" + date
+}
+
+// Lock is a session-level advisory lock held on a dedicated connection
+type Lock struct {
+	conn *sql.Conn
+	key  string
+}
+
+// TryLock takes the advisory lock for key without waiting. It returns
+// ErrLocked if another session holds it.
+func TryLock(ctx context.Context, db *sql.DB, key string) (*Lock, error) {
+	// Session locks belong to a connection, so pin one for the lock's lifetime
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lock connection: %w", err)
+	}
+
+	var ok bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1, hashtext($2))`, lockNamespace, key).Scan(&ok); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to take lock %s: %w", key, err)
+	}
+	if !ok {
+		conn.Close()
+		return nil, fmt.Errorf("%s: %w", key, ErrLocked)
+	}
+	return &Lock{conn: conn, key: key}, nil
+}
+
+// WaitLock takes the advisory lock for key, polling until it is free or ctx is done
+func WaitLock(ctx context.Context, db *sql.DB, key string) (*Lock, error) {
+	logged := false
+	for {
+		lock, err := TryLock(ctx, db, key)
+		if !errors.Is(err, ErrLocked) {
+			return lock, err
+		}
+		if !logged {
+			log.Printf("[Pipeline] ⏳ %s is locked by another loader - waiting", key)
+			logged = true
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockPoll):
+		}
+	}
+}
+
+// Release unlocks and returns the connection to the pool
+func (l *Lock) Release() {
+	if l == nil {
+		return
+	}
+	if _, err := l.conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1, hashtext($2))`, lockNamespace, l.key); err != nil {
+		log.Printf("[Pipeline] ⚠️  Failed to release lock %s: %v", l.key, err)
+	}
+	l.conn.Close()
+}
+
+// LockDatesTx takes the date locks for dates until tx ends, waiting for any
+// loader holding one. Dates are locked in order so two loaders can't deadlock.
+func LockDatesTx(ctx context.Context, tx *sql.Tx, dates []string) error {
+	keys := make([]string, 0, len(dates))
+	for _, date := range dates {
+		keys = append(keys, DateLockKey(date))
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1, hashtext($2))`, lockNamespace, key); err != nil {
+			return fmt.Errorf("failed to take lock %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// Scope is what an ETL run covers (racing.etl_runs.scope)
+type Scope struct {
+	Kind     string   `json:"kind"`
+	Source   string   `json:"source"`
+	Dates    []string `json:"dates"`
+	Regions  []string `json:"regions,omitempty"`
+	Codes    []string `json:"codes,omitempty"`
+	UpdateID int64    `json:"update_id,omitempty"` // racing.data_updates row for pipeline runs
+}
+
+// ETLRun is one racing.etl_runs row
+type ETLRun struct {
+	ID      int64
+	db      *sql.DB
+	started time.Time
+}
+
+// StartETLRun records a run as running
+func StartETLRun(ctx context.Context, db *sql.DB, scope Scope) (*ETLRun, error) {
+	scopeJSON, err := json.Marshal(scope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal scope: %w", err)
+	}
+
+	run := &ETLRun{db: db, started: time.Now()}
+	err = db.QueryRowContext(ctx, `
+		INSERT INTO racing.etl_runs (status, scope) VALUES ('running', $1)
+		RETURNING run_id
+	`, scopeJSON).Scan(&run.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record etl run: %w", err)
+	}
+	return run, nil
+}
+
+// Finish records the run's outcome: success, failed, or canceled when cause
+// is a context cancellation
+func (r *ETLRun) Finish(stats interface{}, cause error) {
+	status := "success"
+	var errMsg interface{}
+	if cause != nil {
+		status = "failed"
+		if errors.Is(cause, context.Canceled) || errors.Is(cause, context.DeadlineExceeded) {
+			status = "canceled"
+		}
+		errMsg = cause.Error()
+	}
+
+	statsJSON, err := json.Marshal(stats)
+	if err != nil {
+		statsJSON = []byte("{}")
+	}
+
+	// Not tied to the run's context: a cancelled run still gets its final status
+	_, err = r.db.Exec(`
+		UPDATE racing.etl_runs SET
+			status = $2,
+			finished_at = now(),
+			stats = $3,
+			error_msg = $4,
+			duration_ms = $5
+		WHERE run_id = $1
+	`, r.ID, status, statsJSON, errMsg, time.Since(r.started).Milliseconds())
+	if err != nil {
+		log.Printf("[Pipeline] ⚠️  Failed to record etl run %d: %v", r.ID, err)
+	}
+}
+
+// Day is one (region, code, date) a loader has fully loaded
+type Day struct {
+	Region string
+	Code   string // flat or jumps (as in the master set's directories)
+	Date   string
+}
+
+// RaceCode returns the ingested_days code for a race type
+func RaceCode(raceType string) string {
+	if strings.EqualFold(strings.TrimSpace(raceType), "flat") {
+		return "flat"
+	}
+	return "jumps"
+}
+
+// DaysFor lists the distinct (region, code, date) covered by races
+func DaysFor(races []scraper.Race) []Day {
+	seen := make(map[Day]bool)
+	var days []Day
+	for _, race := range races {
+		d := Day{Region: strings.ToLower(race.Region), Code: RaceCode(race.Type), Date: race.Date}
+		if d.Region == "" || d.Date == "" || seen[d] {
+			continue
+		}
+		seen[d] = true
+		days = append(days, d)
+	}
+	sort.Slice(days, func(i, j int) bool {
+		if days[i].Date != days[j].Date {
+			return days[i].Date < days[j].Date
+		}
+		if days[i].Region != days[j].Region {
+			return days[i].Region < days[j].Region
+		}
+		return days[i].Code < days[j].Code
+	})
+	return days
+}
+
+// MarkIngested records days as loaded by runID in racing.ingested_days
+func MarkIngested(ctx context.Context, db *sql.DB, runID int64, days []Day) error {
+	for _, d := range days {
+		_, err := db.ExecContext(ctx, `
+			INSERT INTO racing.ingested_days (region, code, d, run_id)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (region, code, d) DO UPDATE SET
+				run_id = EXCLUDED.run_id,
+				inserted_at = now()
+		`, d.Region, d.Code, d.Date, runID)
+		if err != nil {
+			return fmt.Errorf("failed to mark %s/%s %s ingested: %w", d.Region, d.Code, d.Date, err)
+		}
+	}
+	return nil
+}
+
+// Ingested reports whether any results for date are recorded in racing.ingested_days
+func Ingested(ctx context.Context, db *sql.DB, date string) (bool, error) {
+	var ok bool
+	err := db.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM racing.ingested_days WHERE d = $1)
+	`, date).Scan(&ok)
+	if err != nil {
+		return false, fmt.Errorf("failed to check ingested days: %w", err)
+	}
+	return ok, nil
+}
+
+// etlStats is the racing.etl_runs.stats for a pipeline run
+type etlStats struct {
+	StartStage      Stage `json:"start_stage"`
+	RacesScraped    int   `json:"races_scraped"`
+	RunnersScraped  int   `json:"runners_scraped"`
+	BetfairRaces    int   `json:"betfair_races"`
+	RacesMatched    int   `json:"races_matched"`
+	RacesRejected   int   `json:"races_rejected"`
+	RunnersRejected int   `json:"runners_rejected"`
+	Warnings        int   `json:"warnings"`
+	RacesInserted   int   `json:"races_inserted"`
+	RunnersInserted int   `json:"runners_inserted"`
+}
+
+func statsFor(res *Result) etlStats {
+	return etlStats{
+		StartStage:      res.StartStage,
+		RacesScraped:    res.RacesScraped,
+		RunnersScraped:  res.RunnersScraped,
+		BetfairRaces:    res.BetfairRaces,
+		RacesMatched:    res.RacesMatched,
+		RacesRejected:   res.RacesRejected,
+		RunnersRejected: res.RunnersRejected,
+		Warnings:        res.Warnings,
+		RacesInserted:   res.RacesLoaded,
+		RunnersInserted: res.RunnersLoaded,
+	}
+}
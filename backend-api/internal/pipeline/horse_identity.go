@@ -0,0 +1,480 @@
+package pipeline
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"giddyup/api/internal/matching"
+	"giddyup/api/internal/scraper"
+
+	"github.com/lib/pq"
+)
+
+var horseSuffixRe = regexp.MustCompile(`\(([A-Za-z]{2,3})\)\s*$`)
+
+// HorseRef is everything a source tells us about a runner's horse
+type HorseRef struct {
+	Name        string
+	SLHorseID   int64 // Sporting Life horse reference
+	SelectionID int64 // Betfair selection ID
+	FoaledYear  int   // Race year - age
+	Suffix      string
+	Sire        string
+	Dam         string
+}
+
+// Key identifies the ref within a batch (the map key returned by ResolveHorseIDs)
+func (h HorseRef) Key() string {
+	return fmt.Sprintf("%s|%d|%d|%s|%s|%s", h.Name, h.SLHorseID, h.FoaledYear, h.Suffix, h.Sire, h.Dam)
+}
+
+// HorseRefFor describes a runner's horse
+func HorseRefFor(race scraper.Race, runner scraper.Runner) HorseRef {
+	ref := HorseRef{
+		Name:        strings.TrimSpace(runner.Horse),
+		SLHorseID:   int64(runner.SLHorseID),
+		SelectionID: runner.BetfairSelectionID,
+		Suffix:      HorseSuffix(runner.Horse),
+		Sire:        strings.TrimSpace(runner.Sire),
+		Dam:         strings.TrimSpace(runner.Dam),
+	}
+	if runner.Age > 0 && len(race.Date) >= 4 {
+		if year, err := strconv.Atoi(race.Date[:4]); err == nil {
+			ref.FoaledYear = year - runner.Age
+		}
+	}
+	return ref
+}
+
+// HorseSuffix returns the country suffix of a name like "Galileo Gold (IRE)" ("" if none)
+func HorseSuffix(name string) string {
+	if m := horseSuffixRe.FindStringSubmatch(name); m != nil {
+		return strings.ToUpper(m[1])
+	}
+	return ""
+}
+
+// horseRecord is a racing.horses row as seen by the resolver
+type horseRecord struct {
+	ID          int64
+	Name        string
+	SLHorseID   int64
+	SelectionID int64
+	FoaledYear  int
+	Suffix      string
+	Sire        string
+	Dam         string
+	Disambig    string
+}
+
+// conflicts lists the attributes that prove ref is a different horse
+func (h *horseRecord) conflicts(ref HorseRef) []string {
+	var reasons []string
+	if h.SLHorseID > 0 && ref.SLHorseID > 0 && h.SLHorseID != ref.SLHorseID {
+		reasons = append(reasons, "sl_horse_id")
+	}
+	if h.SelectionID > 0 && ref.SelectionID > 0 && h.SelectionID != ref.SelectionID {
+		reasons = append(reasons, "bf_selection_id")
+	}
+	if h.Suffix != "" && ref.Suffix != "" && h.Suffix != ref.Suffix {
+		reasons = append(reasons, "suffix")
+	}
+	// One year of slack for southern-hemisphere ages
+	if h.FoaledYear > 0 && ref.FoaledYear > 0 && abs(h.FoaledYear-ref.FoaledYear) > 1 {
+		reasons = append(reasons, "foaled_year")
+	}
+	if h.Sire != "" && ref.Sire != "" && matching.NormalizeName(h.Sire) != matching.NormalizeName(ref.Sire) {
+		reasons = append(reasons, "sire")
+	}
+	if h.Dam != "" && ref.Dam != "" && matching.NormalizeName(h.Dam) != matching.NormalizeName(ref.Dam) {
+		reasons = append(reasons, "dam")
+	}
+	return reasons
+}
+
+// agreement counts the attributes known on both sides that agree
+func (h *horseRecord) agreement(ref HorseRef) int {
+	n := 0
+	if h.SLHorseID > 0 && h.SLHorseID == ref.SLHorseID {
+		n += 3
+	}
+	if h.SelectionID > 0 && h.SelectionID == ref.SelectionID {
+		n += 2
+	}
+	if h.Suffix != "" && h.Suffix == ref.Suffix {
+		n++
+	}
+	if h.FoaledYear > 0 && h.FoaledYear == ref.FoaledYear {
+		n++
+	}
+	if h.Sire != "" && matching.NormalizeName(h.Sire) == matching.NormalizeName(ref.Sire) {
+		n++
+	}
+	if h.Dam != "" && matching.NormalizeName(h.Dam) == matching.NormalizeName(ref.Dam) {
+		n++
+	}
+	return n
+}
+
+// horseLogEntry is one racing.horse_identity_log row
+type horseLogEntry struct {
+	HorseID int64
+	OtherID int64
+	Action  string
+	Detail  map[string]interface{}
+}
+
+// horseResolver holds the candidate horses for one batch
+type horseResolver struct {
+	tx      *sql.Tx
+	horses  map[int64]*horseRecord
+	byName  map[string][]int64 // source name → horses with that norm_text name or alias
+	bySL    map[int64]int64
+	byBF    map[int64]int64
+	logs    []horseLogEntry
+	created int
+}
+
+// ResolveHorseIDs maps each ref to a horse_id, creating horses as needed. A horse is
+// recognised by Sporting Life reference, then Betfair selection ID, then name (incl.
+// horse_alias); name matches whose suffix, foaling year or sire/dam contradict the
+// ref are rejected and a separate horse is created. New names for a known reference
+// are added to horse_alias. Every decision is logged in racing.horse_identity_log.
+func ResolveHorseIDs(tx *sql.Tx, refs []HorseRef) (map[string]int64, error) {
+	ids := make(map[string]int64, len(refs))
+	if len(refs) == 0 {
+		return ids, nil
+	}
+
+	r := &horseResolver{
+		tx:     tx,
+		horses: make(map[int64]*horseRecord),
+		byName: make(map[string][]int64),
+		bySL:   make(map[int64]int64),
+		byBF:   make(map[int64]int64),
+	}
+	if err := r.load(refs); err != nil {
+		return nil, err
+	}
+
+	for _, ref := range refs {
+		if ref.Name == "" {
+			continue
+		}
+		key := ref.Key()
+		if _, done := ids[key]; done {
+			continue
+		}
+		id, err := r.resolve(ref)
+		if err != nil {
+			return nil, err
+		}
+		ids[key] = id
+	}
+
+	if err := r.writeLog(); err != nil {
+		return nil, err
+	}
+	if r.created > 0 || len(r.logs) > 0 {
+		log.Printf("[HorseIdentity] Resolved %d horses (%d created, %d logged decisions)", len(ids), r.created, len(r.logs))
+	}
+	return ids, nil
+}
+
+// load fetches every horse the batch could refer to (by name, alias, or external ID)
+func (r *horseResolver) load(refs []HorseRef) error {
+	var names []string
+	var slIDs, bfIDs []int64
+	for _, ref := range refs {
+		if ref.Name != "" {
+			names = append(names, ref.Name)
+		}
+		if ref.SLHorseID > 0 {
+			slIDs = append(slIDs, ref.SLHorseID)
+		}
+		if ref.SelectionID > 0 {
+			bfIDs = append(bfIDs, ref.SelectionID)
+		}
+	}
+
+	rows, err := r.tx.Query(`
+		WITH q AS (
+			SELECT DISTINCT name, racing.norm_text(name) AS norm FROM unnest($1::text[]) AS name
+		),
+		c AS (
+			SELECT q.name, h.horse_id FROM q JOIN racing.horses h ON h.horse_norm = q.norm
+			UNION
+			SELECT q.name, a.horse_id FROM q JOIN racing.horse_alias a ON a.alias_norm = q.norm
+			UNION
+			SELECT NULL, horse_id FROM racing.horses WHERE sl_horse_id = ANY($2::bigint[])
+			UNION
+			SELECT NULL, horse_id FROM racing.horses WHERE bf_selection_id = ANY($3::bigint[])
+		)
+		SELECT c.name, h.horse_id, h.horse_name,
+		       COALESCE(h.sl_horse_id, 0), COALESCE(h.bf_selection_id, 0), COALESCE(h.foaled_year, 0),
+		       COALESCE(h.suffix, ''), COALESCE(h.sire, ''), COALESCE(h.dam, ''), h.disambig
+		FROM c
+		JOIN racing.horses h ON h.horse_id = c.horse_id
+	`, pq.Array(names), pq.Array(slIDs), pq.Array(bfIDs))
+	if err != nil {
+		return fmt.Errorf("load horse candidates: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name sql.NullString
+		h := &horseRecord{}
+		if err := rows.Scan(&name, &h.ID, &h.Name, &h.SLHorseID, &h.SelectionID, &h.FoaledYear,
+			&h.Suffix, &h.Sire, &h.Dam, &h.Disambig); err != nil {
+			return fmt.Errorf("scan horse candidate: %w", err)
+		}
+		if _, ok := r.horses[h.ID]; !ok {
+			r.index(h)
+		}
+		if name.Valid {
+			r.byName[name.String] = append(r.byName[name.String], h.ID)
+		}
+	}
+	return rows.Err()
+}
+
+func (r *horseResolver) index(h *horseRecord) {
+	r.horses[h.ID] = h
+	if h.SLHorseID > 0 {
+		r.bySL[h.SLHorseID] = h.ID
+	}
+	if h.SelectionID > 0 {
+		r.byBF[h.SelectionID] = h.ID
+	}
+}
+
+// resolve picks (or creates) the horse for one ref
+func (r *horseResolver) resolve(ref HorseRef) (int64, error) {
+	// 1) External IDs are authoritative
+	for _, ext := range []struct {
+		id    int64
+		index map[int64]int64
+		via   string
+	}{
+		{ref.SLHorseID, r.bySL, "sl_horse_id"},
+		{ref.SelectionID, r.byBF, "bf_selection_id"},
+	} {
+		if ext.id <= 0 {
+			continue
+		}
+		if id, ok := ext.index[ext.id]; ok {
+			h := r.horses[id]
+			if !r.knownAs(ref.Name, id) {
+				if err := r.addAlias(h, ref.Name, ext.via); err != nil {
+					return 0, err
+				}
+			}
+			return id, r.fill(h, ref)
+		}
+	}
+
+	// 2) Name (or alias) matches that don't contradict the ref
+	var best *horseRecord
+	bestScore, ties := -1, 0
+	var rejected []int64
+	for _, id := range r.byName[ref.Name] {
+		h := r.horses[id]
+		if reasons := h.conflicts(ref); len(reasons) > 0 {
+			rejected = append(rejected, id)
+			continue
+		}
+		score := h.agreement(ref)
+		switch {
+		case score > bestScore:
+			best, bestScore, ties = h, score, 0
+		case score == bestScore:
+			ties++
+			if h.ID < best.ID {
+				best = h
+			}
+		}
+	}
+
+	if best != nil {
+		if ties > 0 {
+			r.logs = append(r.logs, horseLogEntry{HorseID: best.ID, Action: "conflict", Detail: map[string]interface{}{
+				"name": ref.Name, "reason": "several horses fit equally well", "candidates": r.byName[ref.Name],
+			}})
+		}
+		return best.ID, r.fill(best, ref)
+	}
+
+	// 3) New horse - its own identity if a same-named horse was ruled out
+	disambig := ""
+	if len(rejected) > 0 {
+		disambig = disambigFor(ref)
+	}
+	h, err := r.create(ref, disambig)
+	if err != nil {
+		return 0, err
+	}
+	detail := map[string]interface{}{"name": ref.Name, "disambig": h.Disambig}
+	var other int64
+	if len(rejected) > 0 {
+		other = rejected[0]
+		detail["conflicts"] = r.horses[other].conflicts(ref)
+	}
+	r.logs = append(r.logs, horseLogEntry{HorseID: h.ID, OtherID: other, Action: "create", Detail: detail})
+	return h.ID, nil
+}
+
+func (r *horseResolver) knownAs(name string, id int64) bool {
+	for _, candidate := range r.byName[name] {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+// disambigFor builds a distinguishing tag for a second horse with the same name
+func disambigFor(ref HorseRef) string {
+	switch {
+	case ref.SLHorseID > 0:
+		return fmt.Sprintf("sl:%d", ref.SLHorseID)
+	case ref.SelectionID > 0:
+		return fmt.Sprintf("bf:%d", ref.SelectionID)
+	case ref.Suffix != "" && ref.FoaledYear > 0:
+		return fmt.Sprintf("%s-%d", ref.Suffix, ref.FoaledYear)
+	case ref.FoaledYear > 0:
+		return strconv.Itoa(ref.FoaledYear)
+	case ref.Suffix != "":
+		return ref.Suffix
+	}
+	return "dup"
+}
+
+// create inserts a new horse, bumping the disambig tag if it is already taken
+func (r *horseResolver) create(ref HorseRef, disambig string) (*horseRecord, error) {
+	for attempt := 1; ; attempt++ {
+		tag := disambig
+		if attempt > 1 {
+			tag = fmt.Sprintf("%s-%d", disambig, attempt)
+		}
+
+		var id int64
+		err := r.tx.QueryRow(`
+			INSERT INTO racing.horses (horse_name, disambig, sl_horse_id, bf_selection_id, foaled_year, suffix, sire, dam, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, now())
+			ON CONFLICT ON CONSTRAINT horses_uniq DO NOTHING
+			RETURNING horse_id
+		`, ref.Name, tag, nullID(ref.SLHorseID), nullID(ref.SelectionID), nullInt(ref.FoaledYear),
+			nullString(ref.Suffix), nullString(ref.Sire), nullString(ref.Dam)).Scan(&id)
+		if err == sql.ErrNoRows {
+			if attempt >= 20 {
+				return nil, fmt.Errorf("create horse %q: no free disambig", ref.Name)
+			}
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("create horse %q: %w", ref.Name, err)
+		}
+
+		h := &horseRecord{ID: id, Name: ref.Name, SLHorseID: ref.SLHorseID, SelectionID: ref.SelectionID,
+			FoaledYear: ref.FoaledYear, Suffix: ref.Suffix, Sire: ref.Sire, Dam: ref.Dam, Disambig: tag}
+		r.index(h)
+		r.byName[ref.Name] = append(r.byName[ref.Name], id)
+		r.created++
+		return h, nil
+	}
+}
+
+// fill records attributes the horse didn't have yet
+func (r *horseResolver) fill(h *horseRecord, ref HorseRef) error {
+	changed := false
+	if h.SLHorseID == 0 && ref.SLHorseID > 0 {
+		if _, taken := r.bySL[ref.SLHorseID]; !taken {
+			h.SLHorseID, changed = ref.SLHorseID, true
+			r.bySL[ref.SLHorseID] = h.ID
+		}
+	}
+	if h.SelectionID == 0 && ref.SelectionID > 0 {
+		if _, taken := r.byBF[ref.SelectionID]; !taken {
+			h.SelectionID, changed = ref.SelectionID, true
+			r.byBF[ref.SelectionID] = h.ID
+		}
+	}
+	if h.FoaledYear == 0 && ref.FoaledYear > 0 {
+		h.FoaledYear, changed = ref.FoaledYear, true
+	}
+	if h.Suffix == "" && ref.Suffix != "" {
+		h.Suffix, changed = ref.Suffix, true
+	}
+	if h.Sire == "" && ref.Sire != "" {
+		h.Sire, changed = ref.Sire, true
+	}
+	if h.Dam == "" && ref.Dam != "" {
+		h.Dam, changed = ref.Dam, true
+	}
+	if !changed {
+		return nil
+	}
+
+	_, err := r.tx.Exec(`
+		UPDATE racing.horses SET
+			sl_horse_id = $2, bf_selection_id = $3, foaled_year = $4,
+			suffix = $5, sire = $6, dam = $7, updated_at = now()
+		WHERE horse_id = $1
+	`, h.ID, nullID(h.SLHorseID), nullID(h.SelectionID), nullInt(h.FoaledYear),
+		nullString(h.Suffix), nullString(h.Sire), nullString(h.Dam))
+	if err != nil {
+		return fmt.Errorf("update horse %d identity: %w", h.ID, err)
+	}
+	return nil
+}
+
+// addAlias records a new name for a horse known by external ID (a rename)
+func (r *horseResolver) addAlias(h *horseRecord, name, via string) error {
+	if _, err := r.tx.Exec(`
+		INSERT INTO racing.horse_alias (horse_id, alias) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`, h.ID, name); err != nil {
+		return fmt.Errorf("add alias %q for horse %d: %w", name, h.ID, err)
+	}
+	r.byName[name] = append(r.byName[name], h.ID)
+	r.logs = append(r.logs, horseLogEntry{HorseID: h.ID, Action: "rename", Detail: map[string]interface{}{
+		"name": h.Name, "alias": name, "via": via,
+	}})
+	return nil
+}
+
+// writeLog appends the batch's decisions to racing.horse_identity_log
+func (r *horseResolver) writeLog() error {
+	for _, entry := range r.logs {
+		detail, err := json.Marshal(entry.Detail)
+		if err != nil {
+			return err
+		}
+		if _, err := r.tx.Exec(`
+			INSERT INTO racing.horse_identity_log (horse_id, other_horse_id, action, source, detail)
+			VALUES ($1, $2, $3, 'resolver', $4)
+		`, entry.HorseID, nullID(entry.OtherID), entry.Action, string(detail)); err != nil {
+			return fmt.Errorf("write horse identity log: %w", err)
+		}
+	}
+	return nil
+}
+
+func nullID(id int64) interface{} {
+	if id <= 0 {
+		return nil
+	}
+	return id
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,107 @@
+package pipeline
+
+import (
+	"slices"
+	"testing"
+
+	"giddyup/api/internal/scraper"
+)
+
+func TestHorseRecordConflicts(t *testing.T) {
+	stored := horseRecord{
+		ID: 1, Name: "Galileo Gold", SLHorseID: 101, SelectionID: 2001,
+		FoaledYear: 2013, Suffix: "GB", Sire: "Paco Boy (IRE)", Dam: "Galicuix",
+	}
+	cases := []struct {
+		name string
+		ref  HorseRef
+		want []string
+	}{
+		{"same horse", HorseRef{Name: "Galileo Gold", SLHorseID: 101, SelectionID: 2001, FoaledYear: 2013,
+			Suffix: "GB", Sire: "Paco Boy", Dam: "GALICUIX"}, nil},
+		{"nothing known", HorseRef{Name: "Galileo Gold"}, nil},
+		{"Sporting Life id", HorseRef{SLHorseID: 102}, []string{"sl_horse_id"}},
+		{"Betfair selection", HorseRef{SelectionID: 2002}, []string{"bf_selection_id"}},
+		{"both ids", HorseRef{SLHorseID: 102, SelectionID: 2002}, []string{"sl_horse_id", "bf_selection_id"}},
+		{"suffix", HorseRef{Suffix: "IRE"}, []string{"suffix"}},
+		{"foaled a year later", HorseRef{FoaledYear: 2014}, nil},
+		{"foaled a year earlier", HorseRef{FoaledYear: 2012}, nil},
+		{"foaled two years later", HorseRef{FoaledYear: 2015}, []string{"foaled_year"}},
+		{"foaled two years earlier", HorseRef{FoaledYear: 2011}, []string{"foaled_year"}},
+		{"sire", HorseRef{Sire: "Frankel"}, []string{"sire"}},
+		{"dam", HorseRef{Dam: "Urban Sea"}, []string{"dam"}},
+		{"everything", HorseRef{SLHorseID: 9, SelectionID: 9, Suffix: "FR", FoaledYear: 2020, Sire: "Frankel", Dam: "Urban Sea"},
+			[]string{"sl_horse_id", "bf_selection_id", "suffix", "foaled_year", "sire", "dam"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := stored.conflicts(tc.ref); !slices.Equal(got, tc.want) {
+				t.Errorf("conflicts = %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	// Attributes the stored horse lacks never conflict
+	if got := (&horseRecord{ID: 2, Name: "Galileo Gold"}).conflicts(HorseRef{SLHorseID: 5, Suffix: "IRE", FoaledYear: 2001}); len(got) > 0 {
+		t.Errorf("bare record conflicts = %v, want none", got)
+	}
+}
+
+func TestHorseRecordAgreement(t *testing.T) {
+	stored := horseRecord{
+		ID: 1, Name: "Galileo Gold", SLHorseID: 101, SelectionID: 2001,
+		FoaledYear: 2013, Suffix: "GB", Sire: "Paco Boy (IRE)", Dam: "Galicuix",
+	}
+	cases := []struct {
+		name string
+		ref  HorseRef
+		want int
+	}{
+		{"name only", HorseRef{Name: "Galileo Gold"}, 0},
+		{"Sporting Life id", HorseRef{SLHorseID: 101}, 3},
+		{"Betfair selection", HorseRef{SelectionID: 2001}, 2},
+		{"suffix", HorseRef{Suffix: "GB"}, 1},
+		{"foaled year", HorseRef{FoaledYear: 2013}, 1},
+		{"foaled a year out agrees on nothing", HorseRef{FoaledYear: 2014}, 0},
+		{"sire and dam, normalized", HorseRef{Sire: "paco boy", Dam: "GALICUIX (GB)"}, 2},
+		{"everything", HorseRef{SLHorseID: 101, SelectionID: 2001, FoaledYear: 2013, Suffix: "GB", Sire: "Paco Boy", Dam: "Galicuix"}, 9},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := stored.agreement(tc.ref); got != tc.want {
+				t.Errorf("agreement = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDisambigFor(t *testing.T) {
+	cases := []struct {
+		name string
+		ref  HorseRef
+		want string
+	}{
+		{"Sporting Life id first", HorseRef{SLHorseID: 101, SelectionID: 2001, Suffix: "IRE", FoaledYear: 2019}, "sl:101"},
+		{"then Betfair selection", HorseRef{SelectionID: 2001, Suffix: "IRE", FoaledYear: 2019}, "bf:2001"},
+		{"suffix and foaling year", HorseRef{Suffix: "IRE", FoaledYear: 2019}, "IRE-2019"},
+		{"foaling year", HorseRef{FoaledYear: 2019}, "2019"},
+		{"suffix", HorseRef{Suffix: "FR"}, "FR"},
+		{"nothing to go on", HorseRef{Name: "Galileo Gold"}, "dup"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := disambigFor(tc.ref); got != tc.want {
+				t.Errorf("disambigFor = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHorseRefFor(t *testing.T) {
+	race := scraper.Race{Date: "2025-10-18"}
+	runner := scraper.Runner{Horse: " Galileo Gold (ire) ", SLHorseID: 101, BetfairSelectionID: 2001, Age: 4, Sire: "Paco Boy ", Dam: " Galicuix"}
+	want := HorseRef{Name: "Galileo Gold (ire)", SLHorseID: 101, SelectionID: 2001, FoaledYear: 2021, Suffix: "IRE", Sire: "Paco Boy", Dam: "Galicuix"}
+	if got := HorseRefFor(race, runner); got != want {
+		t.Errorf("HorseRefFor = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,313 @@
+package pipeline
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"giddyup/api/internal/cache"
+	"giddyup/api/internal/matching"
+	"giddyup/api/internal/scraper"
+)
+
+// Load upserts races and their runners (with bookmaker prices and sectionals)
+// in one transaction and returns the number of races and runners written.
+// Runner IDs are stored back on races for callers that match against Betfair.
+// Cached responses built from the races are invalidated once it commits.
+func Load(ctx context.Context, db *sql.DB, races []scraper.Race, prelim bool) (int, int, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	// Set performance knobs for this transaction
+	tx.Exec(`SET LOCAL synchronous_commit = off`) // Safe for batch ETL
+	tx.Exec(`SET LOCAL statement_timeout = 0`)
+
+	log.Printf("[Pipeline]      📊 Resolving dimension tables (courses, horses, jockeys, trainers, owners)...")
+	if err := ResolveDimensions(tx, races); err != nil {
+		return 0, 0, fmt.Errorf("failed to resolve dimensions: %w", err)
+	}
+
+	raceCount := 0
+	runnerCount := 0
+	raceIDs := make([]int64, 0, len(races))
+	dates := make([]string, 0, len(races))
+
+	progressInterval := 5 // Log every 5 races
+	for raceIdx, race := range races {
+		if raceIdx > 0 && raceIdx%progressInterval == 0 {
+			log.Printf("[Pipeline]      📝 Progress: %d/%d races (%.0f%%), %d runners so far",
+				raceIdx, len(races), float64(raceIdx)/float64(len(races))*100, runnerCount)
+		}
+
+		raceKey := race.Key()
+
+		// Insert race with prelim flag; fields this source left blank keep their stored values
+		var raceID int64
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO racing.races (
+				race_key, race_date, region, course_id, off_time,
+				race_name, race_type, class, dist_raw, dist_f, dist_m,
+				going, surface, ran, prelim, off_time_local
+			) VALUES (
+				$1, $2, $3, $4, $5,
+				$6, $7, $8, $9, $10, $11,
+				$12, $13, $14, $15, $16
+			)
+			ON CONFLICT (race_key, race_date) DO UPDATE SET
+				course_id = COALESCE(EXCLUDED.course_id, racing.races.course_id),
+				off_time = COALESCE(EXCLUDED.off_time, racing.races.off_time),
+				race_name = EXCLUDED.race_name,
+				class = COALESCE(EXCLUDED.class, racing.races.class),
+				dist_raw = COALESCE(EXCLUDED.dist_raw, racing.races.dist_raw),
+				dist_f = COALESCE(EXCLUDED.dist_f, racing.races.dist_f),
+				dist_m = COALESCE(EXCLUDED.dist_m, racing.races.dist_m),
+				going = COALESCE(EXCLUDED.going, racing.races.going),
+				ran = EXCLUDED.ran,
+				prelim = EXCLUDED.prelim,
+				off_time_local = COALESCE(EXCLUDED.off_time_local, racing.races.off_time_local)
+			RETURNING race_id
+		`, raceKey, race.Date, race.Region, nullInt64(race.CourseID), nullString(race.OffTime),
+			race.RaceName, race.Type, nullString(race.Class), nullString(race.Distance),
+			nullFloat64(race.DistanceF), nullInt(race.DistanceM),
+			nullString(race.Going), nullString(race.Surface), race.Ran, prelim,
+			nullString(localOffTime(race))).Scan(&raceID)
+
+		if err != nil {
+			log.Printf("[Pipeline]      ❌ Failed to insert race %d (%s %s): %v", raceIdx, race.Course, race.OffTime, err)
+			return 0, 0, fmt.Errorf("failed to insert race %s: %w", raceKey, err)
+		}
+
+		races[raceIdx].RaceID = int(raceID)
+		raceIDs = append(raceIDs, raceID)
+		dates = append(dates, race.Date)
+		raceCount++
+
+		for j, runner := range race.Runners {
+			runnerKey := runner.Key(raceKey)
+
+			var runnerID int64
+			err := tx.QueryRowContext(ctx, `
+				INSERT INTO racing.runners (
+					runner_key, race_id, race_date,
+					horse_id, trainer_id, jockey_id, owner_id,
+					num, pos_raw, draw, age, lbs, "or", rpr, comment,
+					win_bsp, win_ppwap, place_bsp, place_ppwap,
+					betfair_selection_id, best_odds, best_bookmaker,
+					time_raw, secs, dec
+				) VALUES (
+					$1, $2, $3,
+					$4, $5, $6, $7,
+					$8, $9, $10, $11, $12, $13, $14, $15,
+					$16, $17, $18, $19,
+					$20, $21, $22,
+					$23, $24, $25
+				)
+				ON CONFLICT (runner_key, race_date) DO UPDATE SET
+					pos_raw = COALESCE(EXCLUDED.pos_raw, racing.runners.pos_raw),
+					time_raw = COALESCE(EXCLUDED.time_raw, racing.runners.time_raw),
+					secs = COALESCE(EXCLUDED.secs, racing.runners.secs),
+					dec = COALESCE(EXCLUDED.dec, racing.runners.dec),
+					win_bsp = COALESCE(EXCLUDED.win_bsp, racing.runners.win_bsp),
+					win_ppwap = COALESCE(EXCLUDED.win_ppwap, racing.runners.win_ppwap),
+					place_bsp = COALESCE(EXCLUDED.place_bsp, racing.runners.place_bsp),
+					place_ppwap = COALESCE(EXCLUDED.place_ppwap, racing.runners.place_ppwap),
+					betfair_selection_id = COALESCE(EXCLUDED.betfair_selection_id, racing.runners.betfair_selection_id),
+					best_odds = COALESCE(EXCLUDED.best_odds, racing.runners.best_odds),
+					best_bookmaker = COALESCE(EXCLUDED.best_bookmaker, racing.runners.best_bookmaker)
+				RETURNING runner_id
+			`,
+				runnerKey, raceID, race.Date,
+				nullInt64(runner.HorseID), nullInt64(runner.TrainerID), nullInt64(runner.JockeyID), nullInt64(runner.OwnerID),
+				nullInt(runner.Num), nullString(runner.Pos), nullInt(runner.Draw), nullInt(runner.Age),
+				nullInt(runner.Lbs), nullInt(runner.OR), nullInt(runner.RPR), nullString(runner.Comment),
+				nullFloat64BSP(runner.WinBSP), nullFloat64(runner.WinPPWAP),
+				nullFloat64BSP(runner.PlaceBSP), nullFloat64(runner.PlacePPWAP),
+				nullInt64(int(runner.BetfairSelectionID)), nullFloat64(runner.BestOdds), nullString(runner.BestBookmaker),
+				nullString(runner.Time), nullFloat64(runner.Secs), nullFloat64BSP(runner.Dec),
+			).Scan(&runnerID)
+
+			if err != nil {
+				return 0, 0, fmt.Errorf("failed to insert runner %s: %w", runnerKey, err)
+			}
+
+			// Bookmaker shows (price history) and SP
+			if err := insertBookmakerPrices(ctx, tx, runnerID, raceID, race.Date, runner); err != nil {
+				return 0, 0, fmt.Errorf("runner %s: %w", runnerKey, err)
+			}
+
+			// Sectional splits (results only, where published)
+			for _, sec := range runner.Sectionals {
+				if sec.Secs <= 0 {
+					continue
+				}
+				_, err := tx.ExecContext(ctx, `
+					INSERT INTO racing.runner_sectionals (
+						runner_id, race_date, section_seq, section_label, dist_y, secs
+					) VALUES ($1, $2, $3, $4, $5, $6)
+					ON CONFLICT (runner_id, race_date, section_seq) DO UPDATE SET
+						section_label = EXCLUDED.section_label,
+						dist_y = EXCLUDED.dist_y,
+						secs = EXCLUDED.secs
+				`, runnerID, race.Date, sec.Seq, nullString(sec.Label), nullInt(sec.DistanceY), sec.Secs)
+				if err != nil {
+					return 0, 0, fmt.Errorf("failed to insert sectional %d for runner %s: %w", sec.Seq, runnerKey, err)
+				}
+			}
+
+			// Store runner_id back in the race data for Betfair matching
+			race.Runners[j].RunnerID = int(runnerID)
+			runnerCount++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	// Results also change profiles and calibration
+	cache.InvalidateRaces(ctx, raceIDs, dates, !prelim)
+
+	return raceCount, runnerCount, nil
+}
+
+// ResolveDimensions resolves every course, horse, trainer, jockey and owner in
+// races to its ID (creating people and horses as needed) and fills the IDs in.
+// Courses resolve through the registry: unknown names go to review, not new rows.
+func ResolveDimensions(tx *sql.Tx, races []scraper.Race) error {
+	var horseRefs []HorseRef
+	trainerSet := make(map[string]struct{})
+	jockeySet := make(map[string]struct{})
+	ownerSet := make(map[string]struct{})
+
+	for _, race := range races {
+		for _, runner := range race.Runners {
+			if strings.TrimSpace(runner.Horse) != "" {
+				horseRefs = append(horseRefs, HorseRefFor(race, runner))
+			}
+			if v := strings.TrimSpace(runner.Trainer); v != "" {
+				trainerSet[v] = struct{}{}
+			}
+			if v := strings.TrimSpace(runner.Jockey); v != "" {
+				jockeySet[v] = struct{}{}
+			}
+			if v := strings.TrimSpace(runner.Owner); v != "" {
+				ownerSet[v] = struct{}{}
+			}
+		}
+	}
+
+	toSlice := func(m map[string]struct{}) []string {
+		out := make([]string, 0, len(m))
+		for k := range m {
+			out = append(out, k)
+		}
+		return out
+	}
+
+	courseIDs, err := ResolveCourseIDs(tx, matching.CourseSourceSportingLife, CourseRefsFor(races))
+	if err != nil {
+		return err
+	}
+
+	// Horses go through identity resolution (same name ≠ same horse)
+	horseIDs, err := ResolveHorseIDs(tx, horseRefs)
+	if err != nil {
+		return err
+	}
+
+	trainerIDs, err := UpsertNamesAndFetchIDs(tx, "trainers", "trainer_id", "trainer_name", "trainers_uniq", toSlice(trainerSet))
+	if err != nil {
+		return err
+	}
+
+	jockeyIDs, err := UpsertNamesAndFetchIDs(tx, "jockeys", "jockey_id", "jockey_name", "jockeys_uniq", toSlice(jockeySet))
+	if err != nil {
+		return err
+	}
+
+	ownerIDs, err := UpsertNamesAndFetchIDs(tx, "owners", "owner_id", "owner_name", "owners_uniq", toSlice(ownerSet))
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[Pipeline]      ✓ Resolved %d courses, %d horses, %d trainers, %d jockeys, %d owners",
+		len(courseIDs), len(horseIDs), len(trainerIDs), len(jockeyIDs), len(ownerIDs))
+
+	for i := range races {
+		// Region-qualified first - the same name can exist in two jurisdictions
+		if id, ok := courseIDs[CourseKey(races[i].Region, races[i].Course)]; ok {
+			races[i].CourseID = int(id)
+		} else if id, ok := courseIDs[strings.TrimSpace(races[i].Course)]; ok {
+			races[i].CourseID = int(id)
+		}
+
+		for j := range races[i].Runners {
+			runner := &races[i].Runners[j]
+
+			if id, ok := horseIDs[HorseRefFor(races[i], *runner).Key()]; ok {
+				runner.HorseID = int(id)
+			}
+			if id, ok := trainerIDs[strings.TrimSpace(runner.Trainer)]; ok {
+				runner.TrainerID = int(id)
+			}
+			if id, ok := jockeyIDs[strings.TrimSpace(runner.Jockey)]; ok {
+				runner.JockeyID = int(id)
+			}
+			if id, ok := ownerIDs[strings.TrimSpace(runner.Owner)]; ok {
+				runner.OwnerID = int(id)
+			}
+		}
+	}
+
+	return nil
+}
+
+// localOffTime returns the race's off time in the course's own timezone
+// (races from the cache or Betfair-only paths don't carry it yet)
+func localOffTime(race scraper.Race) string {
+	if race.LocalOffTime != "" {
+		return race.LocalOffTime
+	}
+	return scraper.LocalOffTime(race.Date, race.OffTime, race.Region, race.Course)
+}
+
+func nullString(s string) interface{} {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func nullInt(i int) interface{} {
+	if i == 0 {
+		return nil
+	}
+	return i
+}
+
+func nullInt64(i int) interface{} {
+	if i == 0 {
+		return nil
+	}
+	return int64(i)
+}
+
+func nullFloat64(f float64) interface{} {
+	if f == 0.0 {
+		return nil
+	}
+	return f
+}
+
+func nullFloat64BSP(f float64) interface{} {
+	if f < 1.01 {
+		return nil
+	}
+	return f
+}
@@ -0,0 +1,498 @@
+// Package pipeline is the single ingestion path for race data. Every loader
+// (the API auto-updater and scheduler, admin scrape endpoints, fetch_all and
+// backfill_dates) runs the same stages:
+//
+//	fetch → cache → stitch → validate → load → refresh
+//
+// Each run records its progress on a racing.data_updates row and leaves the
+// races each stage produced in DATA_DIR/pipeline/<kind>/<date>/, so a run that
+// fails or crashes part-way is resumed from the stage that failed.
+//
+// Runs are also recorded in racing.etl_runs, completed results days in
+// racing.ingested_days, and every loader holds the date's advisory lock
+// (DateLockKey) while it writes, so concurrent loaders skip or wait instead of
+// loading the same date twice.
+package pipeline
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"giddyup/api/internal/matching"
+	"giddyup/api/internal/quality"
+	"giddyup/api/internal/scraper"
+)
+
+// Options controls one pipeline run for a single date
+type Options struct {
+	Date         string // YYYY-MM-DD
+	Kind         string // KindDaily (default), KindRacecard (default when Prelim) or KindBackfill
+	Prelim       bool   // Racecards: no Betfair stitch, races load with prelim=true
+	Refetch      bool   // Bypass the Sporting Life cache (intraday refreshes)
+	DeferRefresh bool   // Skip the refresh stage; the caller runs RefreshViews after a batch of dates
+	Fresh        bool   // Start from fetch even if the date's last run was interrupted
+	Wait         bool   // Wait for another loader's lock on the date instead of returning ErrLocked
+}
+
+// Result summarises a pipeline run
+type Result struct {
+	UpdateID        int64           `json:"update_id"`
+	RunID           int64           `json:"run_id"` // racing.etl_runs
+	Date            string          `json:"date"`
+	Kind            string          `json:"kind"`
+	ResumedFrom     int64           `json:"resumed_from,omitempty"` // Interrupted update this run picked up
+	StartStage      Stage           `json:"start_stage"`
+	RacesScraped    int             `json:"races_scraped"`
+	RunnersScraped  int             `json:"runners_scraped"`
+	BetfairRaces    int             `json:"betfair_races"`
+	RacesMatched    int             `json:"races_matched"`    // Races with Betfair prices after the stitch
+	RacesRejected   int             `json:"races_rejected"`   // Races quarantined
+	RunnersRejected int             `json:"runners_rejected"` // Runners quarantined
+	Warnings        int             `json:"warnings"`         // Data-quality warnings (loaded)
+	RacesLoaded     int             `json:"races_loaded"`
+	RunnersLoaded   int             `json:"runners_loaded"`
+	Issues          []quality.Issue `json:"issues,omitempty"`
+}
+
+// Pipeline runs ingestion against one database and data directory
+type Pipeline struct {
+	db      *sql.DB
+	dataDir string
+
+	auditRetentionDays int // Match audit kept by RefreshViews (MATCH_AUDIT_RETENTION_DAYS)
+}
+
+// New creates a pipeline; dataDir holds the Betfair CSVs and run artifacts
+func New(db *sql.DB, dataDir string) *Pipeline {
+	return &Pipeline{
+		db:      db,
+		dataDir: dataDir,
+
+		auditRetentionDays: matching.AuditRetentionDaysFromEnv(),
+	}
+}
+
+// Run ingests one date. If the date's last run of the same kind was
+// interrupted, it resumes from the stage that failed using that run's
+// artifacts (unless opts.Fresh). If another loader holds the date's lock it
+// returns ErrLocked, or waits for it with opts.Wait.
+func (p *Pipeline) Run(ctx context.Context, opts Options) (*Result, error) {
+	opts = withDefaults(opts)
+	if _, err := time.Parse("2006-01-02", opts.Date); err != nil {
+		return nil, fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", opts.Date)
+	}
+
+	var lock *Lock
+	var err error
+	if opts.Wait {
+		lock, err = WaitLock(ctx, p.db, DateLockKey(opts.Date))
+	} else {
+		lock, err = TryLock(ctx, p.db, DateLockKey(opts.Date))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Release()
+
+	var prev *Update
+	if !opts.Fresh {
+		prev, err = lastInterrupted(ctx, p.db, opts.Kind, opts.Date)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return p.run(ctx, opts, prev)
+}
+
+// Resume re-runs every interrupted update from the stage it stopped at,
+// oldest date first, and returns the results of the runs that completed.
+// Views aren't refreshed per date: call RefreshViews once afterwards.
+func (p *Pipeline) Resume(ctx context.Context) ([]*Result, error) {
+	updates, err := Resumable(ctx, p.db)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*Result
+	for i := range updates {
+		res, err := p.resume(ctx, &updates[i])
+		if errors.Is(err, ErrLocked) {
+			log.Printf("[Pipeline] ⏭️  Skipping %s %s - %v", updates[i].Kind, updates[i].Date, err)
+			continue
+		}
+		if err != nil {
+			u := &updates[i]
+			log.Printf("[Pipeline] ❌ Resume of %s %s failed: %v", u.Kind, u.Date, err)
+			if ctx.Err() != nil {
+				return results, ctx.Err()
+			}
+			continue
+		}
+		if res != nil {
+			results = append(results, res)
+		}
+	}
+	return results, nil
+}
+
+// resume re-runs one interrupted update under its date's lock (nil result if
+// another loader finished it in the meantime)
+func (p *Pipeline) resume(ctx context.Context, u *Update) (*Result, error) {
+	lock, err := TryLock(ctx, p.db, DateLockKey(u.Date))
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Release()
+
+	// Re-read under the lock: the listing may be stale
+	prev, err := lastInterrupted(ctx, p.db, u.Kind, u.Date)
+	if err != nil || prev == nil {
+		return nil, err
+	}
+
+	log.Printf("[Pipeline] 🔁 Resuming %s %s (update %d) from %s: %s",
+		prev.Kind, prev.Date, prev.UpdateID, prev.Stage, prev.Error)
+	return p.run(ctx, Options{Date: prev.Date, Kind: prev.Kind, Prelim: prev.Prelim, DeferRefresh: true}, prev)
+}
+
+// Ingest validates and loads races the caller has already fetched (intraday
+// card and result refreshes). Nothing is recorded in racing.data_updates:
+// these refreshes run every few minutes and a failure is retried by the next one.
+// It waits for the lock on each date the races cover.
+func (p *Pipeline) Ingest(ctx context.Context, races []scraper.Race, prelim bool) (int, int, error) {
+	dates := make(map[string]bool)
+	for _, race := range races {
+		dates[race.Date] = true
+	}
+	keys := make([]string, 0, len(dates))
+	for date := range dates {
+		keys = append(keys, DateLockKey(date))
+	}
+	sort.Strings(keys) // Same order everywhere, so two multi-date ingests can't deadlock
+
+	for _, key := range keys {
+		lock, err := WaitLock(ctx, p.db, key)
+		if err != nil {
+			return 0, 0, err
+		}
+		defer lock.Release()
+	}
+
+	valid, report := Validate(races)
+	quality.Log("[Pipeline]   ", report)
+	if err := quality.Record(ctx, p.db, quality.Source{Loader: "intraday"}, report); err != nil {
+		log.Printf("[Pipeline] ⚠️  Failed to record data-quality issues: %v", err)
+	}
+	return Load(ctx, p.db, valid, prelim)
+}
+
+func (p *Pipeline) run(ctx context.Context, opts Options, prev *Update) (*Result, error) {
+	res := &Result{Date: opts.Date, Kind: opts.Kind, StartStage: StageFetch}
+	dir := p.artifactDir(opts)
+
+	var races []scraper.Race
+	if prev != nil {
+		res.ResumedFrom = prev.UpdateID
+		res.RacesScraped, res.RunnersScraped = prev.RacesScraped, prev.RunnersScraped
+		res.RacesMatched, res.RacesRejected = prev.RacesMatched, prev.RacesRejected
+		if prev.ArtifactDir != "" {
+			dir = prev.ArtifactDir
+		}
+		res.StartStage, races = resumePoint(prev, dir)
+	}
+
+	r, err := startRun(ctx, p.db, opts, dir)
+	if err != nil {
+		return nil, err
+	}
+	res.UpdateID = r.id
+	if prev != nil {
+		if err := r.supersede(ctx, prev); err != nil {
+			return nil, err
+		}
+	}
+
+	etl, err := StartETLRun(ctx, p.db, Scope{
+		Kind:     opts.Kind,
+		Source:   "sportinglife",
+		Dates:    []string{opts.Date},
+		Regions:  regionCodes(),
+		UpdateID: r.id,
+	})
+	if err != nil {
+		r.fail(res.StartStage, err)
+		return res, err
+	}
+	res.RunID = etl.ID
+
+	err = p.runStages(ctx, r, opts, dir, races, res)
+	etl.Finish(statsFor(res), err)
+	return res, err
+}
+
+func (p *Pipeline) runStages(ctx context.Context, r *run, opts Options, dir string,
+	races []scraper.Race, res *Result) error {
+	var err error
+
+	log.Printf("[Pipeline] ▶️  %s %s (update %d) from %s", opts.Kind, opts.Date, r.id, res.StartStage)
+
+	for _, stage := range Stages[res.StartStage.index():] {
+		if err := r.begin(ctx, stage); err != nil {
+			r.fail(stage, err)
+			return err
+		}
+
+		races, err = p.runStage(ctx, stage, opts, dir, races, res)
+		if err == nil && ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		if err != nil {
+			r.fail(stage, err)
+			return fmt.Errorf("%s stage failed for %s: %w", stage, opts.Date, err)
+		}
+
+		if err := r.complete(ctx, stage, res); err != nil {
+			r.fail(stage, err)
+			return err
+		}
+	}
+
+	return r.finish(ctx)
+}
+
+func (p *Pipeline) runStage(ctx context.Context, stage Stage, opts Options, dir string,
+	races []scraper.Race, res *Result) ([]scraper.Race, error) {
+
+	switch stage {
+	case StageFetch:
+		slScraper := scraper.NewSportingLifeAPIV2(p.dataDir)
+		var err error
+		if opts.Refetch {
+			races, err = slScraper.FetchRacesForDate(opts.Date)
+		} else {
+			races, err = slScraper.GetRacesForDate(opts.Date)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Sporting Life fetch failed: %w", err)
+		}
+		res.RacesScraped, res.RunnersScraped = len(races), countRunners(races)
+		log.Printf("[Pipeline]   ✓ [fetch] %d %s races, %d runners from Sporting Life",
+			res.RacesScraped, scraper.JurisdictionCodes(), res.RunnersScraped)
+
+	case StageCache:
+		if err := writeArtifact(dir, StageCache, races); err != nil {
+			return nil, err
+		}
+
+	case StageStitch:
+		if !opts.Prelim {
+			bfStitcher := scraper.NewBetfairStitcher(p.dataDir)
+			bfRaces, bfCounts := bfStitcher.StitchJurisdictionsForDate(opts.Date)
+			res.BetfairRaces = len(bfRaces)
+			log.Printf("[Pipeline]   ✓ [stitch] %d Betfair races %v", len(bfRaces), bfCounts)
+
+			races = scraper.MatchAndMerge(races, bfRaces)
+			res.RacesMatched = countPriced(races)
+			log.Printf("[Pipeline]   ✓ [stitch] %d/%d races have Betfair prices", res.RacesMatched, len(races))
+		}
+		if err := writeArtifact(dir, StageStitch, races); err != nil {
+			return nil, err
+		}
+
+	case StageValidate:
+		valid, report := Validate(races)
+		res.Issues = report.Issues
+		res.RacesRejected, res.RunnersRejected = report.HeldRaces(), report.HeldRunners()
+		res.Warnings = report.Count(quality.SeverityWarning)
+		quality.Log("[Pipeline]   ", report)
+		if err := quality.Record(ctx, p.db, quality.Source{Loader: opts.Kind, RunID: res.RunID}, report); err != nil {
+			return nil, err
+		}
+		if len(valid) == 0 && len(races) > 0 {
+			return nil, fmt.Errorf("no valid races (%d rejected)", res.RacesRejected)
+		}
+		races = valid
+		if err := writeArtifact(dir, StageValidate, races); err != nil {
+			return nil, err
+		}
+
+	case StageLoad:
+		raceCount, runnerCount, err := Load(ctx, p.db, races, opts.Prelim)
+		if err != nil {
+			return nil, err
+		}
+		res.RacesLoaded, res.RunnersLoaded = raceCount, runnerCount
+		log.Printf("[Pipeline]   ✓ [load] %d races, %d runners (prelim=%v)", raceCount, runnerCount, opts.Prelim)
+
+		// Cards aren't complete days - only results count as ingested
+		if !opts.Prelim {
+			if err := MarkIngested(ctx, p.db, res.RunID, DaysFor(races)); err != nil {
+				return nil, err
+			}
+		}
+
+	case StageRefresh:
+		if opts.DeferRefresh {
+			log.Printf("[Pipeline]   ⏭️  [refresh] deferred to end of batch")
+			break
+		}
+		if err := p.RefreshViews(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return races, nil
+}
+
+// materializedViews are refreshed after loads, in dependency order
+var materializedViews = []string{"mv_runner_base", "mv_draw_bias_flat", "mv_last_next"}
+
+// RefreshViews refreshes the materialized views that exist in this database
+// (concurrently where the view has the unique index that requires), then
+// drops match audit rows past their retention
+func (p *Pipeline) RefreshViews(ctx context.Context) error {
+	for _, view := range materializedViews {
+		var exists bool
+		err := p.db.QueryRowContext(ctx, `
+			SELECT EXISTS(SELECT 1 FROM pg_matviews WHERE schemaname = 'racing' AND matviewname = $1)
+		`, view).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("failed to check %s: %w", view, err)
+		}
+		if !exists {
+			continue
+		}
+
+		start := time.Now()
+		if _, err := p.db.ExecContext(ctx, `REFRESH MATERIALIZED VIEW CONCURRENTLY racing.`+view); err != nil {
+			if _, err := p.db.ExecContext(ctx, `REFRESH MATERIALIZED VIEW racing.`+view); err != nil {
+				return fmt.Errorf("failed to refresh %s: %w", view, err)
+			}
+		}
+		log.Printf("[Pipeline]   ✓ [refresh] %s (%v)", view, time.Since(start).Round(time.Millisecond))
+	}
+
+	// Housekeeping, so a failure doesn't fail the refresh
+	if n, err := matching.NewDBStore(p.db).PurgeAudit(p.auditRetentionDays); err != nil {
+		log.Printf("[Pipeline]   ⚠️  [refresh] %v", err)
+	} else if n > 0 {
+		log.Printf("[Pipeline]   ✓ [refresh] purged %d match audit row(s) older than %d days", n, p.auditRetentionDays)
+	}
+	return nil
+}
+
+// regionCodes lists the enabled jurisdictions as ingested_days regions
+func regionCodes() []string {
+	var codes []string
+	for _, j := range scraper.Jurisdictions() {
+		codes = append(codes, strings.ToLower(j.Code))
+	}
+	return codes
+}
+
+func withDefaults(opts Options) Options {
+	if opts.Kind == "" {
+		opts.Kind = KindDaily
+		if opts.Prelim {
+			opts.Kind = KindRacecard
+		}
+	}
+	return opts
+}
+
+func (p *Pipeline) artifactDir(opts Options) string {
+	return filepath.Join(p.dataDir, "pipeline", opts.Kind, opts.Date)
+}
+
+// resumePoint returns the first stage prev didn't complete and the races that
+// stage needs (read back from the previous stage's artifact). If the artifact
+// is missing the run starts again from fetch.
+func resumePoint(prev *Update, dir string) (Stage, []scraper.Race) {
+	start := StageRefresh
+	for _, stage := range Stages {
+		if !prev.done(stage) {
+			start = stage
+			break
+		}
+	}
+
+	i := start.index()
+	if start == StageFetch || start == StageRefresh {
+		return start, nil
+	}
+
+	input := Stages[i-1]
+	if input.artifact() == "" {
+		return StageFetch, nil
+	}
+	races, err := readArtifact(dir, input)
+	if err != nil {
+		log.Printf("[Pipeline] ⚠️  Can't resume %s %s from %s (%v) - starting from fetch", prev.Kind, prev.Date, start, err)
+		return StageFetch, nil
+	}
+	return start, races
+}
+
+func writeArtifact(dir string, stage Stage, races []scraper.Race) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	data, err := json.Marshal(races)
+	if err != nil {
+		return fmt.Errorf("failed to marshal races: %w", err)
+	}
+
+	// Write then rename so a crash never leaves a truncated artifact
+	filename := filepath.Join(dir, stage.artifact())
+	if err := os.WriteFile(filename+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filename, err)
+	}
+	if err := os.Rename(filename+".tmp", filename); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filename, err)
+	}
+	return nil
+}
+
+func readArtifact(dir string, stage Stage) ([]scraper.Race, error) {
+	data, err := os.ReadFile(filepath.Join(dir, stage.artifact()))
+	if err != nil {
+		return nil, err
+	}
+	var races []scraper.Race
+	if err := json.Unmarshal(data, &races); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s artifact: %w", stage, err)
+	}
+	return races, nil
+}
+
+func countRunners(races []scraper.Race) int {
+	n := 0
+	for _, race := range races {
+		n += len(race.Runners)
+	}
+	return n
+}
+
+// countPriced counts races where at least one runner picked up a Betfair price
+func countPriced(races []scraper.Race) int {
+	n := 0
+	for _, race := range races {
+		for _, runner := range race.Runners {
+			if runner.WinBSP > 0 || runner.BetfairSelectionID > 0 {
+				n++
+				break
+			}
+		}
+	}
+	return n
+}
@@ -0,0 +1,339 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"giddyup/api/internal/cache"
+	"giddyup/api/internal/quality"
+	"giddyup/api/internal/scraper"
+)
+
+// DefaultReconcileDays is how many settled days reconciliation re-checks
+const DefaultReconcileDays = 3
+
+// Amendment kinds (racing.runner_amendments.kind)
+const (
+	AmendDisqualification = "disqualification"
+	AmendPlacing          = "placing"
+	AmendSP               = "sp"
+	AmendBSP              = "bsp"
+)
+
+// Amendment is one changed field on a settled runner
+type Amendment struct {
+	RaceKey   string  `json:"race_key"`
+	RunnerKey string  `json:"runner_key"`
+	Horse     string  `json:"horse"`
+	Field     string  `json:"field"` // pos_raw, dec, win_bsp, place_bsp
+	OldValue  *string `json:"old_value"`
+	NewValue  string  `json:"new_value"`
+	Kind      string  `json:"kind"`
+	Source    string  `json:"source"` // sportinglife or betfair
+
+	runnerID int64
+	raceID   int64
+	frac     string // Fractional SP of an SP amendment (racing.bookmaker_prices)
+}
+
+// ReconcileResult summarises a reconciliation of one date
+type ReconcileResult struct {
+	Date           string      `json:"date"`
+	RunID          int64       `json:"run_id"` // racing.etl_runs
+	RacesChecked   int         `json:"races_checked"`
+	RunnersChecked int         `json:"runners_checked"`
+	RacesAmended   int         `json:"races_amended"`
+	Amendments     []Amendment `json:"amendments,omitempty"`
+}
+
+// storedRunner is a settled runner's current values
+type storedRunner struct {
+	runnerID int64
+	raceID   int64
+	posRaw   *string
+	dec      *float64
+	winBSP   *float64
+	placeBSP *float64
+}
+
+// Reconcile re-fetches a settled date's results (Sporting Life, fresh) and
+// Betfair prices, diffs them against the stored runners and applies what
+// changed - disqualifications, amended placings, corrected SPs and BSPs - with
+// its history in racing.runner_amendments. Only races already loaded as
+// results (prelim=false) are touched, and a value that disappeared upstream is
+// never cleared. It returns ErrLocked if another loader has the date.
+func (p *Pipeline) Reconcile(ctx context.Context, date string) (*ReconcileResult, error) {
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return nil, fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", date)
+	}
+
+	lock, err := TryLock(ctx, p.db, DateLockKey(date))
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Release()
+
+	etl, err := StartETLRun(ctx, p.db, Scope{
+		Kind:    KindReconcile,
+		Source:  "sportinglife",
+		Dates:   []string{date},
+		Regions: regionCodes(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ReconcileResult{Date: date, RunID: etl.ID}
+	err = p.reconcile(ctx, date, res)
+	etl.Finish(map[string]int{
+		"races_checked":   res.RacesChecked,
+		"runners_checked": res.RunnersChecked,
+		"races_amended":   res.RacesAmended,
+		"amendments":      len(res.Amendments),
+	}, err)
+	return res, err
+}
+
+func (p *Pipeline) reconcile(ctx context.Context, date string, res *ReconcileResult) error {
+	log.Printf("[Pipeline] ▶️  %s %s", KindReconcile, date)
+
+	races, err := scraper.NewSportingLifeAPIV2(p.dataDir).FetchRacesForDate(date)
+	if err != nil {
+		return fmt.Errorf("Sporting Life fetch failed: %w", err)
+	}
+	bfRaces, _ := scraper.NewBetfairStitcher(p.dataDir).StitchJurisdictionsForDate(date)
+	races = scraper.MatchAndMerge(races, bfRaces)
+
+	valid, report := Validate(races)
+	if err := quality.Record(ctx, p.db, quality.Source{Loader: KindReconcile, RunID: res.RunID}, report); err != nil {
+		return err
+	}
+
+	stored, err := p.storedResults(ctx, date)
+	if err != nil {
+		return err
+	}
+
+	for _, race := range valid {
+		if !race.HasResult() {
+			continue
+		}
+		raceKey := race.Key()
+		checked := false
+		for _, runner := range race.Runners {
+			runnerKey := runner.Key(raceKey)
+			s, ok := stored[runnerKey]
+			if !ok {
+				continue
+			}
+			checked = true
+			res.RunnersChecked++
+			for _, a := range diffRunner(s, runner) {
+				a.RaceKey, a.RunnerKey, a.Horse = raceKey, runnerKey, runner.Horse
+				res.Amendments = append(res.Amendments, a)
+			}
+		}
+		if checked {
+			res.RacesChecked++
+		}
+	}
+
+	if len(res.Amendments) == 0 {
+		log.Printf("[Pipeline]   ✓ [reconcile] %s: %d races, %d runners unchanged", date, res.RacesChecked, res.RunnersChecked)
+		return nil
+	}
+
+	res.RacesAmended, err = p.applyAmendments(ctx, date, res.RunID, res.Amendments)
+	if err != nil {
+		return err
+	}
+	for _, a := range res.Amendments {
+		log.Printf("[Pipeline]   ✏️  [reconcile] %s (%s) %s: %s → %s (%s)",
+			a.RaceKey, a.Horse, a.Field, valueOrNone(a.OldValue), a.NewValue, a.Kind)
+	}
+	log.Printf("[Pipeline]   ✓ [reconcile] %s: %d amendment(s) to %d race(s)", date, len(res.Amendments), res.RacesAmended)
+	return nil
+}
+
+// storedResults returns the date's settled runners by runner key
+func (p *Pipeline) storedResults(ctx context.Context, date string) (map[string]storedRunner, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT ru.runner_key, ru.runner_id, ru.race_id, ru.pos_raw, ru.dec, ru.win_bsp, ru.place_bsp
+		FROM racing.runners ru
+		JOIN racing.races ra ON ra.race_id = ru.race_id AND ra.race_date = ru.race_date
+		WHERE ru.race_date = $1 AND ra.prelim = false
+	`, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stored results: %w", err)
+	}
+	defer rows.Close()
+
+	stored := make(map[string]storedRunner)
+	for rows.Next() {
+		var key string
+		var s storedRunner
+		if err := rows.Scan(&key, &s.runnerID, &s.raceID, &s.posRaw, &s.dec, &s.winBSP, &s.placeBSP); err != nil {
+			return nil, err
+		}
+		stored[key] = s
+	}
+	return stored, rows.Err()
+}
+
+// diffRunner lists the fields where a fresh result differs from the stored one.
+// Blank or out-of-range fresh values are ignored rather than clearing data.
+func diffRunner(s storedRunner, fresh scraper.Runner) []Amendment {
+	var out []Amendment
+	amend := func(field, kind, source string, old *string, new string) {
+		out = append(out, Amendment{
+			Field: field, OldValue: old, NewValue: new, Kind: kind, Source: source,
+			runnerID: s.runnerID, raceID: s.raceID,
+		})
+	}
+
+	if pos := strings.TrimSpace(fresh.Pos); pos != "" && (s.posRaw == nil || *s.posRaw != pos) {
+		kind := AmendPlacing
+		switch strings.ToUpper(pos) {
+		case "DSQ", "DQ", "DISQ":
+			kind = AmendDisqualification
+		}
+		amend("pos_raw", kind, "sportinglife", s.posRaw, pos)
+	}
+
+	prices := []struct {
+		field, kind, source string
+		old                 *float64
+		new                 float64
+	}{
+		{"dec", AmendSP, "sportinglife", s.dec, fresh.Dec},
+		{"win_bsp", AmendBSP, "betfair", s.winBSP, fresh.WinBSP},
+		{"place_bsp", AmendBSP, "betfair", s.placeBSP, fresh.PlaceBSP},
+	}
+	for _, p := range prices {
+		if p.new < 1.01 {
+			continue // Not a price (racing.runners stores these as NULL)
+		}
+		if p.old != nil && math.Abs(*p.old-p.new) < 0.005 {
+			continue
+		}
+		var old *string
+		if p.old != nil {
+			v := formatPrice(*p.old)
+			old = &v
+		}
+		amend(p.field, p.kind, p.source, old, formatPrice(p.new))
+		if p.kind == AmendSP {
+			out[len(out)-1].frac = fresh.SP
+		}
+	}
+
+	return out
+}
+
+// applyAmendments records and applies amendments in one transaction and
+// returns the number of races amended
+func (p *Pipeline) applyAmendments(ctx context.Context, date string, runID int64, amendments []Amendment) (int, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	races := make(map[int64]bool)
+	for _, a := range amendments {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO racing.runner_amendments (
+				runner_id, race_id, race_date, runner_key, field, old_value, new_value, kind, source, run_id
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		`, a.runnerID, a.raceID, date, a.RunnerKey, a.Field, a.OldValue, a.NewValue, a.Kind, a.Source, runID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to record amendment for %s: %w", a.RunnerKey, err)
+		}
+
+		// Field names come from diffRunner, never from input
+		var value interface{} = a.NewValue
+		if a.Field != "pos_raw" {
+			value, _ = strconv.ParseFloat(a.NewValue, 64)
+		}
+		_, err = tx.ExecContext(ctx, fmt.Sprintf(`
+			UPDATE racing.runners SET %s = $1 WHERE runner_id = $2 AND race_date = $3
+		`, a.Field), value, a.runnerID, date)
+		if err != nil {
+			return 0, fmt.Errorf("failed to amend %s for %s: %w", a.Field, a.RunnerKey, err)
+		}
+		if a.Kind == AmendSP {
+			if err := upsertSP(ctx, tx, a.runnerID, a.raceID, date, value.(float64), a.frac); err != nil {
+				return 0, fmt.Errorf("failed to amend SP for %s: %w", a.RunnerKey, err)
+			}
+		}
+		races[a.raceID] = true
+	}
+
+	for raceID := range races {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE racing.races SET amended_at = now() WHERE race_id = $1 AND race_date = $2
+		`, raceID, date); err != nil {
+			return 0, fmt.Errorf("failed to mark race %d amended: %w", raceID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit amendments: %w", err)
+	}
+
+	raceIDs := make([]int64, 0, len(races))
+	for raceID := range races {
+		raceIDs = append(raceIDs, raceID)
+	}
+	cache.InvalidateRaces(ctx, raceIDs, []string{date}, true)
+	return len(races), nil
+}
+
+// ReconcileRecent reconciles the days settled in the last `days` days up to
+// yesterday, skipping dates another loader holds. Views are refreshed once
+// at the end if anything changed.
+func (p *Pipeline) ReconcileRecent(ctx context.Context, days int) ([]*ReconcileResult, error) {
+	if days <= 0 {
+		days = DefaultReconcileDays
+	}
+
+	var results []*ReconcileResult
+	amended := 0
+	now := time.Now().In(scraper.UK())
+	for i := days; i >= 1; i-- {
+		date := now.AddDate(0, 0, -i).Format("2006-01-02")
+		res, err := p.Reconcile(ctx, date)
+		if err != nil {
+			if ctx.Err() != nil {
+				return results, ctx.Err()
+			}
+			log.Printf("[Pipeline] ⚠️  Reconcile %s skipped: %v", date, err)
+			continue
+		}
+		results = append(results, res)
+		amended += res.RacesAmended
+	}
+
+	if amended > 0 {
+		if err := p.RefreshViews(ctx); err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+func formatPrice(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func valueOrNone(s *string) string {
+	if s == nil {
+		return "(none)"
+	}
+	return *s
+}
@@ -0,0 +1,50 @@
+package pipeline
+
+// Stage is one step of the ingestion pipeline. Stages run in order and each
+// completed stage is recorded on the date's racing.data_updates row, so a run
+// that dies part-way resumes from the stage that failed.
+type Stage string
+
+const (
+	StageFetch    Stage = "fetch"    // Sporting Life cards/results for the date
+	StageCache    Stage = "cache"    // Persist the fetched races as a run artifact
+	StageStitch   Stage = "stitch"   // Betfair CSVs stitched and merged onto the races
+	StageValidate Stage = "validate" // Drop races/runners that can't be loaded
+	StageLoad     Stage = "load"     // Resolve dimensions and upsert races/runners
+	StageRefresh  Stage = "refresh"  // Refresh materialized views
+)
+
+// Stages lists every stage in run order
+var Stages = []Stage{StageFetch, StageCache, StageStitch, StageValidate, StageLoad, StageRefresh}
+
+// Update types recorded in racing.data_updates.update_type
+const (
+	KindDaily     = "daily"     // Results for a past date (auto-update, fetch_all, admin scrape)
+	KindRacecard  = "racecard"  // Preliminary cards for today/tomorrow
+	KindBackfill  = "backfill"  // Results loaded by cmd/backfill_dates
+	KindReconcile = "reconcile" // Settled results re-checked for amendments
+)
+
+// index returns the stage's position in Stages (-1 if unknown)
+func (s Stage) index() int {
+	for i, st := range Stages {
+		if st == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// artifact is the file a stage leaves behind for later stages
+// (empty for stages whose output lives in the database)
+func (s Stage) artifact() string {
+	switch s {
+	case StageCache:
+		return "fetched.json"
+	case StageStitch:
+		return "stitched.json"
+	case StageValidate:
+		return "validated.json"
+	}
+	return ""
+}
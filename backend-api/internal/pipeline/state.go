@@ -0,0 +1,208 @@
+package pipeline
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// Update is a racing.data_updates row a run can resume from
+type Update struct {
+	UpdateID       int64
+	Kind           string
+	Date           string
+	Status         string
+	Stage          Stage // Stage that failed (or was running when the process died)
+	StagesDone     []Stage
+	Prelim         bool
+	ArtifactDir    string
+	Error          string
+	RacesScraped   int
+	RunnersScraped int
+	RacesMatched   int
+	RacesRejected  int
+}
+
+// done reports whether the update completed stage
+func (u *Update) done(stage Stage) bool {
+	for _, s := range u.StagesDone {
+		if s == stage {
+			return true
+		}
+	}
+	return false
+}
+
+// run records one pipeline run's progress on its racing.data_updates row
+type run struct {
+	db *sql.DB
+	id int64
+}
+
+func startRun(ctx context.Context, db *sql.DB, opts Options, dir string) (*run, error) {
+	var id int64
+	err := db.QueryRowContext(ctx, `
+		INSERT INTO racing.data_updates (update_type, update_date, status, prelim, artifact_dir)
+		VALUES ($1, $2, 'running', $3, $4)
+		RETURNING update_id
+	`, opts.Kind, opts.Date, opts.Prelim, dir).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record update: %w", err)
+	}
+	return &run{db: db, id: id}, nil
+}
+
+// begin marks stage as the one in progress
+func (r *run) begin(ctx context.Context, stage Stage) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE racing.data_updates SET stage = $2 WHERE update_id = $1
+	`, r.id, string(stage))
+	if err != nil {
+		return fmt.Errorf("failed to record stage %s: %w", stage, err)
+	}
+	return nil
+}
+
+// complete records stage as done along with the run's counts so far
+func (r *run) complete(ctx context.Context, stage Stage, res *Result) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE racing.data_updates SET
+			stages_done = array_append(array_remove(stages_done, $2), $2),
+			racing_post_scraped = racing_post_scraped OR $2 = 'fetch',
+			betfair_fetched = betfair_fetched OR ($2 = 'stitch' AND $3 > 0),
+			data_stitched = data_stitched OR $2 = 'stitch',
+			data_loaded = data_loaded OR $2 = 'load',
+			views_refreshed = views_refreshed OR $2 = 'refresh',
+			races_scraped = $4,
+			runners_scraped = $5,
+			races_matched = $6,
+			races_rejected = $7,
+			races_loaded = $8,
+			runners_loaded = $9
+		WHERE update_id = $1
+	`, r.id, string(stage), res.BetfairRaces,
+		res.RacesScraped, res.RunnersScraped, res.RacesMatched, res.RacesRejected,
+		res.RacesLoaded, res.RunnersLoaded)
+	if err != nil {
+		return fmt.Errorf("failed to record stage %s: %w", stage, err)
+	}
+	return nil
+}
+
+// fail records the stage the run stopped at
+func (r *run) fail(stage Stage, cause error) {
+	// Not tied to the run's context: a cancelled run must still be resumable
+	_, _ = r.db.Exec(`
+		UPDATE racing.data_updates SET
+			status = 'failed',
+			failed_stage = $2,
+			error_message = $3,
+			completed_at = NOW()
+		WHERE update_id = $1
+	`, r.id, string(stage), cause.Error())
+}
+
+func (r *run) finish(ctx context.Context) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE racing.data_updates SET status = 'completed', completed_at = NOW()
+		WHERE update_id = $1
+	`, r.id)
+	if err != nil {
+		return fmt.Errorf("failed to record completion: %w", err)
+	}
+	return nil
+}
+
+// supersede marks an interrupted update as resumed by this run
+func (r *run) supersede(ctx context.Context, prev *Update) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE racing.data_updates SET
+			status = 'resumed',
+			resumed_by = $2,
+			completed_at = COALESCE(completed_at, NOW())
+		WHERE update_id = $1
+	`, prev.UpdateID, r.id)
+	if err != nil {
+		return fmt.Errorf("failed to mark update %d resumed: %w", prev.UpdateID, err)
+	}
+	return nil
+}
+
+const resumableSQL = `
+	SELECT u.update_id, u.update_type, u.update_date::text, u.status,
+		COALESCE(u.failed_stage, u.stage, 'fetch'), u.stages_done, u.prelim,
+		COALESCE(u.artifact_dir, ''), COALESCE(u.error_message, ''),
+		COALESCE(u.races_scraped, 0), COALESCE(u.runners_scraped, 0),
+		COALESCE(u.races_matched, 0), COALESCE(u.races_rejected, 0)
+	FROM racing.data_updates u
+	WHERE u.status IN ('failed', 'running')
+	  AND u.resumed_by IS NULL
+	  AND NOT EXISTS (
+		SELECT 1 FROM racing.data_updates later
+		WHERE later.update_type = u.update_type
+		  AND later.update_date = u.update_date
+		  AND later.update_id > u.update_id
+	  )
+`
+
+// Resumable lists runs that failed or are still marked running, and that no
+// later run for the same date has picked up, oldest date first. A 'running' row
+// is only a crashed run if its date lock is free: callers take the lock and
+// re-check with lastInterrupted before resuming.
+func Resumable(ctx context.Context, db *sql.DB) ([]Update, error) {
+	rows, err := db.QueryContext(ctx, resumableSQL+` ORDER BY u.update_date, u.update_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resumable updates: %w", err)
+	}
+	defer rows.Close()
+
+	var updates []Update
+	for rows.Next() {
+		u, err := scanUpdate(rows)
+		if err != nil {
+			return nil, err
+		}
+		updates = append(updates, *u)
+	}
+	return updates, rows.Err()
+}
+
+// lastInterrupted returns the interrupted run for kind/date, if any. Call it
+// holding the date's lock: any row still 'running' then belongs to a dead process.
+func lastInterrupted(ctx context.Context, db *sql.DB, kind, date string) (*Update, error) {
+	row := db.QueryRowContext(ctx, resumableSQL+` AND u.update_type = $1 AND u.update_date = $2`,
+		kind, date)
+	u, err := scanUpdate(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return u, err
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanUpdate(row rowScanner) (*Update, error) {
+	var u Update
+	var stage string
+	var done pq.StringArray
+	err := row.Scan(&u.UpdateID, &u.Kind, &u.Date, &u.Status,
+		&stage, &done, &u.Prelim,
+		&u.ArtifactDir, &u.Error,
+		&u.RacesScraped, &u.RunnersScraped,
+		&u.RacesMatched, &u.RacesRejected)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan update: %w", err)
+	}
+	u.Stage = Stage(stage)
+	for _, s := range done {
+		u.StagesDone = append(u.StagesDone, Stage(s))
+	}
+	return &u, nil
+}
@@ -0,0 +1,34 @@
+package pipeline
+
+import (
+	"giddyup/api/internal/quality"
+	"giddyup/api/internal/scraper"
+)
+
+// Validate checks races against the data-quality rules (see internal/quality)
+// and drops what breaks an error rule: races without a course or a sane off
+// time, duplicate race keys (the copy with more runners wins), runners without
+// a horse name and duplicate runner keys within a race. Warnings and info are
+// left in the report for recording; those races and runners are kept.
+func Validate(races []scraper.Race) ([]scraper.Race, *quality.Report) {
+	checked := quality.FromScraper(races)
+	report := quality.Check(checked)
+	keepRace, keepRunner := report.Keep(checked)
+
+	valid := make([]scraper.Race, 0, len(races))
+	for i, race := range races {
+		if !keepRace[i] {
+			continue
+		}
+		runners := make([]scraper.Runner, 0, len(race.Runners))
+		for j, runner := range race.Runners {
+			if keepRunner[i][j] {
+				runners = append(runners, runner)
+			}
+		}
+		race.Runners = runners
+		valid = append(valid, race)
+	}
+
+	return valid, report
+}
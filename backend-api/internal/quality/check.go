@@ -0,0 +1,308 @@
+package quality
+
+import (
+	"sort"
+	"strings"
+
+	"giddyup/api/internal/scraper"
+)
+
+// Race is what the rules see of a race. Loaders build it from their own rows
+// (scraper races, staged CSV rows) and map issues back by index.
+type Race struct {
+	Key     string
+	Date    string
+	Region  string
+	Course  string
+	OffTime string // Course-local where known (see FromScraper)
+	Ran     int
+	Ref     string      // Loader's own handle on the row (e.g. a staged row's ctid)
+	Row     interface{} // Original row, kept as the quarantine payload when held
+	Runners []Runner
+}
+
+// Runner is what the rules see of a runner
+type Runner struct {
+	Key      string
+	Num      int
+	Pos      string
+	Draw     int
+	Horse    string
+	WinBSP   float64
+	PlaceBSP float64
+	Dec      float64
+	Ref      string
+	Row      interface{}
+}
+
+// Issue is one broken rule
+type Issue struct {
+	Rule      string   `json:"rule"`
+	Severity  Severity `json:"severity"`
+	Scope     Scope    `json:"scope"`
+	RaceKey   string   `json:"race_key"`
+	RaceDate  string   `json:"race_date"`
+	Course    string   `json:"course,omitempty"`
+	OffTime   string   `json:"off_time,omitempty"`
+	RunnerKey string   `json:"runner_key,omitempty"`
+	Horse     string   `json:"horse,omitempty"`
+	Reason    string   `json:"reason"`
+
+	RaceIndex   int         `json:"-"` // Index of the race in the checked slice
+	RunnerIndex int         `json:"-"` // Index of the runner in the race (-1 for race issues)
+	Payload     interface{} `json:"-"` // Held race or runner row
+}
+
+// Held reports whether the issue keeps its race or runner out of the load
+func (i Issue) Held() bool {
+	return i.Severity == SeverityError
+}
+
+// RaceRef identifies a checked race
+type RaceRef struct {
+	Key  string
+	Date string
+}
+
+// Report is the outcome of checking a batch of races
+type Report struct {
+	Checked []RaceRef `json:"-"`
+	Issues  []Issue   `json:"issues"`
+}
+
+// HeldRaces counts races quarantined as a whole
+func (r *Report) HeldRaces() int {
+	n := 0
+	for _, issue := range r.Issues {
+		if issue.Held() && issue.Scope == ScopeRace {
+			n++
+		}
+	}
+	return n
+}
+
+// HeldRunners counts runners quarantined on their own
+func (r *Report) HeldRunners() int {
+	n := 0
+	for _, issue := range r.Issues {
+		if issue.Held() && issue.Scope == ScopeRunner {
+			n++
+		}
+	}
+	return n
+}
+
+// Count counts issues of a severity
+func (r *Report) Count(severity Severity) int {
+	n := 0
+	for _, issue := range r.Issues {
+		if issue.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// Keep reports which races and runners survive: keepRace[i] for races[i] and
+// keepRunner[i][j] for races[i].Runners[j]
+func (r *Report) Keep(races []Race) (keepRace []bool, keepRunner [][]bool) {
+	keepRace = make([]bool, len(races))
+	keepRunner = make([][]bool, len(races))
+	for i, race := range races {
+		keepRace[i] = true
+		keepRunner[i] = make([]bool, len(race.Runners))
+		for j := range race.Runners {
+			keepRunner[i][j] = true
+		}
+	}
+	for _, issue := range r.Issues {
+		if !issue.Held() {
+			continue
+		}
+		if issue.RunnerIndex < 0 {
+			keepRace[issue.RaceIndex] = false
+		} else {
+			keepRunner[issue.RaceIndex][issue.RunnerIndex] = false
+		}
+	}
+	return keepRace, keepRunner
+}
+
+// meeting is the context race rules get about the race's meeting
+type meeting struct {
+	races      int
+	middle     int  // Median off time, minutes after midnight
+	twelveHour bool // No off time after 12:59, so 1:00-9:59 are afternoon/evening
+}
+
+// clock converts an off time to minutes after midnight on the meeting's clock
+func (m meeting) clock(off string) (int, bool) {
+	mins, ok := minutes(off)
+	if ok && m.twelveHour && mins >= 60 && mins < 600 {
+		mins += 12 * 60
+	}
+	return mins, ok
+}
+
+// Check runs every rule over races
+func Check(races []Race) *Report {
+	report := &Report{Checked: make([]RaceRef, 0, len(races))}
+	meetings := meetingsFor(races)
+
+	// Duplicate race keys: keep the copy with most runners
+	keeper := make(map[RaceRef]int)
+	for i, race := range races {
+		ref := RaceRef{Key: race.Key, Date: race.Date}
+		if k, ok := keeper[ref]; !ok || len(race.Runners) > len(races[k].Runners) {
+			keeper[ref] = i
+		}
+	}
+
+	for i, race := range races {
+		ref := RaceRef{Key: race.Key, Date: race.Date}
+		if keeper[ref] == i {
+			report.Checked = append(report.Checked, ref)
+		}
+
+		raceIssue := func(rule Rule, reason string) {
+			report.Issues = append(report.Issues, Issue{
+				Rule: rule.Name, Severity: rule.Severity, Scope: ScopeRace,
+				RaceKey: race.Key, RaceDate: race.Date, Course: race.Course, OffTime: race.OffTime,
+				Reason: reason, RaceIndex: i, RunnerIndex: -1,
+				Payload: race.Row,
+			})
+		}
+		runnerIssue := func(rule Rule, j int, reason string) {
+			runner := race.Runners[j]
+			report.Issues = append(report.Issues, Issue{
+				Rule: rule.Name, Severity: rule.Severity, Scope: ScopeRunner,
+				RaceKey: race.Key, RaceDate: race.Date, Course: race.Course, OffTime: race.OffTime,
+				RunnerKey: runner.Key, Horse: runner.Horse,
+				Reason: reason, RaceIndex: i, RunnerIndex: j,
+				Payload: runner.Row,
+			})
+		}
+
+		if keeper[ref] != i {
+			raceIssue(ruleByName(RuleDuplicateRace), "duplicate race")
+			continue
+		}
+
+		hasResult := false
+		for _, runner := range race.Runners {
+			if runner.Pos != "" {
+				hasResult = true
+				break
+			}
+		}
+
+		held := false
+		seen := make(map[string]bool, len(race.Runners))
+		for _, rule := range Rules {
+			if rule.ResultsOnly && !hasResult {
+				continue
+			}
+			switch {
+			case rule.race != nil:
+				if reason := rule.race(race, meetings[meetingKey(race)]); reason != "" {
+					raceIssue(rule, reason)
+					held = held || rule.Severity == SeverityError
+				}
+			case rule.runner != nil:
+				for j, runner := range race.Runners {
+					if reason := rule.runner(race, runner); reason != "" {
+						runnerIssue(rule, j, reason)
+					}
+				}
+			case rule.Name == RuleDuplicateRunner:
+				for j, runner := range race.Runners {
+					if seen[runner.Key] {
+						runnerIssue(rule, j, "duplicate runner")
+					}
+					seen[runner.Key] = true
+				}
+			}
+			if held {
+				break // Don't pile further issues on a quarantined race
+			}
+		}
+	}
+
+	return report
+}
+
+func meetingKey(race Race) string {
+	return race.Date + "|" + strings.ToLower(race.Region) + "|" + strings.ToLower(strings.TrimSpace(race.Course))
+}
+
+// meetingsFor works out each meeting's size and median off time. Some sources
+// (the master set) write off times on a 12-hour clock.
+func meetingsFor(races []Race) map[string]meeting {
+	offs := make(map[string][]int)
+	for _, race := range races {
+		if off, ok := minutes(race.OffTime); ok {
+			key := meetingKey(race)
+			offs[key] = append(offs[key], off)
+		}
+	}
+	meetings := make(map[string]meeting, len(offs))
+	for key, times := range offs {
+		m := meeting{races: len(times), twelveHour: true}
+		for _, t := range times {
+			if t >= 13*60 {
+				m.twelveHour = false
+			}
+		}
+		clock := make([]int, len(times))
+		for i, t := range times {
+			clock[i], _ = m.clock(hhmm(t))
+		}
+		sort.Ints(clock)
+		m.middle = clock[len(clock)/2]
+		meetings[key] = m
+	}
+	return meetings
+}
+
+// FromScraper converts scraper races for checking (same order and indexes).
+// Off times are checked in course-local time so meeting windows hold abroad.
+func FromScraper(races []scraper.Race) []Race {
+	out := make([]Race, len(races))
+	for i, race := range races {
+		key := race.Key()
+		off := race.LocalOffTime
+		if off == "" {
+			off = scraper.LocalOffTime(race.Date, race.OffTime, race.Region, race.Course)
+		}
+		if off == "" {
+			off = race.OffTime
+		}
+
+		runners := make([]Runner, len(race.Runners))
+		for j, runner := range race.Runners {
+			runners[j] = Runner{
+				Key:      runner.Key(key),
+				Num:      runner.Num,
+				Pos:      runner.Pos,
+				Draw:     runner.Draw,
+				Horse:    runner.Horse,
+				WinBSP:   runner.WinBSP,
+				PlaceBSP: runner.PlaceBSP,
+				Dec:      runner.Dec,
+				Row:      runner,
+			}
+		}
+
+		out[i] = Race{
+			Key:     key,
+			Date:    race.Date,
+			Region:  race.Region,
+			Course:  race.Course,
+			OffTime: off,
+			Ran:     race.Ran,
+			Row:     race,
+			Runners: runners,
+		}
+	}
+	return out
+}
@@ -0,0 +1,189 @@
+package quality
+
+import (
+	"slices"
+	"testing"
+)
+
+// meetingRaces builds one meeting's races at the given off times
+func meetingRaces(course string, offs ...string) []Race {
+	races := make([]Race, len(offs))
+	for i, off := range offs {
+		races[i] = Race{Key: course + off, Date: "2025-10-18", Region: "GB", Course: course, OffTime: off}
+	}
+	return races
+}
+
+func TestMeetingsFor(t *testing.T) {
+	cases := []struct {
+		name           string
+		offs           []string
+		wantTwelveHour bool
+		wantMiddle     string
+		clock          map[string]string // Off time → time on the meeting's clock
+	}{
+		{
+			name: "24-hour clock", offs: []string{"13:30", "14:05", "14:40", "15:15"},
+			wantMiddle: "14:40",
+			clock:      map[string]string{"13:30": "13:30", "2:05": "02:05"},
+		},
+		{
+			name: "12-hour clock", offs: []string{"1:30", "2:05", "2:40", "3:15"},
+			wantTwelveHour: true, wantMiddle: "14:40",
+			clock: map[string]string{"1:30": "13:30", "3:15": "15:15"},
+		},
+		{
+			name: "12-hour clock from noon", offs: []string{"12:20", "12:55", "1:30", "2:05"},
+			wantTwelveHour: true, wantMiddle: "13:30",
+			clock: map[string]string{"12:20": "12:20", "1:30": "13:30"},
+		},
+		{
+			name: "morning meeting on a 12-hour clock", offs: []string{"11:05", "11:40", "12:15", "12:50"},
+			wantTwelveHour: true, wantMiddle: "12:15",
+			clock: map[string]string{"11:05": "11:05", "10:00": "10:00"},
+		},
+		{
+			name: "evening meeting", offs: []string{"17:45", "18:15", "18:45"},
+			wantMiddle: "18:15",
+			clock:      map[string]string{"6:15": "06:15"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			races := meetingRaces("Ascot", tc.offs...)
+			m, ok := meetingsFor(races)[meetingKey(races[0])]
+			if !ok {
+				t.Fatal("meeting not found")
+			}
+			if m.races != len(tc.offs) {
+				t.Errorf("races = %d, want %d", m.races, len(tc.offs))
+			}
+			if m.twelveHour != tc.wantTwelveHour {
+				t.Errorf("twelveHour = %v, want %v", m.twelveHour, tc.wantTwelveHour)
+			}
+			if got := hhmm(m.middle); got != tc.wantMiddle {
+				t.Errorf("middle = %s, want %s", got, tc.wantMiddle)
+			}
+			for off, want := range tc.clock {
+				if mins, _ := m.clock(off); hhmm(mins) != want {
+					t.Errorf("clock(%s) = %s, want %s", off, hhmm(mins), want)
+				}
+			}
+		})
+	}
+}
+
+func TestOffTimeWindow(t *testing.T) {
+	cases := []struct {
+		name string
+		offs []string
+		held []string // Off times quarantined by the rule
+	}{
+		{"meeting within the window", []string{"13:30", "14:05", "14:40", "15:15", "18:20"}, nil},
+		{"race far from the middle", []string{"13:30", "14:05", "14:40", "21:30"}, []string{"21:30"}},
+		{"12-hour meeting", []string{"12:50", "1:25", "2:00", "2:35", "3:10"}, nil},
+		{"two races are not a meeting to judge", []string{"13:00", "22:00"}, nil},
+		{"one race", []string{"03:00"}, nil},
+		{"unparseable off time in a small meeting", []string{"13:00", "25:00"}, []string{"25:00"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			report := Check(meetingRaces("Ascot", tc.offs...))
+			var held []string
+			for _, issue := range report.Issues {
+				if issue.Rule == RuleOffTimeWindow {
+					held = append(held, issue.OffTime)
+				}
+			}
+			if !slices.Equal(held, tc.held) {
+				t.Errorf("held %v, want %v", held, tc.held)
+			}
+		})
+	}
+}
+
+func TestCheckDuplicates(t *testing.T) {
+	runners := func(keys ...string) []Runner {
+		out := make([]Runner, len(keys))
+		for i, key := range keys {
+			out[i] = Runner{Key: key, Num: i + 1, Horse: "Horse " + key}
+		}
+		return out
+	}
+	race := func(key, date string, r []Runner) Race {
+		return Race{Key: key, Date: date, Region: "GB", Course: "Ascot", OffTime: "14:05", Runners: r}
+	}
+
+	cases := []struct {
+		name        string
+		races       []Race
+		wantHeld    []int // Race indexes held as duplicates
+		wantChecked int
+	}{
+		{
+			name:        "copy with most runners kept",
+			races:       []Race{race("r1", "2025-10-18", runners("a", "b")), race("r1", "2025-10-18", runners("a", "b", "c"))},
+			wantHeld:    []int{0},
+			wantChecked: 1,
+		},
+		{
+			name:        "first copy kept on a tie",
+			races:       []Race{race("r1", "2025-10-18", runners("a", "b")), race("r1", "2025-10-18", runners("c", "d"))},
+			wantHeld:    []int{1},
+			wantChecked: 1,
+		},
+		{
+			name: "three copies",
+			races: []Race{race("r1", "2025-10-18", runners("a")), race("r1", "2025-10-18", runners("a", "b", "c")),
+				race("r1", "2025-10-18", runners("a", "b"))},
+			wantHeld:    []int{0, 2},
+			wantChecked: 1,
+		},
+		{
+			name:        "same key on another date",
+			races:       []Race{race("r1", "2025-10-18", runners("a")), race("r1", "2025-10-19", runners("a"))},
+			wantChecked: 2,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			report := Check(tc.races)
+			var held []int
+			for _, issue := range report.Issues {
+				if issue.Rule == RuleDuplicateRace {
+					held = append(held, issue.RaceIndex)
+				}
+			}
+			if !slices.Equal(held, tc.wantHeld) {
+				t.Errorf("held races %v, want %v", held, tc.wantHeld)
+			}
+			if len(report.Checked) != tc.wantChecked {
+				t.Errorf("checked %d races, want %d", len(report.Checked), tc.wantChecked)
+			}
+			keepRace, _ := report.Keep(tc.races)
+			for _, i := range tc.wantHeld {
+				if keepRace[i] {
+					t.Errorf("race %d kept", i)
+				}
+			}
+		})
+	}
+
+	t.Run("duplicate runner", func(t *testing.T) {
+		races := []Race{race("r1", "2025-10-18", runners("a", "b", "a", "a"))}
+		report := Check(races)
+		var held []int
+		for _, issue := range report.Issues {
+			if issue.Rule == RuleDuplicateRunner {
+				held = append(held, issue.RunnerIndex)
+			}
+		}
+		if want := []int{2, 3}; !slices.Equal(held, want) {
+			t.Errorf("held runners %v, want %v", held, want)
+		}
+		_, keepRunner := report.Keep(races)
+		if want := []bool{true, true, false, false}; !slices.Equal(keepRunner[0], want) {
+			t.Errorf("kept runners %v, want %v", keepRunner[0], want)
+		}
+	})
+}
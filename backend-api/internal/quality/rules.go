@@ -0,0 +1,298 @@
+// Package quality checks races and runners against declarative data-quality
+// rules before they are loaded. Every rule has a severity: rows breaking an
+// error rule are quarantined (held back from the load and recorded with the
+// reason in racing.quarantine), while warnings and info are loaded and recorded
+// so they show up in the per-date quality summary.
+package quality
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Severity of a rule
+type Severity string
+
+const (
+	SeverityInfo    Severity = "info"    // Worth knowing (e.g. no Betfair prices); loaded
+	SeverityWarning Severity = "warning" // Suspicious but usable; loaded
+	SeverityError   Severity = "error"   // Unusable; quarantined, not loaded
+)
+
+// Scope is what a rule checks
+type Scope string
+
+const (
+	ScopeRace   Scope = "race"
+	ScopeRunner Scope = "runner"
+)
+
+// Rule is one declarative check. Exactly one of race/runner is set, except for
+// the duplicate rules which the engine applies across races.
+type Rule struct {
+	Name        string   `json:"name"`
+	Severity    Severity `json:"severity"`
+	Scope       Scope    `json:"scope"`
+	ResultsOnly bool     `json:"results_only"` // Skipped for cards (no positions yet)
+	Description string   `json:"description"`
+
+	race   func(race Race, m meeting) string // Reason if broken, "" if fine
+	runner func(race Race, runner Runner) string
+}
+
+// Rule names
+const (
+	RuleMissingCourse   = "missing_course"
+	RuleMissingOffTime  = "missing_off_time"
+	RuleOffTimeWindow   = "off_time_window"
+	RuleDuplicateRace   = "duplicate_race"
+	RuleMissingHorse    = "missing_horse"
+	RuleDuplicateRunner = "duplicate_runner"
+	RuleRanMatches      = "ran_matches_runners"
+	RuleSingleWinner    = "single_winner"
+	RuleMonotonicPos    = "monotonic_positions"
+	RuleDrawInField     = "draw_within_field"
+	RuleBSPRange        = "bsp_range"
+	RuleBetfairPriced   = "betfair_priced"
+)
+
+// meetingWindow is how far a race's off time may be from the middle of its
+// meeting before the time is treated as wrong (bad timezone, AM/PM mix-up)
+const meetingWindow = 5 * time.Hour
+
+// minBSP is the lowest price Betfair can return (and racing.runners accepts)
+const minBSP = 1.01
+
+// Rules are checked in this order; race errors stop the race's other checks
+var Rules = []Rule{
+	{
+		Name: RuleMissingCourse, Severity: SeverityError, Scope: ScopeRace,
+		Description: "Race has a course",
+		race: func(race Race, _ meeting) string {
+			if strings.TrimSpace(race.Course) == "" {
+				return "missing course"
+			}
+			return ""
+		},
+	},
+	{
+		Name: RuleMissingOffTime, Severity: SeverityError, Scope: ScopeRace,
+		Description: "Race has an off time",
+		race: func(race Race, _ meeting) string {
+			if strings.TrimSpace(race.OffTime) == "" {
+				return "missing off time"
+			}
+			return ""
+		},
+	},
+	{
+		Name: RuleOffTimeWindow, Severity: SeverityError, Scope: ScopeRace,
+		Description: "Off time is within 5 hours of the middle of its meeting (meetings of 3+ races)",
+		race: func(race Race, m meeting) string {
+			off, ok := m.clock(race.OffTime)
+			if !ok {
+				return fmt.Sprintf("unparseable off time %q", race.OffTime)
+			}
+			if m.races < 3 {
+				return ""
+			}
+			if d := time.Duration(abs(off-m.middle)) * time.Minute; d > meetingWindow {
+				return fmt.Sprintf("off time %s is %s from the meeting's middle race (%s)",
+					race.OffTime, d, hhmm(m.middle))
+			}
+			return ""
+		},
+	},
+	{
+		Name: RuleDuplicateRace, Severity: SeverityError, Scope: ScopeRace,
+		Description: "Race key appears once per date (the copy with most runners is kept)",
+	},
+	{
+		Name: RuleMissingHorse, Severity: SeverityError, Scope: ScopeRunner,
+		Description: "Runner has a horse name",
+		runner: func(_ Race, runner Runner) string {
+			if strings.TrimSpace(runner.Horse) == "" {
+				return fmt.Sprintf("runner %d has no horse name", runner.Num)
+			}
+			return ""
+		},
+	},
+	{
+		Name: RuleDuplicateRunner, Severity: SeverityError, Scope: ScopeRunner,
+		Description: "Runner key appears once per race",
+	},
+	{
+		Name: RuleRanMatches, Severity: SeverityWarning, Scope: ScopeRace, ResultsOnly: true,
+		Description: "Declared ran matches the runners that started",
+		race: func(race Race, _ meeting) string {
+			if race.Ran <= 0 {
+				return ""
+			}
+			if n := starters(race); n != race.Ran {
+				return fmt.Sprintf("ran is %d but %d runners started", race.Ran, n)
+			}
+			return ""
+		},
+	},
+	{
+		Name: RuleSingleWinner, Severity: SeverityWarning, Scope: ScopeRace, ResultsOnly: true,
+		Description: "Exactly one winner (dead heats are flagged for review)",
+		race: func(race Race, _ meeting) string {
+			winners, placed := 0, 0
+			for _, runner := range race.Runners {
+				if p, ok := position(runner.Pos); ok {
+					placed++
+					if p == 1 {
+						winners++
+					}
+				}
+			}
+			switch {
+			case placed > 0 && winners == 0:
+				return "no winner"
+			case winners > 1:
+				return fmt.Sprintf("%d winners (dead heat?)", winners)
+			}
+			return ""
+		},
+	},
+	{
+		Name: RuleMonotonicPos, Severity: SeverityWarning, Scope: ScopeRace, ResultsOnly: true,
+		Description: "Finishing positions run 1, 2, 3... without gaps (ties skip, e.g. 1, 2, 2, 4)",
+		race: func(race Race, _ meeting) string {
+			var positions []int
+			for _, runner := range race.Runners {
+				if p, ok := position(runner.Pos); ok {
+					positions = append(positions, p)
+				}
+			}
+			sort.Ints(positions)
+			for i, p := range positions {
+				if i > 0 && p == positions[i-1] {
+					continue // Dead heat
+				}
+				if p != i+1 {
+					return fmt.Sprintf("positions %s are not consecutive", joinInts(positions))
+				}
+			}
+			return ""
+		},
+	},
+	{
+		Name: RuleDrawInField, Severity: SeverityWarning, Scope: ScopeRunner,
+		Description: "Stall draw is no higher than the declared field",
+		runner: func(race Race, runner Runner) string {
+			if runner.Draw > len(race.Runners) && runner.Draw > race.Ran {
+				return fmt.Sprintf("draw %d in a field of %d", runner.Draw, len(race.Runners))
+			}
+			return ""
+		},
+	},
+	{
+		Name: RuleBSPRange, Severity: SeverityWarning, Scope: ScopeRunner,
+		Description: "BSP and decimal SP are at least 1.01 (lower values are stored as NULL)",
+		runner: func(_ Race, runner Runner) string {
+			for _, p := range []struct {
+				name  string
+				price float64
+			}{{"win BSP", runner.WinBSP}, {"place BSP", runner.PlaceBSP}, {"SP", runner.Dec}} {
+				if p.price > 0 && p.price < minBSP {
+					return fmt.Sprintf("%s %.3f below %.2f", p.name, p.price, minBSP)
+				}
+			}
+			return ""
+		},
+	},
+	{
+		Name: RuleBetfairPriced, Severity: SeverityInfo, Scope: ScopeRace, ResultsOnly: true,
+		Description: "Result has Betfair SP for at least one runner",
+		race: func(race Race, _ meeting) string {
+			for _, runner := range race.Runners {
+				if runner.WinBSP >= minBSP {
+					return ""
+				}
+			}
+			return "no Betfair prices matched"
+		},
+	},
+}
+
+// ruleByName looks up a rule in Rules
+func ruleByName(name string) Rule {
+	for _, rule := range Rules {
+		if rule.Name == name {
+			return rule
+		}
+	}
+	panic("quality: unknown rule " + name)
+}
+
+// nonStarters are positions for runners that didn't start
+var nonStarters = map[string]bool{
+	"NR": true, "N/R": true, "WD": true, "W/D": true, "RES": true, "RESERVE": true,
+}
+
+// starters counts runners that came under starter's orders
+func starters(race Race) int {
+	n := 0
+	for _, runner := range race.Runners {
+		pos := strings.ToUpper(strings.TrimSpace(runner.Pos))
+		if pos != "" && !nonStarters[pos] {
+			n++
+		}
+	}
+	return n
+}
+
+// position parses a numeric finishing position ("1", "2=", "DH1" style ties)
+func position(pos string) (int, bool) {
+	pos = strings.TrimSuffix(strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(pos)), "DH"), "=")
+	p, err := strconv.Atoi(pos)
+	if err != nil || p <= 0 {
+		return 0, false
+	}
+	return p, true
+}
+
+// minutes parses HH:MM (or H:MM, or HH:MM:SS as off_time::text gives it)
+// into minutes after midnight; seconds are dropped
+func minutes(off string) (int, bool) {
+	h, m, ok := strings.Cut(strings.TrimSpace(off), ":")
+	if !ok {
+		return 0, false
+	}
+	if mm, ss, hasSecs := strings.Cut(m, ":"); hasSecs {
+		if s, err := strconv.Atoi(ss); err != nil || s < 0 || s > 59 {
+			return 0, false
+		}
+		m = mm
+	}
+	hh, err1 := strconv.Atoi(h)
+	mm, err2 := strconv.Atoi(m)
+	if err1 != nil || err2 != nil || hh < 0 || hh > 23 || mm < 0 || mm > 59 {
+		return 0, false
+	}
+	return hh*60 + mm, true
+}
+
+func hhmm(mins int) string {
+	return fmt.Sprintf("%02d:%02d", mins/60, mins%60)
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+func joinInts(xs []int) string {
+	s := make([]string, len(xs))
+	for i, x := range xs {
+		s[i] = strconv.Itoa(x)
+	}
+	return strings.Join(s, ", ")
+}
@@ -0,0 +1,86 @@
+package quality
+
+import "testing"
+
+func TestPosition(t *testing.T) {
+	cases := []struct {
+		pos    string
+		want   int
+		wantOK bool
+	}{
+		{"1", 1, true},
+		{" 3 ", 3, true},
+		{"2=", 2, true},
+		{"DH1", 1, true},
+		{"dh2", 2, true},
+		{"PU", 0, false},
+		{"NR", 0, false},
+		{"0", 0, false},
+		{"", 0, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.pos, func(t *testing.T) {
+			got, ok := position(tc.pos)
+			if got != tc.want || ok != tc.wantOK {
+				t.Errorf("position(%q) = %d, %v, want %d, %v", tc.pos, got, ok, tc.want, tc.wantOK)
+			}
+		})
+	}
+}
+
+func TestMonotonicPositions(t *testing.T) {
+	rule := ruleByName(RuleMonotonicPos)
+	cases := []struct {
+		name      string
+		positions []string
+		broken    bool
+	}{
+		{"in order", []string{"1", "2", "3"}, false},
+		{"unordered runners", []string{"3", "1", "2"}, false},
+		{"dead heat for first with =", []string{"1=", "1=", "3"}, false},
+		{"dead heat for first with DH", []string{"DH1", "DH1", "3", "4"}, false},
+		{"dead heat for second skips third", []string{"1", "2=", "2=", "4"}, false},
+		{"dead heat not skipping", []string{"1", "DH2", "DH2", "3"}, true},
+		{"non-finishers ignored", []string{"1", "PU", "2", "F", "NR"}, false},
+		{"gap", []string{"1", "3"}, true},
+		{"no winner", []string{"2", "3"}, true},
+		{"no positions", []string{"PU", "UR"}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			race := Race{}
+			for _, pos := range tc.positions {
+				race.Runners = append(race.Runners, Runner{Pos: pos})
+			}
+			reason := rule.race(race, meeting{})
+			if (reason != "") != tc.broken {
+				t.Errorf("positions %v: reason %q, want broken=%v", tc.positions, reason, tc.broken)
+			}
+		})
+	}
+}
+
+func TestMinutes(t *testing.T) {
+	cases := []struct {
+		off    string
+		want   int
+		wantOK bool
+	}{
+		{"14:05", 845, true},
+		{"2:05", 125, true},
+		{"14:05:00", 845, true},
+		{"14:05:61", 0, false},
+		{"24:00", 0, false},
+		{"14:60", 0, false},
+		{"1405", 0, false},
+		{"", 0, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.off, func(t *testing.T) {
+			got, ok := minutes(tc.off)
+			if got != tc.want || ok != tc.wantOK {
+				t.Errorf("minutes(%q) = %d, %v, want %d, %v", tc.off, got, ok, tc.want, tc.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package quality
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/lib/pq"
+)
+
+// Source is the loader recording issues (racing.quarantine.source and run_id)
+type Source struct {
+	Loader string // Pipeline kind (daily, backfill, racecard), intraday, master or copy
+	RunID  int64  // racing.etl_runs row (0 = none)
+}
+
+// Record stores a report in racing.quarantine in its own transaction
+func Record(ctx context.Context, db *sql.DB, src Source, report *Report) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := RecordTx(ctx, tx, src, report); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit quarantine: %w", err)
+	}
+	return nil
+}
+
+// RecordTx stores a report in racing.quarantine. Open issues for the checked
+// races that this check didn't raise again are marked resolved; issues raised
+// again are re-opened and counted rather than duplicated.
+func RecordTx(ctx context.Context, tx *sql.Tx, src Source, report *Report) error {
+	if len(report.Checked) > 0 {
+		keys := make([]string, len(report.Checked))
+		dates := make([]string, len(report.Checked))
+		for i, ref := range report.Checked {
+			keys[i], dates[i] = ref.Key, ref.Date
+		}
+		_, err := tx.ExecContext(ctx, `
+			UPDATE racing.quarantine q SET resolved_at = now()
+			FROM unnest($1::text[], $2::date[]) AS c(race_key, race_date)
+			WHERE q.race_key = c.race_key AND q.race_date = c.race_date
+			  AND q.resolved_at IS NULL
+		`, pq.Array(keys), pq.Array(dates))
+		if err != nil {
+			return fmt.Errorf("failed to resolve quarantine: %w", err)
+		}
+	}
+
+	if len(report.Issues) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO racing.quarantine (
+			rule, severity, scope, race_key, race_date, course, off_time,
+			runner_key, horse, reason, held, payload, source, run_id
+		) VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8, NULLIF($9, ''), $10, $11, $12, $13, $14)
+		ON CONFLICT (rule, race_date, race_key, runner_key) DO UPDATE SET
+			severity = EXCLUDED.severity,
+			reason = EXCLUDED.reason,
+			held = EXCLUDED.held,
+			payload = EXCLUDED.payload,
+			source = EXCLUDED.source,
+			run_id = EXCLUDED.run_id,
+			last_seen_at = now(),
+			times_seen = racing.quarantine.times_seen + 1,
+			resolved_at = NULL
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare quarantine insert: %w", err)
+	}
+	defer stmt.Close()
+
+	var runID interface{}
+	if src.RunID > 0 {
+		runID = src.RunID
+	}
+
+	for _, issue := range report.Issues {
+		// Only held rows keep their data: warnings are in racing.runners already
+		var payload interface{}
+		if issue.Held() && issue.Payload != nil {
+			data, err := json.Marshal(issue.Payload)
+			if err != nil {
+				return fmt.Errorf("failed to marshal quarantined row: %w", err)
+			}
+			payload = data
+		}
+
+		if _, err := stmt.ExecContext(ctx,
+			issue.Rule, issue.Severity, issue.Scope, issue.RaceKey, issue.RaceDate, issue.Course, issue.OffTime,
+			issue.RunnerKey, issue.Horse, issue.Reason, issue.Held(), payload, src.Loader, runID,
+		); err != nil {
+			return fmt.Errorf("failed to record %s for %s: %w", issue.Rule, issue.RaceKey, err)
+		}
+	}
+	return nil
+}
+
+// Log writes a report's held rows and a one-line summary with the given prefix
+// (e.g. "[Pipeline]   ")
+func Log(prefix string, report *Report) {
+	for _, issue := range report.Issues {
+		if !issue.Held() {
+			continue
+		}
+		if issue.Horse != "" {
+			log.Printf("%s⚠️  [quality] %s (%s): %s - quarantined", prefix, issue.RaceKey, issue.Horse, issue.Reason)
+		} else {
+			log.Printf("%s⚠️  [quality] %s: %s - quarantined", prefix, issue.RaceKey, issue.Reason)
+		}
+	}
+	if len(report.Issues) > 0 {
+		log.Printf("%s✓ [quality] %d race(s) and %d runner(s) quarantined, %d warning(s), %d info",
+			prefix, report.HeldRaces(), report.HeldRunners(), report.Count(SeverityWarning), report.Count(SeverityInfo))
+	}
+}
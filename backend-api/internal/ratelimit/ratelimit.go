@@ -0,0 +1,196 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Route groups share a quota
+const (
+	GroupRead     = "read"     // Everything not listed below
+	GroupAnalysis = "analysis" // Market, bias, analysis, angles, GraphQL
+	GroupHeavy    = "heavy"    // Multi-year scans (angle backtests, calibration, trainer profiles) and export streams
+	GroupAdmin    = "admin"    // /admin
+)
+
+// Route maps a path prefix to its group
+type Route struct {
+	Prefix string
+	Group  string
+}
+
+// Routes are matched longest prefix first (see GroupFor)
+var Routes = []Route{
+	{"/api/v1/admin", GroupAdmin},
+	{"/api/v1/angles/near-miss-no-hike/past", GroupHeavy},
+	{"/api/v1/market/calibration", GroupHeavy},
+	{"/api/v1/trainers/:id/profile", GroupHeavy},
+	{"/api/v1/market", GroupAnalysis},
+	{"/api/v1/horses/compare", GroupAnalysis},
+	{"/api/v1/races/:id/h2h", GroupAnalysis},
+	{"/api/v1/races/:id/card", GroupAnalysis},
+	{"/api/v1/bias", GroupAnalysis},
+	{"/api/v1/analysis", GroupAnalysis},
+	{"/api/v1/angles", GroupAnalysis},
+	{"/api/v1/graphql", GroupAnalysis},
+	{"/api/v1/export", GroupHeavy},
+	{"/api/v1/export/jobs", GroupAnalysis},
+	{"/api/v1/export/columns", GroupAnalysis},
+}
+
+// Quota allows Requests per Window
+type Quota struct {
+	Requests int
+	Window   time.Duration
+}
+
+func (q Quota) String() string {
+	return fmt.Sprintf("%d/%s", q.Requests, shortDuration(q.Window))
+}
+
+// DefaultQuotas apply per client (API key, JWT subject or IP) and group
+var DefaultQuotas = map[string]Quota{
+	GroupRead:     {Requests: 120, Window: time.Minute},
+	GroupAnalysis: {Requests: 60, Window: time.Minute},
+	GroupHeavy:    {Requests: 10, Window: time.Minute},
+	GroupAdmin:    {Requests: 60, Window: time.Minute},
+}
+
+// ParseQuotas reads "read=120/1m,heavy=10/1m" over the defaults
+func ParseQuotas(s string) (map[string]Quota, error) {
+	quotas := make(map[string]Quota, len(DefaultQuotas))
+	for group, q := range DefaultQuotas {
+		quotas[group] = q
+	}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		group, spec, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid quota %q (expected group=requests/window)", part)
+		}
+		if _, known := DefaultQuotas[group]; !known {
+			return nil, fmt.Errorf("unknown rate limit group %q", group)
+		}
+		reqs, window, ok := strings.Cut(spec, "/")
+		n, err := strconv.Atoi(reqs)
+		if !ok || err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid quota %q (expected e.g. 60/1m)", part)
+		}
+		d, err := time.ParseDuration(window)
+		if err != nil || d < time.Second {
+			return nil, fmt.Errorf("invalid quota window in %q", part)
+		}
+		quotas[group] = Quota{Requests: n, Window: d}
+	}
+	return quotas, nil
+}
+
+// Decision is the outcome of one request against a quota
+type Decision struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	Reset     time.Time // End of the current window
+}
+
+// Store counts hits per bucket in fixed windows
+type Store interface {
+	// Hit counts a request in the window starting at windowStart and returns
+	// the bucket's count in that window
+	Hit(ctx context.Context, bucket string, windowStart time.Time, window time.Duration) (int, error)
+}
+
+// Limiter applies quotas per client and route group
+type Limiter struct {
+	store    Store
+	fallback *MemoryStore // Used if the store fails (the limiter fails open to memory)
+	quotas   map[string]Quota
+}
+
+// NewLimiter creates a limiter over a store (nil = in-memory)
+func NewLimiter(store Store, quotas map[string]Quota) *Limiter {
+	fallback := NewMemoryStore()
+	if store == nil {
+		store = fallback
+	}
+	if quotas == nil {
+		quotas = DefaultQuotas
+	}
+	return &Limiter{store: store, fallback: fallback, quotas: quotas}
+}
+
+// GroupFor returns the group of a route path
+func GroupFor(path string) string {
+	best := Route{Group: GroupRead}
+	for _, r := range Routes {
+		if strings.HasPrefix(path, r.Prefix) && len(r.Prefix) > len(best.Prefix) {
+			best = r
+		}
+	}
+	return best.Group
+}
+
+// Quotas returns the configured quotas by group
+func (l *Limiter) Quotas() map[string]Quota {
+	return l.quotas
+}
+
+// Allow counts a request by client against its group's quota
+func (l *Limiter) Allow(ctx context.Context, client, group string, now time.Time) Decision {
+	q, ok := l.quotas[group]
+	if !ok {
+		q = l.quotas[GroupRead]
+	}
+
+	start := now.Truncate(q.Window)
+	bucket := group + "|" + client
+	count, err := l.store.Hit(ctx, bucket, start, q.Window)
+	if err != nil {
+		log.Printf("[RateLimit] ⚠️  Counter store failed, using memory: %v", err)
+		count, _ = l.fallback.Hit(ctx, bucket, start, q.Window)
+	}
+
+	remaining := q.Requests - count
+	if remaining < 0 {
+		remaining = 0
+	}
+	return Decision{
+		Allowed:   count <= q.Requests,
+		Limit:     q.Requests,
+		Remaining: remaining,
+		Reset:     start.Add(q.Window),
+	}
+}
+
+// Describe lists quotas as "group=requests/window" (for logs)
+func Describe(quotas map[string]Quota) string {
+	groups := make([]string, 0, len(quotas))
+	for group := range quotas {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	parts := make([]string, len(groups))
+	for i, group := range groups {
+		parts[i] = group + "=" + quotas[group].String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+func shortDuration(d time.Duration) string {
+	s := d.String() // "1m0s", "1h0m0s"
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
@@ -0,0 +1,119 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestGroupFor(t *testing.T) {
+	cases := []struct {
+		path string
+		want string
+	}{
+		{"/api/v1/races/:id", GroupRead},
+		{"/api/v1/races/:id/h2h", GroupAnalysis},
+		{"/api/v1/market/movers", GroupAnalysis},
+		{"/api/v1/market/calibration", GroupHeavy},
+		{"/api/v1/trainers/:id/profile", GroupHeavy},
+		{"/api/v1/trainers/:id", GroupRead},
+		{"/api/v1/export/races", GroupHeavy},
+		{"/api/v1/export/jobs/:id", GroupAnalysis},
+		{"/api/v1/admin/keys", GroupAdmin},
+		{"", GroupRead},
+	}
+	for _, tc := range cases {
+		t.Run(tc.path, func(t *testing.T) {
+			if got := GroupFor(tc.path); got != tc.want {
+				t.Errorf("GroupFor(%q) = %q, want %q", tc.path, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseQuotas(t *testing.T) {
+	cases := []struct {
+		name    string
+		in      string
+		want    map[string]Quota
+		wantErr bool
+	}{
+		{name: "empty keeps defaults", in: "", want: DefaultQuotas},
+		{name: "override", in: "read=300/1m, heavy=5/30s", want: map[string]Quota{
+			GroupRead:     {Requests: 300, Window: time.Minute},
+			GroupAnalysis: DefaultQuotas[GroupAnalysis],
+			GroupHeavy:    {Requests: 5, Window: 30 * time.Second},
+			GroupAdmin:    DefaultQuotas[GroupAdmin],
+		}},
+		{name: "unknown group", in: "bulk=10/1m", wantErr: true},
+		{name: "missing window", in: "read=10", wantErr: true},
+		{name: "zero requests", in: "read=0/1m", wantErr: true},
+		{name: "window under a second", in: "read=10/500ms", wantErr: true},
+		{name: "no group", in: "10/1m", wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseQuotas(tc.in)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("ParseQuotas(%q) = %v, want error", tc.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseQuotas(%q): %v", tc.in, err)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("ParseQuotas(%q) = %v, want %v", tc.in, got, tc.want)
+			}
+			for group, q := range tc.want {
+				if got[group] != q {
+					t.Errorf("%s = %v, want %v", group, got[group], q)
+				}
+			}
+		})
+	}
+}
+
+func TestLimiterAllow(t *testing.T) {
+	ctx := context.Background()
+	l := NewLimiter(nil, map[string]Quota{
+		GroupRead:  {Requests: 3, Window: time.Minute},
+		GroupHeavy: {Requests: 1, Window: time.Minute},
+	})
+	start := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	// Three allowed in the window, the fourth refused until it ends
+	for i, wantRemaining := range []int{2, 1, 0} {
+		d := l.Allow(ctx, "key:1", GroupRead, start.Add(time.Duration(i)*time.Second))
+		if !d.Allowed || d.Remaining != wantRemaining || d.Limit != 3 {
+			t.Fatalf("request %d = %+v, want allowed with %d remaining", i+1, d, wantRemaining)
+		}
+	}
+	d := l.Allow(ctx, "key:1", GroupRead, start.Add(59*time.Second))
+	if d.Allowed || d.Remaining != 0 {
+		t.Fatalf("request 4 = %+v, want refused", d)
+	}
+	if want := start.Add(time.Minute); !d.Reset.Equal(want) {
+		t.Errorf("reset = %v, want %v", d.Reset, want)
+	}
+
+	// Other clients and groups count separately
+	if d := l.Allow(ctx, "key:2", GroupRead, start); !d.Allowed {
+		t.Errorf("another client = %+v, want allowed", d)
+	}
+	if d := l.Allow(ctx, "key:1", GroupHeavy, start); !d.Allowed || d.Limit != 1 {
+		t.Errorf("another group = %+v, want allowed with limit 1", d)
+	}
+
+	// Groups without a quota share read's
+	if d := l.Allow(ctx, "key:1", GroupAdmin, start); !d.Allowed || d.Limit != 3 {
+		t.Errorf("admin without a quota = %+v, want read's limit", d)
+	}
+
+	// The next window starts afresh
+	d = l.Allow(ctx, "key:1", GroupRead, start.Add(time.Minute))
+	if !d.Allowed || d.Remaining != 2 {
+		t.Errorf("next window = %+v, want allowed with 2 remaining", d)
+	}
+}
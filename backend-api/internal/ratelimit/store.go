@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// MemoryStore counts hits in process memory (per API instance)
+type MemoryStore struct {
+	mu      sync.Mutex
+	counts  map[string]memoryCount
+	lastGC  time.Time
+	gcEvery time.Duration
+}
+
+type memoryCount struct {
+	start time.Time
+	end   time.Time
+	hits  int
+}
+
+// NewMemoryStore creates an in-memory counter store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counts: make(map[string]memoryCount), gcEvery: time.Minute}
+}
+
+// Hit counts a request in the bucket's current window
+func (m *MemoryStore) Hit(_ context.Context, bucket string, windowStart time.Time, window time.Duration) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Drop finished windows now and then so idle clients don't accumulate
+	if windowStart.Sub(m.lastGC) >= m.gcEvery {
+		for key, c := range m.counts {
+			if !c.end.After(windowStart) {
+				delete(m.counts, key)
+			}
+		}
+		m.lastGC = windowStart
+	}
+
+	c := m.counts[bucket]
+	if !c.start.Equal(windowStart) {
+		c = memoryCount{start: windowStart, end: windowStart.Add(window)}
+	}
+	c.hits++
+	m.counts[bucket] = c
+	return c.hits, nil
+}
+
+// PostgresStore counts hits in racing.rate_limit_counters so every API
+// instance shares one quota
+type PostgresStore struct {
+	db *sql.DB
+
+	mu        sync.Mutex
+	lastPrune time.Time
+}
+
+// NewPostgresStore creates a Postgres-backed counter store
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Hit counts a request in the bucket's current window
+func (p *PostgresStore) Hit(ctx context.Context, bucket string, windowStart time.Time, window time.Duration) (int, error) {
+	var hits int
+	err := p.db.QueryRowContext(ctx, `
+		INSERT INTO racing.rate_limit_counters (bucket, window_start, window_end, hits)
+		VALUES ($1, $2, $3, 1)
+		ON CONFLICT (bucket, window_start) DO UPDATE SET hits = racing.rate_limit_counters.hits + 1
+		RETURNING hits
+	`, bucket, windowStart, windowStart.Add(window)).Scan(&hits)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count request: %w", err)
+	}
+
+	p.mu.Lock()
+	prune := time.Since(p.lastPrune) >= time.Minute
+	if prune {
+		p.lastPrune = time.Now()
+	}
+	p.mu.Unlock()
+	if prune {
+		if _, err := p.db.ExecContext(ctx, `DELETE FROM racing.rate_limit_counters WHERE window_end < now()`); err != nil {
+			return hits, nil // Counted; pruning can wait for the next minute
+		}
+	}
+	return hits, nil
+}
@@ -41,13 +41,49 @@ func (r *AngleRepository) GetNearMissTodayQualifiers(params models.NearMissToday
 		targetDate = *params.On
 	}
 
-	query := `
+	args := []interface{}{targetDate}
+	argCount := 1
+
+	var query string
+	if params.Source == "entries" {
+		// Forward entries/declarations (racing.entries) over the next N days.
+		// These have no racing.races row yet; next_sl_race_id is the entry race.
+		if params.Days <= 0 {
+			params.Days = 5
+		}
+		argCount++
+		query = fmt.Sprintf(`
+		WITH entries AS (
+			SELECT
+				e.entry_id    AS runner_id,
+				e.horse_id,
+				e."or"        AS entry_or,
+				e.status      AS entry_status,
+				NULL::bigint  AS race_id,
+				er.sl_race_id,
+				er.race_date,
+				er.race_type,
+				er.dist_f,
+				er.surface,
+				er.going,
+				er.course_id
+			FROM racing.entries e
+			JOIN racing.entry_races er ON er.sl_race_id = e.sl_race_id
+			WHERE er.race_date >= $1
+				AND er.race_date < $1::date + $%d::int
+				AND e.status IN ('entered', 'declared')
+	`, argCount)
+		args = append(args, params.Days)
+	} else {
+		query = `
 		WITH entries AS (
 			SELECT
 				r.runner_id,
 				r.horse_id,
 				r."or"        AS entry_or,
+				NULL::text    AS entry_status,
 				ra.race_id,
+				NULL::bigint  AS sl_race_id,
 				ra.race_date,
 				ra.race_type,
 				ra.dist_f,
@@ -59,13 +95,11 @@ func (r *AngleRepository) GetNearMissTodayQualifiers(params models.NearMissToday
 			WHERE ra.race_date = $1
 				AND r.pos_raw IS NULL
 	`
-
-	args := []interface{}{targetDate}
-	argCount := 1
+	}
 
 	if params.RaceType != nil {
 		argCount++
-		query += fmt.Sprintf(" AND ra.race_type = $%d", argCount)
+		query += fmt.Sprintf(" AND race_type = $%d", argCount)
 		args = append(args, *params.RaceType)
 	}
 
@@ -100,6 +134,7 @@ func (r *AngleRepository) GetNearMissTodayQualifiers(params models.NearMissToday
 			h.horse_id,
 			h.horse_name,
 			e.race_id   AS next_race_id,
+			e.sl_race_id AS next_sl_race_id,
 			e.race_date AS next_date,
 			e.race_type AS next_race_type,
 			e.course_id AS next_course_id,
@@ -107,6 +142,7 @@ func (r *AngleRepository) GetNearMissTodayQualifiers(params models.NearMissToday
 			e.surface   AS next_surface,
 			e.going     AS next_going,
 			e.entry_or  AS next_or,
+			e.entry_status AS next_status,
 			l.last_race_id,
 			l.last_date,
 			l.last_pos,
@@ -123,10 +159,11 @@ func (r *AngleRepository) GetNearMissTodayQualifiers(params models.NearMissToday
 		FROM entries e
 		JOIN last_run l ON l.horse_id = e.horse_id
 		JOIN racing.horses h ON h.horse_id = e.horse_id
-		WHERE l.last_pos = $2
+		WHERE 1=1
 	`
 
 	argCount++
+	query += fmt.Sprintf(" AND l.last_pos = $%d", argCount)
 	args = append(args, params.LastPos)
 
 	argCount++
@@ -155,7 +192,7 @@ func (r *AngleRepository) GetNearMissTodayQualifiers(params models.NearMissToday
 		query += " AND e.surface = l.last_surface"
 	}
 
-	query += " ORDER BY e.race_date, e.race_id, h.horse_name"
+	query += " ORDER BY e.race_date, COALESCE(e.race_id, e.sl_race_id), h.horse_name"
 
 	argCount++
 	query += fmt.Sprintf(" LIMIT $%d", argCount)
@@ -175,7 +212,15 @@ func (r *AngleRepository) GetNearMissTodayQualifiers(params models.NearMissToday
 	return qualifiers, nil
 }
 
-// GetNearMissPastCases returns historical backtest cases for near-miss-no-hike angle
+// pastCaseCursor is the sort key of a backtest case
+type pastCaseCursor struct {
+	Date       string `json:"d"`
+	HorseID    int64  `json:"h"`
+	LastRaceID int64  `json:"r"`
+}
+
+// GetNearMissPastCases returns historical backtest cases for near-miss-no-hike
+// angle, latest first, one page at a time (meta.next_cursor continues it)
 func (r *AngleRepository) GetNearMissPastCases(params models.NearMissPastParams) (*models.NearMissPastResponse, error) {
 	// Set defaults
 	if params.LastPos == 0 {
@@ -197,6 +242,10 @@ func (r *AngleRepository) GetNearMissPastCases(params models.NearMissPastParams)
 		params.PriceSource = "bsp"
 	}
 
+	// The filters a cursor is bound to (paging and the summary toggle aside)
+	filters := params
+	filters.Limit, filters.Offset, filters.Cursor, filters.Summary = 0, 0, "", false
+
 	// Build the query
 	query := `
 		WITH base AS (
@@ -306,17 +355,29 @@ func (r *AngleRepository) GetNearMissPastCases(params models.NearMissPastParams)
 			p.price
 		FROM priced p
 		JOIN racing.horses h ON h.horse_id = p.horse_id
-		ORDER BY p.last_date DESC
 	`
 
 	argCount++
 	args = append(args, params.PriceSource)
 
+	if params.Cursor != "" {
+		var after pastCaseCursor
+		if err := decodeCursor(params.Cursor, "angle-past", filters, &after); err != nil {
+			return nil, err
+		}
+		query += fmt.Sprintf(" WHERE (p.last_date, p.horse_id, p.last_race_id) < ($%d::date, $%d, $%d)",
+			argCount+1, argCount+2, argCount+3)
+		args = append(args, after.Date, after.HorseID, after.LastRaceID)
+		argCount += 3
+	}
+
+	query += " ORDER BY p.last_date DESC, p.horse_id DESC, p.last_race_id DESC"
+
 	argCount++
 	query += fmt.Sprintf(" LIMIT $%d", argCount)
-	args = append(args, params.Limit)
+	args = append(args, params.Limit+1)
 
-	if params.Offset > 0 {
+	if params.Offset > 0 && params.Cursor == "" {
 		argCount++
 		query += fmt.Sprintf(" OFFSET $%d", argCount)
 		args = append(args, params.Offset)
@@ -327,8 +388,16 @@ func (r *AngleRepository) GetNearMissPastCases(params models.NearMissPastParams)
 		return nil, fmt.Errorf("failed to get past cases: %w", err)
 	}
 
+	cases, next := nextPage(cases, params.Limit, "angle-past", filters, func(c models.NearMissPastCase) interface{} {
+		return pastCaseCursor{Date: cursorDate(c.LastDate), HorseID: c.HorseID, LastRaceID: c.LastRaceID}
+	})
+	if cases == nil {
+		cases = []models.NearMissPastCase{}
+	}
+
 	response := &models.NearMissPastResponse{
 		Cases: cases,
+		Meta:  models.NewPageMeta(len(cases), params.Limit, next),
 	}
 
 	// Calculate summary if requested
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"giddyup/api/internal/auth"
+	"giddyup/api/internal/database"
+	"giddyup/api/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// ErrInvalidScopes is returned when a key asks for scopes its role can't hold
+var ErrInvalidScopes = errors.New("invalid scopes")
+
+type APIKeyRepository struct {
+	db *database.DB
+}
+
+func NewAPIKeyRepository(db *database.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+const apiKeyColumns = `
+	key_id, name, key_prefix, role, scopes, created_by, created_at::text,
+	expires_at::text, last_used_at::text, revoked_at::text
+`
+
+// GetKeys lists API keys, newest first (active only unless filters.Revoked)
+func (r *APIKeyRepository) GetKeys(filters models.APIKeyFilters) ([]models.APIKey, error) {
+	if filters.Limit <= 0 {
+		filters.Limit = 200
+	}
+
+	query := `SELECT ` + apiKeyColumns + ` FROM racing.api_keys WHERE 1=1`
+	if !filters.Revoked {
+		query += " AND revoked_at IS NULL"
+	}
+	query += " ORDER BY created_at DESC, key_id DESC LIMIT $1 OFFSET $2"
+
+	keys := []models.APIKey{}
+	if err := r.db.Select(&keys, query, filters.Limit, filters.Offset); err != nil {
+		return nil, fmt.Errorf("failed to get API keys: %w", err)
+	}
+	return keys, nil
+}
+
+// CreateKey generates and stores a key. Scopes default to the role's and may
+// not exceed them.
+func (r *APIKeyRepository) CreateKey(req models.APIKeyRequest, createdBy string) (*models.APIKeyCreated, error) {
+	scopes, err := auth.ScopesFor(req.Role, req.Scopes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidScopes, err)
+	}
+
+	key, err := auth.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	var expiresIn interface{}
+	if req.ExpiresInDays > 0 {
+		expiresIn = req.ExpiresInDays
+	}
+	var by interface{}
+	if createdBy != "" {
+		by = createdBy
+	}
+
+	created := &models.APIKeyCreated{Key: key}
+	err = r.db.Get(&created.APIKey, `
+		INSERT INTO racing.api_keys (name, key_prefix, key_hash, role, scopes, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, now() + make_interval(days => $7::int))
+		RETURNING `+apiKeyColumns,
+		req.Name, auth.DisplayPrefix(key), auth.HashKey(key), req.Role, pq.Array(scopes), by, expiresIn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create API key: %w", err)
+	}
+	return created, nil
+}
+
+// RevokeKey revokes a key; false if there is no such active key
+func (r *APIKeyRepository) RevokeKey(keyID int64) (bool, error) {
+	var id int64
+	err := r.db.Get(&id, `
+		UPDATE racing.api_keys SET revoked_at = now()
+		WHERE key_id = $1 AND revoked_at IS NULL
+		RETURNING key_id
+	`, keyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,297 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"giddyup/api/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// levelStakesPL is a run's profit to a 1pt win bet at BSP
+const levelStakesPL = `CASE WHEN rb.win_bsp > 0 THEN CASE WHEN rb.win_flag THEN rb.win_bsp - 1 ELSE -1 END END`
+
+// splitRow is a horse's going or distance split
+type splitRow struct {
+	HorseID int64  `db:"horse_id"`
+	Kind    string `db:"kind"`  // going or distance
+	Today   bool   `db:"today"` // Today's going or distance band
+	models.StatsSplit
+}
+
+// cardFlags are a horse's course and distance wins
+type cardFlags struct {
+	HorseID        int64 `db:"horse_id"`
+	CourseWinner   bool  `db:"course_winner"`
+	DistanceWinner bool  `db:"distance_winner"`
+	CDWinner       bool  `db:"cd_winner"`
+}
+
+// comboKey is a jockey and trainer pair
+type comboKey struct {
+	JockeyID  int64 `db:"jockey_id"`
+	TrainerID int64 `db:"trainer_id"`
+}
+
+// GetRaceCard returns a race with each runner's last runs, course and
+// distance record, going and distance splits, trainer form and jockey/trainer
+// record, all from before the race. Every part is one query over the whole
+// field. It returns ErrRaceNotFound if there's no such race.
+func (r *RaceRepository) GetRaceCard(raceID int64) (*models.RaceCard, error) {
+	race, err := r.GetRaceByID(raceID, models.RaceView{Runners: true, Form: true})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRaceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	date := cursorDate(race.Race.RaceDate)
+
+	var horseIDs, trainerIDs []int64
+	var combos []comboKey
+	seenTrainer := make(map[int64]bool)
+	seenCombo := make(map[comboKey]bool)
+	for _, runner := range race.Runners {
+		if runner.HorseID != nil {
+			horseIDs = append(horseIDs, *runner.HorseID)
+		}
+		if runner.TrainerID == nil {
+			continue
+		}
+		if !seenTrainer[*runner.TrainerID] {
+			seenTrainer[*runner.TrainerID] = true
+			trainerIDs = append(trainerIDs, *runner.TrainerID)
+		}
+		if runner.JockeyID != nil {
+			key := comboKey{JockeyID: *runner.JockeyID, TrainerID: *runner.TrainerID}
+			if !seenCombo[key] {
+				seenCombo[key] = true
+				combos = append(combos, key)
+			}
+		}
+	}
+
+	splits, err := r.cardSplits(horseIDs, date, race.Race.Going, race.Race.DistF)
+	if err != nil {
+		return nil, err
+	}
+	flags, err := r.cardFlags(horseIDs, date, race.Race.CourseID, race.Race.DistF)
+	if err != nil {
+		return nil, err
+	}
+	trainerForm, err := r.trainerForm(trainerIDs, date)
+	if err != nil {
+		return nil, err
+	}
+	comboStats, err := r.comboStats(combos, date)
+	if err != nil {
+		return nil, err
+	}
+
+	card := &models.RaceCard{Race: race.Race, Runners: make([]models.CardRunner, len(race.Runners))}
+	for i, runner := range race.Runners {
+		cr := models.CardRunner{Runner: runner}
+		if len(runner.Form) > 0 {
+			last := runner.Form[0]
+			cr.DaysSinceRun = daysBetween(last.RaceDate, date)
+			cr.LastOR = last.OR
+			if runner.OR != nil && last.OR != nil {
+				change := *runner.OR - *last.OR
+				cr.ORChange = &change
+			}
+		}
+		if runner.HorseID != nil {
+			f := flags[*runner.HorseID]
+			cr.CourseWinner, cr.DistanceWinner, cr.CDWinner = f.CourseWinner, f.DistanceWinner, f.CDWinner
+			s := splits[*runner.HorseID]
+			cr.Going, cr.BestGoing = pickSplits(s, "going")
+			cr.Distance, cr.BestDistance = pickSplits(s, "distance")
+		}
+		if runner.TrainerID != nil {
+			cr.TrainerForm = trainerForm[*runner.TrainerID]
+			if runner.JockeyID != nil {
+				cr.JockeyTrainer = comboStats[comboKey{JockeyID: *runner.JockeyID, TrainerID: *runner.TrainerID}]
+			}
+		}
+		card.Runners[i] = cr
+	}
+	return card, nil
+}
+
+// cardSplits returns horses' going and distance splits before a date (as
+// GetHorseGoingSplits and GetHorseDistanceSplits group them), by horse
+func (r *RaceRepository) cardSplits(horseIDs []int64, before string, going *string, distF *float64) (map[int64][]splitRow, error) {
+	byHorse := make(map[int64][]splitRow)
+	if len(horseIDs) == 0 {
+		return byHorse, nil
+	}
+	var rows []splitRow
+	err := r.db.Select(&rows, `
+		WITH runs AS (
+			SELECT rb.horse_id, rb.going, rb.dist_f, `+distanceBand("rb.dist_f")+` AS band,
+				rb.win_flag, rb.pos_num, rb.rpr
+			FROM mv_runner_base rb
+			WHERE rb.horse_id = ANY($1) AND rb.race_date < $2::date
+		)
+		SELECT
+			horse_id, 'going' AS kind, going AS category, COALESCE(going = $3::text, false) AS today,
+			COUNT(*) AS runs,
+			COUNT(*) FILTER (WHERE win_flag) AS wins,
+			COUNT(*) FILTER (WHERE pos_num <= 3) AS places,
+			ROUND(100.0 * COUNT(*) FILTER (WHERE win_flag) / COUNT(*), 2) AS sr,
+			AVG(rpr) FILTER (WHERE rpr IS NOT NULL) AS avg_rpr
+		FROM runs
+		WHERE going IS NOT NULL
+		GROUP BY horse_id, going
+		UNION ALL
+		SELECT
+			horse_id, 'distance', band, COALESCE(band = `+distanceBand("$4::float8")+` AND $4::float8 IS NOT NULL, false),
+			COUNT(*),
+			COUNT(*) FILTER (WHERE win_flag),
+			COUNT(*) FILTER (WHERE pos_num <= 3),
+			ROUND(100.0 * COUNT(*) FILTER (WHERE win_flag) / COUNT(*), 2),
+			AVG(rpr) FILTER (WHERE rpr IS NOT NULL)
+		FROM runs
+		WHERE dist_f IS NOT NULL
+		GROUP BY horse_id, band
+		ORDER BY 1, 2, 3
+	`, pq.Array(horseIDs), before, going, distF)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get card splits: %w", err)
+	}
+	for _, row := range rows {
+		byHorse[row.HorseID] = append(byHorse[row.HorseID], row)
+	}
+	return byHorse, nil
+}
+
+// cardFlags returns which horses won at the course, over the distance (within
+// half a furlong) and both at once before a date, by horse
+func (r *RaceRepository) cardFlags(horseIDs []int64, before string, courseID *int64, distF *float64) (map[int64]cardFlags, error) {
+	byHorse := make(map[int64]cardFlags)
+	if len(horseIDs) == 0 {
+		return byHorse, nil
+	}
+	var rows []cardFlags
+	err := r.db.Select(&rows, `
+		SELECT
+			rb.horse_id,
+			COALESCE(bool_or(rb.course_id = $3), false) AS course_winner,
+			COALESCE(bool_or(ABS(rb.dist_f - $4) <= 0.5), false) AS distance_winner,
+			COALESCE(bool_or(rb.course_id = $3 AND ABS(rb.dist_f - $4) <= 0.5), false) AS cd_winner
+		FROM mv_runner_base rb
+		WHERE rb.horse_id = ANY($1) AND rb.race_date < $2::date AND rb.win_flag
+		GROUP BY rb.horse_id
+	`, pq.Array(horseIDs), before, courseID, distF)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get course and distance wins: %w", err)
+	}
+	for _, row := range rows {
+		byHorse[row.HorseID] = row
+	}
+	return byHorse, nil
+}
+
+// trainerForm returns trainers' runs in the 14 days before a date, by trainer
+func (r *RaceRepository) trainerForm(trainerIDs []int64, before string) (map[int64]*models.FormPeriod, error) {
+	byTrainer := make(map[int64]*models.FormPeriod)
+	if len(trainerIDs) == 0 {
+		return byTrainer, nil
+	}
+	var rows []struct {
+		TrainerID int64 `db:"trainer_id"`
+		models.FormPeriod
+	}
+	err := r.db.Select(&rows, `
+		SELECT
+			t.trainer_id,
+			'14d'::text AS period,
+			COUNT(rb.runner_id) AS runs,
+			COUNT(*) FILTER (WHERE rb.win_flag) AS wins,
+			ROUND(100.0 * COUNT(*) FILTER (WHERE rb.win_flag) / NULLIF(COUNT(rb.runner_id), 0), 2) AS sr,
+			ROUND(SUM(`+levelStakesPL+`)::numeric, 2) AS pl
+		FROM unnest($1::bigint[]) AS t(trainer_id)
+		LEFT JOIN mv_runner_base rb ON rb.trainer_id = t.trainer_id
+			AND rb.race_date >= $2::date - 14 AND rb.race_date < $2::date
+		GROUP BY t.trainer_id
+	`, pq.Array(trainerIDs), before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trainer form: %w", err)
+	}
+	for i := range rows {
+		byTrainer[rows[i].TrainerID] = &rows[i].FormPeriod
+	}
+	return byTrainer, nil
+}
+
+// comboStats returns jockey and trainer pairs' record together before a date,
+// by pair
+func (r *RaceRepository) comboStats(combos []comboKey, before string) (map[comboKey]*models.ComboStats, error) {
+	byCombo := make(map[comboKey]*models.ComboStats)
+	if len(combos) == 0 {
+		return byCombo, nil
+	}
+	jockeyIDs := make([]int64, len(combos))
+	trainerIDs := make([]int64, len(combos))
+	for i, key := range combos {
+		jockeyIDs[i], trainerIDs[i] = key.JockeyID, key.TrainerID
+	}
+	var rows []struct {
+		comboKey
+		models.ComboStats
+	}
+	err := r.db.Select(&rows, `
+		SELECT
+			q.jockey_id, q.trainer_id,
+			COUNT(rb.runner_id) AS runs,
+			COUNT(*) FILTER (WHERE rb.win_flag) AS wins,
+			ROUND(100.0 * COUNT(*) FILTER (WHERE rb.win_flag) / NULLIF(COUNT(rb.runner_id), 0), 2) AS sr,
+			ROUND(SUM(`+levelStakesPL+`)::numeric, 2) AS pl
+		FROM unnest($1::bigint[], $2::bigint[]) AS q(jockey_id, trainer_id)
+		LEFT JOIN mv_runner_base rb ON rb.jockey_id = q.jockey_id AND rb.trainer_id = q.trainer_id
+			AND rb.race_date < $3::date
+		GROUP BY q.jockey_id, q.trainer_id
+	`, pq.Array(jockeyIDs), pq.Array(trainerIDs), before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get jockey/trainer stats: %w", err)
+	}
+	for i := range rows {
+		byCombo[rows[i].comboKey] = &rows[i].ComboStats
+	}
+	return byCombo, nil
+}
+
+// pickSplits returns a horse's split of a kind for today's conditions and its
+// best by strike rate among those with 2+ runs (more runs breaking ties)
+func pickSplits(rows []splitRow, kind string) (today, best *models.StatsSplit) {
+	for i := range rows {
+		row := &rows[i]
+		if row.Kind != kind {
+			continue
+		}
+		if row.Today {
+			today = &row.StatsSplit
+		}
+		if row.Runs < 2 {
+			continue
+		}
+		if best == nil || row.SR > best.SR || (row.SR == best.SR && row.Runs > best.Runs) {
+			best = &row.StatsSplit
+		}
+	}
+	return today, best
+}
+
+// daysBetween is the whole days from one date (YYYY-MM-DD) to another
+func daysBetween(from, to string) *int {
+	f, err1 := time.Parse("2006-01-02", cursorDate(from))
+	t, err2 := time.Parse("2006-01-02", cursorDate(to))
+	if err1 != nil || err2 != nil {
+		return nil
+	}
+	days := int(t.Sub(f).Hours() / 24)
+	return &days
+}
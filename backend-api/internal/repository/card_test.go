@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"testing"
+
+	"giddyup/api/internal/models"
+)
+
+func TestPickSplits(t *testing.T) {
+	split := func(kind, category string, today bool, runs, wins int, sr float64) splitRow {
+		return splitRow{Kind: kind, Today: today, StatsSplit: models.StatsSplit{Category: category, Runs: runs, Wins: wins, SR: sr}}
+	}
+	cases := []struct {
+		name      string
+		kind      string
+		rows      []splitRow
+		wantToday string // Category ("" for nil)
+		wantBest  string
+	}{
+		{name: "no rows", kind: "going"},
+		{
+			name: "today and best differ", kind: "going",
+			rows: []splitRow{
+				split("going", "Good", true, 4, 1, 25),
+				split("going", "Soft", false, 5, 3, 60),
+				split("going", "Heavy", false, 3, 1, 33.3),
+			},
+			wantToday: "Good", wantBest: "Soft",
+		},
+		{
+			name: "best needs two runs", kind: "going",
+			rows: []splitRow{
+				split("going", "Firm", false, 1, 1, 100),
+				split("going", "Good", false, 4, 1, 25),
+			},
+			wantBest: "Good",
+		},
+		{
+			name: "today's split with one run is today but not best", kind: "going",
+			rows: []splitRow{
+				split("going", "Soft", true, 1, 1, 100),
+			},
+			wantToday: "Soft",
+		},
+		{
+			name: "more runs break a strike rate tie", kind: "distance",
+			rows: []splitRow{
+				split("distance", "5f", false, 2, 1, 50),
+				split("distance", "6f", false, 6, 3, 50),
+				split("distance", "7f", false, 4, 2, 50),
+			},
+			wantBest: "6f",
+		},
+		{
+			name: "other kinds ignored", kind: "going",
+			rows: []splitRow{
+				split("distance", "1m", true, 8, 8, 100),
+				split("going", "Good", true, 2, 0, 0),
+			},
+			wantToday: "Good", wantBest: "Good",
+		},
+	}
+	category := func(s *models.StatsSplit) string {
+		if s == nil {
+			return ""
+		}
+		return s.Category
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			today, best := pickSplits(tc.rows, tc.kind)
+			if got := category(today); got != tc.wantToday {
+				t.Errorf("today = %q, want %q", got, tc.wantToday)
+			}
+			if got := category(best); got != tc.wantBest {
+				t.Errorf("best = %q, want %q", got, tc.wantBest)
+			}
+		})
+	}
+}
+
+func TestDaysBetween(t *testing.T) {
+	cases := []struct {
+		from, to string
+		want     *int
+	}{
+		{"2025-10-01", "2025-10-18", intPtr(17)},
+		{"2025-10-18", "2025-10-18", intPtr(0)},
+		{"2025-10-18T00:00:00Z", "2025-10-19", intPtr(1)}, // Timestamps from the database
+		{"2025-03-29", "2025-03-31", intPtr(2)},           // Across the clocks going forward
+		{"2024-02-28", "2025-03-01", intPtr(367)},         // Across a leap day
+		{"2025-10-19", "2025-10-18", intPtr(-1)},
+		{"", "2025-10-18", nil},
+		{"2025-10-18", "yesterday", nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.from+" to "+tc.to, func(t *testing.T) {
+			got := daysBetween(tc.from, tc.to)
+			switch {
+			case got == nil && tc.want == nil:
+			case got == nil || tc.want == nil:
+				t.Errorf("daysBetween = %v, want %v", got, tc.want)
+			case *got != *tc.want:
+				t.Errorf("daysBetween = %d, want %d", *got, *tc.want)
+			}
+		})
+	}
+}
+
+func intPtr(n int) *int {
+	return &n
+}
@@ -0,0 +1,451 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"giddyup/api/internal/database"
+	"giddyup/api/internal/matching"
+	"giddyup/api/internal/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrCourseNotFound is returned when a registry change names a course that doesn't exist
+	ErrCourseNotFound = errors.New("course not found")
+	// ErrReviewNotFound is returned for an unknown course review queue entry
+	ErrReviewNotFound = errors.New("course review not found")
+	// ErrReviewClosed is returned when a queue entry has already been resolved
+	ErrReviewClosed = errors.New("course review already resolved")
+)
+
+type CourseRegistryRepository struct {
+	db *database.DB
+}
+
+func NewCourseRegistryRepository(db *database.DB) *CourseRegistryRepository {
+	return &CourseRegistryRepository{db: db}
+}
+
+const courseDetailColumns = `
+	course_id, course_name, region, timezone, surfaces, direction, profile, updated_at::text
+`
+
+const courseReviewColumns = `
+	review_id, source, region, course_name, cardinality(race_keys) AS race_count,
+	seen_count, first_seen::text, last_seen::text, status, course_id,
+	resolved_at::text, note
+`
+
+// GetCourse returns a registry course with its aliases
+func (r *CourseRegistryRepository) GetCourse(courseID int64) (*models.CourseDetail, error) {
+	var course models.CourseDetail
+	err := r.db.Get(&course, `SELECT`+courseDetailColumns+`FROM racing.courses WHERE course_id = $1`, courseID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCourseNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get course: %w", err)
+	}
+
+	course.Aliases = []models.CourseAlias{}
+	if err := r.db.Select(&course.Aliases, `
+		SELECT alias_id, course_id, source, region, alias, created_at::text
+		FROM racing.course_alias
+		WHERE course_id = $1
+		ORDER BY source, alias
+	`, courseID); err != nil {
+		return nil, fmt.Errorf("failed to get course aliases: %w", err)
+	}
+	return &course, nil
+}
+
+// UpdateCourse changes a course's name or track attributes. A renamed course keeps
+// its old name as a manual alias so loaders still resolve it.
+func (r *CourseRegistryRepository) UpdateCourse(courseID int64, req models.CourseRequest) (*models.CourseDetail, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var oldName, region string
+	err = tx.QueryRow(`SELECT course_name, region FROM racing.courses WHERE course_id = $1 FOR UPDATE`, courseID).Scan(&oldName, &region)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCourseNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get course: %w", err)
+	}
+
+	var surfaces interface{}
+	if req.Surfaces != nil {
+		surfaces = pq.Array(req.Surfaces)
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE racing.courses SET
+			course_name = COALESCE(NULLIF($2, ''), course_name),
+			timezone    = COALESCE(NULLIF($3, ''), timezone),
+			surfaces    = COALESCE($4::text[], surfaces),
+			direction   = COALESCE(NULLIF($5, ''), direction),
+			profile     = COALESCE(NULLIF($6, ''), profile),
+			updated_at  = now()
+		WHERE course_id = $1
+	`, courseID, strings.TrimSpace(req.CourseName), req.Timezone, surfaces, req.Direction, req.Profile); err != nil {
+		return nil, fmt.Errorf("failed to update course: %w", err)
+	}
+
+	if name := strings.TrimSpace(req.CourseName); name != "" && name != oldName {
+		if err := upsertCourseAlias(tx, courseID, matching.CourseSourceManual, region, oldName); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit course update: %w", err)
+	}
+	return r.GetCourse(courseID)
+}
+
+// AddAlias records a source's name for a course and settles any queued review of
+// that name (its waiting races get the course_id)
+func (r *CourseRegistryRepository) AddAlias(courseID int64, req models.CourseAliasRequest) (*models.CourseResolveResult, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var region string
+	err = tx.QueryRow(`SELECT region FROM racing.courses WHERE course_id = $1`, courseID).Scan(&region)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCourseNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get course: %w", err)
+	}
+	if req.Region != "" {
+		region = strings.ToUpper(strings.TrimSpace(req.Region))
+	}
+
+	alias := strings.TrimSpace(req.Alias)
+	if err := upsertCourseAlias(tx, courseID, req.Source, region, alias); err != nil {
+		return nil, err
+	}
+
+	result := &models.CourseResolveResult{}
+	result.Resolved, result.RacesUpdated, err = settleCourseReviews(tx, courseID, region, alias, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit course alias: %w", err)
+	}
+
+	course, err := r.GetCourse(courseID)
+	if err != nil {
+		return nil, err
+	}
+	result.Course = *course
+	return result, nil
+}
+
+// DeleteAlias removes a course alias; returns false if it didn't exist
+func (r *CourseRegistryRepository) DeleteAlias(aliasID int64) (bool, error) {
+	res, err := r.db.Exec(`DELETE FROM racing.course_alias WHERE alias_id = $1`, aliasID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete course alias: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// GetReviewQueue returns queued course names (pending by default), most recently
+// seen first, with likely registry matches for pending entries
+func (r *CourseRegistryRepository) GetReviewQueue(filters models.CourseReviewFilters) ([]models.CourseReview, error) {
+	query := `SELECT` + courseReviewColumns + `FROM racing.course_review_queue WHERE 1=1`
+
+	args := []interface{}{}
+	argCount := 0
+
+	status := "pending"
+	if filters.Status != nil {
+		status = *filters.Status
+	}
+	if status != "all" {
+		argCount++
+		query += fmt.Sprintf(" AND status = $%d", argCount)
+		args = append(args, status)
+	}
+
+	if filters.Source != nil {
+		argCount++
+		query += fmt.Sprintf(" AND source = $%d", argCount)
+		args = append(args, *filters.Source)
+	}
+
+	if filters.Region != nil {
+		argCount++
+		query += fmt.Sprintf(" AND region = $%d", argCount)
+		args = append(args, strings.ToUpper(*filters.Region))
+	}
+
+	query += " ORDER BY last_seen DESC, review_id DESC"
+
+	limit := 200
+	if filters.Limit > 0 {
+		limit = filters.Limit
+	}
+	argCount++
+	query += fmt.Sprintf(" LIMIT $%d", argCount)
+	args = append(args, limit)
+
+	if filters.Offset > 0 {
+		argCount++
+		query += fmt.Sprintf(" OFFSET $%d", argCount)
+		args = append(args, filters.Offset)
+	}
+
+	reviews := []models.CourseReview{}
+	if err := r.db.Select(&reviews, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get course review queue: %w", err)
+	}
+
+	for i := range reviews {
+		if reviews[i].Status != "pending" {
+			continue
+		}
+		if err := r.db.Select(&reviews[i].Suggestions, `
+			SELECT course_id, course_name, similarity(course_norm, racing.norm_text($1)) AS similarity
+			FROM racing.courses
+			WHERE region = $2 AND similarity(course_norm, racing.norm_text($1)) > 0.2
+			ORDER BY similarity DESC
+			LIMIT 3
+		`, reviews[i].CourseName, reviews[i].Region); err != nil {
+			return nil, fmt.Errorf("failed to get course suggestions: %w", err)
+		}
+	}
+	return reviews, nil
+}
+
+// ResolveReview maps a queued name to an existing course or a new one: the name
+// becomes an alias for the course, every queue entry for it is closed and the
+// races waiting on it get the course_id
+func (r *CourseRegistryRepository) ResolveReview(reviewID int64, req models.CourseResolveRequest) (*models.CourseResolveResult, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var review models.CourseReview
+	err = tx.Get(&review, `SELECT`+courseReviewColumns+`FROM racing.course_review_queue WHERE review_id = $1 FOR UPDATE`, reviewID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReviewNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get course review: %w", err)
+	}
+	if review.Status == "resolved" {
+		return nil, ErrReviewClosed
+	}
+
+	var courseID int64
+	if req.CourseID != nil {
+		err = tx.QueryRow(`SELECT course_id FROM racing.courses WHERE course_id = $1`, *req.CourseID).Scan(&courseID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCourseNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get course: %w", err)
+		}
+	} else {
+		course := *req.Course
+		if course.CourseName == "" {
+			course.CourseName = review.CourseName
+		}
+		if course.Region == "" {
+			course.Region = review.Region
+		}
+		if courseID, err = insertCourse(tx, course); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := upsertCourseAlias(tx, courseID, review.Source, review.Region, review.CourseName); err != nil {
+		return nil, err
+	}
+
+	result := &models.CourseResolveResult{}
+	result.Resolved, result.RacesUpdated, err = settleCourseReviews(tx, courseID, review.Region, review.CourseName, req.Note)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit course review: %w", err)
+	}
+
+	course, err := r.GetCourse(courseID)
+	if err != nil {
+		return nil, err
+	}
+	result.Course = *course
+	return result, nil
+}
+
+// IgnoreReview marks a queued name as not a course to register
+func (r *CourseRegistryRepository) IgnoreReview(reviewID int64, note string) (*models.CourseReview, error) {
+	var nullNote interface{}
+	if note != "" {
+		nullNote = note
+	}
+
+	var review models.CourseReview
+	err := r.db.Get(&review, `
+		UPDATE racing.course_review_queue
+		SET status = 'ignored', note = COALESCE($2, note)
+		WHERE review_id = $1 AND status <> 'resolved'
+		RETURNING`+courseReviewColumns, reviewID, nullNote)
+	if errors.Is(err, sql.ErrNoRows) {
+		var exists bool
+		if err := r.db.Get(&exists, `SELECT EXISTS (SELECT 1 FROM racing.course_review_queue WHERE review_id = $1)`, reviewID); err != nil {
+			return nil, fmt.Errorf("failed to get course review: %w", err)
+		}
+		if exists {
+			return nil, ErrReviewClosed
+		}
+		return nil, ErrReviewNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to ignore course review: %w", err)
+	}
+	return &review, nil
+}
+
+// GetOrphans returns course_ids referenced by races but missing from the registry
+func (r *CourseRegistryRepository) GetOrphans() ([]models.CourseOrphan, error) {
+	orphans := []models.CourseOrphan{}
+	if err := r.db.Select(&orphans, `
+		SELECT course_id, region, race_count, first_race::text, last_race::text, sample_races
+		FROM racing.course_orphans
+		ORDER BY race_count DESC
+	`); err != nil {
+		return nil, fmt.Errorf("failed to get orphaned courses: %w", err)
+	}
+	return orphans, nil
+}
+
+// insertCourse creates a canonical course (or returns the existing one with that name).
+// Without a timezone it takes the one most courses in the region use.
+func insertCourse(tx *sqlx.Tx, req models.CourseRequest) (int64, error) {
+	var timezone, direction, profile interface{}
+	if req.Timezone != "" {
+		timezone = req.Timezone
+	}
+	if req.Direction != "" {
+		direction = req.Direction
+	}
+	if req.Profile != "" {
+		profile = req.Profile
+	}
+	surfaces := req.Surfaces
+	if surfaces == nil {
+		surfaces = []string{}
+	}
+
+	var courseID int64
+	err := tx.QueryRow(`
+		INSERT INTO racing.courses (course_name, region, timezone, surfaces, direction, profile, updated_at)
+		VALUES ($1, $2, COALESCE($3, (
+			-- Default to the jurisdiction's usual timezone
+			SELECT timezone FROM racing.courses
+			WHERE region = $2 AND timezone IS NOT NULL
+			GROUP BY timezone ORDER BY count(*) DESC LIMIT 1
+		)), $4, $5, $6, now())
+		ON CONFLICT ON CONSTRAINT courses_uniq DO UPDATE SET updated_at = now()
+		RETURNING course_id
+	`, strings.TrimSpace(req.CourseName), strings.ToUpper(strings.TrimSpace(req.Region)),
+		timezone, pq.Array(surfaces), direction, profile).Scan(&courseID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create course: %w", err)
+	}
+	return courseID, nil
+}
+
+// upsertCourseAlias points a source's name at a course (moving it if it pointed elsewhere)
+func upsertCourseAlias(tx *sqlx.Tx, courseID int64, source, region, alias string) error {
+	if _, err := tx.Exec(`
+		INSERT INTO racing.course_alias (course_id, source, region, alias)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT ON CONSTRAINT course_alias_uniq DO UPDATE SET course_id = EXCLUDED.course_id
+	`, courseID, source, region, alias); err != nil {
+		return fmt.Errorf("failed to save course alias: %w", err)
+	}
+	return nil
+}
+
+// settleCourseReviews closes every open queue entry for a name (from any source)
+// and gives the races and entry races waiting on it the course_id
+func settleCourseReviews(tx *sqlx.Tx, courseID int64, region, name, note string) (int, int, error) {
+	var nullNote interface{}
+	if note != "" {
+		nullNote = note
+	}
+
+	rows, err := tx.Query(`
+		UPDATE racing.course_review_queue
+		SET status = 'resolved', course_id = $1, resolved_at = now(), note = COALESCE($4, note)
+		WHERE region = $2 AND name_norm = racing.norm_text($3) AND status <> 'resolved'
+		RETURNING race_keys
+	`, courseID, region, name, nullNote)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to close course reviews: %w", err)
+	}
+
+	var raceKeys []string
+	resolved := 0
+	for rows.Next() {
+		var keys pq.StringArray
+		if err := rows.Scan(&keys); err != nil {
+			rows.Close()
+			return 0, 0, fmt.Errorf("failed to scan course review: %w", err)
+		}
+		raceKeys = append(raceKeys, keys...)
+		resolved++
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, err
+	}
+	if len(raceKeys) == 0 {
+		return resolved, 0, nil
+	}
+
+	var updated int
+	err = tx.QueryRow(`
+		WITH r AS (
+			UPDATE racing.races SET course_id = $1
+			WHERE course_id IS NULL AND race_key = ANY($2)
+			RETURNING 1
+		), e AS (
+			UPDATE racing.entry_races SET course_id = $1
+			WHERE course_id IS NULL AND race_key = ANY($2)
+			RETURNING 1
+		)
+		SELECT (SELECT count(*) FROM r) + (SELECT count(*) FROM e)
+	`, courseID, pq.Array(raceKeys)).Scan(&updated)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to update races for course: %w", err)
+	}
+	return resolved, updated, nil
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// ErrInvalidCursor is returned for a cursor that is malformed, was issued
+// by a different list, or was issued for different filters
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// cursor is the position after the last row of a page: that row's sort key,
+// tagged with the list it belongs to and a hash of the filters it was queried
+// with. Clients treat it as opaque.
+type cursor struct {
+	List   string          `json:"l"`
+	Filter string          `json:"f"`
+	Key    json.RawMessage `json:"k"`
+}
+
+// filterHash fingerprints a list's filters. Callers pass them without the
+// page fields (limit, offset, cursor), which may change from page to page.
+func filterHash(filters interface{}) string {
+	b, _ := json.Marshal(filters)
+	sum := sha256.Sum256(b)
+	return base64.RawURLEncoding.EncodeToString(sum[:8])
+}
+
+func encodeCursor(list string, filters, key interface{}) string {
+	k, _ := json.Marshal(key)
+	b, _ := json.Marshal(cursor{List: list, Filter: filterHash(filters), Key: k})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor reads the sort key of a cursor issued by the list for the same
+// filters into key
+func decodeCursor(s, list string, filters, key interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(b, &c); err != nil || c.List != list || c.Filter != filterHash(filters) {
+		return ErrInvalidCursor
+	}
+	if err := json.Unmarshal(c.Key, key); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+// nextPage trims rows fetched with LIMIT limit+1 back to limit and returns
+// the cursor after the last row kept ("" when there are no more rows)
+func nextPage[T any](rows []T, limit int, list string, filters interface{}, key func(T) interface{}) ([]T, string) {
+	if len(rows) <= limit {
+		return rows, ""
+	}
+	rows = rows[:limit]
+	return rows, encodeCursor(list, filters, key(rows[limit-1]))
+}
+
+// cursorDate keeps the date part of a scanned DATE column ("2024-05-01" or
+// "2024-05-01T00:00:00Z") so it can be bound back as a date
+func cursorDate(d string) string {
+	if len(d) > 10 {
+		return d[:10]
+	}
+	return d
+}
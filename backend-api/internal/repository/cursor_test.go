@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"giddyup/api/internal/models"
+)
+
+func TestCursorFilters(t *testing.T) {
+	gb, ire := "GB", "IRE"
+	filters := models.RaceFilterSet{Region: &gb}
+	cur := encodeCursor("races", filters, raceCursor{Date: "2025-10-18", OffTime: "14:05:00", RaceID: 7})
+
+	var after raceCursor
+	if err := decodeCursor(cur, "races", models.RaceFilterSet{Region: &gb}, &after); err != nil {
+		t.Fatalf("same filters: %v", err)
+	}
+	if after.RaceID != 7 || after.OffTime != "14:05:00" {
+		t.Fatalf("got %+v", after)
+	}
+
+	for name, decode := range map[string]func() error{
+		"other filters": func() error {
+			return decodeCursor(cur, "races", models.RaceFilterSet{Region: &ire}, &after)
+		},
+		"filter dropped": func() error { return decodeCursor(cur, "races", models.RaceFilterSet{}, &after) },
+		"other list":     func() error { return decodeCursor(cur, "comments", filters, &after) },
+		"malformed":      func() error { return decodeCursor("!"+cur, "races", filters, &after) },
+	} {
+		if err := decode(); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: err = %v, want ErrInvalidCursor", name, err)
+		}
+	}
+}
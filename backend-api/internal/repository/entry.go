@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"fmt"
+
+	"giddyup/api/internal/database"
+	"giddyup/api/internal/models"
+)
+
+type EntryRepository struct {
+	db *database.DB
+}
+
+func NewEntryRepository(db *database.DB) *EntryRepository {
+	return &EntryRepository{db: db}
+}
+
+// GetEntries returns forward entries/declarations matching the filters
+// Defaults to today onwards when no date range is given
+func (r *EntryRepository) GetEntries(filters models.EntryFilters) ([]models.Entry, error) {
+	query := `
+		SELECT
+			e.entry_id,
+			e.sl_race_id,
+			e.race_date::text,
+			er.region,
+			er.course_id,
+			c.course_name,
+			er.off_time::text,
+			er.race_name,
+			er.race_type,
+			er.class,
+			er.dist_f,
+			er.going,
+			e.horse_id,
+			h.horse_name,
+			e.trainer_id,
+			t.trainer_name,
+			e.jockey_id,
+			j.jockey_name,
+			e.num,
+			e.draw,
+			e.lbs,
+			e."or",
+			e.status,
+			e.entered_at::text,
+			e.declared_at::text,
+			e.settled_at::text
+		FROM racing.entries e
+		JOIN racing.entry_races er ON er.sl_race_id = e.sl_race_id
+		JOIN racing.horses h ON h.horse_id = e.horse_id
+		LEFT JOIN racing.courses c ON c.course_id = er.course_id
+		LEFT JOIN racing.trainers t ON t.trainer_id = e.trainer_id
+		LEFT JOIN racing.jockeys j ON j.jockey_id = e.jockey_id
+		WHERE 1=1
+	`
+
+	args := []interface{}{}
+	argCount := 0
+
+	if filters.HorseID != nil {
+		argCount++
+		query += fmt.Sprintf(" AND e.horse_id = $%d", argCount)
+		args = append(args, *filters.HorseID)
+	}
+
+	if filters.TrainerID != nil {
+		argCount++
+		query += fmt.Sprintf(" AND e.trainer_id = $%d", argCount)
+		args = append(args, *filters.TrainerID)
+	}
+
+	if filters.EntryRaceID != nil {
+		argCount++
+		query += fmt.Sprintf(" AND e.sl_race_id = $%d", argCount)
+		args = append(args, *filters.EntryRaceID)
+	}
+
+	if filters.Status != nil {
+		argCount++
+		query += fmt.Sprintf(" AND e.status = $%d", argCount)
+		args = append(args, *filters.Status)
+	}
+
+	if filters.DateFrom != nil {
+		argCount++
+		query += fmt.Sprintf(" AND e.race_date >= $%d", argCount)
+		args = append(args, *filters.DateFrom)
+	} else if filters.EntryRaceID == nil {
+		query += " AND e.race_date >= CURRENT_DATE"
+	}
+
+	if filters.DateTo != nil {
+		argCount++
+		query += fmt.Sprintf(" AND e.race_date <= $%d", argCount)
+		args = append(args, *filters.DateTo)
+	}
+
+	query += " ORDER BY e.race_date, er.off_time, e.sl_race_id, e.num NULLS LAST, h.horse_name"
+
+	limit := 200
+	if filters.Limit > 0 {
+		limit = filters.Limit
+	}
+	argCount++
+	query += fmt.Sprintf(" LIMIT $%d", argCount)
+	args = append(args, limit)
+
+	if filters.Offset > 0 {
+		argCount++
+		query += fmt.Sprintf(" OFFSET $%d", argCount)
+		args = append(args, filters.Offset)
+	}
+
+	entries := []models.Entry{}
+	if err := r.db.Select(&entries, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get entries: %w", err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,443 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"giddyup/api/internal/database"
+	"giddyup/api/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// ErrUnknownExportColumn is returned for a column the dataset doesn't have
+var ErrUnknownExportColumn = errors.New("unknown export column")
+
+// Export column types
+const (
+	ExportInt    = "integer"
+	ExportFloat  = "number"
+	ExportString = "string"
+	ExportBool   = "boolean"
+	ExportDate   = "date"
+)
+
+// ExportColumn is a column of an export dataset
+type ExportColumn struct {
+	Name    string
+	Type    string
+	Default bool // Exported when no columns are asked for
+	expr    string
+}
+
+type exportDataset struct {
+	from    string // FROM clause; races are always r
+	order   string
+	columns []ExportColumn
+}
+
+// exportDatasets are the datasets that can be exported. Columns are a fixed
+// list so nothing else can reach the SELECT.
+var exportDatasets = map[string]exportDataset{
+	"races": {
+		from: `racing.races r
+			LEFT JOIN racing.courses c ON c.course_id = r.course_id`,
+		order: "r.race_date, r.race_id",
+		columns: []ExportColumn{
+			{"race_id", ExportInt, true, "r.race_id"},
+			{"race_key", ExportString, false, "r.race_key"},
+			{"race_date", ExportDate, true, "r.race_date"},
+			{"region", ExportString, true, "r.region"},
+			{"course_id", ExportInt, true, "r.course_id"},
+			{"course_name", ExportString, true, "c.course_name"},
+			{"off_time", ExportString, true, "r.off_time::text"},
+			{"race_name", ExportString, true, "r.race_name"},
+			{"race_type", ExportString, true, "r.race_type"},
+			{"class", ExportString, true, "r.class"},
+			{"pattern", ExportString, false, "r.pattern"},
+			{"rating_band", ExportString, false, "r.rating_band"},
+			{"age_band", ExportString, false, "r.age_band"},
+			{"sex_rest", ExportString, false, "r.sex_rest"},
+			{"dist_raw", ExportString, false, "r.dist_raw"},
+			{"dist_f", ExportFloat, true, "r.dist_f"},
+			{"dist_m", ExportInt, false, "r.dist_m"},
+			{"going", ExportString, true, "r.going"},
+			{"surface", ExportString, true, "r.surface"},
+			{"ran", ExportInt, true, "r.ran"},
+		},
+	},
+	"runners": {
+		from: `racing.runners ru
+			JOIN racing.races r ON r.race_id = ru.race_id AND r.race_date = ru.race_date
+			LEFT JOIN racing.courses c ON c.course_id = r.course_id
+			LEFT JOIN racing.horses h ON h.horse_id = ru.horse_id
+			LEFT JOIN racing.trainers t ON t.trainer_id = ru.trainer_id
+			LEFT JOIN racing.jockeys j ON j.jockey_id = ru.jockey_id`,
+		order: "r.race_date, r.race_id, ru.runner_id",
+		columns: []ExportColumn{
+			{"runner_id", ExportInt, true, "ru.runner_id"},
+			{"race_id", ExportInt, true, "ru.race_id"},
+			{"race_date", ExportDate, true, "ru.race_date"},
+			{"region", ExportString, false, "r.region"},
+			{"course_name", ExportString, true, "c.course_name"},
+			{"off_time", ExportString, true, "r.off_time::text"},
+			{"race_type", ExportString, false, "r.race_type"},
+			{"class", ExportString, false, "r.class"},
+			{"dist_f", ExportFloat, false, "r.dist_f"},
+			{"going", ExportString, false, "r.going"},
+			{"ran", ExportInt, false, "r.ran"},
+			{"horse_id", ExportInt, true, "ru.horse_id"},
+			{"horse_name", ExportString, true, "h.horse_name"},
+			{"trainer_id", ExportInt, false, "ru.trainer_id"},
+			{"trainer_name", ExportString, true, "t.trainer_name"},
+			{"jockey_id", ExportInt, false, "ru.jockey_id"},
+			{"jockey_name", ExportString, true, "j.jockey_name"},
+			{"num", ExportInt, false, "ru.num"},
+			{"draw", ExportInt, true, "ru.draw"},
+			{"age", ExportInt, false, "ru.age"},
+			{"sex", ExportString, false, "ru.sex"},
+			{"lbs", ExportInt, true, "ru.lbs"},
+			{"hg", ExportString, false, "ru.hg"},
+			{"or", ExportInt, true, `ru."or"`},
+			{"rpr", ExportInt, true, "ru.rpr"},
+			{"pos_raw", ExportString, false, "ru.pos_raw"},
+			{"pos_num", ExportInt, true, "ru.pos_num"},
+			{"win_flag", ExportBool, true, "ru.win_flag"},
+			{"btn", ExportFloat, true, "ru.btn"},
+			{"ovr_btn", ExportFloat, false, "ru.ovr_btn"},
+			{"secs", ExportFloat, false, "ru.secs"},
+			{"dec", ExportFloat, true, "ru.dec"},
+			{"win_bsp", ExportFloat, true, "ru.win_bsp"},
+			{"win_ppwap", ExportFloat, false, "ru.win_ppwap"},
+			{"win_ppmax", ExportFloat, false, "ru.win_ppmax"},
+			{"win_ppmin", ExportFloat, false, "ru.win_ppmin"},
+			{"win_ipmax", ExportFloat, false, "ru.win_ipmax"},
+			{"win_ipmin", ExportFloat, false, "ru.win_ipmin"},
+			{"place_bsp", ExportFloat, false, "ru.place_bsp"},
+			{"place_ppwap", ExportFloat, false, "ru.place_ppwap"},
+			{"prize", ExportFloat, false, "ru.prize"},
+			{"comment", ExportString, false, "ru.comment"},
+		},
+	},
+}
+
+// ExportDatasets lists every dataset with its columns
+func ExportDatasets() []models.ExportDataset {
+	var out []models.ExportDataset
+	for _, name := range []string{"races", "runners"} {
+		ds := models.ExportDataset{Dataset: name}
+		for _, col := range exportDatasets[name].columns {
+			ds.Columns = append(ds.Columns, models.ExportColumnInfo{Name: col.Name, Type: col.Type, Default: col.Default})
+		}
+		out = append(out, ds)
+	}
+	return out
+}
+
+// ExportColumns resolves column names for a dataset, in the order given
+// (the default columns when names is empty)
+func ExportColumns(dataset string, names []string) ([]ExportColumn, error) {
+	ds, ok := exportDatasets[dataset]
+	if !ok {
+		return nil, fmt.Errorf("unknown export dataset %q", dataset)
+	}
+	if len(names) == 0 {
+		var cols []ExportColumn
+		for _, col := range ds.columns {
+			if col.Default {
+				cols = append(cols, col)
+			}
+		}
+		return cols, nil
+	}
+
+	cols := make([]ExportColumn, 0, len(names))
+	seen := make(map[string]bool)
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		col, ok := findExportColumn(ds.columns, name)
+		if !ok {
+			return nil, fmt.Errorf("%w %q for %s", ErrUnknownExportColumn, name, dataset)
+		}
+		seen[name] = true
+		cols = append(cols, col)
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("%w: no columns for %s", ErrUnknownExportColumn, dataset)
+	}
+	return cols, nil
+}
+
+func findExportColumn(cols []ExportColumn, name string) (ExportColumn, bool) {
+	for _, col := range cols {
+		if col.Name == name {
+			return col, true
+		}
+	}
+	return ExportColumn{}, false
+}
+
+type ExportRepository struct {
+	db *database.DB
+}
+
+func NewExportRepository(db *database.DB) *ExportRepository {
+	return &ExportRepository{db: db}
+}
+
+// Stream runs an export query and calls fn with each row's values, in
+// column order, as it arrives; rows are never all held in memory. Values are
+// nil, int64, float64, string, bool or time.Time (dates). limit 0 means all
+// rows. It returns the number of rows passed to fn.
+func (r *ExportRepository) Stream(ctx context.Context, dataset string, cols []ExportColumn, filters models.RaceFilterSet, limit int, fn func([]interface{}) error) (int64, error) {
+	ds, ok := exportDatasets[dataset]
+	if !ok {
+		return 0, fmt.Errorf("unknown export dataset %q", dataset)
+	}
+
+	exprs := make([]string, len(cols))
+	for i, col := range cols {
+		exprs[i] = col.expr
+	}
+	query := "SELECT " + strings.Join(exprs, ", ") + " FROM " + ds.from + " WHERE 1=1"
+	query, args := appendRaceFilters(query, nil, filters)
+	query += " ORDER BY " + ds.order
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", len(args)+1)
+		args = append(args, limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query %s export: %w", dataset, err)
+	}
+	defer rows.Close()
+
+	values := make([]interface{}, len(cols))
+	ptrs := make([]interface{}, len(cols))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+
+	var n int64
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return n, fmt.Errorf("failed to scan %s export row: %w", dataset, err)
+		}
+		for i, v := range values {
+			values[i] = exportValue(cols[i].Type, v)
+		}
+		if err := fn(values); err != nil {
+			return n, err
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return n, fmt.Errorf("failed to read %s export: %w", dataset, err)
+	}
+	return n, nil
+}
+
+// exportValue normalises a scanned value to its column type (lib/pq returns
+// text as string or []byte and numerics as []byte)
+func exportValue(typ string, v interface{}) interface{} {
+	if b, ok := v.([]byte); ok {
+		v = string(b)
+	}
+	switch typ {
+	case ExportFloat:
+		switch x := v.(type) {
+		case int64:
+			return float64(x)
+		case string:
+			var f float64
+			if _, err := fmt.Sscan(x, &f); err == nil {
+				return f
+			}
+			return nil
+		}
+	case ExportInt:
+		if f, ok := v.(float64); ok {
+			return int64(f)
+		}
+	case ExportDate:
+		if s, ok := v.(string); ok {
+			if t, err := time.Parse("2006-01-02", s[:min(len(s), 10)]); err == nil {
+				return t
+			}
+			return nil
+		}
+	}
+	return v
+}
+
+// CreateJob queues a background export
+func (r *ExportRepository) CreateJob(job *models.ExportJob) error {
+	filters, err := json.Marshal(job.Filters)
+	if err != nil {
+		return fmt.Errorf("failed to encode export filters: %w", err)
+	}
+	err = r.db.QueryRow(`
+		INSERT INTO racing.export_jobs (client, key_id, dataset, format, columns, filters)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING job_id, status, created_at
+	`, job.Client, job.KeyID, job.Dataset, job.Format, pq.Array(job.Columns), filters).
+		Scan(&job.JobID, &job.Status, &job.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create export job: %w", err)
+	}
+	return nil
+}
+
+// exportJobRow is an export_jobs row with its filters still encoded
+type exportJobRow struct {
+	models.ExportJob
+	FiltersJSON []byte `db:"filters"`
+}
+
+func (row exportJobRow) job() (*models.ExportJob, error) {
+	job := row.ExportJob
+	if err := json.Unmarshal(row.FiltersJSON, &job.Filters); err != nil {
+		return nil, fmt.Errorf("failed to decode filters of export job %d: %w", job.JobID, err)
+	}
+	return &job, nil
+}
+
+const exportJobColumns = `job_id, client, key_id, dataset, format, columns, filters, status,
+	rows, bytes, file_path, error, created_at, started_at, completed_at, expires_at`
+
+// GetJob returns an export job (sql.ErrNoRows if there is none)
+func (r *ExportRepository) GetJob(jobID int64) (*models.ExportJob, error) {
+	var row exportJobRow
+	err := r.db.Get(&row, `SELECT `+exportJobColumns+` FROM racing.export_jobs WHERE job_id = $1`, jobID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get export job: %w", err)
+	}
+	return row.job()
+}
+
+// ListJobs returns a client's latest export jobs (every client's if client is "")
+func (r *ExportRepository) ListJobs(client string, limit int) ([]models.ExportJob, error) {
+	query := `SELECT ` + exportJobColumns + ` FROM racing.export_jobs`
+	args := []interface{}{}
+	if client != "" {
+		query += " WHERE client = $1"
+		args = append(args, client)
+	}
+	query += fmt.Sprintf(" ORDER BY job_id DESC LIMIT $%d", len(args)+1)
+	args = append(args, limit)
+
+	var rows []exportJobRow
+	if err := r.db.Select(&rows, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to list export jobs: %w", err)
+	}
+	jobs := make([]models.ExportJob, 0, len(rows))
+	for _, row := range rows {
+		job, err := row.job()
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, nil
+}
+
+// ClaimJob marks the oldest queued job running and returns it (nil if the
+// queue is empty). Workers on several API instances never claim the same job.
+func (r *ExportRepository) ClaimJob(ctx context.Context) (*models.ExportJob, error) {
+	var row exportJobRow
+	err := r.db.GetContext(ctx, &row, `
+		UPDATE racing.export_jobs SET status = 'running', started_at = now()
+		WHERE job_id = (
+			SELECT job_id FROM racing.export_jobs
+			WHERE status = 'queued'
+			ORDER BY job_id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+exportJobColumns)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim export job: %w", err)
+	}
+	return row.job()
+}
+
+// CompleteJob records a finished job's file
+func (r *ExportRepository) CompleteJob(jobID, rows, bytes int64, path string, expires time.Time) error {
+	_, err := r.db.Exec(`
+		UPDATE racing.export_jobs
+		SET status = 'completed', rows = $2, bytes = $3, file_path = $4, completed_at = now(), expires_at = $5
+		WHERE job_id = $1
+	`, jobID, rows, bytes, path, expires)
+	if err != nil {
+		return fmt.Errorf("failed to complete export job: %w", err)
+	}
+	return nil
+}
+
+// FailJob records why a job failed
+func (r *ExportRepository) FailJob(jobID, rows int64, reason string) error {
+	_, err := r.db.Exec(`
+		UPDATE racing.export_jobs
+		SET status = 'failed', rows = $2, error = $3, completed_at = now()
+		WHERE job_id = $1
+	`, jobID, rows, reason)
+	if err != nil {
+		return fmt.Errorf("failed to fail export job: %w", err)
+	}
+	return nil
+}
+
+// RequeueJob puts a job interrupted by shutdown back in the queue
+func (r *ExportRepository) RequeueJob(jobID int64) error {
+	_, err := r.db.Exec(`
+		UPDATE racing.export_jobs SET status = 'queued', started_at = NULL, rows = 0
+		WHERE job_id = $1 AND status = 'running'
+	`, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to requeue export job: %w", err)
+	}
+	return nil
+}
+
+// RequeueStale puts running jobs started before cutoff back in the queue
+// (their worker died without finishing or requeueing them)
+func (r *ExportRepository) RequeueStale(cutoff time.Time) (int64, error) {
+	res, err := r.db.Exec(`
+		UPDATE racing.export_jobs SET status = 'queued', started_at = NULL, rows = 0
+		WHERE status = 'running' AND started_at < $1
+	`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue stale export jobs: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// ExpireJobs marks completed jobs past their expiry expired and returns their
+// files for deletion
+func (r *ExportRepository) ExpireJobs(now time.Time) ([]string, error) {
+	var paths []string
+	err := r.db.Select(&paths, `
+		UPDATE racing.export_jobs SET status = 'expired'
+		WHERE status = 'completed' AND expires_at < $1
+		RETURNING COALESCE(file_path, '')
+	`, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire export jobs: %w", err)
+	}
+	return paths, nil
+}
@@ -0,0 +1,382 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"giddyup/api/internal/database"
+	"giddyup/api/internal/matching"
+	"giddyup/api/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// maxBeaten caps beaten distances: past it (a distance, tailed off) lengths
+// say nothing about ability
+const maxBeaten = 30.0
+
+// raceH2HYears is how far back a race's h2h looks without date_from, and
+// maxSharedRaces caps the races read (the latest kept) whatever the range
+const (
+	raceH2HYears   = 3
+	maxSharedRaces = 1000
+)
+
+type H2HRepository struct {
+	db *database.DB
+}
+
+func NewH2HRepository(db *database.DB) *H2HRepository {
+	return &H2HRepository{db: db}
+}
+
+// h2hRow is one runner of a settled race a compared horse ran in
+type h2hRow struct {
+	models.H2HRace
+	models.H2HRun
+	HorseName string `db:"horse_name"`
+}
+
+// CompareHorses compares every pair of horses (in the order given) head to
+// head and through common opponents. It returns ErrHorseNotFound if any
+// horse doesn't exist.
+func (r *H2HRepository) CompareHorses(horseIDs []int64, params models.H2HParams) (*models.H2HComparison, error) {
+	found := []models.Horse{}
+	query := `SELECT horse_id, horse_name FROM racing.horses WHERE horse_id = ANY($1)`
+	if err := r.db.Select(&found, query, pq.Array(horseIDs)); err != nil {
+		return nil, fmt.Errorf("failed to get horses: %w", err)
+	}
+	byID := make(map[int64]models.Horse, len(found))
+	for _, h := range found {
+		byID[h.HorseID] = h
+	}
+	horses := make([]models.Horse, 0, len(horseIDs))
+	for _, id := range horseIDs {
+		h, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: %d", ErrHorseNotFound, id)
+		}
+		horses = append(horses, h)
+	}
+
+	rows, err := r.sharedRaces(horseIDs, params.DateFrom, nil)
+	if err != nil {
+		return nil, err
+	}
+	return compareHorses(horses, rows, params.Opponents), nil
+}
+
+// GetRaceH2H compares every pair in a race's field (in racecard order) on
+// their form before the race. It returns ErrRaceNotFound if there's no such
+// race.
+func (r *H2HRepository) GetRaceH2H(raceID int64, params models.H2HParams) (*models.H2HComparison, error) {
+	var raceDate string
+	err := r.db.Get(&raceDate, `SELECT race_date::text FROM racing.races WHERE race_id = $1`, raceID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRaceNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get race: %w", err)
+	}
+
+	horses := []models.Horse{}
+	err = r.db.Select(&horses, `
+		SELECT h.horse_id, h.horse_name
+		FROM racing.runners ru
+		JOIN racing.horses h ON h.horse_id = ru.horse_id
+		WHERE ru.race_id = $1 AND ru.race_date = $2
+		ORDER BY ru.num NULLS LAST, h.horse_name
+	`, raceID, raceDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get race field: %w", err)
+	}
+	horseIDs := make([]int64, len(horses))
+	for i, h := range horses {
+		horseIDs[i] = h.HorseID
+	}
+
+	if params.DateFrom == nil {
+		if d, err := time.Parse("2006-01-02", raceDate); err == nil {
+			from := d.AddDate(-raceH2HYears, 0, 0).Format("2006-01-02")
+			params.DateFrom = &from
+		}
+	}
+
+	rows, err := r.sharedRaces(horseIDs, params.DateFrom, &raceDate)
+	if err != nil {
+		return nil, err
+	}
+	comparison := compareHorses(horses, rows, params.Opponents)
+	comparison.RaceID = &raceID
+	return comparison, nil
+}
+
+// sharedRaces returns every runner of the settled races any of the horses
+// ran in (on or after dateFrom and before `before`, either optional), latest
+// race first and at most maxSharedRaces races
+func (r *H2HRepository) sharedRaces(horseIDs []int64, dateFrom, before *string) ([]h2hRow, error) {
+	var rows []h2hRow
+	err := r.db.Select(&rows, `
+		WITH raced AS (
+			SELECT DISTINCT rb.race_id, rb.race_date
+			FROM mv_runner_base rb
+			WHERE rb.horse_id = ANY($1)
+				AND ($2::date IS NULL OR rb.race_date >= $2::date)
+				AND ($3::date IS NULL OR rb.race_date < $3::date)
+			ORDER BY rb.race_date DESC, rb.race_id DESC
+			LIMIT $4
+		)
+		SELECT
+			ra.race_id, ra.race_date::text AS race_date, c.course_name, ra.race_name, ra.race_type, ra.going, ra.dist_f,
+			ru.runner_id, ru.horse_id, COALESCE(h.horse_name, '') AS horse_name,
+			ru.pos_num, ru.pos_raw, ru.ovr_btn, ru.lbs, ru."or", ru.rpr
+		FROM raced
+		JOIN racing.races ra ON ra.race_id = raced.race_id AND ra.race_date = raced.race_date
+		JOIN racing.runners ru ON ru.race_id = raced.race_id AND ru.race_date = raced.race_date
+		LEFT JOIN racing.courses c ON c.course_id = ra.course_id
+		LEFT JOIN racing.horses h ON h.horse_id = ru.horse_id
+		WHERE ra.prelim = false AND ru.horse_id IS NOT NULL
+		ORDER BY ra.race_date DESC, ra.race_id DESC
+	`, pq.Array(horseIDs), dateFrom, before, maxSharedRaces)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shared races: %w", err)
+	}
+	return rows, nil
+}
+
+// h2hRaceRuns is a race and its runners by horse
+type h2hRaceRuns struct {
+	race models.H2HRace
+	runs map[int64]h2hRow
+}
+
+// opponentForm is how one horse fared against one opponent
+type opponentForm struct {
+	meetings int
+	margins  []float64 // Adjusted, where both had a beaten distance
+	last     string
+}
+
+func compareHorses(horses []models.Horse, rows []h2hRow, opponents int) *models.H2HComparison {
+	// Rows come latest race first; keep that order
+	var races []*h2hRaceRuns
+	byRace := make(map[int64]*h2hRaceRuns)
+	names := make(map[int64]string)
+	for _, row := range rows {
+		rr, ok := byRace[row.RaceID]
+		if !ok {
+			rr = &h2hRaceRuns{race: row.H2HRace, runs: make(map[int64]h2hRow)}
+			byRace[row.RaceID] = rr
+			races = append(races, rr)
+		}
+		rr.runs[row.HorseID] = row
+		names[row.HorseID] = row.HorseName
+	}
+
+	// Each compared horse's form against everything it met
+	form := make(map[int64]map[int64]*opponentForm, len(horses))
+	for _, h := range horses {
+		form[h.HorseID] = make(map[int64]*opponentForm)
+	}
+	for _, rr := range races {
+		for id, vs := range form {
+			mine, ok := rr.runs[id]
+			if !ok {
+				continue
+			}
+			for oppID, theirs := range rr.runs {
+				if oppID == id {
+					continue
+				}
+				f := vs[oppID]
+				if f == nil {
+					f = &opponentForm{last: rr.race.RaceDate}
+					vs[oppID] = f
+				}
+				f.meetings++
+				if m := meet(rr.race, mine, theirs); m.AdjustedMargin != nil {
+					f.margins = append(f.margins, *m.AdjustedMargin)
+				}
+			}
+		}
+	}
+
+	out := &models.H2HComparison{Horses: horses, Pairs: []models.H2HPair{}}
+	for i, a := range horses {
+		for _, b := range horses[i+1:] {
+			pair := models.H2HPair{A: a, B: b, Meetings: []models.H2HMeeting{}}
+			var margins, adjusted []float64
+			for _, rr := range races {
+				ra, okA := rr.runs[a.HorseID]
+				rb, okB := rr.runs[b.HorseID]
+				if !okA || !okB {
+					continue
+				}
+				m := meet(rr.race, ra, rb)
+				pair.Meetings = append(pair.Meetings, m)
+				switch ahead(ra, rb) {
+				case 1:
+					pair.Summary.AAhead++
+				case -1:
+					pair.Summary.BAhead++
+				}
+				if m.Margin != nil {
+					margins = append(margins, *m.Margin)
+					adjusted = append(adjusted, *m.AdjustedMargin)
+				}
+			}
+			pair.Summary.Meetings = len(pair.Meetings)
+			pair.Summary.AvgMargin = mean(margins)
+			pair.Summary.AvgAdjustedMargin = mean(adjusted)
+			pair.Collateral = collateral(a.HorseID, b.HorseID, form, names, opponents)
+			out.Pairs = append(out.Pairs, pair)
+		}
+	}
+	return out
+}
+
+// collateral compares a and b through the opponents both have margins against
+func collateral(a, b int64, form map[int64]map[int64]*opponentForm, names map[int64]string, limit int) models.H2HCollateral {
+	var opps []models.H2HOpponent
+	for oppID, fa := range form[a] {
+		fb, ok := form[b][oppID]
+		if !ok || oppID == b || len(fa.margins) == 0 || len(fb.margins) == 0 {
+			continue
+		}
+		am, bm := *mean(fa.margins), *mean(fb.margins)
+		opps = append(opps, models.H2HOpponent{
+			HorseID:       oppID,
+			HorseName:     names[oppID],
+			AMeetings:     fa.meetings,
+			BMeetings:     fb.meetings,
+			AMargin:       am,
+			BMargin:       bm,
+			ImpliedMargin: round2(am - bm),
+			LastMetOn:     max(fa.last, fb.last),
+		})
+	}
+	sort.Slice(opps, func(i, j int) bool {
+		if opps[i].LastMetOn != opps[j].LastMetOn {
+			return opps[i].LastMetOn > opps[j].LastMetOn
+		}
+		return opps[i].HorseID < opps[j].HorseID
+	})
+
+	implied := make([]float64, len(opps))
+	for i, o := range opps {
+		implied[i] = o.ImpliedMargin
+	}
+	c := models.H2HCollateral{
+		Opponents:           len(opps),
+		AvgImpliedMargin:    mean(implied),
+		MedianImpliedMargin: median(implied),
+		CommonOpponents:     opps,
+	}
+	if len(c.CommonOpponents) > limit {
+		c.CommonOpponents = c.CommonOpponents[:limit]
+	}
+	if c.CommonOpponents == nil {
+		c.CommonOpponents = []models.H2HOpponent{}
+	}
+	return c
+}
+
+// meet puts two runners of a race side by side. The margin is how far a
+// finished ahead of b; the adjusted margin credits the weight a carried
+// over b at the race's pounds per length.
+func meet(race models.H2HRace, a, b h2hRow) models.H2HMeeting {
+	m := models.H2HMeeting{H2HRace: race, A: a.H2HRun, B: b.H2HRun}
+	la, okA := beaten(a.H2HRun)
+	lb, okB := beaten(b.H2HRun)
+	if !okA || !okB {
+		return m
+	}
+	margin := lb - la
+	adjusted := margin
+	if a.Lbs != nil && b.Lbs != nil {
+		adjusted += float64(*a.Lbs-*b.Lbs) / lbsPerLength(race)
+	}
+	margin, adjusted = round2(margin), round2(adjusted)
+	m.Margin, m.AdjustedMargin = &margin, &adjusted
+	return m
+}
+
+// beaten is how far a finisher was behind the winner, capped at maxBeaten
+func beaten(run models.H2HRun) (float64, bool) {
+	switch {
+	case run.PosNum == nil:
+		return 0, false
+	case *run.PosNum == 1:
+		return 0, true
+	case run.OvrBTN == nil:
+		return 0, false
+	}
+	return math.Min(*run.OvrBTN, maxBeaten), true
+}
+
+// ahead is 1 if a finished in front of b, -1 if behind, 0 if neither
+// finished (or they dead-heated). A finisher beats a non-finisher.
+func ahead(a, b h2hRow) int {
+	switch {
+	case a.PosNum != nil && b.PosNum != nil:
+		if *a.PosNum < *b.PosNum {
+			return 1
+		}
+		if *a.PosNum > *b.PosNum {
+			return -1
+		}
+	case a.PosNum != nil:
+		return 1
+	case b.PosNum != nil:
+		return -1
+	}
+	return 0
+}
+
+// lbsPerLength is the usual scale: about 3lb a length at 5f down to 1lb at
+// two miles and over jumps. Jumps races are told by their type ("Hurdle",
+// "Handicap Chase", "NH Flat"); flat handicaps are stored as "Handicap".
+func lbsPerLength(race models.H2HRace) float64 {
+	if matching.RaceTypeFromName(race.RaceType) != "" {
+		return 1
+	}
+	if race.DistF == nil || *race.DistF <= 0 {
+		return 2
+	}
+	return math.Max(1, math.Min(3, 15 / *race.DistF))
+}
+
+func mean(xs []float64) *float64 {
+	if len(xs) == 0 {
+		return nil
+	}
+	sum := 0.0
+	for _, x := range xs {
+		sum += x
+	}
+	m := round2(sum / float64(len(xs)))
+	return &m
+}
+
+func median(xs []float64) *float64 {
+	if len(xs) == 0 {
+		return nil
+	}
+	sorted := append([]float64(nil), xs...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	m := sorted[mid]
+	if len(sorted)%2 == 0 {
+		m = (sorted[mid-1] + sorted[mid]) / 2
+	}
+	m = round2(m)
+	return &m
+}
+
+func round2(x float64) float64 {
+	return math.Round(x*100) / 100
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"testing"
+
+	"giddyup/api/internal/models"
+)
+
+func TestLbsPerLength(t *testing.T) {
+	cases := []struct {
+		raceType string
+		distF    float64 // 0 unknown
+		want     float64
+	}{
+		{"Flat", 5, 3},
+		{"Handicap", 5, 3}, // Flat handicaps are stored as "Handicap"
+		{"Handicap", 10, 1.5},
+		{"Flat", 16, 1},
+		{"Flat", 0, 2},
+		{"Hurdle", 16, 1},
+		{"Handicap Hurdle", 16, 1},
+		{"Chase", 20, 1},
+		{"Handicap Chase", 24, 1},
+		{"NH Flat", 16, 1},
+	}
+	for _, tc := range cases {
+		race := models.H2HRace{RaceType: tc.raceType}
+		if tc.distF > 0 {
+			race.DistF = &tc.distF
+		}
+		if got := lbsPerLength(race); got != tc.want {
+			t.Errorf("lbsPerLength(%s, %v) = %v, want %v", tc.raceType, tc.distF, got, tc.want)
+		}
+	}
+}
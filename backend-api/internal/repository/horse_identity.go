@@ -0,0 +1,364 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"giddyup/api/internal/database"
+	"giddyup/api/internal/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrHorseNotFound is returned when a merge/split names a horse that doesn't exist
+	ErrHorseNotFound = errors.New("horse not found")
+	// ErrNothingToSplit is returned when a split selects no runs of the horse
+	ErrNothingToSplit = errors.New("no runs selected for split")
+)
+
+type HorseIdentityRepository struct {
+	db *database.DB
+}
+
+func NewHorseIdentityRepository(db *database.DB) *HorseIdentityRepository {
+	return &HorseIdentityRepository{db: db}
+}
+
+const horseIdentityColumns = `
+	horse_id, horse_name, disambig, sl_horse_id, bf_selection_id, foaled_year,
+	suffix, sire, dam, updated_at::text
+`
+
+// GetIdentity returns a horse's identity attributes, aliases, runs per implied
+// foaling year and its decision log
+func (r *HorseIdentityRepository) GetIdentity(horseID int64) (*models.HorseIdentity, error) {
+	var identity models.HorseIdentity
+	err := r.db.Get(&identity, `SELECT`+horseIdentityColumns+`FROM racing.horses WHERE horse_id = $1`, horseID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrHorseNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get horse identity: %w", err)
+	}
+
+	identity.Aliases = []string{}
+	if err := r.db.Select(&identity.Aliases, `
+		SELECT alias FROM racing.horse_alias WHERE horse_id = $1 ORDER BY alias
+	`, horseID); err != nil {
+		return nil, fmt.Errorf("failed to get horse aliases: %w", err)
+	}
+
+	identity.FoaledYears = []models.FoaledYearRuns{}
+	if err := r.db.Select(&identity.FoaledYears, `
+		SELECT extract(year FROM race_date)::int - age AS foaled_year,
+		       count(*) AS runs,
+		       min(race_date)::text AS first_run,
+		       max(race_date)::text AS last_run
+		FROM racing.runners
+		WHERE horse_id = $1 AND age > 0
+		GROUP BY 1
+		ORDER BY 1
+	`, horseID); err != nil {
+		return nil, fmt.Errorf("failed to get horse foaling years: %w", err)
+	}
+
+	id := horseID
+	identity.Log, err = r.GetLog(models.HorseIdentityLogFilters{HorseID: &id, Limit: 50})
+	if err != nil {
+		return nil, err
+	}
+
+	return &identity, nil
+}
+
+// GetConflicts lists horses whose runs imply foaling years more than a year apart
+func (r *HorseIdentityRepository) GetConflicts(limit, offset int) ([]models.HorseIdentityConflict, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	conflicts := []models.HorseIdentityConflict{}
+	if err := r.db.Select(&conflicts, `
+		SELECT horse_id, horse_name, min_foaled_year, max_foaled_year, runs
+		FROM racing.horse_identity_conflicts
+		ORDER BY runs DESC, horse_id
+		LIMIT $1 OFFSET $2
+	`, limit, offset); err != nil {
+		return nil, fmt.Errorf("failed to get horse identity conflicts: %w", err)
+	}
+	return conflicts, nil
+}
+
+// GetLog returns identity decisions, newest first
+func (r *HorseIdentityRepository) GetLog(filters models.HorseIdentityLogFilters) ([]models.HorseIdentityLog, error) {
+	query := `
+		SELECT log_id, horse_id, other_horse_id, action, source, detail, note, created_at::text
+		FROM racing.horse_identity_log
+		WHERE 1=1
+	`
+
+	args := []interface{}{}
+	argCount := 0
+
+	if filters.HorseID != nil {
+		argCount++
+		query += fmt.Sprintf(" AND (horse_id = $%d OR other_horse_id = $%d)", argCount, argCount)
+		args = append(args, *filters.HorseID)
+	}
+
+	if filters.Action != nil {
+		argCount++
+		query += fmt.Sprintf(" AND action = $%d", argCount)
+		args = append(args, *filters.Action)
+	}
+
+	if filters.Source != nil {
+		argCount++
+		query += fmt.Sprintf(" AND source = $%d", argCount)
+		args = append(args, *filters.Source)
+	}
+
+	query += " ORDER BY created_at DESC, log_id DESC"
+
+	limit := 200
+	if filters.Limit > 0 {
+		limit = filters.Limit
+	}
+	argCount++
+	query += fmt.Sprintf(" LIMIT $%d", argCount)
+	args = append(args, limit)
+
+	if filters.Offset > 0 {
+		argCount++
+		query += fmt.Sprintf(" OFFSET $%d", argCount)
+		args = append(args, filters.Offset)
+	}
+
+	entries := []models.HorseIdentityLog{}
+	if err := r.db.Select(&entries, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get horse identity log: %w", err)
+	}
+	return entries, nil
+}
+
+// Merge moves every run, entry and alias of source onto target, keeps source's
+// name as an alias, fills target's missing attributes from source and deletes source
+func (r *HorseIdentityRepository) Merge(req models.HorseMergeRequest) (*models.HorseIdentity, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var source, target models.HorseIdentity
+	for _, h := range []struct {
+		id   int64
+		dest *models.HorseIdentity
+	}{{req.SourceID, &source}, {req.TargetID, &target}} {
+		err := tx.Get(h.dest, `SELECT`+horseIdentityColumns+`FROM racing.horses WHERE horse_id = $1 FOR UPDATE`, h.id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrHorseNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load horse %d: %w", h.id, err)
+		}
+	}
+
+	res, err := tx.Exec(`UPDATE racing.runners SET horse_id = $2 WHERE horse_id = $1`, source.HorseID, target.HorseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to move runners: %w", err)
+	}
+	runnersMoved, _ := res.RowsAffected()
+
+	// Entries: drop source rows that would duplicate target's entry in the same race
+	if _, err := tx.Exec(`
+		DELETE FROM racing.entries e
+		WHERE e.horse_id = $1
+		  AND EXISTS (SELECT 1 FROM racing.entries t WHERE t.sl_race_id = e.sl_race_id AND t.horse_id = $2)
+	`, source.HorseID, target.HorseID); err != nil {
+		return nil, fmt.Errorf("failed to drop duplicate entries: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE racing.entries SET horse_id = $2 WHERE horse_id = $1`, source.HorseID, target.HorseID); err != nil {
+		return nil, fmt.Errorf("failed to move entries: %w", err)
+	}
+
+	// Aliases: source's name and its aliases now point at target
+	if _, err := tx.Exec(`
+		INSERT INTO racing.horse_alias (horse_id, alias)
+		SELECT $2::bigint, alias FROM racing.horse_alias WHERE horse_id = $1
+		UNION
+		SELECT $2::bigint, $3::text
+		ON CONFLICT DO NOTHING
+	`, source.HorseID, target.HorseID, source.HorseName); err != nil {
+		return nil, fmt.Errorf("failed to move aliases: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM racing.horses WHERE horse_id = $1`, source.HorseID); err != nil {
+		return nil, fmt.Errorf("failed to delete merged horse: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE racing.horses SET
+			sl_horse_id     = COALESCE(sl_horse_id, $2),
+			bf_selection_id = COALESCE(bf_selection_id, $3),
+			foaled_year     = COALESCE(foaled_year, $4),
+			suffix          = COALESCE(suffix, $5),
+			sire            = COALESCE(sire, $6),
+			dam             = COALESCE(dam, $7),
+			updated_at      = now()
+		WHERE horse_id = $1
+	`, target.HorseID, source.SLHorseID, source.BFSelectionID, source.FoaledYear,
+		source.Suffix, source.Sire, source.Dam); err != nil {
+		return nil, fmt.Errorf("failed to update merged horse: %w", err)
+	}
+
+	if err := logIdentity(tx, target.HorseID, &source.HorseID, "merge", map[string]interface{}{
+		"source_name":   source.HorseName,
+		"runners_moved": runnersMoved,
+	}, req.Note); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit merge: %w", err)
+	}
+	return r.GetIdentity(target.HorseID)
+}
+
+// Split moves the selected runs of a conflated horse to a new horse
+func (r *HorseIdentityRepository) Split(horseID int64, req models.HorseSplitRequest) (*models.HorseIdentity, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var original models.HorseIdentity
+	err = tx.Get(&original, `SELECT`+horseIdentityColumns+`FROM racing.horses WHERE horse_id = $1 FOR UPDATE`, horseID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrHorseNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load horse: %w", err)
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = original.HorseName
+	}
+	disambig := strings.TrimSpace(req.Disambig)
+	if disambig == "" {
+		disambig = splitDisambig(horseID, req)
+	}
+
+	// New horse (bump the tag if the name already has one like it)
+	var newID int64
+	for attempt := 1; ; attempt++ {
+		tag := disambig
+		if attempt > 1 {
+			tag = fmt.Sprintf("%s-%d", disambig, attempt)
+		}
+		err = tx.QueryRow(`
+			INSERT INTO racing.horses (horse_name, disambig, sl_horse_id, foaled_year, suffix, updated_at)
+			VALUES ($1, $2, $3, $4, NULLIF(upper($5), ''), now())
+			ON CONFLICT ON CONSTRAINT horses_uniq DO NOTHING
+			RETURNING horse_id
+		`, name, tag, req.SLHorseID, req.FoaledYear, req.Suffix).Scan(&newID)
+		if errors.Is(err, sql.ErrNoRows) && attempt < 20 {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to create split horse: %w", err)
+		}
+		disambig = tag
+		break
+	}
+
+	// Move the selected runs
+	var res sql.Result
+	switch {
+	case len(req.RunnerIDs) > 0:
+		res, err = tx.Exec(`
+			UPDATE racing.runners SET horse_id = $2
+			WHERE horse_id = $1 AND runner_id = ANY($3)
+		`, horseID, newID, pq.Array(req.RunnerIDs))
+	case req.FoaledYear != nil:
+		res, err = tx.Exec(`
+			UPDATE racing.runners SET horse_id = $2
+			WHERE horse_id = $1 AND age > 0 AND extract(year FROM race_date)::int - age = $3
+		`, horseID, newID, *req.FoaledYear)
+	default:
+		return nil, ErrNothingToSplit
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to move runners: %w", err)
+	}
+	moved, _ := res.RowsAffected()
+	if moved == 0 {
+		return nil, ErrNothingToSplit
+	}
+
+	// The original keeps the other generation's foaling year
+	if req.FoaledYear != nil && original.FoaledYear != nil && *original.FoaledYear == *req.FoaledYear {
+		if _, err := tx.Exec(`
+			UPDATE racing.horses SET foaled_year = NULL, updated_at = now() WHERE horse_id = $1
+		`, horseID); err != nil {
+			return nil, fmt.Errorf("failed to reset foaling year: %w", err)
+		}
+	}
+
+	if err := logIdentity(tx, newID, &horseID, "split", map[string]interface{}{
+		"name":          name,
+		"disambig":      disambig,
+		"runners_moved": moved,
+		"runner_ids":    req.RunnerIDs,
+		"foaled_year":   req.FoaledYear,
+	}, req.Note); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit split: %w", err)
+	}
+	return r.GetIdentity(newID)
+}
+
+// splitDisambig builds the default tag for a split-off horse
+func splitDisambig(horseID int64, req models.HorseSplitRequest) string {
+	suffix := strings.ToUpper(strings.TrimSpace(req.Suffix))
+	switch {
+	case req.SLHorseID != nil:
+		return fmt.Sprintf("sl:%d", *req.SLHorseID)
+	case suffix != "" && req.FoaledYear != nil:
+		return fmt.Sprintf("%s-%d", suffix, *req.FoaledYear)
+	case req.FoaledYear != nil:
+		return strconv.Itoa(*req.FoaledYear)
+	case suffix != "":
+		return suffix
+	}
+	return fmt.Sprintf("split-%d", horseID)
+}
+
+// logIdentity records an admin decision in racing.horse_identity_log
+func logIdentity(tx *sqlx.Tx, horseID int64, otherID *int64, action string, detail map[string]interface{}, note string) error {
+	payload, err := json.Marshal(detail)
+	if err != nil {
+		return err
+	}
+	var noteArg interface{}
+	if note != "" {
+		noteArg = note
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO racing.horse_identity_log (horse_id, other_horse_id, action, source, detail, note)
+		VALUES ($1, $2, $3, 'admin', $4, $5)
+	`, horseID, otherID, action, string(payload), noteArg); err != nil {
+		return fmt.Errorf("failed to write horse identity log: %w", err)
+	}
+	return nil
+}
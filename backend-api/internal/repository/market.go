@@ -15,11 +15,22 @@ func NewMarketRepository(db *database.DB) *MarketRepository {
 	return &MarketRepository{db: db}
 }
 
-// GetMarketMovers returns steamers and drifters
-func (r *MarketRepository) GetMarketMovers(date string, minMove float64, moveType string) ([]models.MarketMover, error) {
+// moveExpr is the size of a runner's move from morning price to BSP
+const moveExpr = "ABS((ru.win_ppmax - ru.win_bsp) / NULLIF(ru.win_ppmax, 0))::float8"
+
+// moverCursor is the sort key of a market mover
+type moverCursor struct {
+	Move     float64 `json:"m"`
+	RunnerID int64   `json:"i"`
+}
+
+// GetMarketMovers returns steamers and drifters, biggest move first. It
+// returns one page and the cursor of the next ("" on the last page).
+func (r *MarketRepository) GetMarketMovers(date string, minMove float64, moveType string, limit int, cur string) ([]models.MarketMover, string, error) {
 	query := `
 		SELECT 
 			r.race_id,
+			ru.runner_id,
 			r.race_date,
 			r.off_time,
 			c.course_name,
@@ -29,7 +40,8 @@ func (r *MarketRepository) GetMarketMovers(date string, minMove float64, moveTyp
 		ru.win_bsp AS bsp,
 		ROUND((100.0 * (ru.win_ppmax - ru.win_bsp) / NULLIF(ru.win_ppmax, 0))::numeric, 2) AS move_pct,
 		ru.win_flag,
-		ru.pos_num
+		ru.pos_num,
+		` + moveExpr + ` AS move_key
 		FROM racing.runners ru
 		JOIN racing.races r ON r.race_id = ru.race_id
 		JOIN racing.courses c ON c.course_id = r.course_id
@@ -42,6 +54,7 @@ func (r *MarketRepository) GetMarketMovers(date string, minMove float64, moveTyp
 	`
 
 	args := []interface{}{date, minMove}
+	filters := []interface{}{date, minMove, moveType} // What a cursor is bound to
 
 	if moveType == "steamer" {
 		query += " AND ru.win_bsp < ru.win_ppmax"
@@ -49,14 +62,37 @@ func (r *MarketRepository) GetMarketMovers(date string, minMove float64, moveTyp
 		query += " AND ru.win_bsp > ru.win_ppmax"
 	}
 
-	query += " ORDER BY ABS((ru.win_ppmax - ru.win_bsp) / NULLIF(ru.win_ppmax, 0)) DESC LIMIT 100"
+	if cur != "" {
+		var after moverCursor
+		if err := decodeCursor(cur, "movers", filters, &after); err != nil {
+			return nil, "", err
+		}
+		query += " AND (" + moveExpr + " < $3 OR (" + moveExpr + " = $3 AND ru.runner_id > $4))"
+		args = append(args, after.Move, after.RunnerID)
+	}
+
+	query += fmt.Sprintf(" ORDER BY %s DESC, ru.runner_id LIMIT $%d", moveExpr, len(args)+1)
+	args = append(args, limit+1)
 
-	var movers []models.MarketMover
-	if err := r.db.Select(&movers, query, args...); err != nil {
-		return nil, fmt.Errorf("failed to get market movers: %w", err)
+	var rows []struct {
+		models.MarketMover
+		MoveKey float64 `db:"move_key"`
+	}
+	if err := r.db.Select(&rows, query, args...); err != nil {
+		return nil, "", fmt.Errorf("failed to get market movers: %w", err)
 	}
 
-	return movers, nil
+	next := ""
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[limit-1]
+		next = encodeCursor("movers", filters, moverCursor{Move: last.MoveKey, RunnerID: last.RunnerID})
+	}
+	movers := make([]models.MarketMover, len(rows))
+	for i, row := range rows {
+		movers[i] = row.MarketMover
+	}
+	return movers, next, nil
 }
 
 // GetWinCalibration returns BSP calibration by price bins
@@ -202,3 +238,68 @@ func (r *MarketRepository) GetBookVsExchange(dateFrom, dateTo string) ([]models.
 
 	return results, nil
 }
+
+// GetBookmakerVsExchange compares each bookmaker's shows with the exchange at the same moment.
+// Shows are matched to the latest live back price at or before the show (within maxLagSecs);
+// SPs are compared with BSP.
+func (r *MarketRepository) GetBookmakerVsExchange(dateFrom, dateTo string, bookmaker *string, maxLagSecs int) ([]models.BookmakerVsExchange, error) {
+	if maxLagSecs <= 0 {
+		maxLagSecs = 300
+	}
+
+	query := `
+		WITH paired AS (
+			SELECT
+				bp.bookmaker,
+				CASE WHEN bp.show_type = 'sp' THEN 'sp' ELSE 'show' END AS show_type,
+				bp.dec AS book_price,
+				CASE WHEN bp.show_type = 'sp' THEN ru.win_bsp ELSE lp.back_price END AS exchange_price,
+				CASE WHEN bp.show_type = 'sp' THEN 0
+					ELSE EXTRACT(EPOCH FROM (bp.ts - lp.ts)) END AS lag_secs
+			FROM racing.bookmaker_prices bp
+			JOIN racing.runners ru ON ru.runner_id = bp.runner_id AND ru.race_date = bp.race_date
+			LEFT JOIN LATERAL (
+				SELECT l.back_price, l.ts
+				FROM racing.live_prices l
+				WHERE l.runner_id = bp.runner_id
+					AND l.ts <= bp.ts
+					AND l.ts >= bp.ts - make_interval(secs => $3)
+				ORDER BY l.ts DESC
+				LIMIT 1
+			) lp ON bp.show_type <> 'sp'
+			WHERE bp.race_date BETWEEN $1 AND $2
+	`
+
+	args := []interface{}{dateFrom, dateTo, maxLagSecs}
+	argCount := 3
+
+	if bookmaker != nil {
+		argCount++
+		query += fmt.Sprintf(" AND bp.bookmaker = $%d", argCount)
+		args = append(args, *bookmaker)
+	}
+
+	query += `
+		)
+		SELECT
+			bookmaker,
+			show_type,
+			COUNT(*) AS samples,
+			ROUND(AVG(book_price)::numeric, 2)::float8 AS avg_book_price,
+			ROUND(AVG(exchange_price)::numeric, 2)::float8 AS avg_exchange_price,
+			ROUND(AVG(book_price / exchange_price)::numeric, 4)::float8 AS avg_ratio,
+			ROUND((100.0 * COUNT(*) FILTER (WHERE book_price > exchange_price) / COUNT(*))::numeric, 1)::float8 AS pct_book_better,
+			ROUND(AVG(lag_secs)::numeric, 1)::float8 AS avg_lag_secs
+		FROM paired
+		WHERE exchange_price >= 1.01
+		GROUP BY bookmaker, show_type
+		ORDER BY bookmaker, show_type
+	`
+
+	results := []models.BookmakerVsExchange{}
+	if err := r.db.Select(&results, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get bookmaker vs exchange: %w", err)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"giddyup/api/internal/database"
+	"giddyup/api/internal/matching"
+	"giddyup/api/internal/models"
+)
+
+// ErrRaceNotFound is returned when an override names a race_id that doesn't exist
+var ErrRaceNotFound = errors.New("race not found")
+
+type MatchingRepository struct {
+	db *database.DB
+}
+
+func NewMatchingRepository(db *database.DB) *MatchingRepository {
+	return &MatchingRepository{db: db}
+}
+
+// GetOverrides returns all overrides, optionally for one race date
+func (r *MatchingRepository) GetOverrides(date *string) ([]models.MatchOverride, error) {
+	query := `
+		SELECT override_id, level, race_key, race_date::text, horse, bf_ref,
+		       action, note, created_at::text
+		FROM racing.match_overrides
+		WHERE 1=1
+	`
+	args := []interface{}{}
+	if date != nil {
+		query += " AND race_date = $1"
+		args = append(args, *date)
+	}
+	query += " ORDER BY race_date DESC NULLS LAST, race_key, level, horse"
+
+	overrides := []models.MatchOverride{}
+	if err := r.db.Select(&overrides, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get match overrides: %w", err)
+	}
+	return overrides, nil
+}
+
+// CreateOverride stores a pin/forbid (replacing the action of an identical pairing).
+// Pinning replaces any other pin for the same race (or horse) so there is only ever one.
+func (r *MatchingRepository) CreateOverride(req models.MatchOverrideRequest) (*models.MatchOverride, error) {
+	raceKey, raceDate := req.RaceKey, req.RaceDate
+	if req.RaceID != nil {
+		var race struct {
+			RaceKey  string `db:"race_key"`
+			RaceDate string `db:"race_date"`
+		}
+		err := r.db.Get(&race, `SELECT race_key, race_date::text AS race_date FROM racing.races WHERE race_id = $1`, *req.RaceID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRaceNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up race: %w", err)
+		}
+		raceKey, raceDate = race.RaceKey, race.RaceDate
+	}
+
+	// Horses are stored as the matcher compares them (see matching.NormalizeName),
+	// so "Galileo Gold (IRE)" and "galileo gold" are the same pairing
+	horse := ""
+	if req.Level == matching.LevelRunner {
+		horse = matching.NormalizeName(req.Horse)
+	}
+	bfRef := matching.NormalizeRef(req.Level, req.BFRef)
+
+	var date, note interface{}
+	if raceDate != "" {
+		date = raceDate
+	}
+	if req.Note != "" {
+		note = req.Note
+	}
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if req.Action == matching.ActionPin {
+		if _, err := tx.Exec(`
+			DELETE FROM racing.match_overrides
+			WHERE level = $1 AND race_key = $2 AND horse = $3 AND action = 'pin' AND bf_ref <> $4
+		`, req.Level, raceKey, horse, bfRef); err != nil {
+			return nil, fmt.Errorf("failed to replace existing pin: %w", err)
+		}
+	}
+
+	var override models.MatchOverride
+	err = tx.Get(&override, `
+		INSERT INTO racing.match_overrides (level, race_key, race_date, horse, bf_ref, action, note)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (level, race_key, horse, bf_ref) DO UPDATE SET
+			action = EXCLUDED.action,
+			race_date = COALESCE(EXCLUDED.race_date, racing.match_overrides.race_date),
+			note = COALESCE(EXCLUDED.note, racing.match_overrides.note),
+			created_at = now()
+		RETURNING override_id, level, race_key, race_date::text, horse, bf_ref,
+		          action, note, created_at::text
+	`, req.Level, raceKey, date, horse, bfRef, req.Action, note)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create match override: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit match override: %w", err)
+	}
+	return &override, nil
+}
+
+// DeleteOverride removes an override; returns false if it didn't exist
+func (r *MatchingRepository) DeleteOverride(id int64) (bool, error) {
+	res, err := r.db.Exec(`DELETE FROM racing.match_overrides WHERE override_id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete match override: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// GetAudit returns recorded match attempts, newest first
+func (r *MatchingRepository) GetAudit(filters models.MatchAuditFilters) ([]models.MatchAudit, error) {
+	query := `
+		SELECT audit_id, run_at::text, pipeline, level, race_date::text, race_key,
+		       subject, outcome, chosen_ref, score, runner_up, override, candidates
+		FROM racing.match_audit
+		WHERE 1=1
+	`
+
+	args := []interface{}{}
+	argCount := 0
+
+	if filters.Date != nil {
+		argCount++
+		query += fmt.Sprintf(" AND race_date = $%d", argCount)
+		args = append(args, *filters.Date)
+	}
+
+	if filters.RaceKey != nil {
+		argCount++
+		query += fmt.Sprintf(" AND race_key = $%d", argCount)
+		args = append(args, *filters.RaceKey)
+	}
+
+	if filters.Outcome != nil {
+		argCount++
+		query += fmt.Sprintf(" AND outcome = $%d", argCount)
+		args = append(args, *filters.Outcome)
+	}
+
+	if filters.Level != nil {
+		argCount++
+		query += fmt.Sprintf(" AND level = $%d", argCount)
+		args = append(args, *filters.Level)
+	}
+
+	if filters.Pipeline != nil {
+		argCount++
+		query += fmt.Sprintf(" AND pipeline = $%d", argCount)
+		args = append(args, *filters.Pipeline)
+	}
+
+	query += " ORDER BY run_at DESC, audit_id DESC"
+
+	limit := 200
+	if filters.Limit > 0 {
+		limit = filters.Limit
+	}
+	argCount++
+	query += fmt.Sprintf(" LIMIT $%d", argCount)
+	args = append(args, limit)
+
+	if filters.Offset > 0 {
+		argCount++
+		query += fmt.Sprintf(" OFFSET $%d", argCount)
+		args = append(args, filters.Offset)
+	}
+
+	audit := []models.MatchAudit{}
+	if err := r.db.Select(&audit, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get match audit: %w", err)
+	}
+	return audit, nil
+}
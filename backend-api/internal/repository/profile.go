@@ -5,6 +5,8 @@ import (
 
 	"giddyup/api/internal/database"
 	"giddyup/api/internal/models"
+
+	"github.com/lib/pq"
 )
 
 type ProfileRepository struct {
@@ -15,6 +17,97 @@ func NewProfileRepository(db *database.DB) *ProfileRepository {
 	return &ProfileRepository{db: db}
 }
 
+// runsColumns are the mv_runner_base columns GetRuns can filter on
+var runsColumns = map[string]string{
+	"horse":   "horse_id",
+	"trainer": "trainer_id",
+	"jockey":  "jockey_id",
+}
+
+// distanceBand is the SQL bucketing a distance in furlongs into the bands
+// distance splits are grouped by
+func distanceBand(col string) string {
+	return `CASE
+		WHEN ` + col + ` < 7 THEN '5-6f'
+		WHEN ` + col + ` < 9 THEN '7-8f'
+		WHEN ` + col + ` < 13 THEN '9-12f'
+		ELSE '13f+'
+	END`
+}
+
+// runCursor is the sort key of a run
+type runCursor struct {
+	Date     string `json:"d"`
+	RunnerID int64  `json:"i"`
+}
+
+// GetRuns returns a horse's, trainer's or jockey's runs, latest first. It
+// returns one page and the cursor of the next ("" on the last page).
+func (r *ProfileRepository) GetRuns(entity string, id int64, limit int, cur string) ([]models.FormEntry, string, error) {
+	column, ok := runsColumns[entity]
+	if !ok {
+		return nil, "", fmt.Errorf("unknown runs entity %q", entity)
+	}
+
+	// Days since run looks up the horse's previous run directly, so it does
+	// not depend on which rows are on the page
+	query := `
+		SELECT 
+			rb.runner_id,
+			rb.race_id,
+			rb.race_date,
+			h.horse_name,
+			c.course_name,
+			COALESCE(ra.race_name, '') AS race_name,
+			rb.race_type,
+			rb.going,
+			rb.dist_f,
+			rb.pos_num,
+			''::text AS pos_raw,
+			rb.btn,
+			rb."or",
+			rb.rpr,
+			rb.win_bsp,
+			rb.dec,
+			t.trainer_name,
+			j.jockey_name,
+			rb.race_date - (
+				SELECT MAX(prev.race_date) FROM mv_runner_base prev
+				WHERE prev.horse_id = rb.horse_id AND prev.race_date < rb.race_date
+			) AS dsr
+		FROM mv_runner_base rb
+		LEFT JOIN racing.races ra ON ra.race_id = rb.race_id AND ra.race_date = rb.race_date
+		LEFT JOIN racing.horses h ON h.horse_id = rb.horse_id
+		LEFT JOIN racing.courses c ON c.course_id = rb.course_id
+		LEFT JOIN racing.trainers t ON t.trainer_id = rb.trainer_id
+		LEFT JOIN racing.jockeys j ON j.jockey_id = rb.jockey_id
+		WHERE rb.` + column + ` = $1
+	`
+	args := []interface{}{id}
+
+	if cur != "" {
+		var after runCursor
+		if err := decodeCursor(cur, entity+"-runs", id, &after); err != nil {
+			return nil, "", err
+		}
+		query += " AND (rb.race_date, rb.runner_id) < ($2::date, $3)"
+		args = append(args, after.Date, after.RunnerID)
+	}
+
+	query += fmt.Sprintf(" ORDER BY rb.race_date DESC, rb.runner_id DESC LIMIT $%d", len(args)+1)
+	args = append(args, limit+1)
+
+	var runs []models.FormEntry
+	if err := r.db.Select(&runs, query, args...); err != nil {
+		return nil, "", fmt.Errorf("failed to get %s runs: %w", entity, err)
+	}
+
+	runs, next := nextPage(runs, limit, entity+"-runs", id, func(run models.FormEntry) interface{} {
+		return runCursor{Date: cursorDate(run.RaceDate), RunnerID: run.RunnerID}
+	})
+	return runs, next, nil
+}
+
 // GetHorseProfile returns complete horse profile with all statistics
 func (r *ProfileRepository) GetHorseProfile(horseID int64) (*models.HorseProfile, error) {
 	profile := &models.HorseProfile{}
@@ -46,6 +139,8 @@ func (r *ProfileRepository) GetHorseProfile(horseID int64) (*models.HorseProfile
 	// Get recent form - using mv_runner_base for faster query
 	formQuery := `
 		SELECT 
+			rb.runner_id,
+			rb.race_id,
 			rb.race_date,
 			c.course_name,
 			''::text AS race_name,
@@ -145,12 +240,7 @@ func (r *ProfileRepository) GetHorseGoingSplits(horseID int64) ([]models.StatsSp
 func (r *ProfileRepository) GetHorseDistanceSplits(horseID int64) ([]models.StatsSplit, error) {
 	query := `
 		SELECT 
-			CASE 
-				WHEN rb.dist_f < 7 THEN '5-6f'
-				WHEN rb.dist_f < 9 THEN '7-8f'
-				WHEN rb.dist_f < 13 THEN '9-12f'
-				ELSE '13f+'
-			END AS category,
+			` + distanceBand("rb.dist_f") + ` AS category,
 			COUNT(*) AS runs,
 			COUNT(*) FILTER (WHERE rb.win_flag) AS wins,
 			COUNT(*) FILTER (WHERE rb.pos_num <= 3) AS places,
@@ -287,12 +377,7 @@ func (r *ProfileRepository) GetTrainerProfile(trainerID int64) (*models.TrainerP
 	// Get distance splits
 	distSplitsQuery := `
 		SELECT 
-			CASE 
-				WHEN r.dist_f < 7 THEN '5-6f'
-				WHEN r.dist_f < 9 THEN '7-8f'
-				WHEN r.dist_f < 13 THEN '9-12f'
-				ELSE '13f+'
-			END AS category,
+			` + distanceBand("r.dist_f") + ` AS category,
 			COUNT(*) AS runs,
 			COUNT(*) FILTER (WHERE ru.win_flag) AS wins,
 			0 AS places,
@@ -423,3 +508,33 @@ func (r *ProfileRepository) GetJockeyProfile(jockeyID int64) (*models.JockeyProf
 
 	return profile, nil
 }
+
+// GetHorses returns horses by ID, in no particular order
+func (r *ProfileRepository) GetHorses(horseIDs []int64) ([]models.Horse, error) {
+	horses := []models.Horse{}
+	query := `SELECT horse_id, horse_name FROM racing.horses WHERE horse_id = ANY($1)`
+	if err := r.db.Select(&horses, query, pq.Array(horseIDs)); err != nil {
+		return nil, fmt.Errorf("failed to get horses: %w", err)
+	}
+	return horses, nil
+}
+
+// GetTrainers returns trainers by ID, in no particular order
+func (r *ProfileRepository) GetTrainers(trainerIDs []int64) ([]models.Trainer, error) {
+	trainers := []models.Trainer{}
+	query := `SELECT trainer_id, trainer_name FROM racing.trainers WHERE trainer_id = ANY($1)`
+	if err := r.db.Select(&trainers, query, pq.Array(trainerIDs)); err != nil {
+		return nil, fmt.Errorf("failed to get trainers: %w", err)
+	}
+	return trainers, nil
+}
+
+// GetJockeys returns jockeys by ID, in no particular order
+func (r *ProfileRepository) GetJockeys(jockeyIDs []int64) ([]models.Jockey, error) {
+	jockeys := []models.Jockey{}
+	query := `SELECT jockey_id, jockey_name FROM racing.jockeys WHERE jockey_id = ANY($1)`
+	if err := r.db.Select(&jockeys, query, pq.Array(jockeyIDs)); err != nil {
+		return nil, fmt.Errorf("failed to get jockeys: %w", err)
+	}
+	return jockeys, nil
+}
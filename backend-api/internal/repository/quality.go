@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"fmt"
+
+	"giddyup/api/internal/database"
+	"giddyup/api/internal/models"
+)
+
+type QualityRepository struct {
+	db *database.DB
+}
+
+func NewQualityRepository(db *database.DB) *QualityRepository {
+	return &QualityRepository{db: db}
+}
+
+// qualityDaysQuery summarises every date from $1 to $2: races and runners
+// loaded, and the open issues in racing.quarantine
+const qualityDaysQuery = `
+	WITH days AS (
+		SELECT generate_series($1::date, $2::date, interval '1 day')::date AS d
+	),
+	races AS (
+		SELECT race_date, COUNT(*) AS n FROM racing.races
+		WHERE race_date BETWEEN $1::date AND $2::date
+		GROUP BY race_date
+	),
+	runners AS (
+		SELECT race_date, COUNT(*) AS n FROM racing.runners
+		WHERE race_date BETWEEN $1::date AND $2::date
+		GROUP BY race_date
+	),
+	issues AS (
+		SELECT
+			race_date,
+			COUNT(*) FILTER (WHERE severity = 'error') AS errors,
+			COUNT(*) FILTER (WHERE severity = 'warning') AS warnings,
+			COUNT(*) FILTER (WHERE severity = 'info') AS info,
+			COUNT(*) FILTER (WHERE held AND scope = 'race') AS races_held,
+			COUNT(*) FILTER (WHERE held AND scope = 'runner') AS runners_held
+		FROM racing.quarantine
+		WHERE race_date BETWEEN $1::date AND $2::date AND resolved_at IS NULL
+		GROUP BY race_date
+	)
+	SELECT
+		days.d::text AS date,
+		COALESCE(races.n, 0) AS races_loaded,
+		COALESCE(runners.n, 0) AS runners_loaded,
+		COALESCE(issues.errors, 0) AS errors,
+		COALESCE(issues.warnings, 0) AS warnings,
+		COALESCE(issues.info, 0) AS info,
+		COALESCE(issues.races_held, 0) AS races_held,
+		COALESCE(issues.runners_held, 0) AS runners_held
+	FROM days
+	LEFT JOIN races ON races.race_date = days.d
+	LEFT JOIN runners ON runners.race_date = days.d
+	LEFT JOIN issues ON issues.race_date = days.d
+	ORDER BY days.d DESC
+`
+
+// GetDays returns the quality summary for each date from dateFrom to dateTo
+func (r *QualityRepository) GetDays(dateFrom, dateTo string) ([]models.QualityDay, error) {
+	days := []models.QualityDay{}
+	if err := r.db.Select(&days, qualityDaysQuery, dateFrom, dateTo); err != nil {
+		return nil, fmt.Errorf("failed to get quality summary: %w", err)
+	}
+	return days, nil
+}
+
+// GetDay returns a date's quality summary, its open issue counts by rule and
+// its issues
+func (r *QualityRepository) GetDay(date string, filters models.QualityIssueFilters) (*models.QualityDayDetail, error) {
+	detail := &models.QualityDayDetail{}
+	if err := r.db.Get(&detail.QualityDay, qualityDaysQuery, date, date); err != nil {
+		return nil, fmt.Errorf("failed to get quality summary: %w", err)
+	}
+
+	detail.ByRule = []models.QualityRuleCount{}
+	if err := r.db.Select(&detail.ByRule, `
+		SELECT rule, severity, COUNT(*) AS count
+		FROM racing.quarantine
+		WHERE race_date = $1 AND resolved_at IS NULL
+		GROUP BY rule, severity
+		ORDER BY CASE severity WHEN 'error' THEN 1 WHEN 'warning' THEN 2 ELSE 3 END, count DESC, rule
+	`, date); err != nil {
+		return nil, fmt.Errorf("failed to get quality rule counts: %w", err)
+	}
+
+	issues, err := r.GetIssues(date, filters)
+	if err != nil {
+		return nil, err
+	}
+	detail.Issues = issues
+	return detail, nil
+}
+
+// GetIssues lists a date's quarantine rows, errors first
+func (r *QualityRepository) GetIssues(date string, filters models.QualityIssueFilters) ([]models.QualityIssue, error) {
+	query := `
+		SELECT
+			id, rule, severity, scope, race_key, race_date::text, course, off_time,
+			runner_key, horse, reason, held, payload, source, run_id,
+			first_seen_at::text, last_seen_at::text, times_seen, resolved_at::text
+		FROM racing.quarantine
+		WHERE race_date = $1
+	`
+	args := []interface{}{date}
+	argCount := 1
+
+	if !filters.Resolved {
+		query += " AND resolved_at IS NULL"
+	}
+
+	if filters.Severity != nil {
+		argCount++
+		query += fmt.Sprintf(" AND severity = $%d", argCount)
+		args = append(args, *filters.Severity)
+	}
+
+	if filters.Rule != nil {
+		argCount++
+		query += fmt.Sprintf(" AND rule = $%d", argCount)
+		args = append(args, *filters.Rule)
+	}
+
+	if filters.Held != nil {
+		argCount++
+		query += fmt.Sprintf(" AND held = $%d", argCount)
+		args = append(args, *filters.Held)
+	}
+
+	query += " ORDER BY CASE severity WHEN 'error' THEN 1 WHEN 'warning' THEN 2 ELSE 3 END, off_time, race_key, runner_key, rule"
+
+	limit := 200
+	if filters.Limit > 0 {
+		limit = filters.Limit
+	}
+	argCount++
+	query += fmt.Sprintf(" LIMIT $%d", argCount)
+	args = append(args, limit)
+
+	if filters.Offset > 0 {
+		argCount++
+		query += fmt.Sprintf(" OFFSET $%d", argCount)
+		args = append(args, filters.Offset)
+	}
+
+	issues := []models.QualityIssue{}
+	if err := r.db.Select(&issues, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get quality issues: %w", err)
+	}
+	return issues, nil
+}
@@ -1,7 +1,10 @@
 package repository
 
 import (
+	"database/sql"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
 	"giddyup/api/internal/database"
@@ -18,71 +21,119 @@ func NewRaceRepository(db *database.DB) *RaceRepository {
 	return &RaceRepository{db: db}
 }
 
-// SearchRaces searches races with filters
-func (r *RaceRepository) SearchRaces(filters models.RaceFilters) ([]models.Race, error) {
+// raceCursor is the sort key of a race in search results
+type raceCursor struct {
+	Date    string `json:"d"`
+	OffTime string `json:"o"`
+	RaceID  int64  `json:"i"`
+}
+
+// SearchRaces searches races with filters, newest first. It returns one page
+// and the cursor of the next ("" on the last page).
+func (r *RaceRepository) SearchRaces(filters models.RaceFilters, view models.RaceView) ([]models.Race, string, error) {
 	if filters.Limit <= 0 {
 		filters.Limit = 100
 	}
 
-	query := `
-		SELECT 
-			r.race_id, r.race_key, r.race_date, r.region, r.course_id,
-			c.course_name, r.off_time::text, r.race_name, r.race_type, r.class,
-			r.pattern, r.rating_band, r.age_band, r.sex_rest,
-			r.dist_raw, r.dist_f, r.dist_m, r.going, r.surface, r.ran
-		FROM racing.races r
-		LEFT JOIN racing.courses c ON c.course_id = r.course_id
-		WHERE 1=1
-	`
+	cols, joins := selectView(raceColumns, view.RaceFields, raceKeyFields)
+	query := "SELECT " + cols + " FROM racing.races r\n" + joins + "\nWHERE 1=1"
 
-	args := []interface{}{}
-	argCount := 0
+	query, args := appendRaceFilters(query, nil, filters.RaceFilterSet)
+	argCount := len(args)
 
-	if filters.DateFrom != nil {
+	// Races without an off time sort last within their day
+	if filters.Cursor != "" {
+		var after raceCursor
+		if err := decodeCursor(filters.Cursor, "races", filters.RaceFilterSet, &after); err != nil {
+			return nil, "", err
+		}
+		query += fmt.Sprintf(` AND (r.race_date < $%d::date OR (r.race_date = $%d::date
+			AND (COALESCE(r.off_time, '23:59:59'::time), r.race_id) > ($%d::time, $%d)))`,
+			argCount+1, argCount+1, argCount+2, argCount+3)
+		args = append(args, after.Date, after.OffTime, after.RaceID)
+		argCount += 3
+	}
+
+	query += " ORDER BY r.race_date DESC, COALESCE(r.off_time, '23:59:59'::time), r.race_id"
+
+	argCount++
+	query += fmt.Sprintf(" LIMIT $%d", argCount)
+	args = append(args, filters.Limit+1)
+
+	if filters.Offset > 0 && filters.Cursor == "" {
+		argCount++
+		query += fmt.Sprintf(" OFFSET $%d", argCount)
+		args = append(args, filters.Offset)
+	}
+
+	var races []models.Race
+	if err := r.db.Select(&races, query, args...); err != nil {
+		return nil, "", fmt.Errorf("failed to search races: %w", err)
+	}
+
+	races, next := nextPage(races, filters.Limit, "races", filters.RaceFilterSet, func(race models.Race) interface{} {
+		off := "23:59:59"
+		if race.OffTime != nil {
+			off = *race.OffTime
+		}
+		return raceCursor{Date: cursorDate(race.RaceDate), OffTime: off, RaceID: race.RaceID}
+	})
+	if err := r.attachRunners(races, view); err != nil {
+		return nil, "", err
+	}
+	return races, next, nil
+}
+
+// appendRaceFilters adds a race filter set's conditions on races r to a
+// query with args already bound
+func appendRaceFilters(query string, args []interface{}, f models.RaceFilterSet) (string, []interface{}) {
+	argCount := len(args)
+
+	if f.DateFrom != nil {
 		argCount++
 		query += fmt.Sprintf(" AND r.race_date >= $%d", argCount)
-		args = append(args, *filters.DateFrom)
+		args = append(args, *f.DateFrom)
 	}
 
-	if filters.DateTo != nil {
+	if f.DateTo != nil {
 		argCount++
 		query += fmt.Sprintf(" AND r.race_date <= $%d", argCount)
-		args = append(args, *filters.DateTo)
+		args = append(args, *f.DateTo)
 	}
 
-	if filters.Region != nil {
+	if f.Region != nil {
 		argCount++
 		query += fmt.Sprintf(" AND r.region = $%d", argCount)
-		args = append(args, *filters.Region)
+		args = append(args, *f.Region)
 	}
 
-	if filters.CourseID != nil {
+	if f.CourseID != nil {
 		argCount++
 		query += fmt.Sprintf(" AND r.course_id = $%d", argCount)
-		args = append(args, *filters.CourseID)
+		args = append(args, *f.CourseID)
 	}
 
-	if filters.Type != nil {
+	if f.Type != nil {
 		argCount++
 		query += fmt.Sprintf(" AND r.race_type = $%d", argCount)
-		args = append(args, *filters.Type)
+		args = append(args, *f.Type)
 	}
 
-	if filters.Class != nil {
+	if f.Class != nil {
 		argCount++
 		query += fmt.Sprintf(" AND r.class = $%d", argCount)
-		args = append(args, *filters.Class)
+		args = append(args, *f.Class)
 	}
 
-	if filters.Pattern != nil {
+	if f.Pattern != nil {
 		argCount++
 		query += fmt.Sprintf(" AND r.pattern = $%d", argCount)
-		args = append(args, *filters.Pattern)
+		args = append(args, *f.Pattern)
 	}
 
-	if filters.Handicap != nil {
+	if f.Handicap != nil {
 		argCount++
-		if *filters.Handicap {
+		if *f.Handicap {
 			query += fmt.Sprintf(" AND r.race_name ILIKE $%d", argCount)
 			args = append(args, "%handicap%")
 		} else {
@@ -91,218 +142,145 @@ func (r *RaceRepository) SearchRaces(filters models.RaceFilters) ([]models.Race,
 		}
 	}
 
-	if filters.DistMin != nil {
+	if f.DistMin != nil {
 		argCount++
 		query += fmt.Sprintf(" AND r.dist_f >= $%d", argCount)
-		args = append(args, *filters.DistMin)
+		args = append(args, *f.DistMin)
 	}
 
-	if filters.DistMax != nil {
+	if f.DistMax != nil {
 		argCount++
 		query += fmt.Sprintf(" AND r.dist_f <= $%d", argCount)
-		args = append(args, *filters.DistMax)
+		args = append(args, *f.DistMax)
 	}
 
-	if filters.Going != nil {
+	if f.Going != nil {
 		argCount++
 		query += fmt.Sprintf(" AND r.going ILIKE $%d", argCount)
-		args = append(args, "%"+*filters.Going+"%")
+		args = append(args, "%"+*f.Going+"%")
 	}
 
-	if filters.Surface != nil {
+	if f.Surface != nil {
 		argCount++
 		query += fmt.Sprintf(" AND r.surface = $%d", argCount)
-		args = append(args, *filters.Surface)
+		args = append(args, *f.Surface)
 	}
 
-	if filters.FieldMin != nil {
+	if f.FieldMin != nil {
 		argCount++
 		query += fmt.Sprintf(" AND r.ran >= $%d", argCount)
-		args = append(args, *filters.FieldMin)
+		args = append(args, *f.FieldMin)
 	}
 
-	if filters.FieldMax != nil {
+	if f.FieldMax != nil {
 		argCount++
 		query += fmt.Sprintf(" AND r.ran <= $%d", argCount)
-		args = append(args, *filters.FieldMax)
+		args = append(args, *f.FieldMax)
 	}
 
-	query += " ORDER BY r.race_date DESC, r.off_time"
-
-	argCount++
-	query += fmt.Sprintf(" LIMIT $%d", argCount)
-	args = append(args, filters.Limit)
+	return query, args
+}
 
-	if filters.Offset > 0 {
-		argCount++
-		query += fmt.Sprintf(" OFFSET $%d", argCount)
-		args = append(args, filters.Offset)
+// GetRaceByID returns a single race with the runners and fields of the view
+func (r *RaceRepository) GetRaceByID(raceID int64, view models.RaceView) (*models.RaceWithRunners, error) {
+	races, err := r.selectRaces(view, nil, "r.race_id = $1", "", raceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get race: %w", err)
 	}
+	if len(races) == 0 {
+		return nil, fmt.Errorf("failed to get race: %w", sql.ErrNoRows)
+	}
+	result := &models.RaceWithRunners{Race: races[0]}
 
-	var races []models.Race
-	if err := r.db.Select(&races, query, args...); err != nil {
-		return nil, fmt.Errorf("failed to search races: %w", err)
+	if view.Runners {
+		result.Runners, err = r.GetRaceRunners(raceID, view)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return races, nil
+	return result, nil
 }
 
-// GetRaceByID returns a single race with runners
-func (r *RaceRepository) GetRaceByID(raceID int64) (*models.RaceWithRunners, error) {
-	result := &models.RaceWithRunners{}
-
-	// Get race details
-	raceQuery := `
-		SELECT 
-			r.race_id, r.race_key, r.race_date, r.region, r.course_id,
-			c.course_name, r.off_time::text, r.race_name, r.race_type, r.class,
-			r.pattern, r.rating_band, r.age_band, r.sex_rest,
-			r.dist_raw, r.dist_f, r.dist_m, r.going, r.surface, r.ran
-		FROM racing.races r
-		LEFT JOIN racing.courses c ON c.course_id = r.course_id
-		WHERE r.race_id = $1
+// getSectionals returns sectional splits matching a WHERE clause on
+// runner_sectionals s and runners ru
+func (r *RaceRepository) getSectionals(where string, args ...interface{}) ([]models.Sectional, error) {
+	query := `
+		SELECT
+			s.runner_id,
+			s.section_seq,
+			s.section_label,
+			s.dist_y,
+			s.secs,
+			SUM(s.secs) OVER (PARTITION BY s.runner_id ORDER BY s.section_seq) AS cum_secs
+		FROM racing.runner_sectionals s
+		JOIN racing.runners ru ON ru.runner_id = s.runner_id AND ru.race_date = s.race_date
+		WHERE ` + where + `
+		ORDER BY s.runner_id, s.section_seq
 	`
-	if err := r.db.Get(&result.Race, raceQuery, raceID); err != nil {
-		return nil, fmt.Errorf("failed to get race: %w", err)
-	}
 
-	// Get runners
-	runners, err := r.GetRaceRunners(raceID)
-	if err != nil {
-		return nil, err
+	sectionals := []models.Sectional{}
+	if err := r.db.Select(&sectionals, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get sectionals: %w", err)
 	}
-	result.Runners = runners
 
-	return result, nil
+	return sectionals, nil
 }
 
 // GetRunnersForRaces returns all runners for multiple races in a single query (optimized)
-func (r *RaceRepository) GetRunnersForRaces(raceIDs []int64) ([]models.Runner, error) {
+func (r *RaceRepository) GetRunnersForRaces(raceIDs []int64, view models.RaceView) ([]models.Runner, error) {
 	if len(raceIDs) == 0 {
 		return []models.Runner{}, nil
 	}
 
-	query := `
-		SELECT 
-			ru.runner_id, ru.runner_key, ru.race_id, ru.race_date,
-			ru.horse_id, h.horse_name,
-			ru.trainer_id, t.trainer_name,
-			ru.jockey_id, j.jockey_name,
-			ru.owner_id, o.owner_name,
-			ru.num, ru.pos_raw, ru.pos_num, ru.draw, ru.ovr_btn, ru.btn,
-			ru.age, ru.sex, ru.lbs, ru.hg, ru.time_raw, ru.secs, ru.dec,
-			ru.prize, ru."or", ru.rpr, ru.comment,
-			ru.win_bsp, ru.win_ppwap, ru.win_morningwap, ru.win_ppmax, ru.win_ppmin,
-			ru.win_ipmax, ru.win_ipmin, ru.win_morning_vol, ru.win_pre_vol, ru.win_ip_vol, ru.win_lose,
-			ru.place_bsp, ru.place_ppwap, ru.place_morningwap, ru.place_ppmax, ru.place_ppmin,
-			ru.place_ipmax, ru.place_ipmin, ru.place_morning_vol, ru.place_pre_vol, ru.place_ip_vol, ru.place_win_lose,
-			bl.sire, bl.dam, bl.damsire,
-			ru.win_flag,
-			ru.price_updated_at
-		FROM racing.runners ru
-		LEFT JOIN racing.horses h ON h.horse_id = ru.horse_id
-		LEFT JOIN racing.trainers t ON t.trainer_id = ru.trainer_id
-		LEFT JOIN racing.jockeys j ON j.jockey_id = ru.jockey_id
-		LEFT JOIN racing.owners o ON o.owner_id = ru.owner_id
-		LEFT JOIN racing.bloodlines bl ON bl.blood_id = ru.blood_id
-		WHERE ru.race_id = ANY($1)
-		ORDER BY ru.race_id, ru.num
-	`
+	return r.selectRunners(view, "ru.race_id = ANY($1)", "ORDER BY ru.race_id, ru.num", pq.Array(raceIDs))
+}
 
-	rows, err := r.db.Query(query, pq.Array(raceIDs))
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var runners []models.Runner
-	for rows.Next() {
-		var runner models.Runner
-		err := rows.Scan(
-			&runner.RunnerID, &runner.RunnerKey, &runner.RaceID, &runner.RaceDate,
-			&runner.HorseID, &runner.HorseName,
-			&runner.TrainerID, &runner.TrainerName,
-			&runner.JockeyID, &runner.JockeyName,
-			&runner.OwnerID, &runner.OwnerName,
-			&runner.Num, &runner.PosRaw, &runner.PosNum, &runner.Draw, &runner.OvrBTN, &runner.BTN,
-			&runner.Age, &runner.Sex, &runner.Lbs, &runner.HG, &runner.TimeRaw, &runner.Secs, &runner.Dec,
-			&runner.Prize, &runner.OR, &runner.RPR, &runner.Comment,
-			&runner.WinBSP, &runner.WinPPWAP, &runner.WinMorningWAP, &runner.WinPPMax, &runner.WinPPMin,
-			&runner.WinIPMax, &runner.WinIPMin, &runner.WinMorningVol, &runner.WinPreVol, &runner.WinIPVol, &runner.WinLose,
-			&runner.PlaceBSP, &runner.PlacePPWAP, &runner.PlaceMorningWAP, &runner.PlacePPMax, &runner.PlacePPMin,
-			&runner.PlaceIPMax, &runner.PlaceIPMin, &runner.PlaceMorningVol, &runner.PlacePreVol, &runner.PlaceIPVol, &runner.PlaceWinLose,
-			&runner.Sire, &runner.Dam, &runner.Damsire,
-			&runner.WinFlag,
-			&runner.PriceUpdatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		runners = append(runners, runner)
+// attachRunners embeds each race's runners when the view includes them
+func (r *RaceRepository) attachRunners(races []models.Race, view models.RaceView) error {
+	if !view.Runners || len(races) == 0 {
+		return nil
 	}
 
-	return runners, rows.Err()
-}
+	raceIDs := make([]int64, len(races))
+	for i, race := range races {
+		raceIDs[i] = race.RaceID
+	}
 
-// GetRaceRunners returns all runners for a race
-func (r *RaceRepository) GetRaceRunners(raceID int64) ([]models.Runner, error) {
-	query := `
-		SELECT 
-			ru.runner_id, ru.runner_key, ru.race_id, ru.race_date,
-			ru.horse_id, h.horse_name,
-			ru.trainer_id, t.trainer_name,
-			ru.jockey_id, j.jockey_name,
-			ru.owner_id, o.owner_name,
-			ru.num, ru.pos_raw, ru.pos_num, ru.draw, ru.ovr_btn, ru.btn,
-			ru.age, ru.sex, ru.lbs, ru.hg, ru.time_raw, ru.secs, ru.dec,
-			ru.prize, ru."or", ru.rpr, ru.comment,
-			ru.win_bsp, ru.win_ppwap, ru.win_morningwap, ru.win_ppmax, ru.win_ppmin,
-			ru.win_ipmax, ru.win_ipmin, ru.win_morning_vol, ru.win_pre_vol, ru.win_ip_vol, ru.win_lose,
-			ru.place_bsp, ru.place_ppwap, ru.place_morningwap, ru.place_ppmax, ru.place_ppmin,
-			ru.place_ipmax, ru.place_ipmin, ru.place_morning_vol, ru.place_pre_vol, ru.place_ip_vol, ru.place_win_lose,
-			bl.sire, bl.dam, bl.damsire,
-			ru.win_flag,
-			ru.price_updated_at
-		FROM racing.runners ru
-		LEFT JOIN racing.horses h ON h.horse_id = ru.horse_id
-		LEFT JOIN racing.trainers t ON t.trainer_id = ru.trainer_id
-		LEFT JOIN racing.jockeys j ON j.jockey_id = ru.jockey_id
-		LEFT JOIN racing.owners o ON o.owner_id = ru.owner_id
-		LEFT JOIN racing.bloodlines bl ON bl.blood_id = ru.blood_id
-		WHERE ru.race_id = $1
-		ORDER BY ru.pos_num NULLS LAST, ru.num
-	`
+	// Fetch ALL runners for ALL races in ONE query
+	runners, err := r.GetRunnersForRaces(raceIDs, view)
+	if err != nil {
+		return err
+	}
 
-	var runners []models.Runner
-	if err := r.db.Select(&runners, query, raceID); err != nil {
-		return nil, fmt.Errorf("failed to get runners: %w", err)
+	runnersByRace := make(map[int64][]models.Runner)
+	for _, runner := range runners {
+		runnersByRace[runner.RaceID] = append(runnersByRace[runner.RaceID], runner)
 	}
+	for i := range races {
+		races[i].Runners = runnersByRace[races[i].RaceID]
+	}
+	return nil
+}
 
-	return runners, nil
+// GetRaceRunners returns all runners for a race
+func (r *RaceRepository) GetRaceRunners(raceID int64, view models.RaceView) ([]models.Runner, error) {
+	return r.selectRunners(view, "ru.race_id = $1", "ORDER BY ru.pos_num NULLS LAST, ru.num", raceID)
 }
 
 // GetRecentRaces returns recent races
-func (r *RaceRepository) GetRecentRaces(date string, limit int) ([]models.Race, error) {
+func (r *RaceRepository) GetRecentRaces(date string, limit int, view models.RaceView) ([]models.Race, error) {
 	if limit <= 0 {
 		limit = 50
 	}
 
-	query := `
-		SELECT 
-			r.race_id, r.race_key, r.race_date, r.region, r.course_id,
-			c.course_name, r.off_time::text, r.race_name, r.race_type, r.class,
-			r.pattern, r.dist_raw, r.dist_f, r.going, r.surface, r.ran
-		FROM racing.races r
-		LEFT JOIN racing.courses c ON c.course_id = r.course_id
-		WHERE r.race_date = $1
-		ORDER BY r.off_time
-		LIMIT $2
-	`
-
-	var races []models.Race
-	if err := r.db.Select(&races, query, date, limit); err != nil {
+	races, err := r.selectRaces(view, nil, "r.race_date = $1", "ORDER BY r.off_time LIMIT $2", date, limit)
+	if err != nil {
 		return nil, fmt.Errorf("failed to get recent races: %w", err)
 	}
+	if err := r.attachRunners(races, view); err != nil {
+		return nil, err
+	}
 
 	return races, nil
 }
@@ -335,7 +313,7 @@ func (r *RaceRepository) GetCourseMeetings(courseID int64, dateFrom, dateTo stri
 
 // GetCourses returns all courses
 func (r *RaceRepository) GetCourses() ([]models.Course, error) {
-	query := `SELECT course_id, course_name, region FROM racing.courses ORDER BY course_name`
+	query := `SELECT course_id, course_name, region, timezone, surfaces, direction, profile FROM racing.courses ORDER BY course_name`
 
 	var courses []models.Course
 	if err := r.db.Select(&courses, query); err != nil {
@@ -346,31 +324,16 @@ func (r *RaceRepository) GetCourses() ([]models.Course, error) {
 }
 
 // GetRacesByMeetings returns races grouped by meetings (course + date)
-func (r *RaceRepository) GetRacesByMeetings(date string) ([]models.MeetingWithRaces, error) {
-	// Get all races for the date
-	races, err := r.GetRecentRaces(date, 1000) // Get all races for this date
+func (r *RaceRepository) GetRacesByMeetings(date string, view models.RaceView) ([]models.MeetingWithRaces, error) {
+	// Get all races for the date, with their runners if the view includes them
+	races, err := r.selectRaces(view, []string{"course_name"}, "r.race_date = $1", "ORDER BY r.off_time LIMIT 1000", date)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to get races: %w", err)
 	}
-
-	// Collect all race IDs
-	raceIDs := make([]int64, len(races))
-	for i, race := range races {
-		raceIDs[i] = race.RaceID
-	}
-
-	// Fetch ALL runners for ALL races in ONE query (much faster!)
-	allRunners, err := r.GetRunnersForRaces(raceIDs)
-	if err != nil {
+	if err := r.attachRunners(races, view); err != nil {
 		return nil, err
 	}
 
-	// Group runners by race_id
-	runnersByRace := make(map[int64][]models.Runner)
-	for _, runner := range allRunners {
-		runnersByRace[runner.RaceID] = append(runnersByRace[runner.RaceID], runner)
-	}
-
 	// Group races by course
 	meetingsMap := make(map[int64]*models.MeetingWithRaces)
 
@@ -396,7 +359,6 @@ func (r *RaceRepository) GetRacesByMeetings(date string) ([]models.MeetingWithRa
 
 		// Add race to meeting with its runners
 		meeting := meetingsMap[courseKey]
-		race.Runners = runnersByRace[race.RaceID] // Get pre-fetched runners
 		meeting.Races = append(meeting.Races, race)
 	}
 
@@ -462,3 +424,177 @@ func buildWhereClause(conditions []string) string {
 	}
 	return " WHERE " + strings.Join(conditions, " AND ")
 }
+
+// GetAmendedRaces returns races whose results were amended after settling,
+// most recently amended first
+func (r *RaceRepository) GetAmendedRaces(filters models.AmendedRaceFilters) ([]models.AmendedRace, error) {
+	if filters.Limit <= 0 {
+		filters.Limit = 200
+	}
+
+	query := `
+		SELECT
+			r.race_id, r.race_key, r.race_date, r.region, c.course_name,
+			r.off_time::text, r.race_name,
+			array_agg(DISTINCT a.kind ORDER BY a.kind) AS kinds,
+			COUNT(*) AS amendments,
+			MAX(a.amended_at)::text AS last_amended_at
+		FROM racing.runner_amendments a
+		JOIN racing.races r ON r.race_id = a.race_id AND r.race_date = a.race_date
+		LEFT JOIN racing.courses c ON c.course_id = r.course_id
+		WHERE 1=1
+	`
+
+	args := []interface{}{}
+	argCount := 0
+
+	if filters.DateFrom != nil {
+		argCount++
+		query += fmt.Sprintf(" AND a.race_date >= $%d", argCount)
+		args = append(args, *filters.DateFrom)
+	}
+
+	if filters.DateTo != nil {
+		argCount++
+		query += fmt.Sprintf(" AND a.race_date <= $%d", argCount)
+		args = append(args, *filters.DateTo)
+	}
+
+	if filters.Kind != nil {
+		argCount++
+		query += fmt.Sprintf(" AND a.kind = $%d", argCount)
+		args = append(args, *filters.Kind)
+	}
+
+	query += `
+		GROUP BY r.race_id, r.race_key, r.race_date, r.region, c.course_name, r.off_time, r.race_name
+		ORDER BY MAX(a.amended_at) DESC, r.race_id
+	`
+
+	argCount++
+	query += fmt.Sprintf(" LIMIT $%d", argCount)
+	args = append(args, filters.Limit)
+
+	if filters.Offset > 0 {
+		argCount++
+		query += fmt.Sprintf(" OFFSET $%d", argCount)
+		args = append(args, filters.Offset)
+	}
+
+	races := []models.AmendedRace{}
+	if err := r.db.Select(&races, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get amended races: %w", err)
+	}
+
+	return races, nil
+}
+
+// GetRaceAmendments returns a race's amendment history, oldest first
+func (r *RaceRepository) GetRaceAmendments(raceID int64) ([]models.RunnerAmendment, error) {
+	query := `
+		SELECT
+			a.amendment_id, a.runner_id, a.race_id, a.race_date, a.runner_key,
+			h.horse_name, a.field, a.old_value, a.new_value, a.kind, a.source,
+			a.run_id, a.amended_at::text
+		FROM racing.runner_amendments a
+		LEFT JOIN racing.runners ru ON ru.runner_id = a.runner_id AND ru.race_date = a.race_date
+		LEFT JOIN racing.horses h ON h.horse_id = ru.horse_id
+		WHERE a.race_id = $1
+		ORDER BY a.amended_at, a.amendment_id
+	`
+
+	amendments := []models.RunnerAmendment{}
+	if err := r.db.Select(&amendments, query, raceID); err != nil {
+		return nil, fmt.Errorf("failed to get race amendments: %w", err)
+	}
+
+	return amendments, nil
+}
+
+// AsFirstPublished rewinds a race's runners to their values as first
+// published: each amended field takes the old value of its earliest amendment
+func (r *RaceRepository) AsFirstPublished(raceID int64, runners []models.Runner) ([]models.Runner, error) {
+	amendments, err := r.GetRaceAmendments(raceID)
+	if err != nil {
+		return nil, err
+	}
+	if len(amendments) == 0 {
+		return runners, nil
+	}
+
+	// Oldest first, so the first amendment seen per field holds the original
+	type fieldKey struct {
+		runnerID int64
+		field    string
+	}
+	original := make(map[fieldKey]*string)
+	for _, a := range amendments {
+		key := fieldKey{a.RunnerID, a.Field}
+		if _, ok := original[key]; !ok {
+			original[key] = a.OldValue
+		}
+	}
+
+	for i := range runners {
+		runner := &runners[i]
+		for _, field := range []string{"pos_raw", "dec", "win_bsp", "place_bsp"} {
+			old, ok := original[fieldKey{runner.RunnerID, field}]
+			if !ok {
+				continue
+			}
+			switch field {
+			case "pos_raw":
+				runner.PosRaw = old
+				runner.PosNum = leadingInt(old)
+				runner.WinFlag = runner.PosNum != nil && *runner.PosNum == 1
+			case "dec":
+				runner.Dec = parseAmendedPrice(old)
+			case "win_bsp":
+				runner.WinBSP = parseAmendedPrice(old)
+			case "place_bsp":
+				runner.PlaceBSP = parseAmendedPrice(old)
+			}
+		}
+	}
+
+	// Keep GetRaceRunners' order (finishing position, then number)
+	sort.SliceStable(runners, func(i, j int) bool {
+		a, b := runners[i], runners[j]
+		if (a.PosNum == nil) != (b.PosNum == nil) {
+			return a.PosNum != nil
+		}
+		if a.PosNum != nil && *a.PosNum != *b.PosNum {
+			return *a.PosNum < *b.PosNum
+		}
+		return a.Num != nil && (b.Num == nil || *a.Num < *b.Num)
+	})
+
+	return runners, nil
+}
+
+// leadingInt mirrors racing.runners.pos_num: the leading digits of pos_raw
+func leadingInt(s *string) *int {
+	if s == nil {
+		return nil
+	}
+	end := 0
+	for end < len(*s) && (*s)[end] >= '0' && (*s)[end] <= '9' {
+		end++
+	}
+	n, err := strconv.Atoi((*s)[:end])
+	if err != nil {
+		return nil
+	}
+	return &n
+}
+
+func parseAmendedPrice(s *string) *float64 {
+	if s == nil {
+		return nil
+	}
+	f, err := strconv.ParseFloat(*s, 64)
+	if err != nil {
+		return nil
+	}
+	return &f
+}
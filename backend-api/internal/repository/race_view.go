@@ -0,0 +1,358 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"giddyup/api/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// ErrUnknownField is returned for a ?fields= name the resource doesn't have
+var ErrUnknownField = errors.New("unknown field")
+
+// formRuns is how many previous runs include=runners.form embeds per runner
+const formRuns = 6
+
+// viewColumn is a selectable field: its expression (aliased to the model's
+// db tag) and the join it needs, if any
+type viewColumn struct {
+	name string
+	expr string
+	join string
+}
+
+// Joins a race or runner column can need
+var viewJoins = map[string]string{
+	"c":  "LEFT JOIN racing.courses c ON c.course_id = r.course_id",
+	"h":  "LEFT JOIN racing.horses h ON h.horse_id = ru.horse_id",
+	"t":  "LEFT JOIN racing.trainers t ON t.trainer_id = ru.trainer_id",
+	"j":  "LEFT JOIN racing.jockeys j ON j.jockey_id = ru.jockey_id",
+	"o":  "LEFT JOIN racing.owners o ON o.owner_id = ru.owner_id",
+	"bl": "LEFT JOIN racing.bloodlines bl ON bl.blood_id = ru.blood_id",
+}
+
+// raceColumns are the fields of models.Race, from races r
+var raceColumns = []viewColumn{
+	{"race_id", "r.race_id", ""},
+	{"race_key", "r.race_key", ""},
+	{"race_date", "r.race_date", ""},
+	{"region", "r.region", ""},
+	{"course_id", "r.course_id", ""},
+	{"course_name", "c.course_name", "c"},
+	{"off_time", "r.off_time::text AS off_time", ""},
+	{"off_time_local", "r.off_time_local::text AS off_time_local", ""},
+	{"race_name", "r.race_name", ""},
+	{"race_type", "r.race_type", ""},
+	{"class", "r.class", ""},
+	{"pattern", "r.pattern", ""},
+	{"rating_band", "r.rating_band", ""},
+	{"age_band", "r.age_band", ""},
+	{"sex_rest", "r.sex_rest", ""},
+	{"dist_raw", "r.dist_raw", ""},
+	{"dist_f", "r.dist_f", ""},
+	{"dist_m", "r.dist_m", ""},
+	{"going", "r.going", ""},
+	{"surface", "r.surface", ""},
+	{"ran", "r.ran", ""},
+	{"prelim", "r.prelim", ""},
+	{"amended_at", "r.amended_at::text AS amended_at", ""},
+}
+
+// runnerColumns are the fields of models.Runner, from runners ru
+var runnerColumns = []viewColumn{
+	{"runner_id", "ru.runner_id", ""},
+	{"runner_key", "ru.runner_key", ""},
+	{"race_id", "ru.race_id", ""},
+	{"race_date", "ru.race_date", ""},
+	{"horse_id", "ru.horse_id", ""},
+	{"horse_name", "h.horse_name", "h"},
+	{"trainer_id", "ru.trainer_id", ""},
+	{"trainer_name", "t.trainer_name", "t"},
+	{"jockey_id", "ru.jockey_id", ""},
+	{"jockey_name", "j.jockey_name", "j"},
+	{"owner_id", "ru.owner_id", ""},
+	{"owner_name", "o.owner_name", "o"},
+	{"num", "ru.num", ""},
+	{"pos_raw", "ru.pos_raw", ""},
+	{"pos_num", "ru.pos_num", ""},
+	{"draw", "ru.draw", ""},
+	{"ovr_btn", "ru.ovr_btn", ""},
+	{"btn", "ru.btn", ""},
+	{"age", "ru.age", ""},
+	{"sex", "ru.sex", ""},
+	{"lbs", "ru.lbs", ""},
+	{"hg", "ru.hg", ""},
+	{"time_raw", "ru.time_raw", ""},
+	{"secs", "ru.secs", ""},
+	{"dec", "ru.dec", ""},
+	{"prize", "ru.prize", ""},
+	{"or", `ru."or"`, ""},
+	{"rpr", "ru.rpr", ""},
+	{"comment", "ru.comment", ""},
+	{"win_bsp", "ru.win_bsp", ""},
+	{"win_ppwap", "ru.win_ppwap", ""},
+	{"win_morningwap", "ru.win_morningwap", ""},
+	{"win_ppmax", "ru.win_ppmax", ""},
+	{"win_ppmin", "ru.win_ppmin", ""},
+	{"win_ipmax", "ru.win_ipmax", ""},
+	{"win_ipmin", "ru.win_ipmin", ""},
+	{"win_morning_vol", "ru.win_morning_vol", ""},
+	{"win_pre_vol", "ru.win_pre_vol", ""},
+	{"win_ip_vol", "ru.win_ip_vol", ""},
+	{"win_lose", "ru.win_lose", ""},
+	{"place_bsp", "ru.place_bsp", ""},
+	{"place_ppwap", "ru.place_ppwap", ""},
+	{"place_morningwap", "ru.place_morningwap", ""},
+	{"place_ppmax", "ru.place_ppmax", ""},
+	{"place_ppmin", "ru.place_ppmin", ""},
+	{"place_ipmax", "ru.place_ipmax", ""},
+	{"place_ipmin", "ru.place_ipmin", ""},
+	{"place_morning_vol", "ru.place_morning_vol", ""},
+	{"place_pre_vol", "ru.place_pre_vol", ""},
+	{"place_ip_vol", "ru.place_ip_vol", ""},
+	{"place_win_lose", "ru.place_win_lose", ""},
+	{"sire", "bl.sire", "bl"},
+	{"dam", "bl.dam", "bl"},
+	{"damsire", "bl.damsire", "bl"},
+	{"win_flag", "ru.win_flag", ""},
+	{"price_updated_at", "ru.price_updated_at", ""},
+}
+
+// Fields every query selects whatever the view asks for: keys, cursor and
+// grouping columns, what the runner order and includes need, and prelim for
+// cache freshness. They are trimmed from sparse responses unless asked for.
+var (
+	raceKeyFields   = []string{"race_id", "race_date", "region", "course_id", "off_time", "race_type", "prelim"}
+	runnerKeyFields = []string{"runner_id", "race_id", "race_date", "horse_id", "num", "pos_num"}
+)
+
+// CheckRaceView rejects fields the race and runner models don't have
+func CheckRaceView(view models.RaceView) error {
+	if err := checkFields(raceColumns, view.RaceFields, "race"); err != nil {
+		return err
+	}
+	return checkFields(runnerColumns, view.RunnerFields, "runner")
+}
+
+func checkFields(cols []viewColumn, names []string, resource string) error {
+	for _, name := range names {
+		if _, ok := findViewColumn(cols, name); !ok {
+			return fmt.Errorf("%w %q for %s", ErrUnknownField, name, resource)
+		}
+	}
+	return nil
+}
+
+func findViewColumn(cols []viewColumn, name string) (viewColumn, bool) {
+	for _, col := range cols {
+		if col.name == name {
+			return col, true
+		}
+	}
+	return viewColumn{}, false
+}
+
+// selectView returns the SELECT list and joins for the fields asked for (all
+// when none are) plus the key fields, in model order
+func selectView(cols []viewColumn, fields, keys []string, extra ...string) (string, string) {
+	want := make(map[string]bool, len(fields)+len(keys)+len(extra))
+	for _, name := range append(append(append([]string{}, fields...), keys...), extra...) {
+		want[name] = true
+	}
+
+	var exprs, joins []string
+	seen := make(map[string]bool)
+	for _, col := range cols {
+		if len(fields) > 0 && !want[col.name] {
+			continue
+		}
+		exprs = append(exprs, col.expr)
+		if col.join != "" && !seen[col.join] {
+			seen[col.join] = true
+			joins = append(joins, viewJoins[col.join])
+		}
+	}
+	return strings.Join(exprs, ", "), strings.Join(joins, "\n")
+}
+
+// selectRaces returns the races matching a WHERE clause on races r
+func (r *RaceRepository) selectRaces(view models.RaceView, extraFields []string, where, order string, args ...interface{}) ([]models.Race, error) {
+	cols, joins := selectView(raceColumns, view.RaceFields, raceKeyFields, extraFields...)
+	query := "SELECT " + cols + " FROM racing.races r\n" + joins + "\nWHERE " + where + " " + order
+
+	var races []models.Race
+	if err := r.db.Select(&races, query, args...); err != nil {
+		return nil, err
+	}
+	return races, nil
+}
+
+// selectRunners returns the runners matching a WHERE clause on runners ru,
+// with the view's includes attached
+func (r *RaceRepository) selectRunners(view models.RaceView, where, order string, args ...interface{}) ([]models.Runner, error) {
+	cols, joins := selectView(runnerColumns, view.RunnerFields, runnerKeyFields)
+	query := "SELECT " + cols + " FROM racing.runners ru\n" + joins + "\nWHERE " + where + " " + order
+
+	var runners []models.Runner
+	if err := r.db.Select(&runners, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get runners: %w", err)
+	}
+	if err := r.attachIncludes(view, runners); err != nil {
+		return nil, err
+	}
+	return runners, nil
+}
+
+// attachIncludes adds the sectionals, form and live prices a view asks for
+func (r *RaceRepository) attachIncludes(view models.RaceView, runners []models.Runner) error {
+	if len(runners) == 0 {
+		return nil
+	}
+	runnerIDs := make([]int64, len(runners))
+	for i, runner := range runners {
+		runnerIDs[i] = runner.RunnerID
+	}
+
+	if view.Sectionals {
+		byRunner, err := r.GetRunnerSectionals(runnerIDs)
+		if err != nil {
+			return err
+		}
+		for i := range runners {
+			runners[i].Sectionals = byRunner[runners[i].RunnerID]
+		}
+	}
+
+	if view.LivePrice {
+		byRunner, err := r.GetLivePrices(runnerIDs)
+		if err != nil {
+			return err
+		}
+		for i := range runners {
+			runners[i].LivePrice = byRunner[runners[i].RunnerID]
+		}
+	}
+
+	if view.Form {
+		var keys []models.FormKey
+		for _, runner := range runners {
+			if key, ok := runnerFormKey(runner); ok {
+				keys = append(keys, key)
+			}
+		}
+		byKey, err := r.GetForm(keys)
+		if err != nil {
+			return err
+		}
+		for i := range runners {
+			if key, ok := runnerFormKey(runners[i]); ok {
+				runners[i].Form = byKey[key]
+			}
+		}
+	}
+	return nil
+}
+
+// runnerFormKey is the form a runner's include=runners.form shows
+func runnerFormKey(runner models.Runner) (models.FormKey, bool) {
+	if runner.HorseID == nil {
+		return models.FormKey{}, false
+	}
+	return models.FormKey{HorseID: *runner.HorseID, Before: cursorDate(runner.RaceDate)}, true
+}
+
+// GetRacesByIDs returns races by ID, in no particular order
+func (r *RaceRepository) GetRacesByIDs(raceIDs []int64) ([]models.Race, error) {
+	if len(raceIDs) == 0 {
+		return []models.Race{}, nil
+	}
+	races, err := r.selectRaces(models.RaceView{}, nil, "r.race_id = ANY($1)", "", pq.Array(raceIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get races: %w", err)
+	}
+	return races, nil
+}
+
+// GetRunnerSectionals returns the sectional splits of runners, by runner
+func (r *RaceRepository) GetRunnerSectionals(runnerIDs []int64) (map[int64][]models.Sectional, error) {
+	sectionals, err := r.getSectionals("s.runner_id = ANY($1)", pq.Array(runnerIDs))
+	if err != nil {
+		return nil, err
+	}
+	byRunner := make(map[int64][]models.Sectional)
+	for _, sec := range sectionals {
+		byRunner[sec.RunnerID] = append(byRunner[sec.RunnerID], sec)
+	}
+	return byRunner, nil
+}
+
+// GetLivePrices returns runners' latest intraday exchange prices, by runner
+func (r *RaceRepository) GetLivePrices(runnerIDs []int64) (map[int64]*models.LivePrice, error) {
+	var prices []struct {
+		RunnerID int64 `db:"runner_id"`
+		models.LivePrice
+	}
+	err := r.db.Select(&prices, `
+		SELECT DISTINCT ON (runner_id)
+			runner_id, back_price, lay_price, vwap, traded_vol, ts
+		FROM racing.live_prices
+		WHERE runner_id = ANY($1)
+		ORDER BY runner_id, ts DESC
+	`, pq.Array(runnerIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get live prices: %w", err)
+	}
+	byRunner := make(map[int64]*models.LivePrice, len(prices))
+	for i := range prices {
+		byRunner[prices[i].RunnerID] = &prices[i].LivePrice
+	}
+	return byRunner, nil
+}
+
+// GetForm returns horses' last formRuns runs before a date, latest first
+func (r *RaceRepository) GetForm(keys []models.FormKey) (map[models.FormKey][]models.FormRun, error) {
+	byKey := make(map[models.FormKey][]models.FormRun)
+	if len(keys) == 0 {
+		return byKey, nil
+	}
+	horseIDs := make([]int64, len(keys))
+	before := make([]string, len(keys))
+	for i, key := range keys {
+		horseIDs[i], before[i] = key.HorseID, key.Before
+	}
+
+	var runs []struct {
+		HorseID int64  `db:"form_horse_id"`
+		Before  string `db:"form_before"`
+		models.FormRun
+	}
+	err := r.db.Select(&runs, `
+		SELECT q.horse_id AS form_horse_id, q.before::text AS form_before, f.*
+		FROM unnest($1::bigint[], $2::date[]) AS q(horse_id, before)
+		CROSS JOIN LATERAL (
+			SELECT
+				ru.race_id, ru.race_date, c.course_name, r.race_type, r.class, r.dist_f,
+				r.going, r.ran, ru.pos_raw, ru.pos_num, ru.btn, ru."or", ru.rpr, ru.win_bsp
+			FROM racing.runners ru
+			JOIN racing.races r ON r.race_id = ru.race_id AND r.race_date = ru.race_date
+			LEFT JOIN racing.courses c ON c.course_id = r.course_id
+			WHERE ru.horse_id = q.horse_id AND ru.race_date < q.before
+			ORDER BY ru.race_date DESC
+			LIMIT $3
+		) f
+		ORDER BY q.horse_id, f.race_date DESC
+	`, pq.Array(horseIDs), pq.Array(before), formRuns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get form: %w", err)
+	}
+
+	for _, run := range runs {
+		key := models.FormKey{HorseID: run.HorseID, Before: run.Before}
+		run.FormRun.RaceDate = cursorDate(run.FormRun.RaceDate)
+		byKey[key] = append(byKey[key], run.FormRun)
+	}
+	return byKey, nil
+}
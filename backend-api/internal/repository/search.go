@@ -115,12 +115,24 @@ func (r *SearchRepository) GlobalSearch(query string, limit int) (*models.Search
 	return results, nil
 }
 
-// SearchComments performs full-text search on runner comments
-func (r *SearchRepository) SearchComments(params models.CommentSearchParams) ([]models.CommentSearchResult, error) {
+// commentCursor is the sort key of a comment search result
+type commentCursor struct {
+	Rank     float64 `json:"r"`
+	Date     string  `json:"d"`
+	RunnerID int64   `json:"i"`
+}
+
+// SearchComments performs full-text search on runner comments, best match
+// first. It returns one page and the cursor of the next ("" on the last page).
+func (r *SearchRepository) SearchComments(params models.CommentSearchParams) ([]models.CommentSearchResult, string, error) {
 	if params.Limit <= 0 {
 		params.Limit = 100
 	}
 
+	// The filters a cursor is bound to
+	filters := params
+	filters.Limit, filters.Cursor = 0, ""
+
 	query := `
 		SELECT 
 			ru.runner_id,
@@ -171,14 +183,31 @@ func (r *SearchRepository) SearchComments(params models.CommentSearchParams) ([]
 		args = append(args, *params.CourseID)
 	}
 
+	// Wrapped so the keyset can compare the computed rank (a real, which
+	// round-trips exactly through the cursor's float64)
+	query = "SELECT * FROM (" + query + ") s"
+	if params.Cursor != "" {
+		var after commentCursor
+		if err := decodeCursor(params.Cursor, "comments", filters, &after); err != nil {
+			return nil, "", err
+		}
+		query += fmt.Sprintf(" WHERE (s.rank, s.race_date, s.runner_id) < ($%d::real, $%d::date, $%d)",
+			argCount+1, argCount+2, argCount+3)
+		args = append(args, after.Rank, after.Date, after.RunnerID)
+		argCount += 3
+	}
+
 	argCount++
-	query += fmt.Sprintf(" ORDER BY rank DESC, r.race_date DESC LIMIT $%d", argCount)
-	args = append(args, params.Limit)
+	query += fmt.Sprintf(" ORDER BY s.rank DESC, s.race_date DESC, s.runner_id DESC LIMIT $%d", argCount)
+	args = append(args, params.Limit+1)
 
 	var results []models.CommentSearchResult
 	if err := r.db.Select(&results, query, args...); err != nil {
-		return nil, fmt.Errorf("failed to search comments: %w", err)
+		return nil, "", fmt.Errorf("failed to search comments: %w", err)
 	}
 
-	return results, nil
+	results, next := nextPage(results, params.Limit, "comments", filters, func(c models.CommentSearchResult) interface{} {
+		return commentCursor{Rank: c.Rank, Date: cursorDate(c.RaceDate), RunnerID: c.RunnerID}
+	})
+	return results, next, nil
 }
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"fmt"
+
+	"giddyup/api/internal/database"
+	"giddyup/api/internal/models"
+)
+
+type UsageRepository struct {
+	db *database.DB
+}
+
+func NewUsageRepository(db *database.DB) *UsageRepository {
+	return &UsageRepository{db: db}
+}
+
+// GetUsage totals racing.api_usage per day, client and route from dateFrom
+// to dateTo (UTC days), busiest first
+func (r *UsageRepository) GetUsage(dateFrom, dateTo string, filters models.UsageFilters) ([]models.UsageRow, error) {
+	if filters.Limit <= 0 {
+		filters.Limit = 200
+	}
+
+	query := `
+		SELECT
+			(u.hour AT TIME ZONE 'UTC')::date::text AS day,
+			u.client, u.key_id, k.name AS key_name, u.route, u.method,
+			SUM(u.requests) AS requests,
+			SUM(u.errors) AS errors,
+			SUM(u.throttled) AS throttled,
+			SUM(u.rows) AS rows,
+			ROUND(SUM(u.total_ms)::numeric / NULLIF(SUM(u.requests), 0), 1)::float8 AS avg_ms,
+			MAX(u.max_ms) AS max_ms
+		FROM racing.api_usage u
+		LEFT JOIN racing.api_keys k ON k.key_id = u.key_id
+		WHERE u.hour >= ($1::date)::timestamp AT TIME ZONE 'UTC'
+		  AND u.hour < ($2::date + 1)::timestamp AT TIME ZONE 'UTC'
+	`
+
+	args := []interface{}{dateFrom, dateTo}
+	argCount := 2
+
+	if filters.Client != nil {
+		argCount++
+		query += fmt.Sprintf(" AND u.client = $%d", argCount)
+		args = append(args, *filters.Client)
+	}
+
+	if filters.KeyID != nil {
+		argCount++
+		query += fmt.Sprintf(" AND u.key_id = $%d", argCount)
+		args = append(args, *filters.KeyID)
+	}
+
+	query += `
+		GROUP BY 1, u.client, u.key_id, k.name, u.route, u.method
+		ORDER BY day DESC, requests DESC, u.client, u.route
+	`
+
+	argCount++
+	query += fmt.Sprintf(" LIMIT $%d", argCount)
+	args = append(args, filters.Limit)
+
+	if filters.Offset > 0 {
+		argCount++
+		query += fmt.Sprintf(" OFFSET $%d", argCount)
+		args = append(args, filters.Offset)
+	}
+
+	rows := []models.UsageRow{}
+	if err := r.db.Select(&rows, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get usage: %w", err)
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,79 @@
+package router
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"giddyup/api/internal/config"
+	"giddyup/api/internal/database"
+	"giddyup/api/internal/openapi"
+	"giddyup/api/internal/usage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+// setupWithoutDB builds the router over a database that is never dialled
+// (sql.Open does not connect)
+func setupWithoutDB(t *testing.T) *gin.Engine {
+	t.Helper()
+	conn, err := sql.Open("postgres", "host=invalid.invalid")
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	db := &database.DB{DB: sqlx.NewDb(conn, "postgres")}
+	return Setup(db, &config.Config{}, usage.NewMeter(conn, 0), nil, nil)
+}
+
+// TestEveryRouteHasSchema fails when a route is added without an OpenAPI
+// operation in spec.go (or an operation outlives its route)
+func TestEveryRouteHasSchema(t *testing.T) {
+	r := setupWithoutDB(t)
+	for _, problem := range openapi.Check(r.Routes(), operations) {
+		t.Error(problem)
+	}
+}
+
+// TestOpenAPIDocument checks /openapi.json is served and covers every route
+func TestOpenAPIDocument(t *testing.T) {
+	r := setupWithoutDB(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /openapi.json: status %d", w.Code)
+	}
+
+	var doc openapi.Document
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid document: %v", err)
+	}
+	if doc.OpenAPI != "3.0.3" {
+		t.Errorf("openapi = %q, want 3.0.3", doc.OpenAPI)
+	}
+
+	documented := 0
+	for path, item := range doc.Paths {
+		for method, op := range item {
+			documented++
+			if op.OperationID == "" {
+				t.Errorf("%s %s has no operationId", method, path)
+			}
+			if len(op.Responses) == 0 {
+				t.Errorf("%s %s has no responses", method, path)
+			}
+		}
+	}
+	if documented != len(operations) {
+		t.Errorf("document has %d operations, want %d", documented, len(operations))
+	}
+	for name, schema := range doc.Components.Schemas {
+		if schema.Type == "" && schema.Ref == "" {
+			t.Errorf("schema %s is empty", name)
+		}
+	}
+}
@@ -1,24 +1,42 @@
 package router
 
 import (
+	"giddyup/api/internal/auth"
+	"giddyup/api/internal/cache"
+	"giddyup/api/internal/config"
 	"giddyup/api/internal/database"
+	"giddyup/api/internal/export"
+	"giddyup/api/internal/gql"
 	"giddyup/api/internal/handlers"
+	"giddyup/api/internal/logger"
 	"giddyup/api/internal/middleware"
+	"giddyup/api/internal/openapi"
+	"giddyup/api/internal/ratelimit"
 	"giddyup/api/internal/repository"
+	"giddyup/api/internal/usage"
 
 	"github.com/gin-gonic/gin"
 )
 
-func Setup(db *database.DB, corsOrigins []string) *gin.Engine {
+// Setup builds the router. Every /api/v1 request is recorded by meter;
+// queued exports wake exports (nil when no workers run here); race, profile
+// and calibration responses are cached in responses (nil for none).
+func Setup(db *database.DB, cfg *config.Config, meter *usage.Meter, exports *export.Runner, responses *cache.Cache) *gin.Engine {
 	// Set Gin mode
 	gin.SetMode(gin.ReleaseMode)
 
 	r := gin.New()
 
+	// X-Forwarded-For is only believed from the configured proxies (none by
+	// default), so clients can't pick the IP their quota is counted under
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		logger.Warn("Setup: trusted proxies: %v", err)
+	}
+
 	// Global middleware
 	r.Use(middleware.Logger())
 	r.Use(middleware.ErrorHandler())
-	r.Use(middleware.CORS(corsOrigins))
+	r.Use(middleware.CORS(cfg.CORS.Origins))
 
 	// Health check
 	r.GET("/health", func(c *gin.Context) {
@@ -29,6 +47,35 @@ func Setup(db *database.DB, corsOrigins []string) *gin.Engine {
 		c.JSON(200, gin.H{"status": "healthy"})
 	})
 
+	// OpenAPI document and docs UI, generated from operations (spec.go) once
+	// every route is registered
+	spec := openapi.NewSpec(apiInfo, operations)
+	r.GET("/openapi.json", spec.ServeJSON)
+	r.GET("/docs", spec.ServeDocs)
+
+	// API keys (racing.api_keys) and JWTs; every /api/v1 route needs a scope
+	authn := auth.NewAuthenticator(db.DB.DB, auth.Config{
+		Required:      cfg.Auth.Required,
+		AnonymousRole: cfg.Auth.AnonymousRole,
+		JWTSecret:     cfg.Auth.JWTSecret,
+		JWTIssuer:     cfg.Auth.JWTIssuer,
+	})
+	read := middleware.RequireScope(auth.ScopeRead)
+	analysis := middleware.RequireScope(auth.ScopeAnalysis)
+
+	// Cached by the freshness handlers mark (middleware.Cacheable)
+	cached := middleware.ResponseCache(responses)
+
+	// Quotas per client and route group (ratelimit.Routes)
+	var limiter *ratelimit.Limiter
+	if cfg.RateLimit.Enabled {
+		var store ratelimit.Store
+		if cfg.RateLimit.Store == "postgres" {
+			store = ratelimit.NewPostgresStore(db.DB.DB)
+		}
+		limiter = ratelimit.NewLimiter(store, cfg.RateLimit.Quotas)
+	}
+
 	// Initialize repositories
 	searchRepo := repository.NewSearchRepository(db)
 	profileRepo := repository.NewProfileRepository(db)
@@ -36,6 +83,15 @@ func Setup(db *database.DB, corsOrigins []string) *gin.Engine {
 	marketRepo := repository.NewMarketRepository(db)
 	biasRepo := repository.NewBiasRepository(db)
 	angleRepo := repository.NewAngleRepository(db)
+	entryRepo := repository.NewEntryRepository(db)
+	matchingRepo := repository.NewMatchingRepository(db)
+	horseIdentityRepo := repository.NewHorseIdentityRepository(db)
+	courseRegistryRepo := repository.NewCourseRegistryRepository(db)
+	qualityRepo := repository.NewQualityRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	usageRepo := repository.NewUsageRepository(db)
+	exportRepo := repository.NewExportRepository(db)
+	h2hRepo := repository.NewH2HRepository(db)
 
 	// Initialize handlers
 	searchHandler := handlers.NewSearchHandler(searchRepo)
@@ -44,86 +100,125 @@ func Setup(db *database.DB, corsOrigins []string) *gin.Engine {
 	marketHandler := handlers.NewMarketHandler(marketRepo)
 	biasHandler := handlers.NewBiasHandler(biasRepo)
 	angleHandler := handlers.NewAngleHandler(angleRepo)
+	entryHandler := handlers.NewEntryHandler(entryRepo)
+	matchingHandler := handlers.NewMatchingHandler(matchingRepo)
+	horseIdentityHandler := handlers.NewHorseIdentityHandler(horseIdentityRepo)
+	courseRegistryHandler := handlers.NewCourseRegistryHandler(courseRegistryRepo)
+	qualityHandler := handlers.NewQualityHandler(qualityRepo)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyRepo, authn)
+	usageHandler := handlers.NewUsageHandler(usageRepo, limiter)
+	exportHandler := handlers.NewExportHandler(exportRepo, exports, cfg.Export)
+	h2hHandler := handlers.NewH2HHandler(h2hRepo)
 	adminHandler := handlers.NewAdminHandler(db.DB)
+	cacheHandler := handlers.NewCacheHandler(responses)
+	graphqlHandler := gql.NewHandler(raceRepo, profileRepo, cfg.GraphQL)
 
 	// API v1 routes
 	v1 := r.Group("/api/v1")
+	v1.Use(middleware.Authenticate(authn))
+	v1.Use(middleware.Meter(meter))
+	if limiter != nil {
+		v1.Use(middleware.RateLimit(limiter))
+	}
 	v1.Use(middleware.ValidatePagination())
 	v1.Use(middleware.ValidateDateParams())
 	{
+		// Who am I (role and scopes of the caller)
+		v1.GET("/auth/me", apiKeyHandler.GetMe)
+
+		// The caller's own usage and quotas
+		v1.GET("/usage", usageHandler.GetMyUsage)
+
 		// Search endpoints
-		search := v1.Group("/search")
+		search := v1.Group("/search", read)
 		{
 			search.GET("", searchHandler.GlobalSearch)
 			search.GET("/comments", searchHandler.SearchComments)
 		}
 
 		// Horse endpoints
-		horses := v1.Group("/horses")
+		horses := v1.Group("/horses", read)
 		{
-			horses.GET("/:id/profile", profileHandler.GetHorseProfile)
+			horses.GET("/compare", cached, h2hHandler.CompareHorses)
+			horses.GET("/:id/profile", cached, profileHandler.GetHorseProfile)
+			horses.GET("/:id/runs", profileHandler.GetHorseRuns)
+			horses.GET("/:id/entries", entryHandler.GetHorseEntries)
 		}
 
 		// Trainer endpoints
-		trainers := v1.Group("/trainers")
+		trainers := v1.Group("/trainers", read)
 		{
-			trainers.GET("/:id/profile", profileHandler.GetTrainerProfile)
+			trainers.GET("/:id/profile", cached, profileHandler.GetTrainerProfile)
+			trainers.GET("/:id/runs", profileHandler.GetTrainerRuns)
+			trainers.GET("/:id/entries", entryHandler.GetTrainerEntries)
 		}
 
 		// Jockey endpoints
-		jockeys := v1.Group("/jockeys")
+		jockeys := v1.Group("/jockeys", read)
 		{
-			jockeys.GET("/:id/profile", profileHandler.GetJockeyProfile)
+			jockeys.GET("/:id/profile", cached, profileHandler.GetJockeyProfile)
+			jockeys.GET("/:id/runs", profileHandler.GetJockeyRuns)
 		}
 
 		// Race endpoints
-		races := v1.Group("/races")
+		races := v1.Group("/races", read)
 		{
 			races.GET("", raceHandler.GetRecentRaces)
 			races.GET("/search", raceHandler.SearchRaces)
-			races.GET("/:id", raceHandler.GetRace)
+			races.GET("/amended", raceHandler.GetAmendedRaces)
+			races.GET("/:id", cached, raceHandler.GetRace)
 			races.GET("/:id/runners", raceHandler.GetRaceRunners)
+			races.GET("/:id/amendments", raceHandler.GetRaceAmendments)
+			races.GET("/:id/h2h", cached, h2hHandler.GetRaceH2H)
+			races.GET("/:id/card", cached, raceHandler.GetRaceCard)
+		}
+
+		// Entries endpoints (forward entries/declarations, up to 5 days ahead)
+		entries := v1.Group("/entries", read)
+		{
+			entries.GET("", entryHandler.GetEntries)
+			entries.GET("/races/:id", entryHandler.GetRaceEntries)
 		}
 
 		// Course endpoints
-		courses := v1.Group("/courses")
+		courses := v1.Group("/courses", read)
 		{
 			courses.GET("", raceHandler.GetCourses)
 			courses.GET("/:id/meetings", raceHandler.GetCourseMeetings)
 		}
 
 		// Meetings endpoint - races grouped by venue
-		v1.GET("/meetings", raceHandler.GetMeetings)
+		v1.GET("/meetings", read, raceHandler.GetMeetings)
 
 		// Convenience endpoints for today/tomorrow (auto-calculate dates)
-		v1.GET("/today", raceHandler.GetTodayMeetings)
-		v1.GET("/tomorrow", raceHandler.GetTomorrowMeetings)
+		v1.GET("/today", read, raceHandler.GetTodayMeetings)
+		v1.GET("/tomorrow", read, raceHandler.GetTomorrowMeetings)
 
 		// Market endpoints
-		market := v1.Group("/market")
+		market := v1.Group("/market", analysis)
 		{
 			market.GET("/movers", marketHandler.GetMarketMovers)
-			market.GET("/calibration/win", marketHandler.GetWinCalibration)
-			market.GET("/calibration/place", marketHandler.GetPlaceCalibration)
+			market.GET("/calibration/win", cached, marketHandler.GetWinCalibration)
+			market.GET("/calibration/place", cached, marketHandler.GetPlaceCalibration)
 			market.GET("/inplay-moves", marketHandler.GetInPlayMoves)
 			market.GET("/book-vs-exchange", marketHandler.GetBookVsExchange)
 		}
 
 		// Bias endpoints
-		bias := v1.Group("/bias")
+		bias := v1.Group("/bias", analysis)
 		{
 			bias.GET("/draw", biasHandler.GetDrawBias)
 		}
 
 		// Analysis endpoints
-		analysis := v1.Group("/analysis")
+		analysisGroup := v1.Group("/analysis", analysis)
 		{
-			analysis.GET("/recency", biasHandler.GetRecencyEffects)
-			analysis.GET("/trainer-change", biasHandler.GetTrainerChanges)
+			analysisGroup.GET("/recency", biasHandler.GetRecencyEffects)
+			analysisGroup.GET("/trainer-change", biasHandler.GetTrainerChanges)
 		}
 
 		// Angles endpoints (betting strategies)
-		angles := v1.Group("/angles")
+		angles := v1.Group("/angles", analysis)
 		{
 			nearMiss := angles.Group("/near-miss-no-hike")
 			{
@@ -132,16 +227,82 @@ func Setup(db *database.DB, corsOrigins []string) *gin.Engine {
 			}
 		}
 
-		// Admin endpoints (data management)
-		admin := v1.Group("/admin")
+		// GraphQL over races, runners, connections and profiles, batched
+		// per request and cost-limited
+		v1.GET("/graphql", read, graphqlHandler.Serve)
+		v1.POST("/graphql", read, graphqlHandler.Serve)
+
+		// Export endpoints: short date ranges stream, anything larger is a job
+		exportGroup := v1.Group("/export", analysis)
+		{
+			exportGroup.GET("/columns", exportHandler.GetColumns)
+			exportGroup.GET("/races", exportHandler.ExportRaces)
+			exportGroup.GET("/runners", exportHandler.ExportRunners)
+			exportGroup.POST("/jobs", exportHandler.CreateJob)
+			exportGroup.GET("/jobs", exportHandler.GetJobs)
+			exportGroup.GET("/jobs/:id", exportHandler.GetJob)
+			exportGroup.GET("/jobs/:id/download", exportHandler.DownloadJob)
+		}
+
+		// Admin endpoints (data management) - admin scope only
+		admin := v1.Group("/admin", middleware.RequireScope(auth.ScopeAdmin))
 		{
 			scrape := admin.Group("/scrape")
 			{
 				scrape.POST("/yesterday", adminHandler.ScrapeYesterday)
 				scrape.POST("/date", adminHandler.ScrapeDate)
+				scrape.POST("/resume", adminHandler.ResumeIngestion)
+				scrape.POST("/reconcile", adminHandler.ReconcileResults)
 			}
+			admin.POST("/entries", adminHandler.IngestEntries)
 			admin.GET("/status", adminHandler.GetUpdateStatus)
 			admin.GET("/gaps", adminHandler.DetectGaps)
+			admin.GET("/usage", usageHandler.GetUsage)
+			admin.DELETE("/cache", cacheHandler.Purge)
+
+			matchingAdmin := admin.Group("/matching")
+			{
+				matchingAdmin.GET("/overrides", matchingHandler.GetOverrides)
+				matchingAdmin.POST("/overrides", matchingHandler.CreateOverride)
+				matchingAdmin.DELETE("/overrides/:id", matchingHandler.DeleteOverride)
+				matchingAdmin.GET("/audit", matchingHandler.GetAudit)
+			}
+
+			horsesAdmin := admin.Group("/horses")
+			{
+				horsesAdmin.GET("/conflicts", horseIdentityHandler.GetConflicts)
+				horsesAdmin.GET("/identity-log", horseIdentityHandler.GetLog)
+				horsesAdmin.POST("/merge", horseIdentityHandler.Merge)
+				horsesAdmin.GET("/:id/identity", horseIdentityHandler.GetIdentity)
+				horsesAdmin.POST("/:id/split", horseIdentityHandler.Split)
+			}
+
+			coursesAdmin := admin.Group("/courses")
+			{
+				coursesAdmin.GET("/review", courseRegistryHandler.GetReviewQueue)
+				coursesAdmin.POST("/review/:id/resolve", courseRegistryHandler.ResolveReview)
+				coursesAdmin.POST("/review/:id/ignore", courseRegistryHandler.IgnoreReview)
+				coursesAdmin.GET("/orphans", courseRegistryHandler.GetOrphans)
+				coursesAdmin.DELETE("/aliases/:alias_id", courseRegistryHandler.DeleteAlias)
+				coursesAdmin.GET("/:id", courseRegistryHandler.GetCourse)
+				coursesAdmin.PATCH("/:id", courseRegistryHandler.UpdateCourse)
+				coursesAdmin.POST("/:id/aliases", courseRegistryHandler.AddAlias)
+			}
+
+			keysAdmin := admin.Group("/keys")
+			{
+				keysAdmin.GET("", apiKeyHandler.GetKeys)
+				keysAdmin.POST("", apiKeyHandler.CreateKey)
+				keysAdmin.GET("/roles", apiKeyHandler.GetRoles)
+				keysAdmin.DELETE("/:id", apiKeyHandler.RevokeKey)
+			}
+
+			qualityAdmin := admin.Group("/quality")
+			{
+				qualityAdmin.GET("", qualityHandler.GetSummary)
+				qualityAdmin.GET("/rules", qualityHandler.GetRules)
+				qualityAdmin.GET("/:date", qualityHandler.GetDay)
+			}
 		}
 	}
 
@@ -153,5 +314,9 @@ func Setup(db *database.DB, corsOrigins []string) *gin.Engine {
 		})
 	})
 
+	if err := spec.Build(r.Routes()); err != nil {
+		logger.Error("OpenAPI: %v", err)
+	}
+
 	return r
 }
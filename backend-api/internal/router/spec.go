@@ -0,0 +1,361 @@
+package router
+
+import (
+	"net/http"
+
+	"giddyup/api/internal/auth"
+	"giddyup/api/internal/gql"
+	"giddyup/api/internal/models"
+	"giddyup/api/internal/openapi"
+	"giddyup/api/internal/pipeline"
+	"giddyup/api/internal/quality"
+)
+
+// apiInfo heads the OpenAPI document
+var apiInfo = openapi.Info{
+	Title:   "GiddyUp Racing API",
+	Version: "1.0.0",
+	Description: "UK and Irish racing results, racecards, market and analysis data. " +
+		"Send an API key or JWT as `Authorization: Bearer <key>` (or `X-API-Key`); " +
+		"x-scope is the scope each route needs and x-rate-limit-group the quota it counts against.",
+}
+
+// Query parameters shared by handlers that read them with c.Query
+var (
+	dateParam      = openapi.Param{Name: "date", Type: "date", Description: "Default today"}
+	dateRangeParam = []openapi.Param{
+		{Name: "date_from", Type: "date"},
+		{Name: "date_to", Type: "date"},
+	}
+	asParam = openapi.Param{
+		Name:        "as",
+		Description: "Results as settled (default) or as first published",
+		Enum:        []string{"settled", "first_published"},
+	}
+	cursorParam  = openapi.Param{Name: "cursor", Description: "meta.next_cursor of the previous page"}
+	fieldsParam  = openapi.Param{Name: "fields", Description: "Comma-separated race fields to return; runners.<field> for runner fields"}
+	includeParam = openapi.Param{
+		Name:        "include",
+		Description: "Comma-separated: runners, runners.sectionals, runners.form, runners.live_price (empty for none)",
+	}
+)
+
+const graphqlDescription = "Races, runners, meetings, courses, horses, trainers and jockeys with their relations " +
+	"(race.runners, runner.horse.profile, runner.form, runner.live_price, ...) in one request. Field names match " +
+	"the REST responses. Related records load in one batch per level. Queries whose estimated cost " +
+	"(extensions.cost) is over GRAPHQL_MAX_COST or nesting is over GRAPHQL_MAX_DEPTH are a 400 and run nothing."
+
+const exportStreamDescription = "Takes the race search filters; date_from and date_to are required and at most " +
+	"EXPORT_SYNC_MAX_DAYS (default 31) apart, queue a job for more. format is csv (default), ndjson or parquet; " +
+	"columns is a comma-separated list from /export/columns. The row count and any failure part way through " +
+	"are sent in the X-Export-Rows and X-Export-Error trailers."
+
+const h2hDescription = "Every pair's direct meetings in settled races and collateral form through common opponents. " +
+	"Margins are lengths A finished ahead of B (negative: behind), from beaten distances capped at 30 lengths; " +
+	"adjusted margins are at level weights (3lb a length at 5f down to 1lb at two miles and over jumps). " +
+	"A common opponent's implied margin is A's mean adjusted margin over it minus B's. " +
+	"At most the 1000 latest races the horses ran in are read."
+
+// Bodies and responses the handlers build inline
+type (
+	healthResponse struct {
+		Status string `json:"status"`
+		Error  string `json:"error,omitempty"`
+	}
+	scrapeDateRequest struct {
+		Date  string `json:"date" binding:"required,datetime=2006-01-02"`
+		Fresh bool   `json:"fresh"` // Start over instead of resuming
+	}
+	dateOrDaysRequest struct {
+		Date string `json:"date" binding:"omitempty,datetime=2006-01-02"`
+		Days int    `json:"days"`
+	}
+	resumeResponse struct {
+		Resumed []pipeline.Result `json:"resumed"`
+		Count   int               `json:"count"`
+	}
+	ingestEntriesResponse struct {
+		Date    string `json:"date"`
+		Days    int    `json:"days"`
+		Races   int    `json:"races"`
+		Entries int    `json:"entries"`
+	}
+	updateStatus struct {
+		Date          string `json:"date"`
+		Kind          string `json:"kind"`
+		Status        string `json:"status"`
+		Stage         string `json:"stage"`
+		RacesLoaded   int64  `json:"races_loaded"`
+		RunnersLoaded int64  `json:"runners_loaded"`
+		CompletedAt   string `json:"completed_at"`
+		Error         string `json:"error,omitempty"`
+	}
+	updateStatusResponse struct {
+		Updates []updateStatus `json:"updates"`
+	}
+	gapsResponse struct {
+		MissingDates []string `json:"missing_dates"`
+		Count        int      `json:"count"`
+	}
+	noteRequest struct {
+		Note string `json:"note"`
+	}
+	deletedResponse struct {
+		Deleted int64 `json:"deleted"`
+	}
+	revokedResponse struct {
+		Revoked int64 `json:"revoked"`
+	}
+	invalidatedResponse struct {
+		Invalidated []string `json:"invalidated"` // Tags dropped ("*" for everything)
+	}
+)
+
+// operations documents every route Setup registers; the router test fails
+// if a route is missing here (or listed here but not routed)
+var operations = []openapi.Operation{
+	// Meta
+	{Method: "GET", Path: "/health", Summary: "Database health check", Response: healthResponse{}},
+	{Method: "GET", Path: "/openapi.json", Summary: "This OpenAPI document", Response: map[string]interface{}{}},
+	{Method: "GET", Path: "/docs", Summary: "Interactive API docs", Response: "", ContentType: "text/html"},
+
+	// Auth and usage
+	{Method: "GET", Path: "/api/v1/auth/me", Summary: "The caller's role and scopes", Response: auth.Principal{}},
+	{Method: "GET", Path: "/api/v1/usage", Summary: "The caller's usage per day and route, with its quotas",
+		Query: models.UsageFilters{}, Response: models.UsageReport{}},
+
+	// Search
+	{Method: "GET", Path: "/api/v1/search", Summary: "Search horses, trainers, jockeys, owners and courses", Scope: auth.ScopeRead,
+		Params:   []openapi.Param{{Name: "q", Required: true}, {Name: "limit", Type: "integer", Description: "Per entity type (default 10)"}},
+		Response: models.SearchResults{}},
+	{Method: "GET", Path: "/api/v1/search/comments", Summary: "Full-text search of runner comments", Scope: auth.ScopeRead,
+		Query: models.CommentSearchParams{}, Response: models.Page[models.CommentSearchResult]{}},
+
+	// Horses, trainers, jockeys
+	{Method: "GET", Path: "/api/v1/horses/compare", Summary: "Head-to-head and collateral form of 2-10 horses", Scope: auth.ScopeRead,
+		Description: h2hDescription, Query: models.HorseCompareParams{}, Response: models.H2HComparison{}},
+	{Method: "GET", Path: "/api/v1/horses/:id/profile", Summary: "Horse profile: career, form, splits and trends", Scope: auth.ScopeRead,
+		Response: models.HorseProfile{}},
+	{Method: "GET", Path: "/api/v1/horses/:id/runs", Summary: "A horse's runs, latest first", Scope: auth.ScopeRead,
+		Query: models.CursorParams{}, Response: models.Page[models.FormEntry]{}},
+	{Method: "GET", Path: "/api/v1/horses/:id/entries", Summary: "A horse's forward entries", Scope: auth.ScopeRead,
+		Query: models.EntryFilters{}, Response: []models.Entry{}},
+	{Method: "GET", Path: "/api/v1/trainers/:id/profile", Summary: "Trainer profile", Scope: auth.ScopeRead,
+		Response: models.TrainerProfile{}},
+	{Method: "GET", Path: "/api/v1/trainers/:id/runs", Summary: "A trainer's runners, latest first", Scope: auth.ScopeRead,
+		Query: models.CursorParams{}, Response: models.Page[models.FormEntry]{}},
+	{Method: "GET", Path: "/api/v1/trainers/:id/entries", Summary: "A trainer's forward entries", Scope: auth.ScopeRead,
+		Query: models.EntryFilters{}, Response: []models.Entry{}},
+	{Method: "GET", Path: "/api/v1/jockeys/:id/profile", Summary: "Jockey profile", Scope: auth.ScopeRead,
+		Response: models.JockeyProfile{}},
+	{Method: "GET", Path: "/api/v1/jockeys/:id/runs", Summary: "A jockey's rides, latest first", Scope: auth.ScopeRead,
+		Query: models.CursorParams{}, Response: models.Page[models.FormEntry]{}},
+
+	// Races
+	{Method: "GET", Path: "/api/v1/races", Summary: "Races on a date", Scope: auth.ScopeRead,
+		Description: "No runners unless include= asks for them.",
+		Params:      []openapi.Param{dateParam, {Name: "limit", Type: "integer", Description: "Default 50"}, fieldsParam, includeParam},
+		Response:    []models.Race{}},
+	{Method: "GET", Path: "/api/v1/races/search", Summary: "Search races", Scope: auth.ScopeRead,
+		Query:       models.RaceFilters{},
+		Description: "No runners unless include= asks for them.",
+		Params:      []openapi.Param{{Name: "date", Type: "date", Description: "Sets date_from and date_to"}, fieldsParam, includeParam},
+		Response:    models.Page[models.Race]{}},
+	{Method: "GET", Path: "/api/v1/races/amended", Summary: "Races whose results were amended after settling", Scope: auth.ScopeRead,
+		Query: models.AmendedRaceFilters{}, Response: []models.AmendedRace{}},
+	{Method: "GET", Path: "/api/v1/races/:id", Summary: "A race with its runners", Scope: auth.ScopeRead,
+		Description: "include defaults to runners,runners.sectionals; with fields only the named fields (and ids) are returned.",
+		Params:      []openapi.Param{asParam, fieldsParam, includeParam}, Response: models.RaceWithRunners{}},
+	{Method: "GET", Path: "/api/v1/races/:id/runners", Summary: "A race's runners", Scope: auth.ScopeRead,
+		Params: []openapi.Param{
+			asParam,
+			{Name: "fields", Description: "Comma-separated runner fields to return"},
+			{Name: "include", Description: "Comma-separated: runners.sectionals, runners.form, runners.live_price"},
+		},
+		Response: []models.Runner{}},
+	{Method: "GET", Path: "/api/v1/races/:id/amendments", Summary: "A race's amendment history", Scope: auth.ScopeRead,
+		Response: models.RaceAmendments{}},
+	{Method: "GET", Path: "/api/v1/races/:id/h2h", Summary: "Head-to-head and collateral form of a race's field", Scope: auth.ScopeRead,
+		Description: h2hDescription + " Only form from before the race counts, from the 3 years before it unless date_from is given.",
+		Query:       models.H2HParams{}, Response: models.H2HComparison{}},
+	{Method: "GET", Path: "/api/v1/races/:id/card", Summary: "A race's full racecard", Scope: auth.ScopeRead,
+		Description: "Each runner with its last 6 runs, days since its last run, course/distance/C&D winner flags, " +
+			"record on today's going and distance band (and its best of each), trainer's 14-day form, " +
+			"jockey/trainer record and OR change since its last run. Only form from before the race counts.",
+		Response: models.RaceCard{}},
+
+	// Entries
+	{Method: "GET", Path: "/api/v1/entries", Summary: "Forward entries and declarations", Scope: auth.ScopeRead,
+		Query: models.EntryFilters{}, Response: []models.Entry{}},
+	{Method: "GET", Path: "/api/v1/entries/races/:id", Summary: "Entries for a race", Scope: auth.ScopeRead,
+		Query: models.EntryFilters{}, Response: []models.Entry{}},
+
+	// Courses and meetings
+	{Method: "GET", Path: "/api/v1/courses", Summary: "All courses", Scope: auth.ScopeRead, Response: []models.Course{}},
+	{Method: "GET", Path: "/api/v1/courses/:id/meetings", Summary: "Meetings at a course", Scope: auth.ScopeRead,
+		Params: dateRangeParam, Response: []models.Meeting{}},
+	{Method: "GET", Path: "/api/v1/meetings", Summary: "Races grouped by meeting", Scope: auth.ScopeRead,
+		Description: "include defaults to runners.",
+		Params:      []openapi.Param{dateParam, fieldsParam, includeParam}, Response: []models.MeetingWithRaces{}},
+	{Method: "GET", Path: "/api/v1/today", Summary: "Today's meetings", Scope: auth.ScopeRead,
+		Params: []openapi.Param{fieldsParam, includeParam}, Response: []models.MeetingWithRaces{}},
+	{Method: "GET", Path: "/api/v1/tomorrow", Summary: "Tomorrow's meetings", Scope: auth.ScopeRead,
+		Params: []openapi.Param{fieldsParam, includeParam}, Response: []models.MeetingWithRaces{}},
+
+	// Market
+	{Method: "GET", Path: "/api/v1/market/movers", Summary: "Steamers and drifters (morning price to BSP)", Scope: auth.ScopeAnalysis,
+		Params: []openapi.Param{
+			dateParam,
+			{Name: "min_move", Type: "number", Description: "Minimum move in percent (default 20)"},
+			{Name: "type", Enum: []string{"all", "steamer", "drifter"}},
+			{Name: "limit", Type: "integer", Description: "Default 100"},
+			cursorParam,
+		},
+		Response: models.Page[models.MarketMover]{}},
+	{Method: "GET", Path: "/api/v1/market/calibration/win", Summary: "Win market calibration by price band", Scope: auth.ScopeAnalysis,
+		Params: dateRangeParam, Response: []models.CalibrationBin{}},
+	{Method: "GET", Path: "/api/v1/market/calibration/place", Summary: "Place market calibration by price band", Scope: auth.ScopeAnalysis,
+		Params: dateRangeParam, Response: []models.CalibrationBin{}},
+	{Method: "GET", Path: "/api/v1/market/inplay-moves", Summary: "In-play price movements", Scope: auth.ScopeAnalysis,
+		Params:   append([]openapi.Param{{Name: "min_move", Type: "number", Description: "Default 20"}}, dateRangeParam...),
+		Response: []models.InPlayMove{}},
+	{Method: "GET", Path: "/api/v1/market/book-vs-exchange", Summary: "SP against BSP", Scope: auth.ScopeAnalysis,
+		Description: "With by=bookmaker, compares each bookmaker's price with the exchange and returns BookmakerVsExchange rows instead.",
+		Params: append([]openapi.Param{
+			{Name: "by", Enum: []string{"bookmaker"}},
+			{Name: "bookmaker"},
+			{Name: "max_lag_secs", Type: "integer", Description: "Default 300"},
+		}, dateRangeParam...),
+		Response: []models.BookVsExchange{}},
+
+	// Bias and analysis
+	{Method: "GET", Path: "/api/v1/bias/draw", Summary: "Draw bias at a course", Scope: auth.ScopeAnalysis,
+		Query: models.DrawBiasParams{}, Response: []models.DrawBiasResult{}},
+	{Method: "GET", Path: "/api/v1/analysis/recency", Summary: "Performance by days since last run", Scope: auth.ScopeAnalysis,
+		Params: dateRangeParam, Response: []models.RecencyEffect{}},
+	{Method: "GET", Path: "/api/v1/analysis/trainer-change", Summary: "First runs after a trainer change", Scope: auth.ScopeAnalysis,
+		Params:   []openapi.Param{{Name: "min_runs", Type: "integer", Description: "Default 5"}},
+		Response: []models.TrainerChange{}},
+
+	// Angles
+	{Method: "GET", Path: "/api/v1/angles/near-miss-no-hike/today", Summary: "Near-miss-no-hike qualifiers on a card or entries", Scope: auth.ScopeAnalysis,
+		Query: models.NearMissTodayParams{}, Response: []models.NearMissQualifier{}},
+	{Method: "GET", Path: "/api/v1/angles/near-miss-no-hike/past", Summary: "Near-miss-no-hike backtest", Scope: auth.ScopeAnalysis,
+		Query: models.NearMissPastParams{}, Response: models.NearMissPastResponse{}},
+
+	// GraphQL
+	{Method: "GET", Path: "/api/v1/graphql", Summary: "Run a GraphQL query", Scope: auth.ScopeRead,
+		Description: graphqlDescription,
+		Params: []openapi.Param{
+			{Name: "query", Required: true},
+			{Name: "operationName"},
+			{Name: "variables", Description: "JSON object"},
+		},
+		Response: gql.Response{}},
+	{Method: "POST", Path: "/api/v1/graphql", Summary: "Run a GraphQL query", Scope: auth.ScopeRead,
+		Description: graphqlDescription, Body: gql.Request{}, Response: gql.Response{}},
+
+	// Exports
+	{Method: "GET", Path: "/api/v1/export/columns", Summary: "Exportable columns per dataset", Scope: auth.ScopeAnalysis,
+		Response: []models.ExportDataset{}},
+	{Method: "GET", Path: "/api/v1/export/races", Summary: "Stream races as CSV, NDJSON or Parquet", Scope: auth.ScopeAnalysis,
+		Description: exportStreamDescription, Query: models.ExportParams{}, Response: "", ContentType: "text/csv"},
+	{Method: "GET", Path: "/api/v1/export/runners", Summary: "Stream runners as CSV, NDJSON or Parquet", Scope: auth.ScopeAnalysis,
+		Description: exportStreamDescription, Query: models.ExportParams{}, Response: "", ContentType: "text/csv"},
+	{Method: "POST", Path: "/api/v1/export/jobs", Summary: "Queue an export of any size", Scope: auth.ScopeAnalysis,
+		Body: models.ExportRequest{}, Response: models.ExportJob{}, Status: http.StatusAccepted},
+	{Method: "GET", Path: "/api/v1/export/jobs", Summary: "The caller's export jobs", Scope: auth.ScopeAnalysis,
+		Params:   []openapi.Param{{Name: "limit", Type: "integer", Description: "Latest first (default 50)"}},
+		Response: models.Page[models.ExportJob]{}},
+	{Method: "GET", Path: "/api/v1/export/jobs/:id", Summary: "Export job status", Scope: auth.ScopeAnalysis,
+		Response: models.ExportJob{}},
+	{Method: "GET", Path: "/api/v1/export/jobs/:id/download", Summary: "Download a completed export", Scope: auth.ScopeAnalysis,
+		Description: "409 until the job is completed, 410 once it has expired.", Response: "", ContentType: "application/octet-stream"},
+
+	// Admin: ingestion
+	{Method: "POST", Path: "/api/v1/admin/scrape/yesterday", Summary: "Ingest yesterday's results", Scope: auth.ScopeAdmin,
+		Response: pipeline.Result{}},
+	{Method: "POST", Path: "/api/v1/admin/scrape/date", Summary: "Ingest a date, resuming an interrupted run", Scope: auth.ScopeAdmin,
+		Body: scrapeDateRequest{}, Response: pipeline.Result{}},
+	{Method: "POST", Path: "/api/v1/admin/scrape/resume", Summary: "Resume every interrupted run", Scope: auth.ScopeAdmin,
+		Response: resumeResponse{}},
+	{Method: "POST", Path: "/api/v1/admin/scrape/reconcile", Summary: "Reconcile settled results and record amendments", Scope: auth.ScopeAdmin,
+		Description: "With {\"date\"} reconciles one date. With {\"days\"} (or no body) reconciles the last N days and returns {reconciled, count, amendments}.",
+		Body:        dateOrDaysRequest{}, Response: pipeline.ReconcileResult{}},
+	{Method: "POST", Path: "/api/v1/admin/entries", Summary: "Fetch forward entries for a date or the next N days", Scope: auth.ScopeAdmin,
+		Body: dateOrDaysRequest{}, Response: ingestEntriesResponse{}},
+	{Method: "GET", Path: "/api/v1/admin/status", Summary: "The last ten data updates", Scope: auth.ScopeAdmin,
+		Response: updateStatusResponse{}},
+	{Method: "GET", Path: "/api/v1/admin/gaps", Summary: "Dates in the last 30 days without a completed update", Scope: auth.ScopeAdmin,
+		Response: gapsResponse{}},
+	{Method: "GET", Path: "/api/v1/admin/usage", Summary: "Usage of every client", Scope: auth.ScopeAdmin,
+		Query: models.UsageFilters{}, Response: []models.UsageRow{}},
+	{Method: "DELETE", Path: "/api/v1/admin/cache", Summary: "Drop cached responses for a race or date, or all of them", Scope: auth.ScopeAdmin,
+		Params: []openapi.Param{
+			{Name: "race_id", Type: "integer", Description: "Only responses built from this race"},
+			{Name: "date", Type: "date", Description: "Only responses built from this day's races"},
+		},
+		Response: invalidatedResponse{}},
+
+	// Admin: matching
+	{Method: "GET", Path: "/api/v1/admin/matching/overrides", Summary: "Manual match overrides", Scope: auth.ScopeAdmin,
+		Params: []openapi.Param{{Name: "date", Type: "date"}}, Response: []models.MatchOverride{}},
+	{Method: "POST", Path: "/api/v1/admin/matching/overrides", Summary: "Pin or forbid a match", Scope: auth.ScopeAdmin,
+		Body: models.MatchOverrideRequest{}, Response: models.MatchOverride{}, Status: http.StatusCreated},
+	{Method: "DELETE", Path: "/api/v1/admin/matching/overrides/:id", Summary: "Remove a match override", Scope: auth.ScopeAdmin,
+		Response: deletedResponse{}},
+	{Method: "GET", Path: "/api/v1/admin/matching/audit", Summary: "Match decisions", Scope: auth.ScopeAdmin,
+		Query: models.MatchAuditFilters{}, Response: []models.MatchAudit{}},
+
+	// Admin: horse identity
+	{Method: "GET", Path: "/api/v1/admin/horses/conflicts", Summary: "Horses whose form implies more than one foaling year", Scope: auth.ScopeAdmin,
+		Params: []openapi.Param{
+			{Name: "limit", Type: "integer", Description: "Default 100"},
+			{Name: "offset", Type: "integer"},
+		},
+		Response: []models.HorseIdentityConflict{}},
+	{Method: "GET", Path: "/api/v1/admin/horses/identity-log", Summary: "Horse merges, splits and creations", Scope: auth.ScopeAdmin,
+		Query: models.HorseIdentityLogFilters{}, Response: []models.HorseIdentityLog{}},
+	{Method: "POST", Path: "/api/v1/admin/horses/merge", Summary: "Merge duplicate horses", Scope: auth.ScopeAdmin,
+		Body: models.HorseMergeRequest{}, Response: models.HorseIdentity{}},
+	{Method: "GET", Path: "/api/v1/admin/horses/:id/identity", Summary: "A horse's identity and runs by foaled year", Scope: auth.ScopeAdmin,
+		Response: models.HorseIdentity{}},
+	{Method: "POST", Path: "/api/v1/admin/horses/:id/split", Summary: "Move runs of a conflated horse to a new horse", Scope: auth.ScopeAdmin,
+		Body: models.HorseSplitRequest{}, Response: models.HorseIdentity{}, Status: http.StatusCreated},
+
+	// Admin: course registry
+	{Method: "GET", Path: "/api/v1/admin/courses/review", Summary: "Unresolved course names", Scope: auth.ScopeAdmin,
+		Query: models.CourseReviewFilters{}, Response: []models.CourseReview{}},
+	{Method: "POST", Path: "/api/v1/admin/courses/review/:id/resolve", Summary: "Resolve a course name to a course", Scope: auth.ScopeAdmin,
+		Body: models.CourseResolveRequest{}, Response: models.CourseResolveResult{}},
+	{Method: "POST", Path: "/api/v1/admin/courses/review/:id/ignore", Summary: "Ignore a course name", Scope: auth.ScopeAdmin,
+		Body: noteRequest{}, Response: models.CourseReview{}},
+	{Method: "GET", Path: "/api/v1/admin/courses/orphans", Summary: "Course IDs used by races but missing from the registry", Scope: auth.ScopeAdmin,
+		Response: []models.CourseOrphan{}},
+	{Method: "DELETE", Path: "/api/v1/admin/courses/aliases/:alias_id", Summary: "Remove a course alias", Scope: auth.ScopeAdmin,
+		Response: deletedResponse{}},
+	{Method: "GET", Path: "/api/v1/admin/courses/:id", Summary: "A course with its aliases", Scope: auth.ScopeAdmin,
+		Response: models.CourseDetail{}},
+	{Method: "PATCH", Path: "/api/v1/admin/courses/:id", Summary: "Update a course", Scope: auth.ScopeAdmin,
+		Body: models.CourseRequest{}, Response: models.CourseDetail{}},
+	{Method: "POST", Path: "/api/v1/admin/courses/:id/aliases", Summary: "Add a course alias", Scope: auth.ScopeAdmin,
+		Body: models.CourseAliasRequest{}, Response: models.CourseResolveResult{}, Status: http.StatusCreated},
+
+	// Admin: API keys
+	{Method: "GET", Path: "/api/v1/admin/keys", Summary: "API keys", Scope: auth.ScopeAdmin,
+		Query: models.APIKeyFilters{}, Response: []models.APIKey{}},
+	{Method: "POST", Path: "/api/v1/admin/keys", Summary: "Create an API key (the key is only returned here)", Scope: auth.ScopeAdmin,
+		Body: models.APIKeyRequest{}, Response: models.APIKeyCreated{}, Status: http.StatusCreated},
+	{Method: "GET", Path: "/api/v1/admin/keys/roles", Summary: "Roles and their scopes", Scope: auth.ScopeAdmin,
+		Response: map[string][]string{}},
+	{Method: "DELETE", Path: "/api/v1/admin/keys/:id", Summary: "Revoke an API key", Scope: auth.ScopeAdmin,
+		Response: revokedResponse{}},
+
+	// Admin: data quality
+	{Method: "GET", Path: "/api/v1/admin/quality", Summary: "Per-date load counts and open issues", Scope: auth.ScopeAdmin,
+		Query: models.QualityFilters{}, Response: []models.QualityDay{}},
+	{Method: "GET", Path: "/api/v1/admin/quality/rules", Summary: "Data-quality rules", Scope: auth.ScopeAdmin,
+		Response: []quality.Rule{}},
+	{Method: "GET", Path: "/api/v1/admin/quality/:date", Summary: "A date's quality issues", Scope: auth.ScopeAdmin,
+		Query: models.QualityIssueFilters{}, Response: models.QualityDayDetail{}},
+}
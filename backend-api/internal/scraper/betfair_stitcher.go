@@ -9,6 +9,8 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"giddyup/api/internal/matching"
 )
 
 // BetfairStitcher handles downloading and stitching Betfair WIN+PLACE CSVs
@@ -26,6 +28,22 @@ type StitchedRace struct {
 	Runners   []StitchedRunner
 }
 
+// MatchingRace describes the Betfair race for the matching engine
+func (r StitchedRace) MatchingRace() matching.Race {
+	horses := make([]string, 0, len(r.Runners))
+	for _, runner := range r.Runners {
+		horses = append(horses, runner.Horse)
+	}
+	return matching.Race{
+		Date:    r.Date,
+		Venue:   r.Venue,
+		OffTime: r.OffTime,
+		Name:    r.EventName,
+		Horses:  horses,
+		Ref:     matching.MarketRef(r.Venue, r.OffTime),
+	}
+}
+
 // StitchedRunner represents a runner with both WIN and PLACE prices
 type StitchedRunner struct {
 	Horse           string
@@ -88,11 +106,12 @@ func NewBetfairStitcher(dataDir string) *BetfairStitcher {
 func (bs *BetfairStitcher) StitchBetfairForDate(date string, region string) error {
 	log.Printf("[BetfairStitcher] Processing %s for %s", date, region)
 
-	// Map region: "uk" for API calls, "gb" for directory structure
+	// Map region: Betfair CSV region for API calls ("uk"), data dir for directory structure ("gb")
 	apiRegion := region
 	dirRegion := region
-	if region == "uk" {
-		dirRegion = "gb"
+	if j, ok := LookupJurisdiction(region); ok {
+		apiRegion = j.BetfairRegion
+		dirRegion = j.DataDir
 	}
 
 	// IMPORTANT: Betfair CSV for date X contains races from X-1
@@ -421,14 +440,32 @@ func (bs *BetfairStitcher) determineRaceType(eventName string) string {
 	return "flat"
 }
 
+// StitchJurisdictionsForDate downloads, stitches and loads Betfair data for every
+// enabled jurisdiction. Returns all races plus a per-region count for logging.
+func (bs *BetfairStitcher) StitchJurisdictionsForDate(date string) ([]StitchedRace, map[string]int) {
+	var all []StitchedRace
+	counts := make(map[string]int)
+
+	for _, j := range Jurisdictions() {
+		if err := bs.StitchBetfairForDate(date, j.BetfairRegion); err != nil {
+			log.Printf("[BetfairStitcher] Warning: %s stitch failed for %s: %v", j.Code, date, err)
+		}
+		races, _ := bs.LoadStitchedRacesForDate(date, j.BetfairRegion)
+		counts[j.Code] = len(races)
+		all = append(all, races...)
+	}
+
+	return all, counts
+}
+
 // LoadStitchedRacesForDate loads stitched Betfair CSVs from disk
 func (bs *BetfairStitcher) LoadStitchedRacesForDate(date string, region string) ([]StitchedRace, error) {
 	races := []StitchedRace{}
 
 	// Map region: "uk" → "gb" for directory lookups
 	dirRegion := region
-	if region == "uk" {
-		dirRegion = "gb"
+	if j, ok := LookupJurisdiction(region); ok {
+		dirRegion = j.DataDir
 	}
 
 	// Read from both flat and jumps
@@ -69,7 +69,7 @@ func (rcm *RaceCacheManager) LoadRaces(date string) ([]Race, bool, error) {
 	foundAny := false
 	
 	// Check all possible region/type combinations
-	regions := []string{"gb", "ire"}
+	regions := jurisdictionDataDirs()
 	types := []string{"flat", "jumps", "nh flat"}
 	
 	for _, region := range regions {
@@ -103,7 +103,7 @@ func (rcm *RaceCacheManager) LoadRaces(date string) ([]Race, bool, error) {
 
 // CacheExists checks if cached data exists for a date
 func (rcm *RaceCacheManager) CacheExists(date string) bool {
-	regions := []string{"gb", "ire"}
+	regions := jurisdictionDataDirs()
 	types := []string{"flat", "jumps", "nh flat"}
 	
 	for _, region := range regions {
@@ -118,3 +118,12 @@ func (rcm *RaceCacheManager) CacheExists(date string) bool {
 	return false
 }
 
+
+// jurisdictionDataDirs returns the data directory names of the enabled jurisdictions
+func jurisdictionDataDirs() []string {
+	dirs := make([]string, 0, len(Jurisdictions()))
+	for _, j := range Jurisdictions() {
+		dirs = append(dirs, j.DataDir)
+	}
+	return dirs
+}
@@ -0,0 +1,193 @@
+package scraper
+
+import (
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"giddyup/api/internal/matching"
+)
+
+// Jurisdiction describes one racing country and how each data source refers to it.
+// Off times everywhere in the pipeline stay in UK local time (that is what Sporting Life
+// and the Betfair CSVs give us after conversion); the timezone is only used to derive
+// the course-local off time.
+type Jurisdiction struct {
+	Code           string   // Region value stored in racing.races / racing.courses (GB, IRE, FR, ...)
+	Name           string   // Human readable name
+	SLCountries    []string // Sporting Life course country short names
+	BetfairCountry string   // Betfair market country code (ISO 3166-1 alpha-2)
+	BetfairRegion  string   // Region segment in Betfair SP CSV URLs (dwbfprices<region>win...)
+	DataDir        string   // Directory name under data/ (racingpost/, betfair_stitched/, master/)
+	Timezone       string   // Default IANA timezone for the jurisdiction's courses
+}
+
+// knownJurisdictions lists every jurisdiction the pipeline knows how to handle.
+// Which of them are actually scraped is controlled by the JURISDICTIONS env var.
+var knownJurisdictions = []Jurisdiction{
+	{Code: "GB", Name: "Great Britain", SLCountries: []string{"ENG", "SCO", "Wale"}, BetfairCountry: "GB", BetfairRegion: "uk", DataDir: "gb", Timezone: "Europe/London"},
+	{Code: "IRE", Name: "Ireland", SLCountries: []string{"Eire"}, BetfairCountry: "IE", BetfairRegion: "ire", DataDir: "ire", Timezone: "Europe/Dublin"},
+	{Code: "FR", Name: "France", SLCountries: []string{"FR", "FRA", "France"}, BetfairCountry: "FR", BetfairRegion: "fr", DataDir: "fr", Timezone: "Europe/Paris"},
+	{Code: "USA", Name: "United States", SLCountries: []string{"USA", "US"}, BetfairCountry: "US", BetfairRegion: "usa", DataDir: "usa", Timezone: "America/New_York"},
+	{Code: "AUS", Name: "Australia", SLCountries: []string{"AUS", "Aus"}, BetfairCountry: "AU", BetfairRegion: "aus", DataDir: "aus", Timezone: "Australia/Sydney"},
+	{Code: "RSA", Name: "South Africa", SLCountries: []string{"RSA", "SAF"}, BetfairCountry: "ZA", BetfairRegion: "rsa", DataDir: "rsa", Timezone: "Africa/Johannesburg"},
+}
+
+var (
+	enabledOnce          sync.Once
+	enabledJurisdictions []Jurisdiction
+	locations            sync.Map // IANA name -> *time.Location
+)
+
+// Jurisdictions returns the enabled jurisdictions, read once from JURISDICTIONS
+// (comma-separated codes, default "GB,IRE"). Unknown codes are logged and ignored.
+func Jurisdictions() []Jurisdiction {
+	enabledOnce.Do(func() {
+		enabledJurisdictions = parseJurisdictions(os.Getenv("JURISDICTIONS"))
+	})
+	return enabledJurisdictions
+}
+
+func parseJurisdictions(value string) []Jurisdiction {
+	if strings.TrimSpace(value) == "" {
+		value = "GB,IRE"
+	}
+
+	var out []Jurisdiction
+	seen := make(map[string]bool)
+	for _, code := range strings.Split(value, ",") {
+		code = strings.TrimSpace(code)
+		if code == "" {
+			continue
+		}
+		j, ok := LookupJurisdiction(code)
+		if !ok {
+			log.Printf("[Jurisdictions] ⚠️  Unknown jurisdiction %q - ignoring", code)
+			continue
+		}
+		if seen[j.Code] {
+			continue
+		}
+		seen[j.Code] = true
+		out = append(out, j)
+	}
+
+	if len(out) == 0 {
+		log.Printf("[Jurisdictions] ⚠️  No valid jurisdictions in %q - falling back to GB,IRE", value)
+		return parseJurisdictions("GB,IRE")
+	}
+	return out
+}
+
+// LookupJurisdiction finds a known jurisdiction by region code, Betfair country,
+// Betfair CSV region or data directory name (case-insensitive)
+func LookupJurisdiction(code string) (Jurisdiction, bool) {
+	for _, j := range knownJurisdictions {
+		if strings.EqualFold(code, j.Code) || strings.EqualFold(code, j.BetfairCountry) ||
+			strings.EqualFold(code, j.BetfairRegion) || strings.EqualFold(code, j.DataDir) {
+			return j, true
+		}
+	}
+	return Jurisdiction{}, false
+}
+
+// JurisdictionForSLCountry maps a Sporting Life course country to an enabled jurisdiction
+func JurisdictionForSLCountry(country string) (Jurisdiction, bool) {
+	for _, j := range Jurisdictions() {
+		for _, c := range j.SLCountries {
+			if strings.EqualFold(country, c) {
+				return j, true
+			}
+		}
+	}
+	return Jurisdiction{}, false
+}
+
+// JurisdictionCodes returns the enabled region codes (e.g. "GB/IRE") for log messages
+func JurisdictionCodes() string {
+	codes := make([]string, 0, len(Jurisdictions()))
+	for _, j := range Jurisdictions() {
+		codes = append(codes, j.Code)
+	}
+	return strings.Join(codes, "/")
+}
+
+// BetfairCountries returns the Betfair market countries for the enabled jurisdictions
+func BetfairCountries() []string {
+	countries := make([]string, 0, len(Jurisdictions()))
+	for _, j := range Jurisdictions() {
+		countries = append(countries, j.BetfairCountry)
+	}
+	return countries
+}
+
+// Location returns the jurisdiction's default timezone
+func (j Jurisdiction) Location() *time.Location {
+	return loadLocation(j.Timezone)
+}
+
+// CourseTimezone returns the IANA timezone for a course in a region: its
+// racing.courses timezone from the course registry (matching.UseCourseRegistry),
+// else the jurisdiction's
+func CourseTimezone(region, course string) string {
+	if tz, ok := matching.Courses().Timezone(region, course); ok {
+		return tz
+	}
+	if j, ok := LookupJurisdiction(region); ok {
+		return j.Timezone
+	}
+	return "Europe/London"
+}
+
+// OffInstant returns the moment a race goes off from its source date and UK-local off
+// time ("HH:MM"). Sources date a race by the course's own calendar, so a US evening
+// race going off after UK midnight keeps the previous day's date: the UK date is moved
+// a day until the course-local date matches. False if the time can't be parsed.
+func OffInstant(date, offTime, region, course string) (time.Time, bool) {
+	off := NormalizeHHMM(offTime)
+	if off == "" {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation("2006-01-02 15:04", date+" "+off, UK())
+	if err != nil {
+		return time.Time{}, false
+	}
+	switch local := t.In(loadLocation(CourseTimezone(region, course))).Format("2006-01-02"); {
+	case local < date:
+		t = t.AddDate(0, 0, 1)
+	case local > date:
+		t = t.AddDate(0, 0, -1)
+	}
+	return t, true
+}
+
+// LocalRaceDate is the date a race going off at t carries: the course-local date
+func LocalRaceDate(t time.Time, region, course string) string {
+	return t.In(loadLocation(CourseTimezone(region, course))).Format("2006-01-02")
+}
+
+// LocalOffTime converts a UK-local off time ("HH:MM") on a race date to the course's local time.
+// Returns "" if the time can't be parsed.
+func LocalOffTime(date, offTime, region, course string) string {
+	t, ok := OffInstant(date, offTime, region, course)
+	if !ok {
+		return ""
+	}
+	return t.In(loadLocation(CourseTimezone(region, course))).Format("15:04")
+}
+
+// loadLocation loads (and caches) an IANA timezone, falling back to UK time
+func loadLocation(name string) *time.Location {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		log.Printf("[Jurisdictions] ⚠️  Unknown timezone %q - using Europe/London", name)
+		return UK()
+	}
+	locations.Store(name, loc)
+	return loc
+}
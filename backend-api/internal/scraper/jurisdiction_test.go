@@ -0,0 +1,84 @@
+package scraper
+
+import (
+	"testing"
+
+	"giddyup/api/internal/matching"
+)
+
+func TestOffInstant(t *testing.T) {
+	// Course timezones come from racing.courses through the registry
+	courses := matching.NewCourseRegistry()
+	courses.SetTimezone("USA", "Santa Anita Park", "America/Los_Angeles")
+	matching.SetCourseRegistry(courses)
+	defer matching.SetCourseRegistry(nil)
+
+	cases := []struct {
+		name                         string
+		date, off, region, course    string
+		want                         string // UK local
+		wantLocal, wantLocalRaceDate string
+	}{
+		{name: "GB afternoon", date: "2025-10-18", off: "14:05", region: "GB", course: "Ascot",
+			want: "2025-10-18 14:05", wantLocal: "14:05", wantLocalRaceDate: "2025-10-18"},
+		{name: "US race after UK midnight", date: "2025-10-18", off: "01:30", region: "USA", course: "Belmont Park",
+			want: "2025-10-19 01:30", wantLocal: "20:30", wantLocalRaceDate: "2025-10-18"},
+		{name: "West Coast race after UK midnight", date: "2025-10-18", off: "03:30", region: "USA", course: "Santa Anita",
+			want: "2025-10-19 03:30", wantLocal: "19:30", wantLocalRaceDate: "2025-10-18"},
+		{name: "US afternoon before UK midnight", date: "2025-10-18", off: "18:40", region: "USA", course: "Belmont Park",
+			want: "2025-10-18 18:40", wantLocal: "13:40", wantLocalRaceDate: "2025-10-18"},
+		{name: "Australian race in the UK small hours", date: "2025-11-04", off: "04:00", region: "AUS", course: "Flemington",
+			want: "2025-11-04 04:00", wantLocal: "15:00", wantLocalRaceDate: "2025-11-04"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := OffInstant(tc.date, tc.off, tc.region, tc.course)
+			if !ok {
+				t.Fatal("not parsed")
+			}
+			if s := got.In(UK()).Format("2006-01-02 15:04"); s != tc.want {
+				t.Errorf("OffInstant = %s, want %s", s, tc.want)
+			}
+			if s := LocalOffTime(tc.date, tc.off, tc.region, tc.course); s != tc.wantLocal {
+				t.Errorf("LocalOffTime = %s, want %s", s, tc.wantLocal)
+			}
+			if s := LocalRaceDate(got, tc.region, tc.course); s != tc.wantLocalRaceDate {
+				t.Errorf("LocalRaceDate = %s, want %s", s, tc.wantLocalRaceDate)
+			}
+		})
+	}
+
+	if _, ok := OffInstant("2025-10-18", "", "GB", "Ascot"); ok {
+		t.Error("empty off time parsed")
+	}
+	if _, ok := OffInstant("2025-10-18", "25:00", "GB", "Ascot"); ok {
+		t.Error("25:00 parsed")
+	}
+}
+
+func TestCourseTimezone(t *testing.T) {
+	courses := matching.NewCourseRegistry()
+	courses.AddAlias("Eagle Farm", "Brisbane Eagle Farm")
+	courses.SetTimezone("AUS", "Eagle Farm", "Australia/Brisbane")
+	courses.SetTimezone("USA", "Santa Anita Park", "America/Los_Angeles")
+	matching.SetCourseRegistry(courses)
+	defer matching.SetCourseRegistry(nil)
+
+	cases := []struct {
+		region, course, want string
+	}{
+		{"AUS", "Eagle Farm", "Australia/Brisbane"},
+		{"AUS", "Brisbane Eagle Farm", "Australia/Brisbane"}, // By alias
+		{"aus", "Eagle Farm", "Australia/Brisbane"},
+		{"USA", "Santa Anita", "America/Los_Angeles"}, // Built-in long/short pair
+		{"USA", "Belmont Park", "America/New_York"},   // Not in the registry: jurisdiction default
+		{"AUS", "Flemington", "Australia/Sydney"},
+		{"GB", "Eagle Farm", "Europe/London"}, // Timezones are per region
+		{"", "Ascot", "Europe/London"},
+	}
+	for _, tc := range cases {
+		if got := CourseTimezone(tc.region, tc.course); got != tc.want {
+			t.Errorf("CourseTimezone(%q, %q) = %s, want %s", tc.region, tc.course, got, tc.want)
+		}
+	}
+}
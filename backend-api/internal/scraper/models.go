@@ -1,5 +1,11 @@
 package scraper
 
+import (
+	"time"
+
+	"giddyup/api/internal/matching"
+)
+
 // Race represents a complete race with all metadata and runners
 type Race struct {
 	// Race metadata
@@ -8,12 +14,14 @@ type Race struct {
 	CourseID int
 	Course   string
 	RaceID   int
-	OffTime  string
+	OffTime  string // UK local time
 	RaceName string
 	Type     string // Flat, Chase, Hurdle, NH Flat
 	Class    string
 	Pattern  string // Group 1, Listed, etc.
 
+	LocalOffTime string // Off time in the course's own timezone
+
 	// Race details
 	AgeBand    string
 	RatingBand string
@@ -30,48 +38,102 @@ type Race struct {
 	Runners []Runner
 }
 
+// Key returns the canonical race_key (see matching.RaceKey)
+func (r Race) Key() string {
+	return matching.RaceKey(r.Date, r.Region, r.Course, r.OffTime, r.RaceName, r.Type)
+}
+
+// MatchingRace describes the race for the matching engine
+func (r Race) MatchingRace() matching.Race {
+	horses := make([]string, 0, len(r.Runners))
+	for _, runner := range r.Runners {
+		horses = append(horses, runner.Horse)
+	}
+	return matching.Race{
+		Date:     r.Date,
+		Venue:    r.Course,
+		OffTime:  r.OffTime,
+		Name:     r.RaceName,
+		RaceType: r.Type,
+		Horses:   horses,
+		Key:      r.Key(),
+	}
+}
+
+// HasResult reports whether any runner has a finishing position (i.e. the result is in)
+func (r Race) HasResult() bool {
+	for _, runner := range r.Runners {
+		if runner.Pos != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Sectional is a single timed section of a runner's race
+type Sectional struct {
+	Seq       int     // 1-based order from the start
+	Label     string  // Source label, e.g. "Final 2f"
+	DistanceY int     // Section length in yards
+	Secs      float64 // Time for this section
+}
+
+// BookmakerOdds is one bookmaker's show for a runner at a point in time
+type BookmakerOdds struct {
+	BookmakerID   int
+	Bookmaker     string
+	Fractional    string
+	Decimal       float64
+	EachWayPlaces int
+	CapturedAt    time.Time
+}
+
 // Runner represents a single runner in a race
 type Runner struct {
-	RunnerID  int // Database runner_id (populated after insert)
-	Num       int
-	Pos       string
-	Draw      int
-	OvrBtn    string
-	Btn       string
-	HorseID   int
-	Horse     string
-	Age       int
-	Sex       string
-	Weight    string
-	Lbs       int
-	Headgear  string
-	Time      string
-	Secs      float64
-	SP        string
-	Dec       float64
-	JockeyID  int
-	Jockey    string
-	TrainerID int
-	Trainer   string
-	Prize     string
-	OR        int
-	RPR       int
-	TS        int
-	SireID    int
-	Sire      string
-	DamID     int
-	Dam       string
-	DamsireID int
-	Damsire   string
-	OwnerID   int
-	Owner     string
-	Comment   string
-	Form      string // Form summary (e.g., "1234")
+	RunnerID   int // Database runner_id (populated after insert)
+	Num        int
+	Pos        string
+	Draw       int
+	OvrBtn     string
+	Btn        string
+	HorseID    int
+	SLHorseID  int // Sporting Life horse reference (stable across renames)
+	Horse      string
+	Age        int
+	Sex        string
+	Weight     string
+	Lbs        int
+	Headgear   string
+	Time       string
+	Secs       float64
+	Sectionals []Sectional // Per-section splits (where published)
+	SP         string
+	Dec        float64
+	JockeyID   int
+	Jockey     string
+	TrainerID  int
+	Trainer    string
+	Prize      string
+	OR         int
+	RPR        int
+	TS         int
+	SireID     int
+	Sire       string
+	DamID      int
+	Dam        string
+	DamsireID  int
+	Damsire    string
+	OwnerID    int
+	Owner      string
+	Comment    string
+	Form       string // Form summary (e.g., "1234")
+	Status     string // Sporting Life ride status ("RUNNER", "NONRUNNER", ...)
 
 	// Betfair/Bookmaker data
-	BetfairSelectionID int64   // Betfair's selection ID for this runner (for easy matching!)
-	BestOdds           float64 // Best available decimal odds across all bookmakers
-	BestBookmaker      string  // Which bookmaker has best odds
+	BetfairSelectionID int64           // Betfair's selection ID for this runner (for easy matching!)
+	BestOdds           float64         // Best available decimal odds across all bookmakers
+	BestBookmaker      string          // Which bookmaker has best odds
+	BookmakerOdds      []BookmakerOdds // Every bookmaker's show at fetch time
 
 	// Betfair historical data (populated by CSV stitcher)
 	WinBSP          float64
@@ -98,6 +160,11 @@ type Runner struct {
 	PlaceWinLose    float64
 }
 
+// Key returns the canonical runner_key for this runner in a race (see matching.RunnerKey)
+func (r Runner) Key(raceKey string) string {
+	return matching.RunnerKey(raceKey, r.Horse, r.Num, r.Draw)
+}
+
 // Racecard represents today's/tomorrow's upcoming race
 type Racecard struct {
 	Date      string
@@ -4,50 +4,14 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
-	"unicode"
 
-	"golang.org/x/text/runes"
-	"golang.org/x/text/transform"
-	"golang.org/x/text/unicode/norm"
+	"giddyup/api/internal/matching"
 )
 
 // NormalizeName removes accents, punctuation, country codes
-// This matches the Python normalize_name function
+// This matches the Python normalize_name function (see matching.NormalizeName)
 func NormalizeName(name string) string {
-	if name == "" {
-		return ""
-	}
-
-	// Remove country codes: (IRE), (GB), (FR), etc.
-	if idx := strings.Index(name, "("); idx != -1 {
-		name = name[:idx]
-	}
-
-	// Convert to lowercase first
-	name = strings.ToLower(name)
-
-	// Remove Roman numerals at end: "Name II" -> "Name"
-	re := regexp.MustCompile(`\s+(i|ii|iii|iv|v|vi)$`)
-	name = re.ReplaceAllString(name, "")
-
-	// Remove accents using unicode normalization
-	// NFD = Canonical Decomposition (separates accents from base characters)
-	// Remove Mark Nonspacing (removes the accent marks)
-	// NFC = Canonical Composition (recomposes characters)
-	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
-	name, _, _ = transform.String(t, name)
-
-	// Remove punctuation
-	name = strings.ReplaceAll(name, ".", " ")
-	name = strings.ReplaceAll(name, "'", "")
-	name = strings.ReplaceAll(name, "-", " ")
-	name = strings.ReplaceAll(name, ",", " ")
-
-	// Collapse whitespace
-	re = regexp.MustCompile(`\s+`)
-	name = re.ReplaceAllString(name, " ")
-
-	return strings.TrimSpace(name)
+	return matching.NormalizeName(name)
 }
 
 // CleanString removes common CSV-breaking characters
@@ -66,21 +30,9 @@ func CleanString(s string) string {
 	return strings.TrimSpace(s)
 }
 
-// NormalizeCourseName normalizes course name for matching
+// NormalizeCourseName normalizes course name for matching (see matching.NormalizeCourseName)
 func NormalizeCourseName(course string) string {
-	course = strings.ToLower(course)
-	course = strings.TrimSpace(course)
-
-	// Remove "AW" suffix
-	course = strings.TrimSuffix(course, " (aw)")
-	course = strings.TrimSuffix(course, " aw")
-
-	// Remove common variations
-	course = strings.ReplaceAll(course, " ", "")
-	course = strings.ReplaceAll(course, "-", "")
-	course = strings.ReplaceAll(course, "'", "")
-
-	return course
+	return matching.NormalizeCourseName(course)
 }
 
 // CleanRaceName removes class/grade/pattern info from race name
@@ -136,3 +88,63 @@ func TimeVariants(hhmm string) []string {
 
 	return variants
 }
+
+// ParseDistanceFurlongs converts a race distance like "2m 4f 110y" or "7f" to furlongs
+// Returns 0 if nothing could be parsed
+func ParseDistanceFurlongs(dist string) float64 {
+	re := regexp.MustCompile(`(\d+(?:\.\d+)?)\s*([mfy])`)
+	var furlongs float64
+	for _, m := range re.FindAllStringSubmatch(strings.ToLower(dist), -1) {
+		var v float64
+		fmt.Sscanf(m[1], "%g", &v)
+		switch m[2] {
+		case "m":
+			furlongs += v * 8
+		case "f":
+			furlongs += v
+		case "y":
+			furlongs += v / 220
+		}
+	}
+	return furlongs
+}
+
+// ParseRaceTime converts a race time like "4m 12.30s", "1:12.30" or "72.3" to seconds
+// Returns 0 if the time can't be parsed
+func ParseRaceTime(raw string) float64 {
+	raw = strings.TrimSpace(strings.ToLower(raw))
+	if raw == "" {
+		return 0
+	}
+
+	var mins, secs float64
+	if m := regexp.MustCompile(`^(\d+)m\s*([\d.]+)s?$`).FindStringSubmatch(raw); m != nil {
+		fmt.Sscanf(m[1], "%g", &mins)
+		fmt.Sscanf(m[2], "%g", &secs)
+	} else if m := regexp.MustCompile(`^(\d+):([\d.]+)$`).FindStringSubmatch(raw); m != nil {
+		fmt.Sscanf(m[1], "%g", &mins)
+		fmt.Sscanf(m[2], "%g", &secs)
+	} else if m := regexp.MustCompile(`^([\d.]+)s?$`).FindStringSubmatch(raw); m != nil {
+		fmt.Sscanf(m[1], "%g", &secs)
+	} else {
+		return 0
+	}
+
+	return mins*60 + secs
+}
+
+// FractionalToDecimal converts fractional odds ("5/2", "Evs", "11/10F") to decimal
+// Returns 0 if the odds can't be parsed
+func FractionalToDecimal(frac string) float64 {
+	frac = strings.ToLower(strings.TrimSpace(frac))
+	frac = strings.TrimRight(frac, "fjc") // Favourite markers: 5/2F, 3/1JF, 2/1CF
+	if frac == "evs" || frac == "evens" || frac == "ev" {
+		return 2.0
+	}
+
+	var num, den float64
+	if n, _ := fmt.Sscanf(frac, "%g/%g", &num, &den); n != 2 || den <= 0 {
+		return 0
+	}
+	return num/den + 1
+}
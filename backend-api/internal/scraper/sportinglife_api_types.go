@@ -47,6 +47,9 @@ type SLRacecardsResponse []struct {
 
 // Step 2: /race/{id} response (full runner details)
 type SLRaceResponse struct {
+	RaceSummary struct {
+		WinningTime string `json:"winning_time"` // e.g. "4m 12.30s" (results only)
+	} `json:"race_summary"`
 	Rides []struct {
 		RideReference struct {
 			ID int `json:"id"`
@@ -81,12 +84,22 @@ type SLRaceResponse struct {
 			} `json:"owner_reference"`
 			Name string `json:"name"`
 		} `json:"owner"`
-		Weight      string      `json:"weight"`
-		FormSummary string      `json:"form_summary"`
-		Headgear    interface{} `json:"headgear"` // Can be []string or object
+		Weight        string        `json:"weight"`
+		FormSummary   string        `json:"form_summary"`
+		Headgear      interface{}   `json:"headgear"`       // Can be []string or object
+		FinishTime    string        `json:"finish_time"`    // Individual time where published
+		Sectionals    []SLSectional `json:"sectionals"`     // Per-runner splits where published
+		StartingPrice string        `json:"starting_price"` // Fractional SP, e.g. "5/2" (results only)
 	} `json:"rides"`
 }
 
+// SLSectional is one sectional split for a runner (only published for some courses)
+type SLSectional struct {
+	Name     string  `json:"name"`     // e.g. "7f-6f", "Final 2f"
+	Distance int     `json:"distance"` // Section length in yards
+	Time     float64 `json:"time"`     // Seconds for this section
+}
+
 // Step 3: /v2/racing/betting/{id} response
 type SLBettingResponse struct {
 	RaceSummary struct {
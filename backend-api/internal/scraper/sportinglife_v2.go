@@ -18,10 +18,13 @@ type SportingLifeAPIV2 struct {
 	userAgents       []string
 	lastRequestTime  time.Time
 	consecutiveFails int
+	dataDir          string // Holds the racecard cache (see SportingLifeCache)
 }
 
-func NewSportingLifeAPIV2() *SportingLifeAPIV2 {
+// NewSportingLifeAPIV2 creates a client caching what it fetches under dataDir
+func NewSportingLifeAPIV2(dataDir string) *SportingLifeAPIV2 {
 	return &SportingLifeAPIV2{
+		dataDir: dataDir,
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
@@ -71,7 +74,7 @@ type raceInfo struct {
 // GetRacesForDate uses the 3-endpoint API flow
 func (s *SportingLifeAPIV2) GetRacesForDate(date string) ([]Race, error) {
 	// Check cache first
-	cache := NewSportingLifeCache("/home/smonaghan/GiddyUp/data")
+	cache := NewSportingLifeCache(s.dataDir)
 	cachedRaces, found, err := cache.LoadRaces(date)
 	if err != nil {
 		log.Printf("[SportingLife] Warning: Cache load error: %v", err)
@@ -82,6 +85,15 @@ func (s *SportingLifeAPIV2) GetRacesForDate(date string) ([]Race, error) {
 		return cachedRaces, nil
 	}
 
+	return s.FetchRacesForDate(date)
+}
+
+// FetchRacesForDate always hits the API (bypassing the cache read) and
+// overwrites the cached copy. Used by intraday refreshes where the cached
+// card may be stale (non-runners, off time changes, results).
+func (s *SportingLifeAPIV2) FetchRacesForDate(date string) ([]Race, error) {
+	cache := NewSportingLifeCache(s.dataDir)
+
 	log.Printf("[SportingLife] Fetching races for %s via API (3-endpoint flow)...", date)
 
 	// STEP 1: Get race IDs from /racing/racecards/{date}
@@ -117,13 +129,13 @@ func (s *SportingLifeAPIV2) GetRacesForDate(date string) ([]Race, error) {
 		return nil, fmt.Errorf("parse racecards JSON failed: %w", err)
 	}
 
-	// Collect UK/IRE race IDs and metadata
+	// Collect race IDs and metadata for the enabled jurisdictions
 	var raceIDs []raceInfo
 
 	for _, meeting := range racecardsData {
 		country := meeting.MeetingSummary.Course.Country.ShortName
-		// Filter UK/IRE only
-		if country != "ENG" && country != "SCO" && country != "Wale" && country != "Eire" {
+		// Filter to enabled jurisdictions only (JURISDICTIONS, default GB/IRE)
+		if _, ok := JurisdictionForSLCountry(country); !ok {
 			continue
 		}
 
@@ -145,7 +157,7 @@ func (s *SportingLifeAPIV2) GetRacesForDate(date string) ([]Race, error) {
 		}
 	}
 
-	log.Printf("[SportingLife] Found %d UK/IRE races for %s", len(raceIDs), date)
+	log.Printf("[SportingLife] Found %d %s races for %s", len(raceIDs), JurisdictionCodes(), date)
 
 	// STEP 2 & 3: For each race, fetch betting data (includes runners + odds + selectionId!)
 	var races []Race
@@ -246,28 +258,27 @@ func (s *SportingLifeAPIV2) fetchRaceWithBetting(info raceInfo) (Race, error) {
 
 	// Build Race object using info from step 1 + betting data from step 3
 	race := Race{
-		Date:     info.Date,
-		Course:   info.CourseName,
-		CourseID: 0, // Will be populated by database lookup
-		RaceID:   info.ID,
-		RaceName: info.RaceName,
-		OffTime:  s.formatOffTime(info.Time),
-		Distance: info.Distance,
-		Going:    info.Going,
-		Class:    info.RaceClass,
-		Surface:  strings.Title(strings.ToLower(info.Surface)),
-		Ran:      len(bettingData.Rides),
-		AgeBand:  info.Age,
-	}
-
-	// Determine region from country code
-	if info.Country == "Eire" {
-		race.Region = "IRE"
-	} else if info.Country == "Wale" {
-		race.Region = "GB" // Wales is part of GB region
-	} else {
-		race.Region = "GB"
-	}
+		Date:      info.Date,
+		Course:    info.CourseName,
+		CourseID:  0, // Will be populated by database lookup
+		RaceID:    info.ID,
+		RaceName:  info.RaceName,
+		OffTime:   s.formatOffTime(info.Time),
+		Distance:  info.Distance,
+		DistanceF: ParseDistanceFurlongs(info.Distance),
+		Going:     info.Going,
+		Class:     info.RaceClass,
+		Surface:   strings.Title(strings.ToLower(info.Surface)),
+		Ran:       len(bettingData.Rides),
+		AgeBand:   info.Age,
+	}
+
+	// Determine region from country code (Wales/Scotland are part of GB)
+	race.Region = "GB"
+	if j, ok := JurisdictionForSLCountry(info.Country); ok {
+		race.Region = j.Code
+	}
+	race.LocalOffTime = LocalOffTime(race.Date, race.OffTime, race.Region, race.Course)
 
 	// Determine race type
 	race.Type = s.extractRaceType(info.RaceName, info.HasHandicap)
@@ -275,9 +286,58 @@ func (s *SportingLifeAPIV2) fetchRaceWithBetting(info raceInfo) (Race, error) {
 	// STEP 3: Merge race details + betting data
 	race.Runners = s.mergeRunnerData(raceData.Rides, bettingData.Rides)
 
+	// Stamp bookmaker shows with the time we saw them
+	capturedAt := time.Now().UTC()
+	for i := range race.Runners {
+		for j := range race.Runners[i].BookmakerOdds {
+			race.Runners[i].BookmakerOdds[j].CapturedAt = capturedAt
+		}
+	}
+
+	// Winning time belongs to the winner (others only get a time if published per ride)
+	if winTime := strings.TrimSpace(raceData.RaceSummary.WinningTime); winTime != "" {
+		for i := range race.Runners {
+			if race.Runners[i].Pos == "1" && race.Runners[i].Time == "" {
+				race.Runners[i].Time = winTime
+				race.Runners[i].Secs = ParseRaceTime(winTime)
+			}
+		}
+	}
+
 	return race, nil
 }
 
+// RefreshRace re-fetches a single race by its Sporting Life race ID, keeping
+// the card metadata (region, type, off time) from the race passed in.
+// Used to pick up results shortly after the off without re-pulling the whole day.
+func (s *SportingLifeAPIV2) RefreshRace(race Race) (Race, error) {
+	s.rateLimit()
+
+	info := raceInfo{
+		ID:         race.RaceID,
+		CourseName: race.Course,
+		RaceName:   race.RaceName,
+		Time:       race.OffTime,
+		Date:       race.Date,
+		Distance:   race.Distance,
+		Going:      race.Going,
+		RaceClass:  race.Class,
+		Age:        race.AgeBand,
+		Surface:    race.Surface,
+	}
+
+	fresh, err := s.fetchRaceWithBetting(info)
+	if err != nil {
+		return Race{}, err
+	}
+
+	fresh.Region = race.Region
+	fresh.LocalOffTime = race.LocalOffTime
+	fresh.Type = race.Type
+	fresh.CourseID = race.CourseID
+	return fresh, nil
+}
+
 // formatOffTime ensures time is in HH:MM:SS format for database
 func (s *SportingLifeAPIV2) formatOffTime(timeStr string) string {
 	// "12:35" → "12:35:00"
@@ -328,9 +388,12 @@ func (s *SportingLifeAPIV2) mergeRunnerData(
 			} `json:"owner_reference"`
 			Name string `json:"name"`
 		} `json:"owner"`
-		Weight      string      `json:"weight"`
-		FormSummary string      `json:"form_summary"`
-		Headgear    interface{} `json:"headgear"` // Can be []string or object
+		Weight        string        `json:"weight"`
+		FormSummary   string        `json:"form_summary"`
+		Headgear      interface{}   `json:"headgear"` // Can be []string or object
+		FinishTime    string        `json:"finish_time"`
+		Sectionals    []SLSectional `json:"sectionals"`
+		StartingPrice string        `json:"starting_price"`
 	},
 	bettingRides []struct {
 		RideReference struct {
@@ -368,6 +431,7 @@ func (s *SportingLifeAPIV2) mergeRunnerData(
 		selectionID   int64
 		bestOdds      float64
 		bestBookmaker string
+		odds          []BookmakerOdds
 	})
 
 	for _, bRide := range bettingRides {
@@ -376,8 +440,20 @@ func (s *SportingLifeAPIV2) mergeRunnerData(
 		var betfairSelectionID int64
 		var bestOdds float64
 		var bestBookmaker string
+		var allOdds []BookmakerOdds
 
 		for _, odds := range bRide.BookmakerOdds {
+			// Keep every bookmaker's current show (stored as price history)
+			if odds.DecimalOdds >= 1.01 {
+				allOdds = append(allOdds, BookmakerOdds{
+					BookmakerID:   odds.BookmakerID,
+					Bookmaker:     odds.BookmakerName,
+					Fractional:    odds.FractionalOdds,
+					Decimal:       odds.DecimalOdds,
+					EachWayPlaces: odds.NumberOfPlaces,
+				})
+			}
+
 			// Capture Betfair selection ID
 			if odds.BookmakerName == "Betfair Sportsbook" && odds.SelectionID != "" {
 				if selID, err := strconv.ParseInt(odds.SelectionID, 10, 64); err == nil {
@@ -396,7 +472,8 @@ func (s *SportingLifeAPIV2) mergeRunnerData(
 			selectionID   int64
 			bestOdds      float64
 			bestBookmaker string
-		}{betfairSelectionID, bestOdds, bestBookmaker}
+			odds          []BookmakerOdds
+		}{betfairSelectionID, bestOdds, bestBookmaker, allOdds}
 	}
 
 	// Build runners from race details, then merge betting data
@@ -417,6 +494,7 @@ func (s *SportingLifeAPIV2) mergeRunnerData(
 			Draw:      rRide.Stall,
 			Horse:     rRide.Horse.Name,
 			HorseID:   0, // Will be looked up in DB
+			SLHorseID: rRide.Horse.HorseReference.ID,
 			Age:       rRide.Horse.Age,
 			Jockey:    rRide.Jockey.Name,
 			JockeyID:  0, // Will be looked up in DB
@@ -425,6 +503,7 @@ func (s *SportingLifeAPIV2) mergeRunnerData(
 			Owner:     rRide.Owner.Name,
 			OwnerID:   0, // Will be looked up in DB
 			Form:      rRide.FormSummary,
+			Status:    rRide.RideStatus,
 		}
 
 		// Parse headgear (can be []string or object or null)
@@ -467,12 +546,33 @@ func (s *SportingLifeAPIV2) mergeRunnerData(
 			runner.Comment = rRide.FinishDistance // Store BTN in comment for now
 		}
 
+		// Industry starting price (results only)
+		if sp := strings.TrimSpace(rRide.StartingPrice); sp != "" {
+			runner.SP = sp
+			runner.Dec = FractionalToDecimal(sp)
+		}
+
+		// Finishing time and sectional splits (results only, not every course)
+		if ft := strings.TrimSpace(rRide.FinishTime); ft != "" {
+			runner.Time = ft
+			runner.Secs = ParseRaceTime(ft)
+		}
+		for i, sec := range rRide.Sectionals {
+			runner.Sectionals = append(runner.Sectionals, Sectional{
+				Seq:       i + 1,
+				Label:     sec.Name,
+				DistanceY: sec.Distance,
+				Secs:      sec.Time,
+			})
+		}
+
 		// Merge betting data
 		normHorse := strings.ToLower(strings.TrimSpace(runner.Horse))
 		if bettingInfo, found := bettingMap[normHorse]; found {
 			runner.BetfairSelectionID = bettingInfo.selectionID
 			runner.BestOdds = bettingInfo.bestOdds
 			runner.BestBookmaker = bettingInfo.bestBookmaker
+			runner.BookmakerOdds = bettingInfo.odds
 		}
 
 		runners = append(runners, runner)
@@ -2,8 +2,8 @@ package services
 
 import (
 	"context"
-	"crypto/md5"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"giddyup/api/internal/betfair"
+	"giddyup/api/internal/pipeline"
 	"giddyup/api/internal/scraper"
 
 	"github.com/jmoiron/sqlx"
@@ -23,6 +24,7 @@ type AutoUpdateService struct {
 	db      *sqlx.DB
 	enabled bool
 	dataDir string
+	ingest  *pipeline.Pipeline
 }
 
 // NewAutoUpdateService creates a new auto-update service
@@ -31,6 +33,7 @@ func NewAutoUpdateService(db *sqlx.DB, enabled bool, dataDir string) *AutoUpdate
 		db:      db,
 		enabled: enabled,
 		dataDir: dataDir,
+		ingest:  pipeline.New(db.DB, dataDir),
 	}
 }
 
@@ -52,6 +55,15 @@ func (s *AutoUpdateService) RunInBackground() {
 		log.Println("[AutoUpdate] 📅 Fetching today/tomorrow (always on startup)...")
 		s.handleTodaysRaces()
 
+		// Pick up any date a previous run left part-way through (crash, restart, scrape error)
+		ctx := context.Background()
+		if resumed, err := s.ingest.Resume(ctx); err != nil {
+			log.Printf("[AutoUpdate] ❌ Failed to resume interrupted runs: %v", err)
+		} else if len(resumed) > 0 {
+			log.Printf("[AutoUpdate] 🔁 Resumed %d interrupted runs", len(resumed))
+			s.refreshViews()
+		}
+
 		// Then backfill any missing historical dates
 		lastDate, err := s.getLastDateInDatabase()
 		if err != nil {
@@ -83,6 +95,11 @@ func (s *AutoUpdateService) RunInBackground() {
 			log.Printf("[AutoUpdate] Processing %s...", dateStr)
 
 			races, runners, err := s.backfillDate(dateStr)
+			if errors.Is(err, pipeline.ErrLocked) {
+				// Another loader (e.g. a cron backfill) has the date - it'll record it
+				log.Printf("[AutoUpdate] ⏭️  Skipping %s: %v", dateStr, err)
+				continue
+			}
 			if err != nil {
 				log.Printf("[AutoUpdate] ❌ Failed %s: %v", dateStr, err)
 				failureCount++
@@ -100,6 +117,10 @@ func (s *AutoUpdateService) RunInBackground() {
 			}
 		}
 
+		if successCount > 0 {
+			s.refreshViews()
+		}
+
 		log.Printf("[AutoUpdate] 🎉 Backfill complete! Success: %d, Failed: %d", successCount, failureCount)
 	}()
 }
@@ -147,6 +168,9 @@ func (s *AutoUpdateService) handleTodaysRaces() {
 
 	wg.Wait()
 	log.Printf("[AutoUpdate] ✅ Parallel load complete: TODAY (%d races) + TOMORROW (%d races)", races, tomorrowRaces)
+	if races+tomorrowRaces > 0 {
+		s.refreshViews()
+	}
 
 	// Start live prices if enabled
 	enableLivePrices := os.Getenv("ENABLE_LIVE_PRICES") == "true"
@@ -174,7 +198,7 @@ func (s *AutoUpdateService) handleTodaysRaces() {
 	}
 }
 
-// backfillRacecards fetches and inserts racecards (preliminary data) - FORCE REFRESH for today/tomorrow
+// backfillRacecards runs the pipeline for a date's racecards (preliminary data) - FORCE REFRESH for today/tomorrow
 func (s *AutoUpdateService) backfillRacecards(dateStr string, forceRefresh bool) (int, int, error) {
 	// Check if this date already has complete data (prelim=false from master files)
 	var hasCompleteData bool
@@ -192,30 +216,28 @@ func (s *AutoUpdateService) backfillRacecards(dateStr string, forceRefresh bool)
 	}
 
 	// DON'T delete - just upsert to preserve prices set by live updater
-	// The ON CONFLICT clauses in insertToDatabase will update race metadata without wiping prices
+	// The ON CONFLICT clauses in the load stage update race metadata without wiping prices
 	if forceRefresh {
 		log.Printf("[AutoUpdate]   🔄 Upserting racecards for %s (preserving prices)...", dateStr)
 	}
 
-	// ALWAYS use Sporting Life API V2 - works for all dates!
-	log.Printf("[AutoUpdate]   [1/2] Fetching racecards from Sporting Life API for %s...", dateStr)
-	slScraper := scraper.NewSportingLifeAPIV2()
-	rpRaces, err := slScraper.GetRacesForDate(dateStr)
-	if err != nil {
-		return 0, 0, fmt.Errorf("Sporting Life API failed: %w", err)
-	}
-
-	log.Printf("[AutoUpdate]   ✓ Got %d UK/IRE races from Sporting Life", len(rpRaces))
-
-	// Insert to database (without Betfair prices initially)
-	log.Printf("[AutoUpdate]   [2/2] Inserting %d races to database (prelim=true)...", len(rpRaces))
-	races, runners, err := s.insertToDatabase(dateStr, rpRaces, true) // true = prelim
+	// Cards go stale, so never resume an old card run - always fetch afresh.
+	// Views are refreshed once both days are in (see handleTodaysRaces).
+	// Today's card is needed for live prices, so wait out any other loader.
+	res, err := s.ingest.Run(context.Background(), pipeline.Options{
+		Date:         dateStr,
+		Prelim:       true,
+		Refetch:      forceRefresh,
+		Fresh:        true,
+		DeferRefresh: true,
+		Wait:         true,
+	})
 	if err != nil {
-		return 0, 0, fmt.Errorf("insert failed: %w", err)
+		return 0, 0, err
 	}
-	log.Printf("[AutoUpdate]   ✓ Inserted %d races, %d runners (preliminary)", races, runners)
+	log.Printf("[AutoUpdate]   ✓ Inserted %d races, %d runners (preliminary)", res.RacesLoaded, res.RunnersLoaded)
 
-	return races, runners, nil
+	return res.RacesLoaded, res.RunnersLoaded, nil
 }
 
 // startLivePrices discovers Betfair markets and starts the live price updater
@@ -308,6 +330,13 @@ func (s *AutoUpdateService) startLivePrices(dateStr string) error {
 	return nil
 }
 
+// refreshViews runs the pipeline's refresh stage once after a batch of dates
+func (s *AutoUpdateService) refreshViews() {
+	if err := s.ingest.RefreshViews(context.Background()); err != nil {
+		log.Printf("[AutoUpdate] ⚠️  Failed to refresh materialized views: %v", err)
+	}
+}
+
 // getLastDateInDatabase finds the most recent race date
 func (s *AutoUpdateService) getLastDateInDatabase() (time.Time, error) {
 	var lastDate time.Time
@@ -325,197 +354,21 @@ func (s *AutoUpdateService) getLastDateInDatabase() (time.Time, error) {
 	return lastDate, nil
 }
 
-// backfillDate runs the full pipeline for a single date (using Sporting Life + Betfair)
+// backfillDate runs the full pipeline for a single date (Sporting Life + Betfair),
+// resuming from the failed stage if an earlier run for the date was interrupted
 func (s *AutoUpdateService) backfillDate(dateStr string) (int, int, error) {
-	// Use Sporting Life API for historical dates
-	log.Printf("[AutoUpdate]   [1/4] Scraping Sporting Life for %s...", dateStr)
-	slScraper := scraper.NewSportingLifeAPIV2()
-	rpRaces, err := slScraper.GetRacesForDate(dateStr)
-	if err != nil {
-		return 0, 0, fmt.Errorf("scrape failed: %w", err)
-	}
-	log.Printf("[AutoUpdate]   ✓ Got %d races from Sporting Life", len(rpRaces))
-
-	// Step 2: Fetch and stitch Betfair data
-	log.Printf("[AutoUpdate]   [2/4] Fetching Betfair data...")
-	bfStitcher := scraper.NewBetfairStitcher(s.dataDir)
-	bfStitcher.StitchBetfairForDate(dateStr, "uk")
-	bfStitcher.StitchBetfairForDate(dateStr, "ire")
-
-	bfUK, _ := bfStitcher.LoadStitchedRacesForDate(dateStr, "uk")
-	bfIRE, _ := bfStitcher.LoadStitchedRacesForDate(dateStr, "ire")
-	log.Printf("[AutoUpdate]   ✓ Got %d Betfair races (UK: %d, IRE: %d)", len(bfUK)+len(bfIRE), len(bfUK), len(bfIRE))
-
-	// Step 3: Match and merge (using shared course+time logic)
-	log.Printf("[AutoUpdate]   [3/4] Matching Sporting Life with Betfair data...")
-	mergedRaces := scraper.MatchAndMerge(rpRaces, append(bfUK, bfIRE...))
-	log.Printf("[AutoUpdate]   ✓ Merged %d races", len(mergedRaces))
-
-	// Step 4: Insert to database
-	log.Printf("[AutoUpdate]   [4/4] Inserting to database...")
-	races, runners, err := s.insertToDatabase(dateStr, mergedRaces, false) // false = not prelim
-	if err != nil {
-		return 0, 0, fmt.Errorf("database insert failed: %w", err)
-	}
-	log.Printf("[AutoUpdate]   ✓ Inserted %d races, %d runners", races, runners)
-
-	return races, runners, nil
-}
-
-// matchAndMerge moved to shared scraper.MatchAndMerge() in internal/scraper/matcher.go
-
-// insertToDatabase inserts races and runners into the database
-func (s *AutoUpdateService) insertToDatabase(dateStr string, races []scraper.Race, prelim bool) (int, int, error) {
-	ctx := context.Background()
-	tx, err := s.db.BeginTx(ctx, nil)
+	res, err := s.ingest.Run(context.Background(), pipeline.Options{
+		Date:         dateStr,
+		DeferRefresh: true,
+	})
 	if err != nil {
 		return 0, 0, err
 	}
-	defer tx.Rollback()
-
-	// STEP 1 & 2: Batch upsert dimensions and get IDs back (optimized!)
-	log.Printf("[AutoUpdate]      📊 Upserting dimension tables (courses, horses, jockeys, trainers, owners)...")
-
-	// Set performance knobs for this transaction
-	tx.Exec(`SET LOCAL synchronous_commit = off`) // Safe for batch ETL
-	tx.Exec(`SET LOCAL statement_timeout = 0`)
-
-	courseIDs, horseIDs, trainerIDs, jockeyIDs, ownerIDs, err := s.batchUpsertDimensions(tx, races)
-	if err != nil {
-		return 0, 0, fmt.Errorf("failed to batch upsert dimensions: %w", err)
-	}
-
-	log.Printf("[AutoUpdate]      ✓ Upserted %d courses, %d horses, %d trainers, %d jockeys, %d owners",
-		len(courseIDs), len(horseIDs), len(trainerIDs), len(jockeyIDs), len(ownerIDs))
-
-	// Populate foreign keys using the returned ID maps (no extra queries!)
-	s.populateForeignKeysFromMaps(courseIDs, horseIDs, trainerIDs, jockeyIDs, ownerIDs, races)
-
-	raceCount := 0
-	runnerCount := 0
-
-	progressInterval := 5 // Log every 5 races
-	for raceIdx, race := range races {
-		if raceIdx > 0 && raceIdx%progressInterval == 0 {
-			log.Printf("[AutoUpdate]      📝 Progress: %d/%d races (%.0f%%), %d runners so far",
-				raceIdx, len(races), float64(raceIdx)/float64(len(races))*100, runnerCount)
-		}
-
-		// Generate race_key
-		raceKey := generateRaceKey(race)
-
-		// Insert race with prelim flag
-		var raceID int64
-		err := tx.QueryRowContext(ctx, `
-			INSERT INTO racing.races (
-				race_key, race_date, region, course_id, off_time,
-				race_name, race_type, class, dist_raw, dist_f, dist_m,
-				going, surface, ran, prelim
-			) VALUES (
-				$1, $2, $3, $4, $5,
-				$6, $7, $8, $9, $10, $11,
-				$12, $13, $14, $15
-			)
-			ON CONFLICT (race_key, race_date) DO UPDATE SET
-				race_name = EXCLUDED.race_name,
-				ran = EXCLUDED.ran,
-				prelim = EXCLUDED.prelim
-			RETURNING race_id
-		`, raceKey, race.Date, race.Region, nullInt64(race.CourseID), nullString(race.OffTime),
-			race.RaceName, race.Type, nullString(race.Class), nullString(race.Distance),
-			nullFloat64(race.DistanceF), nullInt(race.DistanceM),
-			nullString(race.Going), nullString(race.Surface), race.Ran, prelim).Scan(&raceID)
-
-		if err != nil {
-			log.Printf("[AutoUpdate]      ❌ Failed to insert race %d (%s %s): %v", raceIdx, race.Course, race.OffTime, err)
-			return 0, 0, fmt.Errorf("failed to insert race %s: %w", raceKey, err)
-		}
-
-		raceCount++
-
-		// Insert runners and capture runner_ids
-		for j, runner := range race.Runners {
-			if j == 0 && raceIdx%10 == 0 {
-				log.Printf("[AutoUpdate]         Inserting %d runners for race %d...", len(race.Runners), raceIdx)
-			}
-			runnerKey := generateRunnerKey(raceKey, runner)
-
-			var runnerID int64
-			err := tx.QueryRowContext(ctx, `
-				INSERT INTO racing.runners (
-					runner_key, race_id, race_date,
-					horse_id, trainer_id, jockey_id, owner_id,
-					num, pos_raw, draw, age, lbs, "or", rpr, comment,
-					win_bsp, win_ppwap, place_bsp, place_ppwap,
-					betfair_selection_id, best_odds, best_bookmaker
-				) VALUES (
-					$1, $2, $3,
-					$4, $5, $6, $7,
-					$8, $9, $10, $11, $12, $13, $14, $15,
-					$16, $17, $18, $19,
-					$20, $21, $22
-				)
-				ON CONFLICT (runner_key, race_date) DO UPDATE SET
-					pos_raw = COALESCE(EXCLUDED.pos_raw, racing.runners.pos_raw),
-					win_bsp = COALESCE(EXCLUDED.win_bsp, racing.runners.win_bsp),
-					win_ppwap = COALESCE(EXCLUDED.win_ppwap, racing.runners.win_ppwap),
-					place_bsp = COALESCE(EXCLUDED.place_bsp, racing.runners.place_bsp),
-					place_ppwap = COALESCE(EXCLUDED.place_ppwap, racing.runners.place_ppwap),
-					betfair_selection_id = COALESCE(EXCLUDED.betfair_selection_id, racing.runners.betfair_selection_id),
-					best_odds = COALESCE(EXCLUDED.best_odds, racing.runners.best_odds),
-					best_bookmaker = COALESCE(EXCLUDED.best_bookmaker, racing.runners.best_bookmaker)
-				RETURNING runner_id
-			`,
-				runnerKey, raceID, race.Date,
-				nullInt64(runner.HorseID), nullInt64(runner.TrainerID), nullInt64(runner.JockeyID), nullInt64(runner.OwnerID),
-				nullInt(runner.Num), nullString(runner.Pos), nullInt(runner.Draw), nullInt(runner.Age),
-				nullInt(runner.Lbs), nullInt(runner.OR), nullInt(runner.RPR), nullString(runner.Comment),
-				nullFloat64BSP(runner.WinBSP), nullFloat64(runner.WinPPWAP),
-				nullFloat64BSP(runner.PlaceBSP), nullFloat64(runner.PlacePPWAP),
-				nullInt64(int(runner.BetfairSelectionID)), nullFloat64(runner.BestOdds), nullString(runner.BestBookmaker),
-			).Scan(&runnerID)
-
-			if err != nil {
-				return 0, 0, fmt.Errorf("failed to insert runner %s: %w", runnerKey, err)
-			}
-
-			// Store runner_id back in the race data for Betfair matching
-			race.Runners[j].RunnerID = int(runnerID)
-			runnerCount++
-		}
-	}
-
-	if err := tx.Commit(); err != nil {
-		return 0, 0, fmt.Errorf("failed to commit transaction: %w", err)
-	}
-
-	return raceCount, runnerCount, nil
+	return res.RacesLoaded, res.RunnersLoaded, nil
 }
 
 // Helper functions
 
-func generateRaceKey(race scraper.Race) string {
-	normCourse := strings.ToLower(strings.TrimSpace(race.Course))
-	normTime := race.OffTime
-	if len(normTime) >= 5 {
-		normTime = normTime[:5] // Strip seconds: "12:35:00" → "12:35"
-	}
-	normName := strings.ToLower(strings.TrimSpace(race.RaceName))
-	normType := strings.ToLower(strings.TrimSpace(race.Type))
-	normRegion := strings.ToUpper(strings.TrimSpace(race.Region))
-
-	data := fmt.Sprintf("%s|%s|%s|%s|%s|%s", race.Date, normRegion, normCourse, normTime, normName, normType)
-	hash := md5.Sum([]byte(data))
-	return fmt.Sprintf("%x", hash)
-}
-
-func generateRunnerKey(raceKey string, runner scraper.Runner) string {
-	normHorse := strings.ToLower(strings.TrimSpace(runner.Horse))
-	data := fmt.Sprintf("%s|%s|%d|%d", raceKey, normHorse, runner.Num, runner.Draw)
-	hash := md5.Sum([]byte(data))
-	return fmt.Sprintf("%x", hash)
-}
-
 // normalizeTime and parseFloat moved to internal/scraper/matcher.go (shared functions)
 
 // loadRacesFromDB loads races from database with runner IDs populated
@@ -595,7 +448,7 @@ func (s *AutoUpdateService) loadRacesFromDB(dateStr string) ([]scraper.Race, map
 			racesMap[raceID] = race
 
 			// Generate race key for mapping
-			raceKey := generateRaceKey(*race)
+			raceKey := race.Key()
 			raceIDMap[raceKey] = raceID
 		}
 
@@ -667,336 +520,3 @@ func nullFloat64(f float64) interface{} {
 	}
 	return f
 }
-
-func nullFloat64BSP(f float64) interface{} {
-	if f < 1.01 {
-		return nil
-	}
-	return f
-}
-
-// batchUpsertDimensions performs optimized batch upsert and returns ID maps
-// Replaces ~5,000 individual queries with ~15 batch queries (300x faster!)
-func (s *AutoUpdateService) batchUpsertDimensions(tx *sql.Tx, races []scraper.Race) (
-	map[string]int64, map[string]int64, map[string]int64, map[string]int64, map[string]int64, error) {
-
-	// Collect unique entities
-	courses := make(map[string]string) // name -> region
-	horseSet := make(map[string]struct{})
-	trainerSet := make(map[string]struct{})
-	jockeySet := make(map[string]struct{})
-	ownerSet := make(map[string]struct{})
-
-	for _, race := range races {
-		if cn := strings.TrimSpace(race.Course); cn != "" {
-			courses[cn] = race.Region
-		}
-		for _, runner := range race.Runners {
-			if v := strings.TrimSpace(runner.Horse); v != "" {
-				horseSet[v] = struct{}{}
-			}
-			if v := strings.TrimSpace(runner.Trainer); v != "" {
-				trainerSet[v] = struct{}{}
-			}
-			if v := strings.TrimSpace(runner.Jockey); v != "" {
-				jockeySet[v] = struct{}{}
-			}
-			if v := strings.TrimSpace(runner.Owner); v != "" {
-				ownerSet[v] = struct{}{}
-			}
-		}
-	}
-
-	// Convert sets to slices
-	toSlice := func(m map[string]struct{}) []string {
-		out := make([]string, 0, len(m))
-		for k := range m {
-			out = append(out, k)
-		}
-		return out
-	}
-
-	// Batch upsert all entities (3 queries each instead of N*2)
-	courseIDs, err := UpsertCoursesAndFetchIDs(tx, courses)
-	if err != nil {
-		return nil, nil, nil, nil, nil, err
-	}
-
-	horseIDs, err := UpsertNamesAndFetchIDs(tx, "horses", "horse_id", "horse_name", "horses_uniq", toSlice(horseSet))
-	if err != nil {
-		return nil, nil, nil, nil, nil, err
-	}
-
-	trainerIDs, err := UpsertNamesAndFetchIDs(tx, "trainers", "trainer_id", "trainer_name", "trainers_uniq", toSlice(trainerSet))
-	if err != nil {
-		return nil, nil, nil, nil, nil, err
-	}
-
-	jockeyIDs, err := UpsertNamesAndFetchIDs(tx, "jockeys", "jockey_id", "jockey_name", "jockeys_uniq", toSlice(jockeySet))
-	if err != nil {
-		return nil, nil, nil, nil, nil, err
-	}
-
-	ownerIDs, err := UpsertNamesAndFetchIDs(tx, "owners", "owner_id", "owner_name", "owners_uniq", toSlice(ownerSet))
-	if err != nil {
-		return nil, nil, nil, nil, nil, err
-	}
-
-	return courseIDs, horseIDs, trainerIDs, jockeyIDs, ownerIDs, nil
-}
-
-// OLD upsertDimensions (kept for reference, will be removed)
-func (s *AutoUpdateService) upsertDimensionsOld(tx *sql.Tx, races []scraper.Race) error {
-	// Collect all unique names
-	courses := make(map[string]string) // courseName -> region
-	horses := make(map[string]bool)
-	trainers := make(map[string]bool)
-	jockeys := make(map[string]bool)
-	owners := make(map[string]bool)
-
-	for _, race := range races {
-		// Collect course names
-		if race.Course != "" {
-			courses[strings.TrimSpace(race.Course)] = race.Region
-		}
-
-		for _, runner := range race.Runners {
-			if runner.Horse != "" {
-				horses[strings.TrimSpace(runner.Horse)] = true
-			}
-			if runner.Trainer != "" {
-				trainers[strings.TrimSpace(runner.Trainer)] = true
-			}
-			if runner.Jockey != "" {
-				jockeys[strings.TrimSpace(runner.Jockey)] = true
-			}
-			if runner.Owner != "" {
-				owners[strings.TrimSpace(runner.Owner)] = true
-			}
-		}
-	}
-
-	// Upsert courses
-	for courseName, region := range courses {
-		_, err := tx.Exec(`
-			INSERT INTO racing.courses (course_name, region)
-			VALUES ($1, $2)
-			ON CONFLICT ON CONSTRAINT courses_uniq DO NOTHING
-		`, courseName, region)
-		if err != nil {
-			return fmt.Errorf("failed to upsert course %s: %w", courseName, err)
-		}
-	}
-
-	// Upsert horses
-	for horse := range horses {
-		_, err := tx.Exec(`
-			INSERT INTO racing.horses (horse_name)
-			VALUES ($1)
-			ON CONFLICT ON CONSTRAINT horses_uniq DO NOTHING
-		`, horse)
-		if err != nil {
-			return fmt.Errorf("failed to upsert horse %s: %w", horse, err)
-		}
-	}
-
-	// Upsert trainers
-	for trainer := range trainers {
-		_, err := tx.Exec(`
-			INSERT INTO racing.trainers (trainer_name)
-			VALUES ($1)
-			ON CONFLICT ON CONSTRAINT trainers_uniq DO NOTHING
-		`, trainer)
-		if err != nil {
-			return fmt.Errorf("failed to upsert trainer %s: %w", trainer, err)
-		}
-	}
-
-	// Upsert jockeys
-	for jockey := range jockeys {
-		_, err := tx.Exec(`
-			INSERT INTO racing.jockeys (jockey_name)
-			VALUES ($1)
-			ON CONFLICT ON CONSTRAINT jockeys_uniq DO NOTHING
-		`, jockey)
-		if err != nil {
-			return fmt.Errorf("failed to upsert jockey %s: %w", jockey, err)
-		}
-	}
-
-	// Upsert owners
-	for owner := range owners {
-		_, err := tx.Exec(`
-			INSERT INTO racing.owners (owner_name)
-			VALUES ($1)
-			ON CONFLICT ON CONSTRAINT owners_uniq DO NOTHING
-		`, owner)
-		if err != nil {
-			return fmt.Errorf("failed to upsert owner %s: %w", owner, err)
-		}
-	}
-
-	log.Printf("[AutoUpdate]      ✓ Upserted %d courses, %d horses, %d trainers, %d jockeys, %d owners",
-		len(courses), len(horses), len(trainers), len(jockeys), len(owners))
-
-	return nil
-}
-
-// populateForeignKeysFromMaps populates foreign keys using pre-fetched ID maps (no queries!)
-func (s *AutoUpdateService) populateForeignKeysFromMaps(
-	courseIDs, horseIDs, trainerIDs, jockeyIDs, ownerIDs map[string]int64,
-	races []scraper.Race) {
-
-	for i := range races {
-		// Populate course_id
-		if id, ok := courseIDs[strings.TrimSpace(races[i].Course)]; ok {
-			races[i].CourseID = int(id)
-		}
-
-		// Populate runner foreign keys
-		for j := range races[i].Runners {
-			runner := &races[i].Runners[j]
-
-			if id, ok := horseIDs[strings.TrimSpace(runner.Horse)]; ok {
-				runner.HorseID = int(id)
-			}
-			if id, ok := trainerIDs[strings.TrimSpace(runner.Trainer)]; ok {
-				runner.TrainerID = int(id)
-			}
-			if id, ok := jockeyIDs[strings.TrimSpace(runner.Jockey)]; ok {
-				runner.JockeyID = int(id)
-			}
-			if id, ok := ownerIDs[strings.TrimSpace(runner.Owner)]; ok {
-				runner.OwnerID = int(id)
-			}
-		}
-	}
-}
-
-// OLD populateForeignKeys (kept for reference, will be removed)
-func (s *AutoUpdateService) populateForeignKeysOld(tx *sql.Tx, races []scraper.Race) error {
-	// Create lookup maps
-	courseIDs := make(map[string]int64)
-	horseIDs := make(map[string]int64)
-	trainerIDs := make(map[string]int64)
-	jockeyIDs := make(map[string]int64)
-	ownerIDs := make(map[string]int64)
-
-	// Collect all unique names to look up
-	courses := make(map[string]bool)
-	horses := make(map[string]bool)
-	trainers := make(map[string]bool)
-	jockeys := make(map[string]bool)
-	owners := make(map[string]bool)
-
-	for _, race := range races {
-		if race.Course != "" {
-			courses[strings.TrimSpace(race.Course)] = true
-		}
-
-		for _, runner := range race.Runners {
-			if runner.Horse != "" {
-				horses[strings.TrimSpace(runner.Horse)] = true
-			}
-			if runner.Trainer != "" {
-				trainers[strings.TrimSpace(runner.Trainer)] = true
-			}
-			if runner.Jockey != "" {
-				jockeys[strings.TrimSpace(runner.Jockey)] = true
-			}
-			if runner.Owner != "" {
-				owners[strings.TrimSpace(runner.Owner)] = true
-			}
-		}
-	}
-
-	// Look up course IDs
-	for courseName := range courses {
-		var id int64
-		err := tx.QueryRow(`
-			SELECT course_id FROM racing.courses
-			WHERE racing.norm_text(course_name) = racing.norm_text($1)
-		`, courseName).Scan(&id)
-		if err == nil {
-			courseIDs[strings.TrimSpace(courseName)] = id
-		}
-	}
-
-	// Look up horse IDs
-	for horse := range horses {
-		var id int64
-		err := tx.QueryRow(`
-			SELECT horse_id FROM racing.horses
-			WHERE racing.norm_text(horse_name) = racing.norm_text($1)
-		`, horse).Scan(&id)
-		if err == nil {
-			horseIDs[strings.TrimSpace(horse)] = id
-		}
-	}
-
-	// Look up trainer IDs
-	for trainer := range trainers {
-		var id int64
-		err := tx.QueryRow(`
-			SELECT trainer_id FROM racing.trainers
-			WHERE racing.norm_text(trainer_name) = racing.norm_text($1)
-		`, trainer).Scan(&id)
-		if err == nil {
-			trainerIDs[strings.TrimSpace(trainer)] = id
-		}
-	}
-
-	// Look up jockey IDs
-	for jockey := range jockeys {
-		var id int64
-		err := tx.QueryRow(`
-			SELECT jockey_id FROM racing.jockeys
-			WHERE racing.norm_text(jockey_name) = racing.norm_text($1)
-		`, jockey).Scan(&id)
-		if err == nil {
-			jockeyIDs[strings.TrimSpace(jockey)] = id
-		}
-	}
-
-	// Look up owner IDs
-	for owner := range owners {
-		var id int64
-		err := tx.QueryRow(`
-			SELECT owner_id FROM racing.owners
-			WHERE racing.norm_text(owner_name) = racing.norm_text($1)
-		`, owner).Scan(&id)
-		if err == nil {
-			ownerIDs[strings.TrimSpace(owner)] = id
-		}
-	}
-
-	// Populate foreign keys in the race data
-	for i := range races {
-		// Populate course_id for the race
-		if id, ok := courseIDs[strings.TrimSpace(races[i].Course)]; ok {
-			races[i].CourseID = int(id)
-		}
-
-		for j := range races[i].Runners {
-			runner := &races[i].Runners[j]
-
-			if id, ok := horseIDs[strings.TrimSpace(runner.Horse)]; ok {
-				runner.HorseID = int(id)
-			}
-			if id, ok := trainerIDs[strings.TrimSpace(runner.Trainer)]; ok {
-				runner.TrainerID = int(id)
-			}
-			if id, ok := jockeyIDs[strings.TrimSpace(runner.Jockey)]; ok {
-				runner.JockeyID = int(id)
-			}
-			if id, ok := ownerIDs[strings.TrimSpace(runner.Owner)]; ok {
-				runner.OwnerID = int(id)
-			}
-		}
-	}
-
-	log.Printf("[AutoUpdate]      ✓ Populated foreign keys: %d courses, %d horses, %d trainers, %d jockeys, %d owners",
-		len(courseIDs), len(horseIDs), len(trainerIDs), len(jockeyIDs), len(ownerIDs))
-
-	return nil
-}
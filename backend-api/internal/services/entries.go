@@ -0,0 +1,237 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"giddyup/api/internal/pipeline"
+	"giddyup/api/internal/scraper"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Entry statuses (see migration 013_entries.sql)
+const (
+	EntryStatusEntered   = "entered"
+	EntryStatusDeclared  = "declared"
+	EntryStatusRunner    = "runner"
+	EntryStatusNonRunner = "non_runner"
+	EntryStatusScratched = "scratched"
+)
+
+// EntriesService ingests forward entries and declarations into racing.entries
+type EntriesService struct {
+	db      *sqlx.DB
+	dataDir string
+}
+
+// NewEntriesService creates a new entries service; dataDir holds the
+// Sporting Life cache
+func NewEntriesService(db *sqlx.DB, dataDir string) *EntriesService {
+	return &EntriesService{
+		db:      db,
+		dataDir: dataDir,
+	}
+}
+
+// IngestAhead fetches entries for today and the next `days` days
+func (es *EntriesService) IngestAhead(days int) (int, int, error) {
+	today := time.Now().In(scraper.UK())
+
+	totalRaces, totalEntries := 0, 0
+	var lastErr error
+	for i := 0; i <= days; i++ {
+		dateStr := today.AddDate(0, 0, i).Format("2006-01-02")
+		races, entries, err := es.IngestDate(dateStr)
+		if err != nil {
+			log.Printf("[Entries] ⚠️  %s failed: %v", dateStr, err)
+			lastErr = err
+			continue
+		}
+		totalRaces += races
+		totalEntries += entries
+	}
+
+	return totalRaces, totalEntries, lastErr
+}
+
+// IngestBigRaces fetches long-range entries for big-race dates (YYYY-MM-DD)
+// beyond IngestAhead's window of today plus `days`; dates inside the window
+// or already run are skipped
+func (es *EntriesService) IngestBigRaces(dates []string, days int) (int, int, error) {
+	horizon := time.Now().In(scraper.UK()).AddDate(0, 0, days).Format("2006-01-02")
+
+	totalRaces, totalEntries := 0, 0
+	var lastErr error
+	for _, dateStr := range dates {
+		if dateStr <= horizon {
+			continue
+		}
+		races, entries, err := es.IngestDate(dateStr)
+		if err != nil {
+			log.Printf("[Entries] ⚠️  %s failed: %v", dateStr, err)
+			lastErr = err
+			continue
+		}
+		totalRaces += races
+		totalEntries += entries
+	}
+
+	return totalRaces, totalEntries, lastErr
+}
+
+// IngestDate fetches the Sporting Life cards for a date (entries, declarations or
+// results - whatever is published) and records each horse's entry status
+func (es *EntriesService) IngestDate(dateStr string) (int, int, error) {
+	log.Printf("[Entries] Fetching entries for %s...", dateStr)
+
+	slScraper := scraper.NewSportingLifeAPIV2(es.dataDir)
+	races, err := slScraper.FetchRacesForDate(dateStr)
+	if err != nil {
+		return 0, 0, fmt.Errorf("Sporting Life API failed: %w", err)
+	}
+
+	return es.IngestRaces(races)
+}
+
+// IngestRaces upserts already-fetched races into entry_races/entries
+func (es *EntriesService) IngestRaces(races []scraper.Race) (int, int, error) {
+	if len(races) == 0 {
+		return 0, 0, nil
+	}
+
+	tx, err := es.db.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	if err := pipeline.ResolveDimensions(tx, races); err != nil {
+		return 0, 0, fmt.Errorf("failed to resolve dimensions: %w", err)
+	}
+
+	raceCount, entryCount := 0, 0
+	for _, race := range races {
+		if race.RaceID == 0 {
+			continue
+		}
+
+		_, err := tx.Exec(`
+			INSERT INTO racing.entry_races (
+				sl_race_id, race_date, region, course_id, off_time,
+				race_name, race_type, class, dist_raw, dist_f,
+				going, surface, race_key
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			ON CONFLICT (sl_race_id) DO UPDATE SET
+				race_date = EXCLUDED.race_date,
+				course_id = COALESCE(EXCLUDED.course_id, racing.entry_races.course_id),
+				off_time = COALESCE(EXCLUDED.off_time, racing.entry_races.off_time),
+				race_name = EXCLUDED.race_name,
+				race_type = EXCLUDED.race_type,
+				class = COALESCE(EXCLUDED.class, racing.entry_races.class),
+				dist_raw = COALESCE(EXCLUDED.dist_raw, racing.entry_races.dist_raw),
+				dist_f = COALESCE(EXCLUDED.dist_f, racing.entry_races.dist_f),
+				going = COALESCE(EXCLUDED.going, racing.entry_races.going),
+				surface = COALESCE(EXCLUDED.surface, racing.entry_races.surface),
+				race_key = EXCLUDED.race_key,
+				updated_at = now()
+		`, race.RaceID, race.Date, race.Region, nullInt64(race.CourseID), nullString(race.OffTime),
+			race.RaceName, nullString(race.Type), nullString(race.Class), nullString(race.Distance),
+			nullFloat64(race.DistanceF), nullString(race.Going), nullString(race.Surface),
+			race.Key())
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to upsert entry race %d: %w", race.RaceID, err)
+		}
+		raceCount++
+
+		declared := false
+		var seen []int64
+		for _, runner := range race.Runners {
+			if runner.HorseID == 0 {
+				continue
+			}
+
+			status := entryStatus(race, runner)
+			if status != EntryStatusEntered {
+				declared = true
+			}
+
+			_, err := tx.Exec(`
+				INSERT INTO racing.entries (
+					sl_race_id, race_date, horse_id, trainer_id, jockey_id, owner_id,
+					num, draw, lbs, "or", status,
+					declared_at, settled_at
+				) VALUES (
+					$1, $2, $3, $4, $5, $6,
+					$7, $8, $9, $10, $11,
+					CASE WHEN racing.entry_status_rank($11) >= 2 THEN now() END,
+					CASE WHEN racing.entry_status_rank($11) >= 3 THEN now() END
+				)
+				ON CONFLICT ON CONSTRAINT entries_uniq DO UPDATE SET
+					race_date = EXCLUDED.race_date,
+					trainer_id = COALESCE(EXCLUDED.trainer_id, racing.entries.trainer_id),
+					jockey_id = COALESCE(EXCLUDED.jockey_id, racing.entries.jockey_id),
+					owner_id = COALESCE(EXCLUDED.owner_id, racing.entries.owner_id),
+					num = COALESCE(EXCLUDED.num, racing.entries.num),
+					draw = COALESCE(EXCLUDED.draw, racing.entries.draw),
+					lbs = COALESCE(EXCLUDED.lbs, racing.entries.lbs),
+					"or" = COALESCE(EXCLUDED."or", racing.entries."or"),
+					status = CASE
+						WHEN racing.entry_status_rank(EXCLUDED.status) >= racing.entry_status_rank(racing.entries.status)
+						THEN EXCLUDED.status ELSE racing.entries.status END,
+					declared_at = COALESCE(racing.entries.declared_at, EXCLUDED.declared_at),
+					settled_at = COALESCE(racing.entries.settled_at, EXCLUDED.settled_at),
+					updated_at = now()
+			`, race.RaceID, race.Date, runner.HorseID, nullInt64(runner.TrainerID), nullInt64(runner.JockeyID),
+				nullInt64(runner.OwnerID), nullInt(runner.Num), nullInt(runner.Draw), nullInt(runner.Lbs),
+				nullInt(runner.OR), status)
+			if err != nil {
+				return 0, 0, fmt.Errorf("failed to upsert entry %s: %w", runner.Horse, err)
+			}
+			seen = append(seen, int64(runner.HorseID))
+			entryCount++
+		}
+
+		// Once the field is declared, anything no longer listed either missed the
+		// declaration stage (scratched) or was withdrawn after it (non-runner)
+		if declared {
+			_, err := tx.Exec(`
+				UPDATE racing.entries SET
+					status = CASE WHEN status = 'entered' THEN 'scratched' ELSE 'non_runner' END,
+					settled_at = now(),
+					updated_at = now()
+				WHERE sl_race_id = $1
+					AND status IN ('entered', 'declared')
+					AND NOT (horse_id = ANY($2))
+			`, race.RaceID, pq.Array(seen))
+			if err != nil {
+				return 0, 0, fmt.Errorf("failed to settle missing entries for race %d: %w", race.RaceID, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit entries: %w", err)
+	}
+
+	log.Printf("[Entries] ✅ %d races, %d entries", raceCount, entryCount)
+	return raceCount, entryCount, nil
+}
+
+// entryStatus derives a horse's entry status from the Sporting Life card.
+// Entries carry no jockey or stall; declarations do; results have positions.
+func entryStatus(race scraper.Race, runner scraper.Runner) string {
+	if strings.EqualFold(strings.ReplaceAll(runner.Status, "_", ""), "NONRUNNER") {
+		return EntryStatusNonRunner
+	}
+	if race.HasResult() {
+		return EntryStatusRunner
+	}
+	if strings.TrimSpace(runner.Jockey) != "" || runner.Draw > 0 {
+		return EntryStatusDeclared
+	}
+	return EntryStatusEntered
+}
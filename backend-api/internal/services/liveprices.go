@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"giddyup/api/internal/betfair"
+	"giddyup/api/internal/cache"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -102,12 +103,14 @@ func (s *LivePricesService) fetchAndUpdate(ctx context.Context) error {
 	// Process each market
 	ts := time.Now()
 	totalUpdates := 0
+	var raceIDs []int64
 
 	for _, book := range marketBooks {
 		mapping, exists := s.marketMappings[book.MarketID]
 		if !exists {
 			continue
 		}
+		raceIDs = append(raceIDs, mapping.RaceID)
 
 		// Process each runner
 		for _, runner := range book.Runners {
@@ -146,6 +149,9 @@ func (s *LivePricesService) fetchAndUpdate(ctx context.Context) error {
 		log.Printf("[LivePrices] Warning: Mirror to runners failed: %v", err)
 	}
 
+	// Cached race responses now show stale prices
+	cache.InvalidateRaces(ctx, raceIDs, nil, false)
+
 	return nil
 }
 
@@ -0,0 +1,403 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"giddyup/api/internal/pipeline"
+	"giddyup/api/internal/scraper"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// RacecardScheduler keeps today's and tomorrow's cards fresh while the API is running.
+// RunInBackground only fetches racecards once at startup; this re-pulls them on a
+// cadence (non-runners, off time changes) and fetches each race's result shortly
+// after its off time, promoting the race from prelim=true to complete.
+type RacecardScheduler struct {
+	db            *sqlx.DB
+	dataDir       string
+	ingest        *pipeline.Pipeline
+	entries       *EntriesService
+	entriesDays   int           // Days ahead to ingest entries for on each new day (0 = off)
+	bigRaceDates  []string      // Big-race dates to ingest long-range entries for on each new day
+	reconcileDays int           // Settled days to re-check for amended results on each new day (0 = off)
+	cardInterval  time.Duration // How often to re-pull the full racecards
+	resultPoll    time.Duration // How often to check for races that are due a result
+	resultDelay   time.Duration // Wait this long after the off before asking for a result
+	resultTimeout time.Duration // Stop polling a race this long after the off (abandoned/void)
+
+	date    string               // Race date the cards below belong to (UK local)
+	cards   map[int]scraper.Race // Latest card per Sporting Life race ID
+	settled map[int]bool         // Races whose result has been loaded (or given up on)
+}
+
+// NewRacecardScheduler creates a scheduler using the given refresh cadence and result delay
+func NewRacecardScheduler(db *sqlx.DB, dataDir string, cardInterval, resultDelay time.Duration) *RacecardScheduler {
+	return &RacecardScheduler{
+		db:            db,
+		dataDir:       dataDir,
+		ingest:        pipeline.New(db.DB, dataDir),
+		entries:       NewEntriesService(db, dataDir),
+		entriesDays:   5,
+		reconcileDays: pipeline.DefaultReconcileDays,
+		cardInterval:  cardInterval,
+		resultPoll:    time.Minute,
+		resultDelay:   resultDelay,
+		resultTimeout: 3 * time.Hour,
+		cards:         make(map[int]scraper.Race),
+		settled:       make(map[int]bool),
+	}
+}
+
+// NewRacecardSchedulerFromEnv reads RACECARD_REFRESH_MINUTES (default 15),
+// RESULT_DELAY_MINUTES (default 10), ENTRIES_DAYS_AHEAD (default 5),
+// ENTRIES_BIG_RACE_DATES (comma-separated, default none) and
+// RESULT_RECONCILE_DAYS (default 3) and creates a scheduler
+func NewRacecardSchedulerFromEnv(db *sqlx.DB, dataDir string) *RacecardScheduler {
+	cardMins := 15
+	if v := os.Getenv("RACECARD_REFRESH_MINUTES"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			cardMins = parsed
+		}
+	}
+
+	resultMins := 10
+	if v := os.Getenv("RESULT_DELAY_MINUTES"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
+			resultMins = parsed
+		}
+	}
+
+	rs := NewRacecardScheduler(db, dataDir,
+		time.Duration(cardMins)*time.Minute, time.Duration(resultMins)*time.Minute)
+
+	if v := os.Getenv("ENTRIES_DAYS_AHEAD"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
+			rs.entriesDays = parsed
+		}
+	}
+
+	for _, d := range strings.Split(os.Getenv("ENTRIES_BIG_RACE_DATES"), ",") {
+		d = strings.TrimSpace(d)
+		if _, err := time.Parse("2006-01-02", d); err == nil {
+			rs.bigRaceDates = append(rs.bigRaceDates, d)
+		} else if d != "" {
+			log.Printf("[Scheduler] ⚠️  Ignoring ENTRIES_BIG_RACE_DATES entry %q (want YYYY-MM-DD)", d)
+		}
+	}
+
+	if v := os.Getenv("RESULT_RECONCILE_DAYS"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
+			rs.reconcileDays = parsed
+		}
+	}
+
+	return rs
+}
+
+// RunInBackground starts the scheduler in a goroutine (non-blocking)
+func (rs *RacecardScheduler) RunInBackground() {
+	go func() {
+		if err := rs.Run(context.Background()); err != nil && err != context.Canceled {
+			log.Printf("[Scheduler] ❌ Stopped: %v", err)
+		}
+	}()
+}
+
+// Run refreshes cards every cardInterval and polls for due results every resultPoll
+// until the context is cancelled
+func (rs *RacecardScheduler) Run(ctx context.Context) error {
+	log.Printf("[Scheduler] 🕐 Started (cards every %v, results %v after the off)",
+		rs.cardInterval, rs.resultDelay)
+
+	rs.refreshCards()
+
+	cardTicker := time.NewTicker(rs.cardInterval)
+	defer cardTicker.Stop()
+	resultTicker := time.NewTicker(rs.resultPoll)
+	defer resultTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[Scheduler] Stopping...")
+			return ctx.Err()
+		case <-cardTicker.C:
+			rs.refreshCards()
+		case <-resultTicker.C:
+			rs.checkResults()
+		}
+	}
+}
+
+// refreshCards re-pulls today's and tomorrow's racecards from Sporting Life and upserts them
+func (rs *RacecardScheduler) refreshCards() {
+	now := time.Now().In(scraper.UK())
+	today := now.Format("2006-01-02")
+	tomorrow := now.AddDate(0, 0, 1).Format("2006-01-02")
+
+	// Day rolled over - forget yesterday's cards, bar races still to go off or settle
+	if rs.date != today {
+		rs.date = today
+		rs.rollOver(now)
+
+		// Entries/declarations only change a few times a day - pull them once per day
+		if rs.entriesDays > 0 || len(rs.bigRaceDates) > 0 {
+			go func() {
+				if rs.entriesDays > 0 {
+					races, entries, err := rs.entries.IngestAhead(rs.entriesDays)
+					if err != nil {
+						log.Printf("[Scheduler] ⚠️  Entries ingest had failures: %v", err)
+					}
+					log.Printf("[Scheduler] 📋 Entries for next %d days: %d races, %d entries", rs.entriesDays, races, entries)
+				}
+
+				if len(rs.bigRaceDates) > 0 {
+					races, entries, err := rs.entries.IngestBigRaces(rs.bigRaceDates, rs.entriesDays)
+					if err != nil {
+						log.Printf("[Scheduler] ⚠️  Big-race entries ingest had failures: %v", err)
+					}
+					log.Printf("[Scheduler] 📋 Long-range big-race entries: %d races, %d entries", races, entries)
+				}
+			}()
+		}
+
+		// Stewards' inquiries, disqualifications and SP corrections land after
+		// the result - re-check the last few settled days once per day
+		if rs.reconcileDays > 0 {
+			go func() {
+				results, err := rs.ingest.ReconcileRecent(context.Background(), rs.reconcileDays)
+				if err != nil {
+					log.Printf("[Scheduler] ⚠️  Result reconciliation failed: %v", err)
+				}
+				amendments := 0
+				for _, res := range results {
+					amendments += len(res.Amendments)
+				}
+				log.Printf("[Scheduler] ✏️  Reconciled last %d days: %d amendment(s)", rs.reconcileDays, amendments)
+			}()
+		}
+	}
+
+	slScraper := scraper.NewSportingLifeAPIV2(rs.dataDir)
+
+	races, err := slScraper.FetchRacesForDate(today)
+	if err != nil {
+		log.Printf("[Scheduler] ❌ Failed to refresh cards for %s: %v", today, err)
+	} else {
+		var pending, resulted []scraper.Race
+		for _, race := range races {
+			if race.RaceID == 0 {
+				continue
+			}
+
+			if old, ok := rs.cards[race.RaceID]; ok && old.OffTime != race.OffTime {
+				rs.moveOffTime(old, race)
+			}
+			rs.cards[race.RaceID] = race
+
+			if rs.settled[race.RaceID] {
+				continue
+			}
+			if race.HasResult() {
+				resulted = append(resulted, race)
+			} else {
+				pending = append(pending, race)
+			}
+		}
+
+		if len(pending) > 0 {
+			if r, rr, err := rs.ingest.Ingest(context.Background(), pending, true); err != nil {
+				log.Printf("[Scheduler] ❌ Failed to upsert %s cards: %v", today, err)
+			} else {
+				log.Printf("[Scheduler] ✅ %s cards refreshed: %d races, %d runners", today, r, rr)
+			}
+		}
+
+		if len(resulted) > 0 {
+			if r, rr, err := rs.ingest.Ingest(context.Background(), resulted, false); err != nil {
+				log.Printf("[Scheduler] ❌ Failed to load %s results: %v", today, err)
+			} else {
+				for _, race := range resulted {
+					rs.settled[race.RaceID] = true
+				}
+				log.Printf("[Scheduler] 🏁 %s results loaded: %d races, %d runners", today, r, rr)
+			}
+		}
+
+		// Keep entry statuses in step (declared → runner / non-runner)
+		if _, _, err := rs.entries.IngestRaces(races); err != nil {
+			log.Printf("[Scheduler] ⚠️  Failed to update entries for %s: %v", today, err)
+		}
+	}
+
+	tomorrowRaces, err := slScraper.FetchRacesForDate(tomorrow)
+	if err != nil {
+		log.Printf("[Scheduler] ⚠️  Failed to refresh cards for %s: %v", tomorrow, err)
+		return
+	}
+	if r, rr, err := rs.ingest.Ingest(context.Background(), tomorrowRaces, true); err != nil {
+		log.Printf("[Scheduler] ⚠️  Failed to upsert %s cards: %v", tomorrow, err)
+	} else {
+		log.Printf("[Scheduler] ✅ %s cards refreshed: %d races, %d runners", tomorrow, r, rr)
+	}
+}
+
+// checkResults fetches the result for every unsettled race that went off at least resultDelay ago
+func (rs *RacecardScheduler) checkResults() {
+	if len(rs.cards) == 0 {
+		return
+	}
+
+	now := time.Now().In(scraper.UK())
+	slScraper := scraper.NewSportingLifeAPIV2(rs.dataDir)
+
+	for id, race := range rs.cards {
+		if rs.settled[id] {
+			continue
+		}
+
+		offAt, err := raceOffTime(race)
+		if err != nil {
+			continue
+		}
+		if now.Before(offAt.Add(rs.resultDelay)) {
+			continue
+		}
+		if now.After(offAt.Add(rs.resultTimeout)) {
+			log.Printf("[Scheduler] ⚠️  Giving up on result for %s %s (off %s) - no result after %v",
+				race.Course, race.RaceName, race.OffTime, rs.resultTimeout)
+			rs.settled[id] = true
+			continue
+		}
+
+		fresh, err := slScraper.RefreshRace(race)
+		if err != nil {
+			log.Printf("[Scheduler] ⚠️  Failed to fetch result for %s %s: %v", race.Course, race.OffTime, err)
+			continue
+		}
+		if !fresh.HasResult() {
+			continue
+		}
+
+		_, rr, err := rs.ingest.Ingest(context.Background(), []scraper.Race{fresh}, false)
+		if err != nil {
+			log.Printf("[Scheduler] ❌ Failed to load result for %s %s: %v", race.Course, race.OffTime, err)
+			continue
+		}
+
+		if _, _, err := rs.entries.IngestRaces([]scraper.Race{fresh}); err != nil {
+			log.Printf("[Scheduler] ⚠️  Failed to settle entries for %s %s: %v", race.Course, race.OffTime, err)
+		}
+
+		rs.cards[id] = fresh
+		rs.settled[id] = true
+		log.Printf("[Scheduler] 🏁 Result in: %s %s (%d runners) - race marked complete",
+			fresh.Course, fresh.OffTime, rr)
+	}
+}
+
+// moveOffTime re-keys an existing race (and its runners) when Sporting Life changes
+// the off time, so the next upsert updates the row instead of creating a duplicate
+func (rs *RacecardScheduler) moveOffTime(old, updated scraper.Race) {
+	move, ok := offTimeMoveFor(old, updated)
+	if !ok {
+		return
+	}
+
+	log.Printf("[Scheduler] 🔀 Off time changed: %s %s → %s", old.Course, old.OffTime, updated.OffTime)
+
+	tx, err := rs.db.Beginx()
+	if err != nil {
+		log.Printf("[Scheduler] ❌ Failed to begin re-key transaction: %v", err)
+		return
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		UPDATE racing.races SET race_key = $1, off_time = $2
+		WHERE race_key = $3 AND race_date = $4
+	`, move.newKey, nullString(updated.OffTime), move.oldKey, old.Date)
+	if err != nil {
+		log.Printf("[Scheduler] ❌ Failed to re-key race %s: %v", move.oldKey, err)
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return // Never loaded under the old key - nothing to move
+	}
+
+	for _, runner := range move.runners {
+		_, err := tx.Exec(`
+			UPDATE racing.runners SET runner_key = $1
+			WHERE runner_key = $2 AND race_date = $3
+		`, runner.newKey, runner.oldKey, old.Date)
+		if err != nil {
+			log.Printf("[Scheduler] ❌ Failed to re-key runner %s: %v", runner.horse, err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("[Scheduler] ❌ Failed to commit re-key: %v", err)
+	}
+}
+
+// offTimeMove is the race and runner keys moveOffTime moves
+type offTimeMove struct {
+	oldKey, newKey string
+	runners        []runnerMove
+}
+
+type runnerMove struct {
+	horse          string
+	oldKey, newKey string
+}
+
+// offTimeMoveFor works out the keys a race's runners were loaded under and the
+// ones they take with the new off time; false if the race key is unchanged
+// (e.g. only seconds were added to the off time)
+func offTimeMoveFor(old, updated scraper.Race) (offTimeMove, bool) {
+	move := offTimeMove{oldKey: old.Key(), newKey: updated.Key()}
+	if move.oldKey == move.newKey {
+		return move, false
+	}
+	for _, runner := range old.Runners {
+		move.runners = append(move.runners, runnerMove{
+			horse:  runner.Horse,
+			oldKey: runner.Key(move.oldKey),
+			newKey: runner.Key(move.newKey),
+		})
+	}
+	return move, true
+}
+
+// rollOver starts a new UK day's cards, keeping races still due a result: a US
+// evening race going off after UK midnight is dated (and fetched) under the day before
+func (rs *RacecardScheduler) rollOver(now time.Time) {
+	cards := make(map[int]scraper.Race)
+	for id, race := range rs.cards {
+		if rs.settled[id] {
+			continue
+		}
+		if offAt, err := raceOffTime(race); err == nil && now.Before(offAt.Add(rs.resultTimeout)) {
+			cards[id] = race
+		}
+	}
+	rs.cards = cards
+	rs.settled = make(map[int]bool)
+}
+
+// raceOffTime is the moment a race goes off (see scraper.OffInstant), in UK time
+func raceOffTime(race scraper.Race) (time.Time, error) {
+	t, ok := scraper.OffInstant(race.Date, race.OffTime, race.Region, race.Course)
+	if !ok {
+		return time.Time{}, fmt.Errorf("bad off time %q for %s", race.OffTime, race.Date)
+	}
+	return t, nil
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"giddyup/api/internal/scraper"
+)
+
+func TestRaceOffTime(t *testing.T) {
+	cases := []struct {
+		name string
+		race scraper.Race
+		want string // UK local
+	}{
+		{"GB", scraper.Race{Date: "2025-10-18", OffTime: "14:05", Region: "GB", Course: "Ascot"}, "2025-10-18 14:05"},
+		{"seconds ignored", scraper.Race{Date: "2025-10-18", OffTime: "14:05:00", Region: "GB", Course: "Ascot"}, "2025-10-18 14:05"},
+		// Dated by the New York calendar; goes off at 01:30 UK the next morning
+		{"US race after UK midnight", scraper.Race{Date: "2025-10-18", OffTime: "01:30", Region: "USA", Course: "Belmont Park"},
+			"2025-10-19 01:30"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := raceOffTime(tc.race)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s := got.In(scraper.UK()).Format("2006-01-02 15:04"); s != tc.want {
+				t.Fatalf("got %s, want %s", s, tc.want)
+			}
+		})
+	}
+
+	if _, err := raceOffTime(scraper.Race{Date: "2025-10-18", OffTime: "TBC"}); err == nil {
+		t.Fatal("TBC off time parsed")
+	}
+}
+
+func TestRollOverKeepsRacesStillToRun(t *testing.T) {
+	rs := &RacecardScheduler{
+		resultTimeout: 3 * time.Hour,
+		cards: map[int]scraper.Race{
+			1: {Date: "2025-10-18", OffTime: "14:05", Region: "GB", Course: "Ascot"},         // Ran yesterday, no result
+			2: {Date: "2025-10-18", OffTime: "16:10", Region: "GB", Course: "Ascot"},         // Settled
+			3: {Date: "2025-10-18", OffTime: "01:30", Region: "USA", Course: "Belmont Park"}, // Still to run
+			4: {Date: "2025-10-18", OffTime: "22:45", Region: "USA", Course: "Belmont Park"}, // Ran, result due
+		},
+		settled: map[int]bool{2: true},
+	}
+
+	now := time.Date(2025, 10, 19, 0, 5, 0, 0, scraper.UK())
+	rs.rollOver(now)
+
+	for id, want := range map[int]bool{1: false, 2: false, 3: true, 4: true} {
+		if _, kept := rs.cards[id]; kept != want {
+			t.Errorf("race %d kept = %v, want %v", id, kept, want)
+		}
+	}
+	if len(rs.settled) != 0 {
+		t.Errorf("settled not reset: %v", rs.settled)
+	}
+}
+
+func TestOffTimeMoveFor(t *testing.T) {
+	old := scraper.Race{Date: "2025-10-18", Region: "GB", Course: "Ascot", OffTime: "14:05", RaceName: "Sprint Handicap", Type: "Flat",
+		Runners: []scraper.Runner{{Horse: "Galileo Gold", Num: 1, Draw: 3}, {Horse: "Frankel", Num: 2, Draw: 1}}}
+
+	t.Run("off time moved", func(t *testing.T) {
+		updated := old
+		updated.OffTime = "14:20"
+		move, ok := offTimeMoveFor(old, updated)
+		if !ok {
+			t.Fatal("no move")
+		}
+		if move.oldKey != old.Key() || move.newKey != updated.Key() {
+			t.Errorf("race keys %s → %s, want %s → %s", move.oldKey, move.newKey, old.Key(), updated.Key())
+		}
+		if len(move.runners) != len(old.Runners) {
+			t.Fatalf("%d runners moved, want %d", len(move.runners), len(old.Runners))
+		}
+		for i, runner := range old.Runners {
+			got := move.runners[i]
+			if got.oldKey != runner.Key(old.Key()) || got.newKey != runner.Key(updated.Key()) {
+				t.Errorf("runner %s keys %s → %s, want %s → %s", runner.Horse, got.oldKey, got.newKey,
+					runner.Key(old.Key()), runner.Key(updated.Key()))
+			}
+		}
+	})
+
+	t.Run("same off time with seconds", func(t *testing.T) {
+		updated := old
+		updated.OffTime = "14:05:00"
+		if _, ok := offTimeMoveFor(old, updated); ok {
+			t.Error("moved a race whose key didn't change")
+		}
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		if _, ok := offTimeMoveFor(old, old); ok {
+			t.Error("moved an unchanged race")
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package stitcher
+
+import (
+	"giddyup/api/internal/scraper"
+)
+
+// MasterFromRaces converts races that already carry their Betfair prices
+// (pipeline output, after scraper.MatchAndMerge) into master records for the
+// COPY loader
+func MasterFromRaces(races []scraper.Race) ([]MasterRace, []MasterRunner) {
+	masterRaces := make([]MasterRace, 0, len(races))
+	var masterRunners []MasterRunner
+
+	for _, race := range races {
+		raceKey := race.Key()
+		masterRaces = append(masterRaces, masterRace(race, raceKey))
+
+		for _, rpRunner := range race.Runners {
+			runner := masterRunner(race, raceKey, rpRunner)
+			runner.WinBSP = rpRunner.WinBSP
+			runner.WinPPWAP = rpRunner.WinPPWAP
+			runner.WinMorningWAP = rpRunner.WinMorningWAP
+			runner.WinPPMax = rpRunner.WinPPMax
+			runner.WinPPMin = rpRunner.WinPPMin
+			runner.PlaceBSP = rpRunner.PlaceBSP
+			runner.PlacePPWAP = rpRunner.PlacePPWAP
+			masterRunners = append(masterRunners, runner)
+		}
+	}
+
+	return masterRaces, masterRunners
+}
+
+// masterRace copies a race's card fields into a master race
+func masterRace(rpRace scraper.Race, raceKey string) MasterRace {
+	return MasterRace{
+		RaceKey:    raceKey,
+		Date:       rpRace.Date,
+		Region:     rpRace.Region,
+		CourseID:   rpRace.CourseID,
+		Course:     rpRace.Course,
+		OffTime:    rpRace.OffTime,
+		RaceName:   rpRace.RaceName,
+		Type:       rpRace.Type,
+		Class:      rpRace.Class,
+		Pattern:    rpRace.Pattern,
+		AgeBand:    rpRace.AgeBand,
+		RatingBand: rpRace.RatingBand,
+		SexRest:    rpRace.SexRest,
+		Distance:   rpRace.Distance,
+		DistanceF:  rpRace.DistanceF,
+		Going:      rpRace.Going,
+		Surface:    rpRace.Surface,
+		Ran:        rpRace.Ran,
+	}
+}
+
+// masterRunner copies a runner's form fields (not prices) into a master runner
+func masterRunner(rpRace scraper.Race, raceKey string, rpRunner scraper.Runner) MasterRunner {
+	lbs := rpRunner.Lbs
+	if lbs == 0 {
+		lbs = parseWeight(rpRunner.Weight)
+	}
+
+	return MasterRunner{
+		RunnerKey: rpRunner.Key(raceKey),
+		RaceKey:   raceKey,
+		RaceDate:  rpRace.Date, // Pass race_date through
+		Num:       rpRunner.Num,
+		Pos:       rpRunner.Pos,
+		Draw:      rpRunner.Draw,
+		Horse:     rpRunner.Horse,
+		HorseID:   rpRunner.HorseID,
+		Age:       rpRunner.Age,
+		Jockey:    rpRunner.Jockey,
+		JockeyID:  rpRunner.JockeyID,
+		Trainer:   rpRunner.Trainer,
+		TrainerID: rpRunner.TrainerID,
+		Weight:    rpRunner.Weight,
+		Lbs:       lbs,
+		OR:        rpRunner.OR,
+		RPR:       rpRunner.RPR,
+		TS:        rpRunner.TS,
+		SP:        rpRunner.SP,
+		Prize:     rpRunner.Prize,
+		Comment:   rpRunner.Comment,
+	}
+}
@@ -1,15 +1,12 @@
 package stitcher
 
 import (
-	"crypto/md5"
-	"encoding/hex"
-	"fmt"
 	"log"
-	"math"
 	"strconv"
 	"strings"
 	"time"
 
+	"giddyup/api/internal/matching"
 	"giddyup/api/internal/scraper"
 )
 
@@ -17,6 +14,8 @@ import (
 type Stitcher struct {
 	rpRaces  []scraper.Race
 	bfPrices []scraper.BetfairPrice
+	engine   *matching.Engine
+	coverage matching.RunnerCoverage // Runner coverage across all matched races
 }
 
 // MasterRace represents a race with matched Betfair data
@@ -57,6 +56,7 @@ type MasterRunner struct {
 	Trainer   string
 	TrainerID int
 	Weight    string
+	Lbs       int
 	OR        int
 	RPR       int
 	TS        int
@@ -85,6 +85,8 @@ type BetfairMatch struct {
 	Jaccard      float64
 	Score        float64
 	TimeDiffMins int
+	Result       matching.Result
+	Coverage     matching.RunnerCoverage
 }
 
 // New creates a new stitcher
@@ -92,12 +94,14 @@ func New(rpRaces []scraper.Race, bfPrices []scraper.BetfairPrice) *Stitcher {
 	return &Stitcher{
 		rpRaces:  rpRaces,
 		bfPrices: bfPrices,
+		engine:   matching.NewForPipeline(matching.PipelineStitcher),
 	}
 }
 
 // StitchData matches Racing Post races with Betfair prices
 func (s *Stitcher) StitchData() ([]MasterRace, []MasterRunner, error) {
 	log.Printf("[Stitcher] Starting to stitch %d RP races with %d BF prices", len(s.rpRaces), len(s.bfPrices))
+	defer s.engine.Flush()
 
 	// Group Betfair prices by (date, normalized course)
 	bfByDateCourse := s.groupBetfairPrices()
@@ -112,6 +116,9 @@ func (s *Stitcher) StitchData() ([]MasterRace, []MasterRunner, error) {
 		}
 	}
 
+	// Match every RP race against the Betfair races on its date (one-to-one per date)
+	matches := s.matchRaces(bfByDateCourse)
+
 	masterRaces := []MasterRace{}
 	masterRunners := []MasterRunner{}
 	matchedCount := 0
@@ -123,19 +130,9 @@ func (s *Stitcher) StitchData() ([]MasterRace, []MasterRunner, error) {
 				i+1, rpRace.Date, rpRace.Course, rpRace.OffTime, len(rpRace.Runners))
 		}
 		// Generate race key (include race_name and type)
-		raceKey := generateRaceKey(rpRace.Date, rpRace.Region, rpRace.Course, rpRace.OffTime, rpRace.RaceName, rpRace.Type)
-
-		// Find Betfair candidates for this race (by date only)
-		key := rpRace.Date
-		candidates := bfByDateCourse[key]
-
-		// Debug first few lookups
-		if i < 3 {
-			log.Printf("[Stitcher DEBUG] Lookup key: '%s', candidates: %d", key, len(candidates))
-		}
+		raceKey := rpRace.Key()
 
-		// Try to match with Betfair data
-		match := s.matchRace(rpRace, candidates)
+		match := matches[i]
 
 		if i < 3 && match != nil {
 			log.Printf("[Stitcher DEBUG] Match found: jaccard=%.2f, score=%.2f", match.Jaccard, match.Score)
@@ -143,28 +140,7 @@ func (s *Stitcher) StitchData() ([]MasterRace, []MasterRunner, error) {
 			log.Printf("[Stitcher DEBUG] No match found")
 		}
 
-		// Create master race
-		masterRace := MasterRace{
-			RaceKey:    raceKey,
-			Date:       rpRace.Date,
-			Region:     rpRace.Region,
-			CourseID:   rpRace.CourseID,
-			Course:     rpRace.Course,
-			OffTime:    rpRace.OffTime,
-			RaceName:   rpRace.RaceName,
-			Type:       rpRace.Type,
-			Class:      rpRace.Class,
-			Pattern:    rpRace.Pattern,
-			AgeBand:    rpRace.AgeBand,
-			RatingBand: rpRace.RatingBand,
-			SexRest:    rpRace.SexRest,
-			Distance:   rpRace.Distance,
-			DistanceF:  rpRace.DistanceF,
-			Going:      rpRace.Going,
-			Surface:    rpRace.Surface,
-			Ran:        rpRace.Ran,
-		}
-		masterRaces = append(masterRaces, masterRace)
+		masterRaces = append(masterRaces, masterRace(rpRace, raceKey))
 
 		// Stitch runners
 		runners := s.stitchRunners(rpRace, raceKey, match)
@@ -178,8 +154,8 @@ func (s *Stitcher) StitchData() ([]MasterRace, []MasterRunner, error) {
 		}
 	}
 
-	log.Printf("[Stitcher] Complete: %d/%d races matched (%.1f%%)",
-		matchedCount, len(s.rpRaces), float64(matchedCount)/float64(len(s.rpRaces))*100)
+	log.Printf("[Stitcher] Complete: %d/%d races matched (%.1f%%), %s",
+		matchedCount, len(s.rpRaces), float64(matchedCount)/float64(len(s.rpRaces))*100, s.coverage)
 
 	return masterRaces, masterRunners, nil
 }
@@ -189,7 +165,7 @@ func (s *Stitcher) groupBetfairPrices() map[string][]scraper.BetfairPrice {
 	grouped := make(map[string][]scraper.BetfairPrice)
 
 	for _, price := range s.bfPrices {
-		// Group by date only - we'll match by time window and horses
+		// Group by date only - races within a date are told apart by the matching engine
 		key := price.Date
 		grouped[key] = append(grouped[key], price)
 	}
@@ -197,114 +173,107 @@ func (s *Stitcher) groupBetfairPrices() map[string][]scraper.BetfairPrice {
 	return grouped
 }
 
-// matchRace finds the best Betfair match for a Racing Post race
-func (s *Stitcher) matchRace(rpRace scraper.Race, bfPrices []scraper.BetfairPrice) *BetfairMatch {
-	if len(bfPrices) == 0 {
-		return nil
-	}
-
-	// Group BF prices by off time
-	bfByTime := make(map[string][]scraper.BetfairPrice)
-	for _, bf := range bfPrices {
-		bfByTime[bf.OffTime] = append(bfByTime[bf.OffTime], bf)
-	}
+// matchRaces finds the best Betfair race for every Racing Post race using the shared
+// matching engine. Returned slice is indexed like s.rpRaces (nil = no match).
+func (s *Stitcher) matchRaces(bfByDate map[string][]scraper.BetfairPrice) []*BetfairMatch {
+	matches := make([]*BetfairMatch, len(s.rpRaces))
 
-	// Debug: Show time groupings
-	if len(bfByTime) > 0 {
-		log.Printf("[Stitcher DEBUG] RP time: %s, BF times: %d groups", rpRace.OffTime, len(bfByTime))
-		for bfTime := range bfByTime {
-			log.Printf("[Stitcher DEBUG]   BF time: %s", bfTime)
-			break // Just show first one
-		}
+	// RP race indexes per date
+	rpByDate := make(map[string][]int)
+	for i, rpRace := range s.rpRaces {
+		rpByDate[rpRace.Date] = append(rpByDate[rpRace.Date], i)
 	}
 
-	var bestMatch *BetfairMatch
-	bestScore := 0.0
-
-	for bfTime, bfGroup := range bfByTime {
-		// Check if times are within 10 minute window
-		timeDiff := s.timeWindowMinutes(rpRace.OffTime, bfTime)
-		if timeDiff > 10 {
+	for date, rpIdx := range rpByDate {
+		groups := groupByRace(bfByDate[date])
+		if len(groups) == 0 {
 			continue
 		}
 
-		// Get normalized horse sets
-		rpHorses := s.normalizeHorseSet(rpRace.Runners)
-		bfHorses := s.extractHorseSet(bfGroup)
-
-		// Calculate Jaccard similarity
-		jaccard := jaccardSimilarity(rpHorses, bfHorses)
-
-		// Calculate total score with bonuses
-		score := jaccard
-
-		// Bonus: Runner count matches
-		if len(rpRace.Runners) == len(bfHorses) {
-			score += 0.5
+		candidates := make([]matching.Race, len(groups))
+		for i, group := range groups {
+			horses := make([]string, 0, len(group))
+			for _, p := range group {
+				horses = append(horses, p.Horse)
+			}
+			candidates[i] = matching.Race{
+				Date:    date,
+				Venue:   group[0].Course,
+				OffTime: group[0].OffTime,
+				Horses:  horses,
+				Ref:     matching.MarketRef(group[0].Course, group[0].OffTime),
+			}
 		}
 
-		// Bonus: Both handicaps
-		if s.bothHandicap(rpRace.RaceName, bfGroup) {
-			score += 0.5
+		targets := make([]matching.Race, len(rpIdx))
+		for i, idx := range rpIdx {
+			targets[i] = s.rpRaces[idx].MatchingRace()
 		}
 
-		// Accept if score >= 0.5 (matching Python threshold)
-		if score >= 0.5 && score > bestScore {
-			bestScore = score
-			bestMatch = &BetfairMatch{
-				Prices:       bfGroup,
-				Jaccard:      jaccard,
-				Score:        score,
-				TimeDiffMins: timeDiff,
+		for _, result := range s.engine.MatchAll(targets, candidates) {
+			if !result.Matched() {
+				continue
+			}
+			matches[rpIdx[result.Target]] = &BetfairMatch{
+				Prices:       groups[result.Candidate],
+				Jaccard:      result.Score.Jaccard,
+				Score:        result.Score.Total,
+				TimeDiffMins: result.Score.TimeDiffMins,
+				Result:       result,
 			}
 		}
 	}
 
-	return bestMatch
+	return matches
+}
+
+// groupByRace splits a date's Betfair prices into races by (course, off time)
+func groupByRace(prices []scraper.BetfairPrice) [][]scraper.BetfairPrice {
+	index := make(map[string]int)
+	var groups [][]scraper.BetfairPrice
+	for _, p := range prices {
+		key := matching.CanonicalVenue(p.Course) + "|" + matching.NormalizeTime(p.OffTime)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], p)
+	}
+	return groups
 }
 
 // stitchRunners creates master runners with Betfair prices matched
 func (s *Stitcher) stitchRunners(rpRace scraper.Race, raceKey string, match *BetfairMatch) []MasterRunner {
 	runners := []MasterRunner{}
 
-	// Create map of Betfair prices by normalized horse name
-	bfMap := make(map[string]*scraper.BetfairPrice)
+	// Pair RP runners with the matched Betfair prices (audited, overrides honoured)
+	bfByRunner := make(map[int]*scraper.BetfairPrice)
 	if match != nil {
-		for i := range match.Prices {
-			normName := scraper.NormalizeName(match.Prices[i].Horse)
-			bfMap[normName] = &match.Prices[i]
+		horses := make([]matching.RunnerTarget, len(rpRace.Runners))
+		for i, rpRunner := range rpRace.Runners {
+			horses[i] = matching.RunnerTarget{Name: rpRunner.Horse, Num: rpRunner.Num}
+		}
+		bfRunners := make([]matching.RunnerCandidate, len(match.Prices))
+		for i, p := range match.Prices {
+			bfRunners[i] = matching.RunnerCandidate{Ref: matching.RunnerRef(0, p.Horse), Name: p.Horse}
 		}
+		runnerResults := s.engine.MatchRunners(raceKey, rpRace.Date, horses, bfRunners)
+		for _, rr := range runnerResults {
+			if rr.Matched() {
+				bfByRunner[rr.Horse] = &match.Prices[rr.Candidate]
+			}
+		}
+		match.Coverage = matching.Coverage(runnerResults, len(bfRunners))
+		s.coverage.Add(match.Coverage)
 	}
 
-	for _, rpRunner := range rpRace.Runners {
-		runnerKey := generateRunnerKey(raceKey, rpRunner.Horse, rpRunner.Num, rpRunner.Draw)
-
-		runner := MasterRunner{
-			RunnerKey: runnerKey,
-			RaceKey:   raceKey,
-			RaceDate:  rpRace.Date, // Pass race_date through
-			Num:       rpRunner.Num,
-			Pos:       rpRunner.Pos,
-			Draw:      rpRunner.Draw,
-			Horse:     rpRunner.Horse,
-			HorseID:   rpRunner.HorseID,
-			Age:       rpRunner.Age,
-			Jockey:    rpRunner.Jockey,
-			JockeyID:  rpRunner.JockeyID,
-			Trainer:   rpRunner.Trainer,
-			TrainerID: rpRunner.TrainerID,
-			Weight:    rpRunner.Weight,
-			OR:        rpRunner.OR,
-			RPR:       rpRunner.RPR,
-			TS:        rpRunner.TS,
-			SP:        rpRunner.SP,
-			Prize:     rpRunner.Prize,
-			Comment:   rpRunner.Comment,
-		}
+	for i, rpRunner := range rpRace.Runners {
+		runner := masterRunner(rpRace, raceKey, rpRunner)
 
 		// Match with Betfair data if available
-		normName := scraper.NormalizeName(rpRunner.Horse)
-		if bfPrice, found := bfMap[normName]; found {
+		if bfPrice, found := bfByRunner[i]; found {
 			runner.WinBSP = bfPrice.WinBSP
 			runner.WinPPWAP = bfPrice.WinPPWAP
 			runner.WinMorningWAP = bfPrice.WinMorningWAP
@@ -316,7 +285,7 @@ func (s *Stitcher) stitchRunners(rpRace scraper.Race, raceKey string, match *Bet
 			if match != nil {
 				runner.MatchJaccard = match.Jaccard
 				runner.MatchTimeDiff = match.TimeDiffMins
-				runner.MatchReason = "jaccard"
+				runner.MatchReason = match.Result.Outcome
 			}
 		}
 
@@ -326,110 +295,6 @@ func (s *Stitcher) stitchRunners(rpRace scraper.Race, raceKey string, match *Bet
 	return runners
 }
 
-// normalizeHorseSet extracts normalized horse names from runners
-func (s *Stitcher) normalizeHorseSet(runners []scraper.Runner) []string {
-	names := make([]string, 0, len(runners))
-	for _, r := range runners {
-		normalized := scraper.NormalizeName(r.Horse)
-		if normalized != "" {
-			names = append(names, normalized)
-		}
-	}
-	return names
-}
-
-// extractHorseSet extracts normalized horse names from Betfair prices
-func (s *Stitcher) extractHorseSet(prices []scraper.BetfairPrice) []string {
-	names := make([]string, 0, len(prices))
-	seen := make(map[string]bool)
-	for _, p := range prices {
-		normalized := scraper.NormalizeName(p.Horse)
-		if normalized != "" && !seen[normalized] {
-			names = append(names, normalized)
-			seen[normalized] = true
-		}
-	}
-	return names
-}
-
-// timeWindowMinutes calculates difference in minutes between two time strings
-func (s *Stitcher) timeWindowMinutes(time1, time2 string) int {
-	t1 := s.parseTime(time1)
-	t2 := s.parseTime(time2)
-
-	diff := int(math.Abs(float64(t1 - t2)))
-	return diff
-}
-
-// parseTime converts time string (HH:MM) to minutes since midnight
-func (s *Stitcher) parseTime(timeStr string) int {
-	parts := strings.Split(timeStr, ":")
-	if len(parts) != 2 {
-		return 0
-	}
-	hours, _ := strconv.Atoi(parts[0])
-	mins, _ := strconv.Atoi(parts[1])
-	return hours*60 + mins
-}
-
-// bothHandicap checks if race name suggests handicap
-func (s *Stitcher) bothHandicap(raceName string, bfPrices []scraper.BetfairPrice) bool {
-	nameL := strings.ToLower(raceName)
-	return strings.Contains(nameL, "handicap") || strings.Contains(nameL, "hcap")
-}
-
-// jaccardSimilarity calculates Jaccard similarity coefficient
-func jaccardSimilarity(set1, set2 []string) float64 {
-	if len(set1) == 0 || len(set2) == 0 {
-		return 0.0
-	}
-
-	// Convert to maps for faster lookup
-	m1 := make(map[string]bool)
-	for _, s := range set1 {
-		m1[s] = true
-	}
-
-	m2 := make(map[string]bool)
-	for _, s := range set2 {
-		m2[s] = true
-	}
-
-	// Calculate intersection
-	intersection := 0
-	for s := range m1 {
-		if m2[s] {
-			intersection++
-		}
-	}
-
-	// Calculate union
-	union := len(m1) + len(m2) - intersection
-
-	if union == 0 {
-		return 0.0
-	}
-
-	return float64(intersection) / float64(union)
-}
-
-// generateRaceKey creates MD5 hash for race
-// Matches Python: MD5(date|REGION|course|off|race_name|type)
-func generateRaceKey(date, region, course, offTime, raceName, raceType string) string {
-	region = strings.ToUpper(region) // MUST be uppercase: GB not gb
-	keyStr := fmt.Sprintf("%s|%s|%s|%s|%s|%s", date, region, course, offTime, raceName, raceType)
-	hash := md5.Sum([]byte(keyStr))
-	return hex.EncodeToString(hash[:])
-}
-
-// generateRunnerKey creates MD5 hash for runner
-// Matches Python: MD5(race_key|horse|num|draw)
-func generateRunnerKey(raceKey, horse string, num, draw int) string {
-	keyStr := fmt.Sprintf("%s|%s|%d|%d", raceKey, horse, num, draw)
-	hash := md5.Sum([]byte(keyStr))
-	return hex.EncodeToString(hash[:])
-}
-
 // ParseDateYYYYMMDD parses YYYY-MM-DD date format
 func ParseDateYYYYMMDD(dateStr string) (time.Time, error) {
 	return time.Parse("2006-01-02", dateStr)
@@ -0,0 +1,194 @@
+package usage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Hit is one metered request
+type Hit struct {
+	Client   string // "key:<id>", "jwt:<subject>" or "ip:<address>"
+	KeyID    int64  // racing.api_keys (0 = none)
+	Route    string // Route template, e.g. /api/v1/races/:id
+	Method   string
+	Status   int
+	Rows     int // Rows returned (see RowCounter)
+	Duration time.Duration
+	At       time.Time
+}
+
+// counter is the running total for one hour, client and route
+type counter struct {
+	keyID     int64
+	requests  int64
+	errors    int64 // 5xx
+	throttled int64 // 429
+	rows      int64
+	totalMS   int64
+	maxMS     int64
+}
+
+type counterKey struct {
+	hour   time.Time
+	client string
+	route  string
+	method string
+}
+
+// Meter totals requests in memory and flushes them to racing.api_usage
+type Meter struct {
+	db       *sql.DB
+	interval time.Duration
+
+	mu       sync.Mutex
+	counters map[counterKey]*counter
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewMeter creates a meter flushing every interval (default 30s)
+func NewMeter(db *sql.DB, interval time.Duration) *Meter {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	return &Meter{
+		db:       db,
+		interval: interval,
+		counters: make(map[counterKey]*counter),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Record adds a request to the current totals
+func (m *Meter) Record(h Hit) {
+	key := counterKey{hour: h.At.UTC().Truncate(time.Hour), client: h.Client, route: h.Route, method: h.Method}
+	ms := h.Duration.Milliseconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c := m.counters[key]
+	if c == nil {
+		c = &counter{keyID: h.KeyID}
+		m.counters[key] = c
+	}
+	c.requests++
+	if h.Status >= 500 {
+		c.errors++
+	}
+	if h.Status == 429 {
+		c.throttled++
+	}
+	c.rows += int64(h.Rows)
+	c.totalMS += ms
+	if ms > c.maxMS {
+		c.maxMS = ms
+	}
+}
+
+// Start flushes in the background until Close
+func (m *Meter) Start() {
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.stop:
+				return
+			case <-ticker.C:
+				if err := m.Flush(context.Background()); err != nil {
+					log.Printf("[Usage] ⚠️  Flush failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Close stops the background flush and writes what is left
+func (m *Meter) Close() error {
+	close(m.stop)
+	<-m.done
+	return m.Flush(context.Background())
+}
+
+// Flush adds the totals since the last flush to racing.api_usage. Totals that
+// fail to write are kept for the next flush.
+func (m *Meter) Flush(ctx context.Context) error {
+	m.mu.Lock()
+	pending := m.counters
+	m.counters = make(map[counterKey]*counter)
+	m.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	err := m.write(ctx, pending)
+	if err != nil {
+		m.mu.Lock()
+		for key, c := range pending {
+			if cur := m.counters[key]; cur != nil {
+				c.requests += cur.requests
+				c.errors += cur.errors
+				c.throttled += cur.throttled
+				c.rows += cur.rows
+				c.totalMS += cur.totalMS
+				if cur.maxMS > c.maxMS {
+					c.maxMS = cur.maxMS
+				}
+			}
+			m.counters[key] = c
+		}
+		m.mu.Unlock()
+	}
+	return err
+}
+
+func (m *Meter) write(ctx context.Context, pending map[counterKey]*counter) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO racing.api_usage (
+			hour, client, key_id, route, method, requests, errors, throttled, rows, total_ms, max_ms
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (hour, client, route, method) DO UPDATE SET
+			requests = racing.api_usage.requests + EXCLUDED.requests,
+			errors = racing.api_usage.errors + EXCLUDED.errors,
+			throttled = racing.api_usage.throttled + EXCLUDED.throttled,
+			rows = racing.api_usage.rows + EXCLUDED.rows,
+			total_ms = racing.api_usage.total_ms + EXCLUDED.total_ms,
+			max_ms = GREATEST(racing.api_usage.max_ms, EXCLUDED.max_ms)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare usage upsert: %w", err)
+	}
+	defer stmt.Close()
+
+	for key, c := range pending {
+		var keyID interface{}
+		if c.keyID > 0 {
+			keyID = c.keyID
+		}
+		if _, err := stmt.ExecContext(ctx,
+			key.hour, key.client, keyID, key.route, key.method,
+			c.requests, c.errors, c.throttled, c.rows, c.totalMS, c.maxMS,
+		); err != nil {
+			return fmt.Errorf("failed to record usage for %s: %w", key.client, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit usage: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package usage
+
+// RowCounter counts the rows in a JSON response as it is written: the
+// elements of a top-level array, or of the arrays directly inside a
+// top-level object (e.g. a race's "runners"). A top-level object without
+// arrays counts as one row.
+type RowCounter struct {
+	depth    int
+	rootObj  bool
+	inString bool
+	escaped  bool
+	started  bool // Inside the current counted array, an element has begun
+	counting bool // The current array is counted
+	rows     int
+	sawArray bool
+	any      bool
+}
+
+// Write scans the next chunk of the response
+func (r *RowCounter) Write(p []byte) {
+	for _, b := range p {
+		if r.inString {
+			switch {
+			case r.escaped:
+				r.escaped = false
+			case b == '\\':
+				r.escaped = true
+			case b == '"':
+				r.inString = false
+			}
+			continue
+		}
+
+		switch b {
+		case ' ', '\t', '\n', '\r':
+			continue
+		}
+
+		if !r.any {
+			r.any = true
+			r.rootObj = b == '{'
+		}
+
+		// A value starting inside a counted array begins an element
+		if r.counting && !r.started && b != ']' && b != ',' {
+			r.started = true
+			r.rows++
+		}
+
+		switch b {
+		case '"':
+			r.inString = true
+		case '[', '{':
+			r.depth++
+			if b == '[' && (r.depth == 1 || (r.depth == 2 && r.rootObj)) {
+				r.counting, r.started, r.sawArray = true, false, true
+			}
+		case ']', '}':
+			if b == ']' && (r.depth == 1 || (r.depth == 2 && r.rootObj)) {
+				r.counting = false
+			}
+			r.depth--
+		case ',':
+			if r.counting && r.countedDepth() {
+				r.started = false
+			}
+		}
+	}
+}
+
+// countedDepth reports whether the scanner is directly inside a counted array
+func (r *RowCounter) countedDepth() bool {
+	return r.depth == 1 || (r.depth == 2 && r.rootObj)
+}
+
+// Rows returns the rows counted so far
+func (r *RowCounter) Rows() int {
+	if r.rootObj && !r.sawArray {
+		return 1
+	}
+	return r.rows
+}
@@ -51,7 +51,7 @@ echo "Sample Cases (first 5):"
 echo "$BACKTEST" | python3 -c "
 import json, sys
 d = json.load(sys.stdin)
-for i, c in enumerate(d.get('cases', [])[:5]):
+for i, c in enumerate(d.get('data', [])[:5]):
     print(f\"\\n{i+1}. {c['horse_name']}\")
     print(f\"   Last Run: {c['last_date'][:10]} - Pos {c['last_pos']}, Beaten {c.get('last_btn', 'N/A')}L\")
     print(f\"   Next Run: {c['next_date'][:10]} - Pos {c.get('next_pos', '?')}, Won: {c['next_win']}\")
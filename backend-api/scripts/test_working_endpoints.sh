@@ -25,7 +25,7 @@ curl -s -w "\n  Status: %{http_code}, Time: %{time_total}s\n" "$BASE_URL/api/v1/
 echo ""
 
 echo "5️⃣  Race Search with Filters"
-curl -s -w "\n  Status: %{http_code}, Time: %{time_total}s\n" "$BASE_URL/api/v1/races/search?date_from=2024-01-01&date_to=2024-01-02&region=GB&limit=10" | python3 -c "import json, sys; d=json.load(sys.stdin); print(f'  Found {len(d)} GB races')" 2>&1 | head -2
+curl -s -w "\n  Status: %{http_code}, Time: %{time_total}s\n" "$BASE_URL/api/v1/races/search?date_from=2024-01-01&date_to=2024-01-02&region=GB&limit=10" | python3 -c "import json, sys; d=json.load(sys.stdin); print(f'  Found {len(d[\"data\"])} GB races')" 2>&1 | head -2
 echo ""
 
 echo "6️⃣  Single Race Details (Race ID 339)"
@@ -33,7 +33,7 @@ curl -s -w "\n  Status: %{http_code}, Time: %{time_total}s\n" "$BASE_URL/api/v1/
 echo ""
 
 echo "7️⃣  Comment Search"
-curl -s -w "\n  Status: %{http_code}, Time: %{time_total}s\n" "$BASE_URL/api/v1/search/comments?q=led&limit=3" | python3 -c "import json, sys; d=json.load(sys.stdin); print(f'  Found {len(d)} comments')" 2>&1 | head -2
+curl -s -w "\n  Status: %{http_code}, Time: %{time_total}s\n" "$BASE_URL/api/v1/search/comments?q=led&limit=3" | python3 -c "import json, sys; d=json.load(sys.stdin); print(f'  Found {len(d[\"data\"])} comments')" 2>&1 | head -2
 echo ""
 
 echo "8️⃣  Draw Bias Analysis (Aintree 5-7f)"
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"testing"
 	"time"
 
@@ -22,6 +23,10 @@ func makeRequest(t *testing.T, method, url string) ([]byte, int) {
 	if err != nil {
 		t.Fatalf("Failed to create request: %v", err)
 	}
+	// Servers with AUTH_REQUIRED need a key (see cmd/create_api_key)
+	if key := os.Getenv("GIDDYUP_API_KEY"); key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -229,10 +234,11 @@ func TestRaceSearchWithFilters(t *testing.T) {
 				t.Fatalf("Race search failed with status %d: %s", status, string(body))
 			}
 
-			var races []models.Race
-			if err := json.Unmarshal(body, &races); err != nil {
+			var page models.Page[models.Race]
+			if err := json.Unmarshal(body, &page); err != nil {
 				t.Fatalf("Failed to parse races: %v", err)
 			}
+			races := page.Data
 
 			if len(races) < tc.minRaces {
 				t.Errorf("Expected at least %d races, got %d", tc.minRaces, len(races))
@@ -246,6 +252,49 @@ func TestRaceSearchWithFilters(t *testing.T) {
 	}
 }
 
+// Test 3b: Cursor pagination of race search
+func TestRaceSearchCursor(t *testing.T) {
+	t.Log("=== Testing Race Search Cursor ===")
+
+	url := fmt.Sprintf("%s/races/search?date_from=2024-01-01&date_to=2024-01-31&limit=5", baseURL)
+	seen := make(map[int64]bool)
+	cursor := ""
+	for pageNum := 1; pageNum <= 3; pageNum++ {
+		pageURL := url
+		if cursor != "" {
+			pageURL += "&cursor=" + cursor
+		}
+		body, status := makeRequest(t, "GET", pageURL)
+		if status != 200 {
+			t.Fatalf("Page %d failed with status %d: %s", pageNum, status, string(body))
+		}
+
+		var page models.Page[models.Race]
+		if err := json.Unmarshal(body, &page); err != nil {
+			t.Fatalf("Failed to parse page %d: %v", pageNum, err)
+		}
+		if page.Meta.Returned != len(page.Data) || len(page.Data) > 5 {
+			t.Errorf("Page %d: returned=%d with %d rows (limit 5)", pageNum, page.Meta.Returned, len(page.Data))
+		}
+		for _, race := range page.Data {
+			if seen[race.RaceID] {
+				t.Errorf("Race %d appears on more than one page", race.RaceID)
+			}
+			seen[race.RaceID] = true
+		}
+		if page.Meta.NextCursor == nil {
+			break
+		}
+		cursor = *page.Meta.NextCursor
+	}
+	t.Logf("✅ Paged through %d distinct races", len(seen))
+
+	body, status := makeRequest(t, "GET", url+"&cursor=not-a-cursor")
+	if status != 400 {
+		t.Errorf("Invalid cursor: expected 400, got %d: %s", status, string(body))
+	}
+}
+
 // Test 4: Race Details with Runners
 func TestRaceDetailsWithRunners(t *testing.T) {
 	t.Log("=== Testing Race Details with Runners ===")
@@ -375,10 +424,11 @@ func TestMarketMovers(t *testing.T) {
 		t.Fatalf("Failed to get market movers: %d", status)
 	}
 
-	var movers []models.MarketMover
-	if err := json.Unmarshal(body, &movers); err != nil {
+	var page models.Page[models.MarketMover]
+	if err := json.Unmarshal(body, &page); err != nil {
 		t.Fatalf("Failed to parse movers: %v", err)
 	}
+	movers := page.Data
 
 	t.Logf("✅ Found %d market movers", len(movers))
 
@@ -470,10 +520,11 @@ func TestCommentSearch(t *testing.T) {
 				t.Fatalf("Comment search failed: %d", status)
 			}
 
-			var results []models.CommentSearchResult
-			if err := json.Unmarshal(body, &results); err != nil {
+			var page models.Page[models.CommentSearchResult]
+			if err := json.Unmarshal(body, &page); err != nil {
 				t.Fatalf("Failed to parse comment results: %v", err)
 			}
+			results := page.Data
 
 			t.Logf("✅ Found %d results for '%s'", len(results), term)
 			for i, result := range results {
@@ -526,3 +577,59 @@ func TestCoursesAndMeetings(t *testing.T) {
 		t.Logf("   Latest meeting: %s (%d races)", meetings[0].RaceDate, meetings[0].RaceCount)
 	}
 }
+
+// Test: Admin endpoints reject anonymous and bad credentials
+func TestAdminRequiresAuth(t *testing.T) {
+	t.Log("=== Testing Admin Authentication ===")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	cases := []struct {
+		name   string
+		header string
+		want   []int
+	}{
+		{"no credentials", "", []int{401, 403}}, // 403 when anonymous reads are allowed
+		{"unknown key", "Bearer gu_not-a-real-key", []int{401}},
+		{"bad JWT", "Bearer e30.e30.bad", []int{401}},
+		{"wrong scheme", "Basic YWRtaW46YWRtaW4=", []int{401}},
+	}
+
+	for _, tc := range cases {
+		req, err := http.NewRequest("GET", baseURL+"/admin/status", nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		if tc.header != "" {
+			req.Header.Set("Authorization", tc.header)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to call admin status: %v", err)
+		}
+		resp.Body.Close()
+
+		ok := false
+		for _, status := range tc.want {
+			ok = ok || resp.StatusCode == status
+		}
+		if !ok {
+			t.Errorf("%s: expected %v, got %d", tc.name, tc.want, resp.StatusCode)
+			continue
+		}
+		t.Logf("✅ %s: %d", tc.name, resp.StatusCode)
+	}
+
+	// The caller's own principal is always readable
+	body, status := makeRequest(t, "GET", baseURL+"/auth/me")
+	if status == 200 {
+		var me struct {
+			Method string   `json:"method"`
+			Role   string   `json:"role"`
+			Scopes []string `json:"scopes"`
+		}
+		if err := json.Unmarshal(body, &me); err != nil {
+			t.Fatalf("Failed to parse auth/me: %v", err)
+		}
+		t.Logf("✅ Authenticated as %s (%s, scopes %v)", me.Role, me.Method, me.Scopes)
+	}
+}
@@ -0,0 +1,75 @@
+-- Migration 013: Forward entries and declarations
+-- Purpose: Track horses entered/declared for races up to 5 days ahead (and long-range
+--          entries for big races) separately from the runners fact table.
+--          Status lifecycle: entered → declared → runner | non_runner
+--          (entries that drop out before declarations become 'scratched')
+
+SET search_path TO racing, public;
+
+-- Entry races: one row per Sporting Life race, known before the card is final
+CREATE TABLE IF NOT EXISTS entry_races (
+  sl_race_id  bigint PRIMARY KEY,            -- Sporting Life race reference
+  race_date   date NOT NULL,
+  region      text NOT NULL,
+  course_id   bigint REFERENCES courses(course_id),
+  off_time    time,
+  race_name   text NOT NULL,
+  race_type   text,
+  class       text,
+  dist_raw    text,
+  dist_f      double precision,
+  going       text,
+  surface     text,
+  race_key    text,                          -- Matches racing.races.race_key once declared
+  first_seen_at timestamptz NOT NULL DEFAULT now(),
+  updated_at    timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_entry_races_date ON entry_races(race_date);
+CREATE INDEX IF NOT EXISTS idx_entry_races_key  ON entry_races(race_key, race_date);
+
+-- Entries: one row per horse per entry race
+CREATE TABLE IF NOT EXISTS entries (
+  entry_id    bigserial PRIMARY KEY,
+  sl_race_id  bigint NOT NULL REFERENCES entry_races(sl_race_id) ON DELETE CASCADE,
+  race_date   date NOT NULL,
+  horse_id    bigint NOT NULL REFERENCES horses(horse_id),
+  trainer_id  bigint REFERENCES trainers(trainer_id),
+  jockey_id   bigint REFERENCES jockeys(jockey_id),
+  owner_id    bigint REFERENCES owners(owner_id),
+  num         integer,
+  draw        integer,
+  lbs         integer,
+  "or"        integer,
+  status      text NOT NULL CHECK (status IN ('entered','declared','runner','non_runner','scratched')),
+  entered_at  timestamptz NOT NULL DEFAULT now(),
+  declared_at timestamptz,
+  settled_at  timestamptz,
+  updated_at  timestamptz NOT NULL DEFAULT now(),
+  CONSTRAINT entries_uniq UNIQUE (sl_race_id, horse_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_entries_horse   ON entries(horse_id, race_date);
+CREATE INDEX IF NOT EXISTS idx_entries_trainer ON entries(trainer_id, race_date);
+CREATE INDEX IF NOT EXISTS idx_entries_date    ON entries(race_date, status);
+
+-- Status ordering so upserts never move an entry backwards (e.g. declared → entered)
+CREATE OR REPLACE FUNCTION entry_status_rank(s text)
+RETURNS int
+LANGUAGE sql
+IMMUTABLE
+AS $$
+  SELECT CASE s
+    WHEN 'entered'    THEN 1
+    WHEN 'declared'   THEN 2
+    WHEN 'scratched'  THEN 3
+    WHEN 'runner'     THEN 3
+    WHEN 'non_runner' THEN 3
+    ELSE 0
+  END;
+$$;
+
+COMMENT ON TABLE entry_races IS 'Races with forward entries/declarations (up to 5 days ahead plus long-range big-race entries)';
+COMMENT ON TABLE entries IS 'Horse entries per race with status lifecycle entered → declared → runner/non_runner';
+
+\echo '✅ Migration 013 complete: entries tables created'
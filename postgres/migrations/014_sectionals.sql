@@ -0,0 +1,27 @@
+-- Migration 014: Runner sectional times
+-- Purpose: Store per-runner sectional splits (where published) for pace/speed analysis.
+--          Overall finishing times live on runners.time_raw / runners.secs.
+
+SET search_path TO racing, public;
+
+CREATE TABLE IF NOT EXISTS runner_sectionals (
+  runner_id     bigint NOT NULL,
+  race_date     date NOT NULL,
+  section_seq   integer NOT NULL,          -- 1-based order from the start
+  section_label text,                      -- Source label, e.g. "Final 2f"
+  dist_y        integer,                   -- Section length in yards
+  secs          double precision NOT NULL, -- Time for this section
+  inserted_at   timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY (runner_id, race_date, section_seq),
+  FOREIGN KEY (runner_id, race_date) REFERENCES runners(runner_id, race_date) ON DELETE CASCADE,
+  CONSTRAINT runner_sectionals_secs_chk CHECK (secs > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_runner_sectionals_date ON runner_sectionals(race_date);
+
+-- Finishing times (populated from results)
+CREATE INDEX IF NOT EXISTS idx_runners_secs ON runners(race_id) WHERE secs IS NOT NULL;
+
+COMMENT ON TABLE runner_sectionals IS 'Per-runner sectional splits keyed by runner and section (Sporting Life results, where published)';
+
+\echo '✅ Migration 014 complete: runner_sectionals created'
@@ -0,0 +1,34 @@
+-- Migration 015: Bookmaker price history
+-- Purpose: Keep every bookmaker's show per runner (opening show, subsequent shows, SP)
+--          from the Sporting Life betting endpoint, so book prices can be compared
+--          with the Betfair exchange at the same moment.
+
+SET search_path TO racing, public;
+
+CREATE TABLE IF NOT EXISTS bookmaker_prices (
+  price_id     bigserial PRIMARY KEY,
+  runner_id    bigint NOT NULL,
+  race_id      bigint NOT NULL,
+  race_date    date NOT NULL,
+  bookmaker    text NOT NULL,               -- 'SP' for the industry starting price
+  bookmaker_id integer,                     -- Sporting Life bookmaker ID
+  ts           timestamptz NOT NULL,        -- When the show was captured
+  dec          double precision NOT NULL,
+  frac         text,
+  show_type    text NOT NULL CHECK (show_type IN ('opening','show','sp')),
+  ew_places    integer,
+  CONSTRAINT bookmaker_prices_dec_chk CHECK (dec >= 1.01),
+  CONSTRAINT bookmaker_prices_uniq UNIQUE (runner_id, race_date, bookmaker, ts)
+);
+
+-- One SP per runner per bookmaker
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bookmaker_prices_sp
+  ON bookmaker_prices(runner_id, race_date, bookmaker) WHERE show_type = 'sp';
+
+CREATE INDEX IF NOT EXISTS idx_bookmaker_prices_race   ON bookmaker_prices(race_id, ts);
+CREATE INDEX IF NOT EXISTS idx_bookmaker_prices_latest ON bookmaker_prices(runner_id, bookmaker, ts DESC);
+CREATE INDEX IF NOT EXISTS idx_bookmaker_prices_date   ON bookmaker_prices(race_date, bookmaker);
+
+COMMENT ON TABLE bookmaker_prices IS 'Bookmaker show history per runner (opening, subsequent shows when the price changes, SP)';
+
+\echo '✅ Migration 015 complete: bookmaker_prices created'
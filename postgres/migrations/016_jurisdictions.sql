@@ -0,0 +1,38 @@
+-- Migration 016: Jurisdictions and course-local off times
+-- Purpose: Support racing outside GB/IRE (FR, USA, AUS, ...). Region values in
+--          races/courses are the jurisdiction codes from JURISDICTIONS; off_time
+--          stays UK local time (as scraped) and off_time_local holds the course's
+--          own clock, derived from courses.timezone.
+
+SET search_path TO racing, public;
+
+-- Course timezone (IANA name, e.g. 'America/Los_Angeles')
+ALTER TABLE courses ADD COLUMN IF NOT EXISTS timezone text;
+
+UPDATE courses SET timezone = CASE region
+    WHEN 'GB'  THEN 'Europe/London'
+    WHEN 'IRE' THEN 'Europe/Dublin'
+    WHEN 'FR'  THEN 'Europe/Paris'
+    WHEN 'USA' THEN 'America/New_York'
+    WHEN 'AUS' THEN 'Australia/Sydney'
+    WHEN 'RSA' THEN 'Africa/Johannesburg'
+    ELSE 'Europe/London'
+  END
+WHERE timezone IS NULL;
+
+-- Off time on the course's clock (off_time is UK local)
+ALTER TABLE races ADD COLUMN IF NOT EXISTS off_time_local time;
+
+UPDATE races r
+SET off_time_local = ((r.race_date + r.off_time) AT TIME ZONE 'Europe/London' AT TIME ZONE c.timezone)::time
+FROM courses c
+WHERE c.course_id = r.course_id
+  AND r.off_time IS NOT NULL
+  AND r.off_time_local IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_races_region_date ON races(region, race_date);
+
+COMMENT ON COLUMN courses.timezone IS 'IANA timezone of the course, used to derive races.off_time_local';
+COMMENT ON COLUMN races.off_time_local IS 'Off time in the course''s local timezone (off_time is UK local)';
+
+\echo '✅ Migration 016 complete: course timezones and local off times added'
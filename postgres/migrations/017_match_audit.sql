@@ -0,0 +1,48 @@
+-- Migration 017: Match audit trail and manual overrides
+-- Purpose: Record every Sporting Life ↔ Betfair match attempt (race and runner
+--          level, matched or not) with its scored candidates, and let admins pin
+--          or forbid specific race ↔ market and horse ↔ selection pairings.
+--          Every matcher (live, historical, stitcher) honours the overrides.
+
+SET search_path TO racing, public;
+
+-- One row per match attempt
+CREATE TABLE IF NOT EXISTS match_audit (
+    audit_id    bigserial PRIMARY KEY,
+    run_at      timestamptz NOT NULL DEFAULT now(),
+    pipeline    text NOT NULL,                      -- live, historical, stitcher
+    level       text NOT NULL CHECK (level IN ('race', 'runner')),
+    race_date   date,
+    race_key    text NOT NULL DEFAULT '',
+    subject     text NOT NULL,                      -- "Course HH:MM" or horse name
+    outcome     text NOT NULL,                      -- matched, ambiguous, below_threshold, no_candidates, taken
+    chosen_ref  text,                               -- market ID / MarketRef, or selection ID / runner name
+    score       double precision NOT NULL DEFAULT 0,
+    runner_up   double precision NOT NULL DEFAULT 0,
+    override    text,                               -- pin / forbid when an override decided the outcome
+    candidates  jsonb NOT NULL DEFAULT '[]'::jsonb  -- Top candidates with score breakdowns
+);
+
+CREATE INDEX IF NOT EXISTS idx_match_audit_date_level ON match_audit(race_date, level);
+CREATE INDEX IF NOT EXISTS idx_match_audit_race_key ON match_audit(race_key, run_at DESC);
+
+-- Manual pins / forbids
+CREATE TABLE IF NOT EXISTS match_overrides (
+    override_id bigserial PRIMARY KEY,
+    level       text NOT NULL CHECK (level IN ('race', 'runner')),
+    race_key    text NOT NULL,
+    race_date   date,
+    horse       text NOT NULL DEFAULT '',           -- Runner level only
+    bf_ref      text NOT NULL,                      -- Market ID or "venue|HH:MM"; selection ID or runner name
+    action      text NOT NULL CHECK (action IN ('pin', 'forbid')),
+    note        text,
+    created_at  timestamptz NOT NULL DEFAULT now(),
+    UNIQUE (level, race_key, horse, bf_ref)
+);
+
+CREATE INDEX IF NOT EXISTS idx_match_overrides_date ON match_overrides(race_date);
+
+COMMENT ON TABLE match_audit IS 'Every race/runner match attempt by each matching pipeline, with scored candidates';
+COMMENT ON TABLE match_overrides IS 'Manual pin/forbid pairings honoured by every matcher on subsequent runs';
+
+\echo '✅ Migration 017 complete: match_audit and match_overrides created'
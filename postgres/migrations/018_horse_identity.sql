@@ -0,0 +1,82 @@
+-- Migration 018: Horse identity resolution
+-- Purpose: Stop merging different horses that share a name (different countries
+--          or generations). Horses carry external IDs (Sporting Life reference,
+--          Betfair selection ID), foaling year, country suffix and sire/dam; the
+--          unique key becomes (horse_norm, disambig) so a second horse with the
+--          same name gets its own row. Renames are recorded in horse_alias and
+--          every resolver/admin decision is logged in horse_identity_log.
+
+SET search_path TO racing, public;
+
+ALTER TABLE horses ADD COLUMN IF NOT EXISTS sl_horse_id bigint;
+ALTER TABLE horses ADD COLUMN IF NOT EXISTS bf_selection_id bigint;
+ALTER TABLE horses ADD COLUMN IF NOT EXISTS foaled_year int;
+ALTER TABLE horses ADD COLUMN IF NOT EXISTS suffix text;
+ALTER TABLE horses ADD COLUMN IF NOT EXISTS sire text;
+ALTER TABLE horses ADD COLUMN IF NOT EXISTS dam text;
+ALTER TABLE horses ADD COLUMN IF NOT EXISTS disambig text NOT NULL DEFAULT '';
+ALTER TABLE horses ADD COLUMN IF NOT EXISTS updated_at timestamptz;
+
+-- One row per (name, identity). Name-only inserts (disambig '') still conflict
+-- on horses_uniq, so existing loaders keep attaching to the default record.
+ALTER TABLE horses DROP CONSTRAINT IF EXISTS horses_uniq;
+ALTER TABLE horses ADD CONSTRAINT horses_uniq UNIQUE (horse_norm, disambig);
+
+CREATE UNIQUE INDEX IF NOT EXISTS horses_sl_id_uniq ON horses(sl_horse_id) WHERE sl_horse_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS horses_bf_selection_idx ON horses(bf_selection_id) WHERE bf_selection_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS horse_alias_norm_idx ON horse_alias(alias_norm);
+
+-- Country suffix from names like 'Galileo Gold (IRE)'
+UPDATE horses
+SET suffix = upper(substring(horse_name FROM '\(([A-Za-z]{2,3})\)\s*$'))
+WHERE suffix IS NULL
+  AND horse_name ~ '\([A-Za-z]{2,3}\)\s*$';
+
+-- Foaling year from form (race year - age, most common value)
+UPDATE horses h
+SET foaled_year = f.foaled_year
+FROM (
+  SELECT horse_id,
+         mode() WITHIN GROUP (ORDER BY extract(year FROM race_date)::int - age) AS foaled_year
+  FROM runners
+  WHERE age > 0
+  GROUP BY horse_id
+) f
+WHERE f.horse_id = h.horse_id
+  AND h.foaled_year IS NULL;
+
+-- Decisions made by the resolver and by admins
+CREATE TABLE IF NOT EXISTS horse_identity_log (
+  log_id          bigserial PRIMARY KEY,
+  horse_id        bigint NOT NULL,             -- No FK: merged-away horses are deleted
+  other_horse_id  bigint,                      -- Merge source / split origin / conflicting horse
+  action          text NOT NULL CHECK (action IN ('create', 'attach', 'rename', 'conflict', 'merge', 'split')),
+  source          text NOT NULL DEFAULT 'resolver',  -- resolver or admin
+  detail          jsonb NOT NULL DEFAULT '{}'::jsonb,
+  note            text,
+  created_at      timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_horse_identity_log_horse ON horse_identity_log(horse_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_horse_identity_log_action ON horse_identity_log(action, created_at DESC);
+
+-- Horses whose form implies more than one foaling year (likely conflated)
+CREATE OR REPLACE VIEW horse_identity_conflicts AS
+SELECT r.horse_id,
+       h.horse_name,
+       min(extract(year FROM r.race_date)::int - r.age) AS min_foaled_year,
+       max(extract(year FROM r.race_date)::int - r.age) AS max_foaled_year,
+       count(*) AS runs
+FROM runners r
+JOIN horses h ON h.horse_id = r.horse_id
+WHERE r.age > 0
+GROUP BY r.horse_id, h.horse_name
+HAVING max(extract(year FROM r.race_date)::int - r.age) - min(extract(year FROM r.race_date)::int - r.age) > 1;
+
+COMMENT ON COLUMN horses.disambig IS 'Distinguishes horses sharing a name ('''' for the default record, e.g. ''sl:123456'' or ''IRE-2019'')';
+COMMENT ON COLUMN horses.sl_horse_id IS 'Sporting Life horse reference';
+COMMENT ON COLUMN horses.foaled_year IS 'Foaling year (race year - age)';
+COMMENT ON TABLE horse_identity_log IS 'Horse identity decisions (resolver and admin merge/split)';
+COMMENT ON VIEW horse_identity_conflicts IS 'Horses whose runs imply foaling years more than a year apart - candidates for a split';
+
+\echo '✅ Migration 018 complete: horse identity columns, horse_identity_log and conflicts view created'
@@ -0,0 +1,148 @@
+-- Migration 019: Course registry
+-- Purpose: One canonical row per course with per-source aliases, replacing the
+--          ad-hoc remap scripts (REMAP_COURSE_IDS.sql, FIX_ORPHANED_COURSES.sql,
+--          QUICK_FIX_COURSES.sql). Loaders resolve names through
+--          racing.resolve_course(); names that don't resolve are queued in
+--          course_review_queue instead of creating new (orphan) courses.
+
+SET search_path TO racing, public;
+
+-- Track characteristics
+ALTER TABLE courses ADD COLUMN IF NOT EXISTS surfaces text[] NOT NULL DEFAULT '{}';
+ALTER TABLE courses ADD COLUMN IF NOT EXISTS direction text;
+ALTER TABLE courses ADD COLUMN IF NOT EXISTS profile text;
+ALTER TABLE courses ADD COLUMN IF NOT EXISTS updated_at timestamptz;
+
+ALTER TABLE courses DROP CONSTRAINT IF EXISTS courses_direction_chk;
+ALTER TABLE courses ADD CONSTRAINT courses_direction_chk
+  CHECK (direction IS NULL OR direction IN ('left', 'right', 'straight', 'both'));
+
+-- Surfaces seen so far ('Turf', 'AW', ...)
+UPDATE courses c
+SET surfaces = s.surfaces
+FROM (
+  SELECT course_id, array_agg(DISTINCT surface ORDER BY surface) AS surfaces
+  FROM races
+  WHERE course_id IS NOT NULL AND surface IS NOT NULL AND surface <> ''
+  GROUP BY course_id
+) s
+WHERE s.course_id = c.course_id
+  AND c.surfaces = '{}';
+
+-- Per-source names for a course
+CREATE TABLE IF NOT EXISTS course_alias (
+  alias_id   bigserial PRIMARY KEY,
+  course_id  bigint NOT NULL REFERENCES courses(course_id) ON DELETE CASCADE,
+  source     text NOT NULL CHECK (source IN ('sportinglife', 'betfair', 'master', 'manual')),
+  region     text NOT NULL,
+  alias      text NOT NULL,
+  alias_norm text GENERATED ALWAYS AS (racing.norm_text(alias)) STORED,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  CONSTRAINT course_alias_uniq UNIQUE (source, region, alias_norm)
+);
+
+CREATE INDEX IF NOT EXISTS course_alias_lookup_idx ON course_alias(alias_norm, region);
+CREATE INDEX IF NOT EXISTS course_alias_course_idx ON course_alias(course_id);
+
+-- Names no loader could resolve, waiting for an admin decision
+CREATE TABLE IF NOT EXISTS course_review_queue (
+  review_id   bigserial PRIMARY KEY,
+  source      text NOT NULL,
+  region      text NOT NULL,
+  course_name text NOT NULL,
+  name_norm   text GENERATED ALWAYS AS (racing.norm_text(course_name)) STORED,
+  race_keys   text[] NOT NULL DEFAULT '{}',
+  seen_count  int NOT NULL DEFAULT 1,
+  first_seen  timestamptz NOT NULL DEFAULT now(),
+  last_seen   timestamptz NOT NULL DEFAULT now(),
+  status      text NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'resolved', 'ignored')),
+  course_id   bigint REFERENCES courses(course_id) ON DELETE SET NULL,
+  resolved_at timestamptz,
+  note        text,
+  CONSTRAINT course_review_uniq UNIQUE (source, region, name_norm)
+);
+
+CREATE INDEX IF NOT EXISTS course_review_status_idx ON course_review_queue(status, last_seen DESC);
+
+-- Resolve a source's course name: the source's own alias, then any alias, then
+-- the canonical name. NULL when unknown.
+CREATE OR REPLACE FUNCTION racing.resolve_course(p_source text, p_name text, p_region text)
+RETURNS bigint LANGUAGE sql STABLE AS $$
+  SELECT course_id FROM (
+    SELECT a.course_id, CASE WHEN a.source = p_source THEN 0 ELSE 1 END AS pref
+    FROM racing.course_alias a
+    WHERE a.alias_norm = racing.norm_text(p_name) AND a.region = upper(p_region)
+    UNION ALL
+    SELECT c.course_id, 2
+    FROM racing.courses c
+    WHERE c.course_norm = racing.norm_text(p_name) AND c.region = upper(p_region)
+  ) m
+  ORDER BY pref, course_id
+  LIMIT 1
+$$;
+
+-- Queue (or re-sight) an unresolved name, remembering the races waiting on it
+CREATE OR REPLACE FUNCTION racing.queue_course_review(p_source text, p_name text, p_region text, p_race_keys text[])
+RETURNS void LANGUAGE sql AS $$
+  INSERT INTO racing.course_review_queue (source, region, course_name, race_keys)
+  VALUES (p_source, upper(p_region), p_name, COALESCE(p_race_keys, '{}'))
+  ON CONFLICT ON CONSTRAINT course_review_uniq DO UPDATE SET
+    last_seen  = now(),
+    seen_count = course_review_queue.seen_count + 1,
+    race_keys  = ARRAY(SELECT DISTINCT k FROM unnest(course_review_queue.race_keys || EXCLUDED.race_keys) k)
+$$;
+
+-- Seed aliases for the Sporting Life / Betfair spellings the matcher already knew
+-- about (long name ↔ short venue), against whichever form the course is stored as
+WITH pairs(long_name, short_name) AS (
+  VALUES
+    ('Kempton Park', 'Kempton'),
+    ('Sandown Park', 'Sandown'),
+    ('Haydock Park', 'Haydock'),
+    ('Lingfield Park', 'Lingfield'),
+    ('Epsom Downs', 'Epsom'),
+    ('Catterick Bridge', 'Catterick'),
+    ('Great Yarmouth', 'Yarmouth'),
+    ('Newmarket (July)', 'Newmarket'),
+    ('Newmarket (Rowley)', 'Newmarket'),
+    ('Cartmel Park', 'Cartmel'),
+    ('Bangor-on-Dee', 'Bangor'),
+    ('Stratford-on-Avon', 'Stratford'),
+    ('Perth Hunt', 'Perth'),
+    ('Gowran Park', 'Gowran'),
+    ('Chelmsford City', 'Chelmsford'),
+    ('ParisLongchamp', 'Longchamp'),
+    ('Saint-Cloud', 'St Cloud'),
+    ('Santa Anita Park', 'Santa Anita')
+)
+INSERT INTO course_alias (course_id, source, region, alias)
+SELECT c.course_id,
+       CASE WHEN c.course_norm = racing.norm_text(p.short_name) THEN 'sportinglife' ELSE 'betfair' END,
+       c.region,
+       CASE WHEN c.course_norm = racing.norm_text(p.short_name) THEN p.long_name ELSE p.short_name END
+FROM pairs p
+JOIN courses c ON c.course_norm IN (racing.norm_text(p.long_name), racing.norm_text(p.short_name))
+ON CONFLICT ON CONSTRAINT course_alias_uniq DO NOTHING;
+
+-- Races pointing at a course_id that isn't in the registry (what
+-- cmd/fix_orphaned_courses used to list)
+CREATE OR REPLACE VIEW course_orphans AS
+SELECT r.course_id,
+       r.region,
+       count(*)                                   AS race_count,
+       min(r.race_date)                           AS first_race,
+       max(r.race_date)                           AS last_race,
+       (array_agg(r.race_name ORDER BY r.race_date DESC))[1:3] AS sample_races
+FROM races r
+WHERE r.course_id IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM courses c WHERE c.course_id = r.course_id)
+GROUP BY r.course_id, r.region;
+
+COMMENT ON COLUMN courses.surfaces IS 'Surfaces raced on (Turf, AW, ...)';
+COMMENT ON COLUMN courses.direction IS 'Track direction: left, right, straight or both';
+COMMENT ON COLUMN courses.profile IS 'Free-text track characteristics (galloping, tight, undulating, uphill finish, ...)';
+COMMENT ON TABLE course_alias IS 'Course names used by each source (sportinglife, betfair, master, manual)';
+COMMENT ON TABLE course_review_queue IS 'Course names no loader could resolve; races load with a NULL course_id until resolved';
+COMMENT ON FUNCTION racing.resolve_course(text, text, text) IS 'Canonical course_id for a source''s course name and region, NULL if unknown';
+
+\echo '✅ Migration 019 complete: course registry, aliases and review queue created'
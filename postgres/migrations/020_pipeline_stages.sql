@@ -0,0 +1,49 @@
+-- Migration 020: Staged ingestion pipeline state
+-- Purpose: Every loader now runs internal/pipeline (fetch → cache → stitch →
+--          validate → load → refresh). Each run records the stage it is in and
+--          the stages it completed on its data_updates row, so a run that fails
+--          or crashes part-way is resumed from the failed stage for that date.
+
+SET search_path TO racing, public;
+
+ALTER TABLE data_updates ADD COLUMN IF NOT EXISTS stage VARCHAR(20);
+ALTER TABLE data_updates ADD COLUMN IF NOT EXISTS stages_done TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE data_updates ADD COLUMN IF NOT EXISTS failed_stage VARCHAR(20);
+ALTER TABLE data_updates ADD COLUMN IF NOT EXISTS prelim BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE data_updates ADD COLUMN IF NOT EXISTS artifact_dir TEXT;
+ALTER TABLE data_updates ADD COLUMN IF NOT EXISTS races_rejected INT DEFAULT 0;
+ALTER TABLE data_updates ADD COLUMN IF NOT EXISTS views_refreshed BOOLEAN DEFAULT FALSE;
+ALTER TABLE data_updates ADD COLUMN IF NOT EXISTS resumed_by INT REFERENCES data_updates(update_id);
+
+ALTER TABLE data_updates DROP CONSTRAINT IF EXISTS data_updates_stage_chk;
+ALTER TABLE data_updates ADD CONSTRAINT data_updates_stage_chk CHECK (
+  (stage IS NULL OR stage IN ('fetch', 'cache', 'stitch', 'validate', 'load', 'refresh')) AND
+  (failed_stage IS NULL OR failed_stage IN ('fetch', 'cache', 'stitch', 'validate', 'load', 'refresh'))
+);
+
+-- Interrupted runs waiting to be resumed
+CREATE INDEX IF NOT EXISTS idx_data_updates_resumable
+  ON data_updates(update_type, update_date, update_id)
+  WHERE status IN ('running', 'failed') AND resumed_by IS NULL;
+
+-- Backfilled dates count as updated too
+CREATE OR REPLACE VIEW racing.update_status AS
+SELECT
+    update_date,
+    MAX(CASE WHEN status = 'completed' THEN update_id END) as latest_successful_id,
+    MAX(CASE WHEN status = 'completed' THEN completed_at END) as last_updated_at,
+    COUNT(CASE WHEN status = 'failed' THEN 1 END) as failure_count,
+    MAX(CASE WHEN status = 'completed' THEN races_loaded END) as races_loaded,
+    MAX(CASE WHEN status = 'completed' THEN runners_loaded END) as runners_loaded
+FROM racing.data_updates
+WHERE update_type IN ('daily', 'backfill')
+GROUP BY update_date
+ORDER BY update_date DESC;
+
+COMMENT ON COLUMN data_updates.stage IS 'Pipeline stage in progress (or last started)';
+COMMENT ON COLUMN data_updates.stages_done IS 'Pipeline stages completed, in order';
+COMMENT ON COLUMN data_updates.failed_stage IS 'Stage the run failed at; a rerun for the date resumes here';
+COMMENT ON COLUMN data_updates.artifact_dir IS 'Directory holding fetched/stitched/validated races for resuming';
+COMMENT ON COLUMN data_updates.resumed_by IS 'Later run that picked up this interrupted one (status = resumed)';
+
+\echo '✅ Migration 020 complete: data_updates records pipeline stages for resumable ingestion'
@@ -0,0 +1,26 @@
+-- Migration 021: Locked, idempotent ingestion
+-- Purpose: Every loader (pipeline runs, load_master) now records itself in
+--          etl_runs, marks the results days it loaded in ingested_days, and
+--          holds a per-date advisory lock while writing, so the API auto-updater
+--          and cron commands no longer race on the same date.
+
+SET search_path TO racing, public;
+
+-- Reloads re-point a day at the run that last loaded it
+GRANT UPDATE ON ingested_days TO postgres;
+
+-- Runs still in progress (or killed without finishing)
+CREATE INDEX IF NOT EXISTS idx_etl_runs_running
+  ON etl_runs(started_at)
+  WHERE status = 'running';
+
+COMMENT ON COLUMN etl_runs.scope IS 'What the run covered: {"kind","source","dates","regions","codes","update_id"} (master runs list months in dates)';
+COMMENT ON COLUMN etl_runs.stats IS 'Run counts: races/runners scraped, rejected and inserted';
+COMMENT ON COLUMN ingested_days.code IS 'flat or jumps';
+COMMENT ON COLUMN ingested_days.run_id IS 'etl_runs row that last loaded the day';
+
+-- Advisory locks (taken by internal/pipeline, not stored in a table):
+--   pg_try_advisory_lock(7421, hashtext('ingest:<YYYY-MM-DD>'))  -- a date's races
+--   pg_try_advisory_lock(7421, hashtext('ingest:master'))        -- one load_master at a time
+
+\echo '✅ Migration 021 complete: etl_runs/ingested_days recorded by every loader'
@@ -0,0 +1,50 @@
+-- Migration 022: Data-quality rules and quarantine
+-- Purpose: Every loader checks races and runners against the rules in
+--          internal/quality before loading. Rows breaking an error rule are
+--          held back and stored here with the reason; warnings and info are
+--          loaded and recorded here too, for the per-date quality summary.
+
+SET search_path TO racing, public;
+
+CREATE TABLE IF NOT EXISTS quarantine (
+  id            BIGSERIAL PRIMARY KEY,
+  rule          TEXT NOT NULL,
+  severity      TEXT NOT NULL CHECK (severity IN ('info', 'warning', 'error')),
+  scope         TEXT NOT NULL CHECK (scope IN ('race', 'runner')),
+  race_key      TEXT NOT NULL,
+  race_date     DATE NOT NULL,
+  course        TEXT,
+  off_time      TEXT,
+  runner_key    TEXT NOT NULL DEFAULT '',
+  horse         TEXT,
+  reason        TEXT NOT NULL,
+  held          BOOLEAN NOT NULL DEFAULT FALSE,
+  payload       JSONB,
+  source        TEXT NOT NULL,
+  run_id        BIGINT REFERENCES etl_runs(run_id),
+  first_seen_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  last_seen_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+  times_seen    INT NOT NULL DEFAULT 1,
+  resolved_at   TIMESTAMPTZ
+);
+
+-- One row per rule per race/runner; reloads bump it instead of duplicating
+CREATE UNIQUE INDEX IF NOT EXISTS uq_quarantine_issue
+  ON quarantine(rule, race_date, race_key, runner_key);
+
+-- Open issues by date (quality summary)
+CREATE INDEX IF NOT EXISTS idx_quarantine_open
+  ON quarantine(race_date, severity)
+  WHERE resolved_at IS NULL;
+
+GRANT SELECT, INSERT, UPDATE ON quarantine TO postgres;
+GRANT USAGE, SELECT ON SEQUENCE quarantine_id_seq TO postgres;
+
+COMMENT ON TABLE quarantine IS 'Data-quality issues found at load time; held rows were not loaded';
+COMMENT ON COLUMN quarantine.rule IS 'Rule name (GET /api/v1/admin/quality/rules)';
+COMMENT ON COLUMN quarantine.held IS 'Error rules: the race or runner was kept out of racing.races/runners';
+COMMENT ON COLUMN quarantine.payload IS 'The held race or runner as the loader had it (held rows only)';
+COMMENT ON COLUMN quarantine.source IS 'Loader that found it: daily, backfill, racecard, intraday, master or copy';
+COMMENT ON COLUMN quarantine.resolved_at IS 'Set when a later load of the race no longer breaks the rule';
+
+\echo '✅ Migration 022 complete: quarantine table for data-quality rules'
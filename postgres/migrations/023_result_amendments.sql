@@ -0,0 +1,44 @@
+-- Migration 023: Result amendments
+-- Purpose: Results are re-fetched for the last few days after they settle and
+--          diffed against the stored runners. Disqualifications, amended
+--          placings and corrected SPs/BSPs are applied with their history here,
+--          so backtests can use results as settled or as first published.
+
+SET search_path TO racing, public;
+
+CREATE TABLE IF NOT EXISTS runner_amendments (
+  amendment_id BIGSERIAL PRIMARY KEY,
+  runner_id    BIGINT NOT NULL,
+  race_id      BIGINT NOT NULL,
+  race_date    DATE NOT NULL,
+  runner_key   TEXT NOT NULL,
+  field        TEXT NOT NULL CHECK (field IN ('pos_raw', 'dec', 'win_bsp', 'place_bsp')),
+  old_value    TEXT,
+  new_value    TEXT,
+  kind         TEXT NOT NULL CHECK (kind IN ('disqualification', 'placing', 'sp', 'bsp')),
+  source       TEXT NOT NULL,
+  run_id       BIGINT REFERENCES etl_runs(run_id),
+  amended_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_runner_amendments_race
+  ON runner_amendments(race_id, amended_at);
+CREATE INDEX IF NOT EXISTS idx_runner_amendments_date
+  ON runner_amendments(race_date);
+
+-- Races with at least one amendment (NULL = as first published)
+ALTER TABLE races ADD COLUMN IF NOT EXISTS amended_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_races_amended
+  ON races(race_date)
+  WHERE amended_at IS NOT NULL;
+
+GRANT SELECT, INSERT ON runner_amendments TO postgres;
+GRANT USAGE, SELECT ON SEQUENCE runner_amendments_amendment_id_seq TO postgres;
+
+COMMENT ON TABLE runner_amendments IS 'Changes to settled results; the earliest old_value per runner and field is the first-published value';
+COMMENT ON COLUMN runner_amendments.kind IS 'disqualification, placing (pos_raw), sp (dec), bsp (win_bsp/place_bsp)';
+COMMENT ON COLUMN runner_amendments.source IS 'Where the new value came from: sportinglife or betfair';
+COMMENT ON COLUMN races.amended_at IS 'Last time a result amendment was applied to one of the race''s runners';
+
+\echo '✅ Migration 023 complete: result amendments with history'
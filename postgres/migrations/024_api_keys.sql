@@ -0,0 +1,37 @@
+-- Migration 024: API keys, roles and scopes
+-- Purpose: Every /api/v1 route needs a caller with the right scope. Callers
+--          authenticate with an API key from this table or a JWT signed with
+--          JWT_SECRET. Keys belong to a role (reader, analyst, trader, admin)
+--          and carry scopes no wider than the role's. Only a SHA-256 of the
+--          key is stored; the key itself is shown once, when it is created.
+
+SET search_path TO racing, public;
+
+CREATE TABLE IF NOT EXISTS api_keys (
+  key_id       BIGSERIAL PRIMARY KEY,
+  name         TEXT NOT NULL,
+  key_prefix   TEXT NOT NULL,
+  key_hash     TEXT NOT NULL UNIQUE,
+  role         TEXT NOT NULL CHECK (role IN ('reader', 'analyst', 'trader', 'admin')),
+  scopes       TEXT[] NOT NULL,
+  created_by   TEXT,
+  created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+  expires_at   TIMESTAMPTZ,
+  last_used_at TIMESTAMPTZ,
+  revoked_at   TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_active
+  ON api_keys(created_at)
+  WHERE revoked_at IS NULL;
+
+GRANT SELECT, INSERT, UPDATE ON api_keys TO postgres;
+GRANT USAGE, SELECT ON SEQUENCE api_keys_key_id_seq TO postgres;
+
+COMMENT ON TABLE api_keys IS 'API keys for /api/v1 (see internal/auth); keys are stored hashed';
+COMMENT ON COLUMN api_keys.key_prefix IS 'First characters of the key, to recognise it in listings';
+COMMENT ON COLUMN api_keys.key_hash IS 'Hex SHA-256 of the full key';
+COMMENT ON COLUMN api_keys.scopes IS 'read, analysis, trade, admin - a subset of the role''s scopes';
+COMMENT ON COLUMN api_keys.last_used_at IS 'Updated when the key is looked up (at most about once a minute)';
+
+\echo '✅ Migration 024 complete: API keys'
@@ -0,0 +1,53 @@
+-- Migration 025: Rate-limit counters and API usage
+-- Purpose: /api/v1 requests are rate limited per client (API key, JWT
+--          subject or IP) and route group. Counters live in memory unless
+--          RATE_LIMIT_STORE=postgres, when every API instance shares them
+--          here. Usage (requests, rows returned, time taken) is totalled per
+--          hour, client and route for the usage endpoints.
+
+SET search_path TO racing, public;
+
+-- Fixed-window counters; rebuilt from zero after a crash, which is fine
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit_counters (
+  bucket       TEXT NOT NULL,
+  window_start TIMESTAMPTZ NOT NULL,
+  window_end   TIMESTAMPTZ NOT NULL,
+  hits         INT NOT NULL DEFAULT 0,
+  PRIMARY KEY (bucket, window_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_counters_end
+  ON rate_limit_counters(window_end);
+
+CREATE TABLE IF NOT EXISTS api_usage (
+  hour      TIMESTAMPTZ NOT NULL,
+  client    TEXT NOT NULL,
+  key_id    BIGINT REFERENCES api_keys(key_id),
+  route     TEXT NOT NULL,
+  method    TEXT NOT NULL,
+  requests  BIGINT NOT NULL DEFAULT 0,
+  errors    BIGINT NOT NULL DEFAULT 0,
+  throttled BIGINT NOT NULL DEFAULT 0,
+  rows      BIGINT NOT NULL DEFAULT 0,
+  total_ms  BIGINT NOT NULL DEFAULT 0,
+  max_ms    BIGINT NOT NULL DEFAULT 0,
+  PRIMARY KEY (hour, client, route, method)
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_usage_client
+  ON api_usage(client, hour);
+CREATE INDEX IF NOT EXISTS idx_api_usage_key
+  ON api_usage(key_id, hour)
+  WHERE key_id IS NOT NULL;
+
+GRANT SELECT, INSERT, UPDATE, DELETE ON rate_limit_counters TO postgres;
+GRANT SELECT, INSERT, UPDATE ON api_usage TO postgres;
+
+COMMENT ON TABLE rate_limit_counters IS 'Shared rate-limit windows (RATE_LIMIT_STORE=postgres); bucket is group|client';
+COMMENT ON TABLE api_usage IS 'Requests per hour, client and route template (flushed from the API every 30s)';
+COMMENT ON COLUMN api_usage.client IS 'key:<key_id>, jwt:<subject> or ip:<address>';
+COMMENT ON COLUMN api_usage.rows IS 'Rows returned: elements of the JSON response''s top-level array(s)';
+COMMENT ON COLUMN api_usage.total_ms IS 'Time serving the requests (mostly database time); avg = total_ms / requests';
+COMMENT ON COLUMN api_usage.throttled IS 'Requests answered 429 by the rate limiter';
+
+\echo '✅ Migration 025 complete: rate-limit counters and API usage'
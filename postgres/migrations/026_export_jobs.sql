@@ -0,0 +1,53 @@
+-- Migration 026: Export jobs
+-- Purpose: Races and runners can be exported as CSV, NDJSON or Parquet.
+--          Short date ranges stream straight from /api/v1/export/*; larger
+--          ones are queued here and run by the API's export workers, which
+--          write the file to EXPORT_DIR for download until it expires.
+
+SET search_path TO racing, public;
+
+CREATE TABLE IF NOT EXISTS export_jobs (
+  job_id       BIGSERIAL PRIMARY KEY,
+  client       TEXT NOT NULL,
+  key_id       BIGINT REFERENCES api_keys(key_id),
+  dataset      TEXT NOT NULL,
+  format       TEXT NOT NULL,
+  columns      TEXT[] NOT NULL,
+  filters      JSONB NOT NULL DEFAULT '{}',
+  status       TEXT NOT NULL DEFAULT 'queued',
+  rows         BIGINT NOT NULL DEFAULT 0,
+  bytes        BIGINT NOT NULL DEFAULT 0,
+  file_path    TEXT,
+  error        TEXT,
+  created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+  started_at   TIMESTAMPTZ,
+  completed_at TIMESTAMPTZ,
+  expires_at   TIMESTAMPTZ,
+  CONSTRAINT export_jobs_dataset_chk CHECK (dataset IN ('races', 'runners')),
+  CONSTRAINT export_jobs_format_chk CHECK (format IN ('csv', 'ndjson', 'parquet')),
+  CONSTRAINT export_jobs_status_chk CHECK (status IN ('queued', 'running', 'completed', 'failed', 'expired'))
+);
+
+-- Workers claim the oldest queued job
+CREATE INDEX IF NOT EXISTS idx_export_jobs_queued
+  ON export_jobs(job_id)
+  WHERE status = 'queued';
+
+CREATE INDEX IF NOT EXISTS idx_export_jobs_client
+  ON export_jobs(client, job_id DESC);
+
+-- Completed files past their expiry are deleted
+CREATE INDEX IF NOT EXISTS idx_export_jobs_expires
+  ON export_jobs(expires_at)
+  WHERE status = 'completed';
+
+GRANT SELECT, INSERT, UPDATE ON export_jobs TO postgres;
+GRANT USAGE, SELECT ON SEQUENCE export_jobs_job_id_seq TO postgres;
+
+COMMENT ON TABLE export_jobs IS 'Background exports of races/runners (POST /api/v1/export/jobs)';
+COMMENT ON COLUMN export_jobs.client IS 'Requesting client: key:<key_id>, jwt:<subject> or ip:<address>';
+COMMENT ON COLUMN export_jobs.filters IS 'RaceFilters the export was requested with';
+COMMENT ON COLUMN export_jobs.status IS 'queued → running → completed (or failed); completed → expired once the file is deleted';
+COMMENT ON COLUMN export_jobs.file_path IS 'Artifact on the API host that ran the job (EXPORT_DIR)';
+
+\echo '✅ Migration 026 complete: export jobs'
@@ -0,0 +1,71 @@
+-- Migration 027: Per-course timezones
+-- Purpose: 016 gave every course its jurisdiction's timezone, so West Coast and
+--          Central US tracks and Perth/Brisbane/Adelaide/Darwin/Hobart courses
+--          got New York or Sydney time and a wrong off_time_local. Seed the
+--          per-course zones and re-derive those courses' local off times.
+--          racing.courses.timezone is the only list: scraper.CourseTimezone reads
+--          it through the course registry (matching.LoadCourseRegistry), and
+--          /api/v1/admin/courses/{id} changes it.
+
+SET search_path TO racing, public;
+
+BEGIN;
+
+-- Course names keyed as scraper.NormalizeCourseName does: lower case, no AW
+-- suffix, no spaces, hyphens, apostrophes or brackets
+CREATE TEMP TABLE course_tz_overrides (region text, course_key text, timezone text) ON COMMIT DROP;
+
+INSERT INTO course_tz_overrides (region, course_key, timezone) VALUES
+    -- USA - default is Eastern
+    ('USA', 'santaanita',          'America/Los_Angeles'),
+    ('USA', 'santaanitapark',      'America/Los_Angeles'),
+    ('USA', 'delmar',              'America/Los_Angeles'),
+    ('USA', 'losalamitos',         'America/Los_Angeles'),
+    ('USA', 'goldengatefields',    'America/Los_Angeles'),
+    ('USA', 'emeralddowns',        'America/Los_Angeles'),
+    ('USA', 'turfparadise',        'America/Phoenix'),
+    ('USA', 'oaklawnpark',         'America/Chicago'),
+    ('USA', 'fairgrounds',         'America/Chicago'),
+    ('USA', 'lonestarpark',        'America/Chicago'),
+    ('USA', 'remingtonpark',       'America/Chicago'),
+    ('USA', 'canterburypark',      'America/Chicago'),
+    ('USA', 'arlington',           'America/Chicago'),
+    ('USA', 'louisianadowns',      'America/Chicago'),
+    ('USA', 'deltadownsracetrack', 'America/Chicago'),
+    -- Australia - default is Sydney (Melbourne shares its offset)
+    ('AUS', 'ascot',         'Australia/Perth'),
+    ('AUS', 'belmontpark',   'Australia/Perth'),
+    ('AUS', 'eaglefarm',     'Australia/Brisbane'),
+    ('AUS', 'doomben',       'Australia/Brisbane'),
+    ('AUS', 'goldcoast',     'Australia/Brisbane'),
+    ('AUS', 'sunshinecoast', 'Australia/Brisbane'),
+    ('AUS', 'morphettville', 'Australia/Adelaide'),
+    ('AUS', 'darwin',        'Australia/Darwin'),
+    ('AUS', 'hobart',        'Australia/Hobart'),
+    ('AUS', 'launceston',    'Australia/Hobart');
+
+CREATE TEMP TABLE course_tz_changed ON COMMIT DROP AS
+SELECT c.course_id, o.timezone
+FROM courses c
+JOIN course_tz_overrides o
+  ON o.region = c.region
+ AND o.course_key = regexp_replace(
+       regexp_replace(lower(trim(c.course_name)), '\s+\(?aw\)?$', ''),
+       '[\s''()-]', '', 'g')
+WHERE c.timezone IS DISTINCT FROM o.timezone;
+
+UPDATE courses c
+SET timezone = t.timezone
+FROM course_tz_changed t
+WHERE t.course_id = c.course_id;
+
+-- Same derivation as 016 and scraper.LocalOffTime
+UPDATE races r
+SET off_time_local = ((r.race_date + r.off_time) AT TIME ZONE 'Europe/London' AT TIME ZONE t.timezone)::time
+FROM course_tz_changed t
+WHERE t.course_id = r.course_id
+  AND r.off_time IS NOT NULL;
+
+COMMIT;
+
+\echo '✅ Migration 027 complete: per-course timezones seeded and local off times re-derived'
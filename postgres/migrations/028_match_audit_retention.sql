@@ -0,0 +1,11 @@
+-- Migration 028: Match audit retention
+-- Purpose: The live matcher audits every race and runner on each refresh, so
+--          racing.match_audit grows without bound. The pipeline's refresh stage
+--          now deletes rows older than MATCH_AUDIT_RETENTION_DAYS (default 90);
+--          index run_at so that delete doesn't scan the table.
+
+SET search_path TO racing, public;
+
+CREATE INDEX IF NOT EXISTS idx_match_audit_run_at ON match_audit(run_at);
+
+\echo '✅ Migration 028 complete: match_audit run_at index for retention purges'